@@ -0,0 +1,136 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// ConflictPolicy is the policy to handle the conflict between the local data of destination cmdb and the incoming
+// sync data, conflict occurs when the local data is modified in destination cmdb after the incoming data is generated
+type ConflictPolicy string
+
+const (
+	// SourceWins overwrites the local data with the incoming data, it is the default policy
+	SourceWins ConflictPolicy = "source_wins"
+	// DestWins keeps the local data and discards the incoming data
+	DestWins ConflictPolicy = "dest_wins"
+	// RejectAndReport keeps the local data and reports the conflict as pending for manual handling
+	RejectAndReport ConflictPolicy = "reject"
+)
+
+// Validate conflict policy
+func (p ConflictPolicy) Validate() error {
+	switch p {
+	case SourceWins, DestWins, RejectAndReport:
+		return nil
+	default:
+		return fmt.Errorf("invalid conflict policy: %s", p)
+	}
+}
+
+// ConflictStatus is the sync conflict status
+type ConflictStatus string
+
+const (
+	// ConflictResolved means the conflict is resolved by the conflict policy
+	ConflictResolved ConflictStatus = "resolved"
+	// ConflictPending means the incoming data is rejected and the conflict needs to be handled manually
+	ConflictPending ConflictStatus = "pending"
+)
+
+// ConflictOperation is the sync operation that causes the conflict
+type ConflictOperation string
+
+const (
+	// ConflictUpdate means the incoming data updates the locally modified data
+	ConflictUpdate ConflictOperation = "update"
+	// ConflictDelete means the incoming data deletes the locally modified data
+	ConflictDelete ConflictOperation = "delete"
+)
+
+// SyncConflictTable is the table that stores the sync conflicts in destination cmdb
+const SyncConflictTable = "cc_DestSyncConflict"
+
+// SyncConflict is the conflict between the local data of destination cmdb and the incoming sync data
+type SyncConflict struct {
+	ResType   ResType           `json:"resource_type" bson:"resource_type"`
+	SubRes    string            `json:"sub_resource" bson:"sub_resource"`
+	ID        int64             `json:"id" bson:"id"`
+	SrcEnv    string            `json:"src_env" bson:"src_env"`
+	Operation ConflictOperation `json:"operation" bson:"operation"`
+	Policy    ConflictPolicy    `json:"policy" bson:"policy"`
+	Status    ConflictStatus    `json:"status" bson:"status"`
+	// Diffs are the field level differences between the local data and the incoming data
+	Diffs []ConflictFieldDiff `json:"diffs" bson:"diffs"`
+	// LocalLastTime is the last modified time of the local data
+	LocalLastTime *time.Time `json:"local_last_time" bson:"local_last_time"`
+	// SrcLastTime is the last modified time of the incoming data
+	SrcLastTime *time.Time `json:"src_last_time" bson:"src_last_time"`
+	// LastTime is the last time that the conflict is detected
+	LastTime time.Time `json:"last_time" bson:"last_time"`
+}
+
+// ConflictFieldDiff is the difference of one field between the local data and the incoming data
+type ConflictFieldDiff struct {
+	Field         string `json:"field" bson:"field"`
+	LocalValue    any    `json:"local_value" bson:"local_value"`
+	IncomingValue any    `json:"incoming_value" bson:"incoming_value"`
+}
+
+// ListSyncConflictOpt is the list sync conflict option
+type ListSyncConflictOpt struct {
+	ResType ResType           `json:"resource_type"`
+	SubRes  string            `json:"sub_resource"`
+	Status  ConflictStatus    `json:"status"`
+	Page    metadata.BasePage `json:"page"`
+}
+
+// Validate list sync conflict option
+func (o *ListSyncConflictOpt) Validate() ccErr.RawErrorInfo {
+	if o.ResType != "" {
+		if rawErr := o.ResType.Validate(o.SubRes); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+	}
+
+	switch o.Status {
+	case "", ConflictResolved, ConflictPending:
+	default:
+		return ccErr.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsIsInvalid,
+			Args:    []interface{}{"status"},
+		}
+	}
+
+	if rawErr := o.Page.ValidateWithEnableCount(false); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ListSyncConflictRes is the list sync conflict result
+type ListSyncConflictRes struct {
+	Count uint64         `json:"count"`
+	Info  []SyncConflict `json:"info"`
+}
@@ -48,12 +48,26 @@ const (
 	Process ResType = "process"
 	// ProcessRelation is the process relation synchronize resource type
 	ProcessRelation ResType = "process_relation"
+	// Classification is the object classification synchronize resource type
+	Classification ResType = "classification"
+	// AsstKind is the association kind synchronize resource type
+	AsstKind ResType = "association_kind"
+	// Object is the object synchronize resource type
+	Object ResType = "object"
+	// AttributeGroup is the object attribute group synchronize resource type
+	AttributeGroup ResType = "attribute_group"
+	// ObjectAttribute is the object attribute synchronize resource type
+	ObjectAttribute ResType = "object_attribute"
+	// ObjectUnique is the object unique rule synchronize resource type
+	ObjectUnique ResType = "object_unique"
 )
 
 var (
-	// allResType is all synchronize resource type in the order of dependency
-	allResType = []ResType{Biz, ObjectInstance, Set, Module, Host, HostRelation, InstAsst, ServiceInstance, Process,
-		ProcessRelation, QuotedInstance}
+	// allResType is all synchronize resource type in the order of dependency, model metadata must be synced before
+	// the instances so that instances are never synced before their models
+	allResType = []ResType{Classification, AsstKind, Object, AttributeGroup, ObjectAttribute, ObjectUnique, Biz,
+		ObjectInstance, Set, Module, Host, HostRelation, InstAsst, ServiceInstance, Process, ProcessRelation,
+		QuotedInstance}
 	allResTypeMap = make(map[ResType]struct{})
 )
 
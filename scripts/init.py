@@ -346,6 +346,12 @@ es:
 adminServer:
   #同步IAM动态模型的周期,单位为分钟，最小为1分钟,默认为5分钟
   syncIAMPeriodMinutes: 5
+  # 审计日志哈希链校验配置
+  auditChain:
+    #审计日志哈希链的校验周期,单位为分钟，最小为60分钟,默认为1440分钟
+    verifyPeriodMinutes: 1440
+    #是否允许哈希链中的审计日志缺失，开启了审计日志保留策略时需要设置为true
+    allowGaps: false
 
 # operation_server专属配置
 operationServer:
@@ -409,6 +415,26 @@ datacollection:
     rateLimiter:
       qps: 40
       burst: 100
+  # 内置的容器采集器，监听k8s集群中的节点、命名空间、工作负载和pod，并同步到cmdb的容器拓扑中，仅在主节点上运行
+  kubeCollector:
+    # 是否开启容器采集，默认为false
+    enabled: false
+    # 全量同步的周期，单位为分钟，默认值为10
+    resyncIntervalMinutes: 10
+    # 收到k8s事件后，等待多长时间再进行同步，用于合并短时间内的多个事件，单位为秒，默认值为5
+    debounceSeconds: 5
+    # 同步时使用的操作者，默认为cc_collector
+    user: cc_collector
+    # 集群所属的开发商账号，默认为0
+    supplierAccount: "0"
+    # 需要采集的集群列表，集群需要先在cmdb中注册
+    clusters:
+      # bizID为集群所属的业务ID，clusterUID为集群在cmdb中的uid，kubeconfig为访问集群的kubeconfig文件路径，
+      # cloudID为集群节点对应主机的管控区域ID，用于根据节点IP查找主机
+      #- bizID: 2
+      #  clusterUID: BCS-K8S-00001
+      #  kubeconfig: /data/bkce/cmdb/kubeconfig/BCS-K8S-00001
+      #  cloudID: 0
 
 # 监控配置， monitor配置项必须存在
 monitor:
@@ -107,6 +107,10 @@ var ccIamResTypeMap = map[meta.ResourceType]TypeID{
 	meta.KubePodWorkload:          TypeID(""),
 	meta.KubePod:                  TypeID(""),
 	meta.KubeContainer:            TypeID(""),
+	meta.KubeService:              TypeID(""),
+	meta.KubeIngress:              TypeID(""),
+	meta.KubeConfigMap:            TypeID(""),
+	meta.KubeSecret:               TypeID(""),
 	meta.FieldTemplate:            FieldGroupingTemplate,
 	meta.FulltextSearch:           TypeID(""),
 	meta.IDRuleIncrID:             TypeID(""),
@@ -434,6 +438,9 @@ var resourceActionMap = map[meta.ResourceType]map[meta.Action]ActionID{
 		meta.WatchKubeWorkload:     WatchKubeWorkloadEvent,
 		meta.WatchKubePod:          WatchKubePodEvent,
 		meta.WatchProject:          WatchProjectEvent,
+		meta.WatchModel:            WatchModelEvent,
+		meta.WatchTemplate:         WatchTemplateEvent,
+		meta.WatchHostApplyRule:    WatchHostApplyRuleEvent,
 	},
 	meta.UserCustom: {
 		meta.Find:   Skip,
@@ -585,6 +592,30 @@ var resourceActionMap = map[meta.ResourceType]map[meta.Action]ActionID{
 	meta.KubeContainer: {
 		meta.Find: ViewBusinessResource,
 	},
+	meta.KubeService: {
+		meta.Find:   ViewBusinessResource,
+		meta.Update: EditContainerWorkload,
+		meta.Delete: DeleteContainerWorkload,
+		meta.Create: CreateContainerWorkload,
+	},
+	meta.KubeIngress: {
+		meta.Find:   ViewBusinessResource,
+		meta.Update: EditContainerWorkload,
+		meta.Delete: DeleteContainerWorkload,
+		meta.Create: CreateContainerWorkload,
+	},
+	meta.KubeConfigMap: {
+		meta.Find:   ViewBusinessResource,
+		meta.Update: EditContainerWorkload,
+		meta.Delete: DeleteContainerWorkload,
+		meta.Create: CreateContainerWorkload,
+	},
+	meta.KubeSecret: {
+		meta.Find:   ViewBusinessResource,
+		meta.Update: EditContainerWorkload,
+		meta.Delete: DeleteContainerWorkload,
+		meta.Create: CreateContainerWorkload,
+	},
 	meta.Project: {
 		meta.Find:   ViewProject,
 		meta.Update: EditProject,
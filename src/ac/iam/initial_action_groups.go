@@ -431,6 +431,15 @@ func genResourceManageActionGroups() []ActionGroup {
 						{
 							ID: WatchProjectEvent,
 						},
+						{
+							ID: WatchModelEvent,
+						},
+						{
+							ID: WatchTemplateEvent,
+						},
+						{
+							ID: WatchHostApplyRuleEvent,
+						},
 					},
 				},
 				{
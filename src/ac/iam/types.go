@@ -523,6 +523,14 @@ const (
 	WatchPlatEvent ActionID = "watch_plat_event"
 	// WatchProjectEvent watch project event action id
 	WatchProjectEvent ActionID = "watch_project_event"
+	// WatchModelEvent watch model metadata event action id, including model definitions, attributes, attribute
+	// groups, unique rules, model associations, association kinds and classifications
+	WatchModelEvent ActionID = "watch_model_event"
+	// WatchTemplateEvent watch template event action id, including service templates, process templates and set
+	// templates
+	WatchTemplateEvent ActionID = "watch_template_event"
+	// WatchHostApplyRuleEvent watch host apply rule event action id
+	WatchHostApplyRuleEvent ActionID = "watch_host_apply_rule_event"
 
 	// watch kube related event actions
 
@@ -198,6 +198,12 @@ const (
 	WatchPlat Action = "plat"
 	// WatchProject watch project event cc action
 	WatchProject Action = "project"
+	// WatchModel watch model metadata event cc action, all model metadata resources use this action
+	WatchModel Action = "model"
+	// WatchTemplate watch template event cc action, including service templates, process templates and set templates
+	WatchTemplate Action = "template"
+	// WatchHostApplyRule watch host apply rule event cc action
+	WatchHostApplyRule Action = "host_apply_rule"
 
 	// kube related event watch cc actions
 
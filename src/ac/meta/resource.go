@@ -94,6 +94,18 @@ const (
 	// KubeContainer auth resource type in CMDB
 	KubeContainer ResourceType = "kube_container"
 
+	// KubeService auth resource type in CMDB
+	KubeService ResourceType = "kube_service"
+
+	// KubeIngress auth resource type in CMDB
+	KubeIngress ResourceType = "kube_ingress"
+
+	// KubeConfigMap auth resource type in CMDB
+	KubeConfigMap ResourceType = "kube_configmap"
+
+	// KubeSecret auth resource type in CMDB
+	KubeSecret ResourceType = "kube_secret"
+
 	// below are specific workload auth resource types in CMDB, reserved for later use
 
 	// KubeDeployment auth resource type in CMDB
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 
 	"configcenter/src/ac/meta"
@@ -24,6 +25,8 @@ type RequestContext struct {
 	Method string
 	// request's url path
 	URI string
+	// request's url query parameters
+	Query url.Values
 	// elements parsed from url, started with api field
 	// 0: api field
 	// 1: version field
@@ -195,6 +195,25 @@ var cloudResourceTaskConfigs = []AuthConfig{
 			return []int64{taskID}, nil
 		},
 	},
+	{
+		Name:           "previewCloudResourceTaskDiffRegex",
+		Description:    "预览云资源同步任务的同步差异",
+		Regex:          regexp.MustCompile(`^/api/v3/find/cloud/sync/task/([0-9]+)/diff$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.CloudResourceTask,
+		ResourceAction: meta.Find,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) (int64s []int64, e error) {
+			subMatch := re.FindStringSubmatch(request.URI)
+			if len(subMatch) != 2 {
+				return nil, errors.New("unexpected error: this code shouldn't be reached")
+			}
+			id, err := strconv.ParseInt(subMatch[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse task id to int64 failed, err: %s", err)
+			}
+			return []int64{id}, nil
+		},
+	},
 	{
 		Name:           "listCloudResourceRegionPattern",
 		Description:    "查询云资源同步地域信息",
@@ -17,6 +17,7 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -24,6 +25,7 @@ import (
 	"configcenter/src/ac/meta"
 	"configcenter/src/common"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
 	"configcenter/src/common/watch"
 
 	"github.com/tidwall/gjson"
@@ -37,12 +39,14 @@ func (ps *parseStream) eventRelated() *parseStream {
 	ps.watch().
 		syncHostIdentifier().
 		pushHostIdentifier().
-		findHostIdentifierPushResult()
+		findHostIdentifierPushResult().
+		eventSubscription()
 	return ps
 }
 
 var (
-	watchResourceRegexp = regexp.MustCompile(`^/api/v3/event/watch/resource/\S+/?$`)
+	watchResourceRegexp       = regexp.MustCompile(`^/api/v3/event/watch/resource/\S+/?$`)
+	watchStreamResourceRegexp = regexp.MustCompile(`^/api/v3/event/watch/stream/resource/\S+/?$`)
 )
 
 func (ps *parseStream) watch() *parseStream {
@@ -52,70 +56,187 @@ func (ps *parseStream) watch() *parseStream {
 
 	// watch resource.
 	if ps.hitRegexp(watchResourceRegexp, http.MethodPost) {
-		resource := ps.RequestCtx.Elements[5]
-		if len(resource) == 0 {
-			ps.err = fmt.Errorf("watch event resource, but got empty resource: %s", ps.RequestCtx.Elements[5])
-			return ps
-		}
+		return ps.parseWatchResource(ps.RequestCtx.Elements[5], ps.getWatchFilterFromBody)
+	}
 
-		if resource == string(watch.HostIdentifier) {
-			// redirect host identity resource to host resource in iam.
-			resource = string(watch.Host)
-		}
+	// watch resource with server-sent events stream, the watch options is in the body like watch resource api.
+	if ps.hitRegexp(watchStreamResourceRegexp, http.MethodPost) {
+		return ps.parseWatchResource(ps.RequestCtx.Elements[6], ps.getWatchFilterFromBody)
+	}
 
-		if resource == string(watch.BizSetRelation) {
-			// redirect biz set relation resource to biz set resource in iam.
-			resource = string(watch.BizSet)
-		}
+	// watch resource with websocket stream, the watch options is in the query since websocket request has no body.
+	if ps.hitRegexp(watchStreamResourceRegexp, http.MethodGet) {
+		return ps.parseWatchResource(ps.RequestCtx.Elements[6], ps.getWatchFilterFromQuery)
+	}
 
-		authResource := meta.ResourceAttribute{
-			Basic: meta.Basic{
-				Type:   meta.EventWatch,
-				Action: meta.Action(resource),
-			},
-		}
+	return ps
+}
 
-		switch watch.CursorType(resource) {
-		case watch.ObjectBase, watch.MainlineInstance, watch.InstAsst:
-			body, err := ps.RequestCtx.getRequestBody()
-			if err != nil {
-				ps.err = err
-				return ps
-			}
+// getWatchFilterFromBody get the bk_filter of the watch options from request body
+func (ps *parseStream) getWatchFilterFromBody() (gjson.Result, error) {
+	return ps.RequestCtx.getValueFromBody("bk_filter")
+}
 
-			// use sub resource(corresponding to the bk_obj_id of the object) for authorization if it is set
-			// if sub resource is not set, verify authorization of the resource(which means all sub resources)
-			subResource := gjson.GetBytes(body, "bk_filter."+common.BKSubResourceField)
-			if subResource.Exists() {
-				model, err := ps.getOneModel(mapstr.MapStr{common.BKObjIDField: subResource.String()})
-				if err != nil {
-					ps.err = err
-					return ps
-				}
-				authResource.InstanceID = model.ID
-			}
-		case watch.KubeWorkload:
-			body, err := ps.RequestCtx.getRequestBody()
+// getWatchFilterFromQuery get the bk_filter of the watch options from request query, which is a json string
+func (ps *parseStream) getWatchFilterFromQuery() (gjson.Result, error) {
+	return gjson.Parse(ps.RequestCtx.Query.Get("bk_filter")), nil
+}
+
+// parseWatchResource parse the authorization resource of the watch resource request
+func (ps *parseStream) parseWatchResource(resource string, getFilter func() (gjson.Result, error)) *parseStream {
+	if len(resource) == 0 {
+		ps.err = fmt.Errorf("watch event resource, but got empty resource: %s", ps.RequestCtx.URI)
+		return ps
+	}
+
+	if resource == string(watch.HostIdentifier) {
+		// redirect host identity resource to host resource in iam.
+		resource = string(watch.Host)
+	}
+
+	if resource == string(watch.BizSetRelation) {
+		// redirect biz set relation resource to biz set resource in iam.
+		resource = string(watch.BizSet)
+	}
+
+	if resource == string(watch.DynamicGroupMember) {
+		// dynamic group member resource is authorized by the resource of the group's target object in iam.
+		return ps.parseWatchDynamicGroupMember(getFilter)
+	}
+
+	if watch.CursorType(resource).IsModelMetadata() {
+		// redirect model metadata resources to model event watch action in iam.
+		resource = string(meta.WatchModel)
+	}
+
+	if resource == string(watch.ServiceInstance) {
+		// redirect service instance resource to process resource in iam.
+		resource = string(watch.Process)
+	}
+
+	if watch.CursorType(resource).IsTemplate() {
+		// redirect template resources to template event watch action in iam.
+		resource = string(meta.WatchTemplate)
+	}
+
+	if watch.CursorType(resource).IsKubeNsResource() {
+		// redirect kube namespace resources to kube namespace event watch action in iam.
+		resource = string(meta.WatchKubeNamespace)
+	}
+
+	authResource := meta.ResourceAttribute{
+		Basic: meta.Basic{
+			Type:   meta.EventWatch,
+			Action: meta.Action(resource),
+		},
+	}
+
+	switch watch.CursorType(resource) {
+	case watch.ObjectBase, watch.MainlineInstance, watch.InstAsst:
+		filter, err := getFilter()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		// use sub resource(corresponding to the bk_obj_id of the object) for authorization if it is set
+		// if sub resource is not set, verify authorization of the resource(which means all sub resources)
+		subResource := filter.Get(common.BKSubResourceField)
+		if subResource.Exists() {
+			model, err := ps.getOneModel(mapstr.MapStr{common.BKObjIDField: subResource.String()})
 			if err != nil {
 				ps.err = err
 				return ps
 			}
+			authResource.InstanceID = model.ID
+		}
+	case watch.KubeWorkload:
+		filter, err := getFilter()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
 
-			// use sub resource(corresponding to the kind of the workload) for authorization if it is set
-			// if sub resource is not set, verify authorization of the resource(which means all sub resources)
-			subResource := gjson.GetBytes(body, "bk_filter."+common.BKSubResourceField)
-			if subResource.Exists() {
-				authResource.InstanceIDEx = subResource.String()
-			}
+		// use sub resource(corresponding to the kind of the workload) for authorization if it is set
+		// if sub resource is not set, verify authorization of the resource(which means all sub resources)
+		subResource := filter.Get(common.BKSubResourceField)
+		if subResource.Exists() {
+			authResource.InstanceIDEx = subResource.String()
 		}
+	}
+
+	ps.Attribute.Resources = append(ps.Attribute.Resources, authResource)
+	return ps
+}
 
-		ps.Attribute.Resources = append(ps.Attribute.Resources, authResource)
+// parseWatchDynamicGroupMember parse the authorization resources of the dynamic group member watch request. members
+// of a dynamic group are the group's target object instances, so they are authorized by the watch action of the
+// target object. if the sub resource(corresponding to the dynamic group id) is not set, members of all the groups are
+// watched, which may be hosts or sets, so the watch actions of both host and set are required.
+func (ps *parseStream) parseWatchDynamicGroupMember(getFilter func() (gjson.Result, error)) *parseStream {
+	filter, err := getFilter()
+	if err != nil {
+		ps.err = err
 		return ps
 	}
 
+	objIDs := []string{common.BKInnerObjIDHost, common.BKInnerObjIDSet}
+	subResource := filter.Get(common.BKSubResourceField)
+	if subResource.Exists() {
+		objID, err := ps.getDynamicGroupObjID(subResource.String())
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		objIDs = []string{objID}
+	}
+
+	for _, objID := range objIDs {
+		var action meta.Action
+		switch objID {
+		case common.BKInnerObjIDHost:
+			action = meta.Action(watch.Host)
+		case common.BKInnerObjIDSet:
+			action = meta.Action(watch.Set)
+		default:
+			ps.err = fmt.Errorf("dynamic group %s has unsupported object %s", subResource.String(), objID)
+			return ps
+		}
+
+		ps.Attribute.Resources = append(ps.Attribute.Resources, meta.ResourceAttribute{
+			Basic: meta.Basic{
+				Type:   meta.EventWatch,
+				Action: action,
+			},
+		})
+	}
 	return ps
 }
 
+// getDynamicGroupObjID get the target object id of the dynamic group
+func (ps *parseStream) getDynamicGroupObjID(id string) (string, error) {
+	opt := &metadata.QueryCondition{
+		Condition:      mapstr.MapStr{common.BKFieldID: id},
+		Fields:         []string{common.BKObjIDField},
+		Page:           metadata.BasePage{Limit: 1},
+		DisableCounter: true,
+	}
+	resp, err := ps.engine.CoreAPI.CoreService().Host().SearchDynamicGroup(context.Background(),
+		ps.RequestCtx.Header, opt)
+	if err != nil {
+		return "", err
+	}
+
+	if err = resp.CCError(); err != nil {
+		return "", err
+	}
+
+	if len(resp.Data.Info) == 0 {
+		return "", fmt.Errorf("dynamic group %s not found", id)
+	}
+	return resp.Data.Info[0].ObjID, nil
+}
+
 const (
 	syncHostIdentifierPattern           = "/api/v3/event/sync/host_identifier"
 	pushHostIdentifierPattern           = "/api/v3/event/push/host_identifier"
@@ -178,3 +299,44 @@ func (ps *parseStream) findHostIdentifierPushResult() *parseStream {
 
 	return ps
 }
+
+const (
+	createEventSubscriptionPattern    = "/api/v3/event/create/event_subscription"
+	findEventSubscriptionPattern      = "/api/v3/event/findmany/event_subscription"
+	findSubscriptionDeliveryPattern   = "/api/v3/event/findmany/event_subscription/delivery"
+	findSubscriptionDeadLetterPattern = "/api/v3/event/findmany/event_subscription/dead_letter"
+)
+
+var (
+	updateEventSubscriptionRegexp = regexp.MustCompile(
+		`^/api/v3/event/(update|pause|resume)/event_subscription/[0-9]+/?$`)
+	deleteEventSubscriptionRegexp = regexp.MustCompile(`^/api/v3/event/delete/event_subscription/[0-9]+/?$`)
+)
+
+// eventSubscription the event subscription apis are authorized in event server, since the resource of the
+// subscription to be operated is stored in event server. event server only allows the creator to operate and list
+// the event subscription, and authorizes the event watch permission of the subscribed resource.
+func (ps *parseStream) eventSubscription() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(createEventSubscriptionPattern, http.MethodPost) ||
+		ps.hitPattern(findEventSubscriptionPattern, http.MethodPost) ||
+		ps.hitPattern(findSubscriptionDeliveryPattern, http.MethodPost) ||
+		ps.hitPattern(findSubscriptionDeadLetterPattern, http.MethodPost) ||
+		ps.hitRegexp(updateEventSubscriptionRegexp, http.MethodPut) ||
+		ps.hitRegexp(deleteEventSubscriptionRegexp, http.MethodDelete) {
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
@@ -0,0 +1,305 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/apimachinery/coreservice/host"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/watch"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newWatchParseStream(method, uri, body string, query url.Values) *parseStream {
+	return &parseStream{
+		RequestCtx: &RequestContext{
+			Method:   method,
+			URI:      uri,
+			Query:    query,
+			Elements: strings.Split(strings.Trim(uri, "/"), "/"),
+			getBody: func() ([]byte, error) {
+				return []byte(body), nil
+			},
+		},
+	}
+}
+
+func TestParseWatchResource(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		uri    string
+		body   string
+		query  url.Values
+		expect meta.ResourceAttribute
+	}{
+		{
+			name:   "host",
+			method: http.MethodPost,
+			uri:    "/api/v3/event/watch/resource/host",
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch, Action: meta.Action(watch.Host)}},
+		},
+		{
+			name:   "host identifier uses host action",
+			method: http.MethodPost,
+			uri:    "/api/v3/event/watch/resource/host_identifier",
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch, Action: meta.Action(watch.Host)}},
+		},
+		{
+			name:   "biz set relation uses biz set action",
+			method: http.MethodPost,
+			uri:    "/api/v3/event/watch/resource/biz_set_relation",
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch,
+				Action: meta.Action(watch.BizSet)}},
+		},
+		{
+			name:   "model metadata uses model action",
+			method: http.MethodPost,
+			uri:    "/api/v3/event/watch/resource/" + string(watch.ObjectAttribute),
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch, Action: meta.WatchModel}},
+		},
+		{
+			name:   "service instance uses process action",
+			method: http.MethodPost,
+			uri:    "/api/v3/event/watch/resource/" + string(watch.ServiceInstance),
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch,
+				Action: meta.Action(watch.Process)}},
+		},
+		{
+			name:   "template uses template action",
+			method: http.MethodPost,
+			uri:    "/api/v3/event/watch/resource/" + string(watch.SetTemplate),
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch, Action: meta.WatchTemplate}},
+		},
+		{
+			name:   "kube namespace resource uses kube namespace action",
+			method: http.MethodPost,
+			uri:    "/api/v3/event/watch/resource/" + string(watch.KubeService),
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch, Action: meta.WatchKubeNamespace}},
+		},
+		{
+			name:   "object instance without sub resource",
+			method: http.MethodPost,
+			uri:    "/api/v3/event/watch/resource/object_instance",
+			body:   `{"bk_filter":{}}`,
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch,
+				Action: meta.Action(watch.ObjectBase)}},
+		},
+		{
+			name:   "kube workload with sub resource in body",
+			method: http.MethodPost,
+			uri:    "/api/v3/event/watch/resource/kube_workload",
+			body:   `{"bk_filter":{"bk_sub_resource":"deployment"}}`,
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch,
+				Action:       meta.Action(watch.KubeWorkload),
+				InstanceIDEx: "deployment"}},
+		},
+		{
+			name:   "server-sent events stream",
+			method: http.MethodPost,
+			uri:    "/api/v3/event/watch/stream/resource/kube_workload",
+			body:   `{"bk_filter":{"bk_sub_resource":"deployment"}}`,
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch,
+				Action:       meta.Action(watch.KubeWorkload),
+				InstanceIDEx: "deployment"}},
+		},
+		{
+			name:   "websocket stream with sub resource in query",
+			method: http.MethodGet,
+			uri:    "/api/v3/event/watch/stream/resource/kube_workload",
+			query:  url.Values{"bk_filter": []string{`{"bk_sub_resource":"pod"}`}},
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch,
+				Action:       meta.Action(watch.KubeWorkload),
+				InstanceIDEx: "pod"}},
+		},
+		{
+			name:   "websocket stream without filter",
+			method: http.MethodGet,
+			uri:    "/api/v3/event/watch/stream/resource/host",
+			expect: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch, Action: meta.Action(watch.Host)}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ps := newWatchParseStream(tc.method, tc.uri, tc.body, tc.query).watch()
+			require.NoError(t, ps.err)
+			require.Equal(t, []meta.ResourceAttribute{tc.expect}, ps.Attribute.Resources)
+		})
+	}
+}
+
+func TestParseWatchResourceError(t *testing.T) {
+	ps := newWatchParseStream(http.MethodPost, "/api/v3/event/watch/resource/", "", nil)
+	ps.parseWatchResource("", ps.getWatchFilterFromBody)
+	require.Error(t, ps.err)
+	require.Empty(t, ps.Attribute.Resources)
+
+	ps = newWatchParseStream(http.MethodPost, "/api/v3/event/watch/resource/kube_workload", "", nil)
+	ps.RequestCtx.getBody = func() ([]byte, error) {
+		return nil, errors.New("read body failed")
+	}
+	ps.watch()
+	require.Error(t, ps.err)
+	require.Empty(t, ps.Attribute.Resources)
+
+	// not watch request
+	ps = newWatchParseStream(http.MethodDelete, "/api/v3/event/watch/resource/host", "", nil).watch()
+	require.NoError(t, ps.err)
+	require.Empty(t, ps.Attribute.Resources)
+}
+
+func TestParseWatchModelMetadata(t *testing.T) {
+	resources := []watch.CursorType{watch.Object, watch.ObjectAttribute, watch.ObjectAttributeGroup,
+		watch.ObjectUnique, watch.ModelAssociation, watch.AssociationKind, watch.ObjectClassification}
+
+	for _, resource := range resources {
+		t.Run(string(resource), func(t *testing.T) {
+			ps := newWatchParseStream(http.MethodPost, "/api/v3/event/watch/resource/"+string(resource),
+				`{"bk_filter":{"bk_sub_resource":"switch"}}`, nil).watch()
+			require.NoError(t, ps.err)
+			// model metadata events are authorized by the model watch action regardless of the sub resource
+			require.Equal(t, []meta.ResourceAttribute{{Basic: meta.Basic{Type: meta.EventWatch,
+				Action: meta.WatchModel}}}, ps.Attribute.Resources)
+		})
+	}
+}
+
+func TestParseWatchTemplate(t *testing.T) {
+	testCases := []struct {
+		resource watch.CursorType
+		action   meta.Action
+	}{
+		{resource: watch.ServiceInstance, action: meta.Action(watch.Process)},
+		{resource: watch.ServiceTemplate, action: meta.WatchTemplate},
+		{resource: watch.ProcessTemplate, action: meta.WatchTemplate},
+		{resource: watch.SetTemplate, action: meta.WatchTemplate},
+		{resource: watch.HostApplyRule, action: meta.WatchHostApplyRule},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.resource), func(t *testing.T) {
+			ps := newWatchParseStream(http.MethodPost, "/api/v3/event/watch/resource/"+string(tc.resource),
+				`{"bk_filter":{"bk_sub_resource":"2"}}`, nil).watch()
+			require.NoError(t, ps.err)
+			require.Equal(t, []meta.ResourceAttribute{{Basic: meta.Basic{Type: meta.EventWatch,
+				Action: tc.action}}}, ps.Attribute.Resources)
+		})
+	}
+}
+
+type fakeClientSet struct {
+	apimachinery.ClientSetInterface
+	groups map[string]string
+}
+
+func (c *fakeClientSet) CoreService() coreservice.CoreServiceClientInterface {
+	return &fakeCoreService{groups: c.groups}
+}
+
+type fakeCoreService struct {
+	coreservice.CoreServiceClientInterface
+	groups map[string]string
+}
+
+func (c *fakeCoreService) Host() host.HostClientInterface {
+	return &fakeHostClient{groups: c.groups}
+}
+
+// fakeHostClient returns the dynamic groups whose id to bk_obj_id mapping is specified by groups
+type fakeHostClient struct {
+	host.HostClientInterface
+	groups map[string]string
+}
+
+func (c *fakeHostClient) SearchDynamicGroup(_ context.Context, _ http.Header, opt *metadata.QueryCondition) (
+	*metadata.SearchDynamicGroupResult, error) {
+
+	resp := &metadata.SearchDynamicGroupResult{BaseResp: metadata.BaseResp{Result: true}}
+	if objID, exists := c.groups[opt.Condition["id"].(string)]; exists {
+		resp.Data.Info = []metadata.DynamicGroup{{ObjID: objID}}
+	}
+	return resp, nil
+}
+
+func TestParseWatchDynamicGroupMember(t *testing.T) {
+	hostRes := meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch, Action: meta.Action(watch.Host)}}
+	setRes := meta.ResourceAttribute{Basic: meta.Basic{Type: meta.EventWatch, Action: meta.Action(watch.Set)}}
+
+	testCases := []struct {
+		name      string
+		body      string
+		expect    []meta.ResourceAttribute
+		expectErr bool
+	}{
+		{
+			name:   "without sub resource needs both host and set action",
+			body:   `{"bk_filter":{}}`,
+			expect: []meta.ResourceAttribute{hostRes, setRes},
+		},
+		{
+			name:   "host dynamic group uses host action",
+			body:   `{"bk_filter":{"bk_sub_resource":"host_group"}}`,
+			expect: []meta.ResourceAttribute{hostRes},
+		},
+		{
+			name:   "set dynamic group uses set action",
+			body:   `{"bk_filter":{"bk_sub_resource":"set_group"}}`,
+			expect: []meta.ResourceAttribute{setRes},
+		},
+		{
+			name:      "dynamic group not found",
+			body:      `{"bk_filter":{"bk_sub_resource":"not_exist"}}`,
+			expectErr: true,
+		},
+		{
+			name:      "dynamic group with unsupported object",
+			body:      `{"bk_filter":{"bk_sub_resource":"module_group"}}`,
+			expectErr: true,
+		},
+	}
+
+	clientSet := &fakeClientSet{groups: map[string]string{"host_group": "host", "set_group": "set",
+		"module_group": "module"}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ps := newWatchParseStream(http.MethodPost, "/api/v3/event/watch/resource/"+
+				string(watch.DynamicGroupMember), tc.body, nil)
+			ps.engine = &backbone.Engine{CoreAPI: clientSet}
+			ps.watch()
+			if tc.expectErr {
+				require.Error(t, ps.err)
+				require.Empty(t, ps.Attribute.Resources)
+				return
+			}
+			require.NoError(t, ps.err)
+			require.Equal(t, tc.expect, ps.Attribute.Resources)
+		})
+	}
+}
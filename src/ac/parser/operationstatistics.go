@@ -77,6 +77,15 @@ var OperationStatisticAuthConfigs = []AuthConfig{
 		ResourceType:   meta.OperationStatistic,
 		ResourceAction: meta.Find,
 	},
+	{
+		Name:           "SearchOperationStatisticSnapshotRegex",
+		Description:    "查看运营统计快照数据",
+		Regex:          regexp.MustCompile(`^/api/v3/findmany/operation/chart/snapshot/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Find,
+	},
 	{
 		Name:           "UpdateOperationStatisticPositionRegex",
 		Description:    "更新运营统计图表位置",
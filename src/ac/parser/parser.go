@@ -44,6 +44,7 @@ func ParseAttribute(req *restful.Request, engine *backbone.Engine) (*meta.AuthAt
 		Header:   req.Request.Header,
 		Method:   req.Request.Method,
 		URI:      req.Request.URL.Path,
+		Query:    req.Request.URL.Query(),
 		Elements: elements,
 		getBody: func() (body []byte, err error) {
 			body, err = util.PeekRequest(req.Request)
@@ -299,6 +299,106 @@ var ServiceTemplateAuthConfigs = []AuthConfig{
 				return nil, err
 			}
 
+			templateID := val.Int()
+			if templateID <= 0 {
+				return nil, errors.New("invalid service template id")
+			}
+			return []int64{templateID}, nil
+		},
+	}, {
+		Name:           "listServiceTemplateRevision",
+		Description:    "查询服务模板版本",
+		Pattern:        "/api/v3/findmany/proc/service_template/revision",
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.FindMany,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) ([]int64, error) {
+			val, err := request.getValueFromBody(common.BKServiceTemplateIDField)
+			if err != nil {
+				return nil, err
+			}
+
+			templateID := val.Int()
+			if templateID <= 0 {
+				return nil, errors.New("invalid service template id")
+			}
+			return []int64{templateID}, nil
+		},
+	}, {
+		Name:           "diffServiceTemplateRevision",
+		Description:    "对比服务模板版本差异",
+		Pattern:        "/api/v3/find/proc/service_template/revision/difference",
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.FindMany,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) ([]int64, error) {
+			val, err := request.getValueFromBody(common.BKServiceTemplateIDField)
+			if err != nil {
+				return nil, err
+			}
+
+			templateID := val.Int()
+			if templateID <= 0 {
+				return nil, errors.New("invalid service template id")
+			}
+			return []int64{templateID}, nil
+		},
+	}, {
+		Name:           "pinModuleServiceTemplateVersion",
+		Description:    "固定模块使用的服务模板版本",
+		Pattern:        "/api/v3/update/proc/service_template/version_pin",
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.Update,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) ([]int64, error) {
+			val, err := request.getValueFromBody(common.BKServiceTemplateIDField)
+			if err != nil {
+				return nil, err
+			}
+
+			templateID := val.Int()
+			if templateID <= 0 {
+				return nil, errors.New("invalid service template id")
+			}
+			return []int64{templateID}, nil
+		},
+	}, {
+		Name:           "listModuleServiceTemplateVersionPin",
+		Description:    "查询模块固定的服务模板版本",
+		Pattern:        "/api/v3/findmany/proc/service_template/version_pin",
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.FindMany,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) ([]int64, error) {
+			val, err := request.getValueFromBody(common.BKServiceTemplateIDField)
+			if err != nil {
+				return nil, err
+			}
+
+			templateID := val.Int()
+			if templateID <= 0 {
+				return nil, errors.New("invalid service template id")
+			}
+			return []int64{templateID}, nil
+		},
+	}, {
+		Name:           "rollbackServiceTemplate",
+		Description:    "回滚服务模板到指定版本",
+		Pattern:        "/api/v3/update/proc/service_template/rollback",
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.Update,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) ([]int64, error) {
+			val, err := request.getValueFromBody(common.BKServiceTemplateIDField)
+			if err != nil {
+				return nil, err
+			}
+
 			templateID := val.Int()
 			if templateID <= 0 {
 				return nil, errors.New("invalid service template id")
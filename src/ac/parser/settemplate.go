@@ -274,6 +274,46 @@ var SetTemplateAuthConfigs = []AuthConfig{
 		ResourceType:     meta.ModelSet,
 		ResourceAction:   meta.FindMany,
 		InstanceIDGetter: nil,
+	}, {
+		Name:           "GetSetTemplateSyncPolicyRegex",
+		Description:    "获取集群模板同步策略",
+		Regex:          regexp.MustCompile(`^/api/v3/find/topo/set_template/([0-9]+)/bk_biz_id/([0-9]+)/sync_policy/?$`),
+		HTTPMethod:     http.MethodGet,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       7,
+		ResourceType:   meta.SetTemplate,
+		ResourceAction: meta.Find,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) (int64s []int64, e error) {
+			ss := re.FindStringSubmatch(request.URI)
+			if len(ss) < 2 {
+				return nil, errors.New("GetSetTemplateSyncPolicyRegex regex doesn't match")
+			}
+			id, err := strconv.ParseInt(ss[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("GetSetTemplateSyncPolicyRegex regex parse match to int failed, err: %+v", err)
+			}
+			return []int64{id}, nil
+		},
+	}, {
+		Name:           "UpdateSetTemplateSyncPolicyRegex",
+		Description:    "更新集群模板同步策略",
+		Regex:          regexp.MustCompile(`^/api/v3/update/topo/set_template/([0-9]+)/bk_biz_id/([0-9]+)/sync_policy/?$`),
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       7,
+		ResourceType:   meta.SetTemplate,
+		ResourceAction: meta.Update,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) (int64s []int64, e error) {
+			ss := re.FindStringSubmatch(request.URI)
+			if len(ss) < 2 {
+				return nil, errors.New("UpdateSetTemplateSyncPolicyRegex regex doesn't match")
+			}
+			id, err := strconv.ParseInt(ss[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("UpdateSetTemplateSyncPolicyRegex regex parse match to int failed, err: %+v", err)
+			}
+			return []int64{id}, nil
+		},
 	}, {
 		Name:           "updateSetTemplateAttribute",
 		Description:    "更新集群模板配置字段",
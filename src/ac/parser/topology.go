@@ -1258,6 +1258,11 @@ var (
 	searchAuditList   = `/api/v3/findmany/audit_list`
 	searchAuditDetail = `/api/v3/find/audit`
 	searchInstAudit   = `/api/v3/find/inst_audit`
+
+	searchAuditSnapshot     = `/api/v3/find/audit_snapshot`
+	searchAuditSnapshotDiff = `/api/v3/find/audit_snapshot/diff`
+	revertAudit             = `/api/v3/revert/audit`
+	verifyAuditChain        = `/api/v3/verify/audit_chain`
 )
 
 // NOCC:golint/fnsize(设计如此)
@@ -1290,7 +1295,24 @@ func (ps *parseStream) audit() *parseStream {
 		return ps
 	}
 
-	if ps.hitPattern(searchAuditDetail, http.MethodPost) {
+	// reconstruct resource state from audit logs and verify the audit log hash chain use the same permission as the
+	// audit detail
+	if ps.hitPattern(searchAuditDetail, http.MethodPost) || ps.hitPattern(searchAuditSnapshot, http.MethodPost) ||
+		ps.hitPattern(searchAuditSnapshotDiff, http.MethodPost) || ps.hitPattern(verifyAuditChain, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.AuditLog,
+					Action: meta.Find,
+				},
+			},
+		}
+		return ps
+	}
+
+	// revert audit needs to find the audit logs, the reverted instances are authorized in the handler with the same
+	// permissions as the reverted operations
+	if ps.hitPattern(revertAudit, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
 			{
 				Basic: meta.Basic{
@@ -135,6 +135,22 @@ func (c *cloudserver) DeleteSyncTask(ctx context.Context, h http.Header, taskID
 	return
 }
 
+// PreviewSyncTask 预览同步任务的同步差异
+func (c *cloudserver) PreviewSyncTask(ctx context.Context, h http.Header, taskID int64) (resp *metadata.Response,
+	err error) {
+	resp = new(metadata.Response)
+	subPath := "/find/cloud/sync/task/%d/diff"
+
+	err = c.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath, taskID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
 // SearchSyncHistory TODO
 func (c *cloudserver) SearchSyncHistory(ctx context.Context, h http.Header,
 	data map[string]interface{}) (resp *metadata.SearchResp, err error) {
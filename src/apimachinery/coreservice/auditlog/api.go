@@ -74,3 +74,29 @@ func (inst *auditlog) SearchAuditLog(ctx context.Context, h http.Header, param m
 
 	return resp.Data, nil
 }
+
+// VerifyAuditChain api of verify audit log hash chain
+func (inst *auditlog) VerifyAuditChain(ctx context.Context, h http.Header, opt metadata.VerifyAuditChainOption) (
+	*metadata.AuditChainVerifyResult, errors.CCErrorCoder) {
+
+	resp := new(metadata.VerifyAuditChainResponse)
+	subPath := "/read/auditlog/chain/verify"
+
+	err := inst.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
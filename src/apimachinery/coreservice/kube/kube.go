@@ -59,6 +59,19 @@ type KubeClientInterface interface {
 	ListWorkload(ctx context.Context, header http.Header, input *metadata.QueryCondition, kind types.WorkloadType) (
 		*types.WlDataResp, errors.CCErrorCoder)
 
+	// CreateNsResource create namespace resources, such as service, ingress, configmap and secret
+	CreateNsResource(ctx context.Context, header http.Header, kind types.NsResourceKind,
+		data []types.NsResourceInterface) (*metadata.RspIDs, errors.CCErrorCoder)
+	// UpdateNsResource update namespace resources
+	UpdateNsResource(ctx context.Context, header http.Header, kind types.NsResourceKind,
+		option *types.NsResUpdateByIDsOption) errors.CCErrorCoder
+	// DeleteNsResource delete namespace resources
+	DeleteNsResource(ctx context.Context, header http.Header, kind types.NsResourceKind,
+		option *types.NsResDeleteByIDsOption) errors.CCErrorCoder
+	// ListNsResource list namespace resources
+	ListNsResource(ctx context.Context, header http.Header, input *metadata.QueryCondition,
+		kind types.NsResourceKind) (*types.NsResDataResp, errors.CCErrorCoder)
+
 	BatchCreateNode(ctx context.Context, header http.Header, data []types.OneNodeCreateOption) (
 		*types.CreateNodesResult, errors.CCErrorCoder)
 	SearchNode(ctx context.Context, header http.Header, input *metadata.QueryCondition) (*types.SearchNodeRsp,
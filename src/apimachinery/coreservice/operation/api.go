@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 )
 
@@ -176,3 +177,49 @@ func (s *operation) TimerFreshData(ctx context.Context, h http.Header, data inte
 		Into(resp)
 	return
 }
+
+// CreateChartSnapshots create today's snapshots of all snapshot charts
+func (s *operation) CreateChartSnapshots(ctx context.Context, h http.Header) error {
+	resp := new(metadata.BaseResp)
+	subPath := "/create/operation/chart/snapshot"
+
+	err := s.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// SearchChartSnapshots search daily snapshot data of snapshot chart
+func (s *operation) SearchChartSnapshots(ctx context.Context, h http.Header, opt *metadata.SearchChartSnapshotOption) (
+	map[string][]metadata.StringIDCount, error) {
+
+	resp := new(metadata.SearchChartSnapshotResp)
+	subPath := "/findmany/operation/chart/snapshot"
+
+	err := s.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err = resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
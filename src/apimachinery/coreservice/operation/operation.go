@@ -24,6 +24,9 @@ type OperationClientInterface interface {
 	SearchChartCommon(ctx context.Context, h http.Header, data interface{}) (resp *metadata.SearchChartCommon,
 		err error)
 	TimerFreshData(ctx context.Context, h http.Header, data interface{}) (resp *metadata.BoolResponse, err error)
+	CreateChartSnapshots(ctx context.Context, h http.Header) error
+	SearchChartSnapshots(ctx context.Context, h http.Header, opt *metadata.SearchChartSnapshotOption) (
+		map[string][]metadata.StringIDCount, error)
 }
 
 // NewOperationClientInterface TODO
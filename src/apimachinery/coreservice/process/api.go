@@ -1053,3 +1053,103 @@ func (p *process) ListServiceTemplateAttribute(ctx context.Context, h http.Heade
 
 	return ret.Data, nil
 }
+
+// CreateSvcTempRevision create service template revision
+func (p *process) CreateSvcTempRevision(ctx context.Context, h http.Header,
+	revision *metadata.ServiceTemplateRevision) (*metadata.ServiceTemplateRevision, errors.CCErrorCoder) {
+
+	ret := new(metadata.SvcTempRevisionResult)
+	subPath := "/create/process/service_template/revision"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(revision).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+// ListSvcTempRevision list service template revisions
+func (p *process) ListSvcTempRevision(ctx context.Context, h http.Header,
+	option *metadata.ListSvcTempRevisionOption) (*metadata.MultipleSvcTempRevision, errors.CCErrorCoder) {
+
+	ret := new(metadata.MultipleSvcTempRevisionResult)
+	subPath := "/findmany/process/service_template/revision"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+// PinSvcTempVersion pin modules to a service template revision
+func (p *process) PinSvcTempVersion(ctx context.Context, h http.Header,
+	option *metadata.PinSvcTempVersionOption) errors.CCErrorCoder {
+
+	ret := new(metadata.BaseResp)
+	subPath := "/update/process/service_template/version_pin"
+
+	err := p.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return ret.CCError()
+	}
+
+	return nil
+}
+
+// ListSvcTempVersionPin list service template version pins of modules
+func (p *process) ListSvcTempVersionPin(ctx context.Context, h http.Header,
+	option *metadata.ListSvcTempVersionPinOption) ([]metadata.SvcTempVersionPin, errors.CCErrorCoder) {
+
+	ret := new(metadata.SvcTempVersionPinsResult)
+	subPath := "/findmany/process/service_template/version_pin"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return ret.Data, nil
+}
@@ -123,6 +123,15 @@ type ProcessInterface interface {
 		*metadata.ServTempAttrData, errors.CCErrorCoder)
 	CreateServiceTemplateAttrs(ctx context.Context, h http.Header, option *metadata.CreateSvcTempAttrsOption) (
 		[]int64, errors.CCErrorCoder)
+
+	// CreateSvcTempRevision service template revision
+	CreateSvcTempRevision(ctx context.Context, h http.Header, revision *metadata.ServiceTemplateRevision) (
+		*metadata.ServiceTemplateRevision, errors.CCErrorCoder)
+	ListSvcTempRevision(ctx context.Context, h http.Header, option *metadata.ListSvcTempRevisionOption) (
+		*metadata.MultipleSvcTempRevision, errors.CCErrorCoder)
+	PinSvcTempVersion(ctx context.Context, h http.Header, option *metadata.PinSvcTempVersionOption) errors.CCErrorCoder
+	ListSvcTempVersionPin(ctx context.Context, h http.Header, option *metadata.ListSvcTempVersionPinOption) (
+		[]metadata.SvcTempVersionPin, errors.CCErrorCoder)
 }
 
 // NewProcessInterfaceClient TODO
@@ -347,3 +347,78 @@ func (p *setTemplate) ListSetTemplateAttribute(ctx context.Context, h http.Heade
 
 	return ret.Data, nil
 }
+
+// UpsertSetTplSyncPolicy create or update set template sync policy
+func (p *setTemplate) UpsertSetTplSyncPolicy(ctx context.Context, h http.Header,
+	option *metadata.SetTplSyncPolicyOption) (*metadata.SetTplSyncPolicy, errors.CCErrorCoder) {
+
+	ret := new(metadata.SetTplSyncPolicyResult)
+	subPath := "/update/set_template/sync_policy"
+
+	err := p.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+// ListSetTplSyncPolicy list set template sync policies
+func (p *setTemplate) ListSetTplSyncPolicy(ctx context.Context, h http.Header,
+	option *metadata.ListSetTplSyncPolicyOption) (*metadata.MultipleSetTplSyncPolicy, errors.CCErrorCoder) {
+
+	ret := new(metadata.MultipleSetTplSyncPolicyResult)
+	subPath := "/findmany/set_template/sync_policy"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+// UpdateSetTplSyncRun update the last run record of set template sync policy
+func (p *setTemplate) UpdateSetTplSyncRun(ctx context.Context, h http.Header,
+	option *metadata.UpdateSetTplSyncRunOption) errors.CCErrorCoder {
+
+	ret := new(metadata.BaseResp)
+	subPath := "/update/set_template/sync_policy/last_run"
+
+	err := p.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return ret.CCError()
+	}
+
+	return nil
+}
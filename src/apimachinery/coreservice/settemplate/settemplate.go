@@ -52,6 +52,11 @@ type SetTemplateInterface interface {
 		option *metadata.DeleteSetTempAttrOption) errors.CCErrorCoder
 	ListSetTemplateAttribute(ctx context.Context, h http.Header, option *metadata.ListSetTempAttrOption) (
 		*metadata.SetTempAttrData, errors.CCErrorCoder)
+	UpsertSetTplSyncPolicy(ctx context.Context, h http.Header, option *metadata.SetTplSyncPolicyOption) (
+		*metadata.SetTplSyncPolicy, errors.CCErrorCoder)
+	ListSetTplSyncPolicy(ctx context.Context, h http.Header, option *metadata.ListSetTplSyncPolicyOption) (
+		*metadata.MultipleSetTplSyncPolicy, errors.CCErrorCoder)
+	UpdateSetTplSyncRun(ctx context.Context, h http.Header, option *metadata.UpdateSetTplSyncRunOption) errors.CCErrorCoder
 }
 
 // NewSetTemplateInterfaceClient TODO
@@ -76,6 +76,9 @@ type KubeOperationInterface interface {
 
 	BatchCreatePod(ctx context.Context, header http.Header, data *types.CreatePodsOption) ([]int64, errors.CCErrorCoder)
 
+	// DeletePods delete pods and their containers
+	DeletePods(ctx context.Context, header http.Header, option *types.DeletePodsOption) errors.CCErrorCoder
+
 	// ListContainer list container
 	ListContainer(ctx context.Context, header http.Header, option *types.ContainerQueryOption) (
 		*metadata.InstDataInfo, errors.CCErrorCoder)
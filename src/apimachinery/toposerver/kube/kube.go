@@ -470,6 +470,31 @@ func (st *Kube) ListPod(ctx context.Context, header http.Header, option *types.P
 	return &result.Data, nil
 }
 
+// DeletePods delete pods and their containers
+func (st *Kube) DeletePods(ctx context.Context, header http.Header,
+	option *types.DeletePodsOption) errors.CCErrorCoder {
+
+	result := new(metadata.BaseResp)
+
+	err := st.client.Delete().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/deletemany/kube/pod").
+		WithHeaders(header).
+		Do().
+		Into(result)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	if ccErr := result.CCError(); ccErr != nil {
+		return ccErr
+	}
+
+	return nil
+}
+
 // ListContainer list container
 func (st *Kube) ListContainer(ctx context.Context, header http.Header,
 	option *types.ContainerQueryOption) (*metadata.InstDataInfo, errors.CCErrorCoder) {
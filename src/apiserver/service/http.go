@@ -126,12 +126,23 @@ func (s *service) Do(req *restful.Request, resp *restful.Response) {
 		}
 	}
 
+	// the upgraded connection like websocket is tunneled between the client and the backend server directly
+	if response.StatusCode == http.StatusSwitchingProtocols {
+		proxyUpgradedConn(resp, response, rid)
+		return
+	}
+
 	for k, v := range response.Header {
 		if len(v) > 0 {
 			resp.Header().Set(k, v[0])
 		}
 	}
 
+	if isEventStreamResponse(response) {
+		proxyEventStream(resp, response, rid)
+		return
+	}
+
 	parseResponse(req, resp, response.Body, rid)
 
 	blog.V(4).Infof("cost: %dms, action: %s, status code: %d, user: %s, app code: %s, url: %s, rid: %s",
@@ -162,6 +162,10 @@ func (s *service) routeNeedAuthAPI(ws *restful.WebService, errFunc func() errors
 
 	}
 
+	// resource watch stream api responds with server-sent events, which needs to accept the event stream content type
+	ws.Route(ws.POST("/event/watch/stream/resource/{resource}").Produces(mimeEventStream, restful.MIME_JSON).
+		Filter(s.authFilter(errFunc)).Filter(s.URLFilterChan).To(s.Post))
+
 	ws.Route(ws.GET("{.*}").Filter(s.authFilter(errFunc)).Filter(s.URLFilterChan).To(s.Get))
 	ws.Route(ws.POST("{.*}").Filter(s.authFilter(errFunc)).Filter(s.URLFilterChan).To(s.Post))
 	ws.Route(ws.PUT("{.*}").Filter(s.authFilter(errFunc)).Filter(s.URLFilterChan).To(s.Put))
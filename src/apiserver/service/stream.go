@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"configcenter/src/common/blog"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// mimeEventStream is the content type of server-sent events
+const mimeEventStream = "text/event-stream"
+
+// isEventStreamResponse returns if the response is a server-sent events stream
+func isEventStreamResponse(response *http.Response) bool {
+	return strings.HasPrefix(response.Header.Get("Content-Type"), mimeEventStream)
+}
+
+// proxyEventStream copy the server-sent events stream to the client, the received data is flushed to the client
+// immediately, so that the events can be pushed to the client in time.
+func proxyEventStream(resp *restful.Response, response *http.Response, rid string) {
+	defer response.Body.Close()
+
+	resp.WriteHeader(response.StatusCode)
+	resp.Flush()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := response.Body.Read(buf)
+		if n > 0 {
+			if _, writeErr := resp.Write(buf[:n]); writeErr != nil {
+				blog.Errorf("write event stream to client failed, err: %v, rid: %s", writeErr, rid)
+				return
+			}
+			resp.Flush()
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				blog.Errorf("read event stream from server failed, err: %v, rid: %s", err, rid)
+			}
+			return
+		}
+	}
+}
+
+// proxyUpgradedConn tunnel the upgraded connection between the client and the backend server, the switching protocols
+// response is sent to the client, then the data is copied in both directions until one of the connections is closed.
+func proxyUpgradedConn(resp *restful.Response, response *http.Response, rid string) {
+	backendConn, ok := response.Body.(io.ReadWriteCloser)
+	if !ok {
+		response.Body.Close()
+		blog.Errorf("upgraded response body is not writable, rid: %s", rid)
+		resp.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer backendConn.Close()
+
+	clientConn, clientRW, err := resp.Hijack()
+	if err != nil {
+		blog.Errorf("hijack client connection failed, err: %v, rid: %s", err, rid)
+		resp.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer clientConn.Close()
+
+	response.Body = nil
+	if err = response.Write(clientRW); err != nil {
+		blog.Errorf("write switching protocols response to client failed, err: %v, rid: %s", err, rid)
+		return
+	}
+	if err = clientRW.Flush(); err != nil {
+		blog.Errorf("flush switching protocols response to client failed, err: %v, rid: %s", err, rid)
+		return
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		// the buffered reader may contain the data that client sent after the upgrade request
+		_, err := io.Copy(backendConn, clientRW)
+		errCh <- err
+	}()
+	go func() {
+		_, err := io.Copy(clientConn, backendConn)
+		errCh <- err
+	}()
+
+	if err = <-errCh; err != nil {
+		blog.V(4).Infof("upgraded connection is closed, err: %v, rid: %s", err, rid)
+	}
+}
@@ -82,7 +82,7 @@ func (u *URLPath) WithTopo(req *restful.Request) (isHit bool) {
 
 	topoPrefixes := []string{"/search/instances", "/count/instances", "/search/instance_associations",
 		"/count/instance_associations", "/topo/", "/identifier/", "/inst/", "/module/", "/object/", "/set/",
-		"/find/audit", "/find/inst_audit"}
+		"/find/audit", "/find/inst_audit", "/revert/audit", "/verify/audit_chain"}
 
 	for _, prefix := range topoPrefixes {
 		if strings.HasPrefix(string(*u), rootPath+prefix) {
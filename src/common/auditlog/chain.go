@@ -0,0 +1,240 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package auditlog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+)
+
+// auditChainPageSize is the count of audit logs that are verified in one page
+const auditChainPageSize = 500
+
+// GetAuditChainHead get the audit log hash chain head of the supplier account, returns nil if no audit log of the
+// supplier account has been sealed into the chain
+func GetAuditChainHead(ctx context.Context, db dal.RDB, supplierAccount string) (*metadata.AuditChainHead, error) {
+	head := new(metadata.AuditChainHead)
+	cond := mapstr.MapStr{common.BkSupplierAccount: supplierAccount}
+	if err := db.Table(common.BKTableNameAuditLogChain).Find(cond).One(ctx, head); err != nil {
+		if db.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get audit log chain head of supplier account %s failed, err: %v", supplierAccount, err)
+	}
+	return head, nil
+}
+
+// ListAuditChainHeads list the audit log hash chain heads of all the supplier accounts
+func ListAuditChainHeads(ctx context.Context, db dal.RDB) ([]metadata.AuditChainHead, error) {
+	heads := make([]metadata.AuditChainHead, 0)
+	if err := db.Table(common.BKTableNameAuditLogChain).Find(mapstr.MapStr{}).All(ctx, &heads); err != nil {
+		return nil, fmt.Errorf("list audit log chain heads failed, err: %v", err)
+	}
+	return heads, nil
+}
+
+// SealAuditLogs seal the audit logs into the hash chain of their supplier accounts, the audit logs must be sealed in
+// the order that they are inserted. audit logs that are already sealed are skipped, so it is safe to seal them again.
+func SealAuditLogs(ctx context.Context, db dal.RDB, logs []*metadata.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	sealedMap, err := getSealedAuditLogs(ctx, db, logs)
+	if err != nil {
+		return err
+	}
+
+	heads := make(map[string]*metadata.AuditChainHead)
+	for _, auditLog := range logs {
+		head, exists := heads[auditLog.SupplierAccount]
+		if !exists {
+			head, err = GetAuditChainHead(ctx, db, auditLog.SupplierAccount)
+			if err != nil {
+				return err
+			}
+			if head == nil {
+				head = &metadata.AuditChainHead{
+					SupplierAccount: auditLog.SupplierAccount,
+					LastHash:        metadata.AuditChainGenesisHash,
+				}
+			}
+			heads[auditLog.SupplierAccount] = head
+		}
+
+		// the audit log is sealed but the chain head may not be updated if the previous sealing is interrupted
+		if sealed, exists := sealedMap[auditLog.ID]; exists {
+			if sealed.ChainSeq > head.LastSeq {
+				if err = updateAuditChainHead(ctx, db, head, &sealed); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		auditLog.ChainSeq = head.LastSeq + 1
+		auditLog.PrevHash = head.LastHash
+		auditLog.ChainHash, err = auditLog.CalcChainHash()
+		if err != nil {
+			return err
+		}
+
+		cond := mapstr.MapStr{
+			common.BKFieldID:            auditLog.ID,
+			common.BKAuditChainSeqField: mapstr.MapStr{common.BKDBExists: false},
+		}
+		data := mapstr.MapStr{
+			common.BKAuditChainSeqField:  auditLog.ChainSeq,
+			common.BKAuditPrevHashField:  auditLog.PrevHash,
+			common.BKAuditChainHashField: auditLog.ChainHash,
+		}
+		if err = db.Table(common.BKTableNameAuditLog).Update(ctx, cond, data); err != nil {
+			return fmt.Errorf("seal audit log %d failed, err: %v", auditLog.ID, err)
+		}
+
+		if err = updateAuditChainHead(ctx, db, head, auditLog); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getSealedAuditLogs get the chain fields of the audit logs that are already sealed, returns map of audit log id to
+// the sealed audit log
+func getSealedAuditLogs(ctx context.Context, db dal.RDB, logs []*metadata.AuditLog) (map[int64]metadata.AuditLog,
+	error) {
+
+	ids := make([]int64, len(logs))
+	for idx, auditLog := range logs {
+		ids[idx] = auditLog.ID
+	}
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:            mapstr.MapStr{common.BKDBIN: ids},
+		common.BKAuditChainSeqField: mapstr.MapStr{common.BKDBExists: true},
+	}
+	sealed := make([]metadata.AuditLog, 0)
+	err := db.Table(common.BKTableNameAuditLog).Find(cond).Fields(common.BKFieldID, common.BKAuditChainSeqField,
+		common.BKAuditChainHashField, common.BkSupplierAccount).All(ctx, &sealed)
+	if err != nil {
+		return nil, fmt.Errorf("get sealed audit logs by ids %v failed, err: %v", ids, err)
+	}
+
+	sealedMap := make(map[int64]metadata.AuditLog)
+	for _, auditLog := range sealed {
+		sealedMap[auditLog.ID] = auditLog
+	}
+	return sealedMap, nil
+}
+
+func updateAuditChainHead(ctx context.Context, db dal.RDB, head *metadata.AuditChainHead,
+	auditLog *metadata.AuditLog) error {
+
+	head.LastSeq = auditLog.ChainSeq
+	head.LastID = auditLog.ID
+	head.LastHash = auditLog.ChainHash
+	head.LastTime = time.Now()
+
+	cond := mapstr.MapStr{common.BkSupplierAccount: head.SupplierAccount}
+	if err := db.Table(common.BKTableNameAuditLogChain).Upsert(ctx, cond, head); err != nil {
+		return fmt.Errorf("update audit log chain head of supplier account %s failed, err: %v",
+			head.SupplierAccount, err)
+	}
+	return nil
+}
+
+// VerifyAuditChain walk the audit log hash chain of the supplier account in chain sequence order, and report the
+// first broken link. only the audit logs sealed before the verification starts are verified, then the audit logs that
+// are still unsealed after the unsealed timeout are reported as broken.
+func VerifyAuditChain(ctx context.Context, db dal.RDB, supplierAccount string,
+	opt metadata.VerifyAuditChainOption) (*metadata.AuditChainVerifyResult, error) {
+
+	// get the chain head first, so that the audit logs sealed during the verification are not verified
+	head, err := GetAuditChainHead(ctx, db, supplierAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier := metadata.NewAuditChainVerifier(supplierAccount, opt)
+	if head != nil {
+		if err = verifySealedAuditLogs(ctx, db, supplierAccount, opt.StartSeq, head, verifier); err != nil {
+			return nil, err
+		}
+	}
+
+	result := verifier.Finish(head)
+	if result.BrokenLink != nil {
+		return result, nil
+	}
+
+	// audit logs are sealed asynchronously after they are inserted, they can be modified without breaking the chain
+	// before they are sealed, so the audit logs that are still unsealed after the timeout are reported as broken
+	cond := mapstr.MapStr{
+		common.BkSupplierAccount:    supplierAccount,
+		common.BKAuditChainSeqField: mapstr.MapStr{common.BKDBExists: false},
+		common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: time.Now().Add(-opt.UnsealedTimeout())},
+	}
+	unsealed := make([]metadata.AuditLog, 0)
+	err = db.Table(common.BKTableNameAuditLog).Find(cond).Sort(common.BKFieldID).Limit(1).All(ctx, &unsealed)
+	if err != nil {
+		return nil, fmt.Errorf("get unsealed audit logs by cond %+v failed, err: %v", cond, err)
+	}
+
+	if len(unsealed) > 0 {
+		verifier.VerifyUnsealed(&unsealed[0])
+	}
+	return result, nil
+}
+
+// verifySealedAuditLogs verify the sealed audit logs of the supplier account up to the chain head page by page
+func verifySealedAuditLogs(ctx context.Context, db dal.RDB, supplierAccount string, startSeq int64,
+	head *metadata.AuditChainHead, verifier *metadata.AuditChainVerifier) error {
+
+	seqCond := mapstr.MapStr{common.BKDBGTE: startSeq, common.BKDBLTE: head.LastSeq}
+	for {
+		cond := mapstr.MapStr{
+			common.BkSupplierAccount:    supplierAccount,
+			common.BKAuditChainSeqField: seqCond,
+		}
+
+		logs := make([]metadata.AuditLog, 0)
+		err := db.Table(common.BKTableNameAuditLog).Find(cond).Sort(common.BKAuditChainSeqField).
+			Limit(auditChainPageSize).All(ctx, &logs)
+		if err != nil {
+			return fmt.Errorf("get audit logs by cond %+v failed, err: %v", cond, err)
+		}
+
+		for idx := range logs {
+			if !verifier.Verify(&logs[idx]) {
+				return nil
+			}
+		}
+
+		if len(logs) < auditChainPageSize {
+			return nil
+		}
+
+		seqCond = mapstr.MapStr{common.BKDBGT: logs[len(logs)-1].ChainSeq, common.BKDBLTE: head.LastSeq}
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package auditlog
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAuditChain(t *testing.T, count int) []metadata.AuditLog {
+	logs := make([]metadata.AuditLog, count)
+	prevHash := metadata.AuditChainGenesisHash
+	for i := range logs {
+		logs[i] = metadata.AuditLog{
+			ID:              int64(i + 10),
+			SupplierAccount: "0",
+			AuditType:       metadata.HostType,
+			ResourceType:    metadata.HostRes,
+			Action:          metadata.AuditUpdate,
+			ResourceID:      int64(i),
+			OperationTime:   metadata.Now(),
+			OperationDetail: &metadata.BasicOpDetail{Details: &metadata.BasicContent{
+				UpdateFields: map[string]interface{}{"bk_host_name": "a"},
+			}},
+			ChainSeq: int64(i + 1),
+			PrevHash: prevHash,
+		}
+
+		hash, err := logs[i].CalcChainHash()
+		require.NoError(t, err)
+		logs[i].ChainHash = hash
+		prevHash = hash
+	}
+	return logs
+}
+
+func verifyTestAuditChain(logs []metadata.AuditLog, head *metadata.AuditChainHead,
+	opt metadata.VerifyAuditChainOption) *metadata.AuditChainVerifyResult {
+
+	verifier := metadata.NewAuditChainVerifier("0", opt)
+	for i := range logs {
+		if !verifier.Verify(&logs[i]) {
+			break
+		}
+	}
+	return verifier.Finish(head)
+}
+
+func TestAuditChainVerify(t *testing.T) {
+	logs := newTestAuditChain(t, 5)
+	head := &metadata.AuditChainHead{SupplierAccount: "0", LastSeq: 5, LastID: 14, LastHash: logs[4].ChainHash}
+
+	result := verifyTestAuditChain(logs, head, metadata.VerifyAuditChainOption{})
+	require.Nil(t, result.BrokenLink)
+	require.EqualValues(t, 5, result.Verified)
+
+	// verify from the middle of the chain
+	result = verifyTestAuditChain(logs[2:], head, metadata.VerifyAuditChainOption{StartSeq: 3})
+	require.Nil(t, result.BrokenLink)
+	require.EqualValues(t, 3, result.Verified)
+
+	// modify the content of an audit log
+	modified := newTestAuditChain(t, 5)
+	modified[2].User = "tamper"
+	result = verifyTestAuditChain(modified, head, metadata.VerifyAuditChainOption{})
+	require.NotNil(t, result.BrokenLink)
+	require.Equal(t, metadata.AuditChainHashMismatch, result.BrokenLink.Reason)
+	require.EqualValues(t, 3, result.BrokenLink.ChainSeq)
+
+	// remove an audit log from the chain
+	removed := append(append([]metadata.AuditLog{}, logs[:1]...), logs[2:]...)
+	result = verifyTestAuditChain(removed, head, metadata.VerifyAuditChainOption{})
+	require.NotNil(t, result.BrokenLink)
+	require.Equal(t, metadata.AuditChainMissing, result.BrokenLink.Reason)
+	require.EqualValues(t, 2, result.BrokenLink.ChainSeq)
+
+	result = verifyTestAuditChain(removed, head, metadata.VerifyAuditChainOption{AllowGaps: true})
+	require.Nil(t, result.BrokenLink)
+	require.EqualValues(t, 1, result.Gaps)
+
+	// remove the last audit log of the chain
+	result = verifyTestAuditChain(logs[:4], head, metadata.VerifyAuditChainOption{})
+	require.NotNil(t, result.BrokenLink)
+	require.Equal(t, metadata.AuditChainHeadMismatch, result.BrokenLink.Reason)
+}
+
+func TestAuditChainVerifyUnsealed(t *testing.T) {
+	logs := newTestAuditChain(t, 2)
+	head := &metadata.AuditChainHead{SupplierAccount: "0", LastSeq: 2, LastID: 11, LastHash: logs[1].ChainHash}
+	unsealed := &metadata.AuditLog{ID: 20, SupplierAccount: "0", OperationTime: metadata.Now()}
+
+	// unsealed audit log is reported after the sealed audit logs are verified
+	verifier := metadata.NewAuditChainVerifier("0", metadata.VerifyAuditChainOption{})
+	for i := range logs {
+		require.True(t, verifier.Verify(&logs[i]))
+	}
+	result := verifier.Finish(head)
+	verifier.VerifyUnsealed(unsealed)
+	require.NotNil(t, result.BrokenLink)
+	require.Equal(t, metadata.AuditChainUnsealed, result.BrokenLink.Reason)
+	require.EqualValues(t, 20, result.BrokenLink.AuditID)
+	require.EqualValues(t, 2, result.Verified)
+
+	// broken link in the chain is reported first
+	modified := newTestAuditChain(t, 2)
+	modified[1].User = "tamper"
+	verifier = metadata.NewAuditChainVerifier("0", metadata.VerifyAuditChainOption{})
+	for i := range modified {
+		if !verifier.Verify(&modified[i]) {
+			break
+		}
+	}
+	result = verifier.Finish(head)
+	verifier.VerifyUnsealed(unsealed)
+	require.Equal(t, metadata.AuditChainHashMismatch, result.BrokenLink.Reason)
+
+	// unsealed audit log is reported even if no audit log has been sealed
+	verifier = metadata.NewAuditChainVerifier("0", metadata.VerifyAuditChainOption{})
+	result = verifier.Finish(nil)
+	verifier.VerifyUnsealed(unsealed)
+	require.Equal(t, metadata.AuditChainUnsealed, result.BrokenLink.Reason)
+}
+
+func TestVerifyAuditChainOption(t *testing.T) {
+	tests := []struct {
+		name          string
+		opt           metadata.VerifyAuditChainOption
+		valid         bool
+		expectTimeout time.Duration
+	}{
+		{
+			name:          "default unsealed timeout",
+			opt:           metadata.VerifyAuditChainOption{},
+			valid:         true,
+			expectTimeout: metadata.DefaultAuditUnsealedTimeoutMinutes * time.Minute,
+		},
+		{
+			name:          "custom unsealed timeout",
+			opt:           metadata.VerifyAuditChainOption{StartSeq: 10, UnsealedTimeoutMinutes: 30},
+			valid:         true,
+			expectTimeout: 30 * time.Minute,
+		},
+		{
+			name: "negative start sequence",
+			opt:  metadata.VerifyAuditChainOption{StartSeq: -1},
+		},
+		{
+			name: "negative unsealed timeout",
+			opt:  metadata.VerifyAuditChainOption{UnsealedTimeoutMinutes: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawErr := tt.opt.Validate()
+			require.Equal(t, tt.valid, rawErr.ErrCode == 0)
+			if tt.valid {
+				require.Equal(t, tt.expectTimeout, tt.opt.UnsealedTimeout())
+			}
+		})
+	}
+}
@@ -135,6 +135,36 @@ func (c *kubeAuditLog) GenerateWorkloadAuditLog(param *generateAuditCommonParame
 	return auditLogs, nil
 }
 
+var nsResourceAuditTypes = map[types.NsResourceKind]metadata.ResourceType{
+	types.KubeService:   metadata.KubeService,
+	types.KubeIngress:   metadata.KubeIngress,
+	types.KubeConfigMap: metadata.KubeConfigMap,
+	types.KubeSecret:    metadata.KubeSecret,
+}
+
+// GenerateNsResourceAuditLog generate audit log of kube namespace resources, such as service, ingress, configmap
+// and secret.
+func (c *kubeAuditLog) GenerateNsResourceAuditLog(param *generateAuditCommonParameter,
+	data []types.NsResourceInterface, kind types.NsResourceKind) ([]metadata.AuditLog, errors.CCErrorCoder) {
+
+	typ, exists := nsResourceAuditTypes[kind]
+	if !exists {
+		return nil, param.kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, types.KindField)
+	}
+
+	auditLogs := make([]metadata.AuditLog, len(data))
+	for index, d := range data {
+		base := d.GetNsResourceBase()
+		auditLog, err := c.generateAuditLog(param, typ, base.ID, base.BizID, &base.Name, d)
+		if err != nil {
+			return nil, err
+		}
+		auditLogs[index] = auditLog
+	}
+
+	return auditLogs, nil
+}
+
 func (c *kubeAuditLog) generateAuditLog(param *generateAuditCommonParameter, typ metadata.ResourceType,
 	id, bizID int64, name *string, data interface{}) (metadata.AuditLog, errors.CCErrorCoder) {
 
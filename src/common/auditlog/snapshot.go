@@ -0,0 +1,220 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package auditlog
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// AuditSnapshot is the state of a resource at a time point, which is reconstructed by replaying its audit logs
+type AuditSnapshot struct {
+	ResourceType metadata.ResourceType `json:"resource_type"`
+	ResourceID   int64                 `json:"resource_id"`
+	Time         string                `json:"time"`
+	// Exists represents whether the resource exists at the time point
+	Exists bool `json:"exists"`
+	// Complete represents whether the creation audit log of the resource is replayed, if not, the resource is
+	// created before its earliest audit log, so the fields that are not changed since then are missing in Data
+	Complete bool `json:"complete"`
+	// Data is the resource data, it is the last known data of the resource if the resource is deleted
+	Data mapstr.MapStr `json:"data"`
+	// Topology is the business topology of the host, it is only set for host when it has been transferred
+	Topology *metadata.HostBizTopo `json:"topology,omitempty"`
+	// Processes is the processes of the service instance, the key is the process id
+	Processes map[int64]mapstr.MapStr `json:"processes,omitempty"`
+	// Associations is the instance associations of the source instance for instance association resource
+	Associations []metadata.InstanceAssociationOpDetail `json:"associations,omitempty"`
+	// LastAuditID is the id of the last audit log replayed
+	LastAuditID int64 `json:"last_audit_id"`
+	// LastOperationTime is the operation time of the last audit log replayed
+	LastOperationTime *metadata.Time `json:"last_operation_time,omitempty"`
+}
+
+// NewAuditSnapshot new an empty snapshot of the resource at the time point
+func NewAuditSnapshot(res metadata.AuditResource, t string) *AuditSnapshot {
+	return &AuditSnapshot{
+		ResourceType: res.ResourceType,
+		ResourceID:   res.ResourceID,
+		Time:         t,
+		Data:         make(mapstr.MapStr),
+	}
+}
+
+// Apply replays an audit log of the resource on the snapshot, audit logs must be applied in the order of their ids
+func (s *AuditSnapshot) Apply(log *metadata.AuditLog) {
+	switch detail := log.OperationDetail.(type) {
+	case *metadata.HostTransferOpDetail:
+		topo := detail.CurData
+		s.Topology = &topo
+		s.Exists = true
+	case *metadata.InstanceAssociationOpDetail:
+		s.applyAssociation(log.Action, detail)
+	case *metadata.ServiceInstanceOpDetail:
+		s.applyContent(log.Action, detail.Details)
+		for _, proc := range detail.Processes {
+			s.applyProcess(proc)
+		}
+	case *metadata.InstanceOpDetail:
+		s.applyContent(log.Action, detail.Details)
+	case *metadata.BasicOpDetail:
+		s.applyContent(log.Action, detail.Details)
+	}
+
+	s.LastAuditID = log.ID
+	operationTime := log.OperationTime
+	s.LastOperationTime = &operationTime
+}
+
+// applyContent replays the basic content of the audit log on the snapshot data. the previous data is the resource
+// data before the operation, so it is applied first, then the update fields and the current data after the operation
+func (s *AuditSnapshot) applyContent(action metadata.ActionType, content *metadata.BasicContent) {
+	switch action {
+	case metadata.AuditCreate:
+		s.Data = make(mapstr.MapStr)
+		s.Complete = true
+	}
+
+	if content != nil {
+		for _, data := range []map[string]interface{}{content.PreData, content.UpdateFields, content.CurData} {
+			for key, value := range data {
+				s.Data[key] = value
+			}
+		}
+	}
+
+	s.Exists = action != metadata.AuditDelete
+}
+
+// applyProcess replays the process operation detail of the service instance audit log
+func (s *AuditSnapshot) applyProcess(proc metadata.SvcInstProOpDetail) {
+	if s.Processes == nil {
+		s.Processes = make(map[int64]mapstr.MapStr)
+	}
+
+	if proc.Action == metadata.AuditDelete {
+		delete(s.Processes, proc.ProcessIDs)
+		return
+	}
+
+	data, exists := s.Processes[proc.ProcessIDs]
+	if !exists || proc.Action == metadata.AuditCreate {
+		data = make(mapstr.MapStr)
+	}
+
+	if proc.Details != nil {
+		for _, content := range []map[string]interface{}{proc.Details.PreData, proc.Details.UpdateFields,
+			proc.Details.CurData} {
+			for key, value := range content {
+				data[key] = value
+			}
+		}
+	}
+	s.Processes[proc.ProcessIDs] = data
+}
+
+// applyAssociation replays the instance association audit log, which only has create and delete actions
+func (s *AuditSnapshot) applyAssociation(action metadata.ActionType, detail *metadata.InstanceAssociationOpDetail) {
+	for idx, asst := range s.Associations {
+		if asst.AssociationID == detail.AssociationID && asst.TargetModelID == detail.TargetModelID &&
+			asst.TargetInstanceID == detail.TargetInstanceID {
+			s.Associations = append(s.Associations[:idx], s.Associations[idx+1:]...)
+			break
+		}
+	}
+
+	if action != metadata.AuditDelete {
+		s.Associations = append(s.Associations, *detail)
+	}
+	s.Exists = len(s.Associations) > 0
+}
+
+// AuditSnapshotDiff is the difference of a resource between two time points
+type AuditSnapshotDiff struct {
+	From    *AuditSnapshot     `json:"from"`
+	To      *AuditSnapshot     `json:"to"`
+	Changes []AuditFieldChange `json:"changes"`
+}
+
+// AuditFieldChange is a changed field of the resource between two time points
+type AuditFieldChange struct {
+	// Field is the changed field, the field of process is in the form of processes.{process id}.{field}
+	Field    string      `json:"field"`
+	PreValue interface{} `json:"pre_value"`
+	CurValue interface{} `json:"cur_value"`
+}
+
+// DiffAuditSnapshot compares the two snapshots of a resource, returns the changed fields sorted by field name
+func DiffAuditSnapshot(from, to *AuditSnapshot) *AuditSnapshotDiff {
+	changes := make([]AuditFieldChange, 0)
+	addChange := func(field string, preValue, curValue interface{}) {
+		if !reflect.DeepEqual(preValue, curValue) {
+			changes = append(changes, AuditFieldChange{Field: field, PreValue: preValue, CurValue: curValue})
+		}
+	}
+
+	addChange("exists", from.Exists, to.Exists)
+	diffMapStr("", from.Data, to.Data, addChange)
+
+	if from.Topology != nil || to.Topology != nil {
+		addChange("topology", from.Topology, to.Topology)
+	}
+
+	if len(from.Associations) > 0 || len(to.Associations) > 0 {
+		addChange("associations", from.Associations, to.Associations)
+	}
+
+	procIDs := make(map[int64]struct{})
+	for id := range from.Processes {
+		procIDs[id] = struct{}{}
+	}
+	for id := range to.Processes {
+		procIDs[id] = struct{}{}
+	}
+	for id := range procIDs {
+		prefix := fmt.Sprintf("processes.%d", id)
+		preProc, preExists := from.Processes[id]
+		curProc, curExists := to.Processes[id]
+		if !preExists || !curExists {
+			addChange(prefix, from.Processes[id], to.Processes[id])
+			continue
+		}
+		diffMapStr(prefix+".", preProc, curProc, addChange)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return &AuditSnapshotDiff{From: from, To: to, Changes: changes}
+}
+
+func diffMapStr(prefix string, from, to mapstr.MapStr, addChange func(field string, preValue, curValue interface{})) {
+	for key, preValue := range from {
+		addChange(prefix+key, preValue, to[key])
+	}
+
+	for key, curValue := range to {
+		if _, exists := from[key]; !exists {
+			addChange(prefix+key, nil, curValue)
+		}
+	}
+}
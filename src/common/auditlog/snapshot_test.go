@@ -0,0 +1,173 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package auditlog
+
+import (
+	"testing"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditSnapshotReplay(t *testing.T) {
+	res := metadata.AuditResource{ResourceType: metadata.HostRes, ResourceID: 1}
+	logs := []metadata.AuditLog{
+		{
+			ID:     1,
+			Action: metadata.AuditCreate,
+			OperationDetail: &metadata.InstanceOpDetail{BasicOpDetail: metadata.BasicOpDetail{Details: &metadata.BasicContent{
+				CurData: map[string]interface{}{"bk_host_id": 1, "bk_host_name": "a", "operator": "x"},
+			}}},
+		},
+		{
+			ID:     2,
+			Action: metadata.AuditUpdate,
+			OperationDetail: &metadata.InstanceOpDetail{BasicOpDetail: metadata.BasicOpDetail{Details: &metadata.BasicContent{
+				PreData:      map[string]interface{}{"bk_host_id": 1, "bk_host_name": "a", "operator": "x"},
+				UpdateFields: map[string]interface{}{"bk_host_name": "b"},
+			}}},
+		},
+		{
+			ID:     3,
+			Action: metadata.AuditTransferHostModule,
+			OperationDetail: &metadata.HostTransferOpDetail{
+				CurData: metadata.HostBizTopo{BizID: 2, BizName: "biz"},
+			},
+		},
+	}
+
+	from := NewAuditSnapshot(res, "")
+	from.Apply(&logs[0])
+	require.True(t, from.Exists)
+	require.True(t, from.Complete)
+	require.Equal(t, "a", from.Data["bk_host_name"])
+
+	to := NewAuditSnapshot(res, "")
+	for idx := range logs {
+		to.Apply(&logs[idx])
+	}
+	require.Equal(t, "b", to.Data["bk_host_name"])
+	require.Equal(t, int64(2), to.Topology.BizID)
+	require.Equal(t, int64(3), to.LastAuditID)
+
+	diff := DiffAuditSnapshot(from, to)
+	require.Len(t, diff.Changes, 2)
+	require.Equal(t, AuditFieldChange{Field: "bk_host_name", PreValue: "a", CurValue: "b"}, diff.Changes[0])
+	require.Equal(t, "topology", diff.Changes[1].Field)
+
+	to.Apply(&metadata.AuditLog{
+		ID:     4,
+		Action: metadata.AuditDelete,
+		OperationDetail: &metadata.InstanceOpDetail{BasicOpDetail: metadata.BasicOpDetail{Details: &metadata.BasicContent{
+			PreData: map[string]interface{}{"bk_host_id": 1, "bk_host_name": "b", "operator": "x"},
+		}}},
+	})
+	require.False(t, to.Exists)
+	require.Equal(t, "b", to.Data["bk_host_name"])
+}
+
+func TestAuditSnapshotReplayServiceInstance(t *testing.T) {
+	snapshot := NewAuditSnapshot(metadata.AuditResource{ResourceType: metadata.ServiceInstanceRes, ResourceID: 1}, "")
+
+	// the service instance is created before its earliest audit log
+	snapshot.Apply(&metadata.AuditLog{
+		ID:     1,
+		Action: metadata.AuditUpdate,
+		OperationDetail: &metadata.ServiceInstanceOpDetail{
+			Processes: []metadata.SvcInstProOpDetail{
+				{
+					Action:     metadata.AuditCreate,
+					ProcessIDs: 10,
+					BasicOpDetail: metadata.BasicOpDetail{Details: &metadata.BasicContent{
+						CurData: map[string]interface{}{"bk_process_name": "p1"},
+					}},
+				},
+				{
+					Action:     metadata.AuditCreate,
+					ProcessIDs: 11,
+					BasicOpDetail: metadata.BasicOpDetail{Details: &metadata.BasicContent{
+						CurData: map[string]interface{}{"bk_process_name": "p2"},
+					}},
+				},
+			},
+		},
+	})
+	require.True(t, snapshot.Exists)
+	require.False(t, snapshot.Complete)
+	require.Len(t, snapshot.Processes, 2)
+
+	snapshot.Apply(&metadata.AuditLog{
+		ID:     2,
+		Action: metadata.AuditUpdate,
+		OperationDetail: &metadata.ServiceInstanceOpDetail{
+			Processes: []metadata.SvcInstProOpDetail{
+				{Action: metadata.AuditDelete, ProcessIDs: 11},
+				{
+					Action:     metadata.AuditUpdate,
+					ProcessIDs: 10,
+					BasicOpDetail: metadata.BasicOpDetail{Details: &metadata.BasicContent{
+						UpdateFields: map[string]interface{}{"bk_process_name": "p3"},
+					}},
+				},
+			},
+		},
+	})
+	require.Equal(t, map[int64]mapstr.MapStr{10: {"bk_process_name": "p3"}}, snapshot.Processes)
+}
+
+func TestAuditSnapshotReplayAssociation(t *testing.T) {
+	snapshot := NewAuditSnapshot(metadata.AuditResource{ResourceType: metadata.InstanceAssociationRes, ResourceID: 1}, "")
+	asst := &metadata.InstanceAssociationOpDetail{
+		AssociationOpDetail: metadata.AssociationOpDetail{AssociationID: "host_run_app"},
+		TargetModelID:       "app",
+		TargetInstanceID:    2,
+	}
+
+	snapshot.Apply(&metadata.AuditLog{ID: 1, Action: metadata.AuditCreate, OperationDetail: asst})
+	require.True(t, snapshot.Exists)
+	require.Len(t, snapshot.Associations, 1)
+
+	snapshot.Apply(&metadata.AuditLog{ID: 2, Action: metadata.AuditDelete, OperationDetail: asst})
+	require.False(t, snapshot.Exists)
+	require.Len(t, snapshot.Associations, 0)
+}
+
+func TestDiffAuditSnapshot(t *testing.T) {
+	from := &AuditSnapshot{
+		Exists:    true,
+		Data:      mapstr.MapStr{"bk_host_name": "a", "bk_comment": "c"},
+		Processes: map[int64]mapstr.MapStr{1: {"bk_process_name": "p1"}, 2: {"bk_process_name": "p2"}},
+	}
+	to := &AuditSnapshot{
+		Exists:    true,
+		Data:      mapstr.MapStr{"bk_host_name": "a", "operator": "x"},
+		Processes: map[int64]mapstr.MapStr{1: {"bk_process_name": "p3"}},
+	}
+
+	diff := DiffAuditSnapshot(from, to)
+	require.Equal(t, []AuditFieldChange{
+		{Field: "bk_comment", PreValue: "c"},
+		{Field: "operator", CurValue: "x"},
+		{Field: "processes.1.bk_process_name", PreValue: "p1", CurValue: "p3"},
+		{Field: "processes.2", PreValue: mapstr.MapStr{"bk_process_name": "p2"}, CurValue: mapstr.MapStr(nil)},
+	}, diff.Changes)
+
+	diff = DiffAuditSnapshot(to, to)
+	require.Empty(t, diff.Changes)
+}
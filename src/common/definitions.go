@@ -139,6 +139,15 @@ const (
 	// BKInnerObjIDProject the inner object
 	BKInnerObjIDProject = "bk_project"
 
+	// BKInnerObjIDKubeCluster the inner object of kube cluster, it's only used to define custom attributes
+	BKInnerObjIDKubeCluster = "bk_kube_cluster"
+
+	// BKInnerObjIDKubeNamespace the inner object of kube namespace, it's only used to define custom attributes
+	BKInnerObjIDKubeNamespace = "bk_kube_namespace"
+
+	// BKInnerObjIDKubeWorkload the inner object of kube workload, it's only used to define custom attributes
+	BKInnerObjIDKubeWorkload = "bk_kube_workload"
+
 	// BKInnerObjIDSwitch the inner object
 	BKInnerObjIDSwitch = "bk_switch"
 	// BKInnerObjIDRouter the inner object
@@ -451,6 +460,12 @@ const (
 	// BKInstIDField the inst id field
 	BKInstIDField = "bk_inst_id"
 
+	// BKDynamicGroupIDField the dynamic group id field
+	BKDynamicGroupIDField = "dynamic_group_id"
+
+	// BKDynamicGroupMaterializedField the dynamic group materialized field
+	BKDynamicGroupMaterializedField = "materialized"
+
 	// BKInstNameField the inst name field
 	BKInstNameField = "bk_inst_name"
 
@@ -553,6 +568,15 @@ const (
 	// BKExtendResourceNameField the audit extend resource name field
 	BKExtendResourceNameField = "extend_resource_name"
 
+	// BKAuditChainSeqField the audit hash chain sequence field
+	BKAuditChainSeqField = "chain_seq"
+
+	// BKAuditPrevHashField the audit previous chain hash field
+	BKAuditPrevHashField = "prev_hash"
+
+	// BKAuditChainHashField the audit chain hash field
+	BKAuditChainHashField = "chain_hash"
+
 	// BKLabelField the audit resource name field
 	BKLabelField = "label"
 
@@ -1595,6 +1619,9 @@ const (
 const (
 	// OperationCustom TODO
 	OperationCustom = "custom"
+	// OperationSnapshot is the report type of the chart whose data is the daily snapshot of the instance count that
+	// matches the chart filter, grouped by the chart field
+	OperationSnapshot = "snapshot"
 	// OperationReportType TODO
 	OperationReportType = "report_type"
 	// OperationConfigID TODO
@@ -1672,10 +1699,13 @@ const (
 	BKStatusDetail               = "bk_status_detail"
 	BKLastEditor                 = "bk_last_editor"
 	BKSecretID                   = "bk_secret_id"
+	BKCloudEndpoint              = "bk_endpoint"
 	BKVpcID                      = "bk_vpc_id"
 	BKVpcName                    = "bk_vpc_name"
 	BKRegion                     = "bk_region"
 	BKCloudSyncVpcs              = "bk_sync_vpcs"
+	BKCloudResourceMappings      = "bk_resource_mappings"
+	BKCloudDryRun                = "bk_dry_run"
 
 	// 是否为被销毁的云主机
 	IsDestroyedCloudHost = "is_destroyed_cloud_host"
@@ -28,12 +28,52 @@ func init() {
 	// 先注册未规范化的索引，如果索引出现冲突旧，删除未规范化的索引
 	registerIndexes(common.BKTableNameAuditLog, deprecatedAuditLogIndexes)
 	registerIndexes(common.BKTableNameAuditLog, commAuditLogIndexes)
+	registerIndexes(common.BKTableNameAuditLogChain, commAuditLogChainIndexes)
+	registerIndexes(common.BKTableNameAuditLogArchive, commAuditLogArchiveIndexes)
 
 }
 
 //  新加和修改后的索引,索引名字一定要用对应的前缀，CCLogicUniqueIdxNamePrefix|common.CCLogicIndexNamePrefix
 
-var commAuditLogIndexes = []types.Index{}
+var commAuditLogIndexes = []types.Index{
+	{
+		Name: common.CCLogicIndexNamePrefix + "supplierAccount_chainSeq",
+		Keys: bson.D{
+			{common.BkSupplierAccount, 1},
+			{common.BKAuditChainSeqField, 1},
+		},
+		Background: true,
+	},
+}
+
+var commAuditLogChainIndexes = []types.Index{
+	{
+		Name: common.CCLogicUniqueIdxNamePrefix + "supplierAccount",
+		Keys: bson.D{
+			{common.BkSupplierAccount, 1},
+		},
+		Background: true,
+		Unique:     true,
+	},
+}
+
+var commAuditLogArchiveIndexes = []types.Index{
+	{
+		Name: common.CCLogicUniqueIdxNamePrefix + "id",
+		Keys: bson.D{
+			{common.BKFieldID, 1},
+		},
+		Background: true,
+		Unique:     true,
+	},
+	{
+		Name: common.CCLogicIndexNamePrefix + "operationTime",
+		Keys: bson.D{
+			{common.BKOperationTimeField, 1},
+		},
+		Background: true,
+	},
+}
 
 // deprecated 未规范化前的索引，只允许删除不允许新加和修改，
 var deprecatedAuditLogIndexes = []types.Index{
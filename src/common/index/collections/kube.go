@@ -41,6 +41,11 @@ func init() {
 	for _, table := range workLoadTables {
 		registerIndexes(table, commWorkLoadIndexes)
 	}
+
+	// namespace resources such as services have the same unique keys as workloads
+	for _, table := range kubetypes.GetNsResourceTables() {
+		registerIndexes(table, commWorkLoadIndexes)
+	}
 }
 
 var commWorkLoadIndexes = []types.Index{
@@ -209,6 +209,13 @@ type AuditLog struct {
 	RequestID string `json:"rid,omitempty" bson:"rid,omitempty"`
 	// todo ExtendResourceName for the temporary solution of ipv6
 	ExtendResourceName string `json:"extend_resource_name" bson:"extend_resource_name"`
+	// ChainSeq is the sequence of the audit log in the hash chain of its supplier account, the following chain
+	// fields are empty if the audit log is not sealed into the hash chain yet
+	ChainSeq int64 `json:"chain_seq,omitempty" bson:"chain_seq,omitempty"`
+	// PrevHash is the chain hash of the previous audit log in the hash chain
+	PrevHash string `json:"prev_hash,omitempty" bson:"prev_hash,omitempty"`
+	// ChainHash is the hash of this audit log chained to the previous audit log
+	ChainHash string `json:"chain_hash,omitempty" bson:"chain_hash,omitempty"`
 }
 
 type bsonAuditLog struct {
@@ -227,6 +234,9 @@ type bsonAuditLog struct {
 	AppCode            string          `json:"code" bson:"code"`
 	RequestID          string          `json:"rid" bson:"rid"`
 	ExtendResourceName string          `json:"extend_resource_name" bson:"extend_resource_name"`
+	ChainSeq           int64           `json:"chain_seq,omitempty" bson:"chain_seq,omitempty"`
+	PrevHash           string          `json:"prev_hash,omitempty" bson:"prev_hash,omitempty"`
+	ChainHash          string          `json:"chain_hash,omitempty" bson:"chain_hash,omitempty"`
 }
 
 type jsonAuditLog struct {
@@ -245,6 +255,9 @@ type jsonAuditLog struct {
 	AppCode            string          `json:"code" bson:"code"`
 	RequestID          string          `json:"rid" bson:"rid"`
 	ExtendResourceName string          `json:"extend_resource_name" bson:"extend_resource_name"`
+	ChainSeq           int64           `json:"chain_seq,omitempty" bson:"chain_seq,omitempty"`
+	PrevHash           string          `json:"prev_hash,omitempty" bson:"prev_hash,omitempty"`
+	ChainHash          string          `json:"chain_hash,omitempty" bson:"chain_hash,omitempty"`
 }
 
 // DetailFactory TODO
@@ -293,6 +306,9 @@ func (auditLog *AuditLog) UnmarshalJSON(data []byte) error {
 	auditLog.AppCode = audit.AppCode
 	auditLog.RequestID = audit.RequestID
 	auditLog.ExtendResourceName = audit.ExtendResourceName
+	auditLog.ChainSeq = audit.ChainSeq
+	auditLog.PrevHash = audit.PrevHash
+	auditLog.ChainHash = audit.ChainHash
 
 	if audit.OperationDetail == nil {
 		return nil
@@ -357,6 +373,9 @@ func (auditLog *AuditLog) UnmarshalBSON(data []byte) error {
 	auditLog.AppCode = audit.AppCode
 	auditLog.RequestID = audit.RequestID
 	auditLog.ExtendResourceName = audit.ExtendResourceName
+	auditLog.ChainSeq = audit.ChainSeq
+	auditLog.PrevHash = audit.PrevHash
+	auditLog.ChainHash = audit.ChainHash
 
 	if audit.OperationDetail == nil {
 		return nil
@@ -416,6 +435,9 @@ func (auditLog AuditLog) MarshalBSON() ([]byte, error) {
 	audit.AppCode = auditLog.AppCode
 	audit.RequestID = auditLog.RequestID
 	audit.ExtendResourceName = auditLog.ExtendResourceName
+	audit.ChainSeq = auditLog.ChainSeq
+	audit.PrevHash = auditLog.PrevHash
+	audit.ChainHash = auditLog.ChainHash
 	var err error
 	switch val := auditLog.OperationDetail.(type) {
 	default:
@@ -756,6 +778,14 @@ const (
 	KubeWorkload ResourceType = "kube_workload"
 	// KubePod kube pod audit resource type
 	KubePod ResourceType = "kube_pod"
+	// KubeService kube service audit resource type
+	KubeService ResourceType = "kube_service"
+	// KubeIngress kube ingress audit resource type
+	KubeIngress ResourceType = "kube_ingress"
+	// KubeConfigMap kube configmap audit resource type
+	KubeConfigMap ResourceType = "kube_configmap"
+	// KubeSecret kube secret audit resource type
+	KubeSecret ResourceType = "kube_secret"
 
 	// QuotedInst is quoted instance related audit resource type
 	QuotedInst ResourceType = "quoted_inst"
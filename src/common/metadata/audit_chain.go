@@ -0,0 +1,305 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+)
+
+// AuditChainGenesisHash is the previous hash of the first audit log in the hash chain of a supplier account
+const AuditChainGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// DefaultAuditUnsealedTimeoutMinutes is the default minutes that an audit log can stay unsealed after it is created.
+// audit logs are sealed asynchronously by the cache service after they are inserted, an audit log can be modified
+// without breaking the chain before it is sealed, so audit logs that are still unsealed long after they are created
+// are reported as a broken link.
+const DefaultAuditUnsealedTimeoutMinutes = 10
+
+// auditChainContent is the content of the audit log that is hashed into the hash chain, the field order must not be
+// changed, otherwise the chain hash of the sealed audit logs can not be verified.
+type auditChainContent struct {
+	ChainSeq           int64         `json:"chain_seq"`
+	PrevHash           string        `json:"prev_hash"`
+	ID                 int64         `json:"id"`
+	SupplierAccount    string        `json:"bk_supplier_account"`
+	AuditType          AuditType     `json:"audit_type"`
+	User               string        `json:"user"`
+	ResourceType       ResourceType  `json:"resource_type"`
+	Action             ActionType    `json:"action"`
+	OperateFrom        string        `json:"operate_from"`
+	OperationTime      int64         `json:"operation_time"`
+	BusinessID         int64         `json:"bk_biz_id"`
+	ResourceID         interface{}   `json:"resource_id"`
+	ResourceName       string        `json:"resource_name"`
+	AppCode            string        `json:"code"`
+	RequestID          string        `json:"rid"`
+	ExtendResourceName string        `json:"extend_resource_name"`
+	OperationDetail    DetailFactory `json:"operation_detail"`
+}
+
+// CalcChainHash calculate the chain hash of the audit log by its content, chain sequence and previous hash.
+// the operation time is hashed in milliseconds, which is the precision of the time stored in db.
+func (auditLog *AuditLog) CalcChainHash() (string, error) {
+	content := auditChainContent{
+		ChainSeq:           auditLog.ChainSeq,
+		PrevHash:           auditLog.PrevHash,
+		ID:                 auditLog.ID,
+		SupplierAccount:    auditLog.SupplierAccount,
+		AuditType:          auditLog.AuditType,
+		User:               auditLog.User,
+		ResourceType:       auditLog.ResourceType,
+		Action:             auditLog.Action,
+		OperateFrom:        string(auditLog.OperateFrom),
+		OperationTime:      auditLog.OperationTime.UnixMilli(),
+		BusinessID:         auditLog.BusinessID,
+		ResourceID:         auditLog.ResourceID,
+		ResourceName:       auditLog.ResourceName,
+		AppCode:            auditLog.AppCode,
+		RequestID:          auditLog.RequestID,
+		ExtendResourceName: auditLog.ExtendResourceName,
+		OperationDetail:    auditLog.OperationDetail,
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("marshal audit log %d chain content failed, err: %v", auditLog.ID, err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditChainHead is the head of the audit log hash chain of a supplier account
+type AuditChainHead struct {
+	SupplierAccount string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	LastSeq         int64     `json:"last_seq" bson:"last_seq"`
+	LastID          int64     `json:"last_id" bson:"last_id"`
+	LastHash        string    `json:"last_hash" bson:"last_hash"`
+	LastTime        time.Time `json:"last_time" bson:"last_time"`
+}
+
+// VerifyAuditChainOption verify audit log hash chain option
+type VerifyAuditChainOption struct {
+	// StartSeq is the chain sequence that the verification starts from, starts from the first sequence if not set
+	StartSeq int64 `json:"start_seq"`
+	// AllowGaps allows the audit logs in the chain to be missing, which may be removed by the audit log retention
+	// job, the audit logs after the gap are still verified by their own chain hash.
+	AllowGaps bool `json:"allow_gaps"`
+	// UnsealedTimeoutMinutes is the minutes that an audit log can stay unsealed after it is created, audit logs that
+	// are unsealed for a longer time are reported as broken, DefaultAuditUnsealedTimeoutMinutes is used if not set
+	UnsealedTimeoutMinutes int64 `json:"unsealed_timeout_minutes"`
+}
+
+// Validate VerifyAuditChainOption
+func (o *VerifyAuditChainOption) Validate() errors.RawErrorInfo {
+	if o.StartSeq < 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{"start_seq"},
+		}
+	}
+
+	if o.UnsealedTimeoutMinutes < 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{"unsealed_timeout_minutes"},
+		}
+	}
+	return errors.RawErrorInfo{}
+}
+
+// UnsealedTimeout returns the duration that an audit log can stay unsealed after it is created
+func (o *VerifyAuditChainOption) UnsealedTimeout() time.Duration {
+	if o.UnsealedTimeoutMinutes == 0 {
+		return DefaultAuditUnsealedTimeoutMinutes * time.Minute
+	}
+	return time.Duration(o.UnsealedTimeoutMinutes) * time.Minute
+}
+
+// AuditChainBrokenReason is the reason why the audit log hash chain is broken
+type AuditChainBrokenReason string
+
+const (
+	// AuditChainHashMismatch the content of the audit log is modified after it is sealed
+	AuditChainHashMismatch AuditChainBrokenReason = "hash_mismatch"
+	// AuditChainLinkMismatch the previous hash of the audit log does not match the previous audit log
+	AuditChainLinkMismatch AuditChainBrokenReason = "link_mismatch"
+	// AuditChainMissing the audit logs in the chain are missing
+	AuditChainMissing AuditChainBrokenReason = "missing"
+	// AuditChainHeadMismatch the last audit logs in the chain do not match the chain head
+	AuditChainHeadMismatch AuditChainBrokenReason = "head_mismatch"
+	// AuditChainUnsealed the audit log is not sealed into the chain long after it is created, it may be modified
+	// before it is sealed, or the sealing is stopped
+	AuditChainUnsealed AuditChainBrokenReason = "unsealed"
+)
+
+// AuditChainBrokenLink is the first broken link found in the audit log hash chain
+type AuditChainBrokenLink struct {
+	ChainSeq int64                  `json:"chain_seq"`
+	AuditID  int64                  `json:"id"`
+	Reason   AuditChainBrokenReason `json:"reason"`
+	Message  string                 `json:"message"`
+}
+
+// AuditChainVerifyResult is the result of verifying the audit log hash chain of a supplier account
+type AuditChainVerifyResult struct {
+	SupplierAccount string `json:"bk_supplier_account"`
+	// Verified is the count of the verified audit logs
+	Verified int64 `json:"verified"`
+	// Gaps is the count of the missing audit logs that are allowed by the AllowGaps option
+	Gaps int64 `json:"gaps"`
+	// LastSeq is the chain sequence of the last verified audit log
+	LastSeq int64 `json:"last_seq"`
+	// BrokenLink is the first broken link in the chain, it is nil if the chain is intact
+	BrokenLink *AuditChainBrokenLink `json:"broken_link"`
+}
+
+// VerifyAuditChainResponse verify audit log hash chain response
+type VerifyAuditChainResponse struct {
+	BaseResp `json:",inline"`
+	Data     *AuditChainVerifyResult `json:"data"`
+}
+
+// AuditChainVerifier verifies the audit logs of a supplier account one by one in chain sequence order
+type AuditChainVerifier struct {
+	opt      VerifyAuditChainOption
+	prevSeq  int64
+	prevHash string
+	result   *AuditChainVerifyResult
+}
+
+// NewAuditChainVerifier new audit log hash chain verifier
+func NewAuditChainVerifier(supplierAccount string, opt VerifyAuditChainOption) *AuditChainVerifier {
+	v := &AuditChainVerifier{
+		opt:    opt,
+		result: &AuditChainVerifyResult{SupplierAccount: supplierAccount},
+	}
+
+	// the previous hash is unknown if the verification does not start from the first sequence, then the link of the
+	// first verified audit log is not checked
+	if opt.StartSeq > 1 {
+		v.prevSeq = opt.StartSeq - 1
+	} else {
+		v.prevHash = AuditChainGenesisHash
+	}
+	return v
+}
+
+// Verify the next audit log in the chain, returns false if the chain is broken
+func (v *AuditChainVerifier) Verify(auditLog *AuditLog) bool {
+	if v.result.BrokenLink != nil {
+		return false
+	}
+
+	hash, err := auditLog.CalcChainHash()
+	if err != nil {
+		v.broken(auditLog.ChainSeq, auditLog.ID, AuditChainHashMismatch, err.Error())
+		return false
+	}
+
+	if hash != auditLog.ChainHash {
+		v.broken(auditLog.ChainSeq, auditLog.ID, AuditChainHashMismatch,
+			fmt.Sprintf("chain hash is %s, but the calculated hash is %s", auditLog.ChainHash, hash))
+		return false
+	}
+
+	expectedSeq := v.prevSeq + 1
+	switch {
+	case auditLog.ChainSeq < expectedSeq:
+		v.broken(auditLog.ChainSeq, auditLog.ID, AuditChainLinkMismatch,
+			fmt.Sprintf("chain sequence is duplicated, expected sequence is %d", expectedSeq))
+		return false
+
+	case auditLog.ChainSeq > expectedSeq:
+		if !v.opt.AllowGaps {
+			v.broken(expectedSeq, 0, AuditChainMissing, fmt.Sprintf("audit logs from chain sequence %d to %d are "+
+				"missing", expectedSeq, auditLog.ChainSeq-1))
+			return false
+		}
+		v.result.Gaps += auditLog.ChainSeq - expectedSeq
+
+	default:
+		if v.prevHash != "" && auditLog.PrevHash != v.prevHash {
+			v.broken(auditLog.ChainSeq, auditLog.ID, AuditChainLinkMismatch,
+				fmt.Sprintf("previous hash is %s, but the previous chain hash is %s", auditLog.PrevHash, v.prevHash))
+			return false
+		}
+	}
+
+	v.prevSeq = auditLog.ChainSeq
+	v.prevHash = auditLog.ChainHash
+	v.result.Verified++
+	v.result.LastSeq = auditLog.ChainSeq
+	return true
+}
+
+// Finish the verification by checking the last verified audit log against the chain head, the audit logs must be
+// verified up to the chain sequence of the head, head is nil if no audit log has been sealed yet.
+func (v *AuditChainVerifier) Finish(head *AuditChainHead) *AuditChainVerifyResult {
+	if v.result.BrokenLink != nil || head == nil {
+		return v.result
+	}
+
+	switch {
+	case head.LastSeq > v.prevSeq:
+		if !v.opt.AllowGaps {
+			v.broken(v.prevSeq+1, 0, AuditChainHeadMismatch, fmt.Sprintf("audit logs from chain sequence %d to "+
+				"head sequence %d are missing", v.prevSeq+1, head.LastSeq))
+			break
+		}
+		v.result.Gaps += head.LastSeq - v.prevSeq
+
+	case head.LastSeq < v.prevSeq:
+		v.broken(v.prevSeq, 0, AuditChainHeadMismatch, fmt.Sprintf("chain sequence exceeds head sequence %d",
+			head.LastSeq))
+
+	default:
+		if v.result.Verified > 0 && head.LastHash != v.prevHash {
+			v.broken(head.LastSeq, head.LastID, AuditChainHeadMismatch, fmt.Sprintf("last chain hash is %s, "+
+				"but the head hash is %s", v.prevHash, head.LastHash))
+		}
+	}
+
+	return v.result
+}
+
+// VerifyUnsealed reports the audit log that is still unsealed after the unsealed timeout as broken, it must be
+// called after the sealed audit logs are verified, so that the broken links in the chain are reported first.
+func (v *AuditChainVerifier) VerifyUnsealed(auditLog *AuditLog) {
+	if v.result.BrokenLink != nil {
+		return
+	}
+
+	v.broken(0, auditLog.ID, AuditChainUnsealed, fmt.Sprintf("audit log created at %s is not sealed in %s",
+		auditLog.OperationTime.Format(time.RFC3339), v.opt.UnsealedTimeout()))
+}
+
+func (v *AuditChainVerifier) broken(seq, id int64, reason AuditChainBrokenReason, msg string) {
+	v.result.BrokenLink = &AuditChainBrokenLink{
+		ChainSeq: seq,
+		AuditID:  id,
+		Reason:   reason,
+		Message:  msg,
+	}
+}
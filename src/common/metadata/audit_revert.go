@@ -0,0 +1,87 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+)
+
+// RevertAuditOption is the option to revert the operations recorded by audit logs
+type RevertAuditOption struct {
+	IDs []int64 `json:"id"`
+	// DryRun only returns the preview of the revert operations without applying them
+	DryRun bool `json:"dry_run"`
+}
+
+// Validate validates the revert audit option
+func (o *RevertAuditOption) Validate() errors.RawErrorInfo {
+	if len(o.IDs) == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKFieldID},
+		}
+	}
+
+	if len(o.IDs) > common.BKAuditLogPageLimit {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommXXExceedLimit,
+			Args:    []interface{}{common.BKFieldID, common.BKAuditLogPageLimit},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// RevertAuditStatus is the status of the revert operation of an audit log
+type RevertAuditStatus string
+
+const (
+	// RevertAuditReady the operation can be reverted
+	RevertAuditReady RevertAuditStatus = "ready"
+	// RevertAuditReverted the operation has been reverted
+	RevertAuditReverted RevertAuditStatus = "reverted"
+	// RevertAuditConflict the resource has been changed since the operation, so it can not be reverted
+	RevertAuditConflict RevertAuditStatus = "conflict"
+	// RevertAuditUnsupported the operation is not supported to be reverted
+	RevertAuditUnsupported RevertAuditStatus = "unsupported"
+)
+
+// RevertAuditItem is the revert operation of an audit log
+type RevertAuditItem struct {
+	AuditID      int64        `json:"audit_id"`
+	ResourceType ResourceType `json:"resource_type"`
+	ResourceID   int64        `json:"resource_id"`
+	ObjID        string       `json:"bk_obj_id"`
+	// Action is the inverse action of the audited operation, which is applied to revert the operation
+	Action ActionType `json:"action"`
+	// Data is the data to update for update action, or the data to create for create action
+	Data    mapstr.MapStr     `json:"data,omitempty"`
+	Status  RevertAuditStatus `json:"status"`
+	Message string            `json:"message,omitempty"`
+	// NewInstID is the id of the recreated instance when reverting a delete operation, deleted instance is
+	// recreated with a new id, and its associations are not restored
+	NewInstID int64 `json:"new_inst_id,omitempty"`
+}
+
+// RevertAuditResult is the result of reverting the operations recorded by audit logs, the operations are reverted
+// only when all of them are ready to be reverted, in the order of newest to oldest
+type RevertAuditResult struct {
+	Reverted bool              `json:"reverted"`
+	Items    []RevertAuditItem `json:"items"`
+}
@@ -0,0 +1,152 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+
+	"github.com/coccyx/timeparser"
+)
+
+// AuditResource is the resource whose state is reconstructed from its audit logs
+type AuditResource struct {
+	ResourceType ResourceType `json:"resource_type"`
+	ResourceID   int64        `json:"resource_id"`
+	// ObjID is the object id of the instance, it is required when the resource type is model_instance,
+	// mainline_instance or instance_association(the object id of the source instance)
+	ObjID string `json:"bk_obj_id"`
+}
+
+// Validate validates the audit resource
+func (r *AuditResource) Validate() errors.RawErrorInfo {
+	switch r.ResourceType {
+	case HostRes, SetRes, ModuleRes, ServiceInstanceRes:
+	case ModelInstanceRes, MainlineInstanceRes, InstanceAssociationRes:
+		if len(r.ObjID) == 0 {
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{common.BKObjIDField},
+			}
+		}
+	default:
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{common.BKResourceTypeField},
+		}
+	}
+
+	if r.ResourceID <= 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{common.BKResourceIDField},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// ToAuditCond converts the audit resource to the condition to search its audit logs
+func (r *AuditResource) ToAuditCond() mapstr.MapStr {
+	cond := mapstr.MapStr{
+		common.BKResourceTypeField: r.ResourceType,
+		common.BKResourceIDField:   r.ResourceID,
+	}
+
+	switch r.ResourceType {
+	case ModelInstanceRes, MainlineInstanceRes:
+		cond[common.BKOperationDetailField+"."+common.BKObjIDField] = r.ObjID
+	case InstanceAssociationRes:
+		cond[common.BKOperationDetailField+"."+"src_obj_id"] = r.ObjID
+	}
+	return cond
+}
+
+// AuditSnapshotOption is the option to reconstruct the state of a resource at a time point from its audit logs
+type AuditSnapshotOption struct {
+	AuditResource `json:",inline"`
+	// Time is the time point to reconstruct the resource state at, e.g. 2026-03-01 00:00:00
+	Time string `json:"time"`
+}
+
+// Validate validates the audit snapshot option
+func (o *AuditSnapshotOption) Validate() errors.RawErrorInfo {
+	if rawErr := o.AuditResource.Validate(); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if _, rawErr := ParseAuditTime("time", o.Time); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// AuditSnapshotDiffOption is the option to compare the states of a resource between two time points
+type AuditSnapshotDiffOption struct {
+	AuditResource `json:",inline"`
+	StartTime     string `json:"start_time"`
+	EndTime       string `json:"end_time"`
+}
+
+// Validate validates the audit snapshot diff option
+func (o *AuditSnapshotDiffOption) Validate() errors.RawErrorInfo {
+	if rawErr := o.AuditResource.Validate(); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	start, rawErr := ParseAuditTime("start_time", o.StartTime)
+	if rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	end, rawErr := ParseAuditTime("end_time", o.EndTime)
+	if rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if start.After(end) {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{"start_time"},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// ParseAuditTime parses the time point of the audit snapshot in the same way as the audit operation time condition
+func ParseAuditTime(field, value string) (time.Time, errors.RawErrorInfo) {
+	if len(value) == 0 {
+		return time.Time{}, errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{field},
+		}
+	}
+
+	t, err := timeparser.TimeParserInLocation(value, time.Local)
+	if err != nil {
+		return time.Time{}, errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{field},
+		}
+	}
+	return t.Local(), errors.RawErrorInfo{}
+}
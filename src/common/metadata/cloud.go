@@ -27,11 +27,13 @@ import (
 
 // CloudAccount 云账户
 type CloudAccount struct {
-	AccountName string    `json:"bk_account_name" bson:"bk_account_name"`
-	CloudVendor string    `json:"bk_cloud_vendor" bson:"bk_cloud_vendor"`
-	AccountID   int64     `json:"bk_account_id" bson:"bk_account_id"`
-	SecretID    string    `json:"bk_secret_id" bson:"bk_secret_id"`
-	SecretKey   string    `json:"bk_secret_key" bson:"bk_secret_key"`
+	AccountName string `json:"bk_account_name" bson:"bk_account_name"`
+	CloudVendor string `json:"bk_cloud_vendor" bson:"bk_cloud_vendor"`
+	AccountID   int64  `json:"bk_account_id" bson:"bk_account_id"`
+	SecretID    string `json:"bk_secret_id" bson:"bk_secret_id"`
+	SecretKey   string `json:"bk_secret_key" bson:"bk_secret_key"`
+	// Endpoint 云厂商接入地址，仅私有云（如OpenStack的keystone地址）需要设置
+	Endpoint    string    `json:"bk_endpoint,omitempty" bson:"bk_endpoint,omitempty"`
 	Description string    `json:"bk_description" bson:"bk_description"`
 	OwnerID     string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
 	Creator     string    `json:"bk_creator" bson:"bk_creator"`
@@ -76,6 +78,13 @@ func (c *CloudAccount) Validate() (rawError errors.RawErrorInfo) {
 		}
 	}
 
+	if util.InStrArr(EndpointRequiredCloudVendors, c.CloudVendor) && c.Endpoint == "" {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKCloudEndpoint},
+		}
+	}
+
 	return errors.RawErrorInfo{}
 }
 
@@ -91,17 +100,26 @@ type CloudAccountWithExtraInfo struct {
 const (
 	AWS          string = "1"
 	TencentCloud string = "2"
+	// OpenStack 企业私有云，目前支持兼容OpenStack接口的私有云
+	OpenStack   string = "5"
+	AliCloud    string = "9"
+	HuaweiCloud string = "15"
 )
 
 // SupportedCloudVendors 支持的云厂商
 // 实现了相应的云厂商插件
-var SupportedCloudVendors = []string{AWS, TencentCloud}
+var SupportedCloudVendors = []string{AWS, TencentCloud, OpenStack, AliCloud, HuaweiCloud}
+
+// EndpointRequiredCloudVendors 需要设置接入地址的云厂商
+var EndpointRequiredCloudVendors = []string{OpenStack}
 
 // 云同步任务同步状态
 const (
 	CloudSyncSuccess    string = "cloud_sync_success"
 	CloudSyncFail       string = "cloud_sync_fail"
 	CloudSyncInProgress string = "cloud_sync_in_progress"
+	// CloudSyncDryRun 试运行，只记录同步差异，不写入任何数据
+	CloudSyncDryRun string = "cloud_sync_dry_run"
 )
 
 // CloudAccountConf 云厂商账户配置
@@ -110,6 +128,7 @@ type CloudAccountConf struct {
 	VendorName string `json:"bk_cloud_vendor" bson:"bk_cloud_vendor"`
 	SecretID   string `json:"bk_secret_id" bson:"bk_secret_id"`
 	SecretKey  string `json:"bk_secret_key" bson:"bk_secret_key"`
+	Endpoint   string `json:"bk_endpoint,omitempty" bson:"bk_endpoint,omitempty"`
 }
 
 // SearchCloudOption TODO
@@ -187,6 +206,7 @@ type CloudAccountVerify struct {
 	SecretID    string `json:"bk_secret_id"`
 	SecretKey   string `json:"bk_secret_key"`
 	CloudVendor string `json:"bk_cloud_vendor"`
+	Endpoint    string `json:"bk_endpoint"`
 }
 
 // SearchAccountValidityOption TODO
@@ -247,10 +267,14 @@ type CloudSyncTask struct {
 	SyncAll           bool           `json:"bk_sync_all" bson:"bk_sync_all"`
 	SyncAllDir        int64          `json:"bk_sync_all_dir" bson:"bk_sync_all_dir"`
 	SyncVpcs          []VpcSyncInfo  `json:"bk_sync_vpcs" bson:"bk_sync_vpcs"`
-	Creator           string         `json:"bk_creator" bson:"bk_creator"`
-	LastEditor        string         `json:"bk_last_editor" bson:"bk_last_editor"`
-	CreateTime        time.Time      `json:"create_time" bson:"create_time"`
-	LastTime          time.Time      `json:"last_time" bson:"last_time"`
+	// ResourceMappings 主机以外的云资源同步配置，为空时只同步云主机
+	ResourceMappings []CloudResourceMapping `json:"bk_resource_mappings,omitempty" bson:"bk_resource_mappings,omitempty"`
+	// DryRun 为true时任务只计算同步差异并记录到同步历史中，不实际写入主机数据
+	DryRun     bool      `json:"bk_dry_run" bson:"bk_dry_run"`
+	Creator    string    `json:"bk_creator" bson:"bk_creator"`
+	LastEditor string    `json:"bk_last_editor" bson:"bk_last_editor"`
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+	LastTime   time.Time `json:"last_time" bson:"last_time"`
 }
 
 // VpcSyncInfo TODO
@@ -265,6 +289,130 @@ type VpcSyncInfo struct {
 	Destroyed bool `json:"destroyed" bson:"destroyed"`
 }
 
+// CloudResourceKind 主机以外的云资源类型
+type CloudResourceKind string
+
+const (
+	// CloudLoadBalancer 负载均衡
+	CloudLoadBalancer CloudResourceKind = "load_balancer"
+	// CloudDisk 云硬盘
+	CloudDisk CloudResourceKind = "disk"
+	// CloudSecurityGroup 安全组
+	CloudSecurityGroup CloudResourceKind = "security_group"
+)
+
+// SupportedCloudResourceKinds 支持同步的主机以外的云资源类型
+var SupportedCloudResourceKinds = []CloudResourceKind{CloudLoadBalancer, CloudDisk, CloudSecurityGroup}
+
+// 云资源可用于映射的属性，云资源的其他属性以厂商返回的原始名称提供，如size、address
+const (
+	CloudResourceIDField     = "id"
+	CloudResourceNameField   = "name"
+	CloudResourceRegionField = "region"
+	CloudResourceVpcIDField  = "vpc_id"
+	CloudResourceStatusField = "status"
+)
+
+// CloudResourceMapping 云资源到用户自定义模型的同步配置
+type CloudResourceMapping struct {
+	Kind  CloudResourceKind `json:"bk_resource_kind" bson:"bk_resource_kind"`
+	ObjID string            `json:"bk_obj_id" bson:"bk_obj_id"`
+	// IDField 模型中存放云资源id的属性，用于匹配已同步的实例，为空时使用bk_cloud_inst_id
+	IDField string `json:"id_field" bson:"id_field"`
+	// FieldMapping 云资源属性到模型属性的映射，key为云资源属性，value为模型属性
+	FieldMapping map[string]string `json:"field_mapping" bson:"field_mapping"`
+	// HostAsstID 模型与主机之间的关联关系唯一标识(bk_obj_asst_id)，为空时不同步与主机的关联
+	HostAsstID string `json:"host_obj_asst_id" bson:"host_obj_asst_id"`
+}
+
+// GetIDField 获取模型中存放云资源id的属性
+func (c *CloudResourceMapping) GetIDField() string {
+	if c.IDField == "" {
+		return common.BKCloudInstIDField
+	}
+	return c.IDField
+}
+
+// Validate cloud resource mapping validate
+func (c *CloudResourceMapping) Validate() (rawError errors.RawErrorInfo) {
+	supported := false
+	for _, kind := range SupportedCloudResourceKinds {
+		if c.Kind == kind {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{"bk_resource_kind"},
+		}
+	}
+
+	if c.ObjID == "" {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKObjIDField},
+		}
+	}
+
+	// 主机和管控区域由云主机同步维护，不能作为其他云资源的同步目标
+	if c.ObjID == common.BKInnerObjIDHost || c.ObjID == common.BKInnerObjIDPlat {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{common.BKObjIDField},
+		}
+	}
+
+	for _, field := range c.FieldMapping {
+		if field == c.GetIDField() {
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsInvalid,
+				Args:    []interface{}{"field_mapping"},
+			}
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// CloudResourcesInfo 主机以外的云资源列表
+type CloudResourcesInfo struct {
+	Count       int64            `json:"count" bson:"count"`
+	ResourceSet []*CloudResource `json:"resource_set" bson:"resource_set"`
+}
+
+// CloudResource 主机以外的云资源，如负载均衡、云硬盘、安全组
+type CloudResource struct {
+	ResourceID string `json:"id" bson:"id"`
+	Name       string `json:"name" bson:"name"`
+	Region     string `json:"region" bson:"region"`
+	VpcID      string `json:"vpc_id" bson:"vpc_id"`
+	Status     string `json:"status" bson:"status"`
+	// InstanceIDs 云资源关联的云主机实例id
+	InstanceIDs []string `json:"instance_ids" bson:"instance_ids"`
+	// Attributes 云资源的其他属性
+	Attributes map[string]interface{} `json:"attributes" bson:"attributes"`
+}
+
+// GetField 获取云资源用于映射的属性值
+func (c *CloudResource) GetField(field string) (interface{}, bool) {
+	switch field {
+	case CloudResourceIDField:
+		return c.ResourceID, true
+	case CloudResourceNameField:
+		return c.Name, true
+	case CloudResourceRegionField:
+		return c.Region, true
+	case CloudResourceVpcIDField:
+		return c.VpcID, true
+	case CloudResourceStatusField:
+		return c.Status, true
+	}
+	val, exists := c.Attributes[field]
+	return val, exists
+}
+
 // MultipleCloudSyncTask TODO
 type MultipleCloudSyncTask struct {
 	Count int64           `json:"count"`
@@ -395,6 +543,51 @@ type SyncStatusDesc struct {
 type SyncDetail struct {
 	NewAdd SyncSuccessInfo `json:"new_add" bson:"new_add"`
 	Update SyncSuccessInfo `json:"update" bson:"update"`
+	// Destroyed 试运行时将被标记为已销毁的主机
+	Destroyed SyncSuccessInfo `json:"destroyed" bson:"destroyed"`
+	// Resources 主机以外的云资源同步详情，key为同步目标模型
+	Resources map[string]*SyncResourceInfo `json:"resources,omitempty" bson:"resources,omitempty"`
+	// Diff 试运行时计算出的同步差异
+	Diff *CloudSyncDiff `json:"diff,omitempty" bson:"diff,omitempty"`
+}
+
+// CloudSyncDiff 云主机同步差异，即执行同步时将要做的变更
+type CloudSyncDiff struct {
+	TaskID int64 `json:"bk_task_id" bson:"bk_task_id"`
+	// NewAdd 将要新增的主机
+	NewAdd []CloudHostDiff `json:"new_add" bson:"new_add"`
+	// Update 将要更新的主机及其字段变更
+	Update []CloudHostDiff `json:"update" bson:"update"`
+	// Destroyed 将要标记为已销毁的主机
+	Destroyed []CloudHostDiff `json:"destroyed" bson:"destroyed"`
+	// DestroyedVpcs 云端已被销毁的vpc
+	DestroyedVpcs []string `json:"destroyed_vpcs" bson:"destroyed_vpcs"`
+}
+
+// CloudHostDiff 单个云主机的同步差异
+type CloudHostDiff struct {
+	InstanceID string             `json:"bk_cloud_inst_id" bson:"bk_cloud_inst_id"`
+	HostID     int64              `json:"bk_host_id,omitempty" bson:"bk_host_id,omitempty"`
+	CloudID    int64              `json:"bk_cloud_id" bson:"bk_cloud_id"`
+	PrivateIP  string             `json:"bk_host_innerip" bson:"bk_host_innerip"`
+	PublicIP   string             `json:"bk_host_outerip" bson:"bk_host_outerip"`
+	Changes    []CloudFieldChange `json:"changes,omitempty" bson:"changes,omitempty"`
+}
+
+// CloudFieldChange 主机字段的变更
+type CloudFieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+// SyncResourceInfo 主机以外的云资源同步详情
+type SyncResourceInfo struct {
+	Kind         CloudResourceKind `json:"bk_resource_kind" bson:"bk_resource_kind"`
+	NewAdd       int64             `json:"new_add" bson:"new_add"`
+	Update       int64             `json:"update" bson:"update"`
+	NewAssociate int64             `json:"new_associate" bson:"new_associate"`
+	DelAssociate int64             `json:"del_associate" bson:"del_associate"`
 }
 
 // SyncSuccessInfo TODO
@@ -20,11 +20,13 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"configcenter/pkg/filter"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/criteria/enumor"
 	"configcenter/src/common/util"
 
 	"github.com/google/uuid"
@@ -300,10 +302,18 @@ type DynamicGroupInfo struct {
 
 	// VariableCondition is dynamic group index variable conditions set.
 	VariableCondition []DynamicGroupInfoCondition `json:"variable_condition" bson:"variable_condition"`
+
+	// Expression is dynamic group conditions in expression format, it supports nested AND/OR rules across objects,
+	// rule field is in {bk_obj_id}.{field} format, eg: host.bk_os_type. It can not be used with the conditions.
+	Expression *filter.Expression `json:"expression,omitempty" bson:"expression,omitempty"`
 }
 
 // Validate validates dynamic group info format, it's OK if conditions empty in this level.
 func (c *DynamicGroupInfo) Validate(objectID string, validatefunc Validatefunc) error {
+	if c.Expression != nil {
+		return c.validateExpression(objectID, validatefunc)
+	}
+
 	err := ValidDynamicGroupCond(c.Condition, objectID, validatefunc, make(map[string]map[string]struct{}))
 	if err != nil {
 		return err
@@ -317,6 +327,140 @@ func (c *DynamicGroupInfo) Validate(objectID string, validatefunc Validatefunc)
 	return nil
 }
 
+// validateExpression validates dynamic group expression, the objects of the expression rules can be the condition
+// types of the dynamic group or the custom objects.
+func (c *DynamicGroupInfo) validateExpression(objectID string, validatefunc Validatefunc) error {
+	if len(c.Condition) != 0 || len(c.VariableCondition) != 0 {
+		return errors.New("expression can not be used with condition or variable_condition")
+	}
+
+	if c.Expression.RuleFactory == nil {
+		return errors.New("expression is empty")
+	}
+
+	types, isSupport := DynamicGroupConditionTypes[objectID]
+	if !isSupport {
+		return fmt.Errorf("not support dynamic group type, %s", objectID)
+	}
+
+	ruleFields := make(map[string]enumor.FieldType)
+	objIDMap := make(map[string]struct{})
+	for _, field := range c.Expression.RuleFields() {
+		objID, _, err := ParseDynamicGroupExprField(field)
+		if err != nil {
+			return err
+		}
+
+		if _, exists := objIDMap[objID]; exists {
+			continue
+		}
+		objIDMap[objID] = struct{}{}
+
+		if _, isSupport = types[objID]; !isSupport && !IsCommon(objID) {
+			return fmt.Errorf("not support expression object[%s] for %s dynamic group", objID, objectID)
+		}
+
+		attributes, err := validatefunc(objID)
+		if err != nil {
+			return fmt.Errorf("validate dynamic group failed, %+v", err)
+		}
+
+		for _, attribute := range attributes {
+			fieldType, exists := dynamicGroupExprFieldTypes[attribute.PropertyType]
+			if !exists {
+				continue
+			}
+			ruleFields[objID+"."+attribute.PropertyID] = fieldType
+		}
+		ruleFields[objID+"."+common.GetInstIDField(objID)] = enumor.Numeric
+		if objID == common.BKInnerObjIDHost {
+			ruleFields[objID+"."+common.BKCloudIDField] = enumor.Numeric
+		}
+	}
+
+	if err := c.Expression.Validate(filter.NewDefaultExprOpt(ruleFields)); err != nil {
+		return fmt.Errorf("invalid expression, %v", err)
+	}
+	return nil
+}
+
+// dynamicGroupExprFieldTypes is the mapping of attribute types to the field types of dynamic group expression,
+// date attribute value is saved as string, so it is compared as string.
+var dynamicGroupExprFieldTypes = map[string]enumor.FieldType{
+	common.FieldTypeSingleChar:   enumor.String,
+	common.FieldTypeLongChar:     enumor.String,
+	common.FieldTypeEnum:         enumor.String,
+	common.FieldTypeEnumMulti:    enumor.String,
+	common.FieldTypeTimeZone:     enumor.String,
+	common.FieldTypeUser:         enumor.String,
+	common.FieldTypeList:         enumor.String,
+	common.FieldTypeDate:         enumor.String,
+	common.FieldTypeIDRule:       enumor.String,
+	common.FieldTypeInt:          enumor.Numeric,
+	common.FieldTypeFloat:        enumor.Numeric,
+	common.FieldTypeOrganization: enumor.Numeric,
+	common.FieldTypeEnumQuote:    enumor.Numeric,
+	common.FieldTypeBool:         enumor.Boolean,
+	common.FieldTypeTime:         enumor.Time,
+}
+
+// ParseDynamicGroupExprField parses dynamic group expression rule field in {bk_obj_id}.{field} format,
+// returns the object id and the field of the object.
+func ParseDynamicGroupExprField(field string) (string, string, error) {
+	objID, objField, found := strings.Cut(field, ".")
+	if !found || len(objID) == 0 || len(objField) == 0 {
+		return "", "", fmt.Errorf("invalid expression field %s, should be in {bk_obj_id}.{field} format", field)
+	}
+	return objID, objField, nil
+}
+
+// GetExprRuleObjects get the objects of the expression rule, rule field is in {bk_obj_id}.{field} format
+func GetExprRuleObjects(rule filter.RuleFactory) ([]string, error) {
+	objIDs := make([]string, 0)
+	objIDMap := make(map[string]struct{})
+	for _, field := range rule.RuleFields() {
+		objID, _, err := ParseDynamicGroupExprField(field)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := objIDMap[objID]; exists {
+			continue
+		}
+		objIDMap[objID] = struct{}{}
+		objIDs = append(objIDs, objID)
+	}
+
+	if len(objIDs) == 0 {
+		return nil, fmt.Errorf("rule %s has no field", rule.WithType())
+	}
+	return objIDs, nil
+}
+
+// TrimExprRuleObject returns a copy of the expression rule with the object prefix of the fields removed
+func TrimExprRuleObject(rule filter.RuleFactory) (filter.RuleFactory, error) {
+	switch typedRule := rule.(type) {
+	case *filter.AtomRule:
+		_, field, err := ParseDynamicGroupExprField(typedRule.Field)
+		if err != nil {
+			return nil, err
+		}
+		return &filter.AtomRule{Field: field, Operator: typedRule.Operator, Value: typedRule.Value}, nil
+	case *filter.CombinedRule:
+		rules := make([]filter.RuleFactory, len(typedRule.Rules))
+		for idx, subRule := range typedRule.Rules {
+			trimmed, err := TrimExprRuleObject(subRule)
+			if err != nil {
+				return nil, err
+			}
+			rules[idx] = trimmed
+		}
+		return &filter.CombinedRule{Condition: typedRule.Condition, Rules: rules}, nil
+	default:
+		return nil, fmt.Errorf("rule type %s is invalid", rule.WithType())
+	}
+}
+
 // GetMapFromDynamicCond get map from dynamic group condition
 func GetMapFromDynamicCond(condArr []DynamicGroupInfoCondition) map[string]map[string]struct{} {
 	result := make(map[string]map[string]struct{})
@@ -410,6 +554,10 @@ type DynamicGroup struct {
 	// Info is dynamic group core conditions information.
 	Info DynamicGroupInfo `json:"info" bson:"info"`
 
+	// Materialized defines whether the members of the dynamic group is maintained incrementally in the dynamic group
+	// member table, the join and leave events of the members can be watched by dynamic_group_member resource.
+	Materialized bool `json:"materialized" bson:"materialized"`
+
 	// CreateUser create user name.
 	CreateUser string `json:"create_user" bson:"create_user"`
 
@@ -439,13 +587,41 @@ func (g *DynamicGroup) Validate(validatefunc Validatefunc) error {
 	}
 
 	// check conditions format.
-	if len(g.Info.Condition) == 0 && len(g.Info.VariableCondition) == 0 {
+	if len(g.Info.Condition) == 0 && len(g.Info.VariableCondition) == 0 && g.Info.Expression == nil {
 		// it's not OK if conditions empty in this level.
-		return errors.New("info.condition and info.variable_condition can not be empty at the same time")
+		return errors.New("info.condition, info.variable_condition and info.expression can not be empty at the " +
+			"same time")
+	}
+
+	// variable conditions are only given when executing, so the members of the dynamic group can not be materialized.
+	if g.Materialized && len(g.Info.VariableCondition) > 0 {
+		return errors.New("materialized dynamic group can not have info.variable_condition")
 	}
 	return g.Info.Validate(g.ObjID, validatefunc)
 }
 
+// DynamicGroupMember is the member of materialized dynamic group, the member is created when the instance joins the
+// dynamic group, and is deleted when the instance leaves the dynamic group.
+type DynamicGroupMember struct {
+	// AppID is application id which dynamic group belongs to.
+	AppID int64 `json:"bk_biz_id" bson:"bk_biz_id"`
+
+	// GroupID is the unique id of the dynamic group.
+	GroupID string `json:"dynamic_group_id" bson:"dynamic_group_id"`
+
+	// ObjID is the object id of the member, could be host/set now.
+	ObjID string `json:"bk_obj_id" bson:"bk_obj_id"`
+
+	// InstID is the instance id of the member, it is the host id or set id.
+	InstID int64 `json:"bk_inst_id" bson:"bk_inst_id"`
+
+	// OwnerID is the supplier account of the member.
+	OwnerID string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+
+	// CreateTime is the time when the instance joins the dynamic group.
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+}
+
 // DynamicGroupBatch is batch result struct of dynamic group.
 type DynamicGroupBatch struct {
 	// Count batch count.
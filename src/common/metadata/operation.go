@@ -17,9 +17,12 @@
 package metadata
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"configcenter/src/common"
+	"configcenter/src/common/querybuilder"
 )
 
 // ChartConfig TODO
@@ -34,6 +37,100 @@ type ChartConfig struct {
 	ChartType  string `json:"chart_type" bson:"chart_type"`
 	Field      string `json:"field" bson:"field"`
 	XAxisCount int64  `json:"x_axis_count" bson:"x_axis_count"`
+	// Filter is the instance filter of snapshot chart, the instances that matches the filter are counted by Field
+	Filter *querybuilder.QueryFilter `json:"filter,omitempty" bson:"filter,omitempty"`
+}
+
+// ValidateSnapshot validate snapshot chart config
+func (c *ChartConfig) ValidateSnapshot() error {
+	if c.ObjID == "" {
+		return errors.New("bk_obj_id is not set")
+	}
+
+	if c.Field == "" {
+		return errors.New("field is not set")
+	}
+
+	if c.Filter == nil || c.Filter.Rule == nil {
+		return errors.New("filter is not set")
+	}
+
+	if key, err := c.Filter.Validate(&querybuilder.RuleOption{NeedSameSliceElementType: true}); err != nil {
+		return fmt.Errorf("filter.%s is invalid, err: %v", key, err)
+	}
+
+	return nil
+}
+
+// hostRelSnapshotGroupFields are the topology fields that host snapshot chart can be grouped by using host relation
+var hostRelSnapshotGroupFields = map[string]struct{}{
+	common.BKAppIDField:    {},
+	common.BKSetIDField:    {},
+	common.BKModuleIDField: {},
+}
+
+// IsHostRelSnapshotGroupField check if host snapshot chart is grouped by the topology field of host relation
+func IsHostRelSnapshotGroupField(field string) bool {
+	_, exists := hostRelSnapshotGroupFields[field]
+	return exists
+}
+
+// ChartSnapshotDateFormat is the date format of chart snapshot
+const ChartSnapshotDateFormat = "2006-01-02"
+
+// ChartSnapshot is the daily snapshot of snapshot chart data
+type ChartSnapshot struct {
+	ConfigID uint64 `json:"config_id" bson:"config_id"`
+	// Date is the date of the snapshot, the format is 2006-01-02
+	Date string `json:"date" bson:"date"`
+	// Data is the count of the instances that matches the chart filter, grouped by the chart field
+	Data       []StringIDCount `json:"data" bson:"data"`
+	OwnerID    string          `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime time.Time       `json:"create_time" bson:"create_time"`
+}
+
+// SearchChartSnapshotOption is the option to search snapshot chart data
+type SearchChartSnapshotOption struct {
+	ConfigID  uint64 `json:"config_id"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// maxChartSnapshotDays is the max days of chart snapshots that can be searched at one time
+const maxChartSnapshotDays = 366
+
+// Validate search snapshot chart data option
+func (o *SearchChartSnapshotOption) Validate() error {
+	if o.ConfigID == 0 {
+		return errors.New("config_id is not set")
+	}
+
+	start, err := time.Parse(ChartSnapshotDateFormat, o.StartDate)
+	if err != nil {
+		return fmt.Errorf("start_date is invalid, err: %v", err)
+	}
+
+	end, err := time.Parse(ChartSnapshotDateFormat, o.EndDate)
+	if err != nil {
+		return fmt.Errorf("end_date is invalid, err: %v", err)
+	}
+
+	if end.Before(start) {
+		return errors.New("end_date is before start_date")
+	}
+
+	if end.Sub(start) > maxChartSnapshotDays*24*time.Hour {
+		return fmt.Errorf("date range exceeds %d days", maxChartSnapshotDays)
+	}
+
+	return nil
+}
+
+// SearchChartSnapshotResp is the search snapshot chart data response
+type SearchChartSnapshotResp struct {
+	BaseResp `json:",inline"`
+	// Data is the group field value to daily counts map
+	Data map[string][]StringIDCount `json:"data"`
 }
 
 // ChartPosition TODO
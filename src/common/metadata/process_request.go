@@ -47,8 +47,8 @@ type CreateSvcTempAllInfoOption struct {
 
 // SvcTempAttr simplified service template attribute
 type SvcTempAttr struct {
-	AttributeID   int64       `json:"bk_attribute_id"`
-	PropertyValue interface{} `json:"bk_property_value"`
+	AttributeID   int64       `json:"bk_attribute_id" bson:"bk_attribute_id"`
+	PropertyValue interface{} `json:"bk_property_value" bson:"bk_property_value"`
 }
 
 // Validate SvcTempAttr
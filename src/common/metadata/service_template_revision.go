@@ -0,0 +1,430 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+)
+
+// SvcTempRevisionAction 产生服务模板版本的操作
+type SvcTempRevisionAction string
+
+const (
+	// SvcTempRevisionBaseline 服务模板在首次变更前没有任何版本时，记录的变更前的原始定义
+	SvcTempRevisionBaseline SvcTempRevisionAction = "baseline"
+	// SvcTempRevisionCreate 创建服务模板
+	SvcTempRevisionCreate SvcTempRevisionAction = "create"
+	// SvcTempRevisionUpdate 更新服务模板、服务模板属性或进程模板
+	SvcTempRevisionUpdate SvcTempRevisionAction = "update"
+	// SvcTempRevisionRollback 将服务模板回滚到指定版本
+	SvcTempRevisionRollback SvcTempRevisionAction = "rollback"
+)
+
+// ServiceTemplateRevision 服务模板的不可变版本，记录某一时刻服务模板、服务模板属性及进程模板的完整定义
+type ServiceTemplateRevision struct {
+	BizID             int64 `json:"bk_biz_id" bson:"bk_biz_id"`
+	ServiceTemplateID int64 `json:"service_template_id" bson:"service_template_id"`
+	// Version 版本号，同一服务模板下从1开始递增
+	Version           int64                 `json:"version" bson:"version"`
+	Action            SvcTempRevisionAction `json:"action" bson:"action"`
+	Name              string                `json:"name" bson:"name"`
+	ServiceCategoryID int64                 `json:"service_category_id" bson:"service_category_id"`
+	Attributes        []SvcTempAttr         `json:"attributes" bson:"attributes"`
+	ProcessTemplates  []ProcessTemplate     `json:"process_templates" bson:"process_templates"`
+
+	Creator         string    `json:"creator" bson:"creator"`
+	CreateTime      time.Time `json:"create_time" bson:"create_time"`
+	SupplierAccount string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// Validate ServiceTemplateRevision
+func (s *ServiceTemplateRevision) Validate() ccErr.RawErrorInfo {
+	if s.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if s.ServiceTemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+			Args: []interface{}{common.BKServiceTemplateIDField}}
+	}
+
+	switch s.Action {
+	case SvcTempRevisionBaseline, SvcTempRevisionCreate, SvcTempRevisionUpdate, SvcTempRevisionRollback:
+	default:
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"action"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ProcessTemplateMap returns the process templates of the revision indexed by process template id
+func (s *ServiceTemplateRevision) ProcessTemplateMap() map[int64]*ProcessTemplate {
+	procTempMap := make(map[int64]*ProcessTemplate, len(s.ProcessTemplates))
+	for idx := range s.ProcessTemplates {
+		procTempMap[s.ProcessTemplates[idx].ID] = &s.ProcessTemplates[idx]
+	}
+	return procTempMap
+}
+
+// SvcTempFieldDiff 服务模板字段在两个版本之间的变化
+type SvcTempFieldDiff struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// SvcTempAttrChange 服务模板属性在两个版本之间的变化
+type SvcTempAttrChange struct {
+	AttributeID int64       `json:"bk_attribute_id"`
+	From        interface{} `json:"from"`
+	To          interface{} `json:"to"`
+}
+
+// SvcTempAttrRevisionDiff 服务模板属性在两个版本之间的差异
+type SvcTempAttrRevisionDiff struct {
+	Added   []SvcTempAttr       `json:"added"`
+	Changed []SvcTempAttrChange `json:"changed"`
+	Removed []SvcTempAttr       `json:"removed"`
+}
+
+// ProcTempChange 进程模板在两个版本之间的变化
+type ProcTempChange struct {
+	ID          int64            `json:"id"`
+	ProcessName string           `json:"bk_process_name"`
+	From        *ProcessProperty `json:"from"`
+	To          *ProcessProperty `json:"to"`
+}
+
+// ProcTempRevisionDiff 进程模板在两个版本之间的差异，进程模板通过ID进行匹配
+type ProcTempRevisionDiff struct {
+	Added   []ProcessTemplate `json:"added"`
+	Changed []ProcTempChange  `json:"changed"`
+	Removed []ProcessTemplate `json:"removed"`
+}
+
+// SvcTempRevisionDiff 服务模板两个版本之间的差异，版本号为0表示服务模板当前的定义
+type SvcTempRevisionDiff struct {
+	FromVersion       int64                   `json:"from_version"`
+	ToVersion         int64                   `json:"to_version"`
+	Name              *SvcTempFieldDiff       `json:"name,omitempty"`
+	ServiceCategoryID *SvcTempFieldDiff       `json:"service_category_id,omitempty"`
+	Attributes        SvcTempAttrRevisionDiff `json:"attributes"`
+	ProcessTemplates  ProcTempRevisionDiff    `json:"process_templates"`
+}
+
+// IsEmpty returns true if there is no difference between the two revisions
+func (d *SvcTempRevisionDiff) IsEmpty() bool {
+	return d.Name == nil && d.ServiceCategoryID == nil &&
+		len(d.Attributes.Added) == 0 && len(d.Attributes.Changed) == 0 && len(d.Attributes.Removed) == 0 &&
+		len(d.ProcessTemplates.Added) == 0 && len(d.ProcessTemplates.Changed) == 0 &&
+		len(d.ProcessTemplates.Removed) == 0
+}
+
+// DiffSvcTempRevision calculate the difference from one service template revision to another one
+func DiffSvcTempRevision(from, to *ServiceTemplateRevision) *SvcTempRevisionDiff {
+	diff := &SvcTempRevisionDiff{
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Attributes: SvcTempAttrRevisionDiff{
+			Added:   make([]SvcTempAttr, 0),
+			Changed: make([]SvcTempAttrChange, 0),
+			Removed: make([]SvcTempAttr, 0),
+		},
+		ProcessTemplates: ProcTempRevisionDiff{
+			Added:   make([]ProcessTemplate, 0),
+			Changed: make([]ProcTempChange, 0),
+			Removed: make([]ProcessTemplate, 0),
+		},
+	}
+
+	if from.Name != to.Name {
+		diff.Name = &SvcTempFieldDiff{From: from.Name, To: to.Name}
+	}
+
+	if from.ServiceCategoryID != to.ServiceCategoryID {
+		diff.ServiceCategoryID = &SvcTempFieldDiff{From: from.ServiceCategoryID, To: to.ServiceCategoryID}
+	}
+
+	fromAttrMap := make(map[int64]interface{}, len(from.Attributes))
+	for _, attr := range from.Attributes {
+		fromAttrMap[attr.AttributeID] = attr.PropertyValue
+	}
+
+	toAttrMap := make(map[int64]struct{}, len(to.Attributes))
+	for _, attr := range to.Attributes {
+		toAttrMap[attr.AttributeID] = struct{}{}
+
+		fromValue, exists := fromAttrMap[attr.AttributeID]
+		if !exists {
+			diff.Attributes.Added = append(diff.Attributes.Added, attr)
+			continue
+		}
+
+		if !reflect.DeepEqual(fromValue, attr.PropertyValue) {
+			diff.Attributes.Changed = append(diff.Attributes.Changed, SvcTempAttrChange{
+				AttributeID: attr.AttributeID,
+				From:        fromValue,
+				To:          attr.PropertyValue,
+			})
+		}
+	}
+
+	for _, attr := range from.Attributes {
+		if _, exists := toAttrMap[attr.AttributeID]; !exists {
+			diff.Attributes.Removed = append(diff.Attributes.Removed, attr)
+		}
+	}
+
+	fromProcTempMap := from.ProcessTemplateMap()
+	toProcTempMap := to.ProcessTemplateMap()
+	for _, procTemp := range to.ProcessTemplates {
+		fromProcTemp, exists := fromProcTempMap[procTemp.ID]
+		if !exists {
+			diff.ProcessTemplates.Added = append(diff.ProcessTemplates.Added, procTemp)
+			continue
+		}
+
+		if fromProcTemp.ProcessName != procTemp.ProcessName ||
+			!reflect.DeepEqual(fromProcTemp.Property, procTemp.Property) {
+			diff.ProcessTemplates.Changed = append(diff.ProcessTemplates.Changed, ProcTempChange{
+				ID:          procTemp.ID,
+				ProcessName: procTemp.ProcessName,
+				From:        fromProcTemp.Property,
+				To:          procTemp.Property,
+			})
+		}
+	}
+
+	for _, procTemp := range from.ProcessTemplates {
+		if _, exists := toProcTempMap[procTemp.ID]; !exists {
+			diff.ProcessTemplates.Removed = append(diff.ProcessTemplates.Removed, procTemp)
+		}
+	}
+
+	sortSvcTempRevisionDiff(diff)
+	return diff
+}
+
+func sortSvcTempRevisionDiff(diff *SvcTempRevisionDiff) {
+	attrs := diff.Attributes
+	sort.Slice(attrs.Added, func(i, j int) bool { return attrs.Added[i].AttributeID < attrs.Added[j].AttributeID })
+	sort.Slice(attrs.Changed, func(i, j int) bool {
+		return attrs.Changed[i].AttributeID < attrs.Changed[j].AttributeID
+	})
+	sort.Slice(attrs.Removed, func(i, j int) bool {
+		return attrs.Removed[i].AttributeID < attrs.Removed[j].AttributeID
+	})
+
+	procTemps := diff.ProcessTemplates
+	sort.Slice(procTemps.Added, func(i, j int) bool { return procTemps.Added[i].ID < procTemps.Added[j].ID })
+	sort.Slice(procTemps.Changed, func(i, j int) bool { return procTemps.Changed[i].ID < procTemps.Changed[j].ID })
+	sort.Slice(procTemps.Removed, func(i, j int) bool { return procTemps.Removed[i].ID < procTemps.Removed[j].ID })
+}
+
+// SvcTempVersionPin 模块固定使用的服务模板版本，被固定的模块在计算差异及同步服务实例时使用该版本的定义，而不是服务模板当前的定义
+type SvcTempVersionPin struct {
+	BizID             int64 `json:"bk_biz_id" bson:"bk_biz_id"`
+	ServiceTemplateID int64 `json:"service_template_id" bson:"service_template_id"`
+	ModuleID          int64 `json:"bk_module_id" bson:"bk_module_id"`
+	Version           int64 `json:"version" bson:"version"`
+
+	Creator         string    `json:"creator" bson:"creator"`
+	CreateTime      time.Time `json:"create_time" bson:"create_time"`
+	SupplierAccount string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// ListSvcTempRevisionOption list service template revisions option
+type ListSvcTempRevisionOption struct {
+	BizID             int64 `json:"bk_biz_id"`
+	ServiceTemplateID int64 `json:"service_template_id"`
+	// Versions 指定查询的版本号，为空时查询所有版本
+	Versions []int64 `json:"versions"`
+	// Fields 返回的字段，为空时返回全部字段
+	Fields []string `json:"fields"`
+	// Page 默认按版本号倒序排列
+	Page BasePage `json:"page"`
+}
+
+// Validate ListSvcTempRevisionOption
+func (s *ListSvcTempRevisionOption) Validate() ccErr.RawErrorInfo {
+	if s.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if s.ServiceTemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+			Args: []interface{}{common.BKServiceTemplateIDField}}
+	}
+
+	if len(s.Versions) > common.BKMaxPageSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"versions", common.BKMaxPageSize}}
+	}
+
+	if s.Page.IsIllegal() {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommPageLimitIsExceeded}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// DiffSvcTempRevisionOption diff two revisions of service template option
+type DiffSvcTempRevisionOption struct {
+	BizID             int64 `json:"bk_biz_id"`
+	ServiceTemplateID int64 `json:"service_template_id"`
+	// FromVersion 对比的源版本，为0时表示服务模板当前的定义
+	FromVersion int64 `json:"from_version"`
+	// ToVersion 对比的目标版本，为0时表示服务模板当前的定义
+	ToVersion int64 `json:"to_version"`
+}
+
+// Validate DiffSvcTempRevisionOption
+func (s *DiffSvcTempRevisionOption) Validate() ccErr.RawErrorInfo {
+	if s.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if s.ServiceTemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+			Args: []interface{}{common.BKServiceTemplateIDField}}
+	}
+
+	if s.FromVersion < 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"from_version"}}
+	}
+
+	if s.ToVersion < 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"to_version"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// PinSvcTempVersionOption pin modules to a revision of their service template option
+type PinSvcTempVersionOption struct {
+	BizID             int64   `json:"bk_biz_id"`
+	ServiceTemplateID int64   `json:"service_template_id"`
+	ModuleIDs         []int64 `json:"bk_module_ids"`
+	// Version 模块固定使用的版本，为0时表示取消固定，使用服务模板当前的定义
+	Version int64 `json:"version"`
+}
+
+// Validate PinSvcTempVersionOption
+func (s *PinSvcTempVersionOption) Validate() ccErr.RawErrorInfo {
+	if s.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if s.ServiceTemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+			Args: []interface{}{common.BKServiceTemplateIDField}}
+	}
+
+	if len(s.ModuleIDs) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_module_ids"}}
+	}
+
+	if len(s.ModuleIDs) > common.BKMaxUpdateOrCreatePageSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"bk_module_ids", common.BKMaxUpdateOrCreatePageSize}}
+	}
+
+	if s.Version < 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"version"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ListSvcTempVersionPinOption list the version pins of modules option
+type ListSvcTempVersionPinOption struct {
+	BizID             int64   `json:"bk_biz_id"`
+	ServiceTemplateID int64   `json:"service_template_id"`
+	ModuleIDs         []int64 `json:"bk_module_ids"`
+}
+
+// Validate ListSvcTempVersionPinOption
+func (s *ListSvcTempVersionPinOption) Validate() ccErr.RawErrorInfo {
+	if s.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if s.ServiceTemplateID == 0 && len(s.ModuleIDs) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+			Args: []interface{}{common.BKServiceTemplateIDField}}
+	}
+
+	if len(s.ModuleIDs) > common.BKMaxPageSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"bk_module_ids", common.BKMaxPageSize}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// RollbackSvcTempOption roll service template back to a revision option
+type RollbackSvcTempOption struct {
+	BizID             int64 `json:"bk_biz_id"`
+	ServiceTemplateID int64 `json:"service_template_id"`
+	Version           int64 `json:"version"`
+}
+
+// Validate RollbackSvcTempOption
+func (s *RollbackSvcTempOption) Validate() ccErr.RawErrorInfo {
+	if s.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if s.ServiceTemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+			Args: []interface{}{common.BKServiceTemplateIDField}}
+	}
+
+	if s.Version <= 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"version"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// MultipleSvcTempRevision service template revisions
+type MultipleSvcTempRevision struct {
+	Count int64                     `json:"count"`
+	Info  []ServiceTemplateRevision `json:"info"`
+}
+
+// SvcTempRevisionResult service template revision response
+type SvcTempRevisionResult struct {
+	BaseResp `json:",inline"`
+	Data     ServiceTemplateRevision `json:"data"`
+}
+
+// MultipleSvcTempRevisionResult service template revisions response
+type MultipleSvcTempRevisionResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleSvcTempRevision `json:"data"`
+}
+
+// SvcTempVersionPinsResult service template version pins response
+type SvcTempVersionPinsResult struct {
+	BaseResp `json:",inline"`
+	Data     []SvcTempVersionPin `json:"data"`
+}
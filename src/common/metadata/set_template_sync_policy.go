@@ -0,0 +1,334 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+)
+
+// SetTplSyncMode 集群模板同步策略的同步方式
+type SetTplSyncMode string
+
+const (
+	// SetTplSyncModeManual 手动同步，仅由用户主动触发同步，默认方式
+	SetTplSyncModeManual SetTplSyncMode = "manual"
+	// SetTplSyncModeAuto 集群模板变更后自动同步所有需要同步的集群
+	SetTplSyncModeAuto SetTplSyncMode = "auto"
+	// SetTplSyncModeScheduled 仅在维护窗口内自动同步需要同步的集群
+	SetTplSyncModeScheduled SetTplSyncMode = "scheduled"
+)
+
+const (
+	// SetTplSyncDefaultConcurrency 同步策略未设置最大并发数时的默认值
+	SetTplSyncDefaultConcurrency = 10
+	// SetTplSyncMaxConcurrency 同步策略允许设置的最大并发数
+	SetTplSyncMaxConcurrency = 50
+	// SetTplSyncMaxWindowCount 同步策略允许设置的最大维护窗口数
+	SetTplSyncMaxWindowCount = 20
+
+	setTplSyncWindowTimeLayout = "15:04"
+)
+
+// SetTplSyncWindow 集群模板同步的维护窗口，时间为服务器本地时间
+type SetTplSyncWindow struct {
+	// Weekdays 窗口生效的星期，0表示周日，为空表示每天都生效
+	Weekdays []int `json:"weekdays" bson:"weekdays"`
+	// StartTime 窗口开始时间，格式为 HH:MM
+	StartTime string `json:"start_time" bson:"start_time"`
+	// EndTime 窗口结束时间，格式为 HH:MM，小于开始时间时表示窗口跨越零点
+	EndTime string `json:"end_time" bson:"end_time"`
+}
+
+// Validate SetTplSyncWindow
+func (w SetTplSyncWindow) Validate() error {
+	for _, weekday := range w.Weekdays {
+		if weekday < int(time.Sunday) || weekday > int(time.Saturday) {
+			return fmt.Errorf("weekday %d is invalid", weekday)
+		}
+	}
+
+	start, err := parseSyncWindowMinute(w.StartTime)
+	if err != nil {
+		return err
+	}
+
+	end, err := parseSyncWindowMinute(w.EndTime)
+	if err != nil {
+		return err
+	}
+
+	if start == end {
+		return fmt.Errorf("start time %s equals to end time", w.StartTime)
+	}
+	return nil
+}
+
+// ActiveStart returns the start time of the window occurrence that contains the given time,
+// the second return value is false if the given time is not in this window.
+func (w SetTplSyncWindow) ActiveStart(now time.Time) (time.Time, bool) {
+	start, err := parseSyncWindowMinute(w.StartTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := parseSyncWindowMinute(w.EndTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	duration := time.Duration(end-start) * time.Minute
+	if end < start {
+		duration += 24 * time.Hour
+	}
+
+	// the window that contains now may start today, or yesterday if the window crosses midnight
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, day := range []time.Time{today, today.AddDate(0, 0, -1)} {
+		if !w.isWeekdayMatched(day.Weekday()) {
+			continue
+		}
+
+		windowStart := day.Add(time.Duration(start) * time.Minute)
+		if !now.Before(windowStart) && now.Before(windowStart.Add(duration)) {
+			return windowStart, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (w SetTplSyncWindow) isWeekdayMatched(weekday time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+
+	for _, day := range w.Weekdays {
+		if day == int(weekday) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSyncWindowMinute parse HH:MM time to the minutes from midnight
+func parseSyncWindowMinute(value string) (int, error) {
+	t, err := time.Parse(setTplSyncWindowTimeLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("time %s is invalid, should be in HH:MM format", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// SetTplSyncRunStatus 同步策略执行状态
+type SetTplSyncRunStatus string
+
+const (
+	// SetTplSyncRunRunning 同步策略执行中
+	SetTplSyncRunRunning SetTplSyncRunStatus = "running"
+	// SetTplSyncRunFinished 同步策略执行完成，所有集群同步成功
+	SetTplSyncRunFinished SetTplSyncRunStatus = "finished"
+	// SetTplSyncRunHalted 灰度集群同步失败，后续集群不再同步
+	SetTplSyncRunHalted SetTplSyncRunStatus = "halted"
+	// SetTplSyncRunFailed 同步策略执行完成，但存在同步失败的集群
+	SetTplSyncRunFailed SetTplSyncRunStatus = "failed"
+)
+
+// SetTplSyncRun 同步策略的一次执行记录，每个集群的同步详情可通过集群模板同步历史查询
+type SetTplSyncRun struct {
+	Status    SetTplSyncRunStatus `json:"status" bson:"status"`
+	StartTime time.Time           `json:"start_time" bson:"start_time"`
+	EndTime   *time.Time          `json:"end_time,omitempty" bson:"end_time"`
+	// CanarySetIDs 本次执行中作为灰度先行同步的集群
+	CanarySetIDs []int64 `json:"canary_set_ids" bson:"canary_set_ids"`
+	// TaskIDs 本次执行创建的同步任务ID，可用于查询集群模板同步历史
+	TaskIDs      []string `json:"task_ids" bson:"task_ids"`
+	SuccessCount int      `json:"success_count" bson:"success_count"`
+	FailureCount int      `json:"failure_count" bson:"failure_count"`
+	// SkipCount 因灰度失败而未同步的集群数
+	SkipCount int    `json:"skip_count" bson:"skip_count"`
+	Message   string `json:"message" bson:"message"`
+}
+
+// SetTplSyncPolicy 集群模板同步策略
+type SetTplSyncPolicy struct {
+	BizID         int64              `json:"bk_biz_id" bson:"bk_biz_id"`
+	SetTemplateID int64              `json:"set_template_id" bson:"set_template_id"`
+	Mode          SetTplSyncMode     `json:"mode" bson:"mode"`
+	Windows       []SetTplSyncWindow `json:"windows" bson:"windows"`
+	// MaxConcurrency 同时进行同步的最大集群数
+	MaxConcurrency int `json:"max_concurrency" bson:"max_concurrency"`
+	// CanaryCount 先行同步的集群数，这些集群中有同步失败的则停止同步剩余的集群，为0表示不进行灰度
+	CanaryCount int            `json:"canary_count" bson:"canary_count"`
+	LastRun     *SetTplSyncRun `json:"last_run,omitempty" bson:"last_run"`
+
+	Creator         string    `json:"creator" bson:"creator"`
+	Modifier        string    `json:"modifier" bson:"modifier"`
+	CreateTime      time.Time `json:"create_time" bson:"create_time"`
+	LastTime        time.Time `json:"last_time" bson:"last_time"`
+	SupplierAccount string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// GetMaxConcurrency returns the max concurrency of the policy, use default value if not set
+func (p *SetTplSyncPolicy) GetMaxConcurrency() int {
+	if p.MaxConcurrency <= 0 {
+		return SetTplSyncDefaultConcurrency
+	}
+	return p.MaxConcurrency
+}
+
+// ActiveWindowStart returns the start time of the policy's maintenance window that contains the given time,
+// the second return value is false if the given time is not in any window.
+func (p *SetTplSyncPolicy) ActiveWindowStart(now time.Time) (time.Time, bool) {
+	var (
+		windowStart time.Time
+		active      bool
+	)
+	for _, window := range p.Windows {
+		start, ok := window.ActiveStart(now)
+		if !ok {
+			continue
+		}
+
+		// use the earliest start time when windows overlaps, so that they are regarded as one window
+		if !active || start.Before(windowStart) {
+			windowStart = start
+			active = true
+		}
+	}
+	return windowStart, active
+}
+
+// SetTplSyncPolicyOption set template sync policy option
+type SetTplSyncPolicyOption struct {
+	BizID          int64              `json:"bk_biz_id"`
+	SetTemplateID  int64              `json:"set_template_id"`
+	Mode           SetTplSyncMode     `json:"mode"`
+	Windows        []SetTplSyncWindow `json:"windows"`
+	MaxConcurrency int                `json:"max_concurrency"`
+	CanaryCount    int                `json:"canary_count"`
+}
+
+// Validate SetTplSyncPolicyOption
+func (s *SetTplSyncPolicyOption) Validate() ccErr.RawErrorInfo {
+	if s.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if s.SetTemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+			Args: []interface{}{common.BKSetTemplateIDField}}
+	}
+
+	switch s.Mode {
+	case SetTplSyncModeManual, SetTplSyncModeAuto:
+	case SetTplSyncModeScheduled:
+		if len(s.Windows) == 0 {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"windows"}}
+		}
+	default:
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"mode"}}
+	}
+
+	if len(s.Windows) > SetTplSyncMaxWindowCount {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"windows", SetTplSyncMaxWindowCount}}
+	}
+
+	for _, window := range s.Windows {
+		if err := window.Validate(); err != nil {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"windows"}}
+		}
+	}
+
+	if s.MaxConcurrency < 0 || s.MaxConcurrency > SetTplSyncMaxConcurrency {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"max_concurrency"}}
+	}
+
+	if s.CanaryCount < 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"canary_count"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ListSetTplSyncPolicyOption list set template sync policy option
+type ListSetTplSyncPolicyOption struct {
+	// BizID 业务ID，为0时查询所有业务的同步策略
+	BizID          int64            `json:"bk_biz_id"`
+	SetTemplateIDs []int64          `json:"set_template_ids"`
+	Modes          []SetTplSyncMode `json:"modes"`
+	Page           BasePage         `json:"page"`
+}
+
+// Validate ListSetTplSyncPolicyOption
+func (s *ListSetTplSyncPolicyOption) Validate() ccErr.RawErrorInfo {
+	if len(s.SetTemplateIDs) > common.BKMaxPageSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"set_template_ids", common.BKMaxPageSize}}
+	}
+
+	if s.Page.IsIllegal() {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommPageLimitIsExceeded}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// UpdateSetTplSyncRunOption update the last run of set template sync policy option
+type UpdateSetTplSyncRunOption struct {
+	BizID         int64          `json:"bk_biz_id"`
+	SetTemplateID int64          `json:"set_template_id"`
+	LastRun       *SetTplSyncRun `json:"last_run"`
+}
+
+// Validate UpdateSetTplSyncRunOption
+func (s *UpdateSetTplSyncRunOption) Validate() ccErr.RawErrorInfo {
+	if s.BizID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if s.SetTemplateID == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+			Args: []interface{}{common.BKSetTemplateIDField}}
+	}
+
+	if s.LastRun == nil {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"last_run"}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// MultipleSetTplSyncPolicy set template sync policies
+type MultipleSetTplSyncPolicy struct {
+	Count int64              `json:"count"`
+	Info  []SetTplSyncPolicy `json:"info"`
+}
+
+// SetTplSyncPolicyResult set template sync policy response
+type SetTplSyncPolicyResult struct {
+	BaseResp `json:",inline"`
+	Data     SetTplSyncPolicy `json:"data"`
+}
+
+// MultipleSetTplSyncPolicyResult set template sync policies response
+type MultipleSetTplSyncPolicyResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleSetTplSyncPolicy `json:"data"`
+}
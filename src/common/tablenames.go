@@ -66,6 +66,8 @@ const (
 	BKTableNameHistory          = "cc_History"
 	BKTableNameHostFavorite     = "cc_HostFavourite"
 	BKTableNameAuditLog         = "cc_AuditLog"
+	BKTableNameAuditLogChain    = "cc_AuditLogChain"
+	BKTableNameAuditLogArchive  = "cc_AuditLogArchive"
 	BKTableNameUserAPI          = "cc_UserAPI"
 	BKTableNameDynamicGroup     = "cc_DynamicGroup"
 	BKTableNameUserCustom       = "cc_UserCustom"
@@ -74,6 +76,9 @@ const (
 	BKTableNameTransaction      = "cc_Transaction"
 	BKTableNameIDgenerator      = "cc_idgenerator"
 
+	// BKTableNameDynamicGroupMember is the materialized member table of dynamic groups
+	BKTableNameDynamicGroupMember = "cc_DynamicGroupMember"
+
 	BKTableNameNetcollectDevice   = "cc_NetcollectDevice"
 	BKTableNameNetcollectProperty = "cc_NetcollectProperty"
 
@@ -87,6 +92,7 @@ const (
 	BKTableNameChartConfig   = "cc_ChartConfig"
 	BKTableNameChartPosition = "cc_ChartPosition"
 	BKTableNameChartData     = "cc_ChartData"
+	BKTableNameChartSnapshot = "cc_ChartSnapshot"
 
 	// process tables
 	BKTableNameServiceCategory         = "cc_ServiceCategory"
@@ -96,8 +102,12 @@ const (
 	BKTableNameProcessTemplate         = "cc_ProcessTemplate"
 	BKTableNameProcessInstanceRelation = "cc_ProcessInstanceRelation"
 
+	BKTableNameServiceTemplateRevision   = "cc_ServiceTemplateRevision"
+	BKTableNameServiceTemplateVersionPin = "cc_ServiceTemplateVersionPin"
+
 	BKTableNameSetTemplate                = "cc_SetTemplate"
 	BKTableNameSetTemplateAttr            = "cc_SetTemplateAttr"
+	BKTableNameSetTemplateSyncPolicy      = "cc_SetTemplateSyncPolicy"
 	BKTableNameSetServiceTemplateRelation = "cc_SetServiceTemplateRelation"
 	BKTableNameAPITask                    = "cc_APITask"
 	BKTableNameAPITaskSyncHistory         = "cc_APITaskSyncHistory"
@@ -113,6 +123,11 @@ const (
 	// BKTableNameWatchToken the table to store the latest watch token for collections
 	BKTableNameWatchToken = "cc_WatchToken"
 
+	// event subscription tables
+	BKTableNameEventSubscription           = "cc_EventSubscription"
+	BKTableNameEventSubscriptionDelivery   = "cc_EventSubscriptionDelivery"
+	BKTableNameEventSubscriptionDeadLetter = "cc_EventSubscriptionDeadLetter"
+
 	// BKTableNameMainlineInstance is a virtual collection name which represent for mainline instance events
 	BKTableNameMainlineInstance = "cc_MainlineInstance"
 
@@ -151,6 +166,7 @@ var AllTables = []string{
 	BKTableNameAuditLog,
 	BKTableNameUserAPI,
 	BKTableNameDynamicGroup,
+	BKTableNameDynamicGroupMember,
 	BKTableNameUserCustom,
 	BKTableNameObjAsst,
 	BKTableNameTopoGraphics,
@@ -174,12 +190,21 @@ var AllTables = []string{
 	BKTableNameChartConfig,
 	BKTableNameChartPosition,
 	BKTableNameChartData,
+	BKTableNameChartSnapshot,
 	BKTableNameHostApplyRule,
 	BKTableNameAPITask,
 	BKTableNameAPITaskSyncHistory,
 	BKTableNameCloudSyncTask,
 	BKTableNameCloudAccount,
 	BKTableNameCloudSyncHistory,
+	BKTableNameEventSubscription,
+	BKTableNameEventSubscriptionDelivery,
+	BKTableNameEventSubscriptionDeadLetter,
+	BKTableNameAuditLogChain,
+	BKTableNameAuditLogArchive,
+	BKTableNameSetTemplateSyncPolicy,
+	BKTableNameServiceTemplateRevision,
+	BKTableNameServiceTemplateVersionPin,
 }
 
 // TableSpecifier is table specifier type which describes the metadata
@@ -18,6 +18,8 @@
 package table
 
 import (
+	"context"
+
 	fullsynccond "configcenter/pkg/cache/full-sync-cond"
 	"configcenter/src/common"
 	kubetypes "configcenter/src/kube/types"
@@ -35,6 +37,7 @@ var delArchiveCollMap = map[string]string{
 	common.BKTableNameBaseBizSet:              common.BKTableNameDelArchive,
 	common.BKTableNameBasePlat:                common.BKTableNameDelArchive,
 	common.BKTableNameBaseProject:             common.BKTableNameDelArchive,
+	common.BKTableNameDynamicGroupMember:      common.BKTableNameDelArchive,
 	fullsynccond.BKTableNameFullSyncCond:      common.BKTableNameDelArchive,
 
 	common.BKTableNameBaseInst:         common.BKTableNameDelArchive,
@@ -42,6 +45,9 @@ var delArchiveCollMap = map[string]string{
 	common.BKTableNameInstAsst:         common.BKTableNameDelArchive,
 
 	common.BKTableNameServiceInstance: common.BKTableNameDelArchive,
+	common.BKTableNameServiceTemplate: common.BKTableNameDelArchive,
+	common.BKTableNameProcessTemplate: common.BKTableNameDelArchive,
+	common.BKTableNameHostApplyRule:   common.BKTableNameDelArchive,
 
 	kubetypes.BKTableNameBaseCluster:        common.BKTableNameKubeDelArchive,
 	kubetypes.BKTableNameBaseNode:           common.BKTableNameKubeDelArchive,
@@ -56,18 +62,57 @@ var delArchiveCollMap = map[string]string{
 	kubetypes.BKTableNameBaseJob:            common.BKTableNameKubeDelArchive,
 	kubetypes.BKTableNameBasePodWorkload:    common.BKTableNameKubeDelArchive,
 	kubetypes.BKTableNameBaseCustom:         common.BKTableNameKubeDelArchive,
+	kubetypes.BKTableNameBaseService:        common.BKTableNameKubeDelArchive,
+	kubetypes.BKTableNameBaseIngress:        common.BKTableNameKubeDelArchive,
+	kubetypes.BKTableNameBaseConfigMap:      common.BKTableNameKubeDelArchive,
+	kubetypes.BKTableNameBaseSecret:         common.BKTableNameKubeDelArchive,
 	kubetypes.BKTableNameBasePod:            common.BKTableNameKubeDelArchive,
 	kubetypes.BKTableNameBaseContainer:      common.BKTableNameKubeDelArchive,
 	kubetypes.BKTableNameNsSharedClusterRel: common.BKTableNameKubeDelArchive,
 }
 
-// GetDelArchiveTable get delete archive table
+// optionalDelArchiveCollMap is the tables whose deleted docs are archived only when the delete operation requires it
+// by WithDelArchive, e.g. deleting model metadata by api, whose delete events need to be watched and synchronized.
+// other deletions of these tables like the upgrader's data clean up are not archived.
+var optionalDelArchiveCollMap = map[string]string{
+	common.BKTableNameObjClassification: common.BKTableNameDelArchive,
+	common.BKTableNameAsstDes:           common.BKTableNameDelArchive,
+	common.BKTableNameObjDes:            common.BKTableNameDelArchive,
+	common.BKTableNamePropertyGroup:     common.BKTableNameDelArchive,
+	common.BKTableNameObjAttDes:         common.BKTableNameDelArchive,
+	common.BKTableNameObjUnique:         common.BKTableNameDelArchive,
+	common.BKTableNameObjAsst:           common.BKTableNameDelArchive,
+}
+
+type delArchiveCtxKey struct{}
+
+// WithDelArchive returns a context that requires the deleted docs of the optional delete archive tables to be archived
+func WithDelArchive(ctx context.Context) context.Context {
+	return context.WithValue(ctx, delArchiveCtxKey{}, true)
+}
+
+// NeedDelArchive check if the deleted docs of the table need to be archived in the context, returns the archive table
+func NeedDelArchive(ctx context.Context, table string) (string, bool) {
+	if delArchiveTable, exists := optionalDelArchiveCollMap[table]; exists {
+		enabled, _ := ctx.Value(delArchiveCtxKey{}).(bool)
+		return delArchiveTable, enabled
+	}
+
+	return GetDelArchiveTable(table)
+}
+
+// GetDelArchiveTable get delete archive table, including the optional delete archive tables
 func GetDelArchiveTable(table string) (string, bool) {
 	delArchiveTable, exists := delArchiveCollMap[table]
 	if exists {
 		return delArchiveTable, true
 	}
 
+	delArchiveTable, exists = optionalDelArchiveCollMap[table]
+	if exists {
+		return delArchiveTable, true
+	}
+
 	if !common.IsObjectShardingTable(table) {
 		return "", false
 	}
@@ -79,7 +124,9 @@ func GetDelArchiveTable(table string) (string, bool) {
 func GetDelArchiveFields(table string) []string {
 	switch table {
 	case common.BKTableNameServiceInstance:
-		return []string{common.BKFieldID}
+		// only archive the fields that are needed by the service instance delete event, so as to save space
+		return []string{common.BKFieldID, common.BKFieldName, common.BKAppIDField, common.BKModuleIDField,
+			common.BKHostIDField, common.BKServiceTemplateIDField}
 	}
 
 	return make([]string, 0)
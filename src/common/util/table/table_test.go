@@ -0,0 +1,119 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package table
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeedDelArchive(t *testing.T) {
+	instTable := common.GetObjectInstTableName("test_obj", common.BKDefaultOwnerID)
+
+	testCases := []struct {
+		name        string
+		ctx         context.Context
+		table       string
+		expectTable string
+		expectNeed  bool
+	}{
+		{
+			name:        "default archive table",
+			ctx:         context.Background(),
+			table:       common.BKTableNameBaseHost,
+			expectTable: common.BKTableNameDelArchive,
+			expectNeed:  true,
+		},
+		{
+			name:        "default archive table with archive context",
+			ctx:         WithDelArchive(context.Background()),
+			table:       common.BKTableNameBaseHost,
+			expectTable: common.BKTableNameDelArchive,
+			expectNeed:  true,
+		},
+		{
+			name:        "optional archive table without archive context",
+			ctx:         context.Background(),
+			table:       common.BKTableNameObjAttDes,
+			expectTable: common.BKTableNameDelArchive,
+			expectNeed:  false,
+		},
+		{
+			name:        "optional archive table with archive context",
+			ctx:         WithDelArchive(context.Background()),
+			table:       common.BKTableNameObjAttDes,
+			expectTable: common.BKTableNameDelArchive,
+			expectNeed:  true,
+		},
+		{
+			name:        "object instance sharding table",
+			ctx:         context.Background(),
+			table:       instTable,
+			expectTable: common.BKTableNameDelArchive,
+			expectNeed:  true,
+		},
+		{
+			name:       "table without archive",
+			ctx:        WithDelArchive(context.Background()),
+			table:      common.BKTableNameAuditLog,
+			expectNeed: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			archiveTable, need := NeedDelArchive(tc.ctx, tc.table)
+			require.Equal(t, tc.expectNeed, need)
+			if need {
+				require.Equal(t, tc.expectTable, archiveTable)
+			}
+		})
+	}
+}
+
+func TestGetDelArchiveTable(t *testing.T) {
+	// optional archive tables still have archive table for the readers of the deleted docs
+	for tableName := range optionalDelArchiveCollMap {
+		archiveTable, exists := GetDelArchiveTable(tableName)
+		require.True(t, exists, tableName)
+		require.Equal(t, common.BKTableNameDelArchive, archiveTable, tableName)
+	}
+
+	_, exists := GetDelArchiveTable(common.BKTableNameAuditLog)
+	require.False(t, exists)
+}
+
+func TestGetDelArchiveFields(t *testing.T) {
+	// the deleted service instance is watched by the service instance delete event, which needs the business to
+	// be used as the sub resource and the module, host and service template that the instance belongs to
+	fields := GetDelArchiveFields(common.BKTableNameServiceInstance)
+	require.ElementsMatch(t, []string{common.BKFieldID, common.BKFieldName, common.BKAppIDField,
+		common.BKModuleIDField, common.BKHostIDField, common.BKServiceTemplateIDField}, fields)
+
+	// the other watched template tables archive the whole doc
+	for _, tableName := range []string{common.BKTableNameServiceTemplate, common.BKTableNameProcessTemplate,
+		common.BKTableNameSetTemplate, common.BKTableNameHostApplyRule} {
+		archiveTable, exists := GetDelArchiveTable(tableName)
+		require.True(t, exists, tableName)
+		require.Equal(t, common.BKTableNameDelArchive, archiveTable, tableName)
+		require.Empty(t, GetDelArchiveFields(tableName), tableName)
+	}
+}
@@ -83,6 +83,23 @@ var (
 		KubeWorkload:            20,
 		KubePod:                 21,
 		Project:                 22,
+		DynamicGroupMember:      23,
+		Object:                  24,
+		ObjectAttribute:         25,
+		ObjectAttributeGroup:    26,
+		ObjectUnique:            27,
+		ModelAssociation:        28,
+		AssociationKind:         29,
+		ObjectClassification:    30,
+		ServiceInstance:         31,
+		ServiceTemplate:         32,
+		ProcessTemplate:         33,
+		SetTemplate:             34,
+		HostApplyRule:           35,
+		KubeService:             36,
+		KubeIngress:             37,
+		KubeConfigMap:           38,
+		KubeSecret:              39,
 	}
 
 	intCursorTypeMap = make(map[int]CursorType)
@@ -128,6 +145,38 @@ const (
 	Plat CursorType = "plat"
 	// Project project event cursor type
 	Project CursorType = "project"
+	// DynamicGroupMember materialized dynamic group member event cursor type, a member joins the group with a create
+	// event and leaves the group with a delete event
+	DynamicGroupMember CursorType = "dynamic_group_member"
+
+	// model metadata related cursor types
+	// Object model definition event cursor type
+	Object CursorType = "object"
+	// ObjectAttribute model attribute event cursor type
+	ObjectAttribute CursorType = "object_attribute"
+	// ObjectAttributeGroup model attribute group event cursor type
+	ObjectAttributeGroup CursorType = "object_attribute_group"
+	// ObjectUnique model unique rule event cursor type
+	ObjectUnique CursorType = "object_unique"
+	// ModelAssociation model association event cursor type
+	ModelAssociation CursorType = "model_association"
+	// AssociationKind association kind event cursor type
+	AssociationKind CursorType = "association_kind"
+	// ObjectClassification model classification event cursor type
+	ObjectClassification CursorType = "object_classification"
+
+	// service template related cursor types
+	// ServiceInstance service instance event cursor type
+	ServiceInstance CursorType = "service_instance"
+	// ServiceTemplate service template event cursor type
+	ServiceTemplate CursorType = "service_template"
+	// ProcessTemplate process template event cursor type
+	ProcessTemplate CursorType = "process_template"
+	// SetTemplate set template event cursor type
+	SetTemplate CursorType = "set_template"
+	// HostApplyRule host apply rule event cursor type
+	HostApplyRule CursorType = "host_apply_rule"
+
 	// kube related cursor types
 	// KubeCluster cursor type
 	KubeCluster CursorType = "kube_cluster"
@@ -139,6 +188,14 @@ const (
 	KubeWorkload CursorType = "kube_workload"
 	// KubePod cursor type, its event detail is pod info with containers in it
 	KubePod CursorType = "kube_pod"
+	// KubeService cursor type
+	KubeService CursorType = "kube_service"
+	// KubeIngress cursor type
+	KubeIngress CursorType = "kube_ingress"
+	// KubeConfigMap cursor type
+	KubeConfigMap CursorType = "kube_configmap"
+	// KubeSecret cursor type, its event detail only contains the metadata of the secret
+	KubeSecret CursorType = "kube_secret"
 )
 
 // ToInt TODO
@@ -166,7 +223,37 @@ func (ct *CursorType) ParseInt(typ int) {
 func ListCursorTypes() []CursorType {
 	return []CursorType{Host, ModuleHostRelation, Biz, Set, Module, ObjectBase, Process, ProcessInstanceRelation,
 		HostIdentifier, MainlineInstance, InstAsst, BizSet, BizSetRelation, Plat, KubeCluster, KubeNode, KubeNamespace,
-		KubeWorkload, KubePod, Project}
+		KubeWorkload, KubePod, Project, DynamicGroupMember, Object, ObjectAttribute, ObjectAttributeGroup, ObjectUnique,
+		ModelAssociation, AssociationKind, ObjectClassification, ServiceInstance, ServiceTemplate, ProcessTemplate,
+		SetTemplate, HostApplyRule, KubeService, KubeIngress, KubeConfigMap, KubeSecret}
+}
+
+// IsModelMetadata returns if the cursor type is the model metadata related cursor type
+func (ct CursorType) IsModelMetadata() bool {
+	switch ct {
+	case Object, ObjectAttribute, ObjectAttributeGroup, ObjectUnique, ModelAssociation, AssociationKind,
+		ObjectClassification:
+		return true
+	}
+	return false
+}
+
+// IsTemplate returns if the cursor type is the service template or set template related cursor type
+func (ct CursorType) IsTemplate() bool {
+	switch ct {
+	case ServiceTemplate, ProcessTemplate, SetTemplate:
+		return true
+	}
+	return false
+}
+
+// IsKubeNsResource returns if the cursor type is the kube namespace resource related cursor type
+func (ct CursorType) IsKubeNsResource() bool {
+	switch ct {
+	case KubeService, KubeIngress, KubeConfigMap, KubeSecret:
+		return true
+	}
+	return false
 }
 
 // Cursor is a self-defined token which is corresponding to the mongodb's resume token.
@@ -349,6 +436,23 @@ var collEventCursorTypeMap = map[string]CursorType{
 	kubetypes.BKTableNameBaseWorkload:         KubeWorkload,
 	kubetypes.BKTableNameBasePod:              KubePod,
 	common.BKTableNameBaseProject:             Project,
+	common.BKTableNameDynamicGroupMember:      DynamicGroupMember,
+	common.BKTableNameObjDes:                  Object,
+	common.BKTableNameObjAttDes:               ObjectAttribute,
+	common.BKTableNamePropertyGroup:           ObjectAttributeGroup,
+	common.BKTableNameObjUnique:               ObjectUnique,
+	common.BKTableNameObjAsst:                 ModelAssociation,
+	common.BKTableNameAsstDes:                 AssociationKind,
+	common.BKTableNameObjClassification:       ObjectClassification,
+	common.BKTableNameServiceInstance:         ServiceInstance,
+	common.BKTableNameServiceTemplate:         ServiceTemplate,
+	common.BKTableNameProcessTemplate:         ProcessTemplate,
+	common.BKTableNameSetTemplate:             SetTemplate,
+	common.BKTableNameHostApplyRule:           HostApplyRule,
+	kubetypes.BKTableNameBaseService:          KubeService,
+	kubetypes.BKTableNameBaseIngress:          KubeIngress,
+	kubetypes.BKTableNameBaseConfigMap:        KubeConfigMap,
+	kubetypes.BKTableNameBaseSecret:           KubeSecret,
 }
 
 // GetEventCursor get event cursor.
@@ -20,6 +20,7 @@ import (
 	"strconv"
 	"testing"
 
+	"configcenter/src/common"
 	"configcenter/src/storage/stream/types"
 )
 
@@ -85,3 +86,106 @@ func TestCursorDecode(t *testing.T) {
 		return
 	}
 }
+
+type cursorTypeCase struct {
+	typ  CursorType
+	num  int
+	coll string
+}
+
+// testCursorTypes checks the int value of the cursor types, and that the cursors generated by the events of their
+// collections can be decoded back to the same cursors
+func testCursorTypes(t *testing.T, cases []cursorTypeCase) {
+	for _, c := range cases {
+		if c.typ.ToInt() != c.num {
+			t.Errorf("cursor type %s int value %d is not %d", c.typ, c.typ.ToInt(), c.num)
+			continue
+		}
+
+		parsed := new(CursorType)
+		parsed.ParseInt(c.num)
+		if *parsed != c.typ {
+			t.Errorf("parse int %d got cursor type %s, expect %s", c.num, *parsed, c.typ)
+			continue
+		}
+
+		e := &types.Event{
+			Oid:           "5eb385974770a118f4922abe",
+			OperationType: types.Delete,
+			Collection:    c.coll,
+			ClusterTime:   types.TimeStamp{Sec: uint32(1588853652), Nano: 3},
+		}
+		encode, err := GetEventCursor(c.coll, e, 1)
+		if err != nil {
+			t.Errorf("get %s event cursor failed, err: %v", c.coll, err)
+			continue
+		}
+
+		cursor := new(Cursor)
+		if err := cursor.Decode(encode); err != nil {
+			t.Errorf("decode %s cursor failed, err: %v", c.typ, err)
+			continue
+		}
+
+		expect := Cursor{Type: c.typ, ClusterTime: e.ClusterTime, Oid: e.Oid, Oper: types.Delete}
+		if *cursor != expect {
+			t.Errorf("decode %s cursor, got %+v, expect %+v", c.typ, *cursor, expect)
+			continue
+		}
+
+		reEncode, err := cursor.Encode()
+		if err != nil {
+			t.Errorf("encode %s cursor failed, err: %v", c.typ, err)
+			continue
+		}
+
+		if reEncode != encode {
+			t.Errorf("encode %s cursor, got %s, expect %s", c.typ, reEncode, encode)
+		}
+	}
+}
+
+func TestModelMetadataCursor(t *testing.T) {
+	testCursorTypes(t, []cursorTypeCase{
+		{typ: Object, num: 24, coll: common.BKTableNameObjDes},
+		{typ: ObjectAttribute, num: 25, coll: common.BKTableNameObjAttDes},
+		{typ: ObjectAttributeGroup, num: 26, coll: common.BKTableNamePropertyGroup},
+		{typ: ObjectUnique, num: 27, coll: common.BKTableNameObjUnique},
+		{typ: ModelAssociation, num: 28, coll: common.BKTableNameObjAsst},
+		{typ: AssociationKind, num: 29, coll: common.BKTableNameAsstDes},
+		{typ: ObjectClassification, num: 30, coll: common.BKTableNameObjClassification},
+	})
+
+	for _, typ := range []CursorType{Object, ObjectAttribute, ObjectAttributeGroup, ObjectUnique, ModelAssociation,
+		AssociationKind, ObjectClassification} {
+		if !typ.IsModelMetadata() {
+			t.Errorf("cursor type %s should be model metadata", typ)
+		}
+	}
+
+	if ObjectBase.IsModelMetadata() {
+		t.Errorf("cursor type %s should not be model metadata", ObjectBase)
+	}
+}
+
+func TestTemplateCursor(t *testing.T) {
+	testCursorTypes(t, []cursorTypeCase{
+		{typ: ServiceInstance, num: 31, coll: common.BKTableNameServiceInstance},
+		{typ: ServiceTemplate, num: 32, coll: common.BKTableNameServiceTemplate},
+		{typ: ProcessTemplate, num: 33, coll: common.BKTableNameProcessTemplate},
+		{typ: SetTemplate, num: 34, coll: common.BKTableNameSetTemplate},
+		{typ: HostApplyRule, num: 35, coll: common.BKTableNameHostApplyRule},
+	})
+
+	for _, typ := range []CursorType{ServiceTemplate, ProcessTemplate, SetTemplate} {
+		if !typ.IsTemplate() {
+			t.Errorf("cursor type %s should be template", typ)
+		}
+	}
+
+	for _, typ := range []CursorType{ServiceInstance, HostApplyRule} {
+		if typ.IsTemplate() {
+			t.Errorf("cursor type %s should not be template", typ)
+		}
+	}
+}
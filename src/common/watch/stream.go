@@ -0,0 +1,42 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package watch
+
+// StreamMessageType is the type of the message sent by the resource watch stream
+type StreamMessageType string
+
+const (
+	// StreamEvent the message contains one watched event
+	StreamEvent StreamMessageType = "event"
+	// StreamHeartbeat the message is a heartbeat to keep the stream alive, it contains the latest cursor that has been
+	// watched, so that the client can resume watching from it even if no event is hit for a long time
+	StreamHeartbeat StreamMessageType = "heartbeat"
+	// StreamError the message contains the error that ends the stream
+	StreamError StreamMessageType = "error"
+)
+
+// StreamMessage is the message sent by the resource watch stream
+type StreamMessage struct {
+	Type StreamMessageType `json:"type"`
+	// Cursor is the cursor of the event or the latest watched cursor, use it as bk_cursor to resume watching
+	Cursor string `json:"bk_cursor,omitempty"`
+	// Event is the watched event, only set when Type is StreamEvent
+	Event *WatchEventDetail `json:"bk_event,omitempty"`
+	// ErrCode and ErrMsg is the error that ends the stream, only set when Type is StreamError
+	ErrCode int    `json:"bk_error_code,omitempty"`
+	ErrMsg  string `json:"bk_error_msg,omitempty"`
+}
@@ -0,0 +1,449 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package watch
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+const (
+	// SubscriptionMaxNameLength is the max length of event subscription name
+	SubscriptionMaxNameLength = 128
+	// SubscriptionDefaultBatchSize is the default max event count that is delivered in one request
+	SubscriptionDefaultBatchSize = 100
+	// SubscriptionMaxBatchSize is the max value of event subscription batch size
+	SubscriptionMaxBatchSize = 200
+	// SubscriptionDefaultTimeout is the default timeout seconds of one delivery request
+	SubscriptionDefaultTimeout = 10
+	// SubscriptionMaxTimeout is the max value of event subscription timeout seconds
+	SubscriptionMaxTimeout = 60
+	// SubscriptionDefaultMaxRetries is the default max retry times of a failed delivery
+	SubscriptionDefaultMaxRetries = 3
+	// SubscriptionMaxRetries is the max value of event subscription max retry times
+	SubscriptionMaxRetries = 10
+)
+
+// event subscription delivery http headers
+const (
+	// SubscriptionIDHeader is the header that specifies the id of the event subscription
+	SubscriptionIDHeader = "X-Bkcmdb-Subscription-Id"
+	// SubscriptionDeliveryIDHeader is the header that specifies the id of the delivery, retries of a delivery
+	// have the same delivery id, so it can be used to deduplicate the deliveries
+	SubscriptionDeliveryIDHeader = "X-Bkcmdb-Delivery-Id"
+	// SubscriptionTimestampHeader is the header that specifies the unix seconds when the request is sent
+	SubscriptionTimestampHeader = "X-Bkcmdb-Timestamp"
+	// SubscriptionSignatureHeader is the header that specifies the signature of the request, it is the hex encoded
+	// HMAC-SHA256 of "{timestamp}.{body}" using the secret of the event subscription, prefixed with "sha256="
+	SubscriptionSignatureHeader = "X-Bkcmdb-Signature"
+)
+
+// SubscriptionStatus is the status of event subscription
+type SubscriptionStatus string
+
+const (
+	// SubscriptionActive the events of active event subscription are delivered
+	SubscriptionActive SubscriptionStatus = "active"
+	// SubscriptionPaused the events of paused event subscription are not delivered, it continues from where it
+	// is paused after it is resumed
+	SubscriptionPaused SubscriptionStatus = "paused"
+)
+
+// EventSubscription is the subscription that pushes the events of a resource to the callback url
+type EventSubscription struct {
+	ID          int64  `json:"id" bson:"id"`
+	Name        string `json:"name" bson:"name"`
+	CallbackURL string `json:"callback_url" bson:"callback_url"`
+	// Secret is used to sign the delivery requests, it is never returned
+	Secret     string           `json:"-" bson:"secret"`
+	Resource   CursorType       `json:"bk_resource" bson:"bk_resource"`
+	EventTypes []EventType      `json:"bk_event_types" bson:"bk_event_types"`
+	Fields     []string         `json:"bk_fields" bson:"bk_fields"`
+	Filter     WatchEventFilter `json:"bk_filter" bson:"bk_filter"`
+	// BatchSize is the max event count that is delivered in one request
+	BatchSize int `json:"batch_size" bson:"batch_size"`
+	// Timeout is the timeout seconds of one delivery request
+	Timeout int `json:"timeout" bson:"timeout"`
+	// MaxRetries is the max retry times of a failed delivery, the delivery is stored as dead letter after that
+	MaxRetries int                `json:"max_retries" bson:"max_retries"`
+	Status     SubscriptionStatus `json:"status" bson:"status"`
+	// Cursor is the cursor of the last delivered event
+	Cursor string `json:"bk_cursor" bson:"bk_cursor"`
+	// StartFrom is the unix seconds time to where the subscription starts from, it is used only when cursor is not set
+	StartFrom       int64         `json:"bk_start_from" bson:"bk_start_from"`
+	SupplierAccount string        `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator         string        `json:"creator" bson:"creator"`
+	Modifier        string        `json:"modifier" bson:"modifier"`
+	CreateTime      metadata.Time `json:"create_time" bson:"create_time"`
+	LastTime        metadata.Time `json:"last_time" bson:"last_time"`
+}
+
+// WatchOptions returns the watch options to watch the events of the event subscription from its cursor
+func (s *EventSubscription) WatchOptions() *WatchEventOptions {
+	opts := &WatchEventOptions{
+		EventTypes: s.EventTypes,
+		Fields:     s.Fields,
+		Cursor:     s.Cursor,
+		Resource:   s.Resource,
+		Filter:     s.Filter,
+	}
+
+	if len(opts.Cursor) == 0 {
+		if s.StartFrom != 0 {
+			opts.StartFrom = s.StartFrom
+		} else {
+			opts.Cursor = NoEventCursor
+		}
+	}
+	return opts
+}
+
+// CreateEventSubscriptionOption is the create event subscription option
+type CreateEventSubscriptionOption struct {
+	Name        string           `json:"name"`
+	CallbackURL string           `json:"callback_url"`
+	Secret      string           `json:"secret"`
+	Resource    CursorType       `json:"bk_resource"`
+	EventTypes  []EventType      `json:"bk_event_types"`
+	Fields      []string         `json:"bk_fields"`
+	Filter      WatchEventFilter `json:"bk_filter"`
+	BatchSize   int              `json:"batch_size"`
+	Timeout     int              `json:"timeout"`
+	MaxRetries  int              `json:"max_retries"`
+	// StartFrom is the unix seconds time to where the subscription starts from, starts from now if not set
+	StartFrom int64 `json:"bk_start_from"`
+}
+
+// Validate create event subscription option, and set the default values
+func (c *CreateEventSubscriptionOption) Validate() ccErr.RawErrorInfo {
+	if rawErr := validateSubscriptionName(c.Name); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if rawErr := validateSubscriptionCallback(c.CallbackURL); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if len(c.Secret) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"secret"}}
+	}
+
+	if rawErr := validateSubscriptionResource(c.Resource); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if c.StartFrom < 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"bk_start_from"}}
+	}
+
+	watchOpts := &WatchEventOptions{
+		EventTypes: c.EventTypes,
+		Fields:     c.Fields,
+		StartFrom:  c.StartFrom,
+		Resource:   c.Resource,
+		Filter:     c.Filter,
+	}
+	if err := watchOpts.Validate(false); err != nil {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{err.Error()}}
+	}
+
+	var rawErr ccErr.RawErrorInfo
+	if c.BatchSize, rawErr = validateSubscriptionLimit("batch_size", c.BatchSize, SubscriptionDefaultBatchSize,
+		SubscriptionMaxBatchSize); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if c.Timeout, rawErr = validateSubscriptionLimit("timeout", c.Timeout, SubscriptionDefaultTimeout,
+		SubscriptionMaxTimeout); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if c.MaxRetries, rawErr = validateSubscriptionLimit("max_retries", c.MaxRetries, SubscriptionDefaultMaxRetries,
+		SubscriptionMaxRetries); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// UpdateEventSubscriptionOption is the update event subscription option, only the specified fields are updated.
+// NOTE: the resource of the event subscription can not be updated, and the updated event subscription continues
+// from where it is delivered with the new options.
+type UpdateEventSubscriptionOption struct {
+	Name        *string           `json:"name"`
+	CallbackURL *string           `json:"callback_url"`
+	Secret      *string           `json:"secret"`
+	EventTypes  []EventType       `json:"bk_event_types"`
+	Fields      []string          `json:"bk_fields"`
+	Filter      *WatchEventFilter `json:"bk_filter"`
+	BatchSize   *int              `json:"batch_size"`
+	Timeout     *int              `json:"timeout"`
+	MaxRetries  *int              `json:"max_retries"`
+}
+
+// Validate update event subscription option, and apply the update data to the event subscription
+func (u *UpdateEventSubscriptionOption) Validate(subscription *EventSubscription) ccErr.RawErrorInfo {
+	if u.Name != nil {
+		if rawErr := validateSubscriptionName(*u.Name); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+		subscription.Name = *u.Name
+	}
+
+	if u.CallbackURL != nil {
+		if rawErr := validateSubscriptionCallback(*u.CallbackURL); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+		subscription.CallbackURL = *u.CallbackURL
+	}
+
+	if u.Secret != nil {
+		if len(*u.Secret) == 0 {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"secret"}}
+		}
+		subscription.Secret = *u.Secret
+	}
+
+	if u.EventTypes != nil {
+		subscription.EventTypes = u.EventTypes
+	}
+
+	if u.Fields != nil {
+		subscription.Fields = u.Fields
+	}
+
+	if u.Filter != nil {
+		subscription.Filter = *u.Filter
+	}
+
+	watchOpts := &WatchEventOptions{
+		EventTypes: subscription.EventTypes,
+		Fields:     subscription.Fields,
+		Resource:   subscription.Resource,
+		Filter:     subscription.Filter,
+	}
+	if err := watchOpts.Validate(false); err != nil {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{err.Error()}}
+	}
+
+	var rawErr ccErr.RawErrorInfo
+	if u.BatchSize != nil {
+		if subscription.BatchSize, rawErr = validateSubscriptionLimit("batch_size", *u.BatchSize,
+			SubscriptionDefaultBatchSize, SubscriptionMaxBatchSize); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+	}
+
+	if u.Timeout != nil {
+		if subscription.Timeout, rawErr = validateSubscriptionLimit("timeout", *u.Timeout, SubscriptionDefaultTimeout,
+			SubscriptionMaxTimeout); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+	}
+
+	if u.MaxRetries != nil {
+		if subscription.MaxRetries, rawErr = validateSubscriptionLimit("max_retries", *u.MaxRetries,
+			SubscriptionDefaultMaxRetries, SubscriptionMaxRetries); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+func validateSubscriptionName(name string) ccErr.RawErrorInfo {
+	if len(name) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"name"}}
+	}
+
+	if len(name) > SubscriptionMaxNameLength {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{"name", SubscriptionMaxNameLength}}
+	}
+	return ccErr.RawErrorInfo{}
+}
+
+// validateSubscriptionCallback validate the callback url, all the ips that its host is resolved to must be allowed.
+// NOTE: the host may be resolved to other ips when delivering, so the connected ip is checked again when delivering.
+func validateSubscriptionCallback(callback string) ccErr.RawErrorInfo {
+	if len(callback) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"callback_url"}}
+	}
+
+	u, err := url.Parse(callback)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Hostname()) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"callback_url"}}
+	}
+
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil || len(ips) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"callback_url"}}
+	}
+
+	for _, ip := range ips {
+		if err = ValidateCallbackIP(ip); err != nil {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"callback_url"}}
+		}
+	}
+	return ccErr.RawErrorInfo{}
+}
+
+// callbackBlockedIPNets is the ip ranges that are not covered by the ip classification methods but can not be used
+// as callback ip, including "this network" and the shared address space where some cloud metadata services are in.
+var callbackBlockedIPNets = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
+
+// ValidateCallbackIP check if the events of event subscription can be delivered to the ip, loopback, private,
+// link-local(including the cloud metadata services like 169.254.169.254), multicast and unspecified ips are rejected,
+// so that the callback can not be used to access the internal services.
+func ValidateCallbackIP(ip net.IP) error {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("callback ip %s is internal address", ip)
+	}
+
+	for _, ipNet := range callbackBlockedIPNets {
+		if ipNet.Contains(ip) {
+			return fmt.Errorf("callback ip %s is internal address", ip)
+		}
+	}
+	return nil
+}
+
+func validateSubscriptionResource(resource CursorType) ccErr.RawErrorInfo {
+	for _, cursorType := range ListCursorTypes() {
+		if resource == cursorType {
+			return ccErr.RawErrorInfo{}
+		}
+	}
+	return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"bk_resource"}}
+}
+
+// validateSubscriptionLimit validate the limit value of event subscription, returns the default value if not set
+func validateSubscriptionLimit(field string, value, defaultValue, maxValue int) (int, ccErr.RawErrorInfo) {
+	if value == 0 {
+		return defaultValue, ccErr.RawErrorInfo{}
+	}
+
+	if value < 0 {
+		return 0, ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{field}}
+	}
+
+	if value > maxValue {
+		return 0, ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed, Args: []interface{}{field, maxValue}}
+	}
+	return value, ccErr.RawErrorInfo{}
+}
+
+// ListEventSubscriptionResult is the list event subscription result
+type ListEventSubscriptionResult struct {
+	Count uint64               `json:"count"`
+	Info  []*EventSubscription `json:"info"`
+}
+
+// DeliveryStatus is the status of event subscription delivery
+type DeliveryStatus string
+
+const (
+	// DeliverySuccess the events are delivered successfully
+	DeliverySuccess DeliveryStatus = "success"
+	// DeliveryFailed the events are failed to be delivered after all retries, they are stored as dead letter
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// SubscriptionPayload is the request body that is posted to the callback url of the event subscription
+type SubscriptionPayload struct {
+	SubscriptionID int64               `json:"subscription_id"`
+	DeliveryID     int64               `json:"delivery_id"`
+	Resource       CursorType          `json:"bk_resource"`
+	Events         []*WatchEventDetail `json:"bk_events"`
+}
+
+// SubscriptionDelivery is the delivery history of event subscription
+type SubscriptionDelivery struct {
+	ID             int64          `json:"id" bson:"id"`
+	SubscriptionID int64          `json:"subscription_id" bson:"subscription_id"`
+	Status         DeliveryStatus `json:"status" bson:"status"`
+	// StartCursor and EndCursor is the cursor of the first and the last delivered event
+	StartCursor string `json:"start_cursor" bson:"start_cursor"`
+	EndCursor   string `json:"end_cursor" bson:"end_cursor"`
+	EventCount  int    `json:"event_count" bson:"event_count"`
+	// Attempts is the request times of the delivery, including the retries
+	Attempts int `json:"attempts" bson:"attempts"`
+	// StatusCode is the http status code of the last request, 0 means the request is not responded
+	StatusCode int    `json:"status_code" bson:"status_code"`
+	Error      string `json:"error" bson:"error"`
+	// Cost is the milliseconds that the delivery takes, including the retries
+	Cost            int64         `json:"cost" bson:"cost"`
+	SupplierAccount string        `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime      metadata.Time `json:"create_time" bson:"create_time"`
+}
+
+// SubscriptionDeadLetter is the events that are failed to be delivered after all retries
+type SubscriptionDeadLetter struct {
+	ID             int64      `json:"id" bson:"id"`
+	SubscriptionID int64      `json:"subscription_id" bson:"subscription_id"`
+	DeliveryID     int64      `json:"delivery_id" bson:"delivery_id"`
+	Resource       CursorType `json:"bk_resource" bson:"bk_resource"`
+	// Payload is the json encoded SubscriptionPayload request body of the failed delivery
+	Payload         string        `json:"payload" bson:"payload"`
+	Error           string        `json:"error" bson:"error"`
+	SupplierAccount string        `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime      metadata.Time `json:"create_time" bson:"create_time"`
+}
+
+// ListSubscriptionRecordOption is the option to list delivery histories or dead letters of an event subscription
+type ListSubscriptionRecordOption struct {
+	SubscriptionID int64             `json:"subscription_id"`
+	Page           metadata.BasePage `json:"page"`
+}
+
+// Validate list subscription record option
+func (l *ListSubscriptionRecordOption) Validate() ccErr.RawErrorInfo {
+	if l.SubscriptionID <= 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"subscription_id"}}
+	}
+
+	return l.Page.ValidateWithEnableCount(false, common.BKMaxPageSize)
+}
+
+// ListSubscriptionDeliveryResult is the list event subscription delivery history result
+type ListSubscriptionDeliveryResult struct {
+	Count uint64                  `json:"count"`
+	Info  []*SubscriptionDelivery `json:"info"`
+}
+
+// ListSubscriptionDeadLetterResult is the list event subscription dead letter result
+type ListSubscriptionDeadLetterResult struct {
+	Count uint64                    `json:"count"`
+	Info  []*SubscriptionDeadLetter `json:"info"`
+}
@@ -0,0 +1,88 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package watch
+
+import (
+	"net"
+	"testing"
+)
+
+func TestValidateCallbackIP(t *testing.T) {
+	testCases := []struct {
+		ip      string
+		allowed bool
+	}{
+		{ip: "1.1.1.1", allowed: true},
+		{ip: "203.0.113.10", allowed: true},
+		{ip: "2001:4860:4860::8888", allowed: true},
+		{ip: "127.0.0.1"},
+		{ip: "::1"},
+		{ip: "10.0.0.1"},
+		{ip: "172.16.0.1"},
+		{ip: "192.168.1.1"},
+		{ip: "fd00::1"},
+		{ip: "169.254.169.254"},
+		{ip: "fe80::1"},
+		{ip: "100.100.100.200"},
+		{ip: "0.0.0.0"},
+		{ip: "::"},
+		{ip: "224.0.0.1"},
+		{ip: "::ffff:127.0.0.1"},
+		{ip: "::ffff:10.0.0.1"},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateCallbackIP(net.ParseIP(tc.ip))
+		if tc.allowed && err != nil {
+			t.Errorf("callback ip %s should be allowed, but got err: %v", tc.ip, err)
+		}
+		if !tc.allowed && err == nil {
+			t.Errorf("callback ip %s should not be allowed", tc.ip)
+		}
+	}
+}
+
+func TestValidateSubscriptionCallback(t *testing.T) {
+	testCases := []struct {
+		callback string
+		valid    bool
+	}{
+		{callback: "http://1.1.1.1/callback", valid: true},
+		{callback: "https://1.1.1.1:8443/callback", valid: true},
+		{callback: "https://[2001:4860:4860::8888]/callback", valid: true},
+		{callback: ""},
+		{callback: "ftp://1.1.1.1/callback"},
+		{callback: "http:///callback"},
+		{callback: "http://127.0.0.1:8080/callback"},
+		{callback: "http://localhost/callback"},
+		{callback: "http://[::1]/callback"},
+		{callback: "http://10.0.0.1/callback"},
+		{callback: "http://169.254.169.254/latest/meta-data"},
+		{callback: "http://0x7f000001/callback"},
+		{callback: "http://callback.invalid/callback"},
+	}
+
+	for _, tc := range testCases {
+		rawErr := validateSubscriptionCallback(tc.callback)
+		if tc.valid && rawErr.ErrCode != 0 {
+			t.Errorf("callback %s should be valid, but got err: %+v", tc.callback, rawErr)
+		}
+		if !tc.valid && rawErr.ErrCode == 0 {
+			t.Errorf("callback %s should be invalid", tc.callback)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 
+	"configcenter/pkg/filter"
 	"configcenter/src/common/metadata"
 )
 
@@ -44,9 +45,20 @@ type WatchEventOptions struct {
 // WatchEventFilter TODO
 type WatchEventFilter struct {
 	// SubResource the sub resource you want to watch, eg. object ID of the instance resource, watch all if not set
-	SubResource string `json:"bk_sub_resource,omitempty"`
+	SubResource string `json:"bk_sub_resource,omitempty" bson:"bk_sub_resource,omitempty"`
 	// SubResources is the sub resources you want to watch, NOTE: this is a special parameter for internal use only
-	SubResources []string `json:"-"`
+	SubResources []string `json:"-" bson:"-"`
+	// Expression is the filter expression that is evaluated against the whole event detail, only events whose detail
+	// matches the expression will be returned, watch all if not set
+	Expression *filter.Expression `json:"expression,omitempty" bson:"expression,omitempty"`
+	// UpdatedFields only update events that updated or removed at least one of these fields will be returned,
+	// other types of events are not filtered by it. NOTE: update events whose changed fields are expired are returned
+	UpdatedFields []string `json:"updated_fields,omitempty" bson:"updated_fields,omitempty"`
+}
+
+// HasContentFilter returns if the filter contains the condition on the event content
+func (w WatchEventFilter) HasContentFilter() bool {
+	return w.Expression != nil || len(w.UpdatedFields) > 0
 }
 
 // Validate watch event options
@@ -75,12 +87,29 @@ func (w *WatchEventOptions) Validate(isInner bool) error {
 
 	if len(w.Filter.SubResource) > 0 || len(w.Filter.SubResources) > 0 {
 		switch w.Resource {
-		case ObjectBase, MainlineInstance, InstAsst, KubeWorkload:
+		case ObjectBase, MainlineInstance, InstAsst, KubeWorkload, DynamicGroupMember, Object, ObjectAttribute,
+			ObjectAttributeGroup, ObjectUnique, ModelAssociation, ServiceInstance, ServiceTemplate, ProcessTemplate,
+			SetTemplate, HostApplyRule, KubeService, KubeIngress, KubeConfigMap, KubeSecret:
 		default:
 			return fmt.Errorf("%s event cannot have sub resource", w.Resource)
 		}
 	}
 
+	if w.Filter.Expression != nil {
+		opt := filter.NewDefaultExprOpt(nil)
+		opt.IgnoreRuleFields = true
+		if err := w.Filter.Expression.Validate(opt); err != nil {
+			return fmt.Errorf("bk_filter.expression is invalid, err: %v", err)
+		}
+	}
+
+	if len(w.Filter.UpdatedFields) > 0 {
+		switch w.Resource {
+		case HostIdentifier, BizSetRelation:
+			return fmt.Errorf("%s event cannot have updated fields filter", w.Resource)
+		}
+	}
+
 	return nil
 }
 
@@ -31,7 +31,8 @@ const (
 )
 
 // ClusterFields merge the fields of the cluster and the details corresponding to the fields together.
-var ClusterFields = table.MergeFields(CommonSpecFieldsDescriptor, BizIDDescriptor, ClusterSpecFieldsDescriptor)
+var ClusterFields = table.MergeFields(CommonSpecFieldsDescriptor, BizIDDescriptor, ClusterSpecFieldsDescriptor,
+	CustomFieldsDescriptor)
 
 // ClusterSpecFieldsDescriptor cluster spec's fields descriptors.
 var ClusterSpecFieldsDescriptor = table.FieldsDescriptors{
@@ -99,6 +100,8 @@ type Cluster struct {
 	NetWork *[]string `json:"network,omitempty" bson:"network"`
 	// Type cluster network type, e.g. INDEPENDENT_CLUSTER, SHARE_CLUSTER etc.
 	Type *ClusterType `json:"type,omitempty" bson:"type"`
+	// CustomFields custom attributes of the cluster
+	CustomFields *CustomFields `json:"custom_fields,omitempty" bson:"custom_fields"`
 	// Revision record this app's revision information
 	table.Revision `json:",inline" bson:",inline"`
 }
@@ -239,8 +242,8 @@ type QueryClusterOption struct {
 	Fields []string           `json:"fields"`
 }
 
-// Validate the QueryClusterOption
-func (option *QueryClusterOption) Validate() ccErr.RawErrorInfo {
+// Validate the QueryClusterOption, customFieldsType is the filter field types of the cluster custom attributes
+func (option *QueryClusterOption) Validate(customFieldsType map[string]enumor.FieldType) ccErr.RawErrorInfo {
 	if option.BizID == 0 {
 		return ccErr.RawErrorInfo{
 			ErrCode: common.CCErrCommParamsNeedSet,
@@ -256,7 +259,7 @@ func (option *QueryClusterOption) Validate() ccErr.RawErrorInfo {
 		return ccErr.RawErrorInfo{}
 	}
 
-	op := filter.NewDefaultExprOpt(ClusterFields.FieldsType())
+	op := filter.NewDefaultExprOpt(mergeFieldsType(ClusterFields.FieldsType(), customFieldsType))
 	if err := option.Filter.Validate(op); err != nil {
 		return ccErr.RawErrorInfo{
 			ErrCode: common.CCErrCommParamsInvalid,
@@ -0,0 +1,139 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package types
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/criteria/enumor"
+	"configcenter/src/common/errors"
+	"configcenter/src/storage/dal/table"
+)
+
+// ConfigMapFields merge the fields of the ConfigMap and the details corresponding to the fields together.
+var ConfigMapFields = table.MergeFields(CommonSpecFieldsDescriptor, BizIDDescriptor, NamespaceBaseRefDescriptor,
+	ClusterBaseRefDescriptor, NsResourceBaseDescriptor, ConfigMapSpecFieldsDescriptor)
+
+// ConfigMapSpecFieldsDescriptor ConfigMap spec's fields descriptors.
+var ConfigMapSpecFieldsDescriptor = table.FieldsDescriptors{
+	{Field: DataKeysField, Type: enumor.Array, IsRequired: false, IsEditable: true},
+	{Field: ImmutableField, Type: enumor.Boolean, IsRequired: false, IsEditable: true},
+}
+
+// SecretFields merge the fields of the Secret and the details corresponding to the fields together.
+var SecretFields = table.MergeFields(CommonSpecFieldsDescriptor, BizIDDescriptor, NamespaceBaseRefDescriptor,
+	ClusterBaseRefDescriptor, NsResourceBaseDescriptor, SecretSpecFieldsDescriptor)
+
+// SecretSpecFieldsDescriptor Secret spec's fields descriptors.
+var SecretSpecFieldsDescriptor = table.FieldsDescriptors{
+	{Field: TypeField, Type: enumor.String, IsRequired: false, IsEditable: true},
+	{Field: DataKeysField, Type: enumor.Array, IsRequired: false, IsEditable: true},
+	{Field: ImmutableField, Type: enumor.Boolean, IsRequired: false, IsEditable: true},
+}
+
+// ConfigMap define the configmap struct, only the keys of the data are stored, the values are not stored in cc.
+type ConfigMap struct {
+	NsResourceBase `json:",inline" bson:",inline"`
+	DataKeys       *[]string `json:"data_keys,omitempty" bson:"data_keys"`
+	Immutable      *bool     `json:"immutable,omitempty" bson:"immutable"`
+}
+
+// GetNsResourceBase get namespace resource base
+func (c *ConfigMap) GetNsResourceBase() NsResourceBase {
+	return c.NsResourceBase
+}
+
+// SetNsResourceBase set namespace resource base
+func (c *ConfigMap) SetNsResourceBase(base NsResourceBase) {
+	c.NsResourceBase = base
+}
+
+// ValidateCreate validate create configmap
+func (c *ConfigMap) ValidateCreate() errors.RawErrorInfo {
+	if c == nil {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"data"},
+		}
+	}
+
+	return c.NsResourceBase.validateCreate(*c, ConfigMapFields)
+}
+
+// ValidateUpdate validate update configmap
+func (c *ConfigMap) ValidateUpdate() errors.RawErrorInfo {
+	if c == nil {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"data"},
+		}
+	}
+
+	return ValidateUpdate(*c, ConfigMapFields)
+}
+
+// BuildUpdateData build configmap update data
+func (c *ConfigMap) BuildUpdateData(user string) (map[string]interface{}, error) {
+	return buildNsResUpdateData(c, user)
+}
+
+// Secret define the secret struct, only the metadata and the keys of the data are stored, the secret values
+// must never be stored in cc.
+type Secret struct {
+	NsResourceBase `json:",inline" bson:",inline"`
+	Type           *string   `json:"type,omitempty" bson:"type"`
+	DataKeys       *[]string `json:"data_keys,omitempty" bson:"data_keys"`
+	Immutable      *bool     `json:"immutable,omitempty" bson:"immutable"`
+}
+
+// GetNsResourceBase get namespace resource base
+func (s *Secret) GetNsResourceBase() NsResourceBase {
+	return s.NsResourceBase
+}
+
+// SetNsResourceBase set namespace resource base
+func (s *Secret) SetNsResourceBase(base NsResourceBase) {
+	s.NsResourceBase = base
+}
+
+// ValidateCreate validate create secret
+func (s *Secret) ValidateCreate() errors.RawErrorInfo {
+	if s == nil {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"data"},
+		}
+	}
+
+	return s.NsResourceBase.validateCreate(*s, SecretFields)
+}
+
+// ValidateUpdate validate update secret
+func (s *Secret) ValidateUpdate() errors.RawErrorInfo {
+	if s == nil {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"data"},
+		}
+	}
+
+	return ValidateUpdate(*s, SecretFields)
+}
+
+// BuildUpdateData build secret update data
+func (s *Secret) BuildUpdateData(user string) (map[string]interface{}, error) {
+	return buildNsResUpdateData(s, user)
+}
@@ -0,0 +1,172 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package types
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/criteria/enumor"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/table"
+)
+
+// CustomFieldsDescriptor the description of the custom fields, its sub fields are validated by the custom attributes.
+var CustomFieldsDescriptor = table.FieldsDescriptors{
+	{Field: CustomFieldsField, Type: enumor.Object, IsRequired: false, IsEditable: true},
+}
+
+// CustomFields custom attributes of kube cluster, namespace and workload, the key is the property id of the
+// attribute that is defined through the model attribute apis with the related inner object id, e.g. the custom
+// attributes of cluster are defined by the attributes of bk_kube_cluster object.
+type CustomFields map[string]interface{}
+
+// GetCustomFieldObjID get the inner object id that defines the custom attributes of the kube resource type
+func GetCustomFieldObjID(kind string) (string, error) {
+	switch kind {
+	case KubeCluster:
+		return common.BKInnerObjIDKubeCluster, nil
+	case KubeNamespace:
+		return common.BKInnerObjIDKubeNamespace, nil
+	case KubeWorkload:
+		return common.BKInnerObjIDKubeWorkload, nil
+	default:
+		return "", fmt.Errorf("kube resource type %s does not support custom attributes", kind)
+	}
+}
+
+// isCustomFieldTypeSupported returns if the attribute of the property type can be used as a custom attribute,
+// attributes like inner table and id rule need other tables or auto generated values, which kube resources
+// do not support.
+func isCustomFieldTypeSupported(propertyType string) bool {
+	switch propertyType {
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat,
+		common.FieldTypeEnum, common.FieldTypeEnumMulti, common.FieldTypeEnumQuote, common.FieldTypeDate,
+		common.FieldTypeTime, common.FieldTypeTimeZone, common.FieldTypeBool, common.FieldTypeUser,
+		common.FieldTypeList, common.FieldTypeOrganization:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidateCustomFields validate the custom fields by the custom attributes of the kube resource.
+// NOTE: required attributes are not checked when creating, because kube resources are mostly created by the
+// collectors that know nothing about the custom attributes.
+func ValidateCustomFields(ctx context.Context, attrs []metadata.Attribute, fields *CustomFields,
+	isUpdate bool) ccErr.RawErrorInfo {
+
+	if fields == nil {
+		return ccErr.RawErrorInfo{}
+	}
+
+	attrMap := make(map[string]metadata.Attribute)
+	for _, attr := range attrs {
+		attrMap[attr.PropertyID] = attr
+	}
+
+	for key, value := range *fields {
+		attr, exists := attrMap[key]
+		if !exists || !isCustomFieldTypeSupported(attr.PropertyType) {
+			return ccErr.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsIsInvalid,
+				Args:    []interface{}{CustomFieldsField + "." + key},
+			}
+		}
+
+		if isUpdate && !attr.IsEditable {
+			return ccErr.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsIsInvalid,
+				Args:    []interface{}{CustomFieldsField + "." + key},
+			}
+		}
+
+		if err := attr.Validate(ctx, value, key); err.ErrCode != 0 {
+			return err
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// CustomFieldsType returns the filter field types of the custom attributes, so that the kube resources can be
+// searched by the custom fields like "custom_fields.property_id".
+func CustomFieldsType(attrs []metadata.Attribute) map[string]enumor.FieldType {
+	fieldsType := map[string]enumor.FieldType{CustomFieldsField: enumor.Object}
+	for _, attr := range attrs {
+		if !isCustomFieldTypeSupported(attr.PropertyType) {
+			continue
+		}
+
+		field := CustomFieldsField + "." + attr.PropertyID
+		switch attr.PropertyType {
+		case common.FieldTypeInt, common.FieldTypeFloat:
+			fieldsType[field] = enumor.Numeric
+		case common.FieldTypeBool:
+			fieldsType[field] = enumor.Boolean
+		case common.FieldTypeEnumMulti, common.FieldTypeEnumQuote, common.FieldTypeOrganization:
+			fieldsType[field] = enumor.Array
+		default:
+			fieldsType[field] = enumor.String
+		}
+	}
+
+	return fieldsType
+}
+
+// mergeFieldsType merge the custom fields type into the fields type of the kube resource
+func mergeFieldsType(fieldsType, customFieldsType map[string]enumor.FieldType) map[string]enumor.FieldType {
+	for field, typ := range customFieldsType {
+		fieldsType[field] = typ
+	}
+	return fieldsType
+}
+
+// InitCustomFields set the custom fields to empty if it is not set when creating kube resource, so that the
+// custom fields can be partially updated by the dotted fields afterwards.
+func InitCustomFields(fields *CustomFields) *CustomFields {
+	if fields != nil {
+		return fields
+	}
+	return &CustomFields{}
+}
+
+// FlattenCustomFields converts the custom fields in the update data to the dotted fields, so that only the
+// specified custom fields are updated and the others are preserved.
+func FlattenCustomFields(updateData map[string]interface{}) {
+	value, exists := updateData[CustomFieldsField]
+	if !exists {
+		return
+	}
+
+	var fields map[string]interface{}
+	switch val := value.(type) {
+	case CustomFields:
+		fields = val
+	case map[string]interface{}:
+		fields = val
+	default:
+		return
+	}
+
+	delete(updateData, CustomFieldsField)
+	for key, val := range fields {
+		updateData[CustomFieldsField+"."+key] = val
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package types
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/criteria/enumor"
+	"configcenter/src/common/metadata"
+)
+
+var testCustomAttrs = []metadata.Attribute{
+	{PropertyID: "owner_team", PropertyType: common.FieldTypeSingleChar, IsEditable: true},
+	{PropertyID: "online", PropertyType: common.FieldTypeBool, IsEditable: true},
+	{PropertyID: "sla_tier", PropertyType: common.FieldTypeEnumMulti, IsEditable: false},
+	{PropertyID: "details", PropertyType: common.FieldTypeInnerTable, IsEditable: true},
+}
+
+// TestValidateCustomFields test validate custom fields by custom attributes
+func TestValidateCustomFields(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		fields   *CustomFields
+		isUpdate bool
+		valid    bool
+	}{
+		{fields: nil, valid: true},
+		{fields: &CustomFields{"owner_team": "infra", "online": true}, valid: true},
+		{fields: &CustomFields{"online": "yes"}, valid: false},
+		{fields: &CustomFields{"not_exist": "infra"}, valid: false},
+		{fields: &CustomFields{"details": "infra"}, valid: false},
+		{fields: &CustomFields{"owner_team": "infra"}, isUpdate: true, valid: true},
+		{fields: &CustomFields{"sla_tier": []interface{}{"gold"}}, isUpdate: true, valid: false},
+	}
+
+	for idx, c := range cases {
+		rawErr := ValidateCustomFields(ctx, testCustomAttrs, c.fields, c.isUpdate)
+		if (rawErr.ErrCode == 0) != c.valid {
+			t.Errorf("case %d validate custom fields %v result %v is not %v", idx, c.fields, rawErr, c.valid)
+		}
+	}
+}
+
+// TestCustomFieldsType test the filter field types of custom attributes
+func TestCustomFieldsType(t *testing.T) {
+	fieldsType := CustomFieldsType(testCustomAttrs)
+	expected := map[string]enumor.FieldType{
+		CustomFieldsField:                 enumor.Object,
+		CustomFieldsField + ".owner_team": enumor.String,
+		CustomFieldsField + ".online":     enumor.Boolean,
+		CustomFieldsField + ".sla_tier":   enumor.Array,
+	}
+
+	if len(fieldsType) != len(expected) {
+		t.Fatalf("custom fields type %v is not %v", fieldsType, expected)
+	}
+	for field, typ := range expected {
+		if fieldsType[field] != typ {
+			t.Errorf("custom field %s type %s is not %s", field, fieldsType[field], typ)
+		}
+	}
+}
+
+// TestFlattenCustomFields test flatten custom fields in update data
+func TestFlattenCustomFields(t *testing.T) {
+	updateData := map[string]interface{}{
+		KubeNameField:     "nginx",
+		CustomFieldsField: CustomFields{"owner_team": "infra"},
+	}
+	FlattenCustomFields(updateData)
+
+	if _, exists := updateData[CustomFieldsField]; exists {
+		t.Errorf("custom fields are not flattened, update data: %v", updateData)
+	}
+	if updateData[CustomFieldsField+".owner_team"] != "infra" || updateData[KubeNameField] != "nginx" {
+		t.Errorf("flattened update data %v is invalid", updateData)
+	}
+}
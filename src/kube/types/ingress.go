@@ -0,0 +1,153 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package types
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/criteria/enumor"
+	"configcenter/src/common/errors"
+	"configcenter/src/storage/dal/table"
+)
+
+// IngressFields merge the fields of the Ingress and the details corresponding to the fields together.
+var IngressFields = table.MergeFields(CommonSpecFieldsDescriptor, BizIDDescriptor, NamespaceBaseRefDescriptor,
+	ClusterBaseRefDescriptor, NsResourceBaseDescriptor, IngressSpecFieldsDescriptor)
+
+// IngressSpecFieldsDescriptor Ingress spec's fields descriptors.
+var IngressSpecFieldsDescriptor = table.FieldsDescriptors{
+	{Field: IngressClassNameField, Type: enumor.String, IsRequired: false, IsEditable: true},
+	{Field: DefaultBackendField, Type: enumor.Object, IsRequired: false, IsEditable: true},
+	{Field: RulesField, Type: enumor.Array, IsRequired: false, IsEditable: true},
+	{Field: TLSField, Type: enumor.Array, IsRequired: false, IsEditable: true},
+}
+
+// Ingress define the ingress struct.
+type Ingress struct {
+	NsResourceBase   `json:",inline" bson:",inline"`
+	IngressClassName *string `json:"ingress_class_name,omitempty" bson:"ingress_class_name"`
+	// DefaultBackend is the backend that should handle requests that don't match any rule.
+	DefaultBackend *IngressBackend `json:"default_backend,omitempty" bson:"default_backend"`
+	Rules          *[]IngressRule  `json:"rules,omitempty" bson:"rules"`
+	TLS            *[]IngressTLS   `json:"tls,omitempty" bson:"tls"`
+}
+
+// IngressRule represents the rules mapping the paths under a specified host to the related backend services.
+type IngressRule struct {
+	Host  string        `json:"host" bson:"host"`
+	Paths []IngressPath `json:"paths" bson:"paths"`
+}
+
+// IngressPath associates a path with a backend.
+type IngressPath struct {
+	Path     string         `json:"path" bson:"path"`
+	PathType string         `json:"path_type" bson:"path_type"`
+	Backend  IngressBackend `json:"backend" bson:"backend"`
+}
+
+// IngressBackend describes the service in the same namespace that the traffic is routed to.
+type IngressBackend struct {
+	ServiceName string      `json:"service_name" bson:"service_name"`
+	ServicePort IntOrString `json:"service_port" bson:"service_port"`
+}
+
+// IngressTLS describes the transport layer security associated with an Ingress.
+type IngressTLS struct {
+	Hosts      []string `json:"hosts" bson:"hosts"`
+	SecretName string   `json:"secret_name" bson:"secret_name"`
+}
+
+// GetNsResourceBase get namespace resource base
+func (i *Ingress) GetNsResourceBase() NsResourceBase {
+	return i.NsResourceBase
+}
+
+// SetNsResourceBase set namespace resource base
+func (i *Ingress) SetNsResourceBase(base NsResourceBase) {
+	i.NsResourceBase = base
+}
+
+// ValidateCreate validate create ingress
+func (i *Ingress) ValidateCreate() errors.RawErrorInfo {
+	if i == nil {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"data"},
+		}
+	}
+
+	return i.NsResourceBase.validateCreate(*i, IngressFields)
+}
+
+// ValidateUpdate validate update ingress
+func (i *Ingress) ValidateUpdate() errors.RawErrorInfo {
+	if i == nil {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"data"},
+		}
+	}
+
+	return ValidateUpdate(*i, IngressFields)
+}
+
+// BuildUpdateData build ingress update data
+func (i *Ingress) BuildUpdateData(user string) (map[string]interface{}, error) {
+	return buildNsResUpdateData(i, user)
+}
+
+// GetServiceNames get the names of the backend services that the ingress routes traffic to.
+func (i *Ingress) GetServiceNames() []string {
+	names := make([]string, 0)
+	exists := make(map[string]struct{})
+	add := func(backend *IngressBackend) {
+		if backend == nil || backend.ServiceName == "" {
+			return
+		}
+		if _, ok := exists[backend.ServiceName]; ok {
+			return
+		}
+		exists[backend.ServiceName] = struct{}{}
+		names = append(names, backend.ServiceName)
+	}
+
+	add(i.DefaultBackend)
+	if i.Rules == nil {
+		return names
+	}
+
+	for _, rule := range *i.Rules {
+		for idx := range rule.Paths {
+			add(&rule.Paths[idx].Backend)
+		}
+	}
+	return names
+}
+
+// GetSecretNames get the names of the secrets used by the tls of the ingress.
+func (i *Ingress) GetSecretNames() []string {
+	names := make([]string, 0)
+	if i.TLS == nil {
+		return names
+	}
+
+	for _, tls := range *i.TLS {
+		if tls.SecretName != "" {
+			names = append(names, tls.SecretName)
+		}
+	}
+	return names
+}
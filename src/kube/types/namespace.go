@@ -27,7 +27,7 @@ import (
 
 // NamespaceFields merge the fields of the namespace and the details corresponding to the fields together.
 var NamespaceFields = table.MergeFields(CommonSpecFieldsDescriptor, BizIDDescriptor,
-	ClusterBaseRefDescriptor, NamespaceSpecFieldsDescriptor)
+	ClusterBaseRefDescriptor, NamespaceSpecFieldsDescriptor, CustomFieldsDescriptor)
 
 // NamespaceSpecFieldsDescriptor namespace spec's fields descriptors.
 var NamespaceSpecFieldsDescriptor = table.FieldsDescriptors{
@@ -107,6 +107,8 @@ type Namespace struct {
 	Labels          *map[string]string `json:"labels,omitempty" bson:"labels"`
 	ResourceQuotas  *[]ResourceQuota   `json:"resource_quotas,omitempty" bson:"resource_quotas"`
 	SupplierAccount string             `json:"bk_supplier_account,omitempty" bson:"bk_supplier_account"`
+	// CustomFields custom attributes of the namespace
+	CustomFields *CustomFields `json:"custom_fields,omitempty" bson:"custom_fields"`
 	// Revision record this app's revision information
 	table.Revision `json:",inline" bson:",inline"`
 }
@@ -313,8 +315,8 @@ type NsQueryOption struct {
 	Page   metadata.BasePage  `json:"page,omitempty"`
 }
 
-// Validate validate NsQueryReq
-func (ns *NsQueryOption) Validate() errors.RawErrorInfo {
+// Validate validate NsQueryReq, customFieldsType is the filter field types of the namespace custom attributes
+func (ns *NsQueryOption) Validate(customFieldsType map[string]enumor.FieldType) errors.RawErrorInfo {
 	if ns.BizID == 0 {
 		return errors.RawErrorInfo{
 			ErrCode: common.CCErrCommParamsNeedSet,
@@ -330,7 +332,7 @@ func (ns *NsQueryOption) Validate() errors.RawErrorInfo {
 		return errors.RawErrorInfo{}
 	}
 
-	op := filter.NewDefaultExprOpt(NamespaceFields.FieldsType())
+	op := filter.NewDefaultExprOpt(mergeFieldsType(NamespaceFields.FieldsType(), customFieldsType))
 	if err := ns.Filter.Validate(op); err != nil {
 		return errors.RawErrorInfo{
 			ErrCode: common.CCErrCommParamsInvalid,
@@ -0,0 +1,586 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common"
+	"configcenter/src/common/criteria/enumor"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/kube/orm"
+	"configcenter/src/storage/dal/table"
+
+	"github.com/tidwall/gjson"
+)
+
+// NsResourceKind is the kind of namespace scoped kube resources other than workloads and pods, including the
+// network resources(service, ingress) and the config resources(configmap, secret).
+type NsResourceKind string
+
+const (
+	// KubeService k8s service type
+	KubeService NsResourceKind = "service"
+
+	// KubeIngress k8s ingress type
+	KubeIngress NsResourceKind = "ingress"
+
+	// KubeConfigMap k8s configmap type
+	KubeConfigMap NsResourceKind = "configmap"
+
+	// KubeSecret k8s secret type, only the metadata of the secret is stored in cc
+	KubeSecret NsResourceKind = "secret"
+)
+
+// GetNsResourceKinds get all namespace resource kinds
+func GetNsResourceKinds() []NsResourceKind {
+	return []NsResourceKind{KubeService, KubeIngress, KubeConfigMap, KubeSecret}
+}
+
+// GetNsResourceTables get the table names of all namespace resources
+func GetNsResourceTables() []string {
+	return []string{BKTableNameBaseService, BKTableNameBaseIngress, BKTableNameBaseConfigMap, BKTableNameBaseSecret}
+}
+
+// Validate validate NsResourceKind
+func (k NsResourceKind) Validate() error {
+	switch k {
+	case KubeService, KubeIngress, KubeConfigMap, KubeSecret:
+		return nil
+	default:
+		return fmt.Errorf("can not support this kind of namespace resource, kind: %s", k)
+	}
+}
+
+// Table get the table name based on the namespace resource kind
+func (k NsResourceKind) Table() (string, error) {
+	switch k {
+	case KubeService:
+		return BKTableNameBaseService, nil
+
+	case KubeIngress:
+		return BKTableNameBaseIngress, nil
+
+	case KubeConfigMap:
+		return BKTableNameBaseConfigMap, nil
+
+	case KubeSecret:
+		return BKTableNameBaseSecret, nil
+
+	default:
+		return "", fmt.Errorf("can not find table name, kind: %s", k)
+	}
+}
+
+// Fields get the namespace resource kind related table fields
+func (k NsResourceKind) Fields() (*table.Fields, error) {
+	switch k {
+	case KubeService:
+		return ServiceFields, nil
+
+	case KubeIngress:
+		return IngressFields, nil
+
+	case KubeConfigMap:
+		return ConfigMapFields, nil
+
+	case KubeSecret:
+		return SecretFields, nil
+
+	default:
+		return nil, fmt.Errorf("namespace resource kind %s is not supported", k)
+	}
+}
+
+// NewInst new a namespace resource instance according to the kind
+func (k NsResourceKind) NewInst() (NsResourceInterface, error) {
+	switch k {
+	case KubeService:
+		return new(Service), nil
+
+	case KubeIngress:
+		return new(Ingress), nil
+
+	case KubeConfigMap:
+		return new(ConfigMap), nil
+
+	case KubeSecret:
+		return new(Secret), nil
+
+	default:
+		return nil, fmt.Errorf("namespace resource kind %s is not supported", k)
+	}
+}
+
+const (
+	// NsResUpdateLimit limit on the number of namespace resource updates
+	NsResUpdateLimit = 200
+	// NsResDeleteLimit limit on the number of namespace resource delete
+	NsResDeleteLimit = 200
+	// NsResCreateLimit limit on the number of namespace resource create
+	NsResCreateLimit = 200
+	// NsResQueryLimit limit on the number of namespace resource query
+	NsResQueryLimit = 500
+	// NsResRelationLimit limit on the number of resources to find relations for
+	NsResRelationLimit = 100
+)
+
+// NsResourceBaseDescriptor the common fields descriptors of namespace resources.
+var NsResourceBaseDescriptor = table.FieldsDescriptors{
+	{Field: KubeNameField, Type: enumor.String, IsRequired: true, IsEditable: false},
+	{Field: LabelsField, Type: enumor.MapString, IsRequired: false, IsEditable: true},
+}
+
+// NsResourceInterface defines the namespace resource data common operation.
+type NsResourceInterface interface {
+	ValidateCreate() errors.RawErrorInfo
+	ValidateUpdate() errors.RawErrorInfo
+	GetNsResourceBase() NsResourceBase
+	SetNsResourceBase(base NsResourceBase)
+	BuildUpdateData(user string) (map[string]interface{}, error)
+}
+
+// NsResourceBase define the namespace resource common struct, specific attributes are placed in their respective
+// structures.
+type NsResourceBase struct {
+	NamespaceSpec   `json:",inline" bson:",inline"`
+	ID              int64              `json:"id,omitempty" bson:"id"`
+	Name            string             `json:"name,omitempty" bson:"name"`
+	Labels          *map[string]string `json:"labels,omitempty" bson:"labels"`
+	SupplierAccount string             `json:"bk_supplier_account,omitempty" bson:"bk_supplier_account"`
+	// Revision record this app's revision information
+	table.Revision `json:",inline" bson:",inline"`
+}
+
+// validateCreate validate the base of the namespace resource to be created, data is the value of the resource
+func (b *NsResourceBase) validateCreate(data interface{}, fields *table.Fields) errors.RawErrorInfo {
+	if b.BizID == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKAppIDField},
+		}
+	}
+
+	if b.NamespaceID == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{BKNamespaceIDField},
+		}
+	}
+
+	if b.Name == "" {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKFieldName},
+		}
+	}
+
+	return ValidateCreate(data, fields)
+}
+
+var nsResIgnoreField = []string{
+	common.BKAppIDField, BKClusterIDFiled, ClusterUIDField, BKNamespaceIDField, NamespaceField, common.BKFieldName,
+	common.BKFieldID, common.CreateTimeField,
+}
+
+// buildNsResUpdateData build the update data of the namespace resource
+func buildNsResUpdateData(data interface{}, user string) (map[string]interface{}, error) {
+	opts := orm.NewFieldOptions().AddIgnoredFields(nsResIgnoreField...)
+	updateData, err := orm.GetUpdateFieldsWithOption(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	updateData[common.LastTimeField] = time.Now().Unix()
+	updateData[common.ModifierField] = user
+	return updateData, nil
+}
+
+// NsResArrayUnmarshalJSON unmarshal namespace resource array json
+func NsResArrayUnmarshalJSON(kind NsResourceKind, js []byte) ([]NsResourceInterface, error) {
+	newInst, err := kind.NewInst()
+	if err != nil {
+		return nil, err
+	}
+
+	info := reflect.New(reflect.SliceOf(reflect.ValueOf(newInst).Type())).Elem().Addr().Interface()
+	if err = json.Unmarshal(js, info); err != nil {
+		return nil, err
+	}
+
+	infoArr := reflect.ValueOf(info).Elem()
+	resources := make([]NsResourceInterface, infoArr.Len())
+	for i := range resources {
+		resources[i] = infoArr.Index(i).Interface().(NsResourceInterface)
+	}
+
+	return resources, nil
+}
+
+type jsonNsResData struct {
+	BizID int64           `json:"bk_biz_id"`
+	IDs   []int64         `json:"ids"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// NsResCreateOption create namespace resource request
+type NsResCreateOption struct {
+	BizID int64                 `json:"bk_biz_id"`
+	Kind  NsResourceKind        `json:"kind"`
+	Data  []NsResourceInterface `json:"data"`
+}
+
+// UnmarshalJSON unmarshal NsResCreateOption, the kind must be set before unmarshal
+func (o *NsResCreateOption) UnmarshalJSON(data []byte) error {
+	req := new(jsonNsResData)
+	if err := json.Unmarshal(data, req); err != nil {
+		return err
+	}
+
+	o.BizID = req.BizID
+
+	if len(req.Data) == 0 {
+		return nil
+	}
+
+	if err := o.Kind.Validate(); err != nil {
+		return err
+	}
+
+	createData, err := NsResArrayUnmarshalJSON(o.Kind, req.Data)
+	if err != nil {
+		return err
+	}
+	o.Data = createData
+	return nil
+}
+
+// Validate validate NsResCreateOption
+func (o *NsResCreateOption) Validate() errors.RawErrorInfo {
+	if o.BizID == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKAppIDField},
+		}
+	}
+
+	if len(o.Data) == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"data"},
+		}
+	}
+
+	if len(o.Data) > NsResCreateLimit {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommXXExceedLimit,
+			Args:    []interface{}{"data", NsResCreateLimit},
+		}
+	}
+
+	for i := range o.Data {
+		base := o.Data[i].GetNsResourceBase()
+		base.BizID = o.BizID
+		o.Data[i].SetNsResourceBase(base)
+		if err := o.Data[i].ValidateCreate(); err.ErrCode != 0 {
+			return err
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// NsResUpdateOption update namespace resource request
+type NsResUpdateOption struct {
+	BizID int64 `json:"bk_biz_id"`
+	NsResUpdateByIDsOption
+}
+
+// UnmarshalJSON unmarshal NsResUpdateOption, the kind must be set before unmarshal
+func (o *NsResUpdateOption) UnmarshalJSON(data []byte) error {
+	o.BizID = gjson.GetBytes(data, common.BKAppIDField).Int()
+	return json.Unmarshal(data, &o.NsResUpdateByIDsOption)
+}
+
+// Validate validate NsResUpdateOption
+func (o *NsResUpdateOption) Validate() errors.RawErrorInfo {
+	if o.BizID == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKAppIDField},
+		}
+	}
+
+	return o.NsResUpdateByIDsOption.Validate()
+}
+
+// NsResUpdateByIDsOption update namespace resource by ids request
+type NsResUpdateByIDsOption struct {
+	Kind NsResourceKind      `json:"kind"`
+	IDs  []int64             `json:"ids"`
+	Data NsResourceInterface `json:"data"`
+}
+
+// UnmarshalJSON unmarshal NsResUpdateByIDsOption, the kind must be set before unmarshal
+func (o *NsResUpdateByIDsOption) UnmarshalJSON(data []byte) error {
+	if err := o.Kind.Validate(); err != nil {
+		return err
+	}
+
+	req := new(jsonNsResData)
+	if err := json.Unmarshal(data, req); err != nil {
+		return err
+	}
+	o.IDs = req.IDs
+
+	if len(req.Data) == 0 {
+		return nil
+	}
+
+	inst, err := o.Kind.NewInst()
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(req.Data, inst); err != nil {
+		return err
+	}
+	o.Data = inst
+	return nil
+}
+
+// Validate validate NsResUpdateByIDsOption
+func (o *NsResUpdateByIDsOption) Validate() errors.RawErrorInfo {
+	if len(o.IDs) == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"ids"},
+		}
+	}
+
+	if len(o.IDs) > NsResUpdateLimit {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommXXExceedLimit,
+			Args:    []interface{}{"ids", NsResUpdateLimit},
+		}
+	}
+
+	if o.Data == nil {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"data"},
+		}
+	}
+
+	return o.Data.ValidateUpdate()
+}
+
+// NsResDeleteOption delete namespace resource request
+type NsResDeleteOption struct {
+	BizID int64 `json:"bk_biz_id"`
+	NsResDeleteByIDsOption
+}
+
+// Validate validate NsResDeleteOption
+func (o *NsResDeleteOption) Validate() errors.RawErrorInfo {
+	if o.BizID == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKAppIDField},
+		}
+	}
+
+	return o.NsResDeleteByIDsOption.Validate()
+}
+
+// NsResDeleteByIDsOption delete namespace resource by ids request
+type NsResDeleteByIDsOption struct {
+	IDs []int64 `json:"ids"`
+}
+
+// Validate validate NsResDeleteByIDsOption
+func (o *NsResDeleteByIDsOption) Validate() errors.RawErrorInfo {
+	if len(o.IDs) == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"ids"},
+		}
+	}
+
+	if len(o.IDs) > NsResDeleteLimit {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommXXExceedLimit,
+			Args:    []interface{}{"ids", NsResDeleteLimit},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// NsResQueryOption namespace resource query request
+type NsResQueryOption struct {
+	BizID  int64              `json:"bk_biz_id"`
+	Filter *filter.Expression `json:"filter"`
+	Fields []string           `json:"fields,omitempty"`
+	Page   metadata.BasePage  `json:"page,omitempty"`
+}
+
+// Validate validate NsResQueryOption
+func (o *NsResQueryOption) Validate(kind NsResourceKind) errors.RawErrorInfo {
+	if o.BizID == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKAppIDField},
+		}
+	}
+
+	if err := o.Page.ValidateWithEnableCount(false, NsResQueryLimit); err.ErrCode != 0 {
+		return err
+	}
+
+	fields, err := kind.Fields()
+	if err != nil {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsIsInvalid,
+			Args:    []interface{}{KindField},
+		}
+	}
+
+	if o.Filter == nil {
+		return errors.RawErrorInfo{}
+	}
+
+	op := filter.NewDefaultExprOpt(fields.FieldsType())
+	if err := o.Filter.Validate(op); err != nil {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{err.Error()},
+		}
+	}
+	return errors.RawErrorInfo{}
+}
+
+// NsResDataResp namespace resource data
+type NsResDataResp struct {
+	Kind NsResourceKind        `json:"kind"`
+	Info []NsResourceInterface `json:"info"`
+}
+
+type jsonNsResDataResp struct {
+	Info json.RawMessage `json:"info"`
+}
+
+// UnmarshalJSON unmarshal NsResDataResp, the kind must be set before unmarshal
+func (r *NsResDataResp) UnmarshalJSON(data []byte) error {
+	if err := r.Kind.Validate(); err != nil {
+		return err
+	}
+
+	resp := new(jsonNsResDataResp)
+	if err := json.Unmarshal(data, resp); err != nil {
+		return err
+	}
+
+	if len(resp.Info) == 0 {
+		return nil
+	}
+
+	info, err := NsResArrayUnmarshalJSON(r.Kind, resp.Info)
+	if err != nil {
+		return err
+	}
+	r.Info = info
+	return nil
+}
+
+// NsResInstResp namespace resource instance response
+type NsResInstResp struct {
+	metadata.BaseResp `json:",inline"`
+	Data              NsResDataResp `json:"data"`
+}
+
+// NsResCreateResp create namespace resource response
+type NsResCreateResp struct {
+	metadata.BaseResp `json:",inline"`
+	Data              metadata.RspIDs `json:"data"`
+}
+
+// NsResRelationOption find the relations between namespace resources and workloads request, the ids are the ids of
+// the namespace resources when finding the workloads of namespace resources, and the ids of the workloads when
+// finding the namespace resources of workloads.
+type NsResRelationOption struct {
+	BizID int64   `json:"bk_biz_id"`
+	IDs   []int64 `json:"ids"`
+}
+
+// Validate validate NsResRelationOption
+func (o *NsResRelationOption) Validate() errors.RawErrorInfo {
+	if o.BizID == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKAppIDField},
+		}
+	}
+
+	if len(o.IDs) == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"ids"},
+		}
+	}
+
+	if len(o.IDs) > NsResRelationLimit {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommXXExceedLimit,
+			Args:    []interface{}{"ids", NsResRelationLimit},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// RelatedPod the brief info of the pod related to a namespace resource
+type RelatedPod struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// NsResRelation the pods and workloads related to a namespace resource, a service is related to the pods matching
+// its selector, an ingress is related to the pods of its backend services, a configmap or secret is related to the
+// pods mounting it as a volume, the workloads are the owners of the related pods.
+type NsResRelation struct {
+	ID        int64        `json:"id"`
+	Name      string       `json:"name"`
+	Pods      []RelatedPod `json:"pods"`
+	Workloads []Reference  `json:"workloads"`
+}
+
+// NsResReference the reference of a namespace resource
+type NsResReference struct {
+	Kind NsResourceKind `json:"kind"`
+	ID   int64          `json:"id"`
+	Name string         `json:"name"`
+}
+
+// WlNsResRelation the namespace resources related to a workload through its pods
+type WlNsResRelation struct {
+	ID        int64            `json:"id"`
+	Resources []NsResReference `json:"resources"`
+}
@@ -0,0 +1,156 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+)
+
+// TestNsResCreateOptionUnmarshal test unmarshal namespace resource create option by kind
+func TestNsResCreateOptionUnmarshal(t *testing.T) {
+	data := []byte(`{"bk_biz_id":2,"data":[{"bk_namespace_id":3,"name":"nginx","type":"ClusterIP",` +
+		`"selector":{"app":"nginx"},"ports":[{"name":"http","protocol":"TCP","port":80,` +
+		`"target_port":{"type":0,"int_val":8080}}]}]}`)
+
+	opt := NsResCreateOption{Kind: KubeService}
+	if err := opt.UnmarshalJSON(data); err != nil {
+		t.Fatalf("unmarshal service create option failed, err: %v", err)
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		t.Fatalf("validate service create option failed, err: %v", rawErr)
+	}
+
+	svc, ok := opt.Data[0].(*Service)
+	if !ok {
+		t.Fatalf("create data type %T is not service", opt.Data[0])
+	}
+
+	if svc.BizID != 2 || svc.NamespaceID != 3 || svc.Name != "nginx" || len(*svc.Ports) != 1 {
+		t.Fatalf("unmarshal service %+v is not as expected", svc)
+	}
+
+	opt = NsResCreateOption{Kind: "unknown"}
+	if err := opt.UnmarshalJSON(data); err == nil {
+		t.Fatalf("unmarshal create option with invalid kind should fail")
+	}
+}
+
+// TestNsResourceValidateCreate test the validation of namespace resources to be created
+func TestNsResourceValidateCreate(t *testing.T) {
+	cm := &ConfigMap{NsResourceBase: NsResourceBase{NamespaceSpec: NamespaceSpec{NamespaceID: 1}, Name: "conf"}}
+	if rawErr := cm.ValidateCreate(); rawErr.ErrCode != common.CCErrCommParamsNeedSet {
+		t.Fatalf("configmap without biz id should be invalid, err: %v", rawErr)
+	}
+
+	cm.BizID = 2
+	if rawErr := cm.ValidateCreate(); rawErr.ErrCode != 0 {
+		t.Fatalf("validate configmap failed, err: %v", rawErr)
+	}
+
+	secret := &Secret{NsResourceBase: NsResourceBase{NamespaceSpec: NamespaceSpec{ClusterSpec: ClusterSpec{BizID: 2}}}}
+	if rawErr := secret.ValidateCreate(); rawErr.ErrCode != common.CCErrCommParamsNeedSet {
+		t.Fatalf("secret without namespace id should be invalid, err: %v", rawErr)
+	}
+}
+
+// TestNsResourceBuildUpdateData test that the not editable fields are ignored in update data
+func TestNsResourceBuildUpdateData(t *testing.T) {
+	immutable := true
+	keys := []string{"a.conf"}
+	cm := &ConfigMap{
+		NsResourceBase: NsResourceBase{Name: "conf"},
+		DataKeys:       &keys,
+		Immutable:      &immutable,
+	}
+
+	data, err := cm.BuildUpdateData("admin")
+	if err != nil {
+		t.Fatalf("build configmap update data failed, err: %v", err)
+	}
+
+	if _, exists := data[common.BKFieldName]; exists {
+		t.Fatalf("name should not be updated, data: %v", data)
+	}
+
+	if data[ImmutableField] != true || data[common.ModifierField] != "admin" {
+		t.Fatalf("update data %v is not as expected", data)
+	}
+}
+
+// TestServiceMatchLabels test matching pod labels with service selector
+func TestServiceMatchLabels(t *testing.T) {
+	selector := map[string]string{"app": "nginx", "tier": "web"}
+	svc := &Service{Selector: &selector}
+
+	matched := map[string]string{"app": "nginx", "tier": "web", "version": "v1"}
+	if !svc.MatchLabels(&matched) {
+		t.Fatalf("labels %v should match selector %v", matched, selector)
+	}
+
+	unmatched := map[string]string{"app": "nginx"}
+	if svc.MatchLabels(&unmatched) {
+		t.Fatalf("labels %v should not match selector %v", unmatched, selector)
+	}
+
+	if (&Service{}).MatchLabels(&matched) {
+		t.Fatalf("service without selector should not match any labels")
+	}
+}
+
+// TestIngressGetServiceNames test getting the backend service names of ingress
+func TestIngressGetServiceNames(t *testing.T) {
+	rules := []IngressRule{
+		{Host: "a.com", Paths: []IngressPath{
+			{Path: "/", Backend: IngressBackend{ServiceName: "web"}},
+			{Path: "/api", Backend: IngressBackend{ServiceName: "api"}},
+		}},
+		{Host: "b.com", Paths: []IngressPath{{Path: "/", Backend: IngressBackend{ServiceName: "web"}}}},
+	}
+	ingress := &Ingress{DefaultBackend: &IngressBackend{ServiceName: "default"}, Rules: &rules}
+
+	expected := []string{"default", "web", "api"}
+	if names := ingress.GetServiceNames(); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("ingress service names %v is not as expected %v", names, expected)
+	}
+}
+
+// TestPodGetVolumeRefNames test getting the configmaps and secrets mounted by pod
+func TestPodGetVolumeRefNames(t *testing.T) {
+	volumes := []Volume{
+		{Name: "conf", VolumeSource: VolumeSource{ConfigMap: &ConfigMapVolumeSource{
+			LocalObjectReference: LocalObjectReference{Name: "nginx-conf"}}}},
+		{Name: "cert", VolumeSource: VolumeSource{Secret: &SecretVolumeSource{SecretName: "nginx-cert"}}},
+		{Name: "all", VolumeSource: VolumeSource{Projected: &ProjectedVolumeSource{Sources: []VolumeProjection{
+			{ConfigMap: &ConfigMapProjection{LocalObjectReference: LocalObjectReference{Name: "env-conf"}}},
+			{Secret: &SecretProjection{LocalObjectReference: LocalObjectReference{Name: "token"}}},
+		}}}},
+	}
+	pod := &Pod{Volumes: &volumes}
+
+	configMaps, secrets := pod.GetVolumeRefNames()
+	if !reflect.DeepEqual(configMaps, []string{"nginx-conf", "env-conf"}) {
+		t.Fatalf("pod configmaps %v is not as expected", configMaps)
+	}
+
+	if !reflect.DeepEqual(secrets, []string{"nginx-cert", "token"}) {
+		t.Fatalf("pod secrets %v is not as expected", secrets)
+	}
+}
@@ -667,3 +667,37 @@ type GetContainerByPodResp struct {
 	Info  []mapstr.MapStr `json:"info"`
 	Count int64           `json:"count"`
 }
+
+// GetVolumeRefNames get the names of the configmaps and secrets that the pod mounts as volumes.
+func (p *Pod) GetVolumeRefNames() (configMaps []string, secrets []string) {
+	configMaps, secrets = make([]string, 0), make([]string, 0)
+	if p.Volumes == nil {
+		return configMaps, secrets
+	}
+
+	for _, volume := range *p.Volumes {
+		if volume.ConfigMap != nil && volume.ConfigMap.Name != "" {
+			configMaps = append(configMaps, volume.ConfigMap.Name)
+		}
+
+		if volume.Secret != nil && volume.Secret.SecretName != "" {
+			secrets = append(secrets, volume.Secret.SecretName)
+		}
+
+		if volume.Projected == nil {
+			continue
+		}
+
+		for _, source := range volume.Projected.Sources {
+			if source.ConfigMap != nil && source.ConfigMap.Name != "" {
+				configMaps = append(configMaps, source.ConfigMap.Name)
+			}
+
+			if source.Secret != nil && source.Secret.Name != "" {
+				secrets = append(secrets, source.Secret.Name)
+			}
+		}
+	}
+
+	return configMaps, secrets
+}
@@ -235,6 +235,19 @@ const (
 
 	// BKTableNameNsSharedClusterRel the table name of shared cluster and biz relation by namespace dimension
 	BKTableNameNsSharedClusterRel = "cc_NsSharedClusterRelation"
+
+	// BKTableNameBaseService the table name of the kube Service, it is prefixed with kube to distinguish it from
+	// the service template and service instance tables
+	BKTableNameBaseService = "cc_KubeServiceBase"
+
+	// BKTableNameBaseIngress the table name of the Ingress
+	BKTableNameBaseIngress = "cc_IngressBase"
+
+	// BKTableNameBaseConfigMap the table name of the ConfigMap
+	BKTableNameBaseConfigMap = "cc_ConfigMapBase"
+
+	// BKTableNameBaseSecret the table name of the Secret
+	BKTableNameBaseSecret = "cc_SecretBase"
 )
 
 // common field names
@@ -247,6 +260,9 @@ const (
 
 	// KindField object kind field
 	KindField = "kind"
+
+	// CustomFieldsField custom attributes field, its sub fields are defined through the model attribute apis
+	CustomFieldsField = "custom_fields"
 )
 
 const (
@@ -492,3 +508,45 @@ const (
 	// MountsField container mounts field
 	MountsField = "mounts"
 )
+
+// service field names
+const (
+	// ClusterIPField service cluster ip field
+	ClusterIPField = "cluster_ip"
+
+	// ClusterIPsField service cluster ips field
+	ClusterIPsField = "cluster_ips"
+
+	// ExternalIPsField service external ips field
+	ExternalIPsField = "external_ips"
+
+	// SessionAffinityField service session affinity field
+	SessionAffinityField = "session_affinity"
+
+	// ExternalNameField service external name field
+	ExternalNameField = "external_name"
+)
+
+// ingress field names
+const (
+	// IngressClassNameField ingress class name field
+	IngressClassNameField = "ingress_class_name"
+
+	// RulesField ingress rules field
+	RulesField = "rules"
+
+	// DefaultBackendField ingress default backend field
+	DefaultBackendField = "default_backend"
+
+	// TLSField ingress tls field
+	TLSField = "tls"
+)
+
+// config map and secret field names
+const (
+	// DataKeysField config map and secret data keys field, the values of the data are not stored in cc
+	DataKeysField = "data_keys"
+
+	// ImmutableField config map and secret immutable field
+	ImmutableField = "immutable"
+)
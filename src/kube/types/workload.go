@@ -121,6 +121,8 @@ type WorkloadBase struct {
 	ID              int64  `json:"id,omitempty" bson:"id"`
 	Name            string `json:"name,omitempty" bson:"name"`
 	SupplierAccount string `json:"bk_supplier_account,omitempty" bson:"bk_supplier_account"`
+	// CustomFields custom attributes of the workload
+	CustomFields *CustomFields `json:"custom_fields,omitempty" bson:"custom_fields"`
 	// Revision record this app's revision information
 	table.Revision `json:",inline" bson:",inline"`
 }
@@ -448,8 +450,10 @@ type WlQueryOption struct {
 	Page   metadata.BasePage  `json:"page,omitempty"`
 }
 
-// Validate validate WlQueryReq
-func (wl *WlQueryOption) Validate(kind WorkloadType) errors.RawErrorInfo {
+// Validate validate WlQueryReq, customFieldsType is the filter field types of the workload custom attributes
+func (wl *WlQueryOption) Validate(kind WorkloadType,
+	customFieldsType map[string]enumor.FieldType) errors.RawErrorInfo {
+
 	if err := wl.Page.ValidateWithEnableCount(false, WlQueryLimit); err.ErrCode != 0 {
 		return err
 	}
@@ -466,7 +470,7 @@ func (wl *WlQueryOption) Validate(kind WorkloadType) errors.RawErrorInfo {
 		return errors.RawErrorInfo{}
 	}
 
-	op := filter.NewDefaultExprOpt(fields.FieldsType())
+	op := filter.NewDefaultExprOpt(mergeFieldsType(fields.FieldsType(), customFieldsType))
 	if err := wl.Filter.Validate(op); err != nil {
 		return errors.RawErrorInfo{
 			ErrCode: common.CCErrCommParamsInvalid,
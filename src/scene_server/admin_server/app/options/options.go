@@ -69,6 +69,8 @@ type Config struct {
 	ShardingTable  ShardingTableConfig
 	// SyncIAMPeriodMinutes the period for sync IAM resources
 	SyncIAMPeriodMinutes int
+	// AuditChain the audit log hash chain verification config
+	AuditChain AuditChainConfig
 	// 通过何种方式调用gse接口注册dataid
 	DataIdMigrateWay MigrateWay
 }
@@ -104,6 +106,14 @@ type RegisterConfig struct {
 	TLS     ssl.TLSClientConfig
 }
 
+// AuditChainConfig is the audit log hash chain verification config
+type AuditChainConfig struct {
+	// VerifyPeriodMinutes 审计日志哈希链校验周期，单位分钟，最小60分钟，默认1440分钟
+	VerifyPeriodMinutes int
+	// AllowGaps 是否允许链中的审计日志缺失，开启了审计日志保留策略时需要开启
+	AllowGaps bool
+}
+
 // ShardingTableConfig TODO
 type ShardingTableConfig struct {
 	// 表中同步索引间隔时间，单位分钟， 最小30分钟， 默认60分钟， 最大720分钟
@@ -149,6 +149,8 @@ func parseSeverConfig(ctx context.Context, op *options.ServerOption) (*MigrateSe
 	process.Config.DataIdMigrateWay = options.MigrateWay(migrateWay)
 	process.Config.SnapDataID = int64(snapDataID)
 	process.Config.SyncIAMPeriodMinutes, _ = cc.Int("adminServer.syncIAMPeriodMinutes")
+	process.Config.AuditChain.VerifyPeriodMinutes, _ = cc.Int("adminServer.auditChain.verifyPeriodMinutes")
+	process.Config.AuditChain.AllowGaps, _ = cc.Bool("adminServer.auditChain.allowGaps")
 
 	// load mongodb, redis and common config from configure directory
 	mongodbPath := process.Config.Configures.Dir + "/" + types.CCConfigureMongo
@@ -120,4 +120,5 @@ import (
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202405141035"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202410100930"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202502101200"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202510181200"
 )
@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	types2 "configcenter/src/common/types"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/admin_server/app/options"
+	"configcenter/src/storage/dal"
+	"configcenter/src/thirdparty/monitor"
+	"configcenter/src/thirdparty/monitor/meta"
+)
+
+const (
+	// defaultAuditChainVerifyPeriod 默认的审计日志哈希链校验周期，单位分钟
+	defaultAuditChainVerifyPeriod = 1440
+	// minAuditChainVerifyPeriod 最小的审计日志哈希链校验周期，单位分钟
+	minAuditChainVerifyPeriod = 60
+)
+
+// RunAuditChainVerify 周期性校验所有开发商的审计日志哈希链，发现断链时记录日志并上报告警
+func RunAuditChainVerify(e *backbone.Engine, db dal.RDB, conf options.AuditChainConfig) {
+	period := conf.VerifyPeriodMinutes
+	if period == 0 {
+		period = defaultAuditChainVerifyPeriod
+	}
+	if period < minAuditChainVerifyPeriod {
+		period = minAuditChainVerifyPeriod
+	}
+
+	for {
+		time.Sleep(time.Duration(period) * time.Minute)
+
+		rid := util.GenerateRID()
+		if !e.ServiceManageInterface.IsMaster() {
+			blog.V(4).Infof("skip verify audit log chain, reason: not master, rid: %s", rid)
+			continue
+		}
+
+		verifyAuditChains(db, conf, rid)
+	}
+}
+
+func verifyAuditChains(db dal.RDB, conf options.AuditChainConfig, rid string) {
+	ctx := context.Background()
+	heads, err := auditlog.ListAuditChainHeads(ctx, db)
+	if err != nil {
+		blog.Errorf("list audit log chain heads failed, err: %v, rid: %s", err, rid)
+		return
+	}
+
+	opt := metadata.VerifyAuditChainOption{AllowGaps: conf.AllowGaps}
+	for _, head := range heads {
+		result, err := auditlog.VerifyAuditChain(ctx, db, head.SupplierAccount, opt)
+		if err != nil {
+			blog.Errorf("verify audit log chain of supplier account %s failed, err: %v, rid: %s",
+				head.SupplierAccount, err, rid)
+			continue
+		}
+
+		if result.BrokenLink == nil {
+			blog.Infof("audit log chain of supplier account %s is intact, verified: %d, gaps: %d, rid: %s",
+				head.SupplierAccount, result.Verified, result.Gaps, rid)
+			continue
+		}
+
+		link := result.BrokenLink
+		blog.Errorf("audit log chain of supplier account %s is broken, link: %+v, rid: %s", head.SupplierAccount,
+			*link, rid)
+		monitor.Collect(&meta.Alarm{
+			RequestID: rid,
+			Type:      meta.AuditChainBroken,
+			Detail: fmt.Sprintf("audit log chain of supplier account %s is broken at chain sequence %d, reason: %s",
+				head.SupplierAccount, link.ChainSeq, link.Reason),
+			Module:    types2.CC_MODULE_MIGRATE,
+			Dimension: map[string]string{"audit_chain_broken": "yes"},
+		})
+	}
+}
@@ -49,6 +49,7 @@ func (s *Service) BackgroundTask(options options.Config) error {
 	}
 
 	logics.DBSync(s.Engine, db, options)
+	go logics.RunAuditChainVerify(s.Engine, db, options.AuditChain)
 
 	return nil
 }
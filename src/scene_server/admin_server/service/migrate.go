@@ -150,7 +150,11 @@ func (s *Service) createWatchIndexes(cursorType watch.CursorType, key event.Key,
 			ExpireAfterSeconds: dbChainTTLTime},
 	}
 
-	if cursorType == watch.ObjectBase || cursorType == watch.MainlineInstance || cursorType == watch.InstAsst {
+	switch cursorType {
+	case watch.ObjectBase, watch.MainlineInstance, watch.InstAsst, watch.DynamicGroupMember, watch.Object,
+		watch.ObjectAttribute, watch.ObjectAttributeGroup, watch.ObjectUnique, watch.ModelAssociation,
+		watch.ServiceInstance, watch.ServiceTemplate, watch.ProcessTemplate, watch.SetTemplate, watch.HostApplyRule,
+		watch.KubeService, watch.KubeIngress, watch.KubeConfigMap, watch.KubeSecret:
 		subResourceIndex := daltypes.Index{
 			Name: "index_sub_resource", Keys: bson.D{{common.BKSubResourceField, 1}}, Background: true,
 		}
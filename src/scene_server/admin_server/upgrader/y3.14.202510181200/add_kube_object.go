@@ -0,0 +1,120 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package y3_14_202510181200
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	mCommon "configcenter/src/scene_server/admin_server/common"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// kubeObjects the inner objects of kube cluster, namespace and workload, they have no instances, only the custom
+// attributes of the kube resources are defined by their model attributes.
+var kubeObjects = []metadata.Object{
+	{
+		ObjectID:   common.BKInnerObjIDKubeCluster,
+		ObjectName: "容器集群",
+		ObjIcon:    "icon-cc-default",
+	},
+	{
+		ObjectID:   common.BKInnerObjIDKubeNamespace,
+		ObjectName: "命名空间",
+		ObjIcon:    "icon-cc-default",
+	},
+	{
+		ObjectID:   common.BKInnerObjIDKubeWorkload,
+		ObjectName: "工作负载",
+		ObjIcon:    "icon-cc-default",
+	},
+}
+
+func addKubeObjects(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	for _, object := range kubeObjects {
+		if err := addKubeObjectRow(ctx, db, conf.OwnerID, object); err != nil {
+			return err
+		}
+
+		if err := addKubeObjectPropertyGroup(ctx, db, conf.OwnerID, object.ObjectID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addKubeObjectRow(ctx context.Context, db dal.RDB, ownerID string, object metadata.Object) error {
+	filter := mapstr.MapStr{common.BKObjIDField: object.ObjectID}
+	model := new(metadata.Object)
+	err := db.Table(common.BKTableNameObjDes).Find(filter).
+		Fields(common.BKFieldID, common.BKObjIDField, common.BKObjNameField, common.CreatorField).One(ctx, model)
+	if err != nil && !db.IsNotFoundError(err) {
+		blog.Errorf("get kube object %s failed, err: %v", object.ObjectID, err)
+		return err
+	}
+
+	if model.ID != 0 {
+		if model.Creator == common.CCSystemOperatorUserName {
+			return nil
+		}
+		blog.Errorf("the model %s already exists, but is not created by system, object name: %s, creator: %s",
+			object.ObjectID, model.ObjectName, model.Creator)
+		return fmt.Errorf("model %s failed to create", object.ObjectID)
+	}
+
+	now := metadata.Now()
+	object.ObjCls = metadata.ClassificationUncategorizedID
+	object.IsPre = true
+	object.IsHidden = true
+	object.CreateTime = &now
+	object.LastTime = &now
+	object.Creator = common.CCSystemOperatorUserName
+	object.OwnerID = ownerID
+
+	uniqueKeys := []string{common.BKObjIDField}
+	_, _, err = upgrader.Upsert(ctx, db, common.BKTableNameObjDes, object, "id", uniqueKeys, []string{"id"})
+	if err != nil {
+		blog.Errorf("add kube object %s failed, err: %v", object.ObjectID, err)
+		return err
+	}
+	return nil
+}
+
+func addKubeObjectPropertyGroup(ctx context.Context, db dal.RDB, ownerID string, objID string) error {
+	row := &metadata.Group{
+		ObjectID:   objID,
+		GroupID:    mCommon.BaseInfo,
+		GroupName:  mCommon.BaseInfoName,
+		GroupIndex: 1,
+		OwnerID:    ownerID,
+		IsDefault:  true,
+	}
+
+	if _, _, err := upgrader.Upsert(ctx, db, common.BKTableNamePropertyGroup, row, "id",
+		[]string{common.BKObjIDField, common.BKPropertyGroupIDField}, []string{"id"}); err != nil {
+		blog.Errorf("add kube object %s property group failed, err: %v", objID, err)
+		return err
+	}
+
+	return nil
+}
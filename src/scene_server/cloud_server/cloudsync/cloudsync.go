@@ -83,7 +83,7 @@ func SyncCloudResource(taskChan chan *metadata.CloudSyncTask, conf *SyncConf) {
 	// 云主机channel
 	hostChan := make(chan *metadata.CloudSyncTask, 10)
 
-	// 云主机同步器处理同步任务
+	// 云主机同步器处理同步任务，云主机同步完成后在同一个事务中同步任务配置的其他云资源，以便关联到最新的云主机
 	for i := 1; i <= syncorNum; i++ {
 		syncor := NewHostSyncor(conf.Logics)
 		go func(syncor *HostSyncor) {
@@ -25,17 +25,23 @@ type HostSyncor struct {
 	readKit *rest.Kit
 	// writeKit used for write operation
 	writeKit *rest.Kit
+	// resourceSyncor 同步任务配置的主机以外的云资源
+	resourceSyncor *ResourceSyncor
 }
 
 // NewHostSyncor 创建云主机同步器
 func NewHostSyncor(logics *logics.Logics) *HostSyncor {
 	return &HostSyncor{
-		logics: logics,
+		logics:         logics,
+		resourceSyncor: NewResourceSyncor(logics),
 	}
 }
 
 func (h *HostSyncor) syncCloudHost(syncResult *metadata.SyncResult, hostResource *metadata.CloudHostResource,
-	taskID int64, accountConf *metadata.CloudAccountConf, startTime time.Time) error {
+	task *metadata.CloudSyncTask, resources map[metadata.CloudResourceKind][]*metadata.CloudResource,
+	accountConf *metadata.CloudAccountConf, startTime time.Time) error {
+
+	taskID := task.TaskID
 
 	// 让writeKit的header含有同样的事务信息，以保证同一个事务里写操作后的数据能够被读到
 	ccom.CopyHeaderTxnInfo(h.readKit.Header, h.writeKit.Header)
@@ -56,16 +62,16 @@ func (h *HostSyncor) syncCloudHost(syncResult *metadata.SyncResult, hostResource
 	}
 
 	// 根据主机实例id获取mongo中的主机信息,并获取有差异的主机
-	diffHosts, err := h.getDiffHosts(hostResource)
+	diffHosts, _, err := h.getDiffHosts(hostResource)
 	if err != nil {
 		blog.Errorf("getDiffHosts fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
 		return err
 	}
 
-	// 没差异则结束
+	// 没差异且没有需要同步的其他云资源则结束
 	if len(diffHosts) == 0 {
 		blog.Infof("no diff hosts for taskid:%d, rid:%s", taskID, h.readKit.Rid)
-		if syncResult.SuccessInfo.Count == 0 {
+		if syncResult.SuccessInfo.Count == 0 && len(resources) == 0 {
 			blog.Infof("no any hosts need sync for taskID: %d, rid: %s", taskID, h.readKit.Rid)
 			return nil
 		}
@@ -85,6 +91,12 @@ func (h *HostSyncor) syncCloudHost(syncResult *metadata.SyncResult, hostResource
 		return err
 	}
 
+	// 同步任务配置的主机以外的云资源，在云主机同步之后执行以便关联到最新的云主机，同步详情记录在同一条同步历史中
+	syncResult.Detail.Resources, err = h.resourceSyncor.Sync(h.readKit, h.writeKit, task, resources)
+	if err != nil {
+		return err
+	}
+
 	// 设置SyncResult的状态信息
 	err = h.SetSyncResultStatus(syncResult, startTime)
 	if err != nil {
@@ -142,7 +154,14 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 		blog.Errorf("getCloudHostResource fail, taskID: %d, err: %v, rid: %s", task.TaskID, err, h.readKit.Rid)
 		return err
 	}
-	if len(hostResource.HostResource) == 0 && len(hostResource.DestroyedVpcs) == 0 {
+
+	// 获取任务配置的主机以外的云资源，在事务外调用云厂商接口
+	resources, err := h.resourceSyncor.GetResources(h.readKit, task, accountConf)
+	if err != nil {
+		return err
+	}
+
+	if len(hostResource.HostResource) == 0 && len(hostResource.DestroyedVpcs) == 0 && len(resources) == 0 {
 		blog.Infof("hostResource is empty, taskid:%d, rid:%s", task.TaskID, h.readKit.Rid)
 		return nil
 	}
@@ -150,11 +169,16 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 	blog.Infof("taskID: %d, destroyed vpc count: %d, other vpc count: %d, rid: %s", task.TaskID,
 		len(hostResource.DestroyedVpcs), len(hostResource.HostResource), h.readKit.Rid)
 
+	// 试运行的任务只记录同步差异，不写入主机数据
+	if task.DryRun {
+		return h.dryRun(hostResource, startTime)
+	}
+
 	syncResult := new(metadata.SyncResult)
 	syncResult.FailInfo.IPError = make(map[string]string)
 
 	txnErr := h.logics.CoreAPI.CoreService().Txn().AutoRunTxn(h.readKit.Ctx, h.readKit.Header, func() error {
-		return h.syncCloudHost(syncResult, hostResource, task.TaskID, accountConf, startTime)
+		return h.syncCloudHost(syncResult, hostResource, task, resources, accountConf, startTime)
 	})
 
 	// 事务结束，去掉readKit、writeKit中header的事务信息
@@ -205,6 +229,203 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 	return nil
 }
 
+// Preview 预览同步任务的同步差异，使用与同步相同的逻辑计算差异，但不写入任何数据
+func (h *HostSyncor) Preview(kit *rest.Kit, task *metadata.CloudSyncTask) (*metadata.CloudSyncDiff, error) {
+	h.readKit = kit
+
+	accountConf, err := h.logics.GetCloudAccountConf(h.readKit, task.AccountID)
+	if err != nil {
+		blog.Errorf("GetCloudAccountConf fail, taskID: %d, err: %v, rid: %s", task.TaskID, err, h.readKit.Rid)
+		return nil, err
+	}
+
+	hostResource, err := h.getCloudHostResource(task, accountConf)
+	if err != nil {
+		blog.Errorf("getCloudHostResource fail, taskID: %d, err: %v, rid: %s", task.TaskID, err, h.readKit.Rid)
+		return nil, err
+	}
+
+	return h.getSyncDiff(hostResource)
+}
+
+// dryRun 试运行同步任务，将同步差异记录到同步历史中
+func (h *HostSyncor) dryRun(hostResource *metadata.CloudHostResource, startTime time.Time) error {
+	diff, err := h.getSyncDiff(hostResource)
+	if err != nil {
+		blog.Errorf("getSyncDiff fail, taskID: %d, err: %v, rid: %s", hostResource.TaskID, err, h.readKit.Rid)
+		return err
+	}
+
+	costTime, _ := strconv.ParseFloat(fmt.Sprintf("%.1f", float64(time.Since(startTime)/time.Millisecond)/1000.0), 64)
+	history := newDryRunHistory(diff, costTime)
+	if _, err := h.logics.CreateSyncHistory(h.writeKit, history); err != nil {
+		blog.Errorf("add dry run sync history failed, taskID: %d, err: %v, rid: %s", hostResource.TaskID, err,
+			h.readKit.Rid)
+		return err
+	}
+
+	blog.Infof("dry run finished, taskID: %d, add: %d, update: %d, destroyed: %d, rid: %s", hostResource.TaskID,
+		len(diff.NewAdd), len(diff.Update), len(diff.Destroyed), h.readKit.Rid)
+	return nil
+}
+
+// newDryRunHistory 根据同步差异生成试运行的同步历史，新增、更新和将被标记为已销毁的主机分别统计
+func newDryRunHistory(diff *metadata.CloudSyncDiff, costTime float64) *metadata.SyncHistory {
+	history := &metadata.SyncHistory{
+		TaskID:            diff.TaskID,
+		SyncStatus:        metadata.CloudSyncDryRun,
+		StatusDescription: metadata.SyncStatusDesc{CostTime: costTime},
+		Detail:            metadata.SyncDetail{Diff: diff},
+	}
+	for _, host := range diff.NewAdd {
+		history.Detail.NewAdd.Count++
+		history.Detail.NewAdd.IPs = append(history.Detail.NewAdd.IPs, host.PrivateIP)
+	}
+	for _, host := range diff.Update {
+		history.Detail.Update.Count++
+		history.Detail.Update.IPs = append(history.Detail.Update.IPs, host.PrivateIP)
+	}
+	for _, host := range diff.Destroyed {
+		history.Detail.Destroyed.Count++
+		history.Detail.Destroyed.IPs = append(history.Detail.Destroyed.IPs, host.PrivateIP)
+	}
+	return history
+}
+
+// getSyncDiff 计算云主机资源与本地主机的同步差异，包括将要新增、更新和标记为已销毁的主机
+func (h *HostSyncor) getSyncDiff(hostResource *metadata.CloudHostResource) (*metadata.CloudSyncDiff, error) {
+	diff := &metadata.CloudSyncDiff{
+		TaskID:        hostResource.TaskID,
+		NewAdd:        make([]metadata.CloudHostDiff, 0),
+		Update:        make([]metadata.CloudHostDiff, 0),
+		Destroyed:     make([]metadata.CloudHostDiff, 0),
+		DestroyedVpcs: make([]string, 0),
+	}
+
+	// 被销毁vpc对应云区域下的主机都会被标记为已销毁
+	destroyedCloudIDs := make([]int64, 0)
+	for _, vpcInfo := range hostResource.DestroyedVpcs {
+		diff.DestroyedVpcs = append(diff.DestroyedVpcs, vpcInfo.VpcID)
+		destroyedCloudIDs = append(destroyedCloudIDs, vpcInfo.CloudID)
+	}
+	if len(destroyedCloudIDs) > 0 {
+		query := &metadata.QueryCondition{
+			Condition: mapstr.MapStr{common.BKCloudIDField: mapstr.MapStr{common.BKDBIN: destroyedCloudIDs}},
+		}
+		res, err := h.logics.CoreAPI.CoreService().Instance().ReadInstance(h.readKit.Ctx, h.readKit.Header,
+			common.BKInnerObjIDHost, query)
+		if err != nil {
+			blog.Errorf("read destroyed vpc hosts failed, err: %v, query: %#v, rid: %s", err, query, h.readKit.Rid)
+			return nil, err
+		}
+		for _, host := range res.Info {
+			status, _ := host.String(common.BKCloudHostStatusField)
+			if status == common.BKCloudHostStatusDestroyed {
+				continue
+			}
+			diff.Destroyed = append(diff.Destroyed, newDestroyedHostDiff(host))
+		}
+	}
+
+	// 查询vpc对应的云区域，还未创建云区域的vpc下的主机都是新增的
+	for _, hostRes := range hostResource.HostResource {
+		cloudID, err := h.getCloudId(hostRes.Vpc.VpcID)
+		if err != nil {
+			blog.Errorf("getCloudId failed, vpcID: %s, err: %v, rid: %s", hostRes.Vpc.VpcID, err, h.readKit.Rid)
+			return nil, err
+		}
+		hostRes.CloudID = cloudID
+	}
+
+	diffHosts, localHosts, err := h.getDiffHosts(hostResource)
+	if err != nil {
+		blog.Errorf("getDiffHosts fail, taskID: %d, err: %v, rid: %s", hostResource.TaskID, err, h.readKit.Rid)
+		return nil, err
+	}
+
+	for _, host := range diffHosts["add"] {
+		diff.NewAdd = append(diff.NewAdd, metadata.CloudHostDiff{
+			InstanceID: host.InstanceId,
+			CloudID:    host.CloudID,
+			PrivateIP:  host.PrivateIp,
+			PublicIP:   host.PublicIp,
+		})
+	}
+
+	for _, host := range diffHosts["update"] {
+		diff.Update = append(diff.Update, newUpdateHostDiff(host, localHosts[host.InstanceId]))
+	}
+
+	for _, host := range diffHosts["delete"] {
+		diff.Destroyed = append(diff.Destroyed, metadata.CloudHostDiff{
+			InstanceID: host.InstanceId,
+			HostID:     host.HostID,
+			CloudID:    host.CloudID,
+			PrivateIP:  host.PrivateIp,
+			PublicIP:   host.PublicIp,
+			Changes:    getDestroyedHostChanges(host.PrivateIp, host.PublicIp, host.InstanceState),
+		})
+	}
+
+	return diff, nil
+}
+
+// newUpdateHostDiff 根据云端主机和本地主机生成将被更新的主机差异
+func newUpdateHostDiff(host, localHost *metadata.CloudHost) metadata.CloudHostDiff {
+	hostDiff := metadata.CloudHostDiff{
+		InstanceID: host.InstanceId,
+		HostID:     localHost.HostID,
+		CloudID:    host.CloudID,
+		PrivateIP:  host.PrivateIp,
+		PublicIP:   host.PublicIp,
+		Changes:    make([]metadata.CloudFieldChange, 0),
+	}
+	if host.PrivateIp != localHost.PrivateIp {
+		hostDiff.Changes = append(hostDiff.Changes, metadata.CloudFieldChange{
+			Field: common.BKHostInnerIPField, Before: localHost.PrivateIp, After: host.PrivateIp})
+	}
+	if host.PublicIp != localHost.PublicIp {
+		hostDiff.Changes = append(hostDiff.Changes, metadata.CloudFieldChange{
+			Field: common.BKHostOuterIPField, Before: localHost.PublicIp, After: host.PublicIp})
+	}
+	if host.InstanceState != localHost.InstanceState {
+		hostDiff.Changes = append(hostDiff.Changes, metadata.CloudFieldChange{
+			Field: common.BKCloudHostStatusField, Before: localHost.InstanceState, After: host.InstanceState})
+	}
+	if host.CloudID != localHost.CloudID {
+		hostDiff.Changes = append(hostDiff.Changes, metadata.CloudFieldChange{
+			Field: common.BKCloudIDField, Before: localHost.CloudID, After: host.CloudID})
+	}
+	return hostDiff
+}
+
+// newDestroyedHostDiff 根据本地主机数据生成将被标记为已销毁的主机差异
+func newDestroyedHostDiff(host mapstr.MapStr) metadata.CloudHostDiff {
+	instID, _ := host.String(common.BKCloudInstIDField)
+	hostID, _ := host.Int64(common.BKHostIDField)
+	cloudID, _ := host.Int64(common.BKCloudIDField)
+	privateIP, _ := host.String(common.BKHostInnerIPField)
+	publicIP, _ := host.String(common.BKHostOuterIPField)
+	status, _ := host.String(common.BKCloudHostStatusField)
+	return metadata.CloudHostDiff{
+		InstanceID: instID,
+		HostID:     hostID,
+		CloudID:    cloudID,
+		PrivateIP:  privateIP,
+		PublicIP:   publicIP,
+		Changes:    getDestroyedHostChanges(privateIP, publicIP, status),
+	}
+}
+
+// getDestroyedHostChanges 主机被标记为已销毁时的字段变更，内外网ip置空，状态置为已销毁
+func getDestroyedHostChanges(privateIP, publicIP, status string) []metadata.CloudFieldChange {
+	return []metadata.CloudFieldChange{
+		{Field: common.BKHostInnerIPField, Before: privateIP, After: ""},
+		{Field: common.BKHostOuterIPField, Before: publicIP, After: ""},
+		{Field: common.BKCloudHostStatusField, Before: status, After: common.BKCloudHostStatusDestroyed},
+	}
+}
+
 // getCloudHostResource 根据任务详情和账号信息获取要同步的云主机资源
 func (h *HostSyncor) getCloudHostResource(task *metadata.CloudSyncTask,
 	accountConf *metadata.CloudAccountConf) (*metadata.CloudHostResource, error) {
@@ -302,7 +523,9 @@ func (h *HostSyncor) addCLoudId(accountConf *metadata.CloudAccountConf,
 }
 
 // getDiffHosts 根据主机实例id获取mongo中的主机信息,并获取有差异的主机
-func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map[string][]*metadata.CloudHost, error) {
+// 同时返回以实例id为key的本地云主机，用于计算主机的字段差异
+func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map[string][]*metadata.CloudHost,
+	map[string]*metadata.CloudHost, error) {
 	// 云端的主机
 	remoteHostsMap := make(map[string]*metadata.CloudHost)
 	for _, hostRes := range hostResource.HostResource {
@@ -318,6 +541,10 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 
 	cloudIDs := make([]int64, 0)
 	for _, vpcInfo := range hostResource.HostResource {
+		// 试运行时vpc可能还没有对应的云区域，其下的主机都是新增的
+		if vpcInfo.CloudID == 0 {
+			continue
+		}
 		cloudIDs = append(cloudIDs, vpcInfo.CloudID)
 	}
 	blog.V(4).Infof("taskid:%d, host cloudIDs:%#v, rid:%s", hostResource.TaskID, cloudIDs, h.readKit.Rid)
@@ -325,7 +552,7 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 	// 本地已有的云主机
 	localHosts, err := h.getLocalHosts(cloudIDs)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	blog.V(4).Infof("taskid:%d, len(localHosts):%d, rid:%s", hostResource.TaskID, len(localHosts), h.readKit.Rid)
 	localIdHostsMap := make(map[string]*metadata.CloudHost)
@@ -364,7 +591,7 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 		}
 	}
 
-	return diffHosts, nil
+	return diffHosts, localIdHostsMap, nil
 }
 
 // syncDiffHosts 同步有差异的主机数据
@@ -0,0 +1,154 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudsync
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newCloudHost(instID, privateIP, publicIP, state string, cloudID, hostID int64) *metadata.CloudHost {
+	return &metadata.CloudHost{
+		Instance: metadata.Instance{
+			InstanceId:    instID,
+			PrivateIp:     privateIP,
+			PublicIp:      publicIP,
+			InstanceState: state,
+		},
+		CloudID: cloudID,
+		HostID:  hostID,
+	}
+}
+
+func TestNewUpdateHostDiff(t *testing.T) {
+	testCases := []struct {
+		name      string
+		host      *metadata.CloudHost
+		localHost *metadata.CloudHost
+		changes   []metadata.CloudFieldChange
+	}{
+		{
+			name:      "no field changed",
+			host:      newCloudHost("ins-1", "10.0.0.1", "1.1.1.1", "running", 1, 0),
+			localHost: newCloudHost("ins-1", "10.0.0.1", "1.1.1.1", "running", 1, 100),
+			changes:   []metadata.CloudFieldChange{},
+		},
+		{
+			name:      "ip changed",
+			host:      newCloudHost("ins-1", "10.0.0.2", "2.2.2.2", "running", 1, 0),
+			localHost: newCloudHost("ins-1", "10.0.0.1", "1.1.1.1", "running", 1, 100),
+			changes: []metadata.CloudFieldChange{
+				{Field: common.BKHostInnerIPField, Before: "10.0.0.1", After: "10.0.0.2"},
+				{Field: common.BKHostOuterIPField, Before: "1.1.1.1", After: "2.2.2.2"},
+			},
+		},
+		{
+			name:      "status and cloud area changed",
+			host:      newCloudHost("ins-1", "10.0.0.1", "", "stopped", 2, 0),
+			localHost: newCloudHost("ins-1", "10.0.0.1", "", "running", 1, 100),
+			changes: []metadata.CloudFieldChange{
+				{Field: common.BKCloudHostStatusField, Before: "running", After: "stopped"},
+				{Field: common.BKCloudIDField, Before: int64(1), After: int64(2)},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		diff := newUpdateHostDiff(tc.host, tc.localHost)
+		require.Equal(t, tc.host.InstanceId, diff.InstanceID, tc.name)
+		require.Equal(t, tc.localHost.HostID, diff.HostID, tc.name)
+		require.Equal(t, tc.host.CloudID, diff.CloudID, tc.name)
+		require.Equal(t, tc.host.PrivateIp, diff.PrivateIP, tc.name)
+		require.Equal(t, tc.changes, diff.Changes, tc.name)
+	}
+}
+
+func TestNewDestroyedHostDiff(t *testing.T) {
+	host := mapstr.MapStr{
+		common.BKCloudInstIDField:     "ins-1",
+		common.BKHostIDField:          int64(100),
+		common.BKCloudIDField:         int64(1),
+		common.BKHostInnerIPField:     "10.0.0.1",
+		common.BKHostOuterIPField:     "1.1.1.1",
+		common.BKCloudHostStatusField: "running",
+	}
+
+	diff := newDestroyedHostDiff(host)
+	require.Equal(t, metadata.CloudHostDiff{
+		InstanceID: "ins-1",
+		HostID:     100,
+		CloudID:    1,
+		PrivateIP:  "10.0.0.1",
+		PublicIP:   "1.1.1.1",
+		Changes: []metadata.CloudFieldChange{
+			{Field: common.BKHostInnerIPField, Before: "10.0.0.1", After: ""},
+			{Field: common.BKHostOuterIPField, Before: "1.1.1.1", After: ""},
+			{Field: common.BKCloudHostStatusField, Before: "running", After: common.BKCloudHostStatusDestroyed},
+		},
+	}, diff)
+}
+
+func TestNewDryRunHistory(t *testing.T) {
+	testCases := []struct {
+		name      string
+		diff      *metadata.CloudSyncDiff
+		newAdd    metadata.SyncSuccessInfo
+		update    metadata.SyncSuccessInfo
+		destroyed metadata.SyncSuccessInfo
+	}{
+		{
+			name: "no diff",
+			diff: &metadata.CloudSyncDiff{TaskID: 1},
+		},
+		{
+			name: "add, update and destroy hosts",
+			diff: &metadata.CloudSyncDiff{
+				TaskID:    2,
+				NewAdd:    []metadata.CloudHostDiff{{PrivateIP: "10.0.0.1"}, {PrivateIP: "10.0.0.2"}},
+				Update:    []metadata.CloudHostDiff{{PrivateIP: "10.0.0.3"}},
+				Destroyed: []metadata.CloudHostDiff{{PrivateIP: "10.0.0.4"}, {PrivateIP: "10.0.0.5"}},
+			},
+			newAdd:    metadata.SyncSuccessInfo{Count: 2, IPs: []string{"10.0.0.1", "10.0.0.2"}},
+			update:    metadata.SyncSuccessInfo{Count: 1, IPs: []string{"10.0.0.3"}},
+			destroyed: metadata.SyncSuccessInfo{Count: 2, IPs: []string{"10.0.0.4", "10.0.0.5"}},
+		},
+		{
+			name: "only destroyed hosts are not counted as updates",
+			diff: &metadata.CloudSyncDiff{
+				TaskID:    3,
+				Destroyed: []metadata.CloudHostDiff{{PrivateIP: "10.0.0.6"}},
+			},
+			destroyed: metadata.SyncSuccessInfo{Count: 1, IPs: []string{"10.0.0.6"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		history := newDryRunHistory(tc.diff, 1.5)
+		require.Equal(t, tc.diff.TaskID, history.TaskID, tc.name)
+		require.Equal(t, metadata.CloudSyncDryRun, history.SyncStatus, tc.name)
+		require.Equal(t, 1.5, history.StatusDescription.CostTime, tc.name)
+		require.Equal(t, tc.diff, history.Detail.Diff, tc.name)
+		require.Equal(t, tc.newAdd, history.Detail.NewAdd, tc.name)
+		require.Equal(t, tc.update, history.Detail.Update, tc.name)
+		require.Equal(t, tc.destroyed, history.Detail.Destroyed, tc.name)
+	}
+}
@@ -0,0 +1,634 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/cloud_server/logics"
+)
+
+// ResourceSyncor 主机以外的云资源同步器，将负载均衡、云硬盘、安全组等云资源同步到用户自定义模型。
+// 由云主机同步器在云主机同步的事务中调用，同步结果记录在云主机同步的同步历史中
+type ResourceSyncor struct {
+	logics *logics.Logics
+	// readKit used for read operation
+	readKit *rest.Kit
+	// writeKit used for write operation
+	writeKit *rest.Kit
+}
+
+// NewResourceSyncor 创建云资源同步器
+func NewResourceSyncor(logics *logics.Logics) *ResourceSyncor {
+	return &ResourceSyncor{
+		logics: logics,
+	}
+}
+
+// GetResources 获取任务配置的主机以外的云资源，试运行的任务不同步主机以外的云资源
+func (r *ResourceSyncor) GetResources(kit *rest.Kit, task *metadata.CloudSyncTask,
+	accountConf *metadata.CloudAccountConf) (map[metadata.CloudResourceKind][]*metadata.CloudResource, error) {
+
+	if len(task.ResourceMappings) == 0 || task.DryRun {
+		return nil, nil
+	}
+
+	kinds := make([]metadata.CloudResourceKind, 0)
+	for _, mapping := range task.ResourceMappings {
+		kinds = append(kinds, mapping.Kind)
+	}
+	resources, err := r.logics.GetCloudResources(kit, *accountConf, task.SyncVpcs, kinds)
+	if err != nil {
+		blog.Errorf("GetCloudResources fail, taskID: %d, err: %v, rid: %s", task.TaskID, err, kit.Rid)
+		return nil, err
+	}
+	return resources, nil
+}
+
+// Sync 同步任务配置的主机以外的云资源，需要在云主机同步之后执行，以便关联到最新的云主机。
+// 传入的kit需要带有云主机同步的事务信息，返回按目标模型统计的同步详情
+func (r *ResourceSyncor) Sync(readKit, writeKit *rest.Kit, task *metadata.CloudSyncTask,
+	resources map[metadata.CloudResourceKind][]*metadata.CloudResource) (map[string]*metadata.SyncResourceInfo,
+	error) {
+
+	r.readKit = readKit
+	r.writeKit = writeKit
+
+	detail := make(map[string]*metadata.SyncResourceInfo)
+	if len(task.ResourceMappings) == 0 || task.DryRun {
+		return detail, nil
+	}
+
+	if err := r.syncResources(task, resources, detail); err != nil {
+		blog.Errorf("sync resource fail, taskID: %d, err: %v, rid: %s", task.TaskID, err, r.readKit.Rid)
+		return nil, err
+	}
+	return detail, nil
+}
+
+// syncResources 按同步配置将云资源同步到对应的模型
+func (r *ResourceSyncor) syncResources(task *metadata.CloudSyncTask,
+	resources map[metadata.CloudResourceKind][]*metadata.CloudResource,
+	detail map[string]*metadata.SyncResourceInfo) error {
+
+	vpcIDs := make([]string, 0)
+	for _, vpc := range task.SyncVpcs {
+		if !vpc.Destroyed {
+			vpcIDs = append(vpcIDs, vpc.VpcID)
+		}
+	}
+
+	localHosts, err := r.getLocalHostIDs(vpcIDs)
+	if err != nil {
+		blog.Errorf("get local hosts failed, taskID: %d, err: %v, rid: %s", task.TaskID, err, r.readKit.Rid)
+		return err
+	}
+
+	for i := range task.ResourceMappings {
+		mapping := &task.ResourceMappings[i]
+
+		// 只同步属于任务vpc的云资源，以及关联了本任务云主机的云资源
+		taskResources := make([]*metadata.CloudResource, 0)
+		for _, res := range resources[mapping.Kind] {
+			if r.belongToTask(res, vpcIDs, localHosts) {
+				taskResources = append(taskResources, res)
+			}
+		}
+
+		info, err := r.syncMapping(mapping, taskResources, localHosts)
+		if err != nil {
+			blog.Errorf("sync %s to model %s failed, taskID: %d, err: %v, rid: %s", mapping.Kind, mapping.ObjID,
+				task.TaskID, err, r.readKit.Rid)
+			return err
+		}
+		detail[mapping.ObjID] = info
+	}
+
+	return nil
+}
+
+// belongToTask 判断云资源是否属于同步任务
+func (r *ResourceSyncor) belongToTask(res *metadata.CloudResource, vpcIDs []string,
+	localHosts map[string]int64) bool {
+
+	if res.VpcID != "" && util.InStrArr(vpcIDs, res.VpcID) {
+		return true
+	}
+	for _, instID := range res.InstanceIDs {
+		if _, exists := localHosts[instID]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// syncMapping 将一种云资源同步到配置的模型中，并同步与云主机的关联
+func (r *ResourceSyncor) syncMapping(mapping *metadata.CloudResourceMapping, resources []*metadata.CloudResource,
+	localHosts map[string]int64) (*metadata.SyncResourceInfo, error) {
+
+	info := &metadata.SyncResourceInfo{Kind: mapping.Kind}
+	if len(resources) == 0 {
+		return info, nil
+	}
+
+	idField := mapping.GetIDField()
+	resourceIDs := make([]string, 0)
+	for _, res := range resources {
+		resourceIDs = append(resourceIDs, res.ResourceID)
+	}
+
+	localInsts, err := r.getLocalInsts(mapping.ObjID, idField, resourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := auditlog.NewInstanceAudit(r.logics.CoreAPI.CoreService())
+	createData := make([]mapstr.MapStr, 0)
+	for _, res := range resources {
+		data := r.buildInstData(mapping, res)
+
+		localInst, exists := localInsts[res.ResourceID]
+		if !exists {
+			createData = append(createData, data)
+			continue
+		}
+
+		updateData := r.getChangedFields(localInst, data)
+		if len(updateData) == 0 {
+			continue
+		}
+
+		// generate audit log before update.
+		genAuditParam := auditlog.NewGenerateAuditCommonParameter(r.readKit, metadata.AuditUpdate).
+			WithOperateFrom(metadata.FromCloudSync).WithUpdateFields(updateData)
+		logs, err := audit.GenerateAuditLog(genAuditParam, mapping.ObjID, []mapstr.MapStr{localInst})
+		if err != nil {
+			blog.Errorf("generate audit log failed, obj: %s, id: %s, err: %v, rid: %s", mapping.ObjID,
+				res.ResourceID, err, r.readKit.Rid)
+			return nil, err
+		}
+
+		input := &metadata.UpdateOption{
+			Condition:  mapstr.MapStr{idField: res.ResourceID},
+			Data:       updateData,
+			CanEditAll: true,
+		}
+		_, err = r.logics.CoreAPI.CoreService().Instance().UpdateInstance(r.writeKit.Ctx, r.writeKit.Header,
+			mapping.ObjID, input)
+		if err != nil {
+			blog.Errorf("update %s instance failed, input: %#v, err: %v, rid: %s", mapping.ObjID, input, err,
+				r.readKit.Rid)
+			return nil, err
+		}
+
+		if err := audit.SaveAuditLog(r.writeKit, logs...); err != nil {
+			blog.Errorf("save audit log failed after update instance, err: %v, rid: %s", err, r.readKit.Rid)
+			return nil, err
+		}
+		info.Update++
+	}
+
+	if len(createData) > 0 {
+		obj, err := r.logics.GetObject(r.readKit, mapping.ObjID)
+		if err != nil {
+			return nil, err
+		}
+
+		// 通过实例创建逻辑创建实例，将新增的实例注册到iam
+		addedIDs, err := r.logics.CreateInstances(r.writeKit, obj, createData)
+		if err != nil {
+			return nil, err
+		}
+		info.NewAdd = int64(len(addedIDs))
+
+		// generate audit log after create.
+		genAuditParam := auditlog.NewGenerateAuditCommonParameter(r.readKit, metadata.AuditCreate).
+			WithOperateFrom(metadata.FromCloudSync)
+		cond := mapstr.MapStr{common.BKInstIDField: mapstr.MapStr{common.BKDBIN: addedIDs}}
+		logs, err := audit.GenerateAuditLogByCondGetData(genAuditParam, mapping.ObjID, cond)
+		if err != nil {
+			blog.Errorf("generate audit log failed after create instance, obj: %s, err: %v, rid: %s",
+				mapping.ObjID, err, r.readKit.Rid)
+			return nil, err
+		}
+		if len(logs) > 0 {
+			if err := audit.SaveAuditLog(r.writeKit, logs...); err != nil {
+				blog.Errorf("save audit log failed after create instance, err: %v, rid: %s", err, r.readKit.Rid)
+				return nil, err
+			}
+		}
+	}
+
+	if mapping.HostAsstID == "" {
+		return info, nil
+	}
+
+	// 新增的实例需要重新获取实例id
+	localInsts, err = r.getLocalInsts(mapping.ObjID, idField, resourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	info.NewAssociate, info.DelAssociate, err = r.syncHostAssociations(mapping, resources, localInsts, localHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// buildInstData 根据属性映射生成模型实例数据
+func (r *ResourceSyncor) buildInstData(mapping *metadata.CloudResourceMapping,
+	res *metadata.CloudResource) mapstr.MapStr {
+
+	data := mapstr.MapStr{mapping.GetIDField(): res.ResourceID}
+	for cloudField, objField := range mapping.FieldMapping {
+		if val, exists := res.GetField(cloudField); exists {
+			data[objField] = val
+		}
+	}
+
+	// 实例名称是必填的，没有配置映射时使用云资源的名称
+	if _, exists := data[common.BKInstNameField]; !exists {
+		name := res.Name
+		if name == "" {
+			name = res.ResourceID
+		}
+		data[common.BKInstNameField] = name
+	}
+	return data
+}
+
+// getChangedFields 获取实例中与云资源不一致的属性
+func (r *ResourceSyncor) getChangedFields(localInst, data mapstr.MapStr) mapstr.MapStr {
+	changed := mapstr.New()
+	for field, val := range data {
+		if !reflect.DeepEqual(r.normalizeValue(localInst[field]), r.normalizeValue(val)) {
+			changed[field] = val
+		}
+	}
+	return changed
+}
+
+// normalizeValue 将属性值转换为json解码后的结构，使得不同类型的数值(如int与float64)、数组与map可以按值比较，
+// 数值统一转换为json.Number，以避免大整数的精度丢失
+func (r *ResourceSyncor) normalizeValue(val interface{}) interface{} {
+	js, err := json.Marshal(val)
+	if err != nil {
+		return val
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(js))
+	decoder.UseNumber()
+	var normalized interface{}
+	if err := decoder.Decode(&normalized); err != nil {
+		return val
+	}
+	return normalized
+}
+
+// syncHostAssociations 同步云资源实例与云主机的关联，新增缺少的关联，删除云资源已不再关联的主机的关联，
+// 返回新增与删除的关联数量
+func (r *ResourceSyncor) syncHostAssociations(mapping *metadata.CloudResourceMapping,
+	resources []*metadata.CloudResource, localInsts map[string]mapstr.MapStr, localHosts map[string]int64) (
+	int64, int64, error) {
+
+	asst, err := r.getHostAssociation(mapping)
+	if err != nil {
+		return 0, 0, err
+	}
+	// 关联关系中模型可能是源模型也可能是目标模型
+	isSrc := asst.ObjectID == mapping.ObjID
+
+	instIDs := make([]int64, 0)
+	for _, inst := range localInsts {
+		instID, err := inst.Int64(common.BKInstIDField)
+		if err != nil {
+			blog.Errorf("get instance id failed, inst: %#v, err: %v, rid: %s", inst, err, r.readKit.Rid)
+			return 0, 0, err
+		}
+		instIDs = append(instIDs, instID)
+	}
+
+	existAssts, err := r.getExistAssociations(mapping.ObjID, asst.AssociationName, isSrc, instIDs)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	newAssts, staleAssts, err := r.diffHostAssociations(mapping, asst, resources, localInsts, localHosts,
+		existAssts)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := r.deleteHostAssociations(mapping.ObjID, staleAssts); err != nil {
+		return 0, 0, err
+	}
+
+	if err := r.createHostAssociations(newAssts); err != nil {
+		return 0, 0, err
+	}
+
+	return int64(len(newAssts)), int64(len(staleAssts)), nil
+}
+
+// diffHostAssociations 对比云资源关联的云主机与已有的关联，返回需要新增的关联，以及云资源已不再关联的主机的过期关联
+func (r *ResourceSyncor) diffHostAssociations(mapping *metadata.CloudResourceMapping, asst *metadata.Association,
+	resources []*metadata.CloudResource, localInsts map[string]mapstr.MapStr, localHosts map[string]int64,
+	existAssts map[string]metadata.InstAsst) ([]metadata.InstAsst, []metadata.InstAsst, error) {
+
+	// 关联关系中模型可能是源模型也可能是目标模型
+	isSrc := asst.ObjectID == mapping.ObjID
+
+	newAssts := make([]metadata.InstAsst, 0)
+	// 云资源当前关联的主机，不在其中的已有关联为过期的关联
+	validAssts := make(map[string]bool)
+	for _, res := range resources {
+		instID, err := localInsts[res.ResourceID].Int64(common.BKInstIDField)
+		if err != nil {
+			blog.Errorf("get instance id failed, resource: %s, err: %v, rid: %s", res.ResourceID, err, r.readKit.Rid)
+			return nil, nil, err
+		}
+
+		for _, cloudInstID := range res.InstanceIDs {
+			hostID, exists := localHosts[cloudInstID]
+			if !exists {
+				continue
+			}
+
+			key := r.asstKey(instID, hostID)
+			if validAssts[key] {
+				continue
+			}
+			validAssts[key] = true
+			if _, exists := existAssts[key]; exists {
+				continue
+			}
+
+			instAsst := metadata.InstAsst{
+				ObjectAsstID:      asst.AssociationName,
+				AssociationKindID: asst.AsstKindID,
+			}
+			if isSrc {
+				instAsst.ObjectID, instAsst.InstID = mapping.ObjID, instID
+				instAsst.AsstObjectID, instAsst.AsstInstID = common.BKInnerObjIDHost, hostID
+			} else {
+				instAsst.ObjectID, instAsst.InstID = common.BKInnerObjIDHost, hostID
+				instAsst.AsstObjectID, instAsst.AsstInstID = mapping.ObjID, instID
+			}
+			newAssts = append(newAssts, instAsst)
+		}
+	}
+
+	staleAssts := make([]metadata.InstAsst, 0)
+	for key, instAsst := range existAssts {
+		if !validAssts[key] {
+			staleAssts = append(staleAssts, instAsst)
+		}
+	}
+	return newAssts, staleAssts, nil
+}
+
+// createHostAssociations 创建云资源实例与云主机的关联
+func (r *ResourceSyncor) createHostAssociations(assts []metadata.InstAsst) error {
+	if len(assts) == 0 {
+		return nil
+	}
+
+	asstAudit := auditlog.NewInstanceAssociationAudit(r.logics.CoreAPI.CoreService())
+	genAuditParam := auditlog.NewGenerateAuditCommonParameter(r.readKit, metadata.AuditCreate).
+		WithOperateFrom(metadata.FromCloudSync)
+	logs := make([]metadata.AuditLog, 0)
+	for i := range assts {
+		instAsst := &assts[i]
+		result, err := r.logics.CoreAPI.CoreService().Association().CreateInstAssociation(r.writeKit.Ctx,
+			r.writeKit.Header, &metadata.CreateOneInstanceAssociation{Data: *instAsst})
+		if err != nil {
+			blog.Errorf("create instance association failed, asst: %#v, err: %v, rid: %s", instAsst, err,
+				r.readKit.Rid)
+			return err
+		}
+		instAsst.ID = int64(result.Created.ID)
+
+		log, err := asstAudit.GenerateAuditLog(genAuditParam, instAsst.ID, instAsst.ObjectID, instAsst)
+		if err != nil {
+			blog.Errorf("generate inst asst audit log failed, asst: %#v, err: %v, rid: %s", instAsst, err,
+				r.readKit.Rid)
+			return err
+		}
+		logs = append(logs, *log)
+	}
+
+	if err := asstAudit.SaveAuditLog(r.writeKit, logs...); err != nil {
+		blog.Errorf("save audit log failed after create instance association, err: %v, rid: %s", err,
+			r.readKit.Rid)
+		return err
+	}
+	return nil
+}
+
+// deleteHostAssociations 删除云资源实例与云主机之间过期的关联
+func (r *ResourceSyncor) deleteHostAssociations(objID string, assts []metadata.InstAsst) error {
+	if len(assts) == 0 {
+		return nil
+	}
+
+	asstIDs := make([]int64, len(assts))
+	for i, instAsst := range assts {
+		asstIDs[i] = instAsst.ID
+	}
+
+	input := &metadata.InstAsstDeleteOption{
+		Opt: metadata.DeleteOption{
+			Condition: mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: asstIDs}},
+		},
+		ObjID: objID,
+	}
+	_, err := r.logics.CoreAPI.CoreService().Association().DeleteInstAssociation(r.writeKit.Ctx,
+		r.writeKit.Header, input)
+	if err != nil {
+		blog.Errorf("delete instance association failed, input: %#v, err: %v, rid: %s", input, err, r.readKit.Rid)
+		return err
+	}
+
+	asstAudit := auditlog.NewInstanceAssociationAudit(r.logics.CoreAPI.CoreService())
+	genAuditParam := auditlog.NewGenerateAuditCommonParameter(r.readKit, metadata.AuditDelete).
+		WithOperateFrom(metadata.FromCloudSync)
+	logs := make([]metadata.AuditLog, 0)
+	for i := range assts {
+		log, err := asstAudit.GenerateAuditLog(genAuditParam, assts[i].ID, objID, &assts[i])
+		if err != nil {
+			blog.Errorf("generate inst asst audit log failed, asst: %#v, err: %v, rid: %s", assts[i], err,
+				r.readKit.Rid)
+			return err
+		}
+		logs = append(logs, *log)
+	}
+
+	if err := asstAudit.SaveAuditLog(r.writeKit, logs...); err != nil {
+		blog.Errorf("save audit log failed after delete instance association, err: %v, rid: %s", err,
+			r.readKit.Rid)
+		return err
+	}
+	return nil
+}
+
+// getHostAssociation 获取同步配置中模型与主机之间的关联关系
+func (r *ResourceSyncor) getHostAssociation(mapping *metadata.CloudResourceMapping) (*metadata.Association,
+	error) {
+
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{common.AssociationObjAsstIDField: mapping.HostAsstID},
+	}
+	result, err := r.logics.CoreAPI.CoreService().Association().ReadModelAssociation(r.readKit.Ctx,
+		r.readKit.Header, query)
+	if err != nil {
+		blog.Errorf("read model association failed, query: %#v, err: %v, rid: %s", query, err, r.readKit.Rid)
+		return nil, err
+	}
+
+	for i := range result.Info {
+		asst := result.Info[i]
+		if (asst.ObjectID == mapping.ObjID && asst.AsstObjID == common.BKInnerObjIDHost) ||
+			(asst.ObjectID == common.BKInnerObjIDHost && asst.AsstObjID == mapping.ObjID) {
+			return &asst, nil
+		}
+	}
+
+	blog.Errorf("association %s between %s and host is not found, rid: %s", mapping.HostAsstID, mapping.ObjID,
+		r.readKit.Rid)
+	return nil, fmt.Errorf("association %s between %s and host is not found", mapping.HostAsstID, mapping.ObjID)
+}
+
+// getExistAssociations 获取实例与主机之间已有的关联，key为实例与主机关联的唯一标识
+func (r *ResourceSyncor) getExistAssociations(objID, objAsstID string, isSrc bool, instIDs []int64) (
+	map[string]metadata.InstAsst, error) {
+
+	instField, hostField := common.BKInstIDField, common.BKAsstInstIDField
+	if !isSrc {
+		instField, hostField = hostField, instField
+	}
+
+	query := &metadata.InstAsstQueryCondition{
+		Cond: metadata.QueryCondition{
+			Condition: mapstr.MapStr{
+				common.AssociationObjAsstIDField: objAsstID,
+				instField:                        mapstr.MapStr{common.BKDBIN: instIDs},
+			},
+		},
+		ObjID: objID,
+	}
+	result, err := r.logics.CoreAPI.CoreService().Association().ReadInstAssociation(r.readKit.Ctx,
+		r.readKit.Header, query)
+	if err != nil {
+		blog.Errorf("read instance association failed, query: %#v, err: %v, rid: %s", query, err, r.readKit.Rid)
+		return nil, err
+	}
+
+	exists := make(map[string]metadata.InstAsst)
+	for _, asst := range result.Info {
+		if hostField == common.BKAsstInstIDField {
+			exists[r.asstKey(asst.InstID, asst.AsstInstID)] = asst
+		} else {
+			exists[r.asstKey(asst.AsstInstID, asst.InstID)] = asst
+		}
+	}
+	return exists, nil
+}
+
+// asstKey 实例与主机关联的唯一标识
+func (r *ResourceSyncor) asstKey(instID, hostID int64) string {
+	return fmt.Sprintf("%d:%d", instID, hostID)
+}
+
+// getLocalInsts 获取已同步的模型实例，key为云资源id
+func (r *ResourceSyncor) getLocalInsts(objID, idField string, resourceIDs []string) (map[string]mapstr.MapStr,
+	error) {
+
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: resourceIDs}},
+		Page:      metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	result, err := r.logics.CoreAPI.CoreService().Instance().ReadInstance(r.readKit.Ctx, r.readKit.Header, objID,
+		query)
+	if err != nil {
+		blog.Errorf("read %s instances failed, query: %#v, err: %v, rid: %s", objID, query, err, r.readKit.Rid)
+		return nil, err
+	}
+
+	insts := make(map[string]mapstr.MapStr)
+	for _, inst := range result.Info {
+		insts[util.GetStrByInterface(inst[idField])] = inst
+	}
+	return insts, nil
+}
+
+// getLocalHostIDs 获取同步任务vpc下的云主机，key为云主机实例id，value为主机id
+func (r *ResourceSyncor) getLocalHostIDs(vpcIDs []string) (map[string]int64, error) {
+	hosts := make(map[string]int64)
+	if len(vpcIDs) == 0 {
+		return hosts, nil
+	}
+
+	vpcCloudIDs, err := r.logics.GetVpcCloudArea(r.readKit, vpcIDs)
+	if err != nil {
+		return nil, err
+	}
+	cloudIDs := make([]int64, 0)
+	for _, cloudID := range vpcCloudIDs {
+		cloudIDs = append(cloudIDs, cloudID)
+	}
+	if len(cloudIDs) == 0 {
+		return hosts, nil
+	}
+
+	query := &metadata.QueryCondition{
+		Fields: []string{common.BKHostIDField, common.BKCloudInstIDField},
+		Condition: mapstr.MapStr{
+			common.BKCloudIDField: mapstr.MapStr{common.BKDBIN: cloudIDs},
+			// 必须带有实例id，说明是云主机
+			common.BKCloudInstIDField: mapstr.MapStr{common.BKDBNIN: []interface{}{nil, ""}},
+		},
+		Page: metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	result, err := r.logics.CoreAPI.CoreService().Instance().ReadInstance(r.readKit.Ctx, r.readKit.Header,
+		common.BKInnerObjIDHost, query)
+	if err != nil {
+		blog.Errorf("get local hosts failed, query: %#v, err: %v, rid: %s", query, err, r.readKit.Rid)
+		return nil, err
+	}
+
+	for _, host := range result.Info {
+		instID, _ := host.String(common.BKCloudInstIDField)
+		hostID, err := host.Int64(common.BKHostIDField)
+		if err != nil {
+			blog.Errorf("get host id failed, host: %#v, err: %v, rid: %s", host, err, r.readKit.Rid)
+			return nil, err
+		}
+		hosts[instID] = hostID
+	}
+	return hosts, nil
+}
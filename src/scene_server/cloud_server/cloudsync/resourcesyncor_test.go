@@ -0,0 +1,317 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudsync
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudResourceMappingValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mapping metadata.CloudResourceMapping
+		errCode int
+	}{
+		{
+			name:    "valid mapping",
+			mapping: metadata.CloudResourceMapping{Kind: metadata.CloudDisk, ObjID: "disk"},
+		},
+		{
+			name: "valid mapping with custom id field",
+			mapping: metadata.CloudResourceMapping{Kind: metadata.CloudLoadBalancer, ObjID: "lb", IDField: "lb_id",
+				FieldMapping: map[string]string{"name": "lb_name"}},
+		},
+		{
+			name:    "unsupported kind",
+			mapping: metadata.CloudResourceMapping{Kind: "vpc", ObjID: "vpc"},
+			errCode: common.CCErrCommParamsInvalid,
+		},
+		{
+			name:    "empty object id",
+			mapping: metadata.CloudResourceMapping{Kind: metadata.CloudDisk},
+			errCode: common.CCErrCommParamsNeedSet,
+		},
+		{
+			name:    "host can not be the target model",
+			mapping: metadata.CloudResourceMapping{Kind: metadata.CloudDisk, ObjID: common.BKInnerObjIDHost},
+			errCode: common.CCErrCommParamsInvalid,
+		},
+		{
+			name:    "cloud area can not be the target model",
+			mapping: metadata.CloudResourceMapping{Kind: metadata.CloudDisk, ObjID: common.BKInnerObjIDPlat},
+			errCode: common.CCErrCommParamsInvalid,
+		},
+		{
+			name: "field mapping overwrites the default id field",
+			mapping: metadata.CloudResourceMapping{Kind: metadata.CloudSecurityGroup, ObjID: "sg",
+				FieldMapping: map[string]string{"name": common.BKCloudInstIDField}},
+			errCode: common.CCErrCommParamsInvalid,
+		},
+		{
+			name: "field mapping overwrites the custom id field",
+			mapping: metadata.CloudResourceMapping{Kind: metadata.CloudSecurityGroup, ObjID: "sg", IDField: "sg_id",
+				FieldMapping: map[string]string{"name": "sg_id"}},
+			errCode: common.CCErrCommParamsInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.errCode, tc.mapping.Validate().ErrCode, tc.name)
+	}
+}
+
+func TestCloudResourceMappingGetIDField(t *testing.T) {
+	mapping := metadata.CloudResourceMapping{Kind: metadata.CloudDisk, ObjID: "disk"}
+	require.Equal(t, common.BKCloudInstIDField, mapping.GetIDField())
+
+	mapping.IDField = "disk_id"
+	require.Equal(t, "disk_id", mapping.GetIDField())
+}
+
+func TestBelongToTask(t *testing.T) {
+	r := new(ResourceSyncor)
+	vpcIDs := []string{"vpc-1", "vpc-2"}
+	localHosts := map[string]int64{"ins-1": 1, "ins-2": 2}
+
+	testCases := []struct {
+		name     string
+		resource *metadata.CloudResource
+		expected bool
+	}{
+		{
+			name:     "resource in task vpc",
+			resource: &metadata.CloudResource{ResourceID: "lb-1", VpcID: "vpc-2"},
+			expected: true,
+		},
+		{
+			name:     "resource associated with task host",
+			resource: &metadata.CloudResource{ResourceID: "disk-1", InstanceIDs: []string{"ins-x", "ins-1"}},
+			expected: true,
+		},
+		{
+			name:     "resource in other vpc without task host",
+			resource: &metadata.CloudResource{ResourceID: "lb-2", VpcID: "vpc-3", InstanceIDs: []string{"ins-3"}},
+			expected: false,
+		},
+		{
+			name:     "resource without vpc and host",
+			resource: &metadata.CloudResource{ResourceID: "sg-1"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, r.belongToTask(tc.resource, vpcIDs, localHosts), tc.name)
+	}
+}
+
+func TestBuildInstData(t *testing.T) {
+	r := new(ResourceSyncor)
+	resource := &metadata.CloudResource{
+		ResourceID: "disk-1",
+		Name:       "data-disk",
+		Region:     "ap-guangzhou",
+		Status:     "attached",
+		Attributes: map[string]interface{}{"size": 100},
+	}
+
+	testCases := []struct {
+		name     string
+		mapping  *metadata.CloudResourceMapping
+		expected mapstr.MapStr
+	}{
+		{
+			name:    "default id field and name",
+			mapping: &metadata.CloudResourceMapping{Kind: metadata.CloudDisk, ObjID: "disk"},
+			expected: mapstr.MapStr{
+				common.BKCloudInstIDField: "disk-1",
+				common.BKInstNameField:    "data-disk",
+			},
+		},
+		{
+			name: "mapped fields and custom id field",
+			mapping: &metadata.CloudResourceMapping{Kind: metadata.CloudDisk, ObjID: "disk", IDField: "disk_id",
+				FieldMapping: map[string]string{"region": "disk_region", "size": "disk_size", "unknown": "other"}},
+			expected: mapstr.MapStr{
+				"disk_id":              "disk-1",
+				"disk_region":          "ap-guangzhou",
+				"disk_size":            100,
+				common.BKInstNameField: "data-disk",
+			},
+		},
+		{
+			name: "mapped instance name",
+			mapping: &metadata.CloudResourceMapping{Kind: metadata.CloudDisk, ObjID: "disk",
+				FieldMapping: map[string]string{"status": common.BKInstNameField}},
+			expected: mapstr.MapStr{
+				common.BKCloudInstIDField: "disk-1",
+				common.BKInstNameField:    "attached",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, r.buildInstData(tc.mapping, resource), tc.name)
+	}
+
+	// the instance name falls back to resource id when the resource has no name
+	noName := &metadata.CloudResource{ResourceID: "disk-2"}
+	data := r.buildInstData(&metadata.CloudResourceMapping{Kind: metadata.CloudDisk, ObjID: "disk"}, noName)
+	require.Equal(t, "disk-2", data[common.BKInstNameField])
+}
+
+func TestGetChangedFields(t *testing.T) {
+	r := new(ResourceSyncor)
+	localInst := mapstr.MapStr{
+		common.BKInstIDField:   int64(1),
+		common.BKInstNameField: "lb-1",
+		"lb_port":              float64(80),
+		"lb_status":            "running",
+		"lb_rules":             []interface{}{"tcp:80", "tcp:443"},
+		"lb_tags":              map[string]interface{}{"env": float64(1)},
+		"lb_bandwidth":         int64(9007199254740992),
+	}
+
+	testCases := []struct {
+		name     string
+		data     mapstr.MapStr
+		expected mapstr.MapStr
+	}{
+		{
+			name:     "nothing changed",
+			data:     mapstr.MapStr{common.BKInstNameField: "lb-1", "lb_port": 80},
+			expected: mapstr.MapStr{},
+		},
+		{
+			name:     "value changed",
+			data:     mapstr.MapStr{common.BKInstNameField: "lb-1", "lb_status": "stopped"},
+			expected: mapstr.MapStr{"lb_status": "stopped"},
+		},
+		{
+			name:     "field missing in local instance",
+			data:     mapstr.MapStr{"lb_vip": "10.0.0.1"},
+			expected: mapstr.MapStr{"lb_vip": "10.0.0.1"},
+		},
+		{
+			name:     "value with different type",
+			data:     mapstr.MapStr{"lb_port": "80"},
+			expected: mapstr.MapStr{"lb_port": "80"},
+		},
+		{
+			name:     "nil and empty string are different",
+			data:     mapstr.MapStr{"lb_vip": ""},
+			expected: mapstr.MapStr{"lb_vip": ""},
+		},
+		{
+			name:     "same list with different element type",
+			data:     mapstr.MapStr{"lb_rules": []string{"tcp:80", "tcp:443"}},
+			expected: mapstr.MapStr{},
+		},
+		{
+			name:     "list changed",
+			data:     mapstr.MapStr{"lb_rules": []string{"tcp:80"}},
+			expected: mapstr.MapStr{"lb_rules": []string{"tcp:80"}},
+		},
+		{
+			name:     "same map with different value type",
+			data:     mapstr.MapStr{"lb_tags": map[string]int{"env": 1}},
+			expected: mapstr.MapStr{},
+		},
+		{
+			name:     "large integer changed",
+			data:     mapstr.MapStr{"lb_bandwidth": int64(9007199254740993)},
+			expected: mapstr.MapStr{"lb_bandwidth": int64(9007199254740993)},
+		},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, r.getChangedFields(localInst, tc.data), tc.name)
+	}
+}
+
+func TestResourceSyncorSkipTask(t *testing.T) {
+	r := new(ResourceSyncor)
+	mappings := []metadata.CloudResourceMapping{{Kind: metadata.CloudDisk, ObjID: "disk"}}
+
+	// task without resource mappings and dry run task do not sync resources other than host
+	for _, task := range []*metadata.CloudSyncTask{{TaskID: 1}, {TaskID: 2, ResourceMappings: mappings, DryRun: true}} {
+		resources, err := r.GetResources(nil, task, nil)
+		require.NoError(t, err)
+		require.Empty(t, resources)
+
+		detail, err := r.Sync(nil, nil, task, nil)
+		require.NoError(t, err)
+		require.Empty(t, detail)
+	}
+}
+
+func TestDiffHostAssociations(t *testing.T) {
+	r := &ResourceSyncor{readKit: &rest.Kit{}}
+	mapping := &metadata.CloudResourceMapping{Kind: metadata.CloudDisk, ObjID: "disk"}
+	resources := []*metadata.CloudResource{
+		{ResourceID: "disk-1", InstanceIDs: []string{"ins-1", "ins-2", "ins-unknown"}},
+		{ResourceID: "disk-2", InstanceIDs: []string{"ins-1", "ins-1"}},
+	}
+	localInsts := map[string]mapstr.MapStr{
+		"disk-1": {common.BKInstIDField: int64(1)},
+		"disk-2": {common.BKInstIDField: int64(2)},
+	}
+	localHosts := map[string]int64{"ins-1": 11, "ins-2": 12, "ins-3": 13}
+	existAssts := map[string]metadata.InstAsst{
+		// disk-1 is still attached to ins-1
+		r.asstKey(1, 11): {ID: 100, InstID: 1, AsstInstID: 11},
+		// disk-1 is detached from ins-3, disk-2 is detached from ins-2
+		r.asstKey(1, 13): {ID: 101, InstID: 1, AsstInstID: 13},
+		r.asstKey(2, 12): {ID: 102, InstID: 2, AsstInstID: 12},
+	}
+
+	asst := &metadata.Association{AssociationName: "disk_connect_host", AsstKindID: "connect", ObjectID: "disk",
+		AsstObjID: common.BKInnerObjIDHost}
+	newAssts, staleAssts, err := r.diffHostAssociations(mapping, asst, resources, localInsts, localHosts,
+		existAssts)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []metadata.InstAsst{
+		{ObjectID: "disk", InstID: 1, AsstObjectID: common.BKInnerObjIDHost, AsstInstID: 12,
+			ObjectAsstID: "disk_connect_host", AssociationKindID: "connect"},
+		{ObjectID: "disk", InstID: 2, AsstObjectID: common.BKInnerObjIDHost, AsstInstID: 11,
+			ObjectAsstID: "disk_connect_host", AssociationKindID: "connect"},
+	}, newAssts)
+	require.ElementsMatch(t, []metadata.InstAsst{existAssts[r.asstKey(1, 13)], existAssts[r.asstKey(2, 12)]},
+		staleAssts)
+
+	// the object is the target object of the association
+	asst = &metadata.Association{AssociationName: "host_connect_disk", AsstKindID: "connect",
+		ObjectID: common.BKInnerObjIDHost, AsstObjID: "disk"}
+	newAssts, staleAssts, err = r.diffHostAssociations(mapping, asst, resources[1:], localInsts, localHosts,
+		map[string]metadata.InstAsst{})
+	require.NoError(t, err)
+	require.Equal(t, []metadata.InstAsst{{ObjectID: common.BKInnerObjIDHost, InstID: 11, AsstObjectID: "disk",
+		AsstInstID: 2, ObjectAsstID: "host_connect_disk", AssociationKindID: "connect"}}, newAssts)
+	require.Empty(t, staleAssts)
+
+	// the synced instance of the resource is not found
+	_, _, err = r.diffHostAssociations(mapping, asst, []*metadata.CloudResource{{ResourceID: "disk-3"}},
+		localInsts, localHosts, existAssts)
+	require.Error(t, err)
+}
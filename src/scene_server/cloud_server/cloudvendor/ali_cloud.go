@@ -0,0 +1,628 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudvendor
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+)
+
+func init() {
+	Register(metadata.AliCloud, &aliClient{vendorName: metadata.AliCloud})
+}
+
+type aliClient struct {
+	vendorName string
+	secretID   string
+	secretKey  string
+	// ecsEndpoint ecs接口的接入地址
+	ecsEndpoint string
+	// vpcEndpoint vpc接口的接入地址
+	vpcEndpoint string
+	// slbEndpoint 负载均衡接口的接入地址
+	slbEndpoint string
+	httpCli     *http.Client
+}
+
+const (
+	aliMinPageSize int64 = 1
+	// aliMaxPageSize DescribeInstances单页最大值为100，DescribeVpcs单页最大值为50，统一使用较小值
+	aliMaxPageSize int64 = 50
+
+	aliEcsEndpoint   = "https://ecs.aliyuncs.com"
+	aliVpcEndpoint   = "https://vpc.aliyuncs.com"
+	aliSlbEndpoint   = "https://slb.aliyuncs.com"
+	aliEcsAPIVersion = "2014-05-26"
+	aliVpcAPIVersion = "2016-04-28"
+	aliSlbAPIVersion = "2014-05-15"
+	aliTimeFormat    = "2006-01-02T15:04:05Z"
+)
+
+// NewVendorClient 创建云厂商客户端
+func (c *aliClient) NewVendorClient(conf metadata.CloudAccountConf) VendorClient {
+	return &aliClient{
+		vendorName:  metadata.AliCloud,
+		secretID:    conf.SecretID,
+		secretKey:   conf.SecretKey,
+		ecsEndpoint: aliEcsEndpoint,
+		vpcEndpoint: aliVpcEndpoint,
+		slbEndpoint: aliSlbEndpoint,
+		httpCli:     newVendorHttpClient(),
+	}
+}
+
+// aliRegionsResp 地域列表返回结果
+type aliRegionsResp struct {
+	Regions struct {
+		Region []struct {
+			RegionId  string `json:"RegionId"`
+			LocalName string `json:"LocalName"`
+			Status    string `json:"Status"`
+		} `json:"Region"`
+	} `json:"Regions"`
+}
+
+// aliVpcsResp vpc列表返回结果
+type aliVpcsResp struct {
+	TotalCount int64 `json:"TotalCount"`
+	Vpcs       struct {
+		Vpc []struct {
+			VpcId   string `json:"VpcId"`
+			VpcName string `json:"VpcName"`
+		} `json:"Vpc"`
+	} `json:"Vpcs"`
+}
+
+// aliIpAddress 阿里云ip地址列表
+type aliIpAddress struct {
+	IpAddress []string `json:"IpAddress"`
+}
+
+// aliInstancesResp 实例列表返回结果
+type aliInstancesResp struct {
+	TotalCount int64 `json:"TotalCount"`
+	Instances  struct {
+		Instance []struct {
+			InstanceId      string       `json:"InstanceId"`
+			Status          string       `json:"Status"`
+			InnerIpAddress  aliIpAddress `json:"InnerIpAddress"`
+			PublicIpAddress aliIpAddress `json:"PublicIpAddress"`
+			EipAddress      struct {
+				IpAddress string `json:"IpAddress"`
+			} `json:"EipAddress"`
+			VpcAttributes struct {
+				VpcId            string       `json:"VpcId"`
+				PrivateIpAddress aliIpAddress `json:"PrivateIpAddress"`
+			} `json:"VpcAttributes"`
+			SecurityGroupIds struct {
+				SecurityGroupId []string `json:"SecurityGroupId"`
+			} `json:"SecurityGroupIds"`
+		} `json:"Instance"`
+	} `json:"Instances"`
+}
+
+// aliDisksResp 云盘列表返回结果
+type aliDisksResp struct {
+	TotalCount int64 `json:"TotalCount"`
+	Disks      struct {
+		Disk []struct {
+			DiskId     string `json:"DiskId"`
+			DiskName   string `json:"DiskName"`
+			Status     string `json:"Status"`
+			Type       string `json:"Type"`
+			Category   string `json:"Category"`
+			Size       int64  `json:"Size"`
+			ZoneId     string `json:"ZoneId"`
+			InstanceId string `json:"InstanceId"`
+		} `json:"Disk"`
+	} `json:"Disks"`
+}
+
+// aliSecurityGroupsResp 安全组列表返回结果
+type aliSecurityGroupsResp struct {
+	TotalCount     int64 `json:"TotalCount"`
+	SecurityGroups struct {
+		SecurityGroup []struct {
+			SecurityGroupId   string `json:"SecurityGroupId"`
+			SecurityGroupName string `json:"SecurityGroupName"`
+			Description       string `json:"Description"`
+			VpcId             string `json:"VpcId"`
+		} `json:"SecurityGroup"`
+	} `json:"SecurityGroups"`
+}
+
+// aliLoadBalancersResp 负载均衡列表返回结果
+type aliLoadBalancersResp struct {
+	TotalCount    int64 `json:"TotalCount"`
+	LoadBalancers struct {
+		LoadBalancer []struct {
+			LoadBalancerId     string `json:"LoadBalancerId"`
+			LoadBalancerName   string `json:"LoadBalancerName"`
+			LoadBalancerStatus string `json:"LoadBalancerStatus"`
+			Address            string `json:"Address"`
+			AddressType        string `json:"AddressType"`
+			VpcId              string `json:"VpcId"`
+		} `json:"LoadBalancer"`
+	} `json:"LoadBalancers"`
+}
+
+// aliLoadBalancerAttrResp 负载均衡详情返回结果
+type aliLoadBalancerAttrResp struct {
+	BackendServers struct {
+		BackendServer []struct {
+			ServerId string `json:"ServerId"`
+			Type     string `json:"Type"`
+		} `json:"BackendServer"`
+	} `json:"BackendServers"`
+}
+
+// GetRegions 获取地域列表
+// API文档：https://help.aliyun.com/document_detail/25609.html
+func (c *aliClient) GetRegions() ([]*metadata.Region, error) {
+	params := map[string]string{"AcceptLanguage": "zh-CN"}
+	resp := new(aliRegionsResp)
+	if err := c.call(c.ecsEndpoint, aliEcsAPIVersion, "DescribeRegions", params, resp); err != nil {
+		return nil, err
+	}
+
+	regionSet := make([]*metadata.Region, 0)
+	for _, region := range resp.Regions.Region {
+		regionSet = append(regionSet, &metadata.Region{
+			RegionId:    region.RegionId,
+			RegionName:  region.LocalName,
+			RegionState: region.Status,
+		})
+	}
+	return regionSet, nil
+}
+
+// GetVpcs 获取vpc列表
+// API文档：https://help.aliyun.com/document_detail/35739.html
+func (c *aliClient) GetVpcs(region string, opt *ccom.VpcOpt) (*metadata.VpcsInfo, error) {
+	if opt == nil {
+		opt = ccom.GetDefaultVpcOpt()
+	}
+	params := map[string]string{"RegionId": region}
+	if vpcIDs := getStringFilterValues(opt.Filters, "vpc-id"); len(vpcIDs) > 0 {
+		params["VpcId"] = strings.Join(vpcIDs, ",")
+	}
+	params["PageSize"] = strconv.FormatInt(c.getPageSize(opt.Limit), 10)
+
+	vpcsInfo := new(metadata.VpcsInfo)
+	loopCnt := 0
+	var totalCnt int64 = 0
+	// 在limit小于全部数据量的情况下，获取limit数量的数据，否则获取全部数据
+	for pageNum := 1; ; pageNum++ {
+		params["PageNumber"] = strconv.Itoa(pageNum)
+		resp := new(aliVpcsResp)
+		if err := c.call(c.vpcEndpoint, aliVpcAPIVersion, "DescribeVpcs", params, resp); err != nil {
+			return nil, err
+		}
+		for _, vpc := range resp.Vpcs.Vpc {
+			vpcName := vpc.VpcName
+			if vpcName == "" {
+				vpcName = vpc.VpcId
+			}
+			vpcsInfo.VpcSet = append(vpcsInfo.VpcSet, &metadata.Vpc{
+				VpcId:   vpc.VpcId,
+				VpcName: vpcName,
+			})
+		}
+		totalCnt = resp.TotalCount
+		// 在获取到limit数量或者全部数据的情况下，退出循环
+		if opt.Limit <= int64(len(vpcsInfo.VpcSet)) || int64(len(vpcsInfo.VpcSet)) >= totalCnt ||
+			len(resp.Vpcs.Vpc) == 0 {
+			break
+		}
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeVpcs loopCnt:%d, bigger than MaxLoopCnt, TotalCount:%d", loopCnt, totalCnt)
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	vpcsInfo.Count = totalCnt
+
+	return vpcsInfo, nil
+}
+
+// GetInstances 获取实例列表
+// API文档：https://help.aliyun.com/document_detail/25506.html
+func (c *aliClient) GetInstances(region string, opt *ccom.InstanceOpt) (*metadata.InstancesInfo, error) {
+	if opt == nil {
+		opt = ccom.GetDefaultInstanceOpt()
+	}
+	params := map[string]string{"RegionId": region}
+	// 阿里云DescribeInstances只支持按单个vpc过滤
+	if vpcIDs := getStringFilterValues(opt.Filters, "vpc-id"); len(vpcIDs) > 0 {
+		params["VpcId"] = vpcIDs[0]
+	}
+	params["PageSize"] = strconv.FormatInt(c.getPageSize(opt.Limit), 10)
+
+	instancesInfo := new(metadata.InstancesInfo)
+	loopCnt := 0
+	var totalCnt int64 = 0
+	// 在limit小于全部数据量的情况下，获取limit数量的数据，否则获取全部数据
+	for pageNum := 1; ; pageNum++ {
+		params["PageNumber"] = strconv.Itoa(pageNum)
+		resp := new(aliInstancesResp)
+		if err := c.call(c.ecsEndpoint, aliEcsAPIVersion, "DescribeInstances", params, resp); err != nil {
+			return nil, err
+		}
+
+		for _, inst := range resp.Instances.Instance {
+			privateIP := ""
+			if len(inst.VpcAttributes.PrivateIpAddress.IpAddress) > 0 {
+				privateIP = inst.VpcAttributes.PrivateIpAddress.IpAddress[0]
+			} else if len(inst.InnerIpAddress.IpAddress) > 0 {
+				// 经典网络的实例没有vpc私网ip
+				privateIP = inst.InnerIpAddress.IpAddress[0]
+			}
+
+			// 优先使用实例分配的公网ip，没有则使用绑定的弹性公网ip
+			publicIP := inst.EipAddress.IpAddress
+			if len(inst.PublicIpAddress.IpAddress) > 0 {
+				publicIP = inst.PublicIpAddress.IpAddress[0]
+			}
+
+			instancesInfo.InstanceSet = append(instancesInfo.InstanceSet, &metadata.Instance{
+				InstanceId:    inst.InstanceId,
+				PrivateIp:     privateIP,
+				PublicIp:      publicIP,
+				InstanceState: ccom.CovertInstState(inst.Status),
+				VpcId:         inst.VpcAttributes.VpcId,
+			})
+		}
+
+		totalCnt = resp.TotalCount
+		// 在获取到limit数量或者全部数据的情况下，退出循环
+		if opt.Limit <= int64(len(instancesInfo.InstanceSet)) ||
+			int64(len(instancesInfo.InstanceSet)) >= totalCnt || len(resp.Instances.Instance) == 0 {
+			break
+		}
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeInstances loopCnt:%d, bigger than MaxLoopCnt, TotalCount:%d", loopCnt, totalCnt)
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	instancesInfo.Count = totalCnt
+
+	return instancesInfo, nil
+}
+
+// GetInstancesTotalCnt 获取实例总个数
+func (c *aliClient) GetInstancesTotalCnt(region string, opt *ccom.InstanceOpt) (int64, error) {
+	if opt == nil {
+		opt = ccom.GetDefaultInstanceOpt()
+	}
+	// 直接将limit设为最小值，能最快地获取到实例总个数
+	opt.Limit = aliMinPageSize
+	instsInfo, err := c.GetInstances(region, opt)
+	if err != nil {
+		return 0, err
+	}
+	return instsInfo.Count, nil
+}
+
+// GetCloudResources 获取主机以外的云资源列表
+func (c *aliClient) GetCloudResources(region string, kind metadata.CloudResourceKind, opt *ccom.ResourceOpt) (
+	*metadata.CloudResourcesInfo, error) {
+
+	if opt == nil {
+		opt = ccom.GetDefaultResourceOpt()
+	}
+
+	var resources []*metadata.CloudResource
+	var err error
+	switch kind {
+	case metadata.CloudDisk:
+		resources, err = c.getDisks(region, opt)
+	case metadata.CloudSecurityGroup:
+		resources, err = c.getSecurityGroups(region, opt)
+	case metadata.CloudLoadBalancer:
+		resources, err = c.getLoadBalancers(region, opt)
+	default:
+		return nil, ccom.ErrResourceKindNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &metadata.CloudResourcesInfo{Count: int64(len(resources)), ResourceSet: resources}, nil
+}
+
+// getDisks 获取云盘列表，云盘不属于vpc，通过挂载的实例关联到vpc
+// API文档：https://help.aliyun.com/document_detail/25514.html
+func (c *aliClient) getDisks(region string, opt *ccom.ResourceOpt) ([]*metadata.CloudResource, error) {
+	params := map[string]string{"RegionId": region}
+	params["PageSize"] = strconv.FormatInt(c.getPageSize(opt.Limit), 10)
+
+	resources := make([]*metadata.CloudResource, 0)
+	loopCnt := 0
+	for pageNum := 1; ; pageNum++ {
+		params["PageNumber"] = strconv.Itoa(pageNum)
+		resp := new(aliDisksResp)
+		if err := c.call(c.ecsEndpoint, aliEcsAPIVersion, "DescribeDisks", params, resp); err != nil {
+			return nil, err
+		}
+
+		for _, disk := range resp.Disks.Disk {
+			name := disk.DiskName
+			if name == "" {
+				name = disk.DiskId
+			}
+			instIDs := make([]string, 0)
+			if disk.InstanceId != "" {
+				instIDs = append(instIDs, disk.InstanceId)
+			}
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  disk.DiskId,
+				Name:        name,
+				Region:      region,
+				Status:      disk.Status,
+				InstanceIDs: instIDs,
+				Attributes: map[string]interface{}{
+					"size":  disk.Size,
+					"type":  disk.Category,
+					"usage": disk.Type,
+					"zone":  disk.ZoneId,
+				},
+			})
+		}
+
+		if opt.Limit <= int64(len(resources)) || int64(len(resources)) >= resp.TotalCount ||
+			len(resp.Disks.Disk) == 0 {
+			break
+		}
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeDisks loopCnt:%d, bigger than MaxLoopCnt, TotalCount:%d", loopCnt, resp.TotalCount)
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// getSecurityGroups 获取安全组列表，安全组关联的实例从实例列表中获取
+// API文档：https://help.aliyun.com/document_detail/25556.html
+func (c *aliClient) getSecurityGroups(region string, opt *ccom.ResourceOpt) ([]*metadata.CloudResource, error) {
+	groupInstIDs, err := c.getSecurityGroupInstIDs(region)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{"RegionId": region}
+	params["PageSize"] = strconv.FormatInt(c.getPageSize(opt.Limit), 10)
+
+	resources := make([]*metadata.CloudResource, 0)
+	loopCnt := 0
+	for pageNum := 1; ; pageNum++ {
+		params["PageNumber"] = strconv.Itoa(pageNum)
+		resp := new(aliSecurityGroupsResp)
+		if err := c.call(c.ecsEndpoint, aliEcsAPIVersion, "DescribeSecurityGroups", params, resp); err != nil {
+			return nil, err
+		}
+
+		for _, group := range resp.SecurityGroups.SecurityGroup {
+			name := group.SecurityGroupName
+			if name == "" {
+				name = group.SecurityGroupId
+			}
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  group.SecurityGroupId,
+				Name:        name,
+				Region:      region,
+				VpcID:       group.VpcId,
+				InstanceIDs: groupInstIDs[group.SecurityGroupId],
+				Attributes:  map[string]interface{}{"description": group.Description},
+			})
+		}
+
+		if opt.Limit <= int64(len(resources)) || int64(len(resources)) >= resp.TotalCount ||
+			len(resp.SecurityGroups.SecurityGroup) == 0 {
+			break
+		}
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeSecurityGroups loopCnt:%d, bigger than MaxLoopCnt, TotalCount:%d", loopCnt,
+				resp.TotalCount)
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// getSecurityGroupInstIDs 获取地域下每个安全组关联的实例id
+func (c *aliClient) getSecurityGroupInstIDs(region string) (map[string][]string, error) {
+	params := map[string]string{"RegionId": region}
+	params["PageSize"] = strconv.FormatInt(aliMaxPageSize, 10)
+
+	groupInstIDs := make(map[string][]string)
+	var count int64
+	loopCnt := 0
+	for pageNum := 1; ; pageNum++ {
+		params["PageNumber"] = strconv.Itoa(pageNum)
+		resp := new(aliInstancesResp)
+		if err := c.call(c.ecsEndpoint, aliEcsAPIVersion, "DescribeInstances", params, resp); err != nil {
+			return nil, err
+		}
+
+		for _, inst := range resp.Instances.Instance {
+			for _, groupID := range inst.SecurityGroupIds.SecurityGroupId {
+				groupInstIDs[groupID] = append(groupInstIDs[groupID], inst.InstanceId)
+			}
+		}
+		count += int64(len(resp.Instances.Instance))
+
+		if count >= resp.TotalCount || len(resp.Instances.Instance) == 0 {
+			break
+		}
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeInstances loopCnt:%d, bigger than MaxLoopCnt, TotalCount:%d", loopCnt,
+				resp.TotalCount)
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return groupInstIDs, nil
+}
+
+// getLoadBalancers 获取负载均衡列表，负载均衡关联的实例为其后端服务器中类型为ecs的服务器
+// API文档：https://help.aliyun.com/document_detail/27582.html
+func (c *aliClient) getLoadBalancers(region string, opt *ccom.ResourceOpt) ([]*metadata.CloudResource, error) {
+	params := map[string]string{"RegionId": region}
+	// 负载均衡只支持按单个vpc过滤
+	if vpcIDs := getStringFilterValues(opt.Filters, "vpc-id"); len(vpcIDs) == 1 {
+		params["VpcId"] = vpcIDs[0]
+	}
+	params["PageSize"] = strconv.FormatInt(c.getPageSize(opt.Limit), 10)
+
+	resources := make([]*metadata.CloudResource, 0)
+	loopCnt := 0
+	for pageNum := 1; ; pageNum++ {
+		params["PageNumber"] = strconv.Itoa(pageNum)
+		resp := new(aliLoadBalancersResp)
+		if err := c.call(c.slbEndpoint, aliSlbAPIVersion, "DescribeLoadBalancers", params, resp); err != nil {
+			return nil, err
+		}
+
+		for _, lb := range resp.LoadBalancers.LoadBalancer {
+			attrResp := new(aliLoadBalancerAttrResp)
+			attrParams := map[string]string{"RegionId": region, "LoadBalancerId": lb.LoadBalancerId}
+			err := c.call(c.slbEndpoint, aliSlbAPIVersion, "DescribeLoadBalancerAttribute", attrParams, attrResp)
+			if err != nil {
+				return nil, err
+			}
+			instIDs := make([]string, 0)
+			for _, server := range attrResp.BackendServers.BackendServer {
+				if server.Type == "" || server.Type == "ecs" {
+					instIDs = append(instIDs, server.ServerId)
+				}
+			}
+
+			name := lb.LoadBalancerName
+			if name == "" {
+				name = lb.LoadBalancerId
+			}
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  lb.LoadBalancerId,
+				Name:        name,
+				Region:      region,
+				VpcID:       lb.VpcId,
+				Status:      lb.LoadBalancerStatus,
+				InstanceIDs: instIDs,
+				Attributes: map[string]interface{}{
+					"address": lb.Address,
+					"scheme":  lb.AddressType,
+				},
+			})
+		}
+
+		if opt.Limit <= int64(len(resources)) || int64(len(resources)) >= resp.TotalCount ||
+			len(resp.LoadBalancers.LoadBalancer) == 0 {
+			break
+		}
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeLoadBalancers loopCnt:%d, bigger than MaxLoopCnt, TotalCount:%d", loopCnt,
+				resp.TotalCount)
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// getPageSize 获取单次请求返回结果条数
+func (c *aliClient) getPageSize(limit int64) int64 {
+	// 按API要求，PageSize的取值范围为1～50，不在该范围的设为最大值
+	if limit < aliMinPageSize || limit > aliMaxPageSize {
+		return aliMaxPageSize
+	}
+	return limit
+}
+
+// call 调用阿里云RPC风格的接口
+// 签名机制文档：https://help.aliyun.com/document_detail/25492.html
+func (c *aliClient) call(endpoint, version, action string, params map[string]string, result interface{}) error {
+	query := map[string]string{
+		"Format":           "JSON",
+		"Version":          version,
+		"Action":           action,
+		"AccessKeyId":      c.secretID,
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureVersion": "1.0",
+		"SignatureNonce":   util.GenerateRID(),
+		"Timestamp":        time.Now().UTC().Format(aliTimeFormat),
+	}
+	for key, val := range params {
+		query[key] = val
+	}
+	query["Signature"] = c.sign(http.MethodGet, query)
+
+	values := url.Values{}
+	for key, val := range query {
+		values.Set(key, val)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint+"/?"+values.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	_, err = doVendorRequest(c.httpCli, req, result)
+	return err
+}
+
+// sign 计算请求签名
+func (c *aliClient) sign(method string, query map[string]string) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, aliPercentEncode(key)+"="+aliPercentEncode(query[key]))
+	}
+	canonicalized := strings.Join(pairs, "&")
+	stringToSign := method + "&" + aliPercentEncode("/") + "&" + aliPercentEncode(canonicalized)
+
+	mac := hmac.New(sha1.New, []byte(c.secretKey+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// aliPercentEncode 按阿里云签名要求对字符串进行编码
+func aliPercentEncode(s string) string {
+	encoded := url.QueryEscape(s)
+	encoded = strings.ReplaceAll(encoded, "+", "%20")
+	encoded = strings.ReplaceAll(encoded, "*", "%2A")
+	encoded = strings.ReplaceAll(encoded, "%7E", "~")
+	return encoded
+}
@@ -0,0 +1,239 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudvendor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+)
+
+// newAliTestClient 创建使用fake http server的阿里云客户端
+func newAliTestClient(t *testing.T, handler http.HandlerFunc) (*aliClient, func()) {
+	server := httptest.NewServer(handler)
+	conf := metadata.CloudAccountConf{
+		VendorName: metadata.AliCloud,
+		SecretID:   "test-id",
+		SecretKey:  "test-key",
+	}
+	client, err := GetVendorClient(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := client.(*aliClient)
+	cli.ecsEndpoint = server.URL
+	cli.vpcEndpoint = server.URL
+	cli.slbEndpoint = server.URL
+	return cli, server.Close
+}
+
+func TestAliGetRegions(t *testing.T) {
+	cli, closeFn := newAliTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("Action") != "DescribeRegions" || query.Get("AccessKeyId") != "test-id" ||
+			query.Get("Signature") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"Regions":{"Region":[{"RegionId":"cn-hangzhou","LocalName":"华东1（杭州）",` +
+			`"Status":"available"}]}}`))
+	})
+	defer closeFn()
+
+	regionSet, err := cli.GetRegions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regionSet) != 1 || regionSet[0].RegionId != "cn-hangzhou" || regionSet[0].RegionName != "华东1（杭州）" {
+		t.Fatalf("unexpected regions: %#v", regionSet)
+	}
+}
+
+func TestAliGetVpcs(t *testing.T) {
+	cli, closeFn := newAliTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("Action") != "DescribeVpcs" || query.Get("RegionId") != "cn-hangzhou" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if query.Get("VpcId") != "vpc-1" {
+			t.Errorf("vpc id filter is not set, query: %v", query)
+		}
+		w.Write([]byte(`{"TotalCount":1,"Vpcs":{"Vpc":[{"VpcId":"vpc-1","VpcName":""}]}}`))
+	})
+	defer closeFn()
+
+	opt := &ccom.VpcOpt{
+		BaseOpt: ccom.BaseOpt{
+			Filters: []*ccom.Filter{{Name: ccom.StringPtr("vpc-id"), Values: ccom.StringPtrs([]string{"vpc-1"})}},
+			Limit:   ccom.MaxLimit,
+		},
+	}
+	vpcsInfo, err := cli.GetVpcs("cn-hangzhou", opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vpcsInfo.Count != 1 || vpcsInfo.VpcSet[0].VpcId != "vpc-1" || vpcsInfo.VpcSet[0].VpcName != "vpc-1" {
+		t.Fatalf("unexpected vpcs: %#v", vpcsInfo)
+	}
+}
+
+func TestAliGetInstances(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"TotalCount":3,"Instances":{"Instance":[` +
+			`{"InstanceId":"i-1","Status":"Running","PublicIpAddress":{"IpAddress":["1.1.1.1"]},` +
+			`"VpcAttributes":{"VpcId":"vpc-1","PrivateIpAddress":{"IpAddress":["10.0.0.1"]}}},` +
+			`{"InstanceId":"i-2","Status":"Stopped","EipAddress":{"IpAddress":"2.2.2.2"},` +
+			`"VpcAttributes":{"VpcId":"vpc-1","PrivateIpAddress":{"IpAddress":["10.0.0.2"]}}}]}}`,
+		"2": `{"TotalCount":3,"Instances":{"Instance":[` +
+			`{"InstanceId":"i-3","Status":"Starting","InnerIpAddress":{"IpAddress":["10.0.0.3"]}}]}}`,
+	}
+	cli, closeFn := newAliTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("Action") != "DescribeInstances" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(pages[query.Get("PageNumber")]))
+	})
+	defer closeFn()
+
+	instancesInfo, err := cli.GetInstances("cn-hangzhou", &ccom.InstanceOpt{BaseOpt: ccom.BaseOpt{Limit: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instancesInfo.Count != 3 || len(instancesInfo.InstanceSet) != 2 {
+		t.Fatalf("unexpected instances: %#v", instancesInfo)
+	}
+
+	instancesInfo, err = cli.GetInstances("cn-hangzhou", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expects := []metadata.Instance{
+		{InstanceId: "i-1", PrivateIp: "10.0.0.1", PublicIp: "1.1.1.1", VpcId: "vpc-1",
+			InstanceState: common.BKCloudHostStatusRunning},
+		{InstanceId: "i-2", PrivateIp: "10.0.0.2", PublicIp: "2.2.2.2", VpcId: "vpc-1",
+			InstanceState: common.BKCloudHostStatusStopped},
+		{InstanceId: "i-3", PrivateIp: "10.0.0.3", InstanceState: common.BKCloudHostStatusStarting},
+	}
+	if len(instancesInfo.InstanceSet) != len(expects) {
+		t.Fatalf("unexpected instances: %#v", instancesInfo)
+	}
+	for i, expect := range expects {
+		if *instancesInfo.InstanceSet[i] != expect {
+			t.Errorf("instance %d, expect: %#v, actual: %#v", i, expect, *instancesInfo.InstanceSet[i])
+		}
+	}
+
+	count, err := cli.GetInstancesTotalCnt("cn-hangzhou", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Fatalf("unexpected instances count: %d", count)
+	}
+}
+
+func TestAliGetCloudResources(t *testing.T) {
+	responses := map[string]string{
+		"DescribeDisks": `{"TotalCount":1,"Disks":{"Disk":[{"DiskId":"d-1","Status":"In_use","Category":"cloud_ssd",` +
+			`"Type":"system","Size":40,"InstanceId":"i-1"}]}}`,
+		"DescribeInstances": `{"TotalCount":2,"Instances":{"Instance":[` +
+			`{"InstanceId":"i-1","SecurityGroupIds":{"SecurityGroupId":["sg-1"]}},` +
+			`{"InstanceId":"i-2","SecurityGroupIds":{"SecurityGroupId":["sg-1","sg-2"]}}]}}`,
+		"DescribeSecurityGroups": `{"TotalCount":2,"SecurityGroups":{"SecurityGroup":[` +
+			`{"SecurityGroupId":"sg-1","SecurityGroupName":"web","VpcId":"vpc-1"},` +
+			`{"SecurityGroupId":"sg-2","VpcId":"vpc-2"}]}}`,
+		"DescribeLoadBalancers": `{"TotalCount":1,"LoadBalancers":{"LoadBalancer":[{"LoadBalancerId":"lb-1",` +
+			`"LoadBalancerName":"api","LoadBalancerStatus":"active","Address":"10.0.0.100","VpcId":"vpc-1"}]}}`,
+		"DescribeLoadBalancerAttribute": `{"BackendServers":{"BackendServer":[{"ServerId":"i-1","Type":"ecs"},` +
+			`{"ServerId":"eni-1","Type":"eni"}]}}`,
+	}
+	cli, closeFn := newAliTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		resp, exists := responses[r.URL.Query().Get("Action")]
+		if !exists {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(resp))
+	})
+	defer closeFn()
+
+	disks, err := cli.GetCloudResources("cn-hangzhou", metadata.CloudDisk, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disks.Count != 1 || disks.ResourceSet[0].Name != "d-1" || disks.ResourceSet[0].InstanceIDs[0] != "i-1" ||
+		disks.ResourceSet[0].Attributes["type"] != "cloud_ssd" {
+		t.Fatalf("unexpected disks: %#v", disks.ResourceSet)
+	}
+
+	groups, err := cli.GetCloudResources("cn-hangzhou", metadata.CloudSecurityGroup, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups.Count != 2 || len(groups.ResourceSet[0].InstanceIDs) != 2 || groups.ResourceSet[1].Name != "sg-2" ||
+		groups.ResourceSet[1].InstanceIDs[0] != "i-2" {
+		t.Fatalf("unexpected security groups: %#v", groups.ResourceSet)
+	}
+
+	lbs, err := cli.GetCloudResources("cn-hangzhou", metadata.CloudLoadBalancer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lbs.Count != 1 || lbs.ResourceSet[0].VpcID != "vpc-1" || len(lbs.ResourceSet[0].InstanceIDs) != 1 ||
+		lbs.ResourceSet[0].InstanceIDs[0] != "i-1" {
+		t.Fatalf("unexpected load balancers: %#v", lbs.ResourceSet)
+	}
+}
+
+func TestAliAuthFailure(t *testing.T) {
+	cli, closeFn := newAliTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"Code":"InvalidAccessKeyId.NotFound","Message":"Specified access key is not found."}`))
+	})
+	defer closeFn()
+
+	_, err := cli.GetRegions()
+	if err == nil || !strings.Contains(strings.ToLower(err.Error()), "authfailure") {
+		t.Fatalf("expect auth failure, got: %v", err)
+	}
+}
+
+func TestAliSign(t *testing.T) {
+	// 阿里云签名文档中的示例
+	cli := &aliClient{secretKey: "testsecret"}
+	query := map[string]string{
+		"Format":           "XML",
+		"AccessKeyId":      "testid",
+		"Action":           "DescribeRegions",
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureNonce":   "3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf",
+		"SignatureVersion": "1.0",
+		"Timestamp":        "2016-02-23T12:46:24Z",
+		"Version":          "2014-05-26",
+	}
+	if sign := cli.sign(http.MethodGet, query); sign != "OLeaidS1JvxuMvnyHOwuJ+uX5qY=" {
+		t.Fatalf("unexpected signature: %s", sign)
+	}
+}
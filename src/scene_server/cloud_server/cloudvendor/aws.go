@@ -19,12 +19,14 @@ package cloudvendor
 import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 	ccom "configcenter/src/scene_server/cloud_server/common"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elbv2"
 )
 
 func init() {
@@ -65,11 +67,11 @@ var regionIdNameMap = map[string]string{
 }
 
 // NewVendorClient 创建云厂商客户端
-func (c *awsClient) NewVendorClient(secretID, secretKey string) VendorClient {
+func (c *awsClient) NewVendorClient(conf metadata.CloudAccountConf) VendorClient {
 	return &awsClient{
 		vendorName: metadata.AWS,
-		secretID:   secretID,
-		secretKey:  secretKey,
+		secretID:   conf.SecretID,
+		secretKey:  conf.SecretKey,
 	}
 }
 
@@ -254,6 +256,253 @@ func (c *awsClient) GetInstancesTotalCnt(region string, opt *ccom.InstanceOpt) (
 	return int64(len(instances)), nil
 }
 
+// GetCloudResources 获取主机以外的云资源列表
+func (c *awsClient) GetCloudResources(region string, kind metadata.CloudResourceKind, opt *ccom.ResourceOpt) (
+	*metadata.CloudResourcesInfo, error) {
+
+	if opt == nil {
+		opt = ccom.GetDefaultResourceOpt()
+	}
+
+	var resources []*metadata.CloudResource
+	var err error
+	switch kind {
+	case metadata.CloudDisk:
+		resources, err = c.getVolumes(region, opt)
+	case metadata.CloudSecurityGroup:
+		resources, err = c.getSecurityGroups(region, opt)
+	case metadata.CloudLoadBalancer:
+		resources, err = c.getLoadBalancers(region, opt)
+	default:
+		return nil, ccom.ErrResourceKindNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &metadata.CloudResourcesInfo{Count: int64(len(resources)), ResourceSet: resources}, nil
+}
+
+// getVolumes 获取云硬盘列表，云硬盘不属于vpc，通过挂载的实例关联到vpc
+// API文档：https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeVolumes.html
+func (c *awsClient) getVolumes(region string, opt *ccom.ResourceOpt) ([]*metadata.CloudResource, error) {
+	sess, err := c.newSession(region)
+	if err != nil {
+		return nil, err
+	}
+	ec2Svc := ec2.New(sess)
+
+	resources := make([]*metadata.CloudResource, 0)
+	input := &ec2.DescribeVolumesInput{}
+	c.setMaxResults(&input.MaxResults, opt.Limit)
+	loopCnt := 0
+	for {
+		output, err := ec2Svc.DescribeVolumes(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, volume := range output.Volumes {
+			instIDs := make([]string, 0)
+			for _, attachment := range volume.Attachments {
+				instIDs = append(instIDs, aws.StringValue(attachment.InstanceId))
+			}
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  aws.StringValue(volume.VolumeId),
+				Name:        c.getTagName(volume.Tags, aws.StringValue(volume.VolumeId)),
+				Region:      region,
+				Status:      aws.StringValue(volume.State),
+				InstanceIDs: instIDs,
+				Attributes: map[string]interface{}{
+					"size": aws.Int64Value(volume.Size),
+					"type": aws.StringValue(volume.VolumeType),
+					"zone": aws.StringValue(volume.AvailabilityZone),
+				},
+			})
+		}
+		if output.NextToken == nil || *output.NextToken == "" || opt.Limit <= int64(len(resources)) {
+			break
+		}
+		input.NextToken = output.NextToken
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeVolumes loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// getSecurityGroups 获取安全组列表，安全组关联的实例从实例列表中获取
+// API文档：https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeSecurityGroups.html
+func (c *awsClient) getSecurityGroups(region string, opt *ccom.ResourceOpt) ([]*metadata.CloudResource, error) {
+	sess, err := c.newSession(region)
+	if err != nil {
+		return nil, err
+	}
+	ec2Svc := ec2.New(sess)
+
+	groupInstIDs := make(map[string][]string)
+	instInput := &ec2.DescribeInstancesInput{}
+	c.setFilters(&instInput.Filters, opt.Filters)
+	loopCnt := 0
+	for {
+		output, err := ec2Svc.DescribeInstances(instInput)
+		if err != nil {
+			return nil, err
+		}
+		for _, reservation := range output.Reservations {
+			for _, inst := range reservation.Instances {
+				for _, group := range inst.SecurityGroups {
+					groupID := aws.StringValue(group.GroupId)
+					groupInstIDs[groupID] = append(groupInstIDs[groupID], aws.StringValue(inst.InstanceId))
+				}
+			}
+		}
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		instInput.NextToken = output.NextToken
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeInstances loopCnt:%d, bigger than MaxLoopCnt", loopCnt)
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	input := &ec2.DescribeSecurityGroupsInput{}
+	c.setFilters(&input.Filters, opt.Filters)
+	c.setMaxResults(&input.MaxResults, opt.Limit)
+	loopCnt = 0
+	for {
+		output, err := ec2Svc.DescribeSecurityGroups(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range output.SecurityGroups {
+			groupID := aws.StringValue(group.GroupId)
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  groupID,
+				Name:        aws.StringValue(group.GroupName),
+				Region:      region,
+				VpcID:       aws.StringValue(group.VpcId),
+				InstanceIDs: groupInstIDs[groupID],
+				Attributes: map[string]interface{}{
+					"description": aws.StringValue(group.Description),
+				},
+			})
+		}
+		if output.NextToken == nil || *output.NextToken == "" || opt.Limit <= int64(len(resources)) {
+			break
+		}
+		input.NextToken = output.NextToken
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeSecurityGroups loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// getLoadBalancers 获取负载均衡列表，负载均衡关联的实例为其目标组中类型为instance的目标
+// API文档：https://docs.aws.amazon.com/elasticloadbalancing/latest/APIReference/API_DescribeLoadBalancers.html
+func (c *awsClient) getLoadBalancers(region string, opt *ccom.ResourceOpt) ([]*metadata.CloudResource, error) {
+	sess, err := c.newSession(region)
+	if err != nil {
+		return nil, err
+	}
+	elbSvc := elbv2.New(sess)
+
+	vpcIDs := getStringFilterValues(opt.Filters, "vpc-id")
+	resources := make([]*metadata.CloudResource, 0)
+	input := &elbv2.DescribeLoadBalancersInput{}
+	loopCnt := 0
+	for {
+		output, err := elbSvc.DescribeLoadBalancers(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, lb := range output.LoadBalancers {
+			vpcID := aws.StringValue(lb.VpcId)
+			if len(vpcIDs) > 0 && !util.InStrArr(vpcIDs, vpcID) {
+				continue
+			}
+			instIDs, err := c.getLoadBalancerInstIDs(elbSvc, lb.LoadBalancerArn)
+			if err != nil {
+				return nil, err
+			}
+			status := ""
+			if lb.State != nil {
+				status = aws.StringValue(lb.State.Code)
+			}
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  aws.StringValue(lb.LoadBalancerArn),
+				Name:        aws.StringValue(lb.LoadBalancerName),
+				Region:      region,
+				VpcID:       vpcID,
+				Status:      status,
+				InstanceIDs: instIDs,
+				Attributes: map[string]interface{}{
+					"address": aws.StringValue(lb.DNSName),
+					"type":    aws.StringValue(lb.Type),
+					"scheme":  aws.StringValue(lb.Scheme),
+				},
+			})
+		}
+		if output.NextMarker == nil || *output.NextMarker == "" || opt.Limit <= int64(len(resources)) {
+			break
+		}
+		input.Marker = output.NextMarker
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeLoadBalancers loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// getLoadBalancerInstIDs 获取负载均衡目标组中的实例id
+func (c *awsClient) getLoadBalancerInstIDs(elbSvc *elbv2.ELBV2, lbArn *string) ([]string, error) {
+	groups, err := elbSvc.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{LoadBalancerArn: lbArn})
+	if err != nil {
+		return nil, err
+	}
+
+	instIDs := make([]string, 0)
+	for _, group := range groups.TargetGroups {
+		if aws.StringValue(group.TargetType) != elbv2.TargetTypeEnumInstance {
+			continue
+		}
+		health, err := elbSvc.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{
+			TargetGroupArn: group.TargetGroupArn,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, desc := range health.TargetHealthDescriptions {
+			if desc.Target != nil {
+				instIDs = append(instIDs, aws.StringValue(desc.Target.Id))
+			}
+		}
+	}
+	return instIDs, nil
+}
+
+// getTagName 获取Name标签的值作为名称，没有Name标签则使用默认值
+func (c *awsClient) getTagName(tags []*ec2.Tag, defaultName string) string {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == "Name" {
+			return aws.StringValue(tag.Value)
+		}
+	}
+	return defaultName
+}
+
 // newSession 创建会话
 func (c *awsClient) newSession(region string) (*session.Session, error) {
 	sess, err := session.NewSession(&aws.Config{
@@ -0,0 +1,84 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudvendor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"configcenter/src/common/json"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+)
+
+// vendorHttpTimeout 直接调用云厂商http接口的超时时间
+const vendorHttpTimeout = 30 * time.Second
+
+// newVendorHttpClient 创建调用云厂商http接口的客户端，用于没有引入官方sdk的云厂商
+func newVendorHttpClient() *http.Client {
+	return &http.Client{Timeout: vendorHttpTimeout}
+}
+
+// doVendorRequest 发送请求并将返回结果解析到result中
+// 鉴权失败时返回的错误信息以AuthFailure开头，与腾讯云sdk保持一致，便于上层统一识别账户密钥错误
+func doVendorRequest(client *http.Client, req *http.Request, result interface{}) (http.Header, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("AuthFailure: %s %s, status: %d, response: %s", req.Method, req.URL.Path,
+			resp.StatusCode, body)
+	case resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices:
+		return nil, fmt.Errorf("%s %s failed, status: %d, response: %s", req.Method, req.URL.Path, resp.StatusCode,
+			body)
+	}
+
+	if result == nil {
+		return resp.Header, nil
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("decode response of %s %s failed, err: %v", req.Method, req.URL.Path, err)
+	}
+	return resp.Header, nil
+}
+
+// getStringFilterValues 获取指定名称的过滤条件的值
+func getStringFilterValues(filters []*ccom.Filter, name string) []string {
+	values := make([]string, 0)
+	for _, filter := range filters {
+		if filter == nil || filter.Name == nil || *filter.Name != name {
+			continue
+		}
+		for _, val := range filter.Values {
+			if val != nil {
+				values = append(values, *val)
+			}
+		}
+	}
+	return values
+}
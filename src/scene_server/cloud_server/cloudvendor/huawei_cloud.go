@@ -0,0 +1,662 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudvendor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+)
+
+func init() {
+	Register(metadata.HuaweiCloud, &hwClient{vendorName: metadata.HuaweiCloud})
+}
+
+type hwClient struct {
+	vendorName string
+	secretID   string
+	secretKey  string
+	// iamEndpoint 统一身份认证服务的接入地址
+	iamEndpoint string
+	// serviceEndpoint 获取云服务在地域下的接入地址
+	serviceEndpoint func(service, region string) string
+	httpCli         *http.Client
+}
+
+const (
+	hwMinPageSize int64 = 1
+	hwMaxPageSize int64 = 100
+
+	hwIamEndpoint  = "https://iam.myhuaweicloud.com"
+	hwSignAlgo     = "SDK-HMAC-SHA256"
+	hwSdkDateKey   = "X-Sdk-Date"
+	hwSdkDateFmt   = "20060102T150405Z"
+	hwFixedIPType  = "fixed"
+	hwFloatIPType  = "floating"
+	hwIPTypeKey    = "OS-EXT-IPS:type"
+	hwVpcIDMetaKey = "vpc_id"
+)
+
+// NewVendorClient 创建云厂商客户端
+func (c *hwClient) NewVendorClient(conf metadata.CloudAccountConf) VendorClient {
+	return &hwClient{
+		vendorName:  metadata.HuaweiCloud,
+		secretID:    conf.SecretID,
+		secretKey:   conf.SecretKey,
+		iamEndpoint: hwIamEndpoint,
+		serviceEndpoint: func(service, region string) string {
+			return fmt.Sprintf("https://%s.%s.myhuaweicloud.com", service, region)
+		},
+		httpCli: newVendorHttpClient(),
+	}
+}
+
+// hwRegionsResp 地域列表返回结果
+type hwRegionsResp struct {
+	Regions []struct {
+		ID      string            `json:"id"`
+		Type    string            `json:"type"`
+		Locales map[string]string `json:"locales"`
+	} `json:"regions"`
+}
+
+// hwProjectsResp 项目列表返回结果
+type hwProjectsResp struct {
+	Projects []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"projects"`
+}
+
+// hwVpcsResp vpc列表返回结果
+type hwVpcsResp struct {
+	Vpcs []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"vpcs"`
+}
+
+// hwServersResp 云服务器列表返回结果
+type hwServersResp struct {
+	Count   int64      `json:"count"`
+	Servers []hwServer `json:"servers"`
+}
+
+// hwServer 云服务器信息
+type hwServer struct {
+	ID             string                         `json:"id"`
+	Status         string                         `json:"status"`
+	Metadata       map[string]string              `json:"metadata"`
+	Addresses      map[string][]map[string]string `json:"addresses"`
+	SecurityGroups []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"security_groups"`
+}
+
+// hwVolumesResp 云硬盘列表返回结果
+type hwVolumesResp struct {
+	Count   int64 `json:"count"`
+	Volumes []struct {
+		ID               string `json:"id"`
+		Name             string `json:"name"`
+		Status           string `json:"status"`
+		Size             int64  `json:"size"`
+		VolumeType       string `json:"volume_type"`
+		AvailabilityZone string `json:"availability_zone"`
+		Attachments      []struct {
+			ServerID string `json:"server_id"`
+		} `json:"attachments"`
+	} `json:"volumes"`
+}
+
+// hwSecurityGroupsResp 安全组列表返回结果
+type hwSecurityGroupsResp struct {
+	SecurityGroups []struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		VpcID       string `json:"vpc_id"`
+	} `json:"security_groups"`
+}
+
+// hwLoadBalancersResp 负载均衡列表返回结果
+type hwLoadBalancersResp struct {
+	LoadBalancers []struct {
+		ID                 string `json:"id"`
+		Name               string `json:"name"`
+		VipAddress         string `json:"vip_address"`
+		ProvisioningStatus string `json:"provisioning_status"`
+		OperatingStatus    string `json:"operating_status"`
+		Pools              []struct {
+			ID string `json:"id"`
+		} `json:"pools"`
+	} `json:"loadbalancers"`
+}
+
+// hwMembersResp 负载均衡后端云服务器列表返回结果
+type hwMembersResp struct {
+	Members []struct {
+		Address string `json:"address"`
+	} `json:"members"`
+}
+
+// GetRegions 获取地域列表
+// API文档：https://support.huaweicloud.com/api-iam/iam_05_0001.html
+func (c *hwClient) GetRegions() ([]*metadata.Region, error) {
+	resp := new(hwRegionsResp)
+	if err := c.call(http.MethodGet, c.iamEndpoint, "/v3/regions", nil, resp); err != nil {
+		return nil, err
+	}
+
+	regionSet := make([]*metadata.Region, 0)
+	for _, region := range resp.Regions {
+		regionName := region.Locales["zh-cn"]
+		if regionName == "" {
+			regionName = region.ID
+		}
+		regionSet = append(regionSet, &metadata.Region{
+			RegionId:   region.ID,
+			RegionName: regionName,
+		})
+	}
+	return regionSet, nil
+}
+
+// GetVpcs 获取vpc列表
+// API文档：https://support.huaweicloud.com/api-vpc/vpc_api01_0003.html
+func (c *hwClient) GetVpcs(region string, opt *ccom.VpcOpt) (*metadata.VpcsInfo, error) {
+	projectID, err := c.getProjectID(region)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt == nil {
+		opt = ccom.GetDefaultVpcOpt()
+	}
+	vpcIDs := getStringFilterValues(opt.Filters, "vpc-id")
+
+	vpcsInfo := new(metadata.VpcsInfo)
+	loopCnt := 0
+	marker := ""
+	endpoint := c.serviceEndpoint("vpc", region)
+	path := fmt.Sprintf("/v1/%s/vpcs", projectID)
+	// 华为云vpc接口只支持按单个id过滤，因此获取全部数据后再过滤
+	for {
+		query := url.Values{}
+		query.Set("limit", strconv.FormatInt(hwMaxPageSize, 10))
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp := new(hwVpcsResp)
+		if err := c.call(http.MethodGet, endpoint, path, query, resp); err != nil {
+			return nil, err
+		}
+		for _, vpc := range resp.Vpcs {
+			if len(vpcIDs) > 0 && !util.InStrArr(vpcIDs, vpc.ID) {
+				continue
+			}
+			vpcsInfo.VpcSet = append(vpcsInfo.VpcSet, &metadata.Vpc{
+				VpcId:   vpc.ID,
+				VpcName: vpc.Name,
+			})
+		}
+		if int64(len(resp.Vpcs)) < hwMaxPageSize {
+			break
+		}
+		marker = resp.Vpcs[len(resp.Vpcs)-1].ID
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("ListVpcs loopCnt:%d, bigger than MaxLoopCnt, len(vpcsInfo.VpcSet):%d", loopCnt,
+				len(vpcsInfo.VpcSet))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	vpcsInfo.Count = int64(len(vpcsInfo.VpcSet))
+	if opt.Limit > 0 && opt.Limit < vpcsInfo.Count {
+		vpcsInfo.VpcSet = vpcsInfo.VpcSet[:opt.Limit]
+	}
+
+	return vpcsInfo, nil
+}
+
+// GetInstances 获取实例列表
+// API文档：https://support.huaweicloud.com/api-ecs/ecs_02_0103.html
+func (c *hwClient) GetInstances(region string, opt *ccom.InstanceOpt) (*metadata.InstancesInfo, error) {
+	projectID, err := c.getProjectID(region)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt == nil {
+		opt = ccom.GetDefaultInstanceOpt()
+	}
+	vpcIDs := getStringFilterValues(opt.Filters, "vpc-id")
+
+	// 云服务器列表接口不支持按vpc过滤，因此获取全部数据后再过滤
+	servers, err := c.listServers(region, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	instancesInfo := new(metadata.InstancesInfo)
+	for _, server := range servers {
+		vpcID := server.Metadata[hwVpcIDMetaKey]
+		if len(vpcIDs) > 0 && !util.InStrArr(vpcIDs, vpcID) {
+			continue
+		}
+		privateIP, publicIP := c.getServerIPs(server.Addresses, vpcID)
+		instancesInfo.InstanceSet = append(instancesInfo.InstanceSet, &metadata.Instance{
+			InstanceId:    server.ID,
+			PrivateIp:     privateIP,
+			PublicIp:      publicIP,
+			InstanceState: ccom.CovertInstState(server.Status),
+			VpcId:         vpcID,
+		})
+	}
+	instancesInfo.Count = int64(len(instancesInfo.InstanceSet))
+	if opt.Limit > 0 && opt.Limit < instancesInfo.Count {
+		instancesInfo.InstanceSet = instancesInfo.InstanceSet[:opt.Limit]
+	}
+
+	return instancesInfo, nil
+}
+
+// GetInstancesTotalCnt 获取实例总个数
+func (c *hwClient) GetInstancesTotalCnt(region string, opt *ccom.InstanceOpt) (int64, error) {
+	instsInfo, err := c.GetInstances(region, opt)
+	if err != nil {
+		return 0, err
+	}
+	return instsInfo.Count, nil
+}
+
+// GetCloudResources 获取主机以外的云资源列表
+func (c *hwClient) GetCloudResources(region string, kind metadata.CloudResourceKind, opt *ccom.ResourceOpt) (
+	*metadata.CloudResourcesInfo, error) {
+
+	projectID, err := c.getProjectID(region)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt == nil {
+		opt = ccom.GetDefaultResourceOpt()
+	}
+
+	var resources []*metadata.CloudResource
+	switch kind {
+	case metadata.CloudDisk:
+		resources, err = c.getVolumes(region, projectID)
+	case metadata.CloudSecurityGroup:
+		resources, err = c.getSecurityGroups(region, projectID, opt)
+	case metadata.CloudLoadBalancer:
+		resources, err = c.getLoadBalancers(region, projectID)
+	default:
+		return nil, ccom.ErrResourceKindNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resourcesInfo := &metadata.CloudResourcesInfo{Count: int64(len(resources)), ResourceSet: resources}
+	if opt.Limit > 0 && opt.Limit < resourcesInfo.Count {
+		resourcesInfo.ResourceSet = resourcesInfo.ResourceSet[:opt.Limit]
+	}
+	return resourcesInfo, nil
+}
+
+// getVolumes 获取云硬盘列表，云硬盘不属于vpc，通过挂载的云服务器关联到vpc
+// API文档：https://support.huaweicloud.com/api-evs/evs_04_2006.html
+func (c *hwClient) getVolumes(region, projectID string) ([]*metadata.CloudResource, error) {
+	resources := make([]*metadata.CloudResource, 0)
+	loopCnt := 0
+	endpoint := c.serviceEndpoint("evs", region)
+	path := fmt.Sprintf("/v2/%s/cloudvolumes/detail", projectID)
+	for {
+		query := url.Values{}
+		query.Set("limit", strconv.FormatInt(hwMaxPageSize, 10))
+		query.Set("offset", strconv.Itoa(len(resources)))
+		resp := new(hwVolumesResp)
+		if err := c.call(http.MethodGet, endpoint, path, query, resp); err != nil {
+			return nil, err
+		}
+
+		for _, volume := range resp.Volumes {
+			name := volume.Name
+			if name == "" {
+				name = volume.ID
+			}
+			instIDs := make([]string, 0)
+			for _, attachment := range volume.Attachments {
+				instIDs = append(instIDs, attachment.ServerID)
+			}
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  volume.ID,
+				Name:        name,
+				Region:      region,
+				Status:      volume.Status,
+				InstanceIDs: instIDs,
+				Attributes: map[string]interface{}{
+					"size": volume.Size,
+					"type": volume.VolumeType,
+					"zone": volume.AvailabilityZone,
+				},
+			})
+		}
+
+		if int64(len(resp.Volumes)) < hwMaxPageSize || int64(len(resources)) >= resp.Count {
+			break
+		}
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("ListVolumes loopCnt:%d, bigger than MaxLoopCnt, count:%d", loopCnt, resp.Count)
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// getSecurityGroups 获取安全组列表，安全组关联的云服务器从云服务器列表中获取
+// API文档：https://support.huaweicloud.com/api-vpc/vpc_sg01_0003.html
+func (c *hwClient) getSecurityGroups(region, projectID string, opt *ccom.ResourceOpt) ([]*metadata.CloudResource,
+	error) {
+
+	servers, err := c.listServers(region, projectID)
+	if err != nil {
+		return nil, err
+	}
+	groupInstIDs := make(map[string][]string)
+	for _, server := range servers {
+		for _, group := range server.SecurityGroups {
+			groupInstIDs[group.ID] = append(groupInstIDs[group.ID], server.ID)
+		}
+	}
+
+	vpcIDs := getStringFilterValues(opt.Filters, "vpc-id")
+	resources := make([]*metadata.CloudResource, 0)
+	loopCnt := 0
+	marker := ""
+	endpoint := c.serviceEndpoint("vpc", region)
+	path := fmt.Sprintf("/v1/%s/security-groups", projectID)
+	for {
+		query := url.Values{}
+		query.Set("limit", strconv.FormatInt(hwMaxPageSize, 10))
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp := new(hwSecurityGroupsResp)
+		if err := c.call(http.MethodGet, endpoint, path, query, resp); err != nil {
+			return nil, err
+		}
+
+		for _, group := range resp.SecurityGroups {
+			if len(vpcIDs) > 0 && group.VpcID != "" && !util.InStrArr(vpcIDs, group.VpcID) {
+				continue
+			}
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  group.ID,
+				Name:        group.Name,
+				Region:      region,
+				VpcID:       group.VpcID,
+				InstanceIDs: groupInstIDs[group.ID],
+				Attributes:  map[string]interface{}{"description": group.Description},
+			})
+		}
+
+		if int64(len(resp.SecurityGroups)) < hwMaxPageSize {
+			break
+		}
+		marker = resp.SecurityGroups[len(resp.SecurityGroups)-1].ID
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("ListSecurityGroups loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// getLoadBalancers 获取负载均衡列表，后端服务器只返回了ip地址，需要通过云服务器的内网ip找到对应的云服务器
+// API文档：https://support.huaweicloud.com/api-elb/elb_zq_fz_0002.html
+func (c *hwClient) getLoadBalancers(region, projectID string) ([]*metadata.CloudResource, error) {
+	servers, err := c.listServers(region, projectID)
+	if err != nil {
+		return nil, err
+	}
+	ipServers := make(map[string]hwServer)
+	for _, server := range servers {
+		for _, addrs := range server.Addresses {
+			for _, addr := range addrs {
+				if addr[hwIPTypeKey] == hwFixedIPType {
+					ipServers[addr["addr"]] = server
+				}
+			}
+		}
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	loopCnt := 0
+	marker := ""
+	endpoint := c.serviceEndpoint("elb", region)
+	path := fmt.Sprintf("/v2/%s/elb/loadbalancers", projectID)
+	for {
+		query := url.Values{}
+		query.Set("limit", strconv.FormatInt(hwMaxPageSize, 10))
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp := new(hwLoadBalancersResp)
+		if err := c.call(http.MethodGet, endpoint, path, query, resp); err != nil {
+			return nil, err
+		}
+
+		for _, lb := range resp.LoadBalancers {
+			instIDs := make([]string, 0)
+			vpcID := ""
+			for _, pool := range lb.Pools {
+				membersResp := new(hwMembersResp)
+				membersPath := fmt.Sprintf("/v2/%s/elb/pools/%s/members", projectID, pool.ID)
+				if err := c.call(http.MethodGet, endpoint, membersPath, nil, membersResp); err != nil {
+					return nil, err
+				}
+				for _, member := range membersResp.Members {
+					server, exists := ipServers[member.Address]
+					if !exists || util.InStrArr(instIDs, server.ID) {
+						continue
+					}
+					instIDs = append(instIDs, server.ID)
+					// 负载均衡没有直接返回vpc，使用后端云服务器所属的vpc
+					if vpcID == "" {
+						vpcID = server.Metadata[hwVpcIDMetaKey]
+					}
+				}
+			}
+
+			name := lb.Name
+			if name == "" {
+				name = lb.ID
+			}
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  lb.ID,
+				Name:        name,
+				Region:      region,
+				VpcID:       vpcID,
+				Status:      lb.OperatingStatus,
+				InstanceIDs: instIDs,
+				Attributes:  map[string]interface{}{"address": lb.VipAddress},
+			})
+		}
+
+		if int64(len(resp.LoadBalancers)) < hwMaxPageSize {
+			break
+		}
+		marker = resp.LoadBalancers[len(resp.LoadBalancers)-1].ID
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("ListLoadBalancers loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// listServers 获取地域下全部云服务器，offset为页码，从1开始
+func (c *hwClient) listServers(region, projectID string) ([]hwServer, error) {
+	servers := make([]hwServer, 0)
+	loopCnt := 0
+	endpoint := c.serviceEndpoint("ecs", region)
+	path := fmt.Sprintf("/v1/%s/cloudservers/detail", projectID)
+	for pageNum := 1; ; pageNum++ {
+		query := url.Values{}
+		query.Set("limit", strconv.FormatInt(hwMaxPageSize, 10))
+		query.Set("offset", strconv.Itoa(pageNum))
+		resp := new(hwServersResp)
+		if err := c.call(http.MethodGet, endpoint, path, query, resp); err != nil {
+			return nil, err
+		}
+		servers = append(servers, resp.Servers...)
+
+		if int64(len(resp.Servers)) < hwMaxPageSize || int64(pageNum)*hwMaxPageSize >= resp.Count {
+			break
+		}
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("ListServersDetails loopCnt:%d, bigger than MaxLoopCnt, count:%d", loopCnt, resp.Count)
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return servers, nil
+}
+
+// getProjectID 获取地域对应的项目id，华为云的资源接口都需要带上项目id
+// API文档：https://support.huaweicloud.com/api-iam/iam_06_0001.html
+func (c *hwClient) getProjectID(region string) (string, error) {
+	query := url.Values{}
+	query.Set("name", region)
+	resp := new(hwProjectsResp)
+	if err := c.call(http.MethodGet, c.iamEndpoint, "/v3/projects", query, resp); err != nil {
+		return "", err
+	}
+	for _, project := range resp.Projects {
+		if project.Name == region {
+			return project.ID, nil
+		}
+	}
+	return "", fmt.Errorf("project of region %s is not found", region)
+}
+
+// getServerIPs 获取云服务器在所属vpc下的内网ip和公网ip
+func (c *hwClient) getServerIPs(addresses map[string][]map[string]string, vpcID string) (string, string) {
+	privateIP, publicIP := "", ""
+	// 优先取所属vpc下的地址
+	addrs, exists := addresses[vpcID]
+	if !exists {
+		for _, netAddrs := range addresses {
+			addrs = append(addrs, netAddrs...)
+		}
+	}
+	for _, addr := range addrs {
+		switch addr[hwIPTypeKey] {
+		case hwFixedIPType:
+			if privateIP == "" {
+				privateIP = addr["addr"]
+			}
+		case hwFloatIPType:
+			if publicIP == "" {
+				publicIP = addr["addr"]
+			}
+		}
+	}
+	return privateIP, publicIP
+}
+
+// call 调用华为云接口，使用AK/SK签名认证
+// 签名机制文档：https://support.huaweicloud.com/devg-apisign/api-sign-algorithm.html
+func (c *hwClient) call(method, endpoint, path string, query url.Values, result interface{}) error {
+	reqURL := endpoint + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.sign(req, time.Now().UTC())
+
+	_, err = doVendorRequest(c.httpCli, req, result)
+	return err
+}
+
+// sign 计算请求签名并设置到请求头中
+func (c *hwClient) sign(req *http.Request, now time.Time) {
+	req.Header.Set(hwSdkDateKey, now.Format(hwSdkDateFmt))
+
+	signedHeaders := []string{"host", strings.ToLower(hwSdkDateKey)}
+	canonicalHeaders := fmt.Sprintf("host:%s\n%s:%s\n", req.URL.Host, strings.ToLower(hwSdkDateKey),
+		req.Header.Get(hwSdkDateKey))
+
+	canonicalURI := req.URL.EscapedPath()
+	if !strings.HasSuffix(canonicalURI, "/") {
+		canonicalURI += "/"
+	}
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0)
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, val := range values {
+			pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(val))
+		}
+	}
+
+	bodyHash := sha256.Sum256(nil)
+	canonicalRequest := strings.Join([]string{req.Method, canonicalURI, strings.Join(pairs, "&"), canonicalHeaders,
+		strings.Join(signedHeaders, ";"), hex.EncodeToString(bodyHash[:])}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{hwSignAlgo, req.Header.Get(hwSdkDateKey),
+		hex.EncodeToString(requestHash[:])}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(c.secretKey))
+	mac.Write([]byte(stringToSign))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Access=%s, SignedHeaders=%s, Signature=%s", hwSignAlgo,
+		c.secretID, strings.Join(signedHeaders, ";"), signature))
+}
@@ -0,0 +1,227 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudvendor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+)
+
+// newHwTestClient 创建使用fake http server的华为云客户端
+func newHwTestClient(t *testing.T, handler http.HandlerFunc) (*hwClient, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "SDK-HMAC-SHA256 Access=test-id, SignedHeaders=host;x-sdk-date, Signature=") ||
+			r.Header.Get("X-Sdk-Date") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/v3/projects" {
+			w.Write([]byte(`{"projects":[{"id":"project-1","name":"` + r.URL.Query().Get("name") + `"}]}`))
+			return
+		}
+		handler(w, r)
+	}))
+	conf := metadata.CloudAccountConf{
+		VendorName: metadata.HuaweiCloud,
+		SecretID:   "test-id",
+		SecretKey:  "test-key",
+	}
+	client, err := GetVendorClient(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := client.(*hwClient)
+	cli.iamEndpoint = server.URL
+	cli.serviceEndpoint = func(service, region string) string {
+		return server.URL
+	}
+	return cli, server.Close
+}
+
+func TestHwGetRegions(t *testing.T) {
+	cli, closeFn := newHwTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/regions" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"regions":[{"id":"cn-north-4","type":"public","locales":{"zh-cn":"华北-北京四"}},` +
+			`{"id":"cn-east-3","type":"public","locales":{}}]}`))
+	})
+	defer closeFn()
+
+	regionSet, err := cli.GetRegions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regionSet) != 2 || regionSet[0].RegionName != "华北-北京四" || regionSet[1].RegionName != "cn-east-3" {
+		t.Fatalf("unexpected regions: %#v", regionSet)
+	}
+}
+
+func TestHwGetVpcs(t *testing.T) {
+	cli, closeFn := newHwTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/project-1/vpcs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"vpcs":[{"id":"vpc-1","name":"default"},{"id":"vpc-2","name":"test"}]}`))
+	})
+	defer closeFn()
+
+	vpcsInfo, err := cli.GetVpcs("cn-north-4", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vpcsInfo.Count != 2 {
+		t.Fatalf("unexpected vpcs: %#v", vpcsInfo)
+	}
+
+	opt := &ccom.VpcOpt{
+		BaseOpt: ccom.BaseOpt{
+			Filters: []*ccom.Filter{{Name: ccom.StringPtr("vpc-id"), Values: ccom.StringPtrs([]string{"vpc-2"})}},
+			Limit:   ccom.MaxLimit,
+		},
+	}
+	vpcsInfo, err = cli.GetVpcs("cn-north-4", opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vpcsInfo.Count != 1 || vpcsInfo.VpcSet[0].VpcId != "vpc-2" || vpcsInfo.VpcSet[0].VpcName != "test" {
+		t.Fatalf("unexpected vpcs: %#v", vpcsInfo)
+	}
+}
+
+func TestHwGetInstances(t *testing.T) {
+	cli, closeFn := newHwTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/project-1/cloudservers/detail" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"count":2,"servers":[` +
+			`{"id":"s-1","status":"ACTIVE","metadata":{"vpc_id":"vpc-1"},"addresses":{"vpc-1":[` +
+			`{"addr":"192.168.0.1","OS-EXT-IPS:type":"fixed"},{"addr":"3.3.3.3","OS-EXT-IPS:type":"floating"}]}},` +
+			`{"id":"s-2","status":"SHUTOFF","metadata":{"vpc_id":"vpc-2"},"addresses":{"vpc-2":[` +
+			`{"addr":"192.168.1.1","OS-EXT-IPS:type":"fixed"}]}}]}`))
+	})
+	defer closeFn()
+
+	instancesInfo, err := cli.GetInstances("cn-north-4", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expects := []metadata.Instance{
+		{InstanceId: "s-1", PrivateIp: "192.168.0.1", PublicIp: "3.3.3.3", VpcId: "vpc-1",
+			InstanceState: common.BKCloudHostStatusRunning},
+		{InstanceId: "s-2", PrivateIp: "192.168.1.1", VpcId: "vpc-2", InstanceState: common.BKCloudHostStatusStopped},
+	}
+	if len(instancesInfo.InstanceSet) != len(expects) {
+		t.Fatalf("unexpected instances: %#v", instancesInfo)
+	}
+	for i, expect := range expects {
+		if *instancesInfo.InstanceSet[i] != expect {
+			t.Errorf("instance %d, expect: %#v, actual: %#v", i, expect, *instancesInfo.InstanceSet[i])
+		}
+	}
+
+	opt := &ccom.InstanceOpt{
+		BaseOpt: ccom.BaseOpt{
+			Filters: []*ccom.Filter{{Name: ccom.StringPtr("vpc-id"), Values: ccom.StringPtrs([]string{"vpc-2"})}},
+		},
+	}
+	count, err := cli.GetInstancesTotalCnt("cn-north-4", opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("unexpected instances count: %d", count)
+	}
+}
+
+func TestHwGetCloudResources(t *testing.T) {
+	responses := map[string]string{
+		"/v2/project-1/cloudvolumes/detail": `{"count":1,"volumes":[{"id":"vol-1","name":"data","status":"in-use",` +
+			`"size":100,"volume_type":"SSD","attachments":[{"server_id":"ecs-1"}]}]}`,
+		"/v1/project-1/cloudservers/detail": `{"count":2,"servers":[` +
+			`{"id":"ecs-1","metadata":{"vpc_id":"vpc-1"},"security_groups":[{"id":"sg-1"}],` +
+			`"addresses":{"vpc-1":[{"addr":"192.168.0.1","OS-EXT-IPS:type":"fixed"}]}},` +
+			`{"id":"ecs-2","metadata":{"vpc_id":"vpc-2"},"security_groups":[{"id":"sg-2"}],` +
+			`"addresses":{"vpc-2":[{"addr":"192.168.1.1","OS-EXT-IPS:type":"fixed"}]}}]}`,
+		"/v1/project-1/security-groups": `{"security_groups":[{"id":"sg-1","name":"default","vpc_id":"vpc-1"},` +
+			`{"id":"sg-2","name":"web","vpc_id":"vpc-2"}]}`,
+		"/v2/project-1/elb/loadbalancers": `{"loadbalancers":[{"id":"elb-1","name":"","vip_address":"192.168.0.100",` +
+			`"operating_status":"ONLINE","pools":[{"id":"pool-1"}]}]}`,
+		"/v2/project-1/elb/pools/pool-1/members": `{"members":[{"address":"192.168.0.1"}]}`,
+	}
+	cli, closeFn := newHwTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		resp, exists := responses[r.URL.Path]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(resp))
+	})
+	defer closeFn()
+
+	volumes, err := cli.GetCloudResources("cn-north-4", metadata.CloudDisk, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if volumes.Count != 1 || volumes.ResourceSet[0].InstanceIDs[0] != "ecs-1" ||
+		volumes.ResourceSet[0].Attributes["size"] != int64(100) {
+		t.Fatalf("unexpected volumes: %#v", volumes.ResourceSet)
+	}
+
+	opt := &ccom.ResourceOpt{
+		BaseOpt: ccom.BaseOpt{
+			Filters: []*ccom.Filter{{Name: ccom.StringPtr("vpc-id"), Values: ccom.StringPtrs([]string{"vpc-2"})}},
+		},
+	}
+	groups, err := cli.GetCloudResources("cn-north-4", metadata.CloudSecurityGroup, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups.Count != 1 || groups.ResourceSet[0].ResourceID != "sg-2" || groups.ResourceSet[0].InstanceIDs[0] != "ecs-2" {
+		t.Fatalf("unexpected security groups: %#v", groups.ResourceSet)
+	}
+
+	lbs, err := cli.GetCloudResources("cn-north-4", metadata.CloudLoadBalancer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lbs.Count != 1 || lbs.ResourceSet[0].Name != "elb-1" || lbs.ResourceSet[0].VpcID != "vpc-1" ||
+		lbs.ResourceSet[0].InstanceIDs[0] != "ecs-1" {
+		t.Fatalf("unexpected load balancers: %#v", lbs.ResourceSet)
+	}
+}
+
+func TestHwAuthFailure(t *testing.T) {
+	cli, closeFn := newHwTestClient(t, nil)
+	defer closeFn()
+	cli.secretID = "wrong-id"
+
+	_, err := cli.GetRegions()
+	if err == nil || !strings.Contains(strings.ToLower(err.Error()), "authfailure") {
+		t.Fatalf("expect auth failure, got: %v", err)
+	}
+}
@@ -0,0 +1,745 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudvendor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+)
+
+func init() {
+	Register(metadata.OpenStack, &osClient{vendorName: metadata.OpenStack})
+}
+
+// osClient 兼容OpenStack接口的私有云客户端
+// 使用keystone v3的应用凭证认证，secretID为应用凭证id，secretKey为应用凭证密钥，endpoint为keystone地址
+// 以neutron的网络作为vpc，以nova的云服务器作为实例
+type osClient struct {
+	vendorName string
+	secretID   string
+	secretKey  string
+	endpoint   string
+	httpCli    *http.Client
+
+	// token及服务目录在客户端生命周期内复用
+	lock    sync.Mutex
+	token   string
+	catalog []osCatalogEntry
+}
+
+const (
+	osMaxPageSize int64 = 1000
+
+	osTokenHeader     = "X-Auth-Token"
+	osSubjectToken    = "X-Subject-Token"
+	osComputeService  = "compute"
+	osNetworkService  = "network"
+	osVolumeService   = "volumev3"
+	osLBService       = "load-balancer"
+	osComputeOwner    = "compute:"
+	osPublicInterface = "public"
+	osFixedIPType     = "fixed"
+	osFloatIPType     = "floating"
+	osIPTypeKey       = "OS-EXT-IPS:type"
+)
+
+// NewVendorClient 创建云厂商客户端
+func (c *osClient) NewVendorClient(conf metadata.CloudAccountConf) VendorClient {
+	return &osClient{
+		vendorName: metadata.OpenStack,
+		secretID:   conf.SecretID,
+		secretKey:  conf.SecretKey,
+		endpoint:   strings.TrimSuffix(conf.Endpoint, "/"),
+		httpCli:    newVendorHttpClient(),
+	}
+}
+
+// osCatalogEntry keystone返回的服务目录
+type osCatalogEntry struct {
+	Type      string `json:"type"`
+	Endpoints []struct {
+		Interface string `json:"interface"`
+		RegionID  string `json:"region_id"`
+		URL       string `json:"url"`
+	} `json:"endpoints"`
+}
+
+// osTokenResp 获取token的返回结果
+type osTokenResp struct {
+	Token struct {
+		Catalog []osCatalogEntry `json:"catalog"`
+	} `json:"token"`
+}
+
+// osRegionsResp 地域列表返回结果
+type osRegionsResp struct {
+	Regions []struct {
+		ID          string `json:"id"`
+		Description string `json:"description"`
+	} `json:"regions"`
+}
+
+// osNetwork neutron网络
+type osNetwork struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// osNetworksResp 网络列表返回结果
+type osNetworksResp struct {
+	Networks []osNetwork `json:"networks"`
+}
+
+// osServersResp 云服务器列表返回结果
+type osServersResp struct {
+	Servers []struct {
+		ID        string                         `json:"id"`
+		Status    string                         `json:"status"`
+		Addresses map[string][]map[string]string `json:"addresses"`
+	} `json:"servers"`
+}
+
+// osVolumesResp 云硬盘列表返回结果
+type osVolumesResp struct {
+	Volumes []struct {
+		ID               string `json:"id"`
+		Name             string `json:"name"`
+		Status           string `json:"status"`
+		Size             int64  `json:"size"`
+		VolumeType       string `json:"volume_type"`
+		AvailabilityZone string `json:"availability_zone"`
+		Attachments      []struct {
+			ServerID string `json:"server_id"`
+		} `json:"attachments"`
+	} `json:"volumes"`
+}
+
+// osSecurityGroupsResp 安全组列表返回结果
+type osSecurityGroupsResp struct {
+	SecurityGroups []struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"security_groups"`
+}
+
+// osPort neutron端口，云服务器通过端口接入网络
+type osPort struct {
+	ID             string   `json:"id"`
+	DeviceID       string   `json:"device_id"`
+	DeviceOwner    string   `json:"device_owner"`
+	SecurityGroups []string `json:"security_groups"`
+	FixedIPs       []struct {
+		IPAddress string `json:"ip_address"`
+	} `json:"fixed_ips"`
+}
+
+// osPortsResp 端口列表返回结果
+type osPortsResp struct {
+	Ports []osPort `json:"ports"`
+}
+
+// osLoadBalancersResp 负载均衡列表返回结果
+type osLoadBalancersResp struct {
+	LoadBalancers []struct {
+		ID              string `json:"id"`
+		Name            string `json:"name"`
+		VipAddress      string `json:"vip_address"`
+		VipNetworkID    string `json:"vip_network_id"`
+		OperatingStatus string `json:"operating_status"`
+		Pools           []struct {
+			ID string `json:"id"`
+		} `json:"pools"`
+	} `json:"loadbalancers"`
+}
+
+// osMembersResp 负载均衡后端成员列表返回结果
+type osMembersResp struct {
+	Members []struct {
+		Address string `json:"address"`
+	} `json:"members"`
+}
+
+// GetRegions 获取地域列表
+// API文档：https://docs.openstack.org/api-ref/identity/v3/#list-regions
+func (c *osClient) GetRegions() ([]*metadata.Region, error) {
+	if err := c.authenticate(); err != nil {
+		return nil, err
+	}
+
+	resp := new(osRegionsResp)
+	if err := c.call(http.MethodGet, c.endpoint+"/v3/regions", nil, resp); err != nil {
+		return nil, err
+	}
+
+	regionSet := make([]*metadata.Region, 0)
+	for _, region := range resp.Regions {
+		regionName := region.Description
+		if regionName == "" {
+			regionName = region.ID
+		}
+		regionSet = append(regionSet, &metadata.Region{
+			RegionId:   region.ID,
+			RegionName: regionName,
+		})
+	}
+	return regionSet, nil
+}
+
+// GetVpcs 获取vpc列表
+// API文档：https://docs.openstack.org/api-ref/network/v2/#list-networks
+func (c *osClient) GetVpcs(region string, opt *ccom.VpcOpt) (*metadata.VpcsInfo, error) {
+	if opt == nil {
+		opt = ccom.GetDefaultVpcOpt()
+	}
+
+	networks, err := c.listNetworks(region, getStringFilterValues(opt.Filters, "vpc-id"))
+	if err != nil {
+		return nil, err
+	}
+
+	vpcsInfo := new(metadata.VpcsInfo)
+	for _, network := range networks {
+		vpcName := network.Name
+		if vpcName == "" {
+			vpcName = network.ID
+		}
+		vpcsInfo.VpcSet = append(vpcsInfo.VpcSet, &metadata.Vpc{
+			VpcId:   network.ID,
+			VpcName: vpcName,
+		})
+	}
+	vpcsInfo.Count = int64(len(vpcsInfo.VpcSet))
+	if opt.Limit > 0 && opt.Limit < vpcsInfo.Count {
+		vpcsInfo.VpcSet = vpcsInfo.VpcSet[:opt.Limit]
+	}
+
+	return vpcsInfo, nil
+}
+
+// GetInstances 获取实例列表
+// API文档：https://docs.openstack.org/api-ref/compute/#list-servers-detailed
+func (c *osClient) GetInstances(region string, opt *ccom.InstanceOpt) (*metadata.InstancesInfo, error) {
+	if opt == nil {
+		opt = ccom.GetDefaultInstanceOpt()
+	}
+	vpcIDs := getStringFilterValues(opt.Filters, "vpc-id")
+
+	// nova返回的云服务器地址是以网络名称为key的，需要通过网络列表转换为网络id
+	networks, err := c.listNetworks(region, nil)
+	if err != nil {
+		return nil, err
+	}
+	networkIDMap := make(map[string]string)
+	for _, network := range networks {
+		networkIDMap[network.Name] = network.ID
+	}
+
+	computeURL, err := c.getServiceURL(osComputeService, region)
+	if err != nil {
+		return nil, err
+	}
+
+	instancesInfo := new(metadata.InstancesInfo)
+	loopCnt := 0
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("limit", strconv.FormatInt(osMaxPageSize, 10))
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp := new(osServersResp)
+		if err := c.call(http.MethodGet, computeURL+"/servers/detail", query, resp); err != nil {
+			return nil, err
+		}
+
+		for _, server := range resp.Servers {
+			vpcID, privateIP, publicIP := c.getServerNetInfo(server.Addresses, networkIDMap)
+			if len(vpcIDs) > 0 && !util.InStrArr(vpcIDs, vpcID) {
+				continue
+			}
+			instancesInfo.InstanceSet = append(instancesInfo.InstanceSet, &metadata.Instance{
+				InstanceId:    server.ID,
+				PrivateIp:     privateIP,
+				PublicIp:      publicIP,
+				InstanceState: ccom.CovertInstState(server.Status),
+				VpcId:         vpcID,
+			})
+		}
+
+		if int64(len(resp.Servers)) < osMaxPageSize {
+			break
+		}
+		marker = resp.Servers[len(resp.Servers)-1].ID
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("ListServers loopCnt:%d, bigger than MaxLoopCnt, len(instances):%d", loopCnt,
+				len(instancesInfo.InstanceSet))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	instancesInfo.Count = int64(len(instancesInfo.InstanceSet))
+	if opt.Limit > 0 && opt.Limit < instancesInfo.Count {
+		instancesInfo.InstanceSet = instancesInfo.InstanceSet[:opt.Limit]
+	}
+
+	return instancesInfo, nil
+}
+
+// GetInstancesTotalCnt 获取实例总个数
+func (c *osClient) GetInstancesTotalCnt(region string, opt *ccom.InstanceOpt) (int64, error) {
+	instsInfo, err := c.GetInstances(region, opt)
+	if err != nil {
+		return 0, err
+	}
+	return instsInfo.Count, nil
+}
+
+// GetCloudResources 获取主机以外的云资源列表
+func (c *osClient) GetCloudResources(region string, kind metadata.CloudResourceKind, opt *ccom.ResourceOpt) (
+	*metadata.CloudResourcesInfo, error) {
+
+	if opt == nil {
+		opt = ccom.GetDefaultResourceOpt()
+	}
+
+	var resources []*metadata.CloudResource
+	var err error
+	switch kind {
+	case metadata.CloudDisk:
+		resources, err = c.getVolumes(region)
+	case metadata.CloudSecurityGroup:
+		resources, err = c.getSecurityGroups(region)
+	case metadata.CloudLoadBalancer:
+		resources, err = c.getLoadBalancers(region, getStringFilterValues(opt.Filters, "vpc-id"))
+	default:
+		return nil, ccom.ErrResourceKindNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resourcesInfo := &metadata.CloudResourcesInfo{Count: int64(len(resources)), ResourceSet: resources}
+	if opt.Limit > 0 && opt.Limit < resourcesInfo.Count {
+		resourcesInfo.ResourceSet = resourcesInfo.ResourceSet[:opt.Limit]
+	}
+	return resourcesInfo, nil
+}
+
+// getVolumes 获取cinder云硬盘列表，云硬盘不属于网络，通过挂载的云服务器关联到vpc
+// API文档：https://docs.openstack.org/api-ref/block-storage/v3/#list-accessible-volumes-with-details
+func (c *osClient) getVolumes(region string) ([]*metadata.CloudResource, error) {
+	volumeURL, err := c.getServiceURL(osVolumeService, region)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	loopCnt := 0
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("limit", strconv.FormatInt(osMaxPageSize, 10))
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp := new(osVolumesResp)
+		if err := c.call(http.MethodGet, volumeURL+"/volumes/detail", query, resp); err != nil {
+			return nil, err
+		}
+
+		for _, volume := range resp.Volumes {
+			name := volume.Name
+			if name == "" {
+				name = volume.ID
+			}
+			instIDs := make([]string, 0)
+			for _, attachment := range volume.Attachments {
+				instIDs = append(instIDs, attachment.ServerID)
+			}
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  volume.ID,
+				Name:        name,
+				Region:      region,
+				Status:      volume.Status,
+				InstanceIDs: instIDs,
+				Attributes: map[string]interface{}{
+					"size": volume.Size,
+					"type": volume.VolumeType,
+					"zone": volume.AvailabilityZone,
+				},
+			})
+		}
+
+		if int64(len(resp.Volumes)) < osMaxPageSize {
+			break
+		}
+		marker = resp.Volumes[len(resp.Volumes)-1].ID
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("ListVolumes loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// getSecurityGroups 获取neutron安全组列表，安全组关联的云服务器通过云服务器的端口获取
+// API文档：https://docs.openstack.org/api-ref/network/v2/#list-security-groups
+func (c *osClient) getSecurityGroups(region string) ([]*metadata.CloudResource, error) {
+	ports, err := c.listServerPorts(region)
+	if err != nil {
+		return nil, err
+	}
+	groupInstIDs := make(map[string][]string)
+	for _, port := range ports {
+		for _, groupID := range port.SecurityGroups {
+			if !util.InStrArr(groupInstIDs[groupID], port.DeviceID) {
+				groupInstIDs[groupID] = append(groupInstIDs[groupID], port.DeviceID)
+			}
+		}
+	}
+
+	networkURL, err := c.getServiceURL(osNetworkService, region)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	loopCnt := 0
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("limit", strconv.FormatInt(osMaxPageSize, 10))
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp := new(osSecurityGroupsResp)
+		if err := c.call(http.MethodGet, networkURL+"/v2.0/security-groups", query, resp); err != nil {
+			return nil, err
+		}
+
+		for _, group := range resp.SecurityGroups {
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  group.ID,
+				Name:        group.Name,
+				Region:      region,
+				InstanceIDs: groupInstIDs[group.ID],
+				Attributes:  map[string]interface{}{"description": group.Description},
+			})
+		}
+
+		if int64(len(resp.SecurityGroups)) < osMaxPageSize {
+			break
+		}
+		marker = resp.SecurityGroups[len(resp.SecurityGroups)-1].ID
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("ListSecurityGroups loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// getLoadBalancers 获取octavia负载均衡列表，以vip所在网络作为vpc，后端成员通过端口的ip找到对应的云服务器
+// API文档：https://docs.openstack.org/api-ref/load-balancer/v2/#list-load-balancers
+func (c *osClient) getLoadBalancers(region string, vpcIDs []string) ([]*metadata.CloudResource, error) {
+	ports, err := c.listServerPorts(region)
+	if err != nil {
+		return nil, err
+	}
+	ipServerIDs := make(map[string]string)
+	for _, port := range ports {
+		for _, fixedIP := range port.FixedIPs {
+			ipServerIDs[fixedIP.IPAddress] = port.DeviceID
+		}
+	}
+
+	lbURL, err := c.getServiceURL(osLBService, region)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	loopCnt := 0
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("limit", strconv.FormatInt(osMaxPageSize, 10))
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp := new(osLoadBalancersResp)
+		if err := c.call(http.MethodGet, lbURL+"/v2/lbaas/loadbalancers", query, resp); err != nil {
+			return nil, err
+		}
+
+		for _, lb := range resp.LoadBalancers {
+			if len(vpcIDs) > 0 && !util.InStrArr(vpcIDs, lb.VipNetworkID) {
+				continue
+			}
+
+			instIDs := make([]string, 0)
+			for _, pool := range lb.Pools {
+				membersResp := new(osMembersResp)
+				membersURL := fmt.Sprintf("%s/v2/lbaas/pools/%s/members", lbURL, pool.ID)
+				if err := c.call(http.MethodGet, membersURL, nil, membersResp); err != nil {
+					return nil, err
+				}
+				for _, member := range membersResp.Members {
+					serverID, exists := ipServerIDs[member.Address]
+					if exists && !util.InStrArr(instIDs, serverID) {
+						instIDs = append(instIDs, serverID)
+					}
+				}
+			}
+
+			name := lb.Name
+			if name == "" {
+				name = lb.ID
+			}
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  lb.ID,
+				Name:        name,
+				Region:      region,
+				VpcID:       lb.VipNetworkID,
+				Status:      lb.OperatingStatus,
+				InstanceIDs: instIDs,
+				Attributes:  map[string]interface{}{"address": lb.VipAddress},
+			})
+		}
+
+		if int64(len(resp.LoadBalancers)) < osMaxPageSize {
+			break
+		}
+		marker = resp.LoadBalancers[len(resp.LoadBalancers)-1].ID
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("ListLoadBalancers loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// listServerPorts 获取地域下云服务器使用的端口
+// API文档：https://docs.openstack.org/api-ref/network/v2/#list-ports
+func (c *osClient) listServerPorts(region string) ([]osPort, error) {
+	networkURL, err := c.getServiceURL(osNetworkService, region)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := make([]osPort, 0)
+	loopCnt := 0
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("limit", strconv.FormatInt(osMaxPageSize, 10))
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp := new(osPortsResp)
+		if err := c.call(http.MethodGet, networkURL+"/v2.0/ports", query, resp); err != nil {
+			return nil, err
+		}
+		for _, port := range resp.Ports {
+			// device_owner形如compute:nova，只保留云服务器的端口
+			if strings.HasPrefix(port.DeviceOwner, osComputeOwner) && port.DeviceID != "" {
+				ports = append(ports, port)
+			}
+		}
+
+		if int64(len(resp.Ports)) < osMaxPageSize {
+			break
+		}
+		marker = resp.Ports[len(resp.Ports)-1].ID
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("ListPorts loopCnt:%d, bigger than MaxLoopCnt, len(ports):%d", loopCnt, len(ports))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return ports, nil
+}
+
+// listNetworks 获取地域下的非外部网络，外部网络作为公网出口，不作为vpc
+func (c *osClient) listNetworks(region string, networkIDs []string) ([]osNetwork, error) {
+	networkURL, err := c.getServiceURL(osNetworkService, region)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := make([]osNetwork, 0)
+	loopCnt := 0
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("router:external", "false")
+		query.Set("limit", strconv.FormatInt(osMaxPageSize, 10))
+		for _, id := range networkIDs {
+			query.Add("id", id)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp := new(osNetworksResp)
+		if err := c.call(http.MethodGet, networkURL+"/v2.0/networks", query, resp); err != nil {
+			return nil, err
+		}
+		networks = append(networks, resp.Networks...)
+
+		if int64(len(resp.Networks)) < osMaxPageSize {
+			break
+		}
+		marker = resp.Networks[len(resp.Networks)-1].ID
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("ListNetworks loopCnt:%d, bigger than MaxLoopCnt, len(networks):%d", loopCnt,
+				len(networks))
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return networks, nil
+}
+
+// getServerNetInfo 获取云服务器所属的网络id，以及内网ip和公网ip
+func (c *osClient) getServerNetInfo(addresses map[string][]map[string]string,
+	networkIDMap map[string]string) (string, string, string) {
+
+	vpcID, privateIP, publicIP := "", "", ""
+	for netName, addrs := range addresses {
+		for _, addr := range addrs {
+			switch addr[osIPTypeKey] {
+			case osFixedIPType:
+				if privateIP == "" {
+					privateIP = addr["addr"]
+					vpcID = networkIDMap[netName]
+				}
+			case osFloatIPType:
+				if publicIP == "" {
+					publicIP = addr["addr"]
+				}
+			}
+		}
+	}
+	return vpcID, privateIP, publicIP
+}
+
+// authenticate 通过应用凭证获取token及服务目录
+// API文档：https://docs.openstack.org/api-ref/identity/v3/#authenticating-with-an-application-credential
+func (c *osClient) authenticate() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.token != "" {
+		return nil
+	}
+
+	if c.endpoint == "" {
+		return errors.New("openstack keystone endpoint is not set")
+	}
+
+	body := map[string]interface{}{
+		"auth": map[string]interface{}{
+			"identity": map[string]interface{}{
+				"methods": []string{"application_credential"},
+				"application_credential": map[string]string{
+					"id":     c.secretID,
+					"secret": c.secretKey,
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint+"/v3/auth/tokens", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := new(osTokenResp)
+	header, err := doVendorRequest(c.httpCli, req, resp)
+	if err != nil {
+		return err
+	}
+
+	token := header.Get(osSubjectToken)
+	if token == "" {
+		return errors.New("AuthFailure: openstack keystone returns no token")
+	}
+	c.token = token
+	c.catalog = resp.Token.Catalog
+	return nil
+}
+
+// getServiceURL 从服务目录中获取服务在地域下的公共接入地址
+func (c *osClient) getServiceURL(serviceType, region string) (string, error) {
+	if err := c.authenticate(); err != nil {
+		return "", err
+	}
+
+	for _, entry := range c.catalog {
+		if entry.Type != serviceType {
+			continue
+		}
+		for _, endpoint := range entry.Endpoints {
+			if endpoint.Interface == osPublicInterface && endpoint.RegionID == region {
+				return strings.TrimSuffix(endpoint.URL, "/"), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%s service endpoint of region %s is not found", serviceType, region)
+}
+
+// call 携带token调用OpenStack接口
+func (c *osClient) call(method, reqURL string, query url.Values, result interface{}) error {
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(osTokenHeader, c.token)
+
+	_, err = doVendorRequest(c.httpCli, req, result)
+	return err
+}
@@ -0,0 +1,257 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudvendor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+)
+
+// newOsTestServer 创建模拟keystone、nova、neutron、cinder、octavia接口的fake http server
+func newOsTestServer(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		body := make([]byte, r.ContentLength)
+		r.Body.Read(body)
+		if !strings.Contains(string(body), `"secret":"test-key"`) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-Subject-Token", "test-token")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"token":{"catalog":[` +
+			`{"type":"compute","endpoints":[{"interface":"public","region_id":"RegionOne","url":"` +
+			server.URL + `/compute/v2.1/"}]},` +
+			`{"type":"network","endpoints":[{"interface":"internal","region_id":"RegionOne","url":"http://127.0.0.1"},` +
+			`{"interface":"public","region_id":"RegionOne","url":"` + server.URL + `/network"}]},` +
+			`{"type":"volumev3","endpoints":[{"interface":"public","region_id":"RegionOne","url":"` +
+			server.URL + `/volume/v3"}]},` +
+			`{"type":"load-balancer","endpoints":[{"interface":"public","region_id":"RegionOne","url":"` +
+			server.URL + `/lb"}]}]}}`))
+	})
+
+	checkToken := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Auth-Token") != "test-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			handler(w, r)
+		}
+	}
+	mux.HandleFunc("/v3/regions", checkToken(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"regions":[{"id":"RegionOne","description":""}]}`))
+	}))
+	mux.HandleFunc("/network/v2.0/networks", checkToken(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("router:external") != "false" {
+			t.Errorf("external networks should be excluded, query: %v", r.URL.Query())
+		}
+		if r.URL.Query().Get("id") == "net-2" {
+			w.Write([]byte(`{"networks":[{"id":"net-2","name":"private"}]}`))
+			return
+		}
+		w.Write([]byte(`{"networks":[{"id":"net-1","name":"default"},{"id":"net-2","name":"private"}]}`))
+	}))
+	mux.HandleFunc("/compute/v2.1/servers/detail", checkToken(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"servers":[` +
+			`{"id":"vm-1","status":"ACTIVE","addresses":{"default":[` +
+			`{"addr":"172.16.0.1","OS-EXT-IPS:type":"fixed"},{"addr":"4.4.4.4","OS-EXT-IPS:type":"floating"}]}},` +
+			`{"id":"vm-2","status":"BUILD","addresses":{"private":[{"addr":"172.16.1.1","OS-EXT-IPS:type":"fixed"}]}}` +
+			`]}`))
+	}))
+	mux.HandleFunc("/volume/v3/volumes/detail", checkToken(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"volumes":[{"id":"vol-1","name":"","status":"in-use","size":20,` +
+			`"attachments":[{"server_id":"vm-1"}]}]}`))
+	}))
+	mux.HandleFunc("/network/v2.0/security-groups", checkToken(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"security_groups":[{"id":"sg-1","name":"default"},{"id":"sg-2","name":"web"}]}`))
+	}))
+	mux.HandleFunc("/network/v2.0/ports", checkToken(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ports":[` +
+			`{"id":"port-1","device_id":"vm-1","device_owner":"compute:nova","security_groups":["sg-1","sg-2"],` +
+			`"fixed_ips":[{"ip_address":"172.16.0.1"}]},` +
+			`{"id":"port-2","device_id":"vm-2","device_owner":"compute:nova","security_groups":["sg-1"],` +
+			`"fixed_ips":[{"ip_address":"172.16.1.1"}]},` +
+			`{"id":"port-3","device_id":"router-1","device_owner":"network:router_interface",` +
+			`"security_groups":["sg-2"],"fixed_ips":[{"ip_address":"172.16.0.254"}]}]}`))
+	}))
+	mux.HandleFunc("/lb/v2/lbaas/loadbalancers", checkToken(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"loadbalancers":[{"id":"lb-1","name":"api","vip_address":"172.16.0.100",` +
+			`"vip_network_id":"net-1","operating_status":"ONLINE","pools":[{"id":"pool-1"}]}]}`))
+	}))
+	mux.HandleFunc("/lb/v2/lbaas/pools/pool-1/members", checkToken(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"members":[{"address":"172.16.0.1"},{"address":"192.168.0.1"}]}`))
+	}))
+	server = httptest.NewServer(mux)
+	return server
+}
+
+func newOsTestClient(t *testing.T, endpoint, secretKey string) VendorClient {
+	conf := metadata.CloudAccountConf{
+		VendorName: metadata.OpenStack,
+		SecretID:   "test-id",
+		SecretKey:  secretKey,
+		Endpoint:   endpoint,
+	}
+	client, err := GetVendorClient(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestOsGetRegions(t *testing.T) {
+	server := newOsTestServer(t)
+	defer server.Close()
+
+	regionSet, err := newOsTestClient(t, server.URL, "test-key").GetRegions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regionSet) != 1 || regionSet[0].RegionId != "RegionOne" || regionSet[0].RegionName != "RegionOne" {
+		t.Fatalf("unexpected regions: %#v", regionSet)
+	}
+}
+
+func TestOsGetVpcs(t *testing.T) {
+	server := newOsTestServer(t)
+	defer server.Close()
+	client := newOsTestClient(t, server.URL, "test-key")
+
+	vpcsInfo, err := client.GetVpcs("RegionOne", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vpcsInfo.Count != 2 {
+		t.Fatalf("unexpected vpcs: %#v", vpcsInfo)
+	}
+
+	opt := &ccom.VpcOpt{
+		BaseOpt: ccom.BaseOpt{
+			Filters: []*ccom.Filter{{Name: ccom.StringPtr("vpc-id"), Values: ccom.StringPtrs([]string{"net-2"})}},
+		},
+	}
+	vpcsInfo, err = client.GetVpcs("RegionOne", opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vpcsInfo.Count != 1 || vpcsInfo.VpcSet[0].VpcId != "net-2" {
+		t.Fatalf("unexpected vpcs: %#v", vpcsInfo)
+	}
+
+	if _, err = client.GetVpcs("RegionTwo", nil); err == nil {
+		t.Fatal("expect error for region without network endpoint")
+	}
+}
+
+func TestOsGetInstances(t *testing.T) {
+	server := newOsTestServer(t)
+	defer server.Close()
+	client := newOsTestClient(t, server.URL, "test-key")
+
+	instancesInfo, err := client.GetInstances("RegionOne", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expects := []metadata.Instance{
+		{InstanceId: "vm-1", PrivateIp: "172.16.0.1", PublicIp: "4.4.4.4", VpcId: "net-1",
+			InstanceState: common.BKCloudHostStatusRunning},
+		{InstanceId: "vm-2", PrivateIp: "172.16.1.1", VpcId: "net-2", InstanceState: common.BKCloudHostStatusStarting},
+	}
+	if len(instancesInfo.InstanceSet) != len(expects) {
+		t.Fatalf("unexpected instances: %#v", instancesInfo)
+	}
+	for i, expect := range expects {
+		if *instancesInfo.InstanceSet[i] != expect {
+			t.Errorf("instance %d, expect: %#v, actual: %#v", i, expect, *instancesInfo.InstanceSet[i])
+		}
+	}
+
+	opt := &ccom.InstanceOpt{
+		BaseOpt: ccom.BaseOpt{
+			Filters: []*ccom.Filter{{Name: ccom.StringPtr("vpc-id"), Values: ccom.StringPtrs([]string{"net-1"})}},
+		},
+	}
+	count, err := client.GetInstancesTotalCnt("RegionOne", opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("unexpected instances count: %d", count)
+	}
+}
+
+func TestOsGetCloudResources(t *testing.T) {
+	server := newOsTestServer(t)
+	defer server.Close()
+	client := newOsTestClient(t, server.URL, "test-key")
+
+	volumes, err := client.GetCloudResources("RegionOne", metadata.CloudDisk, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if volumes.Count != 1 || volumes.ResourceSet[0].Name != "vol-1" || volumes.ResourceSet[0].InstanceIDs[0] != "vm-1" {
+		t.Fatalf("unexpected volumes: %#v", volumes.ResourceSet)
+	}
+
+	groups, err := client.GetCloudResources("RegionOne", metadata.CloudSecurityGroup, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups.Count != 2 || len(groups.ResourceSet[0].InstanceIDs) != 2 || len(groups.ResourceSet[1].InstanceIDs) != 1 {
+		t.Fatalf("unexpected security groups: %#v", groups.ResourceSet)
+	}
+
+	opt := &ccom.ResourceOpt{
+		BaseOpt: ccom.BaseOpt{
+			Filters: []*ccom.Filter{{Name: ccom.StringPtr("vpc-id"), Values: ccom.StringPtrs([]string{"net-1"})}},
+		},
+	}
+	lbs, err := client.GetCloudResources("RegionOne", metadata.CloudLoadBalancer, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lbs.Count != 1 || lbs.ResourceSet[0].VpcID != "net-1" || len(lbs.ResourceSet[0].InstanceIDs) != 1 ||
+		lbs.ResourceSet[0].InstanceIDs[0] != "vm-1" {
+		t.Fatalf("unexpected load balancers: %#v", lbs.ResourceSet)
+	}
+}
+
+func TestOsAuthFailure(t *testing.T) {
+	server := newOsTestServer(t)
+	defer server.Close()
+
+	_, err := newOsTestClient(t, server.URL, "wrong-key").GetRegions()
+	if err == nil || !strings.Contains(strings.ToLower(err.Error()), "authfailure") {
+		t.Fatalf("expect auth failure, got: %v", err)
+	}
+
+	if _, err = newOsTestClient(t, "", "test-key").GetRegions(); err == nil {
+		t.Fatal("expect error when endpoint is not set")
+	}
+}
@@ -46,11 +46,11 @@ const (
 )
 
 // NewVendorClient 创建云厂商客户端
-func (c *tcClient) NewVendorClient(secretID, secretKey string) VendorClient {
+func (c *tcClient) NewVendorClient(conf metadata.CloudAccountConf) VendorClient {
 	return &tcClient{
 		vendorName: metadata.TencentCloud,
-		secretID:   secretID,
-		secretKey:  secretKey,
+		secretID:   conf.SecretID,
+		secretKey:  conf.SecretKey,
 	}
 }
 
@@ -219,6 +219,189 @@ func (c *tcClient) GetInstancesTotalCnt(region string, opt *ccom.InstanceOpt) (i
 	return instsInfo.Count, nil
 }
 
+// GetCloudResources 获取主机以外的云资源列表
+// 当前仅引入了cvm和vpc的sdk，负载均衡暂不支持
+func (c *tcClient) GetCloudResources(region string, kind metadata.CloudResourceKind, opt *ccom.ResourceOpt) (
+	*metadata.CloudResourcesInfo, error) {
+
+	if opt == nil {
+		opt = ccom.GetDefaultResourceOpt()
+	}
+
+	var resources []*metadata.CloudResource
+	var err error
+	switch kind {
+	case metadata.CloudDisk:
+		resources, err = c.getDisks(region, opt)
+	case metadata.CloudSecurityGroup:
+		resources, err = c.getSecurityGroups(region, opt)
+	default:
+		return nil, ccom.ErrResourceKindNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &metadata.CloudResourcesInfo{Count: int64(len(resources)), ResourceSet: resources}, nil
+}
+
+// getDisks 获取实例挂载的系统盘和数据盘
+// API文档：https://cloud.tencent.com/document/api/213/15728
+func (c *tcClient) getDisks(region string, opt *ccom.ResourceOpt) ([]*metadata.CloudResource, error) {
+	resources := make([]*metadata.CloudResource, 0)
+	err := c.walkCvmInstances(region, opt.Filters, func(inst *cvm.Instance) {
+		vpcID := ""
+		if inst.VirtualPrivateCloud != nil && inst.VirtualPrivateCloud.VpcId != nil {
+			vpcID = *inst.VirtualPrivateCloud.VpcId
+		}
+		if inst.SystemDisk != nil && inst.SystemDisk.DiskId != nil {
+			resources = append(resources, c.newDiskResource(region, vpcID, *inst.InstanceId, "system",
+				inst.SystemDisk.DiskId, inst.SystemDisk.DiskType, inst.SystemDisk.DiskSize))
+		}
+		for _, disk := range inst.DataDisks {
+			if disk == nil || disk.DiskId == nil {
+				continue
+			}
+			resources = append(resources, c.newDiskResource(region, vpcID, *inst.InstanceId, "data",
+				disk.DiskId, disk.DiskType, disk.DiskSize))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// newDiskResource 将实例的磁盘信息转换为云资源
+func (c *tcClient) newDiskResource(region, vpcID, instID, usage string, diskID, diskType *string,
+	diskSize *int64) *metadata.CloudResource {
+
+	attrs := map[string]interface{}{"usage": usage}
+	if diskType != nil {
+		attrs["type"] = *diskType
+	}
+	if diskSize != nil {
+		attrs["size"] = *diskSize
+	}
+	return &metadata.CloudResource{
+		ResourceID:  *diskID,
+		Name:        *diskID,
+		Region:      region,
+		VpcID:       vpcID,
+		InstanceIDs: []string{instID},
+		Attributes:  attrs,
+	}
+}
+
+// getSecurityGroups 获取安全组列表，安全组关联的实例从实例列表中获取
+// API文档：https://cloud.tencent.com/document/api/215/15808
+func (c *tcClient) getSecurityGroups(region string, opt *ccom.ResourceOpt) ([]*metadata.CloudResource, error) {
+	groupInstIDs := make(map[string][]string)
+	err := c.walkCvmInstances(region, opt.Filters, func(inst *cvm.Instance) {
+		for _, groupID := range inst.SecurityGroupIds {
+			if groupID != nil {
+				groupInstIDs[*groupID] = append(groupInstIDs[*groupID], *inst.InstanceId)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	credential := c.newCredential(c.secretID, c.secretKey)
+	client, err := tcVpc.NewClient(credential, region, profile.NewClientProfile())
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	request := tcVpc.NewDescribeSecurityGroupsRequest()
+	c.setVpcLimit(&request.Limit, tcMaxPageSize)
+	loopCnt := 0
+	for {
+		resp, err := client.DescribeSecurityGroups(request)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, group := range resp.Response.SecurityGroupSet {
+			if group.SecurityGroupId == nil {
+				continue
+			}
+			desc := ""
+			if group.SecurityGroupDesc != nil {
+				desc = *group.SecurityGroupDesc
+			}
+			name := *group.SecurityGroupId
+			if group.SecurityGroupName != nil {
+				name = *group.SecurityGroupName
+			}
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID:  *group.SecurityGroupId,
+				Name:        name,
+				Region:      region,
+				InstanceIDs: groupInstIDs[*group.SecurityGroupId],
+				Attributes:  map[string]interface{}{"description": desc},
+			})
+		}
+
+		if len(resp.Response.SecurityGroupSet) == 0 || resp.Response.TotalCount == nil ||
+			uint64(len(resources)) >= *resp.Response.TotalCount || opt.Limit <= int64(len(resources)) {
+			break
+		}
+		offset := fmt.Sprintf("%d", len(resources))
+		request.Offset = &offset
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeSecurityGroups loopCnt:%d, bigger than MaxLoopCnt, TotalCount:%d",
+				loopCnt, *resp.Response.TotalCount)
+			return nil, ccom.ErrorLoopCnt
+		}
+	}
+	return resources, nil
+}
+
+// walkCvmInstances 遍历符合过滤条件的全部实例
+func (c *tcClient) walkCvmInstances(region string, filters []*ccom.Filter, handler func(inst *cvm.Instance)) error {
+	credential := c.newCredential(c.secretID, c.secretKey)
+	client, err := cvm.NewClient(credential, region, profile.NewClientProfile())
+	if err != nil {
+		return err
+	}
+
+	request := cvm.NewDescribeInstancesRequest()
+	c.setCvmFilters(&request.Filters, filters)
+	c.setCvmLimit(&request.Limit, tcMaxPageSize)
+	var count int64
+	loopCnt := 0
+	for {
+		resp, err := client.DescribeInstances(request)
+		if err != nil {
+			return err
+		}
+
+		for _, inst := range resp.Response.InstanceSet {
+			if inst.InstanceId != nil {
+				handler(inst)
+			}
+		}
+		count += int64(len(resp.Response.InstanceSet))
+
+		if len(resp.Response.InstanceSet) == 0 || resp.Response.TotalCount == nil ||
+			count >= *resp.Response.TotalCount {
+			break
+		}
+		request.Offset = &count
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeInstances loopCnt:%d, bigger than MaxLoopCnt, TotalCount:%d",
+				loopCnt, *resp.Response.TotalCount)
+			return ccom.ErrorLoopCnt
+		}
+	}
+	return nil
+}
+
 // newCredential 创建认证信息
 func (c *tcClient) newCredential(secretID, secretKey string) *tcCommon.Credential {
 	return tcCommon.NewCredential(secretID, secretKey)
@@ -28,7 +28,7 @@ var vendorClients = make(map[string]VendorClient, 0)
 // VendorClient TODO
 type VendorClient interface {
 	// NewVendorClient 创建云厂商客户端
-	NewVendorClient(secretID, secretKey string) VendorClient
+	NewVendorClient(conf metadata.CloudAccountConf) VendorClient
 	// GetRegions 获取地域列表
 	GetRegions() ([]*metadata.Region, error)
 	// GetVpcs 获取vpc列表
@@ -37,6 +37,10 @@ type VendorClient interface {
 	GetInstances(region string, opt *ccom.InstanceOpt) (*metadata.InstancesInfo, error)
 	// GetInstancesTotalCnt 获取实例总个数
 	GetInstancesTotalCnt(region string, opt *ccom.InstanceOpt) (int64, error)
+	// GetCloudResources 获取主机以外的云资源列表，如负载均衡、云硬盘、安全组
+	// 云厂商不支持的资源类型返回ErrResourceKindNotSupported
+	GetCloudResources(region string, kind metadata.CloudResourceKind, opt *ccom.ResourceOpt) (
+		*metadata.CloudResourcesInfo, error)
 }
 
 // Register 注册云厂商客户端
@@ -51,6 +55,6 @@ func GetVendorClient(conf metadata.CloudAccountConf) (VendorClient, error) {
 	if client, ok = vendorClients[conf.VendorName]; !ok {
 		return nil, fmt.Errorf("vendor %s is not supported", conf.VendorName)
 	}
-	cli := client.NewVendorClient(conf.SecretID, conf.SecretKey)
+	cli := client.NewVendorClient(conf)
 	return cli, nil
 }
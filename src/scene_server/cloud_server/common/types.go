@@ -31,6 +31,9 @@ const (
 var (
 	// ErrorLoopCnt 循环过多错误
 	ErrorLoopCnt = errors.New("too much loop")
+
+	// ErrResourceKindNotSupported 云厂商不支持同步该类型的云资源
+	ErrResourceKindNotSupported = errors.New("cloud resource kind is not supported by this vendor")
 )
 
 // BaseOpt 云厂商接口请求条件的公共部分
@@ -61,6 +64,11 @@ type InstanceOpt struct {
 	BaseOpt
 }
 
+// ResourceOpt 主机以外的云资源请求条件
+type ResourceOpt struct {
+	BaseOpt
+}
+
 // GetDefaultVpcOpt 获取默认的Vpc请求条件
 func GetDefaultVpcOpt() *VpcOpt {
 	return &VpcOpt{
@@ -79,6 +87,15 @@ func GetDefaultInstanceOpt() *InstanceOpt {
 	}
 }
 
+// GetDefaultResourceOpt 获取默认的云资源请求条件
+func GetDefaultResourceOpt() *ResourceOpt {
+	return &ResourceOpt{
+		BaseOpt{
+			Limit: MaxLimit,
+		},
+	}
+}
+
 // Int64Ptr 获取int64的指针
 func Int64Ptr(v int64) *int64 {
 	return &v
@@ -30,14 +30,15 @@ import (
 
 // CovertInstState 将不同云厂商的实例状态转为统一的实例状态
 func CovertInstState(instState string) string {
+	// 华为云和OpenStack的实例状态沿用nova的定义，如active、build、shutoff等
 	switch strings.ToLower(instState) {
-	case "starting", "pending", "rebooting":
+	case "starting", "pending", "rebooting", "build", "reboot", "hard_reboot", "rebuild":
 		return common.BKCloudHostStatusStarting
-	case "running":
+	case "running", "active":
 		return common.BKCloudHostStatusRunning
 	case "stopping", "shutting-down", "terminating":
 		return common.BKCloudHostStatusStopping
-	case "stopped", "shutdown", "terminated":
+	case "stopped", "shutdown", "terminated", "shutoff", "deleted", "soft_deleted":
 		return common.BKCloudHostStatusStopped
 	default:
 		blog.Infof("convert to unknow state, the origin instState:%s", instState)
@@ -27,6 +27,7 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 	"configcenter/src/scene_server/cloud_server/cloudvendor"
 	ccom "configcenter/src/scene_server/cloud_server/common"
 )
@@ -386,6 +387,50 @@ func getCloudHostResource(allVpcs []metadata.VpcSyncInfo, destroyedVpcs map[stri
 	return result
 }
 
+// GetCloudResources 获取同步任务的vpc所在地域下主机以外的云资源，返回按资源类型分组的云资源
+// 云厂商不支持的资源类型会被忽略，云资源是否属于同步任务由调用方根据vpc和关联的云主机判断
+func (lgc *Logics) GetCloudResources(kit *rest.Kit, conf metadata.CloudAccountConf, syncVpcs []metadata.VpcSyncInfo,
+	kinds []metadata.CloudResourceKind) (map[metadata.CloudResourceKind][]*metadata.CloudResource, error) {
+
+	client, err := cloudvendor.GetVendorClient(conf)
+	if err != nil {
+		blog.Errorf("get vendorClient failed, AccountID: %d, err: %v, rid: %s", conf.AccountID, err, kit.Rid)
+		return nil, err
+	}
+
+	regions := make([]string, 0)
+	for _, vpc := range syncVpcs {
+		if vpc.Destroyed || util.InStrArr(regions, vpc.Region) {
+			continue
+		}
+		regions = append(regions, vpc.Region)
+	}
+
+	result := make(map[metadata.CloudResourceKind][]*metadata.CloudResource)
+	for _, kind := range kinds {
+		if _, exists := result[kind]; exists {
+			continue
+		}
+		result[kind] = make([]*metadata.CloudResource, 0)
+		for _, region := range regions {
+			resourcesInfo, err := client.GetCloudResources(region, kind, nil)
+			if err == ccom.ErrResourceKindNotSupported {
+				blog.Warnf("vendor %s does not support resource kind %s, skip it, rid: %s", conf.VendorName, kind,
+					kit.Rid)
+				break
+			}
+			if err != nil {
+				blog.Errorf("get cloud resources failed, AccountID: %d, region: %s, kind: %s, err: %v, rid: %s",
+					conf.AccountID, region, kind, err, kit.Rid)
+				return nil, err
+			}
+			result[kind] = append(result[kind], resourcesInfo.ResourceSet...)
+		}
+	}
+
+	return result, nil
+}
+
 // GetCloudAccountConf 获取云账户配置
 func (lgc *Logics) GetCloudAccountConf(kit *rest.Kit, accountID int64) (*metadata.CloudAccountConf, error) {
 	option := &metadata.SearchCloudOption{Condition: mapstr.MapStr{common.BKCloudAccountID: accountID}}
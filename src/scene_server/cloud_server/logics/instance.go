@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"strconv"
+
+	"configcenter/src/ac/iam"
+	"configcenter/src/common"
+	"configcenter/src/common/auth"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// GetObject 获取模型信息
+func (lgc *Logics) GetObject(kit *rest.Kit, objID string) (*metadata.Object, error) {
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{common.BKObjIDField: objID},
+		Page:      metadata.BasePage{Limit: 1},
+	}
+	result, err := lgc.CoreAPI.CoreService().Model().ReadModel(kit.Ctx, kit.Header, query)
+	if err != nil {
+		blog.Errorf("read model %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(result.Info) == 0 {
+		blog.Errorf("model %s is not found, rid: %s", objID, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField)
+	}
+	return &result.Info[0], nil
+}
+
+// CreateInstances 批量创建模型实例，并将创建的实例注册到iam以便创建者获得实例的权限，返回按顺序创建的实例id
+func (lgc *Logics) CreateInstances(kit *rest.Kit, obj *metadata.Object, data []mapstr.MapStr) ([]int64, error) {
+	if len(data) == 0 {
+		return make([]int64, 0), nil
+	}
+
+	input := &metadata.BatchCreateModelInstOption{Data: data}
+	result, err := lgc.CoreAPI.CoreService().Instance().BatchCreateInstance(kit.Ctx, kit.Header, obj.ObjectID,
+		input)
+	if err != nil {
+		blog.Errorf("create %s instances failed, data: %#v, err: %v, rid: %s", obj.ObjectID, data, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(result.IDs) != len(data) {
+		blog.Errorf("create %s instances failed, created %d of %d instances, rid: %s", obj.ObjectID,
+			len(result.IDs), len(data), kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrTopoInstCreateFailed)
+	}
+
+	// register created instances resource creator action to iam
+	if auth.EnableAuthorize() {
+		iamInstances := make([]metadata.IamInstance, len(data))
+		for index := range data {
+			iamInstances[index] = metadata.IamInstance{
+				ID:   strconv.FormatInt(result.IDs[index], 10),
+				Name: util.GetStrByInterface(data[index][common.BKInstNameField]),
+			}
+		}
+		iamInstancesWithCreator := metadata.IamInstancesWithCreator{
+			IamInstances: metadata.IamInstances{
+				Type:      string(iam.GenIAMDynamicResTypeID(obj.ID)),
+				Instances: iamInstances,
+			},
+			Creator: kit.User,
+		}
+		_, err := lgc.authorizer.BatchRegisterResourceCreatorAction(kit.Ctx, kit.Header, iamInstancesWithCreator)
+		if err != nil {
+			blog.Errorf("register created %s instances to iam failed, err: %v, rid: %s", obj.ObjectID, err,
+				kit.Rid)
+			return nil, err
+		}
+	}
+
+	return result.IDs, nil
+}
@@ -115,6 +115,8 @@ func (s *Service) initRoute(api *restful.WebService) {
 		Handler: s.UpdateSyncTask})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/cloud/sync/task/{bk_task_id}",
 		Handler: s.DeleteSyncTask})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/cloud/sync/task/{bk_task_id}/diff",
+		Handler: s.PreviewSyncTask})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/history",
 		Handler: s.SearchSyncHistory})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/region",
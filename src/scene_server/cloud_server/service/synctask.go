@@ -24,7 +24,9 @@ import (
 	"configcenter/src/common/auth"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/cloud_server/cloudsync"
 )
 
 // SearchVpc TODO
@@ -169,6 +171,37 @@ func (s *Service) DeleteSyncTask(ctx *rest.Contexts) {
 	ctx.RespEntity(nil)
 }
 
+// PreviewSyncTask 预览同步任务的同步差异，返回将要新增、更新和标记为已销毁的主机，不写入任何数据
+func (s *Service) PreviewSyncTask(ctx *rest.Contexts) {
+	taskIDStr := ctx.Request.PathParameter(common.BKCloudSyncTaskID)
+	taskID, err := strconv.ParseInt(taskIDStr, 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKCloudSyncTaskID))
+		return
+	}
+
+	opt := &metadata.SearchCloudOption{Condition: mapstr.MapStr{common.BKCloudSyncTaskID: taskID}}
+	tasks, err := s.CoreAPI.CoreService().Cloud().SearchSyncTask(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("search sync task failed, taskID: %d, err: %v, rid: %s", taskID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	if len(tasks.Info) == 0 {
+		blog.Errorf("sync task %d is not found, rid: %s", taskID, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKCloudSyncTaskID))
+		return
+	}
+
+	diff, err := cloudsync.NewHostSyncor(s.Logics).Preview(ctx.Kit, &tasks.Info[0])
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(diff)
+}
+
 // SearchSyncHistory TODO
 func (s *Service) SearchSyncHistory(ctx *rest.Contexts) {
 	option := metadata.SearchSyncHistoryOption{}
@@ -42,6 +42,7 @@ import (
 	"configcenter/src/scene_server/datacollection/collections/hostsnap"
 	"configcenter/src/scene_server/datacollection/collections/middleware"
 	"configcenter/src/scene_server/datacollection/collections/netcollect"
+	"configcenter/src/scene_server/datacollection/kubecollector"
 	svc "configcenter/src/scene_server/datacollection/service"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/kafka"
@@ -109,6 +110,9 @@ type DataCollectionConfig struct {
 
 	// SnapReportMode hostsnap report mode
 	SnapReportMode string
+
+	// KubeCollector kube collector configs.
+	KubeCollector *kubecollector.Config
 }
 
 // DataCollection is data collection server.
@@ -292,6 +296,12 @@ func (c *DataCollection) initConfigs() error {
 		}
 	}
 
+	c.config.KubeCollector, err = kubecollector.ParseConfig()
+	if err != nil {
+		blog.Errorf("parse kube collector config failed, err: %v", err)
+		return err
+	}
+
 	c.config.Auth, err = iam.ParseConfigFromKV("authServer", nil)
 	if err != nil {
 		blog.Warnf("parse auth center config failed: %v", err)
@@ -537,6 +547,20 @@ func (c *DataCollection) runCollectPorters() {
 	}
 }
 
+// runKubeCollector runs the kube collector that reconciles the configured clusters into cmdb if it is enabled.
+func (c *DataCollection) runKubeCollector() {
+	if !c.config.KubeCollector.Enabled {
+		blog.Info("DataCollection| kube collector is disabled")
+		return
+	}
+
+	collector := kubecollector.NewCollector(c.config.KubeCollector, c.engine.CoreAPI,
+		c.engine.ServiceManageInterface.IsMaster)
+	c.service.SetKubeCollector(collector)
+	go collector.Run(c.ctx)
+	blog.Infof("DataCollection| run kube collector with %d clusters success", len(c.config.KubeCollector.Clusters))
+}
+
 // Run runs a new datacollection server.
 func (c *DataCollection) Run() error {
 	// init configs.
@@ -556,6 +580,9 @@ func (c *DataCollection) Run() error {
 
 	blog.Info("run collect porters success!")
 
+	// run kube collector for new datacollection instance.
+	c.runKubeCollector()
+
 	return nil
 }
 
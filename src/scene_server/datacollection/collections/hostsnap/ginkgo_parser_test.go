@@ -0,0 +1,97 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package hostsnap
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+
+	"configcenter/src/common"
+	"configcenter/src/common/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// 使用 go test -args -update 重新生成golden文件.
+var updateGolden = flag.Bool("update", false, "update the golden files of the host snapshot parsers")
+
+// snapParserGolden 是解析器的golden文件内容，包含解析出的基础信息和需要更新的主机字段.
+type snapParserGolden struct {
+	AgentID string                 `json:"bk_agent_id"`
+	CloudID int64                  `json:"bk_cloud_id"`
+	IPv4    []string               `json:"ipv4"`
+	IPv6    []string               `json:"ipv6"`
+	Host    map[string]interface{} `json:"host"`
+}
+
+// 按照动态寻址的方式解析上报的消息，得到golden文件的内容.
+func parseSnapMsgToGolden(msg string) []byte {
+	snapMsg, err := parseSnapMsg(msg)
+	Expect(err).NotTo(HaveOccurred())
+
+	agentID, ipv4, ipv6, cloudID, val, err := getBaseInfoFromCollectorsMsg(&snapMsg, "")
+	Expect(err).NotTo(HaveOccurred())
+
+	updateIPv4, updateIPv6, err := getIPv4AndIPv6UpdateData(common.BKAddressingDynamic, ipv4, ipv6)
+	Expect(err).NotTo(HaveOccurred())
+
+	setter, raw := parseV10Setter(&val, &hostInfo{addressing: common.BKAddressingDynamic,
+		updateIPv4: updateIPv4, updateIPv6: updateIPv6})
+	Expect(raw).To(MatchJSON(mustMarshal(setter)))
+
+	return mustMarshal(&snapParserGolden{AgentID: agentID, CloudID: cloudID, IPv4: ipv4, IPv6: ipv6, Host: setter})
+}
+
+func mustMarshal(v interface{}) []byte {
+	out, err := json.MarshalIndent(v, "", "    ")
+	Expect(err).NotTo(HaveOccurred())
+	return out
+}
+
+var _ = Describe("SnapParser", func() {
+	for _, msgType := range []string{nodeExporterSnapMsgType, osquerySnapMsgType} {
+		msgType := msgType
+		It("parse "+msgType+" message", func() {
+			msg, err := ioutil.ReadFile(filepath.Join("testdata", msgType+".json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			actual := parseSnapMsgToGolden(string(msg))
+
+			goldenFile := filepath.Join("testdata", msgType+".golden.json")
+			if *updateGolden {
+				Expect(ioutil.WriteFile(goldenFile, append(actual, '\n'), 0644)).To(Succeed())
+			}
+
+			expected, err := ioutil.ReadFile(goldenFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(MatchJSON(expected))
+		})
+	}
+
+	It("keep gse message as it is", func() {
+		snapMsg, err := parseSnapMsg(MockMessage)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapMsg).To(Equal(MockMessage))
+	})
+
+	It("reject unknown message type", func() {
+		_, err := parseSnapMsg(`{"bk_msg_type": "unknown"}`)
+		Expect(err).To(HaveOccurred())
+	})
+})
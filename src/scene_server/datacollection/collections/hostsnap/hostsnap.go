@@ -285,6 +285,8 @@ func (h *HostSnap) getHostDetail(header http.Header, rid, agentID, msg, sourceTy
 	return host, nil
 }
 
+// 0. The message reported by the collectors other than gse agent is converted to the standard message by the parser
+// registered for its message type, see SnapParser.
 // 1. Parse out the fields such as agentID, ip, cloudID, etc. from the reported message. The ipv4 field is a required
 // field.
 // 2. If the agentID is not empty, you need to use the agentID as the key to query the host. If the agentID is empty or
@@ -306,7 +308,14 @@ func (h *HostSnap) Analyze(msg *string, sourceType string) (bool, error) {
 
 	header, rid := newHeaderWithRid()
 
-	agentID, ipv4, ipv6, cloudID, val, err := getBaseInfoFromCollectorsMsg(msg, rid)
+	// convert the message reported by other collectors to the standard message, the gse message is kept as it is.
+	snapMsg, err := parseSnapMsg(*msg)
+	if err != nil {
+		blog.Errorf("parse host snapshot message failed, msg: %s, err: %v, rid: %s", *msg, err, rid)
+		return false, err
+	}
+
+	agentID, ipv4, ipv6, cloudID, val, err := getBaseInfoFromCollectorsMsg(&snapMsg, rid)
 	if err != nil {
 		blog.Errorf("parse base info failed, msg: %s, err: %v, rid: %s", *msg, err, rid)
 		return false, err
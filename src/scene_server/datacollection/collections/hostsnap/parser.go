@@ -0,0 +1,222 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package hostsnap
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/json"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// snapMsgTypeField is the field of the reported message that specifies which kind of collector reported it,
+	// messages without this field are reported by the gse agent.
+	snapMsgTypeField = "bk_msg_type"
+	// gseSnapMsgType is the message type of the host snapshot reported by the gse agent
+	gseSnapMsgType = "gse"
+	// standardSnapAPIVersion is the api version of the standard host snapshot message that all parsers convert to
+	standardSnapAPIVersion = "v1.0"
+)
+
+// SnapParser converts the host snapshot message reported by a kind of collector to the standard host snapshot
+// message, which is the same as the v1.0 snapshot message reported by the gse agent, so that the host fields
+// like cpu, mem, disk, os, ips and macs can be parsed in the same way for all the collectors.
+type SnapParser interface {
+	// Parse converts the reported message to the standard host snapshot message.
+	Parse(msg string) (string, error)
+}
+
+var (
+	snapParserLock sync.RWMutex
+	snapParsers    = make(map[string]SnapParser)
+)
+
+// RegisterSnapParser register the host snapshot parser of a message type, it is supposed to be called in init.
+func RegisterSnapParser(msgType string, parser SnapParser) {
+	snapParserLock.Lock()
+	defer snapParserLock.Unlock()
+
+	if parser == nil {
+		panic("host snapshot parser is nil")
+	}
+	if _, exists := snapParsers[msgType]; exists {
+		panic(fmt.Sprintf("host snapshot parser of message type %s is already registered", msgType))
+	}
+	snapParsers[msgType] = parser
+}
+
+// parseSnapMsg converts the reported message to the standard host snapshot message with the parser selected by the
+// message type of it.
+func parseSnapMsg(msg string) (string, error) {
+	msgType := gjson.Get(msg, snapMsgTypeField).String()
+	if msgType == "" {
+		msgType = gseSnapMsgType
+	}
+
+	snapParserLock.RLock()
+	parser, exists := snapParsers[msgType]
+	snapParserLock.RUnlock()
+	if !exists {
+		return "", fmt.Errorf("host snapshot message type %s is not supported", msgType)
+	}
+
+	return parser.Parse(msg)
+}
+
+func init() {
+	RegisterSnapParser(gseSnapMsgType, new(gseSnapParser))
+}
+
+// gseSnapParser is the parser of the host snapshot message reported by the gse agent, which is already the
+// standard message, the old and v1.0 version messages are both handled by the analyzer itself.
+type gseSnapParser struct{}
+
+// Parse returns the gse message as it is.
+func (g *gseSnapParser) Parse(msg string) (string, error) {
+	return msg, nil
+}
+
+// standardSnapMsg is the standard host snapshot message, see getBaseInfoFromCollectorsMsg and getHostInfoFromMsgV10
+// for how it is parsed.
+type standardSnapMsg struct {
+	AgentID string           `json:"bk_agent_id"`
+	CloudID int64            `json:"cloudid"`
+	Data    standardSnapData `json:"data"`
+}
+
+type standardSnapData struct {
+	APIVer    string             `json:"apiVer"`
+	Timestamp int64              `json:"timestamp"`
+	CPU       standardSnapCPU    `json:"cpu"`
+	Disk      standardSnapTotal  `json:"disk"`
+	Mem       standardSnapTotal  `json:"mem"`
+	System    standardSnapSystem `json:"system"`
+	Net       standardSnapNet    `json:"net"`
+}
+
+type standardSnapCPU struct {
+	Model string `json:"model"`
+	Total int64  `json:"total"`
+}
+
+// standardSnapTotal is the total size of disk or memory, the unit is byte.
+type standardSnapTotal struct {
+	Total uint64 `json:"total"`
+}
+
+type standardSnapSystem struct {
+	Hostname      string `json:"hostname"`
+	OS            string `json:"os"`
+	Platform      string `json:"platform"`
+	PlatVer       string `json:"platVer"`
+	Arch          string `json:"arch"`
+	KernelVersion string `json:"kernelVersion"`
+	SysType       string `json:"sysType"`
+}
+
+type standardSnapNet struct {
+	Interface []standardSnapInterface `json:"interface"`
+}
+
+type standardSnapInterface struct {
+	Name  string   `json:"name"`
+	MAC   string   `json:"mac"`
+	Addrs []string `json:"addrs"`
+}
+
+// newStandardSnapMsg new standard host snapshot message with the common fields of the reported message.
+func newStandardSnapMsg(msg *gjson.Result, timestamp int64) *standardSnapMsg {
+	if timestamp <= 0 {
+		timestamp = time.Now().Unix()
+	}
+
+	return &standardSnapMsg{
+		AgentID: strings.TrimSpace(msg.Get("bk_agent_id").String()),
+		CloudID: msg.Get("cloudid").Int(),
+		Data: standardSnapData{
+			APIVer:    standardSnapAPIVersion,
+			Timestamp: timestamp,
+			Net:       standardSnapNet{Interface: make([]standardSnapInterface, 0)},
+		},
+	}
+}
+
+// setInterfaces set the network interfaces of the message, macs and addrs are keyed by the interface name, the
+// interfaces are set in the order of names.
+func (s *standardSnapMsg) setInterfaces(names []string, macs map[string]string, addrs map[string][]string) {
+	for _, name := range names {
+		interfaceAddrs := addrs[name]
+		if interfaceAddrs == nil {
+			interfaceAddrs = make([]string, 0)
+		}
+		s.Data.Net.Interface = append(s.Data.Net.Interface, standardSnapInterface{
+			Name:  name,
+			MAC:   macs[name],
+			Addrs: interfaceAddrs,
+		})
+	}
+}
+
+func (s *standardSnapMsg) encode() (string, error) {
+	return json.MarshalToString(s)
+}
+
+// getOSBit get the os bit by the cpu architecture, returns empty if it is unknown.
+func getOSBit(arch string) string {
+	switch strings.ToLower(arch) {
+	case "x86_64", "amd64", "aarch64", "arm64", "ppc64", "ppc64le", "s390x", "mips64", "mips64le", "loongarch64":
+		return "64-bit"
+	case "i386", "i486", "i586", "i686", "x86", "arm", "armv6l", "armv7l", "mips", "mipsle":
+		return "32-bit"
+	default:
+		return ""
+	}
+}
+
+// pseudoFileSystems are the file systems that do not occupy the disk, they are not counted in the disk size.
+var pseudoFileSystems = map[string]struct{}{
+	"tmpfs": {}, "devtmpfs": {}, "overlay": {}, "squashfs": {}, "proc": {}, "sysfs": {}, "cgroup": {},
+	"cgroup2": {}, "devpts": {}, "mqueue": {}, "autofs": {}, "debugfs": {}, "tracefs": {}, "securityfs": {},
+	"pstore": {}, "bpf": {}, "configfs": {}, "fusectl": {}, "hugetlbfs": {}, "nsfs": {}, "rpc_pipefs": {},
+	"binfmt_misc": {}, "ramfs": {}, "iso9660": {}, "nfs": {}, "nfs4": {}, "cifs": {}, "fuse.lxcfs": {},
+}
+
+// diskCounter sums the size of the disk devices, the same device mounted on several paths is counted only once.
+type diskCounter struct {
+	devices map[string]struct{}
+	total   uint64
+}
+
+func newDiskCounter() *diskCounter {
+	return &diskCounter{devices: make(map[string]struct{})}
+}
+
+func (d *diskCounter) add(device, fsType string, size uint64) {
+	if _, exists := pseudoFileSystems[strings.ToLower(fsType)]; exists {
+		return
+	}
+	if _, exists := d.devices[device]; exists {
+		return
+	}
+	d.devices[device] = struct{}{}
+	d.total += size
+}
@@ -0,0 +1,116 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package hostsnap
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// nodeExporterSnapMsgType is the message type of the host snapshot reported by the node_exporter like agent
+const nodeExporterSnapMsgType = "node_exporter"
+
+func init() {
+	RegisterSnapParser(nodeExporterSnapMsgType, new(nodeExporterSnapParser))
+}
+
+// nodeExporterSnapParser is the parser of the host snapshot message reported by the node_exporter like agent, the
+// agent reports the samples of the node_exporter metrics, the message is like:
+//
+//	{
+//	    "bk_msg_type": "node_exporter",
+//	    "bk_agent_id": "xxx",
+//	    "cloudid": 0,
+//	    "timestamp": 1690000000,
+//	    "metrics": [
+//	        {"name": "node_uname_info", "labels": {"nodename": "xxx", "sysname": "Linux", ...}, "value": 1}
+//	    ]
+//	}
+//
+// the metrics used are node_uname_info, node_os_info, node_cpu_info, node_memory_MemTotal_bytes,
+// node_filesystem_size_bytes, node_network_info and node_network_address_info.
+type nodeExporterSnapParser struct{}
+
+// Parse converts the node_exporter like message to the standard host snapshot message.
+func (n *nodeExporterSnapParser) Parse(msg string) (string, error) {
+	val := gjson.Parse(msg)
+	metrics := val.Get("metrics")
+	if !metrics.IsArray() {
+		return "", errors.New("node_exporter message has no metrics")
+	}
+
+	snap := newStandardSnapMsg(&val, val.Get("timestamp").Int())
+	disks := newDiskCounter()
+	cpus := make(map[string]struct{})
+	interfaces := make([]string, 0)
+	macs, addrs := make(map[string]string), make(map[string][]string)
+	addInterface := func(device string) {
+		if _, exists := macs[device]; exists {
+			return
+		}
+		if _, exists := addrs[device]; exists {
+			return
+		}
+		interfaces = append(interfaces, device)
+	}
+
+	for _, metric := range metrics.Array() {
+		labels := metric.Get("labels")
+		switch metric.Get("name").String() {
+		case "node_uname_info":
+			snap.Data.System.Hostname = strings.TrimSpace(labels.Get("nodename").String())
+			snap.Data.System.OS = strings.ToLower(strings.TrimSpace(labels.Get("sysname").String()))
+			snap.Data.System.KernelVersion = strings.TrimSpace(labels.Get("release").String())
+			snap.Data.System.Arch = strings.TrimSpace(labels.Get("machine").String())
+			snap.Data.System.SysType = getOSBit(snap.Data.System.Arch)
+
+		case "node_os_info":
+			snap.Data.System.Platform = strings.TrimSpace(labels.Get("id").String())
+			snap.Data.System.PlatVer = strings.TrimSpace(labels.Get("version_id").String())
+
+		case "node_cpu_info":
+			cpus[labels.Get("cpu").String()] = struct{}{}
+			if snap.Data.CPU.Model == "" {
+				snap.Data.CPU.Model = strings.TrimSpace(labels.Get("model_name").String())
+			}
+
+		case "node_memory_MemTotal_bytes":
+			snap.Data.Mem.Total = metric.Get("value").Uint()
+
+		case "node_filesystem_size_bytes":
+			disks.add(labels.Get("device").String(), labels.Get("fstype").String(), metric.Get("value").Uint())
+
+		case "node_network_info":
+			device := labels.Get("device").String()
+			addInterface(device)
+			macs[device] = strings.TrimSpace(labels.Get("address").String())
+
+		case "node_network_address_info":
+			device := labels.Get("device").String()
+			addInterface(device)
+			addrs[device] = append(addrs[device], strings.TrimSpace(labels.Get("address").String()))
+		}
+	}
+
+	snap.Data.CPU.Total = int64(len(cpus))
+	snap.Data.Disk.Total = disks.total
+	snap.setInterfaces(interfaces, macs, addrs)
+
+	return snap.encode()
+}
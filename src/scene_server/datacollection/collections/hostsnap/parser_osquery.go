@@ -0,0 +1,118 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package hostsnap
+
+import (
+	"errors"
+	"strings"
+
+	"configcenter/src/common"
+
+	"github.com/tidwall/gjson"
+)
+
+// osquerySnapMsgType is the message type of the host snapshot reported by osquery
+const osquerySnapMsgType = "osquery"
+
+func init() {
+	RegisterSnapParser(osquerySnapMsgType, new(osquerySnapParser))
+}
+
+// osquerySnapParser is the parser of the host snapshot message reported by osquery, the message contains the query
+// results of the osquery tables, the values of the columns are all strings as osquery outputs, the message is like:
+//
+//	{
+//	    "bk_msg_type": "osquery",
+//	    "bk_agent_id": "xxx",
+//	    "cloudid": 0,
+//	    "unixTime": 1690000000,
+//	    "results": {
+//	        "system_info": [{"hostname": "xxx", "cpu_brand": "xxx", "cpu_logical_cores": "8", ...}]
+//	    }
+//	}
+//
+// the tables used are system_info, os_version, kernel_info, mounts, interface_details and interface_addresses.
+type osquerySnapParser struct{}
+
+// Parse converts the osquery message to the standard host snapshot message.
+func (o *osquerySnapParser) Parse(msg string) (string, error) {
+	val := gjson.Parse(msg)
+	results := val.Get("results")
+	if !results.IsObject() {
+		return "", errors.New("osquery message has no query results")
+	}
+
+	snap := newStandardSnapMsg(&val, val.Get("unixTime").Int())
+
+	systemInfo := results.Get("system_info.0")
+	snap.Data.System.Hostname = strings.TrimSpace(systemInfo.Get("hostname").String())
+	snap.Data.CPU.Model = strings.TrimSpace(systemInfo.Get("cpu_brand").String())
+	snap.Data.CPU.Total = systemInfo.Get("cpu_logical_cores").Int()
+	snap.Data.Mem.Total = systemInfo.Get("physical_memory").Uint()
+
+	osVersion := results.Get("os_version.0")
+	platform := strings.ToLower(strings.TrimSpace(osVersion.Get("platform").String()))
+	snap.Data.System.PlatVer = strings.TrimSpace(osVersion.Get("version").String())
+	snap.Data.System.Arch = strings.TrimSpace(osVersion.Get("arch").String())
+	if snap.Data.System.Arch == "" {
+		snap.Data.System.Arch = strings.TrimSpace(systemInfo.Get("cpu_type").String())
+	}
+	snap.Data.System.SysType = getOSBit(snap.Data.System.Arch)
+	snap.Data.System.KernelVersion = strings.TrimSpace(results.Get("kernel_info.0.version").String())
+
+	// osquery uses the platform column to distinguish windows, darwin and freebsd, and the distribution for linux
+	switch platform {
+	case common.HostOSTypeName[common.HostOSTypeEnumWindows]:
+		snap.Data.System.OS = common.HostOSTypeName[common.HostOSTypeEnumWindows]
+		snap.Data.System.Platform = strings.TrimSpace(osVersion.Get("name").String())
+	case common.HostOSTypeName[common.HostOSTypeEnumMacOS], common.HostOSTypeName[common.HostOSTypeEnumFreeBSD]:
+		snap.Data.System.OS = platform
+		snap.Data.System.Platform = strings.TrimSpace(osVersion.Get("name").String())
+	default:
+		snap.Data.System.OS = common.HostOSTypeName[common.HostOSTypeEnumLinux]
+		snap.Data.System.Platform = platform
+	}
+
+	disks := newDiskCounter()
+	for _, mount := range results.Get("mounts").Array() {
+		size := mount.Get("blocks").Uint() * mount.Get("blocks_size").Uint()
+		disks.add(mount.Get("device").String(), mount.Get("type").String(), size)
+	}
+	snap.Data.Disk.Total = disks.total
+
+	interfaces := make([]string, 0)
+	macs, addrs := make(map[string]string), make(map[string][]string)
+	for _, detail := range results.Get("interface_details").Array() {
+		name := detail.Get("interface").String()
+		if _, exists := macs[name]; !exists {
+			interfaces = append(interfaces, name)
+		}
+		macs[name] = strings.TrimSpace(detail.Get("mac").String())
+	}
+	for _, address := range results.Get("interface_addresses").Array() {
+		name := address.Get("interface").String()
+		if _, exists := macs[name]; !exists {
+			if _, exists := addrs[name]; !exists {
+				interfaces = append(interfaces, name)
+			}
+		}
+		addrs[name] = append(addrs[name], strings.TrimSpace(address.Get("address").String()))
+	}
+	snap.setInterfaces(interfaces, macs, addrs)
+
+	return snap.encode()
+}
@@ -0,0 +1,436 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kubecollector
+
+import (
+	"errors"
+	"fmt"
+
+	"configcenter/pkg/filter"
+	filtertools "configcenter/pkg/tools/filter"
+	"configcenter/src/apimachinery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/kube/types"
+
+	"github.com/tidwall/gjson"
+)
+
+// cmdbClient is the client that the kube collector uses to operate the kube resources in cmdb.
+type cmdbClient interface {
+	// GetCluster get the cluster registered in cmdb by biz id and cluster uid.
+	GetCluster(kit *rest.Kit, bizID int64, uid string) (*types.Cluster, error)
+	// GetHostID get the host id by the inner ips of the node, returns 0 if the host is not found.
+	GetHostID(kit *rest.Kit, cloudID int64, ips []string) (int64, error)
+
+	ListNodes(kit *rest.Kit, bizID, clusterID int64) ([]types.Node, error)
+	CreateNodes(kit *rest.Kit, bizID int64, nodes []types.OneNodeCreateOption) error
+	UpdateNode(kit *rest.Kit, bizID, id int64, node *types.Node) error
+	DeleteNodes(kit *rest.Kit, bizID int64, ids []int64) error
+
+	ListNamespaces(kit *rest.Kit, bizID, clusterID int64) ([]types.Namespace, error)
+	CreateNamespaces(kit *rest.Kit, bizID int64, namespaces []types.Namespace) error
+	UpdateNamespace(kit *rest.Kit, bizID, id int64, ns *types.Namespace) error
+	DeleteNamespaces(kit *rest.Kit, bizID int64, ids []int64) error
+
+	ListWorkloads(kit *rest.Kit, bizID, clusterID int64, kind types.WorkloadType) ([]types.WorkloadInterface, error)
+	CreateWorkloads(kit *rest.Kit, bizID int64, kind types.WorkloadType, wls []types.WorkloadInterface) error
+	UpdateWorkload(kit *rest.Kit, bizID int64, kind types.WorkloadType, id int64, wl types.WorkloadInterface) error
+	DeleteWorkloads(kit *rest.Kit, bizID int64, kind types.WorkloadType, ids []int64) error
+
+	ListPods(kit *rest.Kit, bizID, clusterID int64) ([]types.Pod, error)
+	ListContainers(kit *rest.Kit, bizID, clusterID int64) ([]types.Container, error)
+	CreatePods(kit *rest.Kit, bizID int64, pods []types.PodsInfo) error
+	DeletePods(kit *rest.Kit, bizID int64, ids []int64) error
+}
+
+const (
+	// nodeBatchLimit is the max number of nodes that can be created in one request.
+	nodeBatchLimit = 100
+	// writeBatchLimit is the max number of namespaces, workloads or pods that can be created or deleted in one request.
+	writeBatchLimit = 200
+	// queryPageLimit is the page size of the kube resources query.
+	queryPageLimit = 500
+)
+
+// topoClient is the cmdbClient implemented by the topo server kube apis.
+type topoClient struct {
+	clientSet apimachinery.ClientSetInterface
+}
+
+// newTopoClient new cmdbClient that operates the kube resources by topo server.
+func newTopoClient(clientSet apimachinery.ClientSetInterface) cmdbClient {
+	return &topoClient{clientSet: clientSet}
+}
+
+func clusterFilter(clusterID int64) *filter.Expression {
+	return filtertools.GenAtomFilter(types.BKClusterIDFiled, filter.Equal, clusterID)
+}
+
+// GetCluster get the cluster registered in cmdb by biz id and cluster uid.
+func (t *topoClient) GetCluster(kit *rest.Kit, bizID int64, uid string) (*types.Cluster, error) {
+	opt := &types.QueryClusterOption{
+		BizID:  bizID,
+		Filter: filtertools.GenAtomFilter(types.UidField, filter.Equal, uid),
+		Page:   metadata.BasePage{Limit: 1},
+	}
+
+	resp, err := t.clientSet.TopoServer().Kube().SearchCluster(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("search cluster failed, biz: %d, uid: %s, err: %v, rid: %s", bizID, uid, err, kit.Rid)
+		return nil, err
+	}
+
+	clusters := make([]types.Cluster, 0)
+	if err := decodeInfo(resp.Data, &clusters); err != nil {
+		blog.Errorf("decode cluster failed, biz: %d, uid: %s, err: %v, rid: %s", bizID, uid, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("cluster %s is not registered in biz %d", uid, bizID)
+	}
+	return &clusters[0], nil
+}
+
+// GetHostID get the host id by the inner ips of the node, returns 0 if the host is not found.
+func (t *topoClient) GetHostID(kit *rest.Kit, cloudID int64, ips []string) (int64, error) {
+	for _, ip := range ips {
+		opt := &metadata.SearchHostWithInnerIPOption{
+			InnerIP: ip,
+			CloudID: cloudID,
+			Fields:  []string{common.BKHostIDField},
+		}
+
+		host, err := t.clientSet.CacheService().Cache().Host().SearchHostWithInnerIPForStatic(kit.Ctx, kit.Header,
+			opt)
+		if err != nil {
+			blog.Errorf("get host with ip: %s, cloud id: %d failed, err: %v, rid: %s", ip, cloudID, err, kit.Rid)
+			return 0, err
+		}
+
+		if hostID := gjson.Get(host, common.BKHostIDField).Int(); hostID > 0 {
+			return hostID, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// ListNodes list all the nodes of the cluster.
+func (t *topoClient) ListNodes(kit *rest.Kit, bizID, clusterID int64) ([]types.Node, error) {
+	all := make([]types.Node, 0)
+	for start := 0; ; start += queryPageLimit {
+		opt := &types.QueryNodeOption{
+			BizID:  bizID,
+			Filter: clusterFilter(clusterID),
+			Page:   metadata.BasePage{Start: start, Limit: queryPageLimit, Sort: common.BKFieldID},
+		}
+
+		resp, err := t.clientSet.TopoServer().Kube().SearchNode(kit.Ctx, kit.Header, opt)
+		if err != nil {
+			blog.Errorf("search node failed, cluster: %d, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		nodes := make([]types.Node, 0)
+		if err := decodeInfo(resp.Data, &nodes); err != nil {
+			blog.Errorf("decode node failed, cluster: %d, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		all = append(all, nodes...)
+		if len(nodes) < queryPageLimit {
+			return all, nil
+		}
+	}
+}
+
+// CreateNodes create nodes.
+func (t *topoClient) CreateNodes(kit *rest.Kit, bizID int64, nodes []types.OneNodeCreateOption) error {
+	opt := &types.CreateNodesOption{BizID: bizID, Nodes: nodes}
+	if _, err := t.clientSet.TopoServer().Kube().BatchCreateNode(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("create nodes failed, biz: %d, err: %v, rid: %s", bizID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// UpdateNode update the node by id.
+func (t *topoClient) UpdateNode(kit *rest.Kit, bizID, id int64, node *types.Node) error {
+	opt := &types.UpdateNodeOption{
+		BizID:                 bizID,
+		UpdateNodeByIDsOption: types.UpdateNodeByIDsOption{IDs: []int64{id}, Data: *node},
+	}
+	if err := t.clientSet.TopoServer().Kube().UpdateNodeFields(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("update node %d failed, biz: %d, err: %v, rid: %s", id, bizID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// DeleteNodes delete nodes by ids.
+func (t *topoClient) DeleteNodes(kit *rest.Kit, bizID int64, ids []int64) error {
+	opt := &types.BatchDeleteNodeOption{
+		BizID:                      bizID,
+		BatchDeleteNodeByIDsOption: types.BatchDeleteNodeByIDsOption{IDs: ids},
+	}
+	if err := t.clientSet.TopoServer().Kube().BatchDeleteNode(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("delete nodes %v failed, biz: %d, err: %v, rid: %s", ids, bizID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// ListNamespaces list all the namespaces of the cluster.
+func (t *topoClient) ListNamespaces(kit *rest.Kit, bizID, clusterID int64) ([]types.Namespace, error) {
+	all := make([]types.Namespace, 0)
+	for start := 0; ; start += queryPageLimit {
+		opt := &types.NsQueryOption{
+			BizID:  bizID,
+			Filter: clusterFilter(clusterID),
+			Page:   metadata.BasePage{Start: start, Limit: queryPageLimit, Sort: common.BKFieldID},
+		}
+
+		resp, err := t.clientSet.TopoServer().Kube().ListNamespace(kit.Ctx, kit.Header, opt)
+		if err != nil {
+			blog.Errorf("list namespace failed, cluster: %d, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		namespaces := make([]types.Namespace, 0)
+		if err := decodeMapStr(resp.Info, &namespaces); err != nil {
+			blog.Errorf("decode namespace failed, cluster: %d, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		all = append(all, namespaces...)
+		if len(namespaces) < queryPageLimit {
+			return all, nil
+		}
+	}
+}
+
+// CreateNamespaces create namespaces.
+func (t *topoClient) CreateNamespaces(kit *rest.Kit, bizID int64, namespaces []types.Namespace) error {
+	opt := &types.NsCreateOption{BizID: bizID, Data: namespaces}
+	if _, err := t.clientSet.TopoServer().Kube().CreateNamespace(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("create namespaces failed, biz: %d, err: %v, rid: %s", bizID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// UpdateNamespace update the namespace by id.
+func (t *topoClient) UpdateNamespace(kit *rest.Kit, bizID, id int64, ns *types.Namespace) error {
+	opt := &types.NsUpdateOption{
+		BizID:               bizID,
+		NsUpdateByIDsOption: types.NsUpdateByIDsOption{IDs: []int64{id}, Data: ns},
+	}
+	if err := t.clientSet.TopoServer().Kube().UpdateNamespace(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("update namespace %d failed, biz: %d, err: %v, rid: %s", id, bizID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// DeleteNamespaces delete namespaces by ids.
+func (t *topoClient) DeleteNamespaces(kit *rest.Kit, bizID int64, ids []int64) error {
+	opt := &types.NsDeleteOption{BizID: bizID, NsDeleteByIDsOption: types.NsDeleteByIDsOption{IDs: ids}}
+	if err := t.clientSet.TopoServer().Kube().DeleteNamespace(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("delete namespaces %v failed, biz: %d, err: %v, rid: %s", ids, bizID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// ListWorkloads list all the workloads of the kind in the cluster.
+func (t *topoClient) ListWorkloads(kit *rest.Kit, bizID, clusterID int64, kind types.WorkloadType) (
+	[]types.WorkloadInterface, error) {
+
+	all := make([]types.WorkloadInterface, 0)
+	for start := 0; ; start += queryPageLimit {
+		opt := &types.WlQueryOption{
+			BizID:  bizID,
+			Filter: clusterFilter(clusterID),
+			Page:   metadata.BasePage{Start: start, Limit: queryPageLimit, Sort: common.BKFieldID},
+		}
+
+		resp, err := t.clientSet.TopoServer().Kube().ListWorkload(kit.Ctx, kit.Header, kind, opt)
+		if err != nil {
+			blog.Errorf("list %s workload failed, cluster: %d, err: %v, rid: %s", kind, clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		js, jsErr := json.Marshal(resp.Info)
+		if jsErr != nil {
+			return nil, jsErr
+		}
+
+		workloads, jsErr := types.WlArrayUnmarshalJSON(kind, js)
+		if jsErr != nil {
+			blog.Errorf("decode %s workload failed, cluster: %d, err: %v, rid: %s", kind, clusterID, jsErr, kit.Rid)
+			return nil, jsErr
+		}
+
+		all = append(all, workloads...)
+		if len(workloads) < queryPageLimit {
+			return all, nil
+		}
+	}
+}
+
+// CreateWorkloads create workloads of the kind.
+func (t *topoClient) CreateWorkloads(kit *rest.Kit, bizID int64, kind types.WorkloadType,
+	wls []types.WorkloadInterface) error {
+
+	opt := &types.WlCreateOption{BizID: bizID, Kind: kind, Data: wls}
+	if _, err := t.clientSet.TopoServer().Kube().CreateWorkload(kit.Ctx, kit.Header, kind, opt); err != nil {
+		blog.Errorf("create %s workloads failed, biz: %d, err: %v, rid: %s", kind, bizID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// UpdateWorkload update the workload of the kind by id.
+func (t *topoClient) UpdateWorkload(kit *rest.Kit, bizID int64, kind types.WorkloadType, id int64,
+	wl types.WorkloadInterface) error {
+
+	opt := &types.WlUpdateOption{
+		BizID:               bizID,
+		WlUpdateByIDsOption: types.WlUpdateByIDsOption{Kind: kind, IDs: []int64{id}, Data: wl},
+	}
+	if err := t.clientSet.TopoServer().Kube().UpdateWorkload(kit.Ctx, kit.Header, kind, opt); err != nil {
+		blog.Errorf("update %s workload %d failed, biz: %d, err: %v, rid: %s", kind, id, bizID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// DeleteWorkloads delete workloads of the kind by ids.
+func (t *topoClient) DeleteWorkloads(kit *rest.Kit, bizID int64, kind types.WorkloadType, ids []int64) error {
+	opt := &types.WlDeleteOption{BizID: bizID, WlDeleteByIDsOption: types.WlDeleteByIDsOption{IDs: ids}}
+	if err := t.clientSet.TopoServer().Kube().DeleteWorkload(kit.Ctx, kit.Header, kind, opt); err != nil {
+		blog.Errorf("delete %s workloads %v failed, biz: %d, err: %v, rid: %s", kind, ids, bizID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// ListPods list all the pods of the cluster.
+func (t *topoClient) ListPods(kit *rest.Kit, bizID, clusterID int64) ([]types.Pod, error) {
+	all := make([]types.Pod, 0)
+	for start := 0; ; start += queryPageLimit {
+		opt := &types.PodQueryOption{
+			BizID:  bizID,
+			Filter: clusterFilter(clusterID),
+			Page:   metadata.BasePage{Start: start, Limit: queryPageLimit, Sort: common.BKFieldID},
+		}
+
+		resp, err := t.clientSet.TopoServer().Kube().ListPod(kit.Ctx, kit.Header, opt)
+		if err != nil {
+			blog.Errorf("list pod failed, cluster: %d, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		pods := make([]types.Pod, 0)
+		if err := decodeMapStr(resp.Info, &pods); err != nil {
+			blog.Errorf("decode pod failed, cluster: %d, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		all = append(all, pods...)
+		if len(pods) < queryPageLimit {
+			return all, nil
+		}
+	}
+}
+
+// ListContainers list all the containers of the cluster.
+func (t *topoClient) ListContainers(kit *rest.Kit, bizID, clusterID int64) ([]types.Container, error) {
+	all := make([]types.Container, 0)
+	for start := 0; ; start += queryPageLimit {
+		opt := &types.ContainerQueryOption{
+			BizID:  bizID,
+			Filter: clusterFilter(clusterID),
+			Page:   metadata.BasePage{Start: start, Limit: queryPageLimit, Sort: common.BKFieldID},
+		}
+
+		resp, err := t.clientSet.TopoServer().Kube().ListContainer(kit.Ctx, kit.Header, opt)
+		if err != nil {
+			blog.Errorf("list container failed, cluster: %d, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		containers := make([]types.Container, 0)
+		if err := decodeMapStr(resp.Info, &containers); err != nil {
+			blog.Errorf("decode container failed, cluster: %d, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		all = append(all, containers...)
+		if len(containers) < queryPageLimit {
+			return all, nil
+		}
+	}
+}
+
+// CreatePods create pods with their containers.
+func (t *topoClient) CreatePods(kit *rest.Kit, bizID int64, pods []types.PodsInfo) error {
+	opt := &types.CreatePodsOption{Data: []types.PodsInfoArray{{BizID: bizID, Pods: pods}}}
+	if _, err := t.clientSet.TopoServer().Kube().BatchCreatePod(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("create pods failed, biz: %d, err: %v, rid: %s", bizID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// DeletePods delete pods and their containers by ids.
+func (t *topoClient) DeletePods(kit *rest.Kit, bizID int64, ids []int64) error {
+	opt := &types.DeletePodsOption{Data: []types.DeletePodData{{BizID: bizID, PodIDs: ids}}}
+	if err := t.clientSet.TopoServer().Kube().DeletePods(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("delete pods %v failed, biz: %d, err: %v, rid: %s", ids, bizID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// decodeInfo decodes the info field of the response data, which is like {"count": 0, "info": []}.
+func decodeInfo(data interface{}, result interface{}) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	info := gjson.GetBytes(js, "info")
+	if !info.Exists() {
+		return errors.New("info field is not found in response data")
+	}
+	return json.Unmarshal([]byte(info.Raw), result)
+}
+
+func decodeMapStr(data []mapstr.MapStr, result interface{}) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(js, result)
+}
@@ -0,0 +1,256 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package kubecollector is the built-in kube collector, it watches the registered clusters with informers and
+// reconciles their nodes, namespaces, workloads and pods into cmdb.
+package kubecollector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/util"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// masterCheckInterval is the interval to check if this instance is the master.
+	masterCheckInterval = 10 * time.Second
+	// clientRetryInterval is the interval to retry when the cluster can not be watched.
+	clientRetryInterval = time.Minute
+)
+
+// Collector collects the kube resources of the configured clusters into cmdb, only the master instance collects.
+type Collector struct {
+	conf       *Config
+	cmdb       cmdbClient
+	isMaster   func() bool
+	newClients clientFactory
+	clusters   []*clusterCollector
+}
+
+// NewCollector new kube collector.
+func NewCollector(conf *Config, clientSet apimachinery.ClientSetInterface, isMaster func() bool) *Collector {
+	return newCollector(conf, newTopoClient(clientSet), isMaster, newKubeClients)
+}
+
+func newCollector(conf *Config, cmdb cmdbClient, isMaster func() bool, newClients clientFactory) *Collector {
+	c := &Collector{conf: conf, cmdb: cmdb, isMaster: isMaster, newClients: newClients}
+	for idx := range conf.Clusters {
+		c.clusters = append(c.clusters, newClusterCollector(conf, &conf.Clusters[idx], cmdb, newClients))
+	}
+	return c
+}
+
+// Run runs the kube collector until the context is done, the clusters are collected when this instance becomes
+// the master, and stopped collecting when it is no longer the master.
+func (c *Collector) Run(ctx context.Context) {
+	var cancel context.CancelFunc
+	wg := new(sync.WaitGroup)
+	stop := func() {
+		if cancel == nil {
+			return
+		}
+		cancel()
+		wg.Wait()
+		cancel = nil
+		for _, cluster := range c.clusters {
+			cluster.status.setState(ClusterStateStopped)
+		}
+	}
+
+	ticker := time.NewTicker(masterCheckInterval)
+	defer ticker.Stop()
+	for {
+		isMaster := c.isMaster()
+		if isMaster && cancel == nil {
+			blog.Infof("kube collector starts to collect %d clusters", len(c.clusters))
+			var runCtx context.Context
+			runCtx, cancel = context.WithCancel(ctx)
+			for _, cluster := range c.clusters {
+				wg.Add(1)
+				go func(cluster *clusterCollector) {
+					defer wg.Done()
+					cluster.run(runCtx)
+				}(cluster)
+			}
+		}
+
+		if !isMaster && cancel != nil {
+			blog.Infof("kube collector is not master any more, stop collecting")
+			stop()
+		}
+
+		select {
+		case <-ctx.Done():
+			stop()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the collecting status of all the configured clusters.
+func (c *Collector) Status() []ClusterStatus {
+	statuses := make([]ClusterStatus, 0, len(c.clusters))
+	for _, cluster := range c.clusters {
+		statuses = append(statuses, cluster.status.get())
+	}
+	return statuses
+}
+
+// clusterCollector collects the kube resources of a cluster.
+type clusterCollector struct {
+	conf       *Config
+	cluster    *ClusterConfig
+	reconciler *reconciler
+	newClients clientFactory
+	status     *statusStore
+	// trigger is notified when the kube resources of the cluster are changed.
+	trigger chan struct{}
+}
+
+func newClusterCollector(conf *Config, cluster *ClusterConfig, cmdb cmdbClient,
+	newClients clientFactory) *clusterCollector {
+
+	return &clusterCollector{
+		conf:       conf,
+		cluster:    cluster,
+		reconciler: &reconciler{cluster: cluster, conf: conf, cmdb: cmdb},
+		newClients: newClients,
+		status:     newStatusStore(cluster),
+		trigger:    make(chan struct{}, 1),
+	}
+}
+
+// run watches the cluster and reconciles it when its resources are changed or the resync interval is reached.
+func (c *clusterCollector) run(ctx context.Context) {
+	c.status.setState(ClusterStatePending)
+
+	var informers *clusterInformers
+	for informers == nil {
+		var err error
+		informers, err = c.startInformers(ctx)
+		if err != nil {
+			blog.Errorf("start informers of cluster %s failed, err: %v", c.cluster.ClusterUID, err)
+			c.status.setFailed(err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(clientRetryInterval):
+			}
+		}
+	}
+
+	// the events of the initial listing are covered by the first reconciliation.
+	c.drainTrigger()
+	c.sync(ctx, informers)
+
+	ticker := time.NewTicker(c.conf.resyncInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.trigger:
+			// wait for a while, so that a burst of events only causes one reconciliation.
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.conf.debounce()):
+			}
+			c.drainTrigger()
+		case <-ticker.C:
+		}
+
+		c.sync(ctx, informers)
+	}
+}
+
+func (c *clusterCollector) startInformers(ctx context.Context) (*clusterInformers, error) {
+	clients, err := c.newClients(c.cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { c.notify() },
+		UpdateFunc: func(interface{}, interface{}) { c.notify() },
+		DeleteFunc: func(interface{}) { c.notify() },
+	}
+
+	informers := newClusterInformers(clients, c.conf.resyncInterval(), handler, util.GenerateRID())
+	if err := informers.start(ctx); err != nil {
+		return nil, err
+	}
+	return informers, nil
+}
+
+func (c *clusterCollector) notify() {
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (c *clusterCollector) drainTrigger() {
+	select {
+	case <-c.trigger:
+	default:
+	}
+}
+
+// sync reconciles the resources of the cluster in the informer caches into cmdb, and records the result.
+func (c *clusterCollector) sync(ctx context.Context, informers *clusterInformers) {
+	kit := c.newKit(ctx)
+	c.status.setState(ClusterStateSyncing)
+
+	snap, err := informers.snapshot()
+	if err != nil {
+		blog.Errorf("read resources of cluster %s failed, err: %v, rid: %s", c.cluster.ClusterUID, err, kit.Rid)
+		c.status.setFailed(err)
+		return
+	}
+
+	start := time.Now()
+	result, err := c.reconciler.reconcile(kit, snap)
+	if err != nil {
+		blog.Errorf("reconcile cluster %s failed, err: %v, rid: %s", c.cluster.ClusterUID, err, kit.Rid)
+		c.status.setFailed(err)
+		return
+	}
+
+	blog.Infof("reconcile cluster %s success, cost: %s, counts: %v, rid: %s", c.cluster.ClusterUID,
+		time.Since(start), result.counts, kit.Rid)
+	c.status.setSynced(result)
+}
+
+func (c *clusterCollector) newKit(ctx context.Context) *rest.Kit {
+	rid := util.GenerateRID()
+	return &rest.Kit{
+		Rid:             rid,
+		Header:          headerutil.GenCommonHeader(c.conf.User, c.conf.SupplierAccount, rid),
+		Ctx:             ctx,
+		User:            c.conf.User,
+		SupplierAccount: c.conf.SupplierAccount,
+	}
+}
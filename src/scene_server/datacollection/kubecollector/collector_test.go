@@ -0,0 +1,365 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kubecollector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"configcenter/src/kube/types"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	testBizID      = int64(2)
+	testClusterUID = "BCS-K8S-00001"
+	testClusterID  = int64(1000)
+)
+
+func newTestConfig() *Config {
+	conf := &Config{
+		Enabled:  true,
+		Clusters: []ClusterConfig{{BizID: testBizID, ClusterUID: testClusterUID, Kubeconfig: "/tmp/kubeconfig"}},
+	}
+	if err := conf.Validate(); err != nil {
+		panic(err)
+	}
+	return conf
+}
+
+func newTestCmdb() *fakeCmdb {
+	cmdb := newFakeCmdb()
+	uid := testClusterUID
+	cmdb.clusters[uid] = &types.Cluster{ID: testClusterID, BizID: testBizID, Uid: &uid}
+	cmdb.hosts["10.0.0.1"] = 100
+	cmdb.nextID = testClusterID
+	return cmdb
+}
+
+func ownerRef(kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+}
+
+func newTestNode(name, ip string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{nodeRoleLabelPrefix + "worker": ""}},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: ip}},
+		},
+	}
+}
+
+func newTestPod(name, nodeName, containerID string, owners []metav1.OwnerReference) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", OwnerReferences: owners},
+		Spec: corev1.PodSpec{
+			NodeName:   nodeName,
+			Containers: []corev1.Container{{Name: "main", Image: "nginx:1.20"}},
+		},
+		Status: corev1.PodStatus{
+			PodIP: "172.16.0.1",
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:        "main",
+				Image:       "nginx:1.20",
+				ContainerID: containerID,
+				State: corev1.ContainerState{
+					Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(time.Unix(1650000000, 0))},
+				},
+			}},
+		},
+	}
+}
+
+// newTestObjects returns the kube resources of the test cluster:
+// a deployment pod and a cronjob pod on node-1, a bare pod on node-1, a pod on node-2 that has no host, and a pod
+// that is not scheduled yet.
+func newTestObjects() []runtime.Object {
+	replicas := int32(1)
+	return []runtime.Object{
+		newTestNode("node-1", "10.0.0.1"),
+		newTestNode("node-2", "10.0.0.2"),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-5d8f", Namespace: "default",
+				OwnerReferences: ownerRef("Deployment", "web")},
+		},
+		&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+			Spec:       batchv1.CronJobSpec{Schedule: "0 * * * *"},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-2760", Namespace: "default",
+				OwnerReferences: ownerRef("CronJob", "backup")},
+		},
+		newTestPod("web-5d8f-x1", "node-1", "containerd://c1", ownerRef("ReplicaSet", "web-5d8f")),
+		newTestPod("backup-2760-y1", "node-1", "containerd://c2", ownerRef("Job", "backup-2760")),
+		newTestPod("debug", "node-1", "containerd://c3", nil),
+		newTestPod("web-5d8f-x2", "node-2", "containerd://c4", ownerRef("ReplicaSet", "web-5d8f")),
+		newTestPod("web-5d8f-x3", "", "", ownerRef("ReplicaSet", "web-5d8f")),
+	}
+}
+
+func startTestInformers(t *testing.T, ctx context.Context, client *fake.Clientset) *clusterInformers {
+	informers := newClusterInformers(&kubeClients{client: client}, 0, cache.ResourceEventHandlerFuncs{}, "test")
+	if err := informers.start(ctx); err != nil {
+		t.Fatalf("start informers failed, err: %v", err)
+	}
+	return informers
+}
+
+// waitFor waits until the condition of the informer caches is satisfied, the informers are updated asynchronously.
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("wait for informer caches timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := fake.NewSimpleClientset(newTestObjects()...)
+	informers := startTestInformers(t, ctx, client)
+
+	conf := newTestConfig()
+	cmdb := newTestCmdb()
+	collector := newClusterCollector(conf, &conf.Clusters[0], cmdb, nil)
+	kit := collector.newKit(ctx)
+
+	snap, err := informers.snapshot()
+	if err != nil {
+		t.Fatalf("get snapshot failed, err: %v", err)
+	}
+
+	result, err := collector.reconciler.reconcile(kit, snap)
+	if err != nil {
+		t.Fatalf("reconcile failed, err: %v", err)
+	}
+
+	if result.clusterID != testClusterID {
+		t.Errorf("cluster id %d is not %d", result.clusterID, testClusterID)
+	}
+	if result.skippedPods != 2 {
+		t.Errorf("skipped pods %d is not 2", result.skippedPods)
+	}
+	expectCounts := map[string]int{types.KubeNode: 2, types.KubeNamespace: 2, types.KubeWorkload: 4, types.KubePod: 3}
+	for kind, count := range expectCounts {
+		if result.counts[kind] != count {
+			t.Errorf("%s count %d is not %d", kind, result.counts[kind], count)
+		}
+		if result.changes[kind] == nil || result.changes[kind].Created != count {
+			t.Errorf("%s created changes %+v is not %d", kind, result.changes[kind], count)
+		}
+	}
+
+	refs := map[string]types.WorkloadType{
+		"web-5d8f-x1":    types.KubeDeployment,
+		"backup-2760-y1": types.KubeCronJob,
+		"debug":          types.KubePodWorkload,
+	}
+	for name, kind := range refs {
+		pod := cmdb.podByName("default", name)
+		if pod == nil {
+			t.Fatalf("pod %s is not created", name)
+		}
+		if pod.Ref == nil || pod.Ref.Kind != kind || pod.Ref.ID == 0 {
+			t.Errorf("pod %s ref %+v is not %s workload", name, pod.Ref, kind)
+		}
+		if pod.HostID != 100 {
+			t.Errorf("pod %s host id %d is not 100", name, pod.HostID)
+		}
+	}
+	if cmdb.workloadByName(types.KubeJob, "default", "backup-2760") == nil {
+		t.Errorf("job backup-2760 is not created")
+	}
+
+	// reconcile again without any change
+	result, err = collector.reconciler.reconcile(kit, snap)
+	if err != nil {
+		t.Fatalf("reconcile again failed, err: %v", err)
+	}
+	for kind, change := range result.changes {
+		if *change != (resourceChanges{}) {
+			t.Errorf("%s is changed %+v without any kube resource change", kind, change)
+		}
+	}
+
+	// scale the deployment, delete the bare pod and restart the container of the deployment pod
+	replicas := int32(3)
+	deploy, _ := client.AppsV1().Deployments("default").Get(ctx, "web", metav1.GetOptions{})
+	deploy.Spec.Replicas = &replicas
+	if _, err := client.AppsV1().Deployments("default").Update(ctx, deploy, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update deployment failed, err: %v", err)
+	}
+	if err := client.CoreV1().Pods("default").Delete(ctx, "debug", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete pod failed, err: %v", err)
+	}
+	pod, _ := client.CoreV1().Pods("default").Get(ctx, "web-5d8f-x1", metav1.GetOptions{})
+	pod.Status.ContainerStatuses[0].ContainerID = "containerd://c5"
+	if _, err := client.CoreV1().Pods("default").Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update pod failed, err: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		snap, err = informers.snapshot()
+		if err != nil || len(snap.pods) != 4 {
+			return false
+		}
+		for _, p := range snap.pods {
+			if p.Name == "web-5d8f-x1" && p.Status.ContainerStatuses[0].ContainerID != "containerd://c5" {
+				return false
+			}
+		}
+		for _, d := range snap.deployments {
+			if *d.Spec.Replicas != replicas {
+				return false
+			}
+		}
+		return true
+	})
+
+	// custom fields are set by users, they must be preserved when the collector updates the workload
+	web := cmdb.workloadByName(types.KubeDeployment, "default", "web")
+	webBase := web.GetWorkloadBase()
+	webBase.CustomFields = &types.CustomFields{"owner_team": "infra"}
+	web.SetWorkloadBase(webBase)
+
+	oldPod := cmdb.podByName("default", "web-5d8f-x1")
+	result, err = collector.reconciler.reconcile(kit, snap)
+	if err != nil {
+		t.Fatalf("reconcile changes failed, err: %v", err)
+	}
+
+	wlChanges := result.changes[types.KubeWorkload]
+	if wlChanges.Updated != 1 || wlChanges.Deleted != 1 || wlChanges.Created != 0 {
+		t.Errorf("workload changes %+v is not 1 updated and 1 deleted", wlChanges)
+	}
+	deployment := cmdb.workloadByName(types.KubeDeployment, "default", "web").(*types.Deployment)
+	if deployment.Replicas == nil || *deployment.Replicas != 3 {
+		t.Errorf("deployment replicas %v is not 3", deployment.Replicas)
+	}
+	if fields := deployment.CustomFields; fields == nil || (*fields)["owner_team"] != "infra" {
+		t.Errorf("deployment custom fields %v are not preserved", fields)
+	}
+	if cmdb.workloadByName(types.KubePodWorkload, "default", podsWorkloadName) != nil {
+		t.Errorf("pods workload without pods is not deleted")
+	}
+	if cmdb.podByName("default", "debug") != nil {
+		t.Errorf("deleted pod debug is not deleted in cmdb")
+	}
+	newPod := cmdb.podByName("default", "web-5d8f-x1")
+	if newPod == nil || newPod.ID == oldPod.ID {
+		t.Errorf("changed pod web-5d8f-x1 is not recreated")
+	}
+	if result.counts[types.KubePod] != 2 {
+		t.Errorf("pod count %d is not 2", result.counts[types.KubePod])
+	}
+}
+
+func TestClusterCollectorStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	informers := startTestInformers(t, ctx, fake.NewSimpleClientset(newTestObjects()...))
+
+	conf := newTestConfig()
+	cmdb := newTestCmdb()
+	collector := newClusterCollector(conf, &conf.Clusters[0], cmdb, nil)
+	if state := collector.status.get().State; state != ClusterStatePending {
+		t.Errorf("initial state %s is not pending", state)
+	}
+
+	collector.sync(ctx, informers)
+	status := collector.status.get()
+	if status.State != ClusterStateSynced || status.ClusterID != testClusterID || status.LastSuccessTime == nil {
+		t.Errorf("status %+v is not synced", status)
+	}
+	if status.Counts[types.KubePod] != 3 || status.SkippedPods != 2 {
+		t.Errorf("status counts %v, skipped pods %d are not expected", status.Counts, status.SkippedPods)
+	}
+
+	delete(cmdb.clusters, testClusterUID)
+	collector.sync(ctx, informers)
+	status = collector.status.get()
+	if status.State != ClusterStateFailed || status.LastError == "" {
+		t.Errorf("status %+v is not failed", status)
+	}
+	if status.LastSuccessTime == nil || status.Counts[types.KubePod] != 3 {
+		t.Errorf("failed status %+v does not keep the last success result", status)
+	}
+}
+
+func TestCollectorStartInformersFailed(t *testing.T) {
+	conf := newTestConfig()
+	newClients := func(*ClusterConfig) (*kubeClients, error) {
+		return nil, errors.New("invalid kubeconfig")
+	}
+	collector := newClusterCollector(conf, &conf.Clusters[0], newTestCmdb(), newClients)
+
+	if _, err := collector.startInformers(context.Background()); err == nil {
+		t.Errorf("start informers with invalid kubeconfig should fail")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	conf := newTestConfig()
+	if conf.User == "" || conf.SupplierAccount == "" {
+		t.Errorf("default user and supplier account are not set, config: %+v", conf)
+	}
+	if conf.resyncInterval() != defaultResyncIntervalMinutes*time.Minute {
+		t.Errorf("default resync interval %s is not expected", conf.resyncInterval())
+	}
+
+	invalid := []*Config{
+		{Enabled: true},
+		{Enabled: true, Clusters: []ClusterConfig{{ClusterUID: testClusterUID, Kubeconfig: "/tmp/kubeconfig"}}},
+		{Enabled: true, Clusters: []ClusterConfig{{BizID: testBizID, Kubeconfig: "/tmp/kubeconfig"}}},
+		{Enabled: true, Clusters: []ClusterConfig{{BizID: testBizID, ClusterUID: testClusterUID}}},
+		{Enabled: true, Clusters: []ClusterConfig{
+			{BizID: testBizID, ClusterUID: testClusterUID, Kubeconfig: "/tmp/kubeconfig"},
+			{BizID: testBizID, ClusterUID: testClusterUID, Kubeconfig: "/tmp/kubeconfig"},
+		}},
+	}
+	for i, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("invalid config %d passes validation", i)
+		}
+	}
+
+	if err := (&Config{}).Validate(); err != nil {
+		t.Errorf("disabled config should be valid, err: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kubecollector
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+)
+
+const (
+	// configKey is the config key of the kube collector in the common config.
+	configKey = "datacollection.kubeCollector"
+
+	// defaultResyncIntervalMinutes is the default interval of the full reconciliation of a cluster.
+	defaultResyncIntervalMinutes = 10
+
+	// defaultDebounceSeconds is the default wait duration before a reconciliation triggered by the k8s events,
+	// so that a burst of events only causes one reconciliation.
+	defaultDebounceSeconds = 5
+)
+
+// Config is the configs of the kube collector.
+type Config struct {
+	// Enabled defines whether the kube collector is enabled, it is disabled by default.
+	Enabled bool `mapstructure:"enabled"`
+	// ResyncIntervalMinutes is the interval of the full reconciliation of a cluster, unit is minute.
+	ResyncIntervalMinutes int `mapstructure:"resyncIntervalMinutes"`
+	// DebounceSeconds is the wait duration before a reconciliation triggered by the k8s events, unit is second.
+	DebounceSeconds int `mapstructure:"debounceSeconds"`
+	// User is the user that the kube collector uses to operate the kube resources in cmdb.
+	User string `mapstructure:"user"`
+	// SupplierAccount is the supplier account of the clusters.
+	SupplierAccount string `mapstructure:"supplierAccount"`
+	// Clusters are the clusters that the kube collector collects, they must be registered in cmdb in advance.
+	Clusters []ClusterConfig `mapstructure:"clusters"`
+}
+
+// ClusterConfig is the config of a cluster that the kube collector collects.
+type ClusterConfig struct {
+	// BizID is the business id of the cluster in cmdb.
+	BizID int64 `mapstructure:"bizID"`
+	// ClusterUID is the uid of the cluster in cmdb.
+	ClusterUID string `mapstructure:"clusterUID"`
+	// Kubeconfig is the path of the kubeconfig file to access the cluster.
+	Kubeconfig string `mapstructure:"kubeconfig"`
+	// CloudID is the cloud area id of the hosts of the cluster nodes, it is used to find the host of a node.
+	CloudID int64 `mapstructure:"cloudID"`
+}
+
+// ParseConfig parses the kube collector configs from the common config.
+func ParseConfig() (*Config, error) {
+	conf := new(Config)
+	if !cc.IsExist(configKey) {
+		return conf, nil
+	}
+
+	if err := cc.UnmarshalKey(configKey, conf); err != nil {
+		return nil, fmt.Errorf("parse kube collector config failed, err: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// Validate validates the kube collector configs and sets the default values.
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.ResyncIntervalMinutes <= 0 {
+		c.ResyncIntervalMinutes = defaultResyncIntervalMinutes
+	}
+
+	if c.DebounceSeconds <= 0 {
+		c.DebounceSeconds = defaultDebounceSeconds
+	}
+
+	if c.User == "" {
+		c.User = common.CCSystemCollectorUserName
+	}
+
+	if c.SupplierAccount == "" {
+		c.SupplierAccount = common.BKDefaultOwnerID
+	}
+
+	if len(c.Clusters) == 0 {
+		return errors.New("kube collector is enabled, but no cluster is configured")
+	}
+
+	clusters := make(map[string]struct{})
+	for _, cluster := range c.Clusters {
+		if cluster.BizID <= 0 {
+			return fmt.Errorf("kube collector cluster %s biz id is invalid", cluster.ClusterUID)
+		}
+
+		if cluster.ClusterUID == "" {
+			return errors.New("kube collector cluster uid is not set")
+		}
+
+		if cluster.Kubeconfig == "" {
+			return fmt.Errorf("kube collector cluster %s kubeconfig is not set", cluster.ClusterUID)
+		}
+
+		if _, exists := clusters[cluster.ClusterUID]; exists {
+			return fmt.Errorf("kube collector cluster %s is duplicated", cluster.ClusterUID)
+		}
+		clusters[cluster.ClusterUID] = struct{}{}
+	}
+
+	return nil
+}
+
+func (c *Config) resyncInterval() time.Duration {
+	return time.Duration(c.ResyncIntervalMinutes) * time.Minute
+}
+
+func (c *Config) debounce() time.Duration {
+	return time.Duration(c.DebounceSeconds) * time.Second
+}
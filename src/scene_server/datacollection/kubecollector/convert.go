@@ -0,0 +1,406 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kubecollector
+
+import (
+	"sort"
+	"strings"
+
+	"configcenter/src/common/criteria/enumor"
+	"configcenter/src/kube/types"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// nodeRoleLabelPrefix is the label prefix that defines the roles of a node.
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+	// podsWorkloadName is the name of the pseudo workload that the pods without controller belong to.
+	podsWorkloadName = "pods"
+)
+
+// convertNode converts the k8s node to the cmdb node, the ids of the node are not set.
+func convertNode(node *corev1.Node) *types.Node {
+	internalIPs, externalIPs := make([]string, 0), make([]string, 0)
+	hostname := ""
+	for _, addr := range node.Status.Addresses {
+		switch addr.Type {
+		case corev1.NodeInternalIP:
+			internalIPs = append(internalIPs, addr.Address)
+		case corev1.NodeExternalIP:
+			externalIPs = append(externalIPs, addr.Address)
+		case corev1.NodeHostName:
+			hostname = addr.Address
+		}
+	}
+
+	roles := make([]string, 0)
+	for key := range node.Labels {
+		if strings.HasPrefix(key, nodeRoleLabelPrefix) {
+			roles = append(roles, strings.TrimPrefix(key, nodeRoleLabelPrefix))
+		}
+	}
+	sort.Strings(roles)
+
+	taints := make(enumor.MapStringType)
+	for _, taint := range node.Spec.Taints {
+		taints[taint.Key] = taint.Value + ":" + string(taint.Effect)
+	}
+
+	labels := enumor.MapStringType(copyStringMap(node.Labels))
+	unschedulable := node.Spec.Unschedulable
+	runtime := node.Status.NodeInfo.ContainerRuntimeVersion
+
+	return &types.Node{
+		Name:             &node.Name,
+		Roles:            stringPtrOrNil(strings.Join(roles, ",")),
+		Labels:           &labels,
+		Taints:           &taints,
+		Unschedulable:    &unschedulable,
+		InternalIP:       &internalIPs,
+		ExternalIP:       &externalIPs,
+		HostName:         stringPtrOrNil(hostname),
+		RuntimeComponent: stringPtrOrNil(runtime),
+		PodCidr:          stringPtrOrNil(node.Spec.PodCIDR),
+	}
+}
+
+// convertNamespace converts the k8s namespace and its resource quotas to the cmdb namespace, the ids of the
+// namespace are not set.
+func convertNamespace(ns *corev1.Namespace, quotas []*corev1.ResourceQuota) *types.Namespace {
+	resourceQuotas := make([]types.ResourceQuota, 0)
+	for _, quota := range quotas {
+		hard := make(map[string]string)
+		for name, quantity := range quota.Spec.Hard {
+			hard[string(name)] = quantity.String()
+		}
+
+		scopes := make([]types.ResourceQuotaScope, 0)
+		for _, scope := range quota.Spec.Scopes {
+			scopes = append(scopes, types.ResourceQuotaScope(scope))
+		}
+
+		var scopeSelector *types.ScopeSelector
+		if quota.Spec.ScopeSelector != nil {
+			scopeSelector = &types.ScopeSelector{
+				MatchExpressions: make([]types.ScopedResourceSelectorRequirement, 0),
+			}
+			for _, expr := range quota.Spec.ScopeSelector.MatchExpressions {
+				scopeSelector.MatchExpressions = append(scopeSelector.MatchExpressions,
+					types.ScopedResourceSelectorRequirement{
+						ScopeName: types.ResourceQuotaScope(expr.ScopeName),
+						Operator:  types.ScopeSelectorOperator(expr.Operator),
+						Values:    expr.Values,
+					})
+			}
+		}
+
+		resourceQuotas = append(resourceQuotas, types.ResourceQuota{
+			Hard:          hard,
+			Scopes:        scopes,
+			ScopeSelector: scopeSelector,
+		})
+	}
+
+	labels := copyStringMap(ns.Labels)
+	return &types.Namespace{
+		Name:           ns.Name,
+		Labels:         &labels,
+		ResourceQuotas: &resourceQuotas,
+	}
+}
+
+// workloadSpec is the spec fields that all kinds of workloads have.
+type workloadSpec struct {
+	labels          map[string]string
+	selector        *metav1.LabelSelector
+	replicas        *int32
+	minReadySeconds int32
+}
+
+func (w *workloadSpec) fields() (*map[string]string, *types.LabelSelector, *int64, *int64) {
+	labels := copyStringMap(w.labels)
+	var replicas *int64
+	if w.replicas != nil {
+		value := int64(*w.replicas)
+		replicas = &value
+	}
+	minReadySeconds := int64(w.minReadySeconds)
+	return &labels, convertSelector(w.selector), replicas, &minReadySeconds
+}
+
+// convertDeployment converts the k8s deployment to the cmdb deployment, the workload base is not set.
+func convertDeployment(d *appsv1.Deployment) types.WorkloadInterface {
+	spec := &workloadSpec{labels: d.Labels, selector: d.Spec.Selector, replicas: d.Spec.Replicas,
+		minReadySeconds: d.Spec.MinReadySeconds}
+	wl := new(types.Deployment)
+	wl.Labels, wl.Selector, wl.Replicas, wl.MinReadySeconds = spec.fields()
+
+	strategyType := types.DeploymentStrategyType(d.Spec.Strategy.Type)
+	wl.StrategyType = &strategyType
+	if d.Spec.Strategy.RollingUpdate != nil {
+		wl.RollingUpdateStrategy = &types.RollingUpdateDeployment{
+			MaxUnavailable: convertIntOrString(d.Spec.Strategy.RollingUpdate.MaxUnavailable),
+			MaxSurge:       convertIntOrString(d.Spec.Strategy.RollingUpdate.MaxSurge),
+		}
+	}
+	return wl
+}
+
+// convertStatefulSet converts the k8s statefulSet to the cmdb statefulSet, the workload base is not set.
+func convertStatefulSet(s *appsv1.StatefulSet) types.WorkloadInterface {
+	spec := &workloadSpec{labels: s.Labels, selector: s.Spec.Selector, replicas: s.Spec.Replicas,
+		minReadySeconds: s.Spec.MinReadySeconds}
+	wl := new(types.StatefulSet)
+	wl.Labels, wl.Selector, wl.Replicas, wl.MinReadySeconds = spec.fields()
+
+	strategyType := types.StatefulSetUpdateStrategyType(s.Spec.UpdateStrategy.Type)
+	wl.StrategyType = &strategyType
+	if s.Spec.UpdateStrategy.RollingUpdate != nil {
+		wl.RollingUpdateStrategy = &types.RollingUpdateStatefulSetStrategy{
+			Partition: s.Spec.UpdateStrategy.RollingUpdate.Partition,
+		}
+	}
+	return wl
+}
+
+// convertDaemonSet converts the k8s daemonSet to the cmdb daemonSet, the workload base is not set.
+func convertDaemonSet(d *appsv1.DaemonSet) types.WorkloadInterface {
+	spec := &workloadSpec{labels: d.Labels, selector: d.Spec.Selector, replicas: &d.Status.DesiredNumberScheduled,
+		minReadySeconds: d.Spec.MinReadySeconds}
+	wl := new(types.DaemonSet)
+	wl.Labels, wl.Selector, wl.Replicas, wl.MinReadySeconds = spec.fields()
+
+	strategyType := types.DaemonSetUpdateStrategyType(d.Spec.UpdateStrategy.Type)
+	wl.StrategyType = &strategyType
+	if d.Spec.UpdateStrategy.RollingUpdate != nil {
+		wl.RollingUpdateStrategy = &types.RollingUpdateDaemonSet{
+			MaxUnavailable: convertIntOrString(d.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable),
+			MaxSurge:       convertIntOrString(d.Spec.UpdateStrategy.RollingUpdate.MaxSurge),
+		}
+	}
+	return wl
+}
+
+// convertJob converts the k8s job to the cmdb job, the workload base is not set.
+func convertJob(j *batchv1.Job) types.WorkloadInterface {
+	spec := &workloadSpec{labels: j.Labels, selector: j.Spec.Selector, replicas: j.Spec.Parallelism}
+	wl := new(types.Job)
+	wl.Labels, wl.Selector, wl.Replicas, wl.MinReadySeconds = spec.fields()
+	return wl
+}
+
+// convertCronJob converts the k8s cronJob to the cmdb cronJob, the workload base is not set.
+func convertCronJob(c *batchv1.CronJob) types.WorkloadInterface {
+	spec := &workloadSpec{labels: c.Labels, selector: c.Spec.JobTemplate.Spec.Selector,
+		replicas: c.Spec.JobTemplate.Spec.Parallelism}
+	wl := new(types.CronJob)
+	wl.Labels, wl.Selector, wl.Replicas, wl.MinReadySeconds = spec.fields()
+	return wl
+}
+
+// convertGameWorkload converts the gameDeployment or gameStatefulSet custom resource to the cmdb workload, only
+// the spec fields that all kinds of workloads have are converted, the workload base is not set.
+func convertGameWorkload(kind types.WorkloadType, obj *unstructured.Unstructured) types.WorkloadInterface {
+	spec := &workloadSpec{labels: obj.GetLabels()}
+	if replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); found {
+		value := int32(replicas)
+		spec.replicas = &value
+	}
+	if seconds, found, _ := unstructured.NestedInt64(obj.Object, "spec", "minReadySeconds"); found {
+		spec.minReadySeconds = int32(seconds)
+	}
+	if selector, found, _ := unstructured.NestedMap(obj.Object, "spec", "selector"); found {
+		labelSelector := new(metav1.LabelSelector)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selector, labelSelector); err == nil {
+			spec.selector = labelSelector
+		}
+	}
+
+	strategyType, _, _ := unstructured.NestedString(obj.Object, "spec", "updateStrategy", "type")
+
+	labels, selector, replicas, minReadySeconds := spec.fields()
+	switch kind {
+	case types.KubeGameDeployment:
+		wl := &types.GameDeployment{Labels: labels, Selector: selector, Replicas: replicas,
+			MinReadySeconds: minReadySeconds}
+		if strategyType != "" {
+			wlStrategyType := types.GameDeploymentUpdateStrategyType(strategyType)
+			wl.StrategyType = &wlStrategyType
+		}
+		return wl
+	default:
+		wl := &types.GameStatefulSet{Labels: labels, Selector: selector, Replicas: replicas,
+			MinReadySeconds: minReadySeconds}
+		if strategyType != "" {
+			wlStrategyType := types.GameStatefulSetUpdateStrategyType(strategyType)
+			wl.StrategyType = &wlStrategyType
+		}
+		return wl
+	}
+}
+
+// newPodsWorkload new the pseudo workload that the pods without controller in the namespace belong to.
+func newPodsWorkload() types.WorkloadInterface {
+	return new(types.PodsWorkload)
+}
+
+func convertSelector(selector *metav1.LabelSelector) *types.LabelSelector {
+	if selector == nil {
+		return nil
+	}
+
+	result := &types.LabelSelector{
+		MatchLabels:      copyStringMap(selector.MatchLabels),
+		MatchExpressions: make([]types.LabelSelectorRequirement, 0),
+	}
+	for _, expr := range selector.MatchExpressions {
+		result.MatchExpressions = append(result.MatchExpressions, types.LabelSelectorRequirement{
+			Key:      expr.Key,
+			Operator: types.LabelSelectorOperator(expr.Operator),
+			Values:   expr.Values,
+		})
+	}
+	return result
+}
+
+func convertIntOrString(value *intstr.IntOrString) *types.IntOrString {
+	if value == nil {
+		return nil
+	}
+	return &types.IntOrString{Type: types.Type(value.Type), IntVal: value.IntVal, StrVal: value.StrVal}
+}
+
+// convertPod converts the k8s pod to the cmdb pod, the ids and references of the pod are not set.
+func convertPod(pod *corev1.Pod, operator string) *types.Pod {
+	ips := make([]types.PodIP, 0)
+	for _, ip := range pod.Status.PodIPs {
+		ips = append(ips, types.PodIP{IP: ip.IP})
+	}
+
+	tolerations := make([]types.Toleration, 0)
+	for _, toleration := range pod.Spec.Tolerations {
+		tolerations = append(tolerations, types.Toleration{
+			Key:               toleration.Key,
+			Operator:          types.TolerationOperator(toleration.Operator),
+			Value:             toleration.Value,
+			Effect:            types.TaintEffect(toleration.Effect),
+			TolerationSeconds: toleration.TolerationSeconds,
+		})
+	}
+
+	labels := copyStringMap(pod.Labels)
+	nodeSelectors := copyStringMap(pod.Spec.NodeSelector)
+	qosClass := types.PodQOSClass(pod.Status.QOSClass)
+	operators := []string{operator}
+
+	return &types.Pod{
+		Name:          &pod.Name,
+		Priority:      pod.Spec.Priority,
+		Labels:        &labels,
+		IP:            stringPtrOrNil(pod.Status.PodIP),
+		IPs:           &ips,
+		QOSClass:      &qosClass,
+		NodeSelectors: &nodeSelectors,
+		Tolerations:   &tolerations,
+		Operator:      &operators,
+	}
+}
+
+// convertContainers converts the containers of the k8s pod to the cmdb containers, the containers that are not
+// created yet have no container id, they are skipped.
+func convertContainers(pod *corev1.Pod) []types.Container {
+	statuses := make(map[string]corev1.ContainerStatus)
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+
+	containers := make([]types.Container, 0)
+	for idx := range pod.Spec.Containers {
+		spec := &pod.Spec.Containers[idx]
+		status, exists := statuses[spec.Name]
+		if !exists || status.ContainerID == "" {
+			continue
+		}
+
+		ports := make([]types.ContainerPort, 0)
+		for _, port := range spec.Ports {
+			ports = append(ports, types.ContainerPort{
+				Name:          port.Name,
+				HostPort:      port.HostPort,
+				ContainerPort: port.ContainerPort,
+				Protocol:      types.Protocol(port.Protocol),
+				HostIP:        port.HostIP,
+			})
+		}
+
+		envs := make([]types.EnvVar, 0)
+		for _, env := range spec.Env {
+			envs = append(envs, types.EnvVar{Name: env.Name, Value: env.Value})
+		}
+
+		mounts := make([]types.VolumeMount, 0)
+		for _, mount := range spec.VolumeMounts {
+			mounts = append(mounts, types.VolumeMount{
+				Name:        mount.Name,
+				ReadOnly:    mount.ReadOnly,
+				MountPath:   mount.MountPath,
+				SubPath:     mount.SubPath,
+				SubPathExpr: mount.SubPathExpr,
+			})
+		}
+
+		args := append(append(make([]string, 0), spec.Command...), spec.Args...)
+		container := types.Container{
+			Name:        &spec.Name,
+			ContainerID: &status.ContainerID,
+			Image:       &spec.Image,
+			Ports:       &ports,
+			Args:        &args,
+			Environment: &envs,
+			Mounts:      &mounts,
+		}
+		if status.State.Running != nil {
+			started := status.State.Running.StartedAt.Unix()
+			container.Started = &started
+		}
+		containers = append(containers, container)
+	}
+
+	return containers
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for key, value := range m {
+		result[key] = value
+	}
+	return result
+}
+
+func stringPtrOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
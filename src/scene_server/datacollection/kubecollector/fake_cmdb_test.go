@@ -0,0 +1,394 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kubecollector
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/kube/types"
+)
+
+// fakeCmdb is the in-memory cmdbClient for tests, it checks the resource dependencies like topo server does.
+type fakeCmdb struct {
+	lock       sync.Mutex
+	nextID     int64
+	clusters   map[string]*types.Cluster
+	hosts      map[string]int64
+	nodes      map[int64]types.Node
+	namespaces map[int64]types.Namespace
+	workloads  map[types.WorkloadType]map[int64]types.WorkloadInterface
+	pods       map[int64]types.Pod
+	containers map[int64]types.Container
+}
+
+func newFakeCmdb() *fakeCmdb {
+	return &fakeCmdb{
+		clusters:   make(map[string]*types.Cluster),
+		hosts:      make(map[string]int64),
+		nodes:      make(map[int64]types.Node),
+		namespaces: make(map[int64]types.Namespace),
+		workloads:  make(map[types.WorkloadType]map[int64]types.WorkloadInterface),
+		pods:       make(map[int64]types.Pod),
+		containers: make(map[int64]types.Container),
+	}
+}
+
+func (f *fakeCmdb) genID() int64 {
+	f.nextID++
+	return f.nextID
+}
+
+// merge overwrites the fields of dst with the fields set in src.
+func merge(dst, src interface{}) error {
+	dstFields, err := toFieldMap(dst)
+	if err != nil {
+		return err
+	}
+	srcFields, err := toFieldMap(src)
+	if err != nil {
+		return err
+	}
+	for key, value := range srcFields {
+		dstFields[key] = value
+	}
+	js, err := json.Marshal(dstFields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(js, dst)
+}
+
+func (f *fakeCmdb) GetCluster(_ *rest.Kit, bizID int64, uid string) (*types.Cluster, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	cluster, exists := f.clusters[uid]
+	if !exists || cluster.BizID != bizID {
+		return nil, fmt.Errorf("cluster %s is not registered in biz %d", uid, bizID)
+	}
+	return cluster, nil
+}
+
+func (f *fakeCmdb) GetHostID(_ *rest.Kit, _ int64, ips []string) (int64, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, ip := range ips {
+		if hostID, exists := f.hosts[ip]; exists {
+			return hostID, nil
+		}
+	}
+	return 0, nil
+}
+
+func (f *fakeCmdb) ListNodes(_ *rest.Kit, _, clusterID int64) ([]types.Node, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	nodes := make([]types.Node, 0)
+	for _, node := range f.nodes {
+		if node.ClusterID == clusterID {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+func (f *fakeCmdb) CreateNodes(_ *rest.Kit, bizID int64, nodes []types.OneNodeCreateOption) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, opt := range nodes {
+		node := opt.Node
+		node.ID, node.BizID, node.HostID, node.ClusterID = f.genID(), bizID, opt.HostID, opt.ClusterID
+		f.nodes[node.ID] = node
+	}
+	return nil
+}
+
+func (f *fakeCmdb) UpdateNode(_ *rest.Kit, _, id int64, node *types.Node) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	exists, ok := f.nodes[id]
+	if !ok {
+		return fmt.Errorf("node %d not exists", id)
+	}
+	if node.Name != nil {
+		return errors.New("node name is not editable")
+	}
+	if err := merge(&exists, node); err != nil {
+		return err
+	}
+	f.nodes[id] = exists
+	return nil
+}
+
+func (f *fakeCmdb) DeleteNodes(_ *rest.Kit, _ int64, ids []int64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, id := range ids {
+		for _, pod := range f.pods {
+			if pod.NodeID == id {
+				return fmt.Errorf("node %d has pods", id)
+			}
+		}
+		delete(f.nodes, id)
+	}
+	return nil
+}
+
+func (f *fakeCmdb) ListNamespaces(_ *rest.Kit, _, clusterID int64) ([]types.Namespace, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	namespaces := make([]types.Namespace, 0)
+	for _, ns := range f.namespaces {
+		if ns.ClusterID == clusterID {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces, nil
+}
+
+func (f *fakeCmdb) CreateNamespaces(_ *rest.Kit, _ int64, namespaces []types.Namespace) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, ns := range namespaces {
+		if ns.BizID == 0 || ns.ClusterID == 0 || ns.ClusterUID == "" {
+			return errors.New("cluster spec of namespace is not set")
+		}
+		ns.ID = f.genID()
+		f.namespaces[ns.ID] = ns
+	}
+	return nil
+}
+
+func (f *fakeCmdb) UpdateNamespace(_ *rest.Kit, _, id int64, ns *types.Namespace) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	exists, ok := f.namespaces[id]
+	if !ok {
+		return fmt.Errorf("namespace %d not exists", id)
+	}
+	if err := merge(&exists, ns); err != nil {
+		return err
+	}
+	f.namespaces[id] = exists
+	return nil
+}
+
+func (f *fakeCmdb) DeleteNamespaces(_ *rest.Kit, _ int64, ids []int64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, id := range ids {
+		for _, workloads := range f.workloads {
+			for _, wl := range workloads {
+				if wl.GetWorkloadBase().NamespaceID == id {
+					return fmt.Errorf("namespace %d has workloads", id)
+				}
+			}
+		}
+		delete(f.namespaces, id)
+	}
+	return nil
+}
+
+func (f *fakeCmdb) ListWorkloads(_ *rest.Kit, _, clusterID int64, kind types.WorkloadType) (
+	[]types.WorkloadInterface, error) {
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	workloads := make([]types.WorkloadInterface, 0)
+	for _, wl := range f.workloads[kind] {
+		if wl.GetWorkloadBase().ClusterID == clusterID {
+			workloads = append(workloads, wl)
+		}
+	}
+	return workloads, nil
+}
+
+func (f *fakeCmdb) CreateWorkloads(_ *rest.Kit, _ int64, kind types.WorkloadType,
+	wls []types.WorkloadInterface) error {
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.workloads[kind] == nil {
+		f.workloads[kind] = make(map[int64]types.WorkloadInterface)
+	}
+	for _, wl := range wls {
+		base := wl.GetWorkloadBase()
+		if base.NamespaceID == 0 || base.ClusterID == 0 || base.Name == "" {
+			return errors.New("workload base is not set")
+		}
+		base.ID = f.genID()
+		wl.SetWorkloadBase(base)
+		f.workloads[kind][base.ID] = wl
+	}
+	return nil
+}
+
+func (f *fakeCmdb) UpdateWorkload(_ *rest.Kit, _ int64, kind types.WorkloadType, id int64,
+	wl types.WorkloadInterface) error {
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	exists, ok := f.workloads[kind][id]
+	if !ok {
+		return fmt.Errorf("%s workload %d not exists", kind, id)
+	}
+	if wl.GetWorkloadBase().Name != "" {
+		return errors.New("workload name is not editable")
+	}
+	base := exists.GetWorkloadBase()
+	if err := merge(exists, wl); err != nil {
+		return err
+	}
+	exists.SetWorkloadBase(base)
+	return nil
+}
+
+func (f *fakeCmdb) DeleteWorkloads(_ *rest.Kit, _ int64, kind types.WorkloadType, ids []int64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, id := range ids {
+		for _, pod := range f.pods {
+			if pod.Ref.Kind == kind && pod.Ref.ID == id {
+				return fmt.Errorf("%s workload %d has pods", kind, id)
+			}
+		}
+		delete(f.workloads[kind], id)
+	}
+	return nil
+}
+
+func (f *fakeCmdb) ListPods(_ *rest.Kit, _, clusterID int64) ([]types.Pod, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	pods := make([]types.Pod, 0)
+	for _, pod := range f.pods {
+		if pod.ClusterID == clusterID {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+func (f *fakeCmdb) ListContainers(_ *rest.Kit, _, clusterID int64) ([]types.Container, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	containers := make([]types.Container, 0)
+	for _, container := range f.containers {
+		if container.ClusterID == clusterID {
+			containers = append(containers, container)
+		}
+	}
+	return containers, nil
+}
+
+func (f *fakeCmdb) CreatePods(_ *rest.Kit, bizID int64, pods []types.PodsInfo) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, info := range pods {
+		if info.HostID == 0 || info.Spec.NodeID == 0 || info.Spec.Ref.ID == 0 {
+			return errors.New("host, node or workload of pod is not set")
+		}
+		ns, exists := f.namespaces[info.Spec.NamespaceID]
+		if !exists {
+			return fmt.Errorf("namespace %d not exists", info.Spec.NamespaceID)
+		}
+
+		pod := info.Pod
+		pod.ID = f.genID()
+		ref := info.Spec.Ref
+		pod.SysSpec = types.SysSpec{
+			WorkloadSpec: types.WorkloadSpec{
+				NamespaceSpec: types.NamespaceSpec{
+					ClusterSpec: types.ClusterSpec{BizID: bizID, ClusterID: info.Spec.ClusterID},
+					NamespaceID: ns.ID,
+					Namespace:   ns.Name,
+				},
+				Ref: &ref,
+			},
+			HostID: info.HostID,
+			NodeID: info.Spec.NodeID,
+		}
+		f.pods[pod.ID] = pod
+
+		for _, container := range info.Containers {
+			container.ID, container.PodID, container.ClusterID = f.genID(), pod.ID, info.Spec.ClusterID
+			f.containers[container.ID] = container
+		}
+	}
+	return nil
+}
+
+func (f *fakeCmdb) DeletePods(_ *rest.Kit, _ int64, ids []int64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, id := range ids {
+		delete(f.pods, id)
+		for containerID, container := range f.containers {
+			if container.PodID == id {
+				delete(f.containers, containerID)
+			}
+		}
+	}
+	return nil
+}
+
+// podByName get the pod by name, the pod is nil if it is not found.
+func (f *fakeCmdb) podByName(namespace, name string) *types.Pod {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, pod := range f.pods {
+		if pod.Namespace == namespace && pod.Name != nil && *pod.Name == name {
+			return &pod
+		}
+	}
+	return nil
+}
+
+// workloadByName get the workload by name, the workload is nil if it is not found.
+func (f *fakeCmdb) workloadByName(kind types.WorkloadType, namespace, name string) types.WorkloadInterface {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, wl := range f.workloads[kind] {
+		if base := wl.GetWorkloadBase(); base.Namespace == namespace && base.Name == name {
+			return wl
+		}
+	}
+	return nil
+}
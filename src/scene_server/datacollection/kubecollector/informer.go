@@ -0,0 +1,227 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kubecollector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/kube/types"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// gameWorkloadGroupVersion is the group version of the gameDeployment and gameStatefulSet custom resources.
+var gameWorkloadGroupVersion = schema.GroupVersion{Group: "tkex.tencent.com", Version: "v1alpha1"}
+
+// gameWorkloadResources are the resources of the game workloads, keyed by the workload type.
+var gameWorkloadResources = map[types.WorkloadType]string{
+	types.KubeGameDeployment:  "gamedeployments",
+	types.KubeGameStatefulSet: "gamestatefulsets",
+}
+
+// kubeClients are the clients to access a cluster.
+type kubeClients struct {
+	client  kubernetes.Interface
+	dynamic dynamic.Interface
+}
+
+// clientFactory creates the clients to access the cluster.
+type clientFactory func(cluster *ClusterConfig) (*kubeClients, error)
+
+// newKubeClients creates the clients to access the cluster by its kubeconfig file.
+func newKubeClients(cluster *ClusterConfig) (*kubeClients, error) {
+	restConf, err := clientcmd.BuildConfigFromFlags("", cluster.Kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("build rest config from kubeconfig %s failed, err: %v", cluster.Kubeconfig, err)
+	}
+
+	client, err := kubernetes.NewForConfig(restConf)
+	if err != nil {
+		return nil, fmt.Errorf("create kube client failed, err: %v", err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConf)
+	if err != nil {
+		return nil, fmt.Errorf("create kube dynamic client failed, err: %v", err)
+	}
+
+	return &kubeClients{client: client, dynamic: dynamicClient}, nil
+}
+
+// clusterSnapshot is the kube resources of a cluster read from the informer caches.
+type clusterSnapshot struct {
+	nodes         []*corev1.Node
+	namespaces    []*corev1.Namespace
+	quotas        []*corev1.ResourceQuota
+	deployments   []*appsv1.Deployment
+	replicaSets   []*appsv1.ReplicaSet
+	statefulSets  []*appsv1.StatefulSet
+	daemonSets    []*appsv1.DaemonSet
+	jobs          []*batchv1.Job
+	cronJobs      []*batchv1.CronJob
+	gameWorkloads map[types.WorkloadType][]*unstructured.Unstructured
+	pods          []*corev1.Pod
+}
+
+// clusterInformers are the informers that watch the kube resources of a cluster.
+type clusterInformers struct {
+	factory        informers.SharedInformerFactory
+	dynamicFactory dynamicinformer.DynamicSharedInformerFactory
+	gameInformers  map[types.WorkloadType]informers.GenericInformer
+	synced         []cache.InformerSynced
+}
+
+// newClusterInformers creates the informers of the cluster, every change of the watched resources calls the handler.
+// the game workload informers are only created when the custom resources are installed in the cluster.
+func newClusterInformers(clients *kubeClients, resync time.Duration, handler cache.ResourceEventHandler,
+	rid string) *clusterInformers {
+
+	ci := &clusterInformers{
+		factory:       informers.NewSharedInformerFactory(clients.client, resync),
+		gameInformers: make(map[types.WorkloadType]informers.GenericInformer),
+	}
+
+	sharedInformers := []cache.SharedIndexInformer{
+		ci.factory.Core().V1().Nodes().Informer(),
+		ci.factory.Core().V1().Namespaces().Informer(),
+		ci.factory.Core().V1().ResourceQuotas().Informer(),
+		ci.factory.Apps().V1().Deployments().Informer(),
+		ci.factory.Apps().V1().ReplicaSets().Informer(),
+		ci.factory.Apps().V1().StatefulSets().Informer(),
+		ci.factory.Apps().V1().DaemonSets().Informer(),
+		ci.factory.Batch().V1().Jobs().Informer(),
+		ci.factory.Batch().V1().CronJobs().Informer(),
+		ci.factory.Core().V1().Pods().Informer(),
+	}
+
+	if clients.dynamic != nil && hasGameWorkloads(clients.client, rid) {
+		ci.dynamicFactory = dynamicinformer.NewDynamicSharedInformerFactory(clients.dynamic, resync)
+		for kind, resource := range gameWorkloadResources {
+			informer := ci.dynamicFactory.ForResource(gameWorkloadGroupVersion.WithResource(resource))
+			ci.gameInformers[kind] = informer
+			sharedInformers = append(sharedInformers, informer.Informer())
+		}
+	}
+
+	for _, informer := range sharedInformers {
+		informer.AddEventHandler(handler)
+		ci.synced = append(ci.synced, informer.HasSynced)
+	}
+
+	return ci
+}
+
+// hasGameWorkloads checks if the game workload custom resources are installed in the cluster.
+func hasGameWorkloads(client kubernetes.Interface, rid string) bool {
+	resources, err := client.Discovery().ServerResourcesForGroupVersion(gameWorkloadGroupVersion.String())
+	if err != nil {
+		blog.V(4).Infof("game workloads are not found in cluster, err: %v, rid: %s", err, rid)
+		return false
+	}
+
+	found := make(map[string]struct{})
+	for _, resource := range resources.APIResources {
+		found[resource.Name] = struct{}{}
+	}
+
+	for _, resource := range gameWorkloadResources {
+		if _, exists := found[resource]; !exists {
+			return false
+		}
+	}
+	return true
+}
+
+// start starts the informers and waits for their caches to be synced.
+func (ci *clusterInformers) start(ctx context.Context) error {
+	ci.factory.Start(ctx.Done())
+	if ci.dynamicFactory != nil {
+		ci.dynamicFactory.Start(ctx.Done())
+	}
+
+	if !cache.WaitForCacheSync(ctx.Done(), ci.synced...) {
+		return fmt.Errorf("wait for kube informer caches to be synced failed")
+	}
+	return nil
+}
+
+// snapshot reads all the watched kube resources from the informer caches.
+func (ci *clusterInformers) snapshot() (*clusterSnapshot, error) {
+	all := labels.Everything()
+	snap := &clusterSnapshot{gameWorkloads: make(map[types.WorkloadType][]*unstructured.Unstructured)}
+
+	var err error
+	if snap.nodes, err = ci.factory.Core().V1().Nodes().Lister().List(all); err != nil {
+		return nil, err
+	}
+	if snap.namespaces, err = ci.factory.Core().V1().Namespaces().Lister().List(all); err != nil {
+		return nil, err
+	}
+	if snap.quotas, err = ci.factory.Core().V1().ResourceQuotas().Lister().List(all); err != nil {
+		return nil, err
+	}
+	if snap.deployments, err = ci.factory.Apps().V1().Deployments().Lister().List(all); err != nil {
+		return nil, err
+	}
+	if snap.replicaSets, err = ci.factory.Apps().V1().ReplicaSets().Lister().List(all); err != nil {
+		return nil, err
+	}
+	if snap.statefulSets, err = ci.factory.Apps().V1().StatefulSets().Lister().List(all); err != nil {
+		return nil, err
+	}
+	if snap.daemonSets, err = ci.factory.Apps().V1().DaemonSets().Lister().List(all); err != nil {
+		return nil, err
+	}
+	if snap.jobs, err = ci.factory.Batch().V1().Jobs().Lister().List(all); err != nil {
+		return nil, err
+	}
+	if snap.cronJobs, err = ci.factory.Batch().V1().CronJobs().Lister().List(all); err != nil {
+		return nil, err
+	}
+	if snap.pods, err = ci.factory.Core().V1().Pods().Lister().List(all); err != nil {
+		return nil, err
+	}
+
+	for kind, informer := range ci.gameInformers {
+		objs, err := informer.Lister().List(all)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range objs {
+			if wl, ok := obj.(*unstructured.Unstructured); ok {
+				snap.gameWorkloads[kind] = append(snap.gameWorkloads[kind], wl)
+			}
+		}
+	}
+
+	return snap, nil
+}
@@ -0,0 +1,84 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kubecollector
+
+import (
+	"configcenter/src/kube/types"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ownerKinds maps the kind of the pod owner to the workload type, the owners that are not in the map are not
+// workloads, e.g. replicaSet is owned by deployment.
+var ownerKinds = map[string]types.WorkloadType{
+	"Deployment":      types.KubeDeployment,
+	"StatefulSet":     types.KubeStatefulSet,
+	"DaemonSet":       types.KubeDaemonSet,
+	"Job":             types.KubeJob,
+	"CronJob":         types.KubeCronJob,
+	"GameDeployment":  types.KubeGameDeployment,
+	"GameStatefulSet": types.KubeGameStatefulSet,
+}
+
+// ownerResolver resolves the workload that a pod belongs to.
+type ownerResolver struct {
+	// owners is the controller owner of replicaSets and jobs, keyed by kind and namespace/name.
+	owners    map[string]map[string]*metav1.OwnerReference
+	workloads map[types.WorkloadType]map[string]types.WorkloadInterface
+}
+
+func newOwnerResolver(snap *clusterSnapshot,
+	workloads map[types.WorkloadType]map[string]types.WorkloadInterface) *ownerResolver {
+
+	owners := map[string]map[string]*metav1.OwnerReference{"ReplicaSet": {}, "Job": {}}
+	for _, rs := range snap.replicaSets {
+		owners["ReplicaSet"][resourceKey(rs.Namespace, rs.Name)] = metav1.GetControllerOf(rs)
+	}
+	for _, job := range snap.jobs {
+		owners["Job"][resourceKey(job.Namespace, job.Name)] = metav1.GetControllerOf(job)
+	}
+
+	return &ownerResolver{owners: owners, workloads: workloads}
+}
+
+// resolve returns the workload kind and name of the pod, the pods of replicaSets belong to the deployments, the
+// pods of jobs belong to the cronJobs if the jobs are created by cronJobs. if the workload of the pod is not found,
+// the pod belongs to the pods pseudo workload of the namespace.
+func (o *ownerResolver) resolve(pod *corev1.Pod) (types.WorkloadType, string) {
+	owner := metav1.GetControllerOf(pod)
+	for owner != nil {
+		if parents, exists := o.owners[owner.Kind]; exists {
+			if parent := parents[resourceKey(pod.Namespace, owner.Name)]; parent != nil {
+				owner = parent
+				continue
+			}
+		}
+
+		kind, exists := ownerKinds[owner.Kind]
+		if !exists {
+			break
+		}
+
+		if _, exists := o.workloads[kind][resourceKey(pod.Namespace, owner.Name)]; !exists {
+			break
+		}
+		return kind, owner.Name
+	}
+
+	return types.KubePodWorkload, podsWorkloadName
+}
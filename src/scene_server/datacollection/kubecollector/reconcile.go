@@ -0,0 +1,717 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kubecollector
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/kube/types"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// workloadKinds are the workload kinds that are reconciled, in the order of creation.
+var workloadKinds = []types.WorkloadType{types.KubeDeployment, types.KubeStatefulSet, types.KubeDaemonSet,
+	types.KubeGameDeployment, types.KubeGameStatefulSet, types.KubeCronJob, types.KubeJob, types.KubePodWorkload}
+
+// ignoredCompareFields are the fields that are not compared when checking if a resource is changed, they are either
+// the identities of the resource or maintained by cmdb.
+var ignoredCompareFields = map[string]struct{}{
+	"id": {}, "bk_biz_id": {}, "bk_supplier_account": {}, types.BKClusterIDFiled: {}, types.ClusterUIDField: {},
+	types.BKNamespaceIDField: {}, "namespace": {}, "name": {}, "bk_host_id": {}, types.BKNodeIDField: {},
+	"node_name": {}, "ref": {}, "has_pod": {}, "operator": {}, "creator": {}, "modifier": {}, "create_time": {},
+	"last_time": {},
+}
+
+// resourceChanges is the number of changed resources of one reconciliation.
+type resourceChanges struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+}
+
+// syncResult is the result of one reconciliation.
+type syncResult struct {
+	clusterID int64
+	// counts is the number of resources of each kind in the cluster after the reconciliation.
+	counts map[string]int
+	// changes is the number of changed resources of each kind in the reconciliation.
+	changes map[string]*resourceChanges
+	// skippedPods is the number of pods that are not reconciled, because they are not scheduled yet or their
+	// nodes are not bound to any host.
+	skippedPods int
+}
+
+func (r *syncResult) change(kind string) *resourceChanges {
+	if r.changes[kind] == nil {
+		r.changes[kind] = new(resourceChanges)
+	}
+	return r.changes[kind]
+}
+
+// reconciler reconciles the kube resources of a cluster into cmdb.
+type reconciler struct {
+	cluster *ClusterConfig
+	conf    *Config
+	cmdb    cmdbClient
+}
+
+// reconcileState is the state shared by the reconciliation steps.
+type reconcileState struct {
+	kit     *rest.Kit
+	snap    *clusterSnapshot
+	cluster *types.Cluster
+	result  *syncResult
+
+	// nodes is the cmdb nodes keyed by node name.
+	nodes map[string]types.Node
+	// namespaces is the cmdb namespace ids keyed by namespace name.
+	namespaces map[string]int64
+	// workloads is the cmdb workload ids keyed by kind and namespace/name.
+	workloads map[types.WorkloadType]map[string]int64
+	// desiredWorkloads is the k8s workloads keyed by kind and namespace/name.
+	desiredWorkloads map[types.WorkloadType]map[string]types.WorkloadInterface
+	// desiredPods is the pods to be reconciled keyed by namespace/name.
+	desiredPods map[string]*desiredPod
+}
+
+// desiredPod is a k8s pod that is converted to the cmdb pod.
+type desiredPod struct {
+	pod        *types.Pod
+	containers []types.Container
+	nodeID     int64
+	hostID     int64
+	namespace  string
+	ref        types.Reference
+}
+
+func resourceKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// reconcile compares the kube resources in the cluster with the ones in cmdb, and creates, updates or deletes the
+// cmdb ones to make them consistent. the stale pods are deleted first and other stale resources are deleted last,
+// so that no resource is deleted while there are still resources that belong to it.
+func (r *reconciler) reconcile(kit *rest.Kit, snap *clusterSnapshot) (*syncResult, error) {
+	cluster, err := r.cmdb.GetCluster(kit, r.cluster.BizID, r.cluster.ClusterUID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &reconcileState{
+		kit:     kit,
+		snap:    snap,
+		cluster: cluster,
+		result: &syncResult{
+			clusterID: cluster.ID,
+			counts:    make(map[string]int),
+			changes:   make(map[string]*resourceChanges),
+		},
+		nodes:       make(map[string]types.Node),
+		namespaces:  make(map[string]int64),
+		workloads:   make(map[types.WorkloadType]map[string]int64),
+		desiredPods: make(map[string]*desiredPod),
+	}
+
+	currentPods, err := r.cmdb.ListPods(kit, r.cluster.BizID, cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []func(*reconcileState) error{r.reconcileNodes, r.reconcileNamespaces, r.reconcileWorkloads}
+	for _, step := range steps {
+		if err := step(state); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.reconcilePods(state, currentPods); err != nil {
+		return nil, err
+	}
+
+	if err := r.deleteStaleResources(state); err != nil {
+		return nil, err
+	}
+
+	return state.result, nil
+}
+
+// reconcileNodes creates and updates the nodes, the host of a new node is found by its internal ips.
+func (r *reconciler) reconcileNodes(state *reconcileState) error {
+	kit, bizID, clusterID := state.kit, r.cluster.BizID, state.cluster.ID
+	current, err := r.cmdb.ListNodes(kit, bizID, clusterID)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range current {
+		if node.Name != nil {
+			state.nodes[*node.Name] = node
+		}
+	}
+
+	toCreate := make([]types.OneNodeCreateOption, 0)
+	for _, k8sNode := range state.snap.nodes {
+		desired := convertNode(k8sNode)
+		exists, ok := state.nodes[k8sNode.Name]
+		if !ok {
+			hostID, err := r.cmdb.GetHostID(kit, r.cluster.CloudID, *desired.InternalIP)
+			if err != nil {
+				return err
+			}
+			if hostID == 0 {
+				blog.Warnf("host of node %s is not found, ips: %v, rid: %s", k8sNode.Name, *desired.InternalIP,
+					kit.Rid)
+			}
+			toCreate = append(toCreate, types.OneNodeCreateOption{BizID: bizID, HostID: hostID,
+				ClusterID: clusterID, Node: *desired})
+			continue
+		}
+
+		changed, err := specChanged(desired, exists)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+
+		// the name of the node is not editable.
+		desired.Name = nil
+		if err := r.cmdb.UpdateNode(kit, bizID, exists.ID, desired); err != nil {
+			return err
+		}
+		state.result.change(types.KubeNode).Updated++
+	}
+
+	for start := 0; start < len(toCreate); start += nodeBatchLimit {
+		end := minInt(start+nodeBatchLimit, len(toCreate))
+		if err := r.cmdb.CreateNodes(kit, bizID, toCreate[start:end]); err != nil {
+			return err
+		}
+	}
+	state.result.change(types.KubeNode).Created += len(toCreate)
+
+	if len(toCreate) > 0 {
+		// get the ids and hosts of the created nodes.
+		current, err = r.cmdb.ListNodes(kit, bizID, clusterID)
+		if err != nil {
+			return err
+		}
+		for _, node := range current {
+			if node.Name != nil {
+				state.nodes[*node.Name] = node
+			}
+		}
+	}
+
+	state.result.counts[types.KubeNode] = len(state.snap.nodes)
+	return nil
+}
+
+// reconcileNamespaces creates and updates the namespaces.
+func (r *reconciler) reconcileNamespaces(state *reconcileState) error {
+	kit, bizID, clusterID := state.kit, r.cluster.BizID, state.cluster.ID
+	current, err := r.cmdb.ListNamespaces(kit, bizID, clusterID)
+	if err != nil {
+		return err
+	}
+
+	currentMap := make(map[string]types.Namespace)
+	for _, ns := range current {
+		currentMap[ns.Name] = ns
+		state.namespaces[ns.Name] = ns.ID
+	}
+
+	quotas := make(map[string][]*corev1.ResourceQuota)
+	for _, quota := range state.snap.quotas {
+		quotas[quota.Namespace] = append(quotas[quota.Namespace], quota)
+	}
+
+	toCreate := make([]types.Namespace, 0)
+	for _, k8sNs := range state.snap.namespaces {
+		desired := convertNamespace(k8sNs, quotas[k8sNs.Name])
+		exists, ok := currentMap[k8sNs.Name]
+		if !ok {
+			desired.ClusterSpec = types.ClusterSpec{BizID: bizID, ClusterID: clusterID,
+				ClusterUID: r.cluster.ClusterUID}
+			toCreate = append(toCreate, *desired)
+			continue
+		}
+
+		changed, err := specChanged(desired, exists)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+
+		update := &types.Namespace{Labels: desired.Labels, ResourceQuotas: desired.ResourceQuotas}
+		if err := r.cmdb.UpdateNamespace(kit, bizID, exists.ID, update); err != nil {
+			return err
+		}
+		state.result.change(types.KubeNamespace).Updated++
+	}
+
+	for start := 0; start < len(toCreate); start += writeBatchLimit {
+		end := minInt(start+writeBatchLimit, len(toCreate))
+		if err := r.cmdb.CreateNamespaces(kit, bizID, toCreate[start:end]); err != nil {
+			return err
+		}
+	}
+	state.result.change(types.KubeNamespace).Created += len(toCreate)
+
+	if len(toCreate) > 0 {
+		current, err = r.cmdb.ListNamespaces(kit, bizID, clusterID)
+		if err != nil {
+			return err
+		}
+		for _, ns := range current {
+			state.namespaces[ns.Name] = ns.ID
+		}
+	}
+
+	state.result.counts[types.KubeNamespace] = len(state.snap.namespaces)
+	return nil
+}
+
+// desiredWorkloads converts the k8s workloads to the cmdb workloads keyed by kind and namespace/name, it also
+// resolves the workloads of the pods and generates the desired pods.
+func (r *reconciler) desiredWorkloads(state *reconcileState) map[types.WorkloadType]map[string]types.WorkloadInterface {
+	snap := state.snap
+	desired := make(map[types.WorkloadType]map[string]types.WorkloadInterface)
+	add := func(kind types.WorkloadType, namespace, name string, wl types.WorkloadInterface) {
+		if desired[kind] == nil {
+			desired[kind] = make(map[string]types.WorkloadInterface)
+		}
+		wl.SetWorkloadBase(types.WorkloadBase{Name: name, NamespaceSpec: types.NamespaceSpec{Namespace: namespace}})
+		desired[kind][resourceKey(namespace, name)] = wl
+	}
+
+	for _, d := range snap.deployments {
+		add(types.KubeDeployment, d.Namespace, d.Name, convertDeployment(d))
+	}
+	for _, s := range snap.statefulSets {
+		add(types.KubeStatefulSet, s.Namespace, s.Name, convertStatefulSet(s))
+	}
+	for _, d := range snap.daemonSets {
+		add(types.KubeDaemonSet, d.Namespace, d.Name, convertDaemonSet(d))
+	}
+	for _, j := range snap.jobs {
+		add(types.KubeJob, j.Namespace, j.Name, convertJob(j))
+	}
+	for _, c := range snap.cronJobs {
+		add(types.KubeCronJob, c.Namespace, c.Name, convertCronJob(c))
+	}
+	for kind, objs := range snap.gameWorkloads {
+		for _, obj := range objs {
+			add(kind, obj.GetNamespace(), obj.GetName(), convertGameWorkload(kind, obj))
+		}
+	}
+
+	owners := newOwnerResolver(snap, desired)
+	for _, pod := range snap.pods {
+		node, exists := state.nodes[pod.Spec.NodeName]
+		if pod.Spec.NodeName == "" || !exists || node.HostID == 0 {
+			state.result.skippedPods++
+			continue
+		}
+
+		kind, name := owners.resolve(pod)
+		if kind == types.KubePodWorkload {
+			if _, exists := desired[kind][resourceKey(pod.Namespace, name)]; !exists {
+				add(kind, pod.Namespace, name, newPodsWorkload())
+			}
+		}
+
+		state.desiredPods[resourceKey(pod.Namespace, pod.Name)] = &desiredPod{
+			pod:        convertPod(pod, r.conf.User),
+			containers: convertContainers(pod),
+			nodeID:     node.ID,
+			hostID:     node.HostID,
+			namespace:  pod.Namespace,
+			ref:        types.Reference{Kind: kind, Name: name},
+		}
+	}
+
+	return desired
+}
+
+// reconcileWorkloads creates and updates the workloads of all kinds.
+func (r *reconciler) reconcileWorkloads(state *reconcileState) error {
+	kit, bizID, clusterID := state.kit, r.cluster.BizID, state.cluster.ID
+	desired := r.desiredWorkloads(state)
+	state.desiredWorkloads = desired
+
+	for _, kind := range workloadKinds {
+		current, err := r.cmdb.ListWorkloads(kit, bizID, clusterID, kind)
+		if err != nil {
+			return err
+		}
+
+		ids := make(map[string]int64)
+		currentMap := make(map[string]types.WorkloadInterface)
+		for _, wl := range current {
+			base := wl.GetWorkloadBase()
+			key := resourceKey(base.Namespace, base.Name)
+			currentMap[key] = wl
+			ids[key] = base.ID
+		}
+		state.workloads[kind] = ids
+
+		toCreate := make([]types.WorkloadInterface, 0)
+		for key, wl := range desired[kind] {
+			base := wl.GetWorkloadBase()
+			exists, ok := currentMap[key]
+			if !ok {
+				nsID, ok := state.namespaces[base.Namespace]
+				if !ok {
+					blog.Warnf("namespace of %s workload %s is not found, rid: %s", kind, key, kit.Rid)
+					continue
+				}
+				base.NamespaceSpec = types.NamespaceSpec{
+					ClusterSpec: types.ClusterSpec{BizID: bizID, ClusterID: clusterID, ClusterUID: r.cluster.ClusterUID},
+					NamespaceID: nsID,
+					Namespace:   base.Namespace,
+				}
+				wl.SetWorkloadBase(base)
+				toCreate = append(toCreate, wl)
+				continue
+			}
+
+			changed, err := specChanged(wl, exists)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+
+			wl.SetWorkloadBase(types.WorkloadBase{})
+			if err := r.cmdb.UpdateWorkload(kit, bizID, kind, exists.GetWorkloadBase().ID, wl); err != nil {
+				return err
+			}
+			state.result.change(types.KubeWorkload).Updated++
+		}
+
+		for start := 0; start < len(toCreate); start += writeBatchLimit {
+			end := minInt(start+writeBatchLimit, len(toCreate))
+			if err := r.cmdb.CreateWorkloads(kit, bizID, kind, toCreate[start:end]); err != nil {
+				return err
+			}
+		}
+		state.result.change(types.KubeWorkload).Created += len(toCreate)
+
+		if len(toCreate) > 0 {
+			current, err = r.cmdb.ListWorkloads(kit, bizID, clusterID, kind)
+			if err != nil {
+				return err
+			}
+			for _, wl := range current {
+				base := wl.GetWorkloadBase()
+				ids[resourceKey(base.Namespace, base.Name)] = base.ID
+			}
+		}
+
+		state.result.counts[types.KubeWorkload] += len(desired[kind])
+	}
+
+	return nil
+}
+
+// reconcilePods deletes the stale pods and creates the new pods, a changed pod is deleted and created again,
+// because pods can not be updated in cmdb.
+func (r *reconciler) reconcilePods(state *reconcileState, currentPods []types.Pod) error {
+	kit, bizID, clusterID := state.kit, r.cluster.BizID, state.cluster.ID
+
+	containers, err := r.cmdb.ListContainers(kit, bizID, clusterID)
+	if err != nil {
+		return err
+	}
+
+	podContainers := make(map[int64][]types.Container)
+	for _, container := range containers {
+		podContainers[container.PodID] = append(podContainers[container.PodID], container)
+	}
+
+	for _, pod := range state.desiredPods {
+		pod.ref.ID = state.workloads[pod.ref.Kind][resourceKey(pod.namespace, pod.ref.Name)]
+	}
+
+	toDelete := make([]int64, 0)
+	unchanged := make(map[string]struct{})
+	recreated := 0
+	for _, current := range currentPods {
+		if current.Name == nil {
+			continue
+		}
+
+		key := resourceKey(current.Namespace, *current.Name)
+		desired, exists := state.desiredPods[key]
+		if !exists {
+			toDelete = append(toDelete, current.ID)
+			continue
+		}
+
+		changed, err := podChanged(desired, &current, podContainers[current.ID])
+		if err != nil {
+			return err
+		}
+		if changed {
+			toDelete = append(toDelete, current.ID)
+			recreated++
+			continue
+		}
+		unchanged[key] = struct{}{}
+	}
+
+	for start := 0; start < len(toDelete); start += writeBatchLimit {
+		end := minInt(start+writeBatchLimit, len(toDelete))
+		if err := r.cmdb.DeletePods(kit, bizID, toDelete[start:end]); err != nil {
+			return err
+		}
+	}
+
+	toCreate := make([]types.PodsInfo, 0)
+	for key, desired := range state.desiredPods {
+		if _, exists := unchanged[key]; exists {
+			continue
+		}
+
+		nsID := state.namespaces[desired.namespace]
+		if nsID == 0 || desired.ref.ID == 0 {
+			blog.Warnf("namespace or workload of pod %s is not found, rid: %s", key, kit.Rid)
+			state.result.skippedPods++
+			continue
+		}
+
+		toCreate = append(toCreate, types.PodsInfo{
+			Spec: types.SpecSimpleInfo{
+				ClusterID:   clusterID,
+				NamespaceID: nsID,
+				Ref:         desired.ref,
+				NodeID:      desired.nodeID,
+			},
+			HostID:     desired.hostID,
+			Pod:        *desired.pod,
+			Containers: desired.containers,
+		})
+	}
+
+	for start := 0; start < len(toCreate); start += writeBatchLimit {
+		end := minInt(start+writeBatchLimit, len(toCreate))
+		if err := r.cmdb.CreatePods(kit, bizID, toCreate[start:end]); err != nil {
+			return err
+		}
+	}
+
+	changes := state.result.change(types.KubePod)
+	changes.Updated += recreated
+	changes.Deleted += len(toDelete) - recreated
+	changes.Created += len(toCreate) - recreated
+	state.result.counts[types.KubePod] = len(state.desiredPods)
+
+	return nil
+}
+
+// podChanged checks if the pod or its containers are changed.
+func podChanged(desired *desiredPod, current *types.Pod, currentContainers []types.Container) (bool, error) {
+	if current.NodeID != desired.nodeID || current.HostID != desired.hostID || current.Ref == nil ||
+		current.Ref.Kind != desired.ref.Kind || current.Ref.ID != desired.ref.ID {
+		return true, nil
+	}
+
+	changed, err := specChanged(desired.pod, current)
+	if err != nil || changed {
+		return changed, err
+	}
+
+	return containersFingerprint(desired.containers) != containersFingerprint(currentContainers), nil
+}
+
+// containersFingerprint returns the fingerprint of the containers, which consists of the name, container id and
+// image of the containers, the containers are restarted or recreated if it is changed.
+func containersFingerprint(containers []types.Container) string {
+	items := make([]string, 0, len(containers))
+	for _, container := range containers {
+		items = append(items, fmt.Sprintf("%s|%s|%s", stringValue(container.Name),
+			stringValue(container.ContainerID), stringValue(container.Image)))
+	}
+	sort.Strings(items)
+	return fmt.Sprint(items)
+}
+
+// deleteStaleResources deletes the workloads, namespaces and nodes that are not in the cluster any more.
+func (r *reconciler) deleteStaleResources(state *reconcileState) error {
+	kit, bizID := state.kit, r.cluster.BizID
+
+	// workloads are deleted in the reverse order of creation.
+	for idx := len(workloadKinds) - 1; idx >= 0; idx-- {
+		kind := workloadKinds[idx]
+		desired := state.desiredWorkloads[kind]
+		toDelete := make([]int64, 0)
+		for key, id := range state.workloads[kind] {
+			if _, exists := desired[key]; !exists {
+				toDelete = append(toDelete, id)
+			}
+		}
+
+		for start := 0; start < len(toDelete); start += writeBatchLimit {
+			end := minInt(start+writeBatchLimit, len(toDelete))
+			if err := r.cmdb.DeleteWorkloads(kit, bizID, kind, toDelete[start:end]); err != nil {
+				return err
+			}
+		}
+		state.result.change(types.KubeWorkload).Deleted += len(toDelete)
+	}
+
+	desiredNs := make(map[string]struct{})
+	for _, ns := range state.snap.namespaces {
+		desiredNs[ns.Name] = struct{}{}
+	}
+	nsToDelete := make([]int64, 0)
+	for name, id := range state.namespaces {
+		if _, exists := desiredNs[name]; !exists {
+			nsToDelete = append(nsToDelete, id)
+		}
+	}
+	for start := 0; start < len(nsToDelete); start += writeBatchLimit {
+		end := minInt(start+writeBatchLimit, len(nsToDelete))
+		if err := r.cmdb.DeleteNamespaces(kit, bizID, nsToDelete[start:end]); err != nil {
+			return err
+		}
+	}
+	state.result.change(types.KubeNamespace).Deleted += len(nsToDelete)
+
+	desiredNodes := make(map[string]struct{})
+	for _, node := range state.snap.nodes {
+		desiredNodes[node.Name] = struct{}{}
+	}
+	nodesToDelete := make([]int64, 0)
+	for name, node := range state.nodes {
+		if _, exists := desiredNodes[name]; !exists {
+			nodesToDelete = append(nodesToDelete, node.ID)
+		}
+	}
+	for start := 0; start < len(nodesToDelete); start += nodeBatchLimit {
+		end := minInt(start+nodeBatchLimit, len(nodesToDelete))
+		if err := r.cmdb.DeleteNodes(kit, bizID, nodesToDelete[start:end]); err != nil {
+			return err
+		}
+	}
+	state.result.change(types.KubeNode).Deleted += len(nodesToDelete)
+
+	return nil
+}
+
+// specChanged checks if the fields of the desired resource are different from the current resource, the fields
+// that are not set in the desired resource and the ignoredCompareFields are not compared.
+func specChanged(desired, current interface{}) (bool, error) {
+	desiredFields, err := toFieldMap(desired)
+	if err != nil {
+		return false, err
+	}
+
+	currentFields, err := toFieldMap(current)
+	if err != nil {
+		return false, err
+	}
+
+	for field, value := range desiredFields {
+		if _, ignored := ignoredCompareFields[field]; ignored {
+			continue
+		}
+
+		if !reflect.DeepEqual(normalizeValue(value), normalizeValue(currentFields[field])) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func toFieldMap(data interface{}) (map[string]interface{}, error) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(js, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// normalizeValue normalizes the json decoded value, the empty values are all converted to nil, so that an empty
+// value is equal to an unset one.
+func normalizeValue(value interface{}) interface{} {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{})
+		for key, item := range val {
+			if item = normalizeValue(item); item != nil {
+				result[key] = item
+			}
+		}
+		if len(result) == 0 {
+			return nil
+		}
+		return result
+	case []interface{}:
+		if len(val) == 0 {
+			return nil
+		}
+		result := make([]interface{}, len(val))
+		for idx, item := range val {
+			result[idx] = normalizeValue(item)
+		}
+		return result
+	case string:
+		if val == "" {
+			return nil
+		}
+	case bool:
+		if !val {
+			return nil
+		}
+	case float64:
+		if val == 0 {
+			return nil
+		}
+	}
+	return value
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,112 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kubecollector
+
+import (
+	"sync"
+	"time"
+)
+
+// ClusterState is the collecting state of a cluster.
+type ClusterState string
+
+const (
+	// ClusterStatePending the cluster is waiting to be collected.
+	ClusterStatePending ClusterState = "pending"
+	// ClusterStateSyncing the cluster is being reconciled.
+	ClusterStateSyncing ClusterState = "syncing"
+	// ClusterStateSynced the last reconciliation of the cluster succeeded.
+	ClusterStateSynced ClusterState = "synced"
+	// ClusterStateFailed the last reconciliation of the cluster failed.
+	ClusterStateFailed ClusterState = "failed"
+	// ClusterStateStopped the cluster is not collected by this instance, because it is not the master.
+	ClusterStateStopped ClusterState = "stopped"
+)
+
+// ClusterStatus is the collecting status of a cluster.
+type ClusterStatus struct {
+	BizID      int64        `json:"bk_biz_id"`
+	ClusterUID string       `json:"cluster_uid"`
+	ClusterID  int64        `json:"bk_cluster_id"`
+	State      ClusterState `json:"state"`
+	// LastSyncTime is the finish time of the last reconciliation.
+	LastSyncTime *time.Time `json:"last_sync_time,omitempty"`
+	// LastSuccessTime is the finish time of the last successful reconciliation.
+	LastSuccessTime *time.Time `json:"last_success_time,omitempty"`
+	// LastError is the error of the last reconciliation, it is empty if the last reconciliation succeeded.
+	LastError string `json:"last_error,omitempty"`
+	// Counts is the number of the nodes, namespaces, workloads and pods in the cluster.
+	Counts map[string]int `json:"counts,omitempty"`
+	// Changes is the number of the changed resources of each kind in the last successful reconciliation.
+	Changes map[string]*resourceChanges `json:"changes,omitempty"`
+	// SkippedPods is the number of pods that are not collected, because they are not scheduled yet or their
+	// nodes are not bound to any host.
+	SkippedPods int `json:"skipped_pods"`
+}
+
+// statusStore stores the collecting status of a cluster.
+type statusStore struct {
+	lock   sync.RWMutex
+	status ClusterStatus
+}
+
+func newStatusStore(cluster *ClusterConfig) *statusStore {
+	return &statusStore{status: ClusterStatus{
+		BizID:      cluster.BizID,
+		ClusterUID: cluster.ClusterUID,
+		State:      ClusterStatePending,
+	}}
+}
+
+func (s *statusStore) get() ClusterStatus {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.status
+}
+
+func (s *statusStore) setState(state ClusterState) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status.State = state
+}
+
+// setFailed records the failure of a reconciliation or the error that prevents the cluster from being reconciled.
+func (s *statusStore) setFailed(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	s.status.State = ClusterStateFailed
+	s.status.LastSyncTime = &now
+	s.status.LastError = err.Error()
+}
+
+// setSynced records the result of a successful reconciliation.
+func (s *statusStore) setSynced(result *syncResult) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	s.status.State = ClusterStateSynced
+	s.status.ClusterID = result.clusterID
+	s.status.LastSyncTime = &now
+	s.status.LastSuccessTime = &now
+	s.status.LastError = ""
+	s.status.Counts = result.counts
+	s.status.Changes = result.changes
+	s.status.SkippedPods = result.skippedPods
+}
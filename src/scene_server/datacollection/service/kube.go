@@ -0,0 +1,41 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/datacollection/kubecollector"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// KubeCollectorStatus is the status of the kube collector.
+type KubeCollectorStatus struct {
+	Enabled  bool                          `json:"enabled"`
+	Clusters []kubecollector.ClusterStatus `json:"clusters"`
+}
+
+// GetKubeCollectorStatus get the collecting status of the clusters collected by the kube collector.
+func (s *Service) GetKubeCollectorStatus(req *restful.Request, resp *restful.Response) {
+	status := KubeCollectorStatus{Clusters: make([]kubecollector.ClusterStatus, 0)}
+	if s.kubeCollector != nil {
+		status.Enabled = true
+		status.Clusters = s.kubeCollector.Status()
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(status))
+}
@@ -29,6 +29,7 @@ import (
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
 	"configcenter/src/common/webservice/restfulservice"
+	"configcenter/src/scene_server/datacollection/kubecollector"
 	"configcenter/src/scene_server/datacollection/logics"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/redis"
@@ -50,6 +51,8 @@ type Service struct {
 	netCli  redis.Client
 
 	logics *logics.Logics
+
+	kubeCollector *kubecollector.Collector
 }
 
 // NewService creates a new Service object.
@@ -87,6 +90,11 @@ func (s *Service) SetNetCollectCli(db redis.Client) {
 	s.netCli = db
 }
 
+// SetKubeCollector setups kube collector.
+func (s *Service) SetKubeCollector(collector *kubecollector.Collector) {
+	s.kubeCollector = collector
+}
+
 // WebService setups a new restful web service.
 func (s *Service) WebService() *restful.Container {
 	container := restful.NewContainer()
@@ -121,6 +129,8 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/netcollect/collector/action/update").To(s.UpdateCollector))
 	api.Route(api.POST("/netcollect/collector/action/discover").To(s.DiscoverNetDevice))
 
+	api.Route(api.GET("/kube/collector/status").To(s.GetKubeCollectorStatus))
+
 	container.Add(api)
 
 	// common api
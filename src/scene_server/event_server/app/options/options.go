@@ -67,4 +67,7 @@ type Config struct {
 
 	// ApiConf gse apiServer connection config
 	ApiConf *client.GseConnConfig
+
+	// StreamOrigins allowed origins of the websocket event stream
+	StreamOrigins []string
 }
@@ -35,6 +35,7 @@ import (
 	"configcenter/src/scene_server/event_server/app/options"
 	svc "configcenter/src/scene_server/event_server/service"
 	"configcenter/src/scene_server/event_server/sync/hostidentifier"
+	"configcenter/src/scene_server/event_server/sync/subscription"
 	eventtype "configcenter/src/scene_server/event_server/types"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/mongo/local"
@@ -188,6 +189,14 @@ func (es *EventServer) initConfigs() error {
 		return err
 	}
 
+	if cc.IsExist("eventServer.stream.allowedOrigins") {
+		es.config.StreamOrigins, err = cc.StringSlice("eventServer.stream.allowedOrigins")
+		if err != nil {
+			blog.Errorf("parse eventServer stream allowed origins config error, err: %v", err)
+			return err
+		}
+	}
+
 	identifierConf, err := hostidentifier.ParseIdentifierConf()
 	if err != nil {
 		blog.Errorf("parse eventServer host identifier config error, err: %v", err)
@@ -243,6 +252,10 @@ func (es *EventServer) initModules() error {
 	es.service.SetCache(redisCli)
 	blog.Infof("init modules, connected to cc redis, %+v", es.config.Redis)
 
+	if err = es.service.SetStreamOrigins(es.config.StreamOrigins); err != nil {
+		return err
+	}
+
 	// initialize auth authorizer
 	es.service.SetAuthorizer(iam.NewAuthorizer(es.engine.CoreAPI))
 
@@ -275,6 +288,9 @@ func (es *EventServer) Run() error {
 	}
 	blog.Info("init modules success!")
 
+	// deliver the events of the event subscriptions to their callback urls
+	go subscription.NewSubscription(es.ctx, es.engine, es.db).Run()
+
 	if err := es.runSyncData(); err != nil {
 		return err
 	}
@@ -19,7 +19,9 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/url"
 
 	"configcenter/src/ac"
 	"configcenter/src/ac/extensions"
@@ -52,6 +54,9 @@ type Service struct {
 
 	// SyncData is sync host identifier operator
 	SyncData *hostidentifier.HostIdentifier
+
+	// streamOrigins is the allowed origins of the websocket event stream, which are the cmdb site urls
+	streamOrigins []*url.URL
 }
 
 // NewService creates a new Service object.
@@ -69,6 +74,23 @@ func (s *Service) SetCache(db redis.Client) {
 	s.cache = db
 }
 
+// SetStreamOrigins setups the allowed origins of the websocket event stream
+func (s *Service) SetStreamOrigins(origins []string) error {
+	s.streamOrigins = make([]*url.URL, 0, len(origins))
+	for _, origin := range origins {
+		if len(origin) == 0 {
+			continue
+		}
+
+		originURL, err := url.Parse(origin)
+		if err != nil || len(originURL.Scheme) == 0 || len(originURL.Host) == 0 {
+			return fmt.Errorf("event stream origin %s is invalid", origin)
+		}
+		s.streamOrigins = append(s.streamOrigins, originURL)
+	}
+	return nil
+}
+
 // SetAuthorizer TODO
 func (s *Service) SetAuthorizer(authorizer ac.AuthorizeInterface) {
 	s.authorizer = authorizer
@@ -116,8 +138,31 @@ func (s *Service) initService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/host_identifier_push_result",
 		Handler: s.GetHostIdentifierPushResult})
 
+	// event subscription api
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/event_subscription",
+		Handler: s.CreateEventSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/event_subscription/{id}",
+		Handler: s.UpdateEventSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/event_subscription/{id}",
+		Handler: s.DeleteEventSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/pause/event_subscription/{id}",
+		Handler: s.PauseEventSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/resume/event_subscription/{id}",
+		Handler: s.ResumeEventSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/event_subscription",
+		Handler: s.ListEventSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/event_subscription/delivery",
+		Handler: s.ListSubscriptionDelivery})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/event_subscription/dead_letter",
+		Handler: s.ListSubscriptionDeadLetter})
+
 	utility.AddToRestfulWebService(web)
 
+	// resource watch stream api writes the response directly, so that the events can be pushed to client continually.
+	// server-sent events stream is requested by POST, websocket stream is requested by GET with upgrade header.
+	web.Route(web.POST("/watch/stream/resource/{resource}").Produces(mimeEventStream, restful.MIME_JSON).
+		To(s.WatchEventStream))
+	web.Route(web.GET("/watch/stream/resource/{resource}").To(s.WatchEventStream))
 }
 
 // Healthz is a HTTP restful interface for health check.
@@ -0,0 +1,413 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/watch"
+
+	"github.com/emicklei/go-restful/v3"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// streamHeartbeatInterval is the interval to send heartbeat to the client to keep the stream alive
+	streamHeartbeatInterval = 15 * time.Second
+	// streamWriteTimeout is the max duration to write one message to the websocket client, the client that can not
+	// receive a message in this duration is regarded as broken and the stream is closed
+	streamWriteTimeout = 30 * time.Second
+	// streamBufferSize is the max number of messages waiting to be sent to the client, watching is paused when the
+	// buffer is full, so that the slow client will not cause the watched events to pile up in memory
+	streamBufferSize = 200
+	// mimeEventStream is the content type of server-sent events
+	mimeEventStream = "text/event-stream"
+)
+
+// WatchEventStream watch resource events with a persistent stream, the events are pushed to the client continually
+// until the client closes the stream. if the request asks for upgrading to websocket, the events are sent by the
+// websocket connection, otherwise they are sent as server-sent events.
+func (s *Service) WatchEventStream(req *restful.Request, resp *restful.Response) {
+	kit := rest.NewKitFromHeader(req.Request.Header, s.engine.CCErr)
+
+	isWebsocket := strings.EqualFold(req.Request.Header.Get("Upgrade"), "websocket")
+
+	var options *watch.WatchEventOptions
+	var err error
+	if isWebsocket {
+		options, err = parseStreamOptionsFromQuery(kit, req.Request)
+	} else {
+		options, err = parseStreamOptionsFromBody(kit, req)
+	}
+	if err != nil {
+		blog.Errorf("watch event stream, but parse options failed, err: %v, rid: %s", err, kit.Rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+
+	options.Resource = watch.CursorType(req.PathParameter("resource"))
+	if err = options.Validate(false); err != nil {
+		blog.Errorf("watch event stream, but got invalid options, err: %v, rid: %s", err, kit.Rid)
+		_ = resp.WriteError(http.StatusBadRequest,
+			&metadata.RespError{Msg: kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	if isWebsocket {
+		server := websocket.Server{
+			Handshake: s.checkStreamOrigin,
+			Handler: func(conn *websocket.Conn) {
+				s.serveEventStream(kit, req.Request.Context(), options, newWebsocketStream(conn))
+			},
+		}
+		server.ServeHTTP(resp, req.Request)
+		return
+	}
+
+	sse, err := newServerSentEventStream(resp)
+	if err != nil {
+		blog.Errorf("watch event stream, but response do not support streaming, err: %v, rid: %s", err, kit.Rid)
+		_ = resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+	s.serveEventStream(kit, req.Request.Context(), options, sse)
+}
+
+// checkStreamOrigin check the origin of the websocket handshake request to prevent cross site websocket hijacking,
+// browsers always send the origin, it must be one of the cmdb site urls. requests without origin are not sent by
+// browsers, they are authorized by the credentials in the header like the other apis.
+func (s *Service) checkStreamOrigin(config *websocket.Config, req *http.Request) error {
+	origin, err := websocket.Origin(config, req)
+	if err != nil {
+		return err
+	}
+
+	if origin != nil && !isStreamOriginAllowed(origin, s.streamOrigins) {
+		blog.Errorf("websocket event stream origin %s is not allowed, rid: %s", origin, httpheader.GetRid(req.Header))
+		return fmt.Errorf("origin %s is not allowed", origin)
+	}
+
+	config.Origin = origin
+	return nil
+}
+
+// isStreamOriginAllowed returns if the origin has the same scheme and host with one of the allowed origins
+func isStreamOriginAllowed(origin *url.URL, allowedOrigins []*url.URL) bool {
+	for _, allowed := range allowedOrigins {
+		if strings.EqualFold(origin.Scheme, allowed.Scheme) && strings.EqualFold(origin.Host, allowed.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStreamOptionsFromBody parse server-sent events stream options from request body, the Last-Event-ID header that
+// is set by the client when reconnecting takes precedence over the bk_cursor in the body
+func parseStreamOptionsFromBody(kit *rest.Kit, req *restful.Request) (*watch.WatchEventOptions, error) {
+	body, err := io.ReadAll(req.Request.Body)
+	if err != nil {
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPReadBodyFailed)
+	}
+
+	options := new(watch.WatchEventOptions)
+	if len(body) != 0 {
+		if err = json.Unmarshal(body, options); err != nil {
+			return nil, kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+		}
+	}
+
+	if lastEventID := req.Request.Header.Get("Last-Event-ID"); lastEventID != "" {
+		options.Cursor = lastEventID
+		options.StartFrom = 0
+	}
+	return options, nil
+}
+
+// parseStreamOptionsFromQuery parse websocket stream options from url query, since websocket handshake request has
+// no body. bk_event_types and bk_fields are separated by comma, bk_filter is the json string of the filter.
+func parseStreamOptionsFromQuery(kit *rest.Kit, req *http.Request) (*watch.WatchEventOptions, error) {
+	query := req.URL.Query()
+	options := &watch.WatchEventOptions{
+		Cursor: query.Get("bk_cursor"),
+	}
+
+	if startFrom := query.Get("bk_start_from"); startFrom != "" {
+		var err error
+		options.StartFrom, err = strconv.ParseInt(startFrom, 10, 64)
+		if err != nil {
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_start_from")
+		}
+	}
+
+	if eventTypes := query.Get("bk_event_types"); eventTypes != "" {
+		for _, eventType := range strings.Split(eventTypes, ",") {
+			options.EventTypes = append(options.EventTypes, watch.EventType(eventType))
+		}
+	}
+
+	if fields := query.Get("bk_fields"); fields != "" {
+		options.Fields = strings.Split(fields, ",")
+	}
+
+	if filter := query.Get("bk_filter"); filter != "" {
+		if err := json.UnmarshalFromString(filter, &options.Filter); err != nil {
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_filter")
+		}
+	}
+
+	return options, nil
+}
+
+// eventStream is the stream to push the watch stream messages to the client
+type eventStream interface {
+	// send the message to the client
+	send(msg *watch.StreamMessage) error
+	// closed returns a channel that is closed when the client closes the stream
+	closed() <-chan struct{}
+}
+
+// serveEventStream watch events continually and push them to the client, the events are watched in another goroutine,
+// and are sent to the client through a buffered channel, so that the watching is paused when the client is slow.
+func (s *Service) serveEventStream(kit *rest.Kit, reqCtx context.Context, options *watch.WatchEventOptions,
+	stream eventStream) {
+
+	ctx, cancel := context.WithCancel(reqCtx)
+	defer cancel()
+
+	msgCh := make(chan *watch.StreamMessage, streamBufferSize)
+	go s.loopWatchStreamEvents(ctx, kit, options, msgCh)
+
+	blog.Infof("start watch %s event stream, cursor: %s, rid: %s", options.Resource, options.Cursor, kit.Rid)
+
+	ticker := time.NewTicker(streamHeartbeatInterval)
+	defer ticker.Stop()
+
+	cursor := options.Cursor
+	for {
+		select {
+		case <-ctx.Done():
+			blog.Infof("watch %s event stream is closed by client, rid: %s", options.Resource, kit.Rid)
+			return
+		case <-stream.closed():
+			blog.Infof("watch %s event stream is closed by client, rid: %s", options.Resource, kit.Rid)
+			return
+		case <-ticker.C:
+			if err := stream.send(&watch.StreamMessage{Type: watch.StreamHeartbeat, Cursor: cursor}); err != nil {
+				blog.Errorf("send heartbeat to %s event stream failed, err: %v, rid: %s", options.Resource, err,
+					kit.Rid)
+				return
+			}
+		case msg := <-msgCh:
+			if len(msg.Cursor) != 0 {
+				cursor = msg.Cursor
+			}
+
+			// heartbeat from the watch loop only records the latest cursor, it is sent by ticker
+			if msg.Type == watch.StreamHeartbeat {
+				continue
+			}
+
+			if err := stream.send(msg); err != nil {
+				blog.Errorf("send message to %s event stream failed, err: %v, rid: %s", options.Resource, err,
+					kit.Rid)
+				return
+			}
+
+			if msg.Type == watch.StreamError {
+				return
+			}
+		}
+	}
+}
+
+// loopWatchStreamEvents watch events from cache service continually with the last watched cursor, and put them into
+// the message channel, it stops when the context is done or an error occurs.
+func (s *Service) loopWatchStreamEvents(ctx context.Context, kit *rest.Kit, options *watch.WatchEventOptions,
+	msgCh chan<- *watch.StreamMessage) {
+
+	opts := *options
+	for {
+		resp, err := s.engine.CoreAPI.CacheService().Cache().Event().InnerWatchEvent(ctx, kit.Header, &opts)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			blog.Errorf("watch %s event stream, but call cache service failed, cursor: %s, err: %v, rid: %s",
+				opts.Resource, opts.Cursor, err, kit.Rid)
+			putStreamMessage(ctx, msgCh, newStreamErrorMessage(err))
+			return
+		}
+
+		for _, msg := range parseStreamMessages(resp) {
+			if !putStreamMessage(ctx, msgCh, msg) {
+				return
+			}
+			if len(msg.Cursor) != 0 {
+				opts.Cursor = msg.Cursor
+			}
+		}
+
+		// start from time and watch from now is only used in the first round, then watch with cursor continually
+		opts.StartFrom = 0
+		if len(opts.Cursor) == 0 {
+			opts.Cursor = watch.NoEventCursor
+		}
+	}
+}
+
+// parseStreamMessages parse watch response into stream messages, the response without hit events is converted to a
+// heartbeat message with the latest watched cursor
+func parseStreamMessages(resp *watch.WatchResp) []*watch.StreamMessage {
+	if resp == nil || len(resp.Events) == 0 {
+		return make([]*watch.StreamMessage, 0)
+	}
+
+	if !resp.Watched {
+		cursor := resp.Events[0].Cursor
+		if cursor == watch.NoEventCursor {
+			cursor = ""
+		}
+		return []*watch.StreamMessage{{Type: watch.StreamHeartbeat, Cursor: cursor}}
+	}
+
+	messages := make([]*watch.StreamMessage, len(resp.Events))
+	for idx, event := range resp.Events {
+		messages[idx] = &watch.StreamMessage{Type: watch.StreamEvent, Cursor: event.Cursor, Event: event}
+	}
+	return messages
+}
+
+// putStreamMessage put message into message channel, it blocks when the channel is full until the context is done,
+// returns false if the context is done
+func putStreamMessage(ctx context.Context, msgCh chan<- *watch.StreamMessage, msg *watch.StreamMessage) bool {
+	select {
+	case msgCh <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func newStreamErrorMessage(err error) *watch.StreamMessage {
+	msg := &watch.StreamMessage{Type: watch.StreamError, ErrCode: common.CCErrCommHTTPDoRequestFailed,
+		ErrMsg: err.Error()}
+	if ccErr, ok := err.(errors.CCErrorCoder); ok {
+		msg.ErrCode = ccErr.GetCode()
+	}
+	return msg
+}
+
+// serverSentEventStream is the event stream using server-sent events
+type serverSentEventStream struct {
+	resp    http.ResponseWriter
+	flusher http.Flusher
+	done    chan struct{}
+}
+
+func newServerSentEventStream(resp *restful.Response) (*serverSentEventStream, error) {
+	flusher, ok := resp.ResponseWriter.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("response writer do not support flush")
+	}
+
+	resp.Header().Set("Content-Type", mimeEventStream)
+	resp.Header().Set("Cache-Control", "no-cache")
+	resp.Header().Set("Connection", "keep-alive")
+	// disable the response buffering of nginx, so that the events can be pushed to the client in time
+	resp.Header().Set("X-Accel-Buffering", "no")
+	resp.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &serverSentEventStream{resp: resp, flusher: flusher, done: make(chan struct{})}, nil
+}
+
+// send the message as a server-sent event, the cursor is used as the event id so that the client can resume watching
+// with the Last-Event-ID header when reconnecting
+func (s *serverSentEventStream) send(msg *watch.StreamMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if len(msg.Cursor) != 0 {
+		if _, err = fmt.Fprintf(s.resp, "id: %s\n", msg.Cursor); err != nil {
+			return err
+		}
+	}
+
+	if _, err = fmt.Fprintf(s.resp, "event: %s\ndata: %s\n\n", msg.Type, data); err != nil {
+		return err
+	}
+
+	s.flusher.Flush()
+	return nil
+}
+
+// closed server-sent events stream is closed when the request context is done, so this channel is never closed
+func (s *serverSentEventStream) closed() <-chan struct{} {
+	return s.done
+}
+
+// websocketStream is the event stream using websocket, every message is sent as a json text message
+type websocketStream struct {
+	conn *websocket.Conn
+	done chan struct{}
+}
+
+func newWebsocketStream(conn *websocket.Conn) *websocketStream {
+	stream := &websocketStream{conn: conn, done: make(chan struct{})}
+
+	// read from the connection to detect the close of the client, messages sent by the client are ignored
+	go func() {
+		defer close(stream.done)
+		for {
+			var msg string
+			if err := websocket.Message.Receive(conn, &msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	return stream
+}
+
+// send the message as a json text message, the client that can not receive a message in time is regarded as broken
+func (w *websocketStream) send(msg *watch.StreamMessage) error {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		return err
+	}
+	return websocket.JSON.Send(w.conn, msg)
+}
+
+func (w *websocketStream) closed() <-chan struct{} {
+	return w.done
+}
@@ -0,0 +1,293 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/watch"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func newTestStreamService(t *testing.T, origins ...string) *Service {
+	s := NewService(context.Background(), &backbone.Engine{CCErr: errors.NewFromCtx(errors.EmptyErrorsSetting)})
+	require.NoError(t, s.SetStreamOrigins(origins))
+	return s
+}
+
+func newTestKit() *rest.Kit {
+	return rest.NewKitFromHeader(http.Header{}, errors.NewFromCtx(errors.EmptyErrorsSetting))
+}
+
+func TestSetStreamOrigins(t *testing.T) {
+	s := newTestStreamService(t, "http://cmdb.example.com", "", "https://cmdb.example.com:8443/")
+	require.Len(t, s.streamOrigins, 2)
+
+	for _, origin := range []string{"cmdb.example.com", "/cmdb", "http://%zz"} {
+		require.Error(t, s.SetStreamOrigins([]string{origin}), origin)
+	}
+}
+
+func TestCheckStreamOrigin(t *testing.T) {
+	s := newTestStreamService(t, "http://cmdb.example.com", "https://cmdb.example.com:8443")
+
+	testCases := []struct {
+		name    string
+		origin  string
+		isError bool
+	}{
+		{name: "site url", origin: "http://cmdb.example.com"},
+		{name: "site url with different case", origin: "HTTP://CMDB.example.com"},
+		{name: "site url with port", origin: "https://cmdb.example.com:8443"},
+		{name: "request without origin", origin: ""},
+		{name: "other host", origin: "http://evil.example.com", isError: true},
+		{name: "other scheme", origin: "https://cmdb.example.com", isError: true},
+		{name: "other port", origin: "http://cmdb.example.com:8080", isError: true},
+		{name: "null origin", origin: "null", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/event/v3/watch/stream/resource/host", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+
+			config := &websocket.Config{Version: websocket.ProtocolVersionHybi13}
+			err := s.checkStreamOrigin(config, req)
+			if tc.isError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			if tc.origin == "" {
+				require.Nil(t, config.Origin)
+			} else {
+				require.True(t, strings.EqualFold(tc.origin, config.Origin.String()))
+			}
+		})
+	}
+}
+
+func newTestStreamServer(s *Service) *httptest.Server {
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/watch/stream/resource/{resource}").To(s.WatchEventStream))
+	ws.Route(ws.POST("/watch/stream/resource/{resource}").To(s.WatchEventStream))
+	container := restful.NewContainer()
+	container.Add(ws)
+	return httptest.NewServer(container)
+}
+
+func TestWatchEventStream(t *testing.T) {
+	server := newTestStreamServer(newTestStreamService(t, "http://cmdb.example.com"))
+	defer server.Close()
+
+	// server-sent events stream with invalid options
+	resp, err := http.Post(server.URL+"/watch/stream/resource/host", "application/json",
+		strings.NewReader(`{"bk_cursor":"cursor","bk_start_from":1}`))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Post(server.URL+"/watch/stream/resource/host", "application/json", strings.NewReader(`{`))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// websocket stream with invalid query options
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/watch/stream/resource/host?bk_start_from=abc", nil)
+	require.NoError(t, err)
+	req.Header.Set("Upgrade", "websocket")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// websocket stream from other site is rejected in the handshake
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/watch/stream/resource/biz_set?bk_fields=bk_biz_set_id"
+	config, err := websocket.NewConfig(wsURL, "http://evil.example.com")
+	require.NoError(t, err)
+	_, err = websocket.DialConfig(config)
+	require.Error(t, err)
+}
+
+func TestParseStreamOptionsFromQuery(t *testing.T) {
+	kit := newTestKit()
+
+	query := url.Values{
+		"bk_cursor":      []string{"cursor"},
+		"bk_event_types": []string{"create,delete"},
+		"bk_fields":      []string{"bk_host_id,bk_host_innerip"},
+		"bk_filter":      []string{`{"bk_sub_resource":"switch","updated_fields":["bk_host_name"]}`},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/watch?"+query.Encode(), nil)
+	options, err := parseStreamOptionsFromQuery(kit, req)
+	require.NoError(t, err)
+	require.Equal(t, &watch.WatchEventOptions{
+		EventTypes: []watch.EventType{watch.Create, watch.Delete},
+		Fields:     []string{common.BKHostIDField, common.BKHostInnerIPField},
+		Cursor:     "cursor",
+		Filter:     watch.WatchEventFilter{SubResource: "switch", UpdatedFields: []string{common.BKHostNameField}},
+	}, options)
+
+	req = httptest.NewRequest(http.MethodGet, "/watch?bk_start_from=100", nil)
+	options, err = parseStreamOptionsFromQuery(kit, req)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), options.StartFrom)
+
+	for _, rawQuery := range []string{"bk_start_from=abc", "bk_filter=%7B"} {
+		req = httptest.NewRequest(http.MethodGet, "/watch?"+rawQuery, nil)
+		_, err = parseStreamOptionsFromQuery(kit, req)
+		require.Error(t, err, rawQuery)
+	}
+}
+
+func TestParseStreamOptionsFromBody(t *testing.T) {
+	kit := newTestKit()
+
+	newRequest := func(body, lastEventID string) *restful.Request {
+		req := httptest.NewRequest(http.MethodPost, "/watch", strings.NewReader(body))
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		return restful.NewRequest(req)
+	}
+
+	options, err := parseStreamOptionsFromBody(kit, newRequest(`{"bk_start_from":100,"bk_fields":["bk_host_id"]}`, ""))
+	require.NoError(t, err)
+	require.Equal(t, &watch.WatchEventOptions{StartFrom: 100, Fields: []string{common.BKHostIDField}}, options)
+
+	// the cursor of the reconnecting client takes precedence
+	options, err = parseStreamOptionsFromBody(kit, newRequest(`{"bk_start_from":100,"bk_cursor":"old"}`, "new"))
+	require.NoError(t, err)
+	require.Equal(t, &watch.WatchEventOptions{Cursor: "new"}, options)
+
+	options, err = parseStreamOptionsFromBody(kit, newRequest("", ""))
+	require.NoError(t, err)
+	require.Equal(t, &watch.WatchEventOptions{}, options)
+
+	_, err = parseStreamOptionsFromBody(kit, newRequest("{", ""))
+	require.Error(t, err)
+}
+
+func TestParseStreamMessages(t *testing.T) {
+	event1 := &watch.WatchEventDetail{Cursor: "c1", Resource: watch.Host, EventType: watch.Create}
+	event2 := &watch.WatchEventDetail{Cursor: "c2", Resource: watch.Host, EventType: watch.Delete}
+
+	testCases := []struct {
+		name   string
+		resp   *watch.WatchResp
+		expect []*watch.StreamMessage
+	}{
+		{
+			name:   "nil response",
+			expect: []*watch.StreamMessage{},
+		},
+		{
+			name:   "no events",
+			resp:   &watch.WatchResp{Watched: true},
+			expect: []*watch.StreamMessage{},
+		},
+		{
+			name:   "not watched",
+			resp:   &watch.WatchResp{Events: []*watch.WatchEventDetail{{Cursor: "latest"}}},
+			expect: []*watch.StreamMessage{{Type: watch.StreamHeartbeat, Cursor: "latest"}},
+		},
+		{
+			name:   "not watched without any event",
+			resp:   &watch.WatchResp{Events: []*watch.WatchEventDetail{{Cursor: watch.NoEventCursor}}},
+			expect: []*watch.StreamMessage{{Type: watch.StreamHeartbeat}},
+		},
+		{
+			name: "watched",
+			resp: &watch.WatchResp{Watched: true, Events: []*watch.WatchEventDetail{event1, event2}},
+			expect: []*watch.StreamMessage{
+				{Type: watch.StreamEvent, Cursor: "c1", Event: event1},
+				{Type: watch.StreamEvent, Cursor: "c2", Event: event2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expect, parseStreamMessages(tc.resp))
+		})
+	}
+}
+
+func TestNewStreamErrorMessage(t *testing.T) {
+	msg := newStreamErrorMessage(errors.New(common.CCErrCommParamsInvalid, "invalid"))
+	require.Equal(t, &watch.StreamMessage{Type: watch.StreamError, ErrCode: common.CCErrCommParamsInvalid,
+		ErrMsg: "invalid"}, msg)
+
+	msg = newStreamErrorMessage(context.DeadlineExceeded)
+	require.Equal(t, common.CCErrCommHTTPDoRequestFailed, msg.ErrCode)
+}
+
+func TestPutStreamMessage(t *testing.T) {
+	msgCh := make(chan *watch.StreamMessage, 1)
+	require.True(t, putStreamMessage(context.Background(), msgCh, &watch.StreamMessage{}))
+
+	// the channel is full, it returns when the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.False(t, putStreamMessage(ctx, msgCh, &watch.StreamMessage{}))
+}
+
+func TestServerSentEventStream(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	stream, err := newServerSentEventStream(restful.NewResponse(recorder))
+	require.NoError(t, err)
+	require.Equal(t, mimeEventStream, recorder.Header().Get("Content-Type"))
+
+	require.NoError(t, stream.send(&watch.StreamMessage{Type: watch.StreamHeartbeat}))
+	require.NoError(t, stream.send(&watch.StreamMessage{Type: watch.StreamHeartbeat, Cursor: "c1"}))
+	expect := "event: heartbeat\ndata: {\"type\":\"heartbeat\"}\n\n" +
+		"id: c1\nevent: heartbeat\ndata: {\"type\":\"heartbeat\",\"bk_cursor\":\"c1\"}\n\n"
+	require.Equal(t, expect, recorder.Body.String())
+	require.True(t, recorder.Flushed)
+}
+
+func TestWebsocketStream(t *testing.T) {
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		stream := newWebsocketStream(conn)
+		require.NoError(t, stream.send(&watch.StreamMessage{Type: watch.StreamEvent, Cursor: "c1"}))
+		<-stream.closed()
+	}))
+	defer server.Close()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	require.NoError(t, err)
+
+	msg := new(watch.StreamMessage)
+	require.NoError(t, websocket.JSON.Receive(conn, msg))
+	require.Equal(t, &watch.StreamMessage{Type: watch.StreamEvent, Cursor: "c1"}, msg)
+	require.NoError(t, conn.Close())
+}
@@ -0,0 +1,560 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/watch"
+	"configcenter/src/scene_server/event_server/sync/subscription"
+)
+
+// CreateEventSubscription create event subscription that pushes the events of a resource to the callback url
+func (s *Service) CreateEventSubscription(ctx *rest.Contexts) {
+	opt := new(watch.CreateEventSubscriptionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		blog.Errorf("create event subscription option is invalid, err: %v, opt: %+v, rid: %s", rawErr, opt,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if !s.authorizeSubscription(ctx, opt.Resource, &opt.Filter) {
+		return
+	}
+
+	if err := s.checkSubscriptionNameDuplicate(ctx.Kit, 0, opt.Name); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	// subscription starts from the latest event if start from time is not set, so that the existing events are skipped
+	cursor := ""
+	if opt.StartFrom == 0 {
+		var err error
+		cursor, err = subscription.GetLatestCursor(ctx.Kit.Ctx, s.engine, ctx.Kit.Header, opt.Resource)
+		if err != nil {
+			blog.Errorf("get %s latest cursor failed, err: %v, rid: %s", opt.Resource, err, ctx.Kit.Rid)
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
+	id, err := s.db.NextSequence(ctx.Kit.Ctx, common.BKTableNameEventSubscription)
+	if err != nil {
+		blog.Errorf("generate event subscription id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	now := metadata.Now()
+	sub := &watch.EventSubscription{
+		ID:              int64(id),
+		Name:            opt.Name,
+		CallbackURL:     opt.CallbackURL,
+		Secret:          opt.Secret,
+		Resource:        opt.Resource,
+		EventTypes:      opt.EventTypes,
+		Fields:          opt.Fields,
+		Filter:          opt.Filter,
+		BatchSize:       opt.BatchSize,
+		Timeout:         opt.Timeout,
+		MaxRetries:      opt.MaxRetries,
+		Status:          watch.SubscriptionActive,
+		Cursor:          cursor,
+		StartFrom:       opt.StartFrom,
+		SupplierAccount: ctx.Kit.SupplierAccount,
+		Creator:         ctx.Kit.User,
+		Modifier:        ctx.Kit.User,
+		CreateTime:      now,
+		LastTime:        now,
+	}
+
+	if err := s.db.Table(common.BKTableNameEventSubscription).Insert(ctx.Kit.Ctx, sub); err != nil {
+		blog.Errorf("create event subscription failed, err: %v, data: %+v, rid: %s", err, sub, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBInsertFailed))
+		return
+	}
+
+	ctx.RespEntity(metadata.RspID{ID: sub.ID})
+}
+
+// UpdateEventSubscription update event subscription, the subscription continues from where it is delivered
+func (s *Service) UpdateEventSubscription(ctx *rest.Contexts) {
+	sub, ok := s.getSubscriptionByPath(ctx)
+	if !ok {
+		return
+	}
+
+	opt := new(watch.UpdateEventSubscriptionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(sub); rawErr.ErrCode != 0 {
+		blog.Errorf("update event subscription option is invalid, err: %v, opt: %+v, rid: %s", rawErr, opt,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if !s.authorizeSubscription(ctx, sub.Resource, &sub.Filter) {
+		return
+	}
+
+	if opt.Name != nil {
+		if err := s.checkSubscriptionNameDuplicate(ctx.Kit, sub.ID, sub.Name); err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
+	data := mapstr.MapStr{
+		common.BKFieldName: sub.Name,
+		"callback_url":     sub.CallbackURL,
+		"secret":           sub.Secret,
+		"bk_event_types":   sub.EventTypes,
+		"bk_fields":        sub.Fields,
+		"bk_filter":        sub.Filter,
+		"batch_size":       sub.BatchSize,
+		"timeout":          sub.Timeout,
+		"max_retries":      sub.MaxRetries,
+	}
+	if !s.updateSubscription(ctx, sub.ID, data) {
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteEventSubscription delete event subscription with its delivery histories and dead letters
+func (s *Service) DeleteEventSubscription(ctx *rest.Contexts) {
+	sub, ok := s.getSubscriptionByPath(ctx)
+	if !ok {
+		return
+	}
+
+	if !s.authorizeSubscription(ctx, sub.Resource, &sub.Filter) {
+		return
+	}
+
+	cond := mapstr.MapStr{common.BKFieldID: sub.ID}
+	if err := s.db.Table(common.BKTableNameEventSubscription).Delete(ctx.Kit.Ctx, cond); err != nil {
+		blog.Errorf("delete event subscription %d failed, err: %v, rid: %s", sub.ID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBDeleteFailed))
+		return
+	}
+
+	recordCond := mapstr.MapStr{"subscription_id": sub.ID}
+	for _, table := range []string{common.BKTableNameEventSubscriptionDelivery,
+		common.BKTableNameEventSubscriptionDeadLetter} {
+
+		if err := s.db.Table(table).Delete(ctx.Kit.Ctx, recordCond); err != nil {
+			blog.Errorf("delete event subscription %d records in %s failed, err: %v, rid: %s", sub.ID, table, err,
+				ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBDeleteFailed))
+			return
+		}
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// PauseEventSubscription pause event subscription, its events are not delivered until it is resumed
+func (s *Service) PauseEventSubscription(ctx *rest.Contexts) {
+	s.setSubscriptionStatus(ctx, watch.SubscriptionPaused)
+}
+
+// ResumeEventSubscription resume event subscription, its events are delivered from where it is paused
+func (s *Service) ResumeEventSubscription(ctx *rest.Contexts) {
+	s.setSubscriptionStatus(ctx, watch.SubscriptionActive)
+}
+
+func (s *Service) setSubscriptionStatus(ctx *rest.Contexts, status watch.SubscriptionStatus) {
+	sub, ok := s.getSubscriptionByPath(ctx)
+	if !ok {
+		return
+	}
+
+	if !s.authorizeSubscription(ctx, sub.Resource, &sub.Filter) {
+		return
+	}
+
+	if sub.Status == status {
+		ctx.RespEntity(nil)
+		return
+	}
+
+	if !s.updateSubscription(ctx, sub.ID, mapstr.MapStr{"status": status}) {
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ListEventSubscription list the event subscriptions created by the user, their secrets are not returned
+func (s *Service) ListEventSubscription(ctx *rest.Contexts) {
+	opt := new(metadata.CommonQueryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		blog.Errorf("list event subscription option is invalid, err: %v, opt: %+v, rid: %s", rawErr, opt,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	cond, err := opt.ToMgo()
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, err.Error()))
+		return
+	}
+	// only the event subscriptions created by the user are returned
+	cond[common.BkSupplierAccount] = ctx.Kit.SupplierAccount
+	cond[common.CreatorField] = ctx.Kit.User
+
+	table := s.db.Table(common.BKTableNameEventSubscription)
+	if opt.Page.EnableCount {
+		count, err := table.Find(cond).Count(ctx.Kit.Ctx)
+		if err != nil {
+			blog.Errorf("count event subscription failed, err: %v, cond: %+v, rid: %s", err, cond, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+			return
+		}
+		ctx.RespEntity(watch.ListEventSubscriptionResult{Count: count})
+		return
+	}
+
+	subs := make([]*watch.EventSubscription, 0)
+	err = table.Find(cond).Fields(opt.Fields...).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).
+		Sort(getSortOrDefault(opt.Page.Sort)).All(ctx.Kit.Ctx, &subs)
+	if err != nil {
+		blog.Errorf("list event subscription failed, err: %v, cond: %+v, rid: %s", err, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	ctx.RespEntity(watch.ListEventSubscriptionResult{Info: subs})
+}
+
+// ListSubscriptionDelivery list the delivery histories of an event subscription
+func (s *Service) ListSubscriptionDelivery(ctx *rest.Contexts) {
+	opt, ok := s.decodeSubscriptionRecordOption(ctx)
+	if !ok {
+		return
+	}
+
+	result := watch.ListSubscriptionDeliveryResult{Info: make([]*watch.SubscriptionDelivery, 0)}
+	count, ok := s.listSubscriptionRecords(ctx, common.BKTableNameEventSubscriptionDelivery, opt, &result.Info)
+	if !ok {
+		return
+	}
+	result.Count = count
+
+	ctx.RespEntity(result)
+}
+
+// ListSubscriptionDeadLetter list the dead letters of an event subscription
+func (s *Service) ListSubscriptionDeadLetter(ctx *rest.Contexts) {
+	opt, ok := s.decodeSubscriptionRecordOption(ctx)
+	if !ok {
+		return
+	}
+
+	result := watch.ListSubscriptionDeadLetterResult{Info: make([]*watch.SubscriptionDeadLetter, 0)}
+	count, ok := s.listSubscriptionRecords(ctx, common.BKTableNameEventSubscriptionDeadLetter, opt, &result.Info)
+	if !ok {
+		return
+	}
+	result.Count = count
+
+	ctx.RespEntity(result)
+}
+
+func (s *Service) decodeSubscriptionRecordOption(ctx *rest.Contexts) (*watch.ListSubscriptionRecordOption, bool) {
+	opt := new(watch.ListSubscriptionRecordOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return nil, false
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		blog.Errorf("list event subscription record option is invalid, err: %v, opt: %+v, rid: %s", rawErr, opt,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return nil, false
+	}
+
+	sub, ok := s.getSubscription(ctx, opt.SubscriptionID)
+	if !ok {
+		return nil, false
+	}
+
+	if !s.authorizeSubscription(ctx, sub.Resource, &sub.Filter) {
+		return nil, false
+	}
+	return opt, true
+}
+
+// listSubscriptionRecords list the delivery histories or dead letters of an event subscription into the result,
+// returns the count if page.enable_count is set
+func (s *Service) listSubscriptionRecords(ctx *rest.Contexts, table string, opt *watch.ListSubscriptionRecordOption,
+	result interface{}) (uint64, bool) {
+
+	cond := mapstr.MapStr{
+		"subscription_id":        opt.SubscriptionID,
+		common.BkSupplierAccount: ctx.Kit.SupplierAccount,
+	}
+
+	if opt.Page.EnableCount {
+		count, err := s.db.Table(table).Find(cond).Count(ctx.Kit.Ctx)
+		if err != nil {
+			blog.Errorf("count %s failed, err: %v, cond: %+v, rid: %s", table, err, cond, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+			return 0, false
+		}
+		return count, true
+	}
+
+	err := s.db.Table(table).Find(cond).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).
+		Sort(getSortOrDefault(opt.Page.Sort)).All(ctx.Kit.Ctx, result)
+	if err != nil {
+		blog.Errorf("list %s failed, err: %v, cond: %+v, rid: %s", table, err, cond, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return 0, false
+	}
+	return 0, true
+}
+
+// getSortOrDefault returns the sort of the page, the latest data is returned first by default
+func getSortOrDefault(sort string) string {
+	if len(sort) == 0 {
+		return "-" + common.BKFieldID
+	}
+	return sort
+}
+
+// getSubscriptionByPath get the event subscription whose id is specified in the path
+func (s *Service) getSubscriptionByPath(ctx *rest.Contexts) (*watch.EventSubscription, bool) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID))
+		return nil, false
+	}
+
+	return s.getSubscription(ctx, id)
+}
+
+// getSubscription get the event subscription, an event subscription can only be operated by its creator, so the
+// event subscription created by others is regarded as not found
+func (s *Service) getSubscription(ctx *rest.Contexts, id int64) (*watch.EventSubscription, bool) {
+	cond := mapstr.MapStr{
+		common.BKFieldID:         id,
+		common.BkSupplierAccount: ctx.Kit.SupplierAccount,
+		common.CreatorField:      ctx.Kit.User,
+	}
+
+	sub := new(watch.EventSubscription)
+	if err := s.db.Table(common.BKTableNameEventSubscription).Find(cond).One(ctx.Kit.Ctx, sub); err != nil {
+		if s.db.IsNotFoundError(err) {
+			ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommNotFound))
+			return nil, false
+		}
+		blog.Errorf("get event subscription %d failed, err: %v, rid: %s", id, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return nil, false
+	}
+	return sub, true
+}
+
+// updateSubscription update the event subscription, the last time is updated so that its delivery worker is restarted
+func (s *Service) updateSubscription(ctx *rest.Contexts, id int64, data mapstr.MapStr) bool {
+	data[common.ModifierField] = ctx.Kit.User
+	data[common.LastTimeField] = metadata.Now()
+
+	cond := mapstr.MapStr{common.BKFieldID: id}
+	if err := s.db.Table(common.BKTableNameEventSubscription).Update(ctx.Kit.Ctx, cond, data); err != nil {
+		blog.Errorf("update event subscription %d failed, err: %v, data: %+v, rid: %s", id, err, data, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBUpdateFailed))
+		return false
+	}
+	return true
+}
+
+func (s *Service) checkSubscriptionNameDuplicate(kit *rest.Kit, id int64, name string) error {
+	cond := mapstr.MapStr{
+		common.BKFieldName:       name,
+		common.BkSupplierAccount: kit.SupplierAccount,
+	}
+	if id != 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBNE: id}
+	}
+
+	count, err := s.db.Table(common.BKTableNameEventSubscription).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count event subscription by name %s failed, err: %v, rid: %s", name, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if count > 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, common.BKFieldName)
+	}
+	return nil
+}
+
+// authorizeSubscription authorize the event watch permission of the subscribed resource, which is the same as the
+// resource watch api, responds and returns false if not authorized
+func (s *Service) authorizeSubscription(ctx *rest.Contexts, resource watch.CursorType,
+	filter *watch.WatchEventFilter) bool {
+
+	if resource == watch.DynamicGroupMember {
+		// dynamic group member resource is authorized by the resource of the group's target object in iam.
+		return s.authorizeDynamicGroupMemberSubscription(ctx, filter)
+	}
+
+	switch resource {
+	case watch.HostIdentifier:
+		// redirect host identity resource to host resource in iam.
+		resource = watch.Host
+	case watch.BizSetRelation:
+		// redirect biz set relation resource to biz set resource in iam.
+		resource = watch.BizSet
+	case watch.ServiceInstance:
+		// redirect service instance resource to process resource in iam.
+		resource = watch.Process
+	}
+
+	action := meta.Action(resource)
+	switch {
+	case resource.IsModelMetadata():
+		// redirect model metadata resources to model event watch action in iam.
+		action = meta.WatchModel
+	case resource.IsTemplate():
+		// redirect template resources to template event watch action in iam.
+		action = meta.WatchTemplate
+	case resource.IsKubeNsResource():
+		// redirect kube namespace resources to kube namespace event watch action in iam.
+		action = meta.WatchKubeNamespace
+	}
+
+	authResource := meta.ResourceAttribute{
+		Basic: meta.Basic{
+			Type:   meta.EventWatch,
+			Action: action,
+		},
+	}
+
+	switch resource {
+	case watch.ObjectBase, watch.MainlineInstance, watch.InstAsst:
+		// use sub resource(corresponding to the bk_obj_id of the object) for authorization if it is set
+		if len(filter.SubResource) > 0 {
+			model := new(metadata.Object)
+			cond := mapstr.MapStr{common.BKObjIDField: filter.SubResource}
+			err := s.db.Table(common.BKTableNameObjDes).Find(cond).Fields(common.BKFieldID).One(ctx.Kit.Ctx, model)
+			if err != nil {
+				blog.Errorf("get sub resource %s model failed, err: %v, rid: %s", filter.SubResource, err,
+					ctx.Kit.Rid)
+				if s.db.IsNotFoundError(err) {
+					ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid,
+						common.BKSubResourceField))
+					return false
+				}
+				ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+				return false
+			}
+			authResource.InstanceID = model.ID
+		}
+	case watch.KubeWorkload:
+		// use sub resource(corresponding to the kind of the workload) for authorization if it is set
+		authResource.InstanceIDEx = filter.SubResource
+	}
+
+	if resp, authorized := s.AuthManager.Authorize(ctx.Kit, authResource); !authorized {
+		ctx.RespNoAuth(resp)
+		return false
+	}
+	return true
+}
+
+// authorizeDynamicGroupMemberSubscription authorize the event watch permission of the dynamic group members, which is
+// the watch permission of the group's target object, or the watch permissions of both host and set if the sub
+// resource(corresponding to the dynamic group id) is not set. responds and returns false if not authorized
+func (s *Service) authorizeDynamicGroupMemberSubscription(ctx *rest.Contexts, filter *watch.WatchEventFilter) bool {
+	objIDs := []string{common.BKInnerObjIDHost, common.BKInnerObjIDSet}
+	if len(filter.SubResource) > 0 {
+		group := new(metadata.DynamicGroup)
+		cond := mapstr.MapStr{common.BKFieldID: filter.SubResource}
+		err := s.db.Table(common.BKTableNameDynamicGroup).Find(cond).Fields(common.BKObjIDField).One(ctx.Kit.Ctx,
+			group)
+		if err != nil {
+			blog.Errorf("get sub resource %s dynamic group failed, err: %v, rid: %s", filter.SubResource, err,
+				ctx.Kit.Rid)
+			if s.db.IsNotFoundError(err) {
+				ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid,
+					common.BKSubResourceField))
+				return false
+			}
+			ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+			return false
+		}
+		objIDs = []string{group.ObjID}
+	}
+
+	authResources := make([]meta.ResourceAttribute, 0)
+	for _, objID := range objIDs {
+		var resource watch.CursorType
+		switch objID {
+		case common.BKInnerObjIDHost:
+			resource = watch.Host
+		case common.BKInnerObjIDSet:
+			resource = watch.Set
+		default:
+			blog.Errorf("dynamic group %s has unsupported object %s, rid: %s", filter.SubResource, objID,
+				ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKSubResourceField))
+			return false
+		}
+
+		authResources = append(authResources, meta.ResourceAttribute{
+			Basic: meta.Basic{
+				Type:   meta.EventWatch,
+				Action: meta.Action(resource),
+			},
+		})
+	}
+
+	if resp, authorized := s.AuthManager.Authorize(ctx.Kit, authResources...); !authorized {
+		ctx.RespNoAuth(resp)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,234 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/watch"
+)
+
+const (
+	// retryBaseInterval is the interval before the first retry of a failed delivery, it is doubled for each retry
+	retryBaseInterval = time.Second
+	// retryMaxInterval is the max interval between two retries of a failed delivery
+	retryMaxInterval = time.Minute
+	// maxRespBodyLength is the max length of the response body that is recorded in the delivery error
+	maxRespBodyLength = 512
+	// callbackDialTimeout is the timeout to connect to the callback url
+	callbackDialTimeout = 5 * time.Second
+)
+
+// newCallbackClient new the http client to deliver the events to the callback urls, the ip it connects to is checked
+// by checkIP after dns resolution, so that the callback can not be resolved to internal ips when delivering.
+// redirects are not followed and proxy is not used, since they bypass the ip check, redirect response is regarded as
+// a failed delivery. the request timeout of each subscription is set by the request context, the client timeout is
+// the max value of it.
+func newCallbackClient(checkIP func(ip net.IP) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: callbackDialTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("callback address %s is invalid", address)
+			}
+			return checkIP(ip)
+		},
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: callbackDialTimeout,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Timeout: watch.SubscriptionMaxTimeout * time.Second,
+	}
+}
+
+// deliver the events to the callback url of the event subscription, the failed delivery is retried with
+// exponential backoff, and stored as dead letter after all retries are failed. returns error only when the
+// delivery is not finished, in which case the events should be delivered again.
+func (w *worker) deliver(subscription *watch.EventSubscription, events []*watch.WatchEventDetail, rid string) error {
+	deliveryID, err := w.s.db.NextSequence(w.ctx, common.BKTableNameEventSubscriptionDelivery)
+	if err != nil {
+		blog.Errorf("generate event subscription %d delivery id failed, err: %v, rid: %s", w.id, err, rid)
+		return err
+	}
+
+	payload := &watch.SubscriptionPayload{
+		SubscriptionID: subscription.ID,
+		DeliveryID:     int64(deliveryID),
+		Resource:       subscription.Resource,
+		Events:         events,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		blog.Errorf("marshal event subscription %d payload failed, err: %v, rid: %s", w.id, err, rid)
+		return err
+	}
+
+	start := time.Now()
+	delivery := &watch.SubscriptionDelivery{
+		ID:              int64(deliveryID),
+		SubscriptionID:  subscription.ID,
+		Status:          watch.DeliveryFailed,
+		StartCursor:     events[0].Cursor,
+		EndCursor:       events[len(events)-1].Cursor,
+		EventCount:      len(events),
+		SupplierAccount: subscription.SupplierAccount,
+		CreateTime:      metadata.Now(),
+	}
+
+	for retry := 0; retry <= subscription.MaxRetries; retry++ {
+		if retry > 0 && !w.sleep(backoff(retry)) {
+			return w.ctx.Err()
+		}
+
+		delivery.Attempts++
+		delivery.StatusCode, err = w.post(subscription, delivery.ID, body)
+		if err == nil {
+			delivery.Status = watch.DeliverySuccess
+			delivery.Error = ""
+			break
+		}
+
+		if w.ctx.Err() != nil {
+			return w.ctx.Err()
+		}
+
+		delivery.Error = err.Error()
+		blog.Errorf("deliver event subscription %d events failed, delivery: %d, attempts: %d, err: %v, rid: %s",
+			w.id, delivery.ID, delivery.Attempts, err, rid)
+	}
+	delivery.Cost = time.Since(start).Milliseconds()
+
+	if delivery.Status == watch.DeliveryFailed {
+		deadLetter := &watch.SubscriptionDeadLetter{
+			SubscriptionID:  subscription.ID,
+			DeliveryID:      delivery.ID,
+			Resource:        subscription.Resource,
+			Payload:         string(body),
+			Error:           delivery.Error,
+			SupplierAccount: subscription.SupplierAccount,
+			CreateTime:      delivery.CreateTime,
+		}
+		if err := w.saveDeadLetter(deadLetter, rid); err != nil {
+			return err
+		}
+	}
+
+	// delivery history is only for troubleshooting, so the events are not delivered again if it is failed to save
+	if err := w.s.db.Table(common.BKTableNameEventSubscriptionDelivery).Insert(w.ctx, delivery); err != nil {
+		blog.Errorf("save event subscription %d delivery %d history failed, err: %v, rid: %s", w.id, delivery.ID,
+			err, rid)
+	}
+	return nil
+}
+
+// saveDeadLetter save the events that are failed to be delivered after all retries
+func (w *worker) saveDeadLetter(deadLetter *watch.SubscriptionDeadLetter, rid string) error {
+	id, err := w.s.db.NextSequence(w.ctx, common.BKTableNameEventSubscriptionDeadLetter)
+	if err != nil {
+		blog.Errorf("generate event subscription %d dead letter id failed, err: %v, rid: %s", w.id, err, rid)
+		return err
+	}
+	deadLetter.ID = int64(id)
+
+	if err := w.s.db.Table(common.BKTableNameEventSubscriptionDeadLetter).Insert(w.ctx, deadLetter); err != nil {
+		blog.Errorf("save event subscription %d dead letter failed, delivery: %d, err: %v, rid: %s", w.id,
+			deadLetter.DeliveryID, err, rid)
+		return err
+	}
+	return nil
+}
+
+// post the delivery request to the callback url of the event subscription, returns the http status code
+func (w *worker) post(subscription *watch.EventSubscription, deliveryID int64, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(w.ctx, time.Duration(subscription.Timeout)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(watch.SubscriptionIDHeader, strconv.FormatInt(subscription.ID, 10))
+	req.Header.Set(watch.SubscriptionDeliveryIDHeader, strconv.FormatInt(deliveryID, 10))
+	req.Header.Set(watch.SubscriptionTimestampHeader, timestamp)
+	req.Header.Set(watch.SubscriptionSignatureHeader, sign(subscription.Secret, timestamp, body))
+
+	resp, err := w.s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxRespBodyLength))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("callback responds with status code %d, body: %s", resp.StatusCode,
+			respBody)
+	}
+	return resp.StatusCode, nil
+}
+
+// sign generate the signature of the delivery request, which is the hex encoded HMAC-SHA256 of "{timestamp}.{body}"
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff returns the interval before the retry, which is doubled for each retry until it reaches the max interval
+func backoff(retry int) time.Duration {
+	interval := retryBaseInterval
+	for i := 1; i < retry; i++ {
+		interval *= 2
+		if interval >= retryMaxInterval {
+			return retryMaxInterval
+		}
+	}
+	return interval
+}
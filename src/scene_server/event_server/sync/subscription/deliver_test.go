@@ -0,0 +1,64 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package subscription
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"configcenter/src/common/watch"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackClient(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	// the test servers listen on loopback ip, which is not allowed
+	_, err := newCallbackClient(watch.ValidateCallbackIP).Post(target.URL, "application/json", nil)
+	require.Error(t, err)
+
+	client := newCallbackClient(func(ip net.IP) error {
+		return nil
+	})
+	resp, err := client.Post(target.URL, "application/json", nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// redirect is not followed
+	resp, err = client.Post(redirect.URL, "application/json", nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, retryBaseInterval, backoff(1))
+	require.Equal(t, 4*retryBaseInterval, backoff(3))
+	require.Equal(t, retryMaxInterval, backoff(20))
+}
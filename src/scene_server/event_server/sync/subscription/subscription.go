@@ -0,0 +1,166 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package subscription delivers the events of the event subscriptions to their callback urls
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	// reloadInterval is the interval to reload the active event subscriptions
+	reloadInterval = 10 * time.Second
+)
+
+// Subscription maintains the delivery workers of the active event subscriptions, each active event subscription
+// has one worker that watches its events from its cursor and delivers them to its callback url.
+// NOTE: only the master event server delivers the events, so that the events are delivered in order.
+type Subscription struct {
+	ctx    context.Context
+	engine *backbone.Engine
+	db     dal.RDB
+	client *http.Client
+
+	// workers is the delivery workers of the active event subscriptions, key is the event subscription id
+	workers map[int64]*worker
+}
+
+// NewSubscription new event subscription delivery manager
+func NewSubscription(ctx context.Context, engine *backbone.Engine, db dal.RDB) *Subscription {
+	return &Subscription{
+		ctx:     ctx,
+		engine:  engine,
+		db:      db,
+		client:  newCallbackClient(watch.ValidateCallbackIP),
+		workers: make(map[int64]*worker),
+	}
+}
+
+// Run loop reload the active event subscriptions and start or stop their delivery workers
+func (s *Subscription) Run() {
+	for {
+		if !s.engine.Discovery().IsMaster() {
+			if len(s.workers) > 0 {
+				blog.Infof("event server is not master now, stop all event subscription delivery workers")
+				s.stopWorkers(nil)
+			}
+			blog.V(4).Infof("loop deliver event subscriptions, but not master, skip.")
+			time.Sleep(reloadInterval)
+			continue
+		}
+
+		rid := util.GenerateRID()
+		subscriptions, err := s.listActiveSubscriptions(rid)
+		if err != nil {
+			time.Sleep(reloadInterval)
+			continue
+		}
+
+		// stop the workers of the event subscriptions that are paused, deleted, or updated
+		activeMap := make(map[int64]*watch.EventSubscription)
+		for _, subscription := range subscriptions {
+			activeMap[subscription.ID] = subscription
+		}
+		s.stopWorkers(activeMap)
+
+		// start the workers of the event subscriptions that are new, resumed, or updated
+		for _, subscription := range subscriptions {
+			if _, exists := s.workers[subscription.ID]; exists {
+				continue
+			}
+
+			blog.Infof("start event subscription %d delivery worker, rid: %s", subscription.ID, rid)
+			w := newWorker(s.ctx, s, subscription)
+			s.workers[subscription.ID] = w
+			go w.run()
+		}
+
+		time.Sleep(reloadInterval)
+	}
+}
+
+// stopWorkers stop the workers whose event subscription is not in the active event subscriptions or is updated
+func (s *Subscription) stopWorkers(activeMap map[int64]*watch.EventSubscription) {
+	for id, w := range s.workers {
+		subscription, exists := activeMap[id]
+		if exists && subscription.LastTime.Equal(w.lastTime.Time) {
+			continue
+		}
+
+		blog.Infof("stop event subscription %d delivery worker", id)
+		w.stop()
+		delete(s.workers, id)
+	}
+}
+
+// listActiveSubscriptions list the id and last time of all the active event subscriptions
+func (s *Subscription) listActiveSubscriptions(rid string) ([]*watch.EventSubscription, error) {
+	cond := mapstr.MapStr{"status": watch.SubscriptionActive}
+	subscriptions := make([]*watch.EventSubscription, 0)
+	err := s.db.Table(common.BKTableNameEventSubscription).Find(cond).
+		Fields(common.BKFieldID, common.LastTimeField).All(s.ctx, &subscriptions)
+	if err != nil {
+		blog.Errorf("list active event subscriptions failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// GetLatestCursor get the cursor of the latest event of the resource, returns NoEventCursor if there is no event
+func GetLatestCursor(ctx context.Context, engine *backbone.Engine, header http.Header,
+	resource watch.CursorType) (string, error) {
+
+	opts := &watch.WatchEventOptions{Resource: resource}
+	resp, err := engine.CoreAPI.CacheService().Cache().Event().InnerWatchEvent(ctx, header, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if resp == nil || len(resp.Events) == 0 || len(resp.Events[0].Cursor) == 0 {
+		return watch.NoEventCursor, nil
+	}
+	return resp.Events[0].Cursor, nil
+}
+
+func newHeaderWithRid(supplierAccount string) (http.Header, string) {
+	rid := util.GenerateRID()
+	header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, supplierAccount, rid)
+	return header, rid
+}
+
+// updateCursor update the cursor of the event subscription, last time is not updated so that the delivery
+// worker is not restarted
+func (s *Subscription) updateCursor(ctx context.Context, id int64, cursor string, rid string) error {
+	cond := mapstr.MapStr{common.BKFieldID: id}
+	data := mapstr.MapStr{"bk_cursor": cursor}
+	if err := s.db.Table(common.BKTableNameEventSubscription).Update(ctx, cond, data); err != nil {
+		blog.Errorf("update event subscription %d cursor to %s failed, err: %v, rid: %s", id, cursor, err, rid)
+		return err
+	}
+	return nil
+}
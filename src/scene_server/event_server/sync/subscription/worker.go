@@ -0,0 +1,191 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package subscription
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/watch"
+)
+
+// worker watches the events of an event subscription from its cursor and delivers them to its callback url
+type worker struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	s      *Subscription
+
+	id int64
+	// lastTime is the last time of the event subscription when the worker is started, the worker is restarted
+	// when the event subscription is updated
+	lastTime metadata.Time
+}
+
+func newWorker(ctx context.Context, s *Subscription, subscription *watch.EventSubscription) *worker {
+	ctx, cancel := context.WithCancel(ctx)
+	return &worker{
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		s:        s,
+		id:       subscription.ID,
+		lastTime: subscription.LastTime,
+	}
+}
+
+// stop the worker and wait until it is stopped, the events that are being delivered is not retried any more,
+// they are delivered again when the worker is started next time since the cursor is not updated.
+func (w *worker) stop() {
+	w.cancel()
+	<-w.done
+}
+
+func (w *worker) run() {
+	defer close(w.done)
+
+	// the event subscription is reloaded from db to get the latest cursor, since it may be updated by previous worker
+	// after the event subscription is listed.
+	subscription, err := w.getSubscription()
+	for err != nil {
+		if !w.sleep(time.Second) {
+			return
+		}
+		subscription, err = w.getSubscription()
+	}
+
+	opts := subscription.WatchOptions()
+	for w.ctx.Err() == nil {
+		header, rid := newHeaderWithRid(subscription.SupplierAccount)
+		resp, err := w.s.engine.CoreAPI.CacheService().Cache().Event().InnerWatchEvent(w.ctx, header, opts)
+		if w.ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			if err.GetCode() == common.CCErrEventChainNodeNotExist {
+				w.resetCursor(subscription, opts, rid)
+				continue
+			}
+
+			blog.Errorf("watch event subscription %d %s events failed, cursor: %s, err: %v, rid: %s", w.id,
+				opts.Resource, opts.Cursor, err, rid)
+			w.sleep(time.Second)
+			continue
+		}
+
+		if resp == nil || len(resp.Events) == 0 {
+			w.sleep(time.Second)
+			continue
+		}
+
+		if !resp.Watched {
+			// no matched event, save the returned cursor so that the unmatched events are not searched again
+			cursor := resp.Events[0].Cursor
+			if len(cursor) == 0 || cursor == watch.NoEventCursor || cursor == opts.Cursor {
+				// watch with start from time returns immediately, sleep for a while to avoid watching too frequently
+				if opts.StartFrom != 0 {
+					w.sleep(time.Second)
+				}
+				continue
+			}
+
+			if err := w.s.updateCursor(w.ctx, w.id, cursor, rid); err != nil {
+				w.sleep(time.Second)
+				continue
+			}
+			opts.Cursor, opts.StartFrom = cursor, 0
+			continue
+		}
+
+		if !w.deliverEvents(subscription, opts, resp.Events, rid) {
+			w.sleep(time.Second)
+		}
+	}
+}
+
+// deliverEvents deliver the watched events in batches and update the cursor after each batch is delivered,
+// returns false if the events are not all delivered
+func (w *worker) deliverEvents(subscription *watch.EventSubscription, opts *watch.WatchEventOptions,
+	events []*watch.WatchEventDetail, rid string) bool {
+
+	for start := 0; start < len(events); start += subscription.BatchSize {
+		end := start + subscription.BatchSize
+		if end > len(events) {
+			end = len(events)
+		}
+		batch := events[start:end]
+
+		if err := w.deliver(subscription, batch, rid); err != nil {
+			return false
+		}
+
+		cursor := batch[len(batch)-1].Cursor
+		if err := w.s.updateCursor(w.ctx, w.id, cursor, rid); err != nil {
+			return false
+		}
+		opts.Cursor, opts.StartFrom = cursor, 0
+	}
+	return true
+}
+
+// resetCursor reset the cursor of the event subscription to the latest event when the cursor is expired, the events
+// between the expired cursor and the latest event are lost, since they are already removed from the event chain.
+func (w *worker) resetCursor(subscription *watch.EventSubscription, opts *watch.WatchEventOptions, rid string) {
+	header, _ := newHeaderWithRid(subscription.SupplierAccount)
+	cursor, err := GetLatestCursor(w.ctx, w.s.engine, header, subscription.Resource)
+	if err != nil {
+		blog.Errorf("get event subscription %d %s latest cursor failed, err: %v, rid: %s", w.id,
+			subscription.Resource, err, rid)
+		w.sleep(time.Second)
+		return
+	}
+
+	blog.Errorf("event subscription %d cursor %s is expired, reset to the latest cursor %s, the events in between "+
+		"are lost, rid: %s", w.id, opts.Cursor, cursor, rid)
+
+	if err := w.s.updateCursor(w.ctx, w.id, cursor, rid); err != nil {
+		w.sleep(time.Second)
+		return
+	}
+	opts.Cursor, opts.StartFrom = cursor, 0
+}
+
+// getSubscription get the event subscription of the worker from db
+func (w *worker) getSubscription() (*watch.EventSubscription, error) {
+	cond := mapstr.MapStr{common.BKFieldID: w.id}
+	subscription := new(watch.EventSubscription)
+	if err := w.s.db.Table(common.BKTableNameEventSubscription).Find(cond).One(w.ctx, subscription); err != nil {
+		blog.Errorf("get event subscription %d failed, err: %v", w.id, err)
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// sleep for the duration, returns false if the worker is stopped
+func (w *worker) sleep(duration time.Duration) bool {
+	select {
+	case <-w.ctx.Done():
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
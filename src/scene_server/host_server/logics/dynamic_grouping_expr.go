@@ -0,0 +1,393 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	hostutil "configcenter/src/scene_server/host_server/util"
+)
+
+// ExecuteDynamicGroupExpr searches hosts or sets in the business that match the dynamic group expression.
+func (lgc *Logics) ExecuteDynamicGroupExpr(kit *rest.Kit, bizID int64, objID string, expr *filter.Expression,
+	fields []string, page metadata.BasePage, disableCounter bool) (*metadata.InstDataInfo, error) {
+
+	if expr == nil || expr.RuleFactory == nil {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "expression")
+	}
+
+	executor := &dynamicGroupExprExecutor{kit: kit, lgc: lgc, bizID: bizID, objID: objID}
+	cond, err := executor.buildCond(expr.RuleFactory)
+	if err != nil {
+		blog.Errorf("build dynamic group expression condition failed, err: %v, expr: %s, rid: %s", err, expr,
+			kit.Rid)
+		return nil, err
+	}
+
+	switch objID {
+	case common.BKInnerObjIDHost:
+		return executor.searchHosts(cond, fields, page, disableCounter)
+	case common.BKInnerObjIDSet:
+		query := &metadata.QueryCondition{
+			Condition:      mapstr.MapStr{common.BKDBAND: []map[string]interface{}{cond, {common.BKAppIDField: bizID}}},
+			Fields:         fields,
+			Page:           page,
+			DisableCounter: disableCounter,
+		}
+		result, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(kit.Ctx, kit.Header, objID, query)
+		if err != nil {
+			blog.Errorf("search set failed, err: %v, input: %+v, rid: %s", err, query, kit.Rid)
+			return nil, err
+		}
+		return result, nil
+	default:
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+	}
+}
+
+// dynamicGroupExprExecutor converts dynamic group expression to the condition of the dynamic group object, the rules
+// of other objects are converted to the ids of the dynamic group object by topology or instance associations.
+type dynamicGroupExprExecutor struct {
+	kit   *rest.Kit
+	lgc   *Logics
+	bizID int64
+	objID string
+}
+
+// buildCond build the condition of the dynamic group object by the expression rule.
+func (e *dynamicGroupExprExecutor) buildCond(rule filter.RuleFactory) (map[string]interface{}, error) {
+	objIDs, err := metadata.GetExprRuleObjects(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	// the rule is of one object, convert it as a whole
+	if len(objIDs) == 1 {
+		objRule, err := metadata.TrimExprRuleObject(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		cond, err := objRule.ToMgo()
+		if err != nil {
+			return nil, err
+		}
+
+		if objIDs[0] == e.objID {
+			return cond, nil
+		}
+
+		ids, err := e.getIDsByObjCond(objIDs[0], cond)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{common.GetInstIDField(e.objID): map[string]interface{}{common.BKDBIN: ids}},
+			nil
+	}
+
+	// the rule is of multiple objects, it must be a combined rule, convert the sub rules and combine them
+	combinedRule, ok := rule.(*filter.CombinedRule)
+	if !ok {
+		return nil, fmt.Errorf("rule type %s is invalid", rule.WithType())
+	}
+
+	conds := make([]map[string]interface{}, len(combinedRule.Rules))
+	for idx, subRule := range combinedRule.Rules {
+		conds[idx], err = e.buildCond(subRule)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch combinedRule.Condition {
+	case filter.And:
+		return map[string]interface{}{common.BKDBAND: conds}, nil
+	case filter.Or:
+		return map[string]interface{}{common.BKDBOR: conds}, nil
+	default:
+		return nil, fmt.Errorf("combined rule condition %s is invalid", combinedRule.Condition)
+	}
+}
+
+// getIDsByObjCond get the ids of the dynamic group object that are related to the instances matching the condition
+func (e *dynamicGroupExprExecutor) getIDsByObjCond(objID string, cond map[string]interface{}) ([]int64, error) {
+	switch objID {
+	case common.BKInnerObjIDSet, common.BKInnerObjIDModule:
+		if e.objID != common.BKInnerObjIDHost {
+			return nil, fmt.Errorf("%s rule is invalid for %s dynamic group", objID, e.objID)
+		}
+
+		cond = map[string]interface{}{common.BKDBAND: []map[string]interface{}{cond, {common.BKAppIDField: e.bizID}}}
+		instIDs, err := e.searchInstIDs(objID, cond)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(instIDs) == 0 {
+			return make([]int64, 0), nil
+		}
+
+		topoOpt := &metadata.DistinctHostIDByTopoRelationRequest{ApplicationIDArr: []int64{e.bizID}}
+		if objID == common.BKInnerObjIDSet {
+			topoOpt.SetIDArr = instIDs
+		} else {
+			topoOpt.ModuleIDArr = instIDs
+		}
+		return e.getHostIDsByTopo(topoOpt)
+	default:
+		if !metadata.IsCommon(objID) {
+			return nil, fmt.Errorf("%s rule is invalid for %s dynamic group", objID, e.objID)
+		}
+
+		instIDs, err := e.searchInstIDs(objID, cond)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(instIDs) == 0 {
+			return make([]int64, 0), nil
+		}
+
+		return e.getAsstIDs(objID, instIDs)
+	}
+}
+
+// searchInstIDs search the ids of the instances matching the condition page by page
+func (e *dynamicGroupExprExecutor) searchInstIDs(objID string, cond map[string]interface{}) ([]int64, error) {
+	idField := common.GetInstIDField(objID)
+	query := &metadata.QueryCondition{
+		Condition:      cond,
+		Fields:         []string{idField},
+		Page:           metadata.BasePage{Limit: common.BKMaxPageSize, Sort: idField},
+		DisableCounter: true,
+	}
+
+	ids := make([]int64, 0)
+	for {
+		result, err := e.lgc.CoreAPI.CoreService().Instance().ReadInstance(e.kit.Ctx, e.kit.Header, objID, query)
+		if err != nil {
+			blog.Errorf("search %s instances failed, err: %v, input: %+v, rid: %s", objID, err, query, e.kit.Rid)
+			return nil, err
+		}
+
+		for _, inst := range result.Info {
+			id, err := util.GetInt64ByInterface(inst[idField])
+			if err != nil {
+				blog.Errorf("parse %s instance id failed, err: %v, inst: %+v, rid: %s", objID, err, inst, e.kit.Rid)
+				return nil, e.kit.CCError.CCErrorf(common.CCErrCommInstFieldConvertFail, objID, idField, "int",
+					err.Error())
+			}
+			ids = append(ids, id)
+		}
+
+		if len(result.Info) < common.BKMaxPageSize {
+			return ids, nil
+		}
+		query.Page.Start += common.BKMaxPageSize
+	}
+}
+
+// getHostIDsByTopo get the ids of the hosts in the topology
+func (e *dynamicGroupExprExecutor) getHostIDsByTopo(opt *metadata.DistinctHostIDByTopoRelationRequest) ([]int64,
+	error) {
+
+	hostIDs, err := e.lgc.CoreAPI.CoreService().Host().GetDistinctHostIDByTopology(e.kit.Ctx, e.kit.Header, opt)
+	if err != nil {
+		blog.Errorf("get host ids by topology failed, err: %v, input: %+v, rid: %s", err, opt, e.kit.Rid)
+		return nil, err
+	}
+	return hostIDs, nil
+}
+
+// getAsstIDs get the ids of the dynamic group object that are associated with the instances, both the associations
+// with the dynamic group object as source and as destination are included.
+func (e *dynamicGroupExprExecutor) getAsstIDs(objID string, instIDs []int64) ([]int64, error) {
+	ids := make([]int64, 0)
+	for start := 0; start < len(instIDs); start += common.BKMaxInstanceLimit {
+		end := start + common.BKMaxInstanceLimit
+		if end > len(instIDs) {
+			end = len(instIDs)
+		}
+
+		asstIDs, err := e.getAsstIDsByInstIDs(objID, instIDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, asstIDs...)
+	}
+	return util.IntArrayUnique(ids), nil
+}
+
+// getAsstIDsByInstIDs get the ids of the dynamic group object that are associated with one batch of the instances
+func (e *dynamicGroupExprExecutor) getAsstIDsByInstIDs(objID string, instIDs []int64) ([]int64, error) {
+	cond := mapstr.MapStr{
+		common.BKDBOR: []map[string]interface{}{
+			{
+				common.BKObjIDField:           e.objID,
+				common.BKAsstObjIDField:       objID,
+				common.BKAsstInstIDField:      map[string]interface{}{common.BKDBIN: instIDs},
+				common.AssociationKindIDField: map[string]interface{}{common.BKDBNE: common.AssociationKindMainline},
+			},
+			{
+				common.BKObjIDField:           objID,
+				common.BKInstIDField:          map[string]interface{}{common.BKDBIN: instIDs},
+				common.BKAsstObjIDField:       e.objID,
+				common.AssociationKindIDField: map[string]interface{}{common.BKDBNE: common.AssociationKindMainline},
+			},
+		},
+	}
+
+	query := &metadata.InstAsstQueryCondition{
+		Cond: metadata.QueryCondition{
+			Condition:      cond,
+			Page:           metadata.BasePage{Limit: common.BKMaxPageSize, Sort: common.BKFieldID},
+			DisableCounter: true,
+		},
+		ObjID: e.objID,
+	}
+
+	ids := make([]int64, 0)
+	for {
+		result, err := e.lgc.CoreAPI.CoreService().Association().ReadInstAssociation(e.kit.Ctx, e.kit.Header, query)
+		if err != nil {
+			blog.Errorf("search %s associations failed, err: %v, input: %+v, rid: %s", objID, err, query, e.kit.Rid)
+			return nil, err
+		}
+
+		for _, asst := range result.Info {
+			if asst.ObjectID == e.objID {
+				ids = append(ids, asst.InstID)
+				continue
+			}
+			ids = append(ids, asst.AsstInstID)
+		}
+
+		if len(result.Info) < common.BKMaxPageSize {
+			return ids, nil
+		}
+		query.Cond.Page.Start += common.BKMaxPageSize
+	}
+}
+
+// searchHosts search the hosts in the business that match the condition. the business hosts are matched in batches
+// so that no query contains all of them, then the matched hosts are sorted and paged, and only the hosts in the
+// page are returned with the required fields.
+func (e *dynamicGroupExprExecutor) searchHosts(cond map[string]interface{}, fields []string,
+	page metadata.BasePage, disableCounter bool) (*metadata.InstDataInfo, error) {
+
+	bizHostIDs, err := e.getHostIDsByTopo(&metadata.DistinctHostIDByTopoRelationRequest{
+		ApplicationIDArr: []int64{e.bizID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortFields := []string{common.BKHostIDField}
+	for _, sort := range hostutil.ParseHostSort(page.Sort) {
+		sortFields = append(sortFields, sort.Field)
+	}
+
+	matched := make([]mapstr.MapStr, 0)
+	for start := 0; start < len(bizHostIDs); start += common.BKMaxInstanceLimit {
+		end := start + common.BKMaxInstanceLimit
+		if end > len(bizHostIDs) {
+			end = len(bizHostIDs)
+		}
+
+		hosts, err := e.getHosts(cond, bizHostIDs[start:end], sortFields)
+		if err != nil {
+			return nil, err
+		}
+		matched = append(matched, hosts...)
+	}
+
+	result := &metadata.InstDataInfo{Info: make([]mapstr.MapStr, 0)}
+	if !disableCounter {
+		result.Count = len(matched)
+	}
+
+	pageHostIDs, err := hostutil.SortAndPageHosts(matched, page)
+	if err != nil {
+		blog.Errorf("sort and page hosts failed, err: %v, page: %+v, rid: %s", err, page, e.kit.Rid)
+		return nil, err
+	}
+
+	if len(pageHostIDs) == 0 {
+		return result, nil
+	}
+
+	// empty means all fields.
+	if len(fields) != 0 {
+		fields = append(fields, common.BKHostIDField, common.BKCloudIDField)
+	}
+
+	hosts, err := e.getHosts(nil, pageHostIDs, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	hostMap := make(map[int64]mapstr.MapStr, len(hosts))
+	for _, host := range hosts {
+		hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+		if err != nil {
+			blog.Errorf("parse host id failed, err: %v, host: %+v, rid: %s", err, host, e.kit.Rid)
+			return nil, err
+		}
+		hostMap[hostID] = host
+	}
+
+	// keep the hosts in the sorted order
+	for _, hostID := range pageHostIDs {
+		if host, exists := hostMap[hostID]; exists {
+			result.Info = append(result.Info, host)
+		}
+	}
+	return result, nil
+}
+
+// getHosts get the hosts in the host ids that match the condition, the host ids should not exceed one batch
+func (e *dynamicGroupExprExecutor) getHosts(cond map[string]interface{}, hostIDs []int64, fields []string) (
+	[]mapstr.MapStr, error) {
+
+	hostCond := map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs}}
+	if len(cond) != 0 {
+		hostCond = map[string]interface{}{common.BKDBAND: []map[string]interface{}{cond, hostCond}}
+	}
+
+	query := &metadata.QueryInput{
+		Condition:      hostCond,
+		Fields:         strings.Join(util.StrArrayUnique(fields), ","),
+		Limit:          len(hostIDs),
+		Sort:           common.BKHostIDField,
+		DisableCounter: true,
+	}
+
+	result, err := e.lgc.CoreAPI.CoreService().Host().GetHosts(e.kit.Ctx, e.kit.Header, query)
+	if err != nil {
+		blog.Errorf("get hosts failed, err: %v, rid: %s", err, e.kit.Rid)
+		return nil, err
+	}
+	return result.Info, nil
+}
@@ -170,7 +170,7 @@ func (s *Service) UpdateDynamicGroup(ctx *rest.Contexts) {
 	}
 	// final updates.
 	updates := make(map[string]interface{})
-	if err := s.updateGroupParamCheck(ctx.Kit, bizIDInt64, params, updates); err != nil {
+	if err := s.updateGroupParamCheck(ctx.Kit, bizIDInt64, targetID, params, updates); err != nil {
 		blog.Errorf("update request param check failed, err: %v, rid: %s", err, ctx.Kit.Rid)
 		ctx.RespAutoError(err)
 		return
@@ -217,7 +217,8 @@ func (s *Service) UpdateDynamicGroup(ctx *rest.Contexts) {
 }
 
 // updateGroupParamCheck 更新动态分组接口请求参数检查
-func (s *Service) updateGroupParamCheck(kit *rest.Kit, bizID int64, params, updates map[string]interface{}) error {
+func (s *Service) updateGroupParamCheck(kit *rest.Kit, bizID int64, targetID string,
+	params, updates map[string]interface{}) error {
 
 	if info, isExist := params["info"]; isExist {
 		// update dynamic group info.
@@ -267,10 +268,11 @@ func (s *Service) updateGroupParamCheck(kit *rest.Kit, bizID int64, params, upda
 			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid)
 		}
 
-		_, isExist = params[common.BKFieldName]
-		if !isExist {
-			blog.Errorf("update dynamic group failed, err: empty update content, bk_biz_id/info/name, input: %+v, "+
-				"rid: %s", params, kit.Rid)
+		_, isNameExist := params[common.BKFieldName]
+		_, isMaterializedExist := params[common.BKDynamicGroupMaterializedField]
+		if !isNameExist && !isMaterializedExist {
+			blog.Errorf("update dynamic group failed, err: empty update content, bk_biz_id/info/name/materialized, "+
+				"input: %+v, rid: %s", params, kit.Rid)
 			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid)
 		}
 	}
@@ -278,6 +280,66 @@ func (s *Service) updateGroupParamCheck(kit *rest.Kit, bizID int64, params, upda
 	if name, isExist := params[common.BKFieldName]; isExist {
 		updates[common.BKFieldName] = name
 	}
+
+	// update materialized mode.
+	return s.checkGroupMaterialized(kit, bizID, targetID, params, updates)
+}
+
+// checkGroupMaterialized check and set the materialized mode of the dynamic group to be updated, dynamic group with
+// variable conditions can not be materialized.
+func (s *Service) checkGroupMaterialized(kit *rest.Kit, bizID int64, targetID string,
+	params, updates map[string]interface{}) error {
+
+	isMaterialized := false
+	materialized, isMaterializedSet := params[common.BKDynamicGroupMaterializedField]
+	if isMaterializedSet {
+		var ok bool
+		isMaterialized, ok = materialized.(bool)
+		if !ok {
+			blog.Errorf("update dynamic group failed, invalid materialized type, materialized: %+v, rid: %s",
+				materialized, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, common.BKDynamicGroupMaterializedField)
+		}
+		updates[common.BKDynamicGroupMaterializedField] = isMaterialized
+	}
+
+	groupInfo, isInfoSet := updates["info"].(*meta.DynamicGroupInfo)
+	if !isInfoSet && !isMaterializedSet {
+		return nil
+	}
+
+	// dynamic group without variable condition or not materialized needs no more check.
+	if isInfoSet && len(groupInfo.VariableCondition) == 0 || isMaterializedSet && !isMaterialized {
+		return nil
+	}
+
+	// get the exist dynamic group to fill in the info or the materialized mode that is not updated.
+	if !isInfoSet || !isMaterializedSet {
+		result, err := s.CoreAPI.CoreService().Host().GetDynamicGroup(kit.Ctx, strconv.FormatInt(bizID, 10),
+			targetID, kit.Header)
+		if err != nil {
+			blog.Errorf("get dynamic group failed, err: %v, bizID: %d, ID: %s, rid: %s", err, bizID, targetID,
+				kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("get dynamic group failed, errcode: %d, errmsg: %s, bizID: %d, ID: %s, rid: %s",
+				result.Code, result.ErrMsg, bizID, targetID, kit.Rid)
+			return result.CCError()
+		}
+
+		if !isInfoSet {
+			groupInfo = &result.Data.Info
+		}
+		if !isMaterializedSet {
+			isMaterialized = result.Data.Materialized
+		}
+	}
+
+	if isMaterialized && len(groupInfo.VariableCondition) > 0 {
+		blog.Errorf("materialized dynamic group %s can not have variable condition, rid: %s", targetID, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, common.BKDynamicGroupMaterializedField)
+	}
 	return nil
 }
 
@@ -477,6 +539,23 @@ func (s *Service) ExecuteDynamicGroup(ctx *rest.Contexts) {
 
 	// target dynamic group.
 	targetDynamicGroup := result.Data
+
+	// execute dynamic group with expression, rules of other objects are converted by topology or associations.
+	if targetDynamicGroup.Info.Expression != nil {
+		data, err := logics.NewLogics(s.Engine, s.CacheDB, s.AuthManager).ExecuteDynamicGroupExpr(ctx.Kit,
+			bizIDInt64, targetDynamicGroup.ObjID, targetDynamicGroup.Info.Expression, input.Fields, input.Page,
+			input.DisableCounter)
+		if err != nil {
+			blog.Errorf("execute dynamic group expression failed, err: %v, bizID: %s, ID: %s, rid: %s",
+				err, bizID, targetID, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrGetUserCustomQueryDetailFailed, err.Error()))
+			return
+		}
+
+		ctx.RespEntity(data)
+		return
+	}
+
 	// execute dynamic group with target object type.
 	searchPage := input.Page
 	switch targetDynamicGroup.ObjID {
@@ -564,6 +643,13 @@ func (s *Service) checkAndBuildParam(kit *rest.Kit, input *meta.ExecuteOption, b
 		return nil, nil, kit.CCError.Error(common.CCErrCommNotFound)
 	}
 
+	// expression dynamic group has no variable conditions, it can only be executed with its own expression.
+	if result.Data.Info.Expression != nil && len(input.VariableCondition) != 0 {
+		blog.Errorf("variable condition is not supported by expression dynamic group, input: %+v, rid: %s", input,
+			kit.Rid)
+		return nil, nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "variable_condition")
+	}
+
 	validateFunc := func(objectID string) ([]meta.Attribute, error) {
 		return logics.NewLogics(s.Engine, s.CacheDB, s.AuthManager).SearchObjectAttributes(kit, bizID, objectID)
 	}
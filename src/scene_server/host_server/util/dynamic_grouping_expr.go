@@ -0,0 +1,130 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// SortAndPageHosts sorts the hosts by the page sort fields and host id, then returns the host ids in the page
+func SortAndPageHosts(hosts []mapstr.MapStr, page metadata.BasePage) ([]int64, error) {
+	hostIDs := make([]int64, len(hosts))
+	for idx, host := range hosts {
+		hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+		if err != nil {
+			return nil, fmt.Errorf("parse host id failed, err: %v, host: %+v", err, host)
+		}
+		hostIDs[idx] = hostID
+	}
+
+	indexes := make([]int, len(hosts))
+	for idx := range indexes {
+		indexes[idx] = idx
+	}
+
+	sorts := ParseHostSort(page.Sort)
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := hosts[indexes[i]], hosts[indexes[j]]
+		for _, s := range sorts {
+			res := compareSortValue(a[s.Field], b[s.Field])
+			if res == 0 {
+				continue
+			}
+			if s.IsDsc {
+				return res > 0
+			}
+			return res < 0
+		}
+		return hostIDs[indexes[i]] < hostIDs[indexes[j]]
+	})
+
+	start := page.Start
+	if start >= len(indexes) {
+		return make([]int64, 0), nil
+	}
+
+	end := len(indexes)
+	if page.Limit > 0 && start+page.Limit < end {
+		end = start + page.Limit
+	}
+
+	pageHostIDs := make([]int64, 0, end-start)
+	for _, idx := range indexes[start:end] {
+		pageHostIDs = append(pageHostIDs, hostIDs[idx])
+	}
+	return pageHostIDs, nil
+}
+
+// ParseHostSort parses the page sort in the same way as the db sort, supported formats are "field", "-field" and
+// "field:-1", the fields are separated by comma.
+func ParseHostSort(sortStr string) []metadata.SearchSort {
+	sorts := make([]metadata.SearchSort, 0)
+	if len(sortStr) == 0 {
+		return sorts
+	}
+
+	for _, sortItem := range strings.Split(sortStr, ",") {
+		sortItemArr := strings.Split(strings.TrimSpace(sortItem), ":")
+		sortField := metadata.SearchSort{Field: strings.TrimLeft(sortItemArr[0], "+-")}
+		if len(sortItemArr) == 2 {
+			sortField.IsDsc = strings.TrimSpace(sortItemArr[1]) == "-1"
+		} else {
+			sortField.IsDsc = strings.HasPrefix(sortItemArr[0], "-")
+		}
+		sorts = append(sorts, sortField)
+	}
+	return sorts
+}
+
+// compareSortValue compares the values of the sort field, nil value is the smallest, numeric values are compared as
+// numbers and the others are compared as strings.
+func compareSortValue(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	_, isAStr := a.(string)
+	_, isBStr := b.(string)
+	if !isAStr && !isBStr {
+		aNum, aErr := util.GetFloat64ByInterface(a)
+		bNum, bErr := util.GetFloat64ByInterface(b)
+		if aErr == nil && bErr == nil {
+			switch {
+			case aNum < bNum:
+				return -1
+			case aNum > bNum:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
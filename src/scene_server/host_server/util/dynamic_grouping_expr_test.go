@@ -0,0 +1,312 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func exprAtom(field string, op filter.OpType, value interface{}) *filter.AtomRule {
+	return &filter.AtomRule{Field: field, Operator: op.Factory(), Value: value}
+}
+
+func TestValidateDynamicGroupExpression(t *testing.T) {
+	attributes := map[string][]metadata.Attribute{
+		common.BKInnerObjIDHost: {
+			{PropertyID: common.BKHostInnerIPField, PropertyType: common.FieldTypeSingleChar},
+			{PropertyID: common.BKOSTypeField, PropertyType: common.FieldTypeEnum},
+			{PropertyID: common.BKCpuField, PropertyType: common.FieldTypeInt},
+		},
+		common.BKInnerObjIDSet:    {{PropertyID: common.BKSetNameField, PropertyType: common.FieldTypeSingleChar}},
+		common.BKInnerObjIDModule: {{PropertyID: common.BKModuleNameField, PropertyType: common.FieldTypeSingleChar}},
+		"switch":                  {{PropertyID: common.BKInstNameField, PropertyType: common.FieldTypeSingleChar}},
+	}
+	validateFunc := func(objID string) ([]metadata.Attribute, error) {
+		attrs, exists := attributes[objID]
+		if !exists {
+			return nil, errors.New("object not found")
+		}
+		return attrs, nil
+	}
+
+	crossObjRule := &filter.CombinedRule{
+		Condition: filter.And,
+		Rules: []filter.RuleFactory{
+			exprAtom("host.bk_os_type", filter.Equal, "1"),
+			&filter.CombinedRule{
+				Condition: filter.Or,
+				Rules: []filter.RuleFactory{
+					exprAtom("set.bk_set_name", filter.Equal, "db"),
+					exprAtom("switch.bk_inst_name", filter.In, []interface{}{"sw1", "sw2"}),
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name    string
+		objID   string
+		info    metadata.DynamicGroupInfo
+		wantErr bool
+	}{
+		{
+			name:  "rules across topology and custom objects",
+			objID: common.BKInnerObjIDHost,
+			info:  metadata.DynamicGroupInfo{Expression: &filter.Expression{RuleFactory: crossObjRule}},
+		},
+		{
+			name:  "instance id field is always allowed",
+			objID: common.BKInnerObjIDHost,
+			info: metadata.DynamicGroupInfo{Expression: &filter.Expression{
+				RuleFactory: exprAtom("host.bk_host_id", filter.GreaterOrEqual, 10),
+			}},
+		},
+		{
+			name:  "used with condition",
+			objID: common.BKInnerObjIDHost,
+			info: metadata.DynamicGroupInfo{
+				Condition:  []metadata.DynamicGroupInfoCondition{{ObjID: common.BKInnerObjIDHost}},
+				Expression: &filter.Expression{RuleFactory: crossObjRule},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "used with variable condition",
+			objID: common.BKInnerObjIDHost,
+			info: metadata.DynamicGroupInfo{
+				VariableCondition: []metadata.DynamicGroupInfoCondition{{ObjID: common.BKInnerObjIDHost}},
+				Expression:        &filter.Expression{RuleFactory: crossObjRule},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "empty rule",
+			objID:   common.BKInnerObjIDHost,
+			info:    metadata.DynamicGroupInfo{Expression: &filter.Expression{}},
+			wantErr: true,
+		},
+		{
+			name:  "field without object prefix",
+			objID: common.BKInnerObjIDHost,
+			info: metadata.DynamicGroupInfo{Expression: &filter.Expression{
+				RuleFactory: exprAtom("bk_os_type", filter.Equal, "1"),
+			}},
+			wantErr: true,
+		},
+		{
+			name:  "module rule of set dynamic group",
+			objID: common.BKInnerObjIDSet,
+			info: metadata.DynamicGroupInfo{Expression: &filter.Expression{
+				RuleFactory: exprAtom("module.bk_module_name", filter.Equal, "mysql"),
+			}},
+			wantErr: true,
+		},
+		{
+			name:  "business object is not supported",
+			objID: common.BKInnerObjIDHost,
+			info: metadata.DynamicGroupInfo{Expression: &filter.Expression{
+				RuleFactory: exprAtom("biz.bk_biz_name", filter.Equal, "test"),
+			}},
+			wantErr: true,
+		},
+		{
+			name:  "attribute not exists",
+			objID: common.BKInnerObjIDHost,
+			info: metadata.DynamicGroupInfo{Expression: &filter.Expression{
+				RuleFactory: exprAtom("host.not_exists", filter.Equal, "1"),
+			}},
+			wantErr: true,
+		},
+		{
+			name:  "value type mismatch",
+			objID: common.BKInnerObjIDHost,
+			info: metadata.DynamicGroupInfo{Expression: &filter.Expression{
+				RuleFactory: exprAtom("host.bk_cpu", filter.Equal, "4"),
+			}},
+			wantErr: true,
+		},
+		{
+			name:  "object attributes search failed",
+			objID: common.BKInnerObjIDHost,
+			info: metadata.DynamicGroupInfo{Expression: &filter.Expression{
+				RuleFactory: exprAtom("router.bk_inst_name", filter.Equal, "r1"),
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.info.Validate(tc.objID, validateFunc)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGetExprRuleObjects(t *testing.T) {
+	rule := &filter.CombinedRule{
+		Condition: filter.Or,
+		Rules: []filter.RuleFactory{
+			exprAtom("set.bk_set_name", filter.Equal, "db"),
+			exprAtom("host.bk_os_type", filter.Equal, "1"),
+			exprAtom("set.bk_set_env", filter.Equal, "3"),
+		},
+	}
+
+	objIDs, err := metadata.GetExprRuleObjects(rule)
+	require.NoError(t, err)
+	require.Equal(t, []string{common.BKInnerObjIDSet, common.BKInnerObjIDHost}, objIDs)
+
+	_, err = metadata.GetExprRuleObjects(exprAtom("bk_set_name", filter.Equal, "db"))
+	require.Error(t, err)
+
+	_, err = metadata.GetExprRuleObjects(&filter.CombinedRule{Condition: filter.And})
+	require.Error(t, err)
+}
+
+func TestTrimExprRuleObject(t *testing.T) {
+	rule := &filter.CombinedRule{
+		Condition: filter.And,
+		Rules: []filter.RuleFactory{
+			exprAtom("host.bk_os_type", filter.Equal, "1"),
+			&filter.CombinedRule{
+				Condition: filter.Or,
+				Rules: []filter.RuleFactory{
+					exprAtom("host.bk_cpu", filter.GreaterOrEqual, 4),
+					exprAtom("host.bk_host_innerip", filter.In, []interface{}{"127.0.0.1"}),
+				},
+			},
+		},
+	}
+
+	trimmed, err := metadata.TrimExprRuleObject(rule)
+	require.NoError(t, err)
+	require.Equal(t, []string{common.BKOSTypeField, common.BKCpuField, common.BKHostInnerIPField},
+		trimmed.RuleFields())
+
+	// the original rule is not changed
+	require.Equal(t, []string{"host.bk_os_type", "host.bk_cpu", "host.bk_host_innerip"}, rule.RuleFields())
+
+	cond, err := trimmed.ToMgo()
+	require.NoError(t, err)
+	condJs, err := json.Marshal(cond)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"$and":[{"bk_os_type":{"$eq":"1"}},{"$or":[{"bk_cpu":{"$gte":4}},`+
+		`{"bk_host_innerip":{"$in":["127.0.0.1"]}}]}]}`, string(condJs))
+
+	_, err = metadata.TrimExprRuleObject(exprAtom("bk_os_type", filter.Equal, "1"))
+	require.Error(t, err)
+}
+
+func TestSortAndPageHosts(t *testing.T) {
+	hosts := []mapstr.MapStr{
+		{common.BKHostIDField: int64(3), common.BKCpuField: json.Number("8"), common.BKHostNameField: "b"},
+		{common.BKHostIDField: float64(1), common.BKCpuField: int64(4), common.BKHostNameField: "c"},
+		{common.BKHostIDField: json.Number("4"), common.BKHostNameField: "a"},
+		{common.BKHostIDField: 2, common.BKCpuField: float64(8), common.BKHostNameField: "a"},
+	}
+
+	testCases := []struct {
+		name   string
+		page   metadata.BasePage
+		expect []int64
+	}{
+		{
+			name:   "sort by host id by default",
+			page:   metadata.BasePage{Limit: 10},
+			expect: []int64{1, 2, 3, 4},
+		},
+		{
+			name:   "sort by numeric field descending, ties by host id",
+			page:   metadata.BasePage{Limit: 10, Sort: "-" + common.BKCpuField},
+			expect: []int64{2, 3, 1, 4},
+		},
+		{
+			name:   "sort by multiple fields",
+			page:   metadata.BasePage{Limit: 10, Sort: common.BKHostNameField + ",-" + common.BKCpuField},
+			expect: []int64{2, 4, 3, 1},
+		},
+		{
+			name:   "page start and limit with db sort format",
+			page:   metadata.BasePage{Start: 1, Limit: 2, Sort: common.BKHostNameField + ":1"},
+			expect: []int64{4, 3},
+		},
+		{
+			name:   "limit exceeds hosts",
+			page:   metadata.BasePage{Start: 3, Limit: 2, Sort: common.BKCpuField + ":-1"},
+			expect: []int64{4},
+		},
+		{
+			name:   "start exceeds hosts",
+			page:   metadata.BasePage{Start: 4, Limit: 2},
+			expect: []int64{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hostIDs, err := SortAndPageHosts(hosts, tc.page)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, hostIDs)
+		})
+	}
+
+	_, err := SortAndPageHosts([]mapstr.MapStr{{common.BKHostIDField: "1"}}, metadata.BasePage{Limit: 10})
+	require.Error(t, err)
+}
+
+func TestParseHostSort(t *testing.T) {
+	require.Equal(t, []metadata.SearchSort{}, ParseHostSort(""))
+	require.Equal(t, []metadata.SearchSort{
+		{Field: common.BKHostIDField},
+		{Field: common.BKCpuField, IsDsc: true},
+		{Field: common.BKHostNameField, IsDsc: true},
+		{Field: common.BKOSTypeField},
+	}, ParseHostSort("+bk_host_id, -bk_cpu,bk_host_name:-1,bk_os_type:1"))
+}
+
+func TestCompareSortValue(t *testing.T) {
+	testCases := []struct {
+		a, b   interface{}
+		expect int
+	}{
+		{a: nil, b: nil, expect: 0},
+		{a: nil, b: 1, expect: -1},
+		{a: "a", b: nil, expect: 1},
+		{a: int64(9), b: json.Number("10"), expect: -1},
+		{a: float64(2), b: 2, expect: 0},
+		{a: "9", b: "10", expect: 1},
+		{a: "a", b: "b", expect: -1},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expect, compareSortValue(tc.a, tc.b), "compare %v and %v", tc.a, tc.b)
+	}
+}
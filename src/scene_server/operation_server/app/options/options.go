@@ -36,6 +36,8 @@ type Config struct {
 	ConfigMap map[string]string
 	Mongo     mongo.Config
 	Timer     string
+	// SnapshotTimer is the cron spec of creating the daily snapshots of snapshot charts
+	SnapshotTimer string
 	// Auth is auth config
 	Auth iam.AuthConfig
 }
@@ -41,10 +41,13 @@ type Logics struct {
 	ccLang      language.DefaultCCLanguageIf
 	AuthManager *extensions.AuthManager
 	timerSpec   string
+	// snapshotTimerSpec is the cron spec of creating chart snapshots
+	snapshotTimerSpec string
 }
 
 // NewLogics get logics handle
-func NewLogics(b *backbone.Engine, header http.Header, authManager *extensions.AuthManager, spec string) *Logics {
+func NewLogics(b *backbone.Engine, header http.Header, authManager *extensions.AuthManager, spec,
+	snapshotSpec string) *Logics {
 	lang := httpheader.GetLanguage(header)
 	return &Logics{
 		Engine:      b,
@@ -56,6 +59,8 @@ func NewLogics(b *backbone.Engine, header http.Header, authManager *extensions.A
 		ownerID:     httpheader.GetSupplierAccount(header),
 		AuthManager: authManager,
 		timerSpec:   spec,
+
+		snapshotTimerSpec: snapshotSpec,
 	}
 }
 
@@ -79,6 +84,8 @@ func (lgc *Logics) NewFromHeader(header http.Header) *Logics {
 		user:      httpheader.GetUser(header),
 		ownerID:   httpheader.GetSupplierAccount(header),
 		timerSpec: lgc.timerSpec,
+
+		snapshotTimerSpec: lgc.snapshotTimerSpec,
 	}
 	// if language not exist, use old language
 	if lang == "" {
@@ -160,6 +160,38 @@ func (lgc *Logics) InnerChartData(kit *rest.Kit, chartInfo metadata.ChartConfig)
 	}
 }
 
+// defaultSnapshotDays is the default days of snapshot chart data when the x axis count of the chart is not set
+const defaultSnapshotDays = 90
+
+// SnapshotChartData get the daily snapshot data of the latest x axis count days of snapshot chart
+func (lgc *Logics) SnapshotChartData(kit *rest.Kit, chartInfo metadata.ChartConfig) (
+	map[string][]metadata.StringIDCount, error) {
+
+	days := chartInfo.XAxisCount
+	if days <= 0 {
+		days = defaultSnapshotDays
+	}
+
+	now := time.Now()
+	opt := &metadata.SearchChartSnapshotOption{
+		ConfigID:  chartInfo.ConfigID,
+		StartDate: now.AddDate(0, 0, -int(days-1)).Format(metadata.ChartSnapshotDateFormat),
+		EndDate:   now.Format(metadata.ChartSnapshotDateFormat),
+	}
+
+	if err := opt.Validate(); err != nil {
+		blog.Errorf("snapshot chart option is invalid, err: %v, opt: %+v, rid: %s", err, opt, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	data, err := lgc.CoreAPI.CoreService().Operation().SearchChartSnapshots(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("search chart snapshots fail, chart name: %s, err: %v, rid: %s", chartInfo.Name, err, kit.Rid)
+		return nil, err
+	}
+	return data, nil
+}
+
 // TimerFreshData TODO
 func (lgc *Logics) TimerFreshData(ctx context.Context) {
 	lgc.CheckTableExist(ctx)
@@ -199,6 +231,30 @@ func (lgc *Logics) TimerFreshData(ctx context.Context) {
 	}
 }
 
+// TimerCreateChartSnapshots create the daily snapshots of snapshot charts by the snapshot timer, snapshot charts are
+// defined by users, so the snapshots are still created when the operation statistics function is disabled
+func (lgc *Logics) TimerCreateChartSnapshots(ctx context.Context) {
+	c := cron.New()
+	err := c.AddFunc(lgc.snapshotTimerSpec, func() {
+		if !lgc.Engine.ServiceManageInterface.IsMaster() {
+			return
+		}
+
+		blog.V(3).Infof("begin create chart snapshots, time: %v", time.Now())
+		if err := lgc.CoreAPI.CoreService().Operation().CreateChartSnapshots(ctx, lgc.header); err != nil {
+			blog.Errorf("create chart snapshots fail, err: %v", err)
+		}
+	})
+	if err != nil {
+		blog.Errorf("new chart snapshot cron failed, please contact developer, err: %v", err)
+		return
+	}
+	c.Start()
+
+	<-ctx.Done()
+	c.Stop()
+}
+
 // CheckTableExist 检测cc_chartData集合是否存在
 func (lgc *Logics) CheckTableExist(ctx context.Context) {
 	opt := mapstr.MapStr{}
@@ -33,4 +33,5 @@ func (o *OperationServer) InitFunc() {
 
 	srvData := o.newSrvComm(header)
 	go srvData.lgc.TimerFreshData(srvData.ctx)
+	go srvData.lgc.TimerCreateChartSnapshots(srvData.ctx)
 }
@@ -17,7 +17,11 @@
 package service
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"configcenter/src/common"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
@@ -32,24 +36,34 @@ func (o *OperationServer) CreateOperationChart(ctx *rest.Contexts) {
 		return
 	}
 
-	// 图表是否已经存在
-	filterCondition := mapstr.MapStr{}
-	filterCondition[common.BKObjIDField] = chartInfo.ObjID
-	filterCondition[common.OperationReportType] = chartInfo.ReportType
-	filterCondition["field"] = chartInfo.Field
-	exist, err := o.CoreAPI.CoreService().Operation().SearchChartCommon(ctx.Kit.Ctx, ctx.Kit.Header, filterCondition)
-	if err != nil {
-		ctx.RespErrorCodeOnly(common.CCErrOperationNewAddStatisticFail,
-			"new add operation chart fail, err: %v, rid: %v", err, ctx.Kit.Rid)
-		return
-	}
-	if exist.Data.Count > 0 {
-		ctx.RespErrorCodeOnly(common.CCErrOperationChartAlreadyExist,
-			"create operation chart fail, err: chart already exist, rid: %v", ctx.Kit.Rid)
-		return
+	if chartInfo.ReportType == common.OperationSnapshot {
+		// 快照图表可以对同一字段使用不同的过滤条件，所以不校验图表是否已经存在
+		if err := o.validateSnapshotChart(ctx.Kit, chartInfo); err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+	} else {
+		// 图表是否已经存在
+		filterCondition := mapstr.MapStr{}
+		filterCondition[common.BKObjIDField] = chartInfo.ObjID
+		filterCondition[common.OperationReportType] = chartInfo.ReportType
+		filterCondition["field"] = chartInfo.Field
+		exist, err := o.CoreAPI.CoreService().Operation().SearchChartCommon(ctx.Kit.Ctx, ctx.Kit.Header,
+			filterCondition)
+		if err != nil {
+			ctx.RespErrorCodeOnly(common.CCErrOperationNewAddStatisticFail,
+				"new add operation chart fail, err: %v, rid: %v", err, ctx.Kit.Rid)
+			return
+		}
+		if exist.Data.Count > 0 {
+			ctx.RespErrorCodeOnly(common.CCErrOperationChartAlreadyExist,
+				"create operation chart fail, err: chart already exist, rid: %v", ctx.Kit.Rid)
+			return
+		}
 	}
 
 	var id uint64
+	var err error
 	resp := new(metadata.SearchChartCommon)
 
 	defer func() {
@@ -66,8 +80,8 @@ func (o *OperationServer) CreateOperationChart(ctx *rest.Contexts) {
 		return
 	}()
 
-	// 自定义报表
-	if chartInfo.ReportType == common.OperationCustom {
+	// 自定义报表和快照报表
+	if chartInfo.ReportType == common.OperationCustom || chartInfo.ReportType == common.OperationSnapshot {
 		result, err := o.Engine.CoreAPI.CoreService().Operation().CreateOperationChart(ctx.Kit.Ctx, ctx.Kit.Header,
 			chartInfo)
 		if err != nil {
@@ -127,6 +141,11 @@ func (o *OperationServer) UpdateOperationChart(ctx *rest.Contexts) {
 		return
 	}
 
+	if err := o.validateUpdatedChart(ctx.Kit, opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	if _, err := o.Engine.CoreAPI.CoreService().Operation().UpdateOperationChart(ctx.Kit.Ctx, ctx.Kit.Header,
 		opt); err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrOperationUpdateChartFail,
@@ -161,6 +180,16 @@ func (o *OperationServer) SearchChartData(ctx *rest.Contexts) {
 		common.ModelInstChangeChart,
 	}
 
+	if chart.Data.Info.ReportType == common.OperationSnapshot {
+		data, err := srvData.lgc.SnapshotChartData(ctx.Kit, chart.Data.Info)
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+		ctx.RespEntity(data)
+		return
+	}
+
 	if util.InStrArr(innerChart, chart.Data.Info.ReportType) {
 		data, err := srvData.lgc.InnerChartData(ctx.Kit, chart.Data.Info)
 		if err != nil {
@@ -213,3 +242,124 @@ func (o *OperationServer) UpdateChartPosition(ctx *rest.Contexts) {
 
 	ctx.RespEntity(nil)
 }
+
+// validateSnapshotChart validate snapshot chart config and check if the model and the group field exist
+func (o *OperationServer) validateSnapshotChart(kit *rest.Kit, chartInfo *metadata.ChartConfig) error {
+	if err := chartInfo.ValidateSnapshot(); err != nil {
+		blog.Errorf("snapshot chart is invalid, err: %v, chart: %+v, rid: %s", err, chartInfo, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	// host can be grouped by the topology fields of host relation, which are not host attributes
+	if chartInfo.ObjID == common.BKInnerObjIDHost && metadata.IsHostRelSnapshotGroupField(chartInfo.Field) {
+		return nil
+	}
+
+	cond := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{common.BKPropertyIDField: chartInfo.Field},
+		Page:      metadata.BasePage{Limit: 1},
+	}
+	attrs, err := o.CoreAPI.CoreService().Model().ReadModelAttr(kit.Ctx, kit.Header, chartInfo.ObjID, cond)
+	if err != nil {
+		blog.Errorf("get snapshot chart group field failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return err
+	}
+
+	if len(attrs.Info) == 0 {
+		blog.Errorf("snapshot chart group field %s of %s not exists, rid: %s", chartInfo.Field, chartInfo.ObjID,
+			kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "field")
+	}
+
+	return nil
+}
+
+// validateUpdatedChart validate the chart after update by the report type of the stored chart, so that the update
+// option of snapshot chart is validated even if it does not contain the report type
+func (o *OperationServer) validateUpdatedChart(kit *rest.Kit, opt mapstr.MapStr) error {
+	configID, err := util.GetInt64ByInterface(opt[common.OperationConfigID])
+	if err != nil || configID <= 0 {
+		blog.Errorf("update chart config id is invalid, err: %v, opt: %+v, rid: %s", err, opt, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.OperationConfigID)
+	}
+
+	stored, err := o.CoreAPI.CoreService().Operation().SearchChartCommon(kit.Ctx, kit.Header,
+		mapstr.MapStr{common.OperationConfigID: configID})
+	if err != nil {
+		blog.Errorf("search chart %d failed, err: %v, rid: %s", configID, err, kit.Rid)
+		return err
+	}
+
+	if stored.Data.Count == 0 {
+		blog.Errorf("chart %d to be updated not exists, rid: %s", configID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.OperationConfigID)
+	}
+
+	chartInfo, err := mergeUpdatedChart(stored.Data.Info, opt)
+	if err != nil {
+		blog.Errorf("merge updated chart failed, err: %v, opt: %+v, rid: %s", err, opt, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	if chartInfo.ReportType != common.OperationSnapshot {
+		return nil
+	}
+
+	return o.validateSnapshotChart(kit, chartInfo)
+}
+
+// mergeUpdatedChart merge the update option into the stored chart config, the report type of snapshot chart can not
+// be changed, because the snapshot data and the other chart data are not compatible
+func mergeUpdatedChart(stored metadata.ChartConfig, opt mapstr.MapStr) (*metadata.ChartConfig, error) {
+	if reportType, exists := opt[common.OperationReportType]; exists {
+		newType := util.GetStrByInterface(reportType)
+		if newType != stored.ReportType &&
+			(newType == common.OperationSnapshot || stored.ReportType == common.OperationSnapshot) {
+			return nil, fmt.Errorf("%s can not be changed from %s to %s", common.OperationReportType,
+				stored.ReportType, newType)
+		}
+	}
+
+	storedJs, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := mapstr.NewFromInterface(storedJs)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range opt {
+		merged[key] = value
+	}
+
+	chartInfo := new(metadata.ChartConfig)
+	if err := merged.MarshalJSONInto(chartInfo); err != nil {
+		return nil, err
+	}
+	return chartInfo, nil
+}
+
+// SearchChartSnapshots search daily snapshot data of snapshot chart in the date range
+func (o *OperationServer) SearchChartSnapshots(ctx *rest.Contexts) {
+	opt := new(metadata.SearchChartSnapshotOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Validate(); err != nil {
+		blog.Errorf("search chart snapshots option is invalid, err: %v, opt: %+v, rid: %s", err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	ctx.SetReadPreference(common.SecondaryPreferredMode)
+	data, err := o.CoreAPI.CoreService().Operation().SearchChartSnapshots(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(data)
+}
@@ -0,0 +1,138 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeUpdatedChart(t *testing.T) {
+	snapshotChart := metadata.ChartConfig{
+		ConfigID:   1,
+		ReportType: common.OperationSnapshot,
+		Name:       "host os",
+		ObjID:      common.BKInnerObjIDHost,
+		Field:      common.BKOSTypeField,
+		XAxisCount: 30,
+		Filter: &querybuilder.QueryFilter{
+			Rule: querybuilder.CombinedRule{
+				Condition: querybuilder.ConditionAnd,
+				Rules: []querybuilder.Rule{
+					querybuilder.AtomRule{
+						Field:    common.BKHostInnerIPField,
+						Operator: querybuilder.OperatorEqual,
+						Value:    "127.0.0.1",
+					},
+				},
+			},
+		},
+	}
+	customChart := metadata.ChartConfig{
+		ConfigID:   2,
+		ReportType: common.OperationCustom,
+		Name:       "host os",
+		ObjID:      common.BKInnerObjIDHost,
+		Field:      common.BKOSTypeField,
+	}
+
+	testCases := []struct {
+		name          string
+		stored        metadata.ChartConfig
+		opt           mapstr.MapStr
+		expectErr     bool
+		expectInvalid bool
+		expectName    string
+		expectType    string
+	}{
+		{
+			name:       "update snapshot chart name without report type",
+			stored:     snapshotChart,
+			opt:        mapstr.MapStr{common.OperationConfigID: 1, "name": "new name"},
+			expectName: "new name",
+			expectType: common.OperationSnapshot,
+		},
+		{
+			name:          "clear snapshot chart filter without report type",
+			stored:        snapshotChart,
+			opt:           mapstr.MapStr{common.OperationConfigID: 1, "filter": nil},
+			expectInvalid: true,
+			expectName:    "host os",
+			expectType:    common.OperationSnapshot,
+		},
+		{
+			name:          "clear snapshot chart field without report type",
+			stored:        snapshotChart,
+			opt:           mapstr.MapStr{common.OperationConfigID: 1, "field": ""},
+			expectInvalid: true,
+			expectName:    "host os",
+			expectType:    common.OperationSnapshot,
+		},
+		{
+			name:      "change snapshot chart to custom chart",
+			stored:    snapshotChart,
+			opt:       mapstr.MapStr{common.OperationConfigID: 1, common.OperationReportType: common.OperationCustom},
+			expectErr: true,
+		},
+		{
+			name:      "change custom chart to snapshot chart",
+			stored:    customChart,
+			opt:       mapstr.MapStr{common.OperationConfigID: 2, common.OperationReportType: common.OperationSnapshot},
+			expectErr: true,
+		},
+		{
+			name:       "update custom chart",
+			stored:     customChart,
+			opt:        mapstr.MapStr{common.OperationConfigID: 2, "name": "new name"},
+			expectName: "new name",
+			expectType: common.OperationCustom,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chart, err := mergeUpdatedChart(tc.stored, tc.opt)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectName, chart.Name)
+			require.Equal(t, tc.expectType, chart.ReportType)
+			require.Equal(t, tc.stored.ConfigID, chart.ConfigID)
+
+			if chart.ReportType != common.OperationSnapshot {
+				return
+			}
+
+			err = chart.ValidateSnapshot()
+			if tc.expectInvalid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.stored.Field, chart.Field)
+			require.NotNil(t, chart.Filter)
+		})
+	}
+}
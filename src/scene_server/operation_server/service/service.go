@@ -81,7 +81,7 @@ func (o *OperationServer) newSrvComm(header http.Header) *srvComm {
 		ctxCancelFunc: cancel,
 		user:          httpheader.GetUser(header),
 		ownerID:       httpheader.GetSupplierAccount(header),
-		lgc:           logics.NewLogics(o.Engine, header, o.AuthManager, o.Config.Timer),
+		lgc:           logics.NewLogics(o.Engine, header, o.AuthManager, o.Config.Timer, o.Config.SnapshotTimer),
 	}
 }
 
@@ -133,6 +133,8 @@ func (o *OperationServer) newOperationService(web *restful.WebService) {
 		Handler: o.SearchChartData})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/operation/chart/position",
 		Handler: o.UpdateChartPosition})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/operation/chart/snapshot",
+		Handler: o.SearchChartSnapshots})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -192,6 +194,13 @@ func (o *OperationServer) OnOperationConfigUpdate(previous, current cc.ProcessCo
 		blog.Errorf("parse timer config failed, err: %v", err)
 		return
 	}
+
+	// 快照图表使用单独的定时配置，不受运营统计开关的影响
+	o.Config.SnapshotTimer, err = o.ParseTimerConfigFromKV("operationServer.snapshotTimer", nil)
+	if err != nil {
+		blog.Errorf("parse snapshot timer config failed, err: %v", err)
+		return
+	}
 }
 
 // ParseTimerConfigFromKV parse timer from kv
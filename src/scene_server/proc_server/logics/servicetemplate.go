@@ -161,6 +161,13 @@ func (lgc *Logic) getSvcTempSyncStatus(kit *rest.Kit, svcTemp *metadata.ServiceT
 	if cErr != nil {
 		return false, nil, cErr
 	}
+
+	// modules pinned to a service template revision are compared with the revision instead of the current template
+	pinnedDefs, cErr := lgc.getModulePinnedSvcTempDefs(kit, svcTemp, modules)
+	if cErr != nil {
+		return false, nil, cErr
+	}
+
 	for _, module := range modules {
 		if isFinish {
 			break
@@ -183,8 +190,10 @@ func (lgc *Logic) getSvcTempSyncStatus(kit *rest.Kit, svcTemp *metadata.ServiceT
 			continue
 		}
 
+		pinnedDef, isPinned := pinnedDefs[moduleID]
+
 		// get process templates to compare with the processes of the module
-		if !isProcTempMapSet {
+		if !isPinned && !isProcTempMapSet {
 			procTempOpt := &metadata.ListProcessTemplatesOption{
 				BusinessID: svcTemp.BizID, ServiceTemplateIDs: []int64{svcTemp.ID}}
 
@@ -209,9 +218,21 @@ func (lgc *Logic) getSvcTempSyncStatus(kit *rest.Kit, svcTemp *metadata.ServiceT
 				<-pipeline
 			}()
 
-			attrNeedSync := lgc.getModuleAttrNeedSync(module, propertyIDs, attrIdPropertyIdMap, srvTempAttrValueMap)
+			def := &svcTempDefinition{
+				propertyIDs:         propertyIDs,
+				attrValueMap:        srvTempAttrValueMap,
+				attrIdPropertyIdMap: attrIdPropertyIdMap,
+				procTempMap:         procTempMap,
+			}
+			if isPinned {
+				def = pinnedDef
+			}
+
+			attrNeedSync := lgc.getModuleAttrNeedSync(module, def.propertyIDs, def.attrIdPropertyIdMap,
+				def.attrValueMap)
 
-			moduleNeedSync, err := lgc.getModuleProcessSyncStatus(kit, bizID, serviceTemplateID, moduleID, procTempMap)
+			moduleNeedSync, err := lgc.getModuleProcessSyncStatus(kit, bizID, serviceTemplateID, moduleID,
+				def.procTempMap)
 			if err != nil {
 				blog.Errorf("get module(%+v) process sync status failed, err: %v, rid: %s", module, err, kit.Rid)
 				if firstErr == nil {
@@ -243,6 +264,65 @@ func (lgc *Logic) getSvcTempSyncStatus(kit *rest.Kit, svcTemp *metadata.ServiceT
 	return needSync, statuses, nil
 }
 
+// svcTempDefinition 服务模板某一版本的属性及进程模板定义，用于计算模块是否需要同步
+type svcTempDefinition struct {
+	propertyIDs         []string
+	attrValueMap        map[int64]interface{}
+	attrIdPropertyIdMap map[int64]string
+	procTempMap         map[int64]*metadata.ProcessTemplate
+}
+
+// getModulePinnedSvcTempDefs 获取固定了服务模板版本的模块所使用的服务模板定义，返回模块ID与定义的映射
+func (lgc *Logic) getModulePinnedSvcTempDefs(kit *rest.Kit, svcTemp *metadata.ServiceTemplate,
+	modules []mapstr.MapStr) (map[int64]*svcTempDefinition, errors.CCErrorCoder) {
+
+	moduleIDs := make([]int64, 0)
+	for _, module := range modules {
+		_, moduleID, err := getModuleNameAndID(kit, module)
+		if err != nil {
+			return nil, err
+		}
+		moduleIDs = append(moduleIDs, moduleID)
+	}
+
+	revisionMap, err := lgc.GetModuleSvcTempRevisions(kit, svcTemp.BizID, svcTemp.ID, moduleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	defs := make(map[int64]*svcTempDefinition)
+	versionDefs := make(map[int64]*svcTempDefinition)
+	for moduleID, revision := range revisionMap {
+		if def, exists := versionDefs[revision.Version]; exists {
+			defs[moduleID] = def
+			continue
+		}
+
+		attrIDs := make([]int64, 0)
+		attrValueMap := make(map[int64]interface{})
+		for _, attr := range revision.Attributes {
+			attrIDs = append(attrIDs, attr.AttributeID)
+			attrValueMap[attr.AttributeID] = attr.PropertyValue
+		}
+
+		propertyIDs, attrIdPropertyIdMap, err := lgc.getModuleAttrIDAndPropertyID(kit, attrIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		def := &svcTempDefinition{
+			propertyIDs:         propertyIDs,
+			attrValueMap:        attrValueMap,
+			attrIdPropertyIdMap: attrIdPropertyIdMap,
+			procTempMap:         revision.ProcessTemplateMap(),
+		}
+		versionDefs[revision.Version] = def
+		defs[moduleID] = def
+	}
+
+	return defs, nil
+}
+
 // getSrvTemplateAttrIdAndPropertyValue 获取服务模板的属性id以及对应的属性值
 func (lgc *Logic) getSrvTemplateAttrIdAndPropertyValue(kit *rest.Kit, bizID, serviceTemplateID int64) ([]int64,
 	map[int64]interface{}, errors.CCErrorCoder) {
@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// GetModuleSvcTempRevisions get the service template revisions that the modules are pinned to, returns the map of
+// module id to revision. modules that are not pinned are not in the map, they use the current service template.
+func (lgc *Logic) GetModuleSvcTempRevisions(kit *rest.Kit, bizID, serviceTemplateID int64, moduleIDs []int64) (
+	map[int64]*metadata.ServiceTemplateRevision, errors.CCErrorCoder) {
+
+	revisionMap := make(map[int64]*metadata.ServiceTemplateRevision)
+
+	pinOpt := &metadata.ListSvcTempVersionPinOption{
+		BizID:             bizID,
+		ServiceTemplateID: serviceTemplateID,
+		ModuleIDs:         moduleIDs,
+	}
+	pins, err := lgc.CoreAPI.CoreService().Process().ListSvcTempVersionPin(kit.Ctx, kit.Header, pinOpt)
+	if err != nil {
+		blog.Errorf("list service template version pins failed, option: %+v, err: %v, rid: %s", pinOpt, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(pins) == 0 {
+		return revisionMap, nil
+	}
+
+	versions := make([]int64, 0)
+	versionExists := make(map[int64]struct{})
+	for _, pin := range pins {
+		if _, exists := versionExists[pin.Version]; exists {
+			continue
+		}
+		versionExists[pin.Version] = struct{}{}
+		versions = append(versions, pin.Version)
+	}
+
+	revisionOpt := &metadata.ListSvcTempRevisionOption{
+		BizID:             bizID,
+		ServiceTemplateID: serviceTemplateID,
+		Versions:          versions,
+		Page:              metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	revisions, err := lgc.CoreAPI.CoreService().Process().ListSvcTempRevision(kit.Ctx, kit.Header, revisionOpt)
+	if err != nil {
+		blog.Errorf("list service template revisions failed, option: %+v, err: %v, rid: %s", revisionOpt, err,
+			kit.Rid)
+		return nil, err
+	}
+
+	versionRevisionMap := make(map[int64]*metadata.ServiceTemplateRevision)
+	for idx, revision := range revisions.Info {
+		versionRevisionMap[revision.Version] = &revisions.Info[idx]
+	}
+
+	for _, pin := range pins {
+		revision, exists := versionRevisionMap[pin.Version]
+		if !exists {
+			blog.Errorf("module %d is pinned to version %d of service template %d, but the revision is not found, "+
+				"rid: %s", pin.ModuleID, pin.Version, serviceTemplateID, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommNotFound)
+		}
+		revisionMap[pin.ModuleID] = revision
+	}
+
+	return revisionMap, nil
+}
+
+// GetModuleSvcTempRevision get the service template revision that the module is pinned to, returns nil if the
+// module is not pinned, which means it uses the current service template.
+func (lgc *Logic) GetModuleSvcTempRevision(kit *rest.Kit, bizID, serviceTemplateID, moduleID int64) (
+	*metadata.ServiceTemplateRevision, errors.CCErrorCoder) {
+
+	revisionMap, err := lgc.GetModuleSvcTempRevisions(kit, bizID, serviceTemplateID, []int64{moduleID})
+	if err != nil {
+		return nil, err
+	}
+
+	return revisionMap[moduleID], nil
+}
@@ -55,22 +55,24 @@ func (ps *ProcServer) CreateProcessTemplateBatch(ctx *rest.Contexts) {
 
 	ids := make([]int64, 0)
 	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
-		for _, process := range input.Processes {
-			t := &metadata.ProcessTemplate{
-				BizID:             input.BizID,
-				ServiceTemplateID: input.ServiceTemplateID,
-				Property:          process.Spec,
+		return ps.changeSvcTempWithRevision(ctx.Kit, input.BizID, []int64{input.ServiceTemplateID}, func() error {
+			for _, process := range input.Processes {
+				t := &metadata.ProcessTemplate{
+					BizID:             input.BizID,
+					ServiceTemplateID: input.ServiceTemplateID,
+					Property:          process.Spec,
+				}
+
+				temp, err := ps.CoreAPI.CoreService().Process().CreateProcessTemplate(ctx.Kit.Ctx, ctx.Kit.Header, t)
+				if err != nil {
+					blog.Errorf("create process template failed, template: %+v", *t)
+					return err
+				}
+
+				ids = append(ids, temp.ID)
 			}
-
-			temp, err := ps.CoreAPI.CoreService().Process().CreateProcessTemplate(ctx.Kit.Ctx, ctx.Kit.Header, t)
-			if err != nil {
-				blog.Errorf("create process template failed, template: %+v", *t)
-				return err
-			}
-
-			ids = append(ids, temp.ID)
-		}
-		return nil
+			return nil
+		})
 	})
 
 	if txnErr != nil {
@@ -122,12 +124,15 @@ func (ps *ProcServer) DeleteProcessTemplateBatch(ctx *rest.Contexts) {
 	}
 
 	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
-		err := ps.CoreAPI.CoreService().Process().DeleteProcessTemplateBatch(ctx.Kit.Ctx, ctx.Kit.Header, input.ProcessTemplates)
-		if err != nil {
-			blog.Errorf("delete process template: %v failed", input.ProcessTemplates)
-			return ctx.Kit.CCError.CCError(common.CCErrProcDeleteTemplateFail)
-		}
-		return nil
+		return ps.changeSvcTempWithRevision(ctx.Kit, input.BizID, serviceTemplateIDs, func() error {
+			err := ps.CoreAPI.CoreService().Process().DeleteProcessTemplateBatch(ctx.Kit.Ctx, ctx.Kit.Header,
+				input.ProcessTemplates)
+			if err != nil {
+				blog.Errorf("delete process template: %v failed", input.ProcessTemplates)
+				return ctx.Kit.CCError.CCError(common.CCErrProcDeleteTemplateFail)
+			}
+			return nil
+		})
 	})
 
 	if txnErr != nil {
@@ -175,13 +180,16 @@ func (ps *ProcServer) UpdateProcessTemplate(ctx *rest.Contexts) {
 
 	var template *metadata.ProcessTemplate
 	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
-		var err error
-		template, err = ps.CoreAPI.CoreService().Process().UpdateProcessTemplate(ctx.Kit.Ctx, ctx.Kit.Header, input.ProcessTemplateID, input.Property)
-		if err != nil {
-			blog.Errorf("update process template: %v failed.", input)
-			return err
-		}
-		return nil
+		return ps.changeSvcTempWithRevision(ctx.Kit, input.BizID, serviceTemplateIDs, func() error {
+			var err error
+			template, err = ps.CoreAPI.CoreService().Process().UpdateProcessTemplate(ctx.Kit.Ctx, ctx.Kit.Header,
+				input.ProcessTemplateID, input.Property)
+			if err != nil {
+				blog.Errorf("update process template: %v failed.", input)
+				return err
+			}
+			return nil
+		})
 	})
 
 	if txnErr != nil {
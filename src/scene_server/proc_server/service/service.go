@@ -150,6 +150,18 @@ func (ps *ProcServer) newProcessService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/service_template/attribute",
 		Handler: ps.ListServiceTemplateAttribute})
 
+	// service template revision
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/service_template/revision",
+		Handler: ps.ListServiceTemplateRevision})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/proc/service_template/revision/difference",
+		Handler: ps.DiffServiceTemplateRevision})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/service_template/version_pin",
+		Handler: ps.PinModuleServiceTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/service_template/version_pin",
+		Handler: ps.ListModuleServiceTemplateVersionPin})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/service_template/rollback",
+		Handler: ps.RollbackServiceTemplate})
+
 	// process template
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/proc/proc_template",
 		Handler: ps.CreateProcessTemplateBatch})
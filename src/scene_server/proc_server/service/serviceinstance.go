@@ -817,7 +817,8 @@ func (ps *ProcServer) DiffServiceTemplateGeneral(ctx *rest.Contexts) {
 		return
 	}
 
-	processTemplates, cErr := ps.getProcessTemplate(ctx.Kit, option.BizID, option.ServiceTemplateID)
+	processTemplates, cErr := ps.getModuleProcessTemplates(ctx.Kit, option.BizID, option.ServiceTemplateID,
+		option.ModuleID, 0)
 	if cErr != nil {
 		blog.Errorf("get process templates failed, option: %v, err %v, rid: %s", option, cErr, rid)
 		err := ctx.Kit.CCError.CCErrorf(common.CCErrProcGetProcessTemplatesFailed, cErr.Error())
@@ -1067,31 +1068,6 @@ func (ps *ProcServer) calculateGeneralDiff(ctx *rest.Contexts, bizID int64, host
 	return moduleDifference, nil
 }
 
-func (ps *ProcServer) getProcessTemplate(kit *rest.Kit, bizId int64, serviceTemplateID int64) (
-	*metadata.MultipleProcessTemplate, ccErr.CCErrorCoder) {
-
-	option := &metadata.ListProcessTemplatesOption{
-		Page: metadata.BasePage{
-			Sort: common.BKFieldID,
-		},
-	}
-	if bizId != 0 {
-		option.BusinessID = bizId
-	}
-
-	if serviceTemplateID != 0 {
-		option.ServiceTemplateIDs = []int64{serviceTemplateID}
-	}
-
-	processTemplates, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(kit.Ctx, kit.Header, option)
-	if err != nil {
-		blog.Errorf("list process templates failed, option: %+v, err: %v, rid: %s", option, err, kit.Rid)
-		return nil, err
-	}
-
-	return processTemplates, nil
-}
-
 // hostAndServiceInstsOption 查询实例的指定字段
 type hostAndServiceInstsOpt struct {
 	ProcTemplateId int64
@@ -1114,21 +1090,9 @@ func (ps *ProcServer) getHostAndServiceInsts(ctx *rest.Contexts, option *hostAnd
 	map[int64]map[string]interface{}, []int64, *metadata.MultipleProcessTemplate, map[int64]*metadata.ProcessTemplate,
 	map[int64]struct{}, ccErr.CCErrorCoder) {
 
-	cond := &metadata.ListProcessTemplatesOption{
-		BusinessID:         module.BizID,
-		ServiceTemplateIDs: []int64{module.ServiceTemplateID},
-		Page: metadata.BasePage{
-			Sort: common.BKFieldID,
-		},
-	}
-
-	if option.ProcTemplateId != 0 {
-		cond.ProcessTemplateIDs = []int64{option.ProcTemplateId}
-	}
-
-	processTemplates, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(ctx.Kit.Ctx, ctx.Kit.Header, cond)
+	processTemplates, err := ps.getModuleProcessTemplates(ctx.Kit, module.BizID, module.ServiceTemplateID,
+		option.ModuleID, option.ProcTemplateId)
 	if err != nil {
-		blog.Errorf("list process templates failed, option: %s, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
 		return nil, nil, nil, []int64{}, nil, nil, nil, err
 	}
 
@@ -1677,9 +1641,24 @@ func (ps *ProcServer) getModuleMapStr(kit *rest.Kit, bizID, serviceTemplateId in
 	return modules.Info, nil
 }
 
-// getSrvTemplateAttrIdAndPropertyValue 获取服务模板的属性id以及对应的属性值
-func (ps *ProcServer) getSrvTemplateAttrIdAndPropertyValue(kit *rest.Kit, bizID, serviceTemplateID int64) ([]int64,
-	map[int64]interface{}, ccErr.CCErrorCoder) {
+// getSrvTemplateAttrIdAndPropertyValue 获取服务模板的属性id以及对应的属性值，模块固定了服务模板版本时取该版本的属性
+func (ps *ProcServer) getSrvTemplateAttrIdAndPropertyValue(kit *rest.Kit, bizID, serviceTemplateID,
+	moduleID int64) ([]int64, map[int64]interface{}, ccErr.CCErrorCoder) {
+
+	revision, cErr := ps.Logic.GetModuleSvcTempRevision(kit, bizID, serviceTemplateID, moduleID)
+	if cErr != nil {
+		return nil, nil, cErr
+	}
+
+	if revision != nil {
+		attrIDs := make([]int64, 0)
+		srvTemplateAttrValueMap := make(map[int64]interface{})
+		for _, attr := range revision.Attributes {
+			attrIDs = append(attrIDs, attr.AttributeID)
+			srvTemplateAttrValueMap[attr.AttributeID] = attr.PropertyValue
+		}
+		return attrIDs, srvTemplateAttrValueMap, nil
+	}
 
 	option := &metadata.ListServTempAttrOption{
 		BizID:  bizID,
@@ -1745,7 +1724,7 @@ func (ps *ProcServer) getAttributesResult(kit *rest.Kit, option *metadata.Servic
 	attrValues := make([]metadata.AttributeFields, 0)
 	// 1、获取指定服务模板的属性ID及属性值
 	attrIDs, srvTemplateAttrValueMap, cErr := ps.getSrvTemplateAttrIdAndPropertyValue(kit, option.BizID,
-		option.ServiceTemplateID)
+		option.ServiceTemplateID, option.ModuleID)
 	if cErr != nil {
 		return attrValues, cErr
 	}
@@ -2124,7 +2103,7 @@ func (ps *ProcServer) updateModuleAttributes(kit *rest.Kit, option *metadata.Syn
 
 	// 1、获取服务模板的属性id与对应的property_value
 	attrIDs, srvTemplateAttrValueMap, cErr := ps.getSrvTemplateAttrIdAndPropertyValue(kit, option.BizID,
-		option.ServiceTemplateID)
+		option.ServiceTemplateID, option.ModuleID)
 	if cErr != nil {
 		return nil, cErr
 	}
@@ -2256,14 +2235,9 @@ func (ps *ProcServer) getProcessInfo(kit *rest.Kit, option *metadata.SyncService
 		processInstanceWithTemplateMap: make(map[int64]int64),
 	}
 
-	// find all the process template under the service template
-	procTempOpt := &metadata.ListProcessTemplatesOption{
-		BusinessID:         option.BizID,
-		ServiceTemplateIDs: []int64{option.ServiceTemplateID},
-	}
-	procTemps, cErr := ps.CoreAPI.CoreService().Process().ListProcessTemplates(kit.Ctx, kit.Header, procTempOpt)
+	// find all the process template under the service template, or under the revision if the module is pinned
+	procTemps, cErr := ps.getModuleProcessTemplates(kit, option.BizID, option.ServiceTemplateID, option.ModuleID, 0)
 	if cErr != nil {
-		blog.Errorf("list process templates failed, option: %+v, err: %v, rid: %s", procTempOpt, cErr, kit.Rid)
 		return nil, nil, cErr
 	}
 
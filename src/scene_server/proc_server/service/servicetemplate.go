@@ -58,6 +58,10 @@ func (ps *ProcServer) CreateServiceTemplate(ctx *rest.Contexts) {
 			return err
 		}
 
+		if _, err = ps.recordSvcTempRevision(ctx.Kit, tpl.BizID, tpl.ID, metadata.SvcTempRevisionCreate); err != nil {
+			return err
+		}
+
 		// register service template resource creator action to iam
 		if auth.EnableAuthorize() {
 			iamInstance := metadata.IamInstanceWithCreator{
@@ -138,6 +142,10 @@ func (ps *ProcServer) CreateServiceTemplateAllInfo(ctx *rest.Contexts) {
 			}
 		}
 
+		if _, err = ps.recordSvcTempRevision(ctx.Kit, tpl.BizID, templateID, metadata.SvcTempRevisionCreate); err != nil {
+			return err
+		}
+
 		// register service template resource creator action to iam
 		if auth.EnableAuthorize() {
 			iamInstance := metadata.IamInstanceWithCreator{
@@ -758,14 +766,16 @@ func (ps *ProcServer) UpdateServiceTemplate(ctx *rest.Contexts) {
 
 	var tpl *metadata.ServiceTemplate
 	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
-		var err error
-		tpl, err = ps.CoreAPI.CoreService().Process().UpdateServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, option.ID,
-			updateParam)
-		if err != nil {
-			blog.Errorf("update service template failed, err: %v", err)
-			return err
-		}
-		return nil
+		return ps.changeSvcTempWithRevision(ctx.Kit, option.BizID, []int64{option.ID}, func() error {
+			var err error
+			tpl, err = ps.CoreAPI.CoreService().Process().UpdateServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header,
+				option.ID, updateParam)
+			if err != nil {
+				blog.Errorf("update service template failed, err: %v", err)
+				return err
+			}
+			return nil
+		})
 	})
 
 	if txnErr != nil {
@@ -790,32 +800,36 @@ func (ps *ProcServer) UpdateServiceTemplateAllInfo(ctx *rest.Contexts) {
 	}
 
 	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
-		// update service template
-		if option.Name != allInfo.Name || option.ServiceCategoryID != allInfo.ServiceCategoryID {
-			opt := &metadata.ServiceTemplate{
-				Name:              option.Name,
-				ServiceCategoryID: option.ServiceCategoryID,
+		return ps.changeSvcTempWithRevision(ctx.Kit, allInfo.BizID, []int64{allInfo.ID}, func() error {
+			// update service template
+			if option.Name != allInfo.Name || option.ServiceCategoryID != allInfo.ServiceCategoryID {
+				opt := &metadata.ServiceTemplate{
+					Name:              option.Name,
+					ServiceCategoryID: option.ServiceCategoryID,
+				}
+
+				if _, err := ps.CoreAPI.CoreService().Process().UpdateServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header,
+					option.ID, opt); err != nil {
+					blog.Errorf("update svc temp %d failed, opt: %+v, err: %v, rid: %s", option.ID, opt, err,
+						ctx.Kit.Rid)
+					return err
+				}
 			}
 
-			if _, err := ps.CoreAPI.CoreService().Process().UpdateServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header,
-				option.ID, opt); err != nil {
-				blog.Errorf("update svc temp %d failed, opt: %+v, err: %v, rid: %s", option.ID, opt, err, ctx.Kit.Rid)
+			// update service template attributes
+			err := ps.updateSvcTempAllAttrs(ctx.Kit, allInfo.ID, allInfo.BizID, allInfo.Attributes, option.Attributes)
+			if err != nil {
 				return err
 			}
-		}
-
-		// update service template attributes
-		err = ps.updateSvcTempAllAttrs(ctx.Kit, allInfo.ID, allInfo.BizID, allInfo.Attributes, option.Attributes)
-		if err != nil {
-			return err
-		}
 
-		// update process templates
-		err = ps.updateSvcTempAllProcTemps(ctx.Kit, allInfo.ID, allInfo.BizID, allInfo.Processes, option.Processes)
-		if err != nil {
-			return err
-		}
-		return nil
+			// update process templates
+			err = ps.updateSvcTempAllProcTemps(ctx.Kit, allInfo.ID, allInfo.BizID, allInfo.Processes,
+				option.Processes)
+			if err != nil {
+				return err
+			}
+			return nil
+		})
 	})
 
 	if txnErr != nil {
@@ -1247,11 +1261,10 @@ func (ps *ProcServer) UpdateServiceTemplateAttribute(ctx *rest.Contexts) {
 	}
 
 	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
-		if err := ps.Engine.CoreAPI.CoreService().Process().UpdateServiceTemplateAttribute(ctx.Kit.Ctx, ctx.Kit.Header,
-			option); err != nil {
-			return err
-		}
-		return nil
+		return ps.changeSvcTempWithRevision(ctx.Kit, option.BizID, []int64{option.ID}, func() error {
+			return ps.Engine.CoreAPI.CoreService().Process().UpdateServiceTemplateAttribute(ctx.Kit.Ctx, ctx.Kit.Header,
+				option)
+		})
 	})
 
 	if txnErr != nil {
@@ -1276,12 +1289,10 @@ func (ps *ProcServer) DeleteServiceTemplateAttribute(ctx *rest.Contexts) {
 	}
 
 	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
-		if err := ps.Engine.CoreAPI.CoreService().Process().DeleteServiceTemplateAttribute(ctx.Kit.Ctx, ctx.Kit.Header,
-			option); err != nil {
-			return err
-		}
-
-		return nil
+		return ps.changeSvcTempWithRevision(ctx.Kit, option.BizID, []int64{option.ID}, func() error {
+			return ps.Engine.CoreAPI.CoreService().Process().DeleteServiceTemplateAttribute(ctx.Kit.Ctx, ctx.Kit.Header,
+				option)
+		})
 	})
 
 	if txnErr != nil {
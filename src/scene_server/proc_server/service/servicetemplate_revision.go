@@ -0,0 +1,424 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// ListServiceTemplateRevision list the revisions of service template
+func (ps *ProcServer) ListServiceTemplateRevision(ctx *rest.Contexts) {
+	option := new(metadata.ListSvcTempRevisionOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	revisions, err := ps.CoreAPI.CoreService().Process().ListSvcTempRevision(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("list service template revisions failed, option: %+v, err: %v, rid: %s", option, err,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(revisions)
+}
+
+// DiffServiceTemplateRevision get the difference between two revisions of service template, version 0 stands for
+// the current definition of the service template
+func (ps *ProcServer) DiffServiceTemplateRevision(ctx *rest.Contexts) {
+	option := new(metadata.DiffSvcTempRevisionOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	from, err := ps.getSvcTempRevisionOrSnapshot(ctx.Kit, option.BizID, option.ServiceTemplateID, option.FromVersion)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	to, err := ps.getSvcTempRevisionOrSnapshot(ctx.Kit, option.BizID, option.ServiceTemplateID, option.ToVersion)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(metadata.DiffSvcTempRevision(from, to))
+}
+
+// PinModuleServiceTemplateVersion pin modules to a revision of their service template, the difference and sync of
+// the pinned modules' service instances use the process templates and attributes of the revision. version 0 means
+// unpin the modules. module name and service category are not affected by pin, they always follow the template.
+func (ps *ProcServer) PinModuleServiceTemplateVersion(ctx *rest.Contexts) {
+	option := new(metadata.PinSvcTempVersionOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		// make sure that the current definition can be pinned to even if the template has never been changed
+		if err := ps.ensureSvcTempBaseline(ctx.Kit, option.BizID, option.ServiceTemplateID); err != nil {
+			return err
+		}
+
+		err := ps.CoreAPI.CoreService().Process().PinSvcTempVersion(ctx.Kit.Ctx, ctx.Kit.Header, option)
+		if err != nil {
+			blog.Errorf("pin modules to service template version failed, option: %+v, err: %v, rid: %s", option,
+				err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListModuleServiceTemplateVersionPin list the service template versions that modules are pinned to
+func (ps *ProcServer) ListModuleServiceTemplateVersionPin(ctx *rest.Contexts) {
+	option := new(metadata.ListSvcTempVersionPinOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	pins, err := ps.CoreAPI.CoreService().Process().ListSvcTempVersionPin(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("list service template version pins failed, option: %+v, err: %v, rid: %s", option, err,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(pins)
+}
+
+// RollbackServiceTemplate roll the service template back to the definition of a revision, including name, service
+// category, attributes and process templates, then record it as a new revision. process templates that have been
+// removed after the revision are recreated with new ids.
+func (ps *ProcServer) RollbackServiceTemplate(ctx *rest.Contexts) {
+	option := new(metadata.RollbackSvcTempOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	revision, err := ps.rollbackSvcTemp(ctx.Kit, option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(revision)
+}
+
+// rollbackSvcTemp roll the service template back to the revision in transaction, returns the rollback revision
+func (ps *ProcServer) rollbackSvcTemp(kit *rest.Kit, option *metadata.RollbackSvcTempOption) (
+	*metadata.ServiceTemplateRevision, error) {
+
+	target, err := ps.getSvcTempRevision(kit, option.BizID, option.ServiceTemplateID, option.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	allInfo, err := ps.getServiceTemplateAllInfo(kit, option.ServiceTemplateID, option.BizID)
+	if err != nil {
+		return nil, err
+	}
+
+	var revision *metadata.ServiceTemplateRevision
+	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(kit.Ctx, kit.Header, func() error {
+		if err := ps.ensureSvcTempBaseline(kit, option.BizID, option.ServiceTemplateID); err != nil {
+			return err
+		}
+
+		if target.Name != allInfo.Name || target.ServiceCategoryID != allInfo.ServiceCategoryID {
+			opt := &metadata.ServiceTemplate{
+				Name:              target.Name,
+				ServiceCategoryID: target.ServiceCategoryID,
+			}
+
+			if _, err := ps.CoreAPI.CoreService().Process().UpdateServiceTemplate(kit.Ctx, kit.Header,
+				option.ServiceTemplateID, opt); err != nil {
+				blog.Errorf("update svc temp %d failed, opt: %+v, err: %v, rid: %s", option.ServiceTemplateID, opt,
+					err, kit.Rid)
+				return err
+			}
+		}
+
+		err := ps.updateSvcTempAllAttrs(kit, allInfo.ID, allInfo.BizID, allInfo.Attributes, target.Attributes)
+		if err != nil {
+			return err
+		}
+
+		err = ps.updateSvcTempAllProcTemps(kit, allInfo.ID, allInfo.BizID, allInfo.Processes,
+			target.ProcessTemplates)
+		if err != nil {
+			return err
+		}
+
+		revision, err = ps.recordSvcTempRevision(kit, option.BizID, option.ServiceTemplateID,
+			metadata.SvcTempRevisionRollback)
+		return err
+	})
+
+	if txnErr != nil {
+		return nil, txnErr
+	}
+	return revision, nil
+}
+
+// getSvcTempSnapshot get the current definition of service template in the form of revision, its version is 0
+func (ps *ProcServer) getSvcTempSnapshot(kit *rest.Kit, bizID, id int64) (*metadata.ServiceTemplateRevision,
+	errors.CCErrorCoder) {
+
+	allInfo, err := ps.getServiceTemplateAllInfo(kit, id, bizID)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make([]metadata.SvcTempAttr, len(allInfo.Attributes))
+	for idx, attr := range allInfo.Attributes {
+		attrs[idx] = metadata.SvcTempAttr{
+			AttributeID:   attr.AttributeID,
+			PropertyValue: attr.PropertyValue,
+		}
+	}
+
+	return &metadata.ServiceTemplateRevision{
+		BizID:             allInfo.BizID,
+		ServiceTemplateID: allInfo.ID,
+		Name:              allInfo.Name,
+		ServiceCategoryID: allInfo.ServiceCategoryID,
+		Attributes:        attrs,
+		ProcessTemplates:  allInfo.Processes,
+	}, nil
+}
+
+// getSvcTempRevision get the specified revision of service template
+func (ps *ProcServer) getSvcTempRevision(kit *rest.Kit, bizID, id, version int64) (*metadata.ServiceTemplateRevision,
+	errors.CCErrorCoder) {
+
+	option := &metadata.ListSvcTempRevisionOption{
+		BizID:             bizID,
+		ServiceTemplateID: id,
+		Versions:          []int64{version},
+		Page:              metadata.BasePage{Limit: 1},
+	}
+	revisions, err := ps.CoreAPI.CoreService().Process().ListSvcTempRevision(kit.Ctx, kit.Header, option)
+	if err != nil {
+		blog.Errorf("get service template revision failed, option: %+v, err: %v, rid: %s", option, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(revisions.Info) == 0 {
+		blog.Errorf("service template %d has no version %d, rid: %s", id, version, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "version")
+	}
+
+	return &revisions.Info[0], nil
+}
+
+// getSvcTempRevisionOrSnapshot get the specified revision of service template, or the current definition if
+// version is 0
+func (ps *ProcServer) getSvcTempRevisionOrSnapshot(kit *rest.Kit, bizID, id, version int64) (
+	*metadata.ServiceTemplateRevision, errors.CCErrorCoder) {
+
+	if version == 0 {
+		return ps.getSvcTempSnapshot(kit, bizID, id)
+	}
+	return ps.getSvcTempRevision(kit, bizID, id, version)
+}
+
+// getLatestSvcTempRevision get the latest revision of service template, returns nil if it has no revision
+func (ps *ProcServer) getLatestSvcTempRevision(kit *rest.Kit, bizID, id int64) (*metadata.ServiceTemplateRevision,
+	errors.CCErrorCoder) {
+
+	option := &metadata.ListSvcTempRevisionOption{
+		BizID:             bizID,
+		ServiceTemplateID: id,
+		Page:              metadata.BasePage{Limit: 1},
+	}
+	revisions, err := ps.CoreAPI.CoreService().Process().ListSvcTempRevision(kit.Ctx, kit.Header, option)
+	if err != nil {
+		blog.Errorf("get latest service template revision failed, option: %+v, err: %v, rid: %s", option, err,
+			kit.Rid)
+		return nil, err
+	}
+
+	if len(revisions.Info) == 0 {
+		return nil, nil
+	}
+	return &revisions.Info[0], nil
+}
+
+// ensureSvcTempBaseline records the current definition as the baseline revision if the service template has no
+// revision, so that the definition of template created before versioning is introduced can still be rolled back to.
+func (ps *ProcServer) ensureSvcTempBaseline(kit *rest.Kit, bizID, id int64) errors.CCErrorCoder {
+	latest, err := ps.getLatestSvcTempRevision(kit, bizID, id)
+	if err != nil {
+		return err
+	}
+
+	if latest != nil {
+		return nil
+	}
+
+	_, err = ps.recordSvcTempRevision(kit, bizID, id, metadata.SvcTempRevisionBaseline)
+	return err
+}
+
+// recordSvcTempRevision records the current definition of service template as a new revision, the latest revision
+// is returned without recording a new one if the definition is not changed.
+func (ps *ProcServer) recordSvcTempRevision(kit *rest.Kit, bizID, id int64, action metadata.SvcTempRevisionAction) (
+	*metadata.ServiceTemplateRevision, errors.CCErrorCoder) {
+
+	snapshot, err := ps.getSvcTempSnapshot(kit, bizID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := ps.getLatestSvcTempRevision(kit, bizID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if latest != nil && metadata.DiffSvcTempRevision(latest, snapshot).IsEmpty() {
+		return latest, nil
+	}
+
+	snapshot.Action = action
+	revision, err := ps.CoreAPI.CoreService().Process().CreateSvcTempRevision(kit.Ctx, kit.Header, snapshot)
+	if err != nil {
+		blog.Errorf("create service template %d revision failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// changeSvcTempWithRevision do the change of service templates, and record their definitions before and after the
+// change as revisions. it should be called in transaction.
+func (ps *ProcServer) changeSvcTempWithRevision(kit *rest.Kit, bizID int64, svcTempIDs []int64,
+	change func() error) error {
+
+	svcTempIDs = util.IntArrayUnique(svcTempIDs)
+	for _, id := range svcTempIDs {
+		if err := ps.ensureSvcTempBaseline(kit, bizID, id); err != nil {
+			return err
+		}
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	for _, id := range svcTempIDs {
+		if _, err := ps.recordSvcTempRevision(kit, bizID, id, metadata.SvcTempRevisionUpdate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getModuleProcessTemplates get the process templates that the module uses, which are the process templates of the
+// revision if the module is pinned, or the current process templates of the service template otherwise. only the
+// specified process template is returned if processTemplateID is not 0.
+func (ps *ProcServer) getModuleProcessTemplates(kit *rest.Kit, bizID, serviceTemplateID, moduleID,
+	processTemplateID int64) (*metadata.MultipleProcessTemplate, errors.CCErrorCoder) {
+
+	revision, err := ps.Logic.GetModuleSvcTempRevision(kit, bizID, serviceTemplateID, moduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if revision == nil {
+		option := &metadata.ListProcessTemplatesOption{
+			BusinessID:         bizID,
+			ServiceTemplateIDs: []int64{serviceTemplateID},
+			Page: metadata.BasePage{
+				Sort: common.BKFieldID,
+			},
+		}
+
+		if processTemplateID != 0 {
+			option.ProcessTemplateIDs = []int64{processTemplateID}
+		}
+
+		processTemplates, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(kit.Ctx, kit.Header, option)
+		if err != nil {
+			blog.Errorf("list process templates failed, option: %+v, err: %v, rid: %s", option, err, kit.Rid)
+			return nil, err
+		}
+		return processTemplates, nil
+	}
+
+	processTemplates := make([]metadata.ProcessTemplate, 0)
+	for _, processTemplate := range revision.ProcessTemplates {
+		if processTemplateID != 0 && processTemplate.ID != processTemplateID {
+			continue
+		}
+		processTemplates = append(processTemplates, processTemplate)
+	}
+
+	sort.Slice(processTemplates, func(i, j int) bool {
+		return processTemplates[i].ID < processTemplates[j].ID
+	})
+
+	return &metadata.MultipleProcessTemplate{
+		Count: uint64(len(processTemplates)),
+		Info:  processTemplates,
+	}, nil
+}
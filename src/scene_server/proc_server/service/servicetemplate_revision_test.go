@@ -0,0 +1,431 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"testing"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/coreservice"
+	coreprocess "configcenter/src/apimachinery/coreservice/process"
+	"configcenter/src/apimachinery/transaction"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClientSet struct {
+	apimachinery.ClientSetInterface
+	coreService *fakeCoreService
+}
+
+// CoreService returns the fake core service client
+func (f *fakeClientSet) CoreService() coreservice.CoreServiceClientInterface {
+	return f.coreService
+}
+
+type fakeCoreService struct {
+	coreservice.CoreServiceClientInterface
+	process *fakeProcessClient
+}
+
+// Process returns the fake process client
+func (f *fakeCoreService) Process() coreprocess.ProcessInterface {
+	return f.process
+}
+
+// Txn returns the fake transaction client
+func (f *fakeCoreService) Txn() transaction.Interface {
+	return &fakeTxn{}
+}
+
+type fakeTxn struct {
+	transaction.Interface
+}
+
+// AutoRunTxn runs the function directly
+func (f *fakeTxn) AutoRunTxn(_ context.Context, _ http.Header, run func() error, _ ...metadata.TxnOption) error {
+	return run()
+}
+
+// fakeProcessClient stores one service template with its attributes, process templates and revisions in memory
+type fakeProcessClient struct {
+	coreprocess.ProcessInterface
+	svcTemp   metadata.ServiceTemplate
+	attrs     map[int64]interface{}
+	procTemps map[int64]metadata.ProcessTemplate
+	revisions []metadata.ServiceTemplateRevision
+	nextID    int64
+}
+
+// GetServiceTemplate returns the fake service template
+func (f *fakeProcessClient) GetServiceTemplate(_ context.Context, _ http.Header, _ int64) (
+	*metadata.ServiceTemplate, errors.CCErrorCoder) {
+	svcTemp := f.svcTemp
+	return &svcTemp, nil
+}
+
+// UpdateServiceTemplate updates the name and service category of the fake service template
+func (f *fakeProcessClient) UpdateServiceTemplate(_ context.Context, _ http.Header, _ int64,
+	template *metadata.ServiceTemplate) (*metadata.ServiceTemplate, errors.CCErrorCoder) {
+	f.svcTemp.Name = template.Name
+	f.svcTemp.ServiceCategoryID = template.ServiceCategoryID
+	svcTemp := f.svcTemp
+	return &svcTemp, nil
+}
+
+// ListServiceTemplateAttribute returns the fake service template attributes sorted by attribute id
+func (f *fakeProcessClient) ListServiceTemplateAttribute(_ context.Context, _ http.Header,
+	_ *metadata.ListServTempAttrOption) (*metadata.ServTempAttrData, errors.CCErrorCoder) {
+	attrs := make([]metadata.ServiceTemplateAttr, 0)
+	for attrID, value := range f.attrs {
+		attrs = append(attrs, metadata.ServiceTemplateAttr{
+			BizID:             f.svcTemp.BizID,
+			ServiceTemplateID: f.svcTemp.ID,
+			AttributeID:       attrID,
+			PropertyValue:     value,
+		})
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].AttributeID < attrs[j].AttributeID })
+	return &metadata.ServTempAttrData{Attributes: attrs}, nil
+}
+
+// CreateServiceTemplateAttrs adds the fake service template attributes
+func (f *fakeProcessClient) CreateServiceTemplateAttrs(_ context.Context, _ http.Header,
+	option *metadata.CreateSvcTempAttrsOption) ([]int64, errors.CCErrorCoder) {
+	ids := make([]int64, 0)
+	for _, attr := range option.Attributes {
+		f.attrs[attr.AttributeID] = attr.PropertyValue
+		ids = append(ids, attr.AttributeID)
+	}
+	return ids, nil
+}
+
+// UpdateServiceTemplateAttribute updates the fake service template attributes
+func (f *fakeProcessClient) UpdateServiceTemplateAttribute(_ context.Context, _ http.Header,
+	option *metadata.UpdateServTempAttrOption) errors.CCErrorCoder {
+	for _, attr := range option.Attributes {
+		f.attrs[attr.AttributeID] = attr.PropertyValue
+	}
+	return nil
+}
+
+// DeleteServiceTemplateAttribute deletes the fake service template attributes
+func (f *fakeProcessClient) DeleteServiceTemplateAttribute(_ context.Context, _ http.Header,
+	option *metadata.DeleteServTempAttrOption) errors.CCErrorCoder {
+	for _, attrID := range option.AttributeIDs {
+		delete(f.attrs, attrID)
+	}
+	return nil
+}
+
+// ListProcessTemplates returns the fake process templates sorted by id
+func (f *fakeProcessClient) ListProcessTemplates(_ context.Context, _ http.Header,
+	_ *metadata.ListProcessTemplatesOption) (*metadata.MultipleProcessTemplate, errors.CCErrorCoder) {
+	procTemps := make([]metadata.ProcessTemplate, 0)
+	for _, procTemp := range f.procTemps {
+		procTemps = append(procTemps, procTemp)
+	}
+	sort.Slice(procTemps, func(i, j int) bool { return procTemps[i].ID < procTemps[j].ID })
+	return &metadata.MultipleProcessTemplate{Count: uint64(len(procTemps)), Info: procTemps}, nil
+}
+
+// CreateProcessTemplate adds the fake process template with a new id
+func (f *fakeProcessClient) CreateProcessTemplate(_ context.Context, _ http.Header,
+	template *metadata.ProcessTemplate) (*metadata.ProcessTemplate, errors.CCErrorCoder) {
+	f.nextID++
+	procTemp := *template
+	procTemp.ID = f.nextID
+	f.procTemps[procTemp.ID] = procTemp
+	return &procTemp, nil
+}
+
+// UpdateProcessTemplate replaces the property of the fake process template
+func (f *fakeProcessClient) UpdateProcessTemplate(_ context.Context, _ http.Header, templateID int64,
+	property map[string]interface{}) (*metadata.ProcessTemplate, errors.CCErrorCoder) {
+	js, err := json.Marshal(property)
+	if err != nil {
+		return nil, errors.New(1, err.Error())
+	}
+
+	procTemp := f.procTemps[templateID]
+	procTemp.Property = new(metadata.ProcessProperty)
+	if err := json.Unmarshal(js, procTemp.Property); err != nil {
+		return nil, errors.New(1, err.Error())
+	}
+	f.procTemps[templateID] = procTemp
+	return &procTemp, nil
+}
+
+// DeleteProcessTemplate deletes the fake process template
+func (f *fakeProcessClient) DeleteProcessTemplate(_ context.Context, _ http.Header,
+	templateID int64) errors.CCErrorCoder {
+	delete(f.procTemps, templateID)
+	return nil
+}
+
+// CreateSvcTempRevision records the revision with the next version, the same as core service does
+func (f *fakeProcessClient) CreateSvcTempRevision(_ context.Context, _ http.Header,
+	revision *metadata.ServiceTemplateRevision) (*metadata.ServiceTemplateRevision, errors.CCErrorCoder) {
+	created := *revision
+	created.Version = int64(len(f.revisions)) + 1
+	f.revisions = append(f.revisions, created)
+	return &created, nil
+}
+
+// ListSvcTempRevision returns the fake revisions sorted by version in descending order
+func (f *fakeProcessClient) ListSvcTempRevision(_ context.Context, _ http.Header,
+	option *metadata.ListSvcTempRevisionOption) (*metadata.MultipleSvcTempRevision, errors.CCErrorCoder) {
+	versions := make(map[int64]struct{})
+	for _, version := range option.Versions {
+		versions[version] = struct{}{}
+	}
+
+	revisions := make([]metadata.ServiceTemplateRevision, 0)
+	for idx := len(f.revisions) - 1; idx >= 0; idx-- {
+		if _, exists := versions[f.revisions[idx].Version]; len(versions) > 0 && !exists {
+			continue
+		}
+		revisions = append(revisions, f.revisions[idx])
+	}
+
+	count := int64(len(revisions))
+	if option.Page.Limit > 0 && len(revisions) > option.Page.Limit {
+		revisions = revisions[:option.Page.Limit]
+	}
+	return &metadata.MultipleSvcTempRevision{Count: count, Info: revisions}, nil
+}
+
+func strProp(value string) metadata.PropertyString {
+	return metadata.PropertyString{Value: &value}
+}
+
+func newTestProcTemp(id int64, funcName string) metadata.ProcessTemplate {
+	return metadata.ProcessTemplate{
+		ID:                id,
+		ProcessName:       funcName,
+		BizID:             1,
+		ServiceTemplateID: 10,
+		Property:          &metadata.ProcessProperty{FuncName: strProp(funcName)},
+	}
+}
+
+func newTestProcServer(t *testing.T) (*ProcServer, *fakeProcessClient, *rest.Kit) {
+	process := &fakeProcessClient{
+		svcTemp:   metadata.ServiceTemplate{ID: 10, BizID: 1, Name: "svc", ServiceCategoryID: 2},
+		attrs:     map[int64]interface{}{1: "a"},
+		procTemps: map[int64]metadata.ProcessTemplate{100: newTestProcTemp(100, "nginx")},
+		nextID:    100,
+	}
+	ps := &ProcServer{Engine: &backbone.Engine{
+		CoreAPI: &fakeClientSet{coreService: &fakeCoreService{process: process}},
+	}}
+
+	errFactory, err := errors.NewFactory("../../../../resources/errors/")
+	require.NoError(t, err)
+	kit := &rest.Kit{Ctx: context.Background(), Header: make(http.Header), Rid: "rid",
+		CCError: errFactory.CreateDefaultCCErrorIf("en")}
+	return ps, process, kit
+}
+
+func TestDiffSvcTempRevision(t *testing.T) {
+	from := &metadata.ServiceTemplateRevision{
+		Version:           1,
+		Name:              "svc",
+		ServiceCategoryID: 2,
+		Attributes: []metadata.SvcTempAttr{
+			{AttributeID: 1, PropertyValue: "a"},
+			{AttributeID: 2, PropertyValue: "b"},
+			{AttributeID: 3, PropertyValue: "c"},
+		},
+		ProcessTemplates: []metadata.ProcessTemplate{newTestProcTemp(100, "nginx"), newTestProcTemp(101, "redis"),
+			newTestProcTemp(102, "mysql")},
+	}
+
+	renamed := newTestProcTemp(101, "redis")
+	renamed.ProcessName = "cache"
+	changedProp := newTestProcTemp(102, "mysql")
+	changedProp.Property.StopCmd = strProp("stop")
+	to := &metadata.ServiceTemplateRevision{
+		Version:           3,
+		Name:              "svc-new",
+		ServiceCategoryID: 5,
+		Attributes: []metadata.SvcTempAttr{
+			{AttributeID: 5, PropertyValue: "e"},
+			{AttributeID: 3, PropertyValue: "cc"},
+			{AttributeID: 4, PropertyValue: "d"},
+			{AttributeID: 2, PropertyValue: "b"},
+		},
+		ProcessTemplates: []metadata.ProcessTemplate{changedProp, newTestProcTemp(104, "mongo"), renamed,
+			newTestProcTemp(103, "kafka")},
+	}
+
+	diff := metadata.DiffSvcTempRevision(from, to)
+	require.False(t, diff.IsEmpty())
+	require.Equal(t, int64(1), diff.FromVersion)
+	require.Equal(t, int64(3), diff.ToVersion)
+	require.Equal(t, &metadata.SvcTempFieldDiff{From: "svc", To: "svc-new"}, diff.Name)
+	require.Equal(t, &metadata.SvcTempFieldDiff{From: int64(2), To: int64(5)}, diff.ServiceCategoryID)
+
+	require.Equal(t, []metadata.SvcTempAttr{{AttributeID: 4, PropertyValue: "d"}, {AttributeID: 5, PropertyValue: "e"}},
+		diff.Attributes.Added)
+	require.Equal(t, []metadata.SvcTempAttrChange{{AttributeID: 3, From: "c", To: "cc"}}, diff.Attributes.Changed)
+	require.Equal(t, []metadata.SvcTempAttr{{AttributeID: 1, PropertyValue: "a"}}, diff.Attributes.Removed)
+
+	require.Len(t, diff.ProcessTemplates.Added, 2)
+	require.Equal(t, int64(103), diff.ProcessTemplates.Added[0].ID)
+	require.Equal(t, int64(104), diff.ProcessTemplates.Added[1].ID)
+	require.Len(t, diff.ProcessTemplates.Changed, 2)
+	require.Equal(t, int64(101), diff.ProcessTemplates.Changed[0].ID)
+	require.Equal(t, "cache", diff.ProcessTemplates.Changed[0].ProcessName)
+	require.Equal(t, int64(102), diff.ProcessTemplates.Changed[1].ID)
+	require.Nil(t, diff.ProcessTemplates.Changed[1].From.StopCmd.Value)
+	require.Equal(t, "stop", *diff.ProcessTemplates.Changed[1].To.StopCmd.Value)
+	require.Len(t, diff.ProcessTemplates.Removed, 1)
+	require.Equal(t, int64(100), diff.ProcessTemplates.Removed[0].ID)
+
+	same := &metadata.ServiceTemplateRevision{
+		Name:              from.Name,
+		ServiceCategoryID: from.ServiceCategoryID,
+		Attributes:        []metadata.SvcTempAttr{from.Attributes[2], from.Attributes[0], from.Attributes[1]},
+		ProcessTemplates: []metadata.ProcessTemplate{newTestProcTemp(102, "mysql"), newTestProcTemp(100, "nginx"),
+			newTestProcTemp(101, "redis")},
+	}
+	diff = metadata.DiffSvcTempRevision(from, same)
+	require.True(t, diff.IsEmpty())
+	require.Equal(t, int64(1), diff.FromVersion)
+	require.Equal(t, int64(0), diff.ToVersion)
+	require.NotNil(t, diff.Attributes.Added)
+	require.NotNil(t, diff.ProcessTemplates.Removed)
+}
+
+func TestEnsureSvcTempBaseline(t *testing.T) {
+	ps, process, kit := newTestProcServer(t)
+
+	require.NoError(t, ps.ensureSvcTempBaseline(kit, 1, 10))
+	require.Len(t, process.revisions, 1)
+	baseline := process.revisions[0]
+	require.Equal(t, int64(1), baseline.Version)
+	require.Equal(t, metadata.SvcTempRevisionBaseline, baseline.Action)
+	require.Equal(t, "svc", baseline.Name)
+	require.Equal(t, []metadata.SvcTempAttr{{AttributeID: 1, PropertyValue: "a"}}, baseline.Attributes)
+	require.Len(t, baseline.ProcessTemplates, 1)
+
+	// the baseline is only recorded when the template has no revision
+	process.svcTemp.Name = "svc-new"
+	require.NoError(t, ps.ensureSvcTempBaseline(kit, 1, 10))
+	require.Len(t, process.revisions, 1)
+}
+
+func TestChangeSvcTempWithRevision(t *testing.T) {
+	ps, process, kit := newTestProcServer(t)
+
+	err := ps.changeSvcTempWithRevision(kit, 1, []int64{10, 10}, func() error {
+		process.svcTemp.Name = "svc-new"
+		process.attrs[2] = "b"
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, process.revisions, 2)
+	require.Equal(t, metadata.SvcTempRevisionBaseline, process.revisions[0].Action)
+	require.Equal(t, "svc", process.revisions[0].Name)
+	require.Equal(t, int64(2), process.revisions[1].Version)
+	require.Equal(t, metadata.SvcTempRevisionUpdate, process.revisions[1].Action)
+	require.Equal(t, "svc-new", process.revisions[1].Name)
+	require.Len(t, process.revisions[1].Attributes, 2)
+
+	// the next change continues the numbering
+	err = ps.changeSvcTempWithRevision(kit, 1, []int64{10}, func() error {
+		delete(process.procTemps, 100)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, process.revisions, 3)
+	require.Equal(t, int64(3), process.revisions[2].Version)
+	require.Empty(t, process.revisions[2].ProcessTemplates)
+
+	// no revision is recorded if the change makes no difference
+	err = ps.changeSvcTempWithRevision(kit, 1, []int64{10}, func() error { return nil })
+	require.NoError(t, err)
+	require.Len(t, process.revisions, 3)
+
+	latest, err := ps.recordSvcTempRevision(kit, 1, 10, metadata.SvcTempRevisionUpdate)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), latest.Version)
+}
+
+func TestRollbackSvcTemp(t *testing.T) {
+	ps, process, kit := newTestProcServer(t)
+
+	err := ps.changeSvcTempWithRevision(kit, 1, []int64{10}, func() error {
+		process.svcTemp.Name = "svc-new"
+		process.svcTemp.ServiceCategoryID = 3
+		process.attrs[1] = "aa"
+		process.attrs[2] = "b"
+		delete(process.procTemps, 100)
+		process.nextID++
+		process.procTemps[process.nextID] = newTestProcTemp(process.nextID, "redis")
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, process.revisions, 2)
+
+	revision, err := ps.rollbackSvcTemp(kit, &metadata.RollbackSvcTempOption{BizID: 1, ServiceTemplateID: 10,
+		Version: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), revision.Version)
+	require.Equal(t, metadata.SvcTempRevisionRollback, revision.Action)
+	require.Len(t, process.revisions, 3)
+
+	require.Equal(t, "svc", process.svcTemp.Name)
+	require.Equal(t, int64(2), process.svcTemp.ServiceCategoryID)
+	require.Equal(t, map[int64]interface{}{1: "a"}, process.attrs)
+
+	// the removed process template is recreated with a new id
+	require.Len(t, process.procTemps, 1)
+	for id, procTemp := range process.procTemps {
+		require.Equal(t, int64(102), id)
+		require.Equal(t, "nginx", procTemp.ProcessName)
+		require.Equal(t, "nginx", *procTemp.Property.FuncName.Value)
+	}
+
+	// the rolled back definition equals to the target revision except the process template ids
+	diff := metadata.DiffSvcTempRevision(&process.revisions[0], revision)
+	require.Nil(t, diff.Name)
+	require.Nil(t, diff.ServiceCategoryID)
+	require.Empty(t, diff.Attributes.Added)
+	require.Empty(t, diff.Attributes.Changed)
+	require.Empty(t, diff.Attributes.Removed)
+
+	// rollback to the same definition again records no new revision
+	revision, err = ps.rollbackSvcTemp(kit, &metadata.RollbackSvcTempOption{BizID: 1, ServiceTemplateID: 10,
+		Version: 3})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), revision.Version)
+	require.Len(t, process.revisions, 3)
+
+	_, err = ps.rollbackSvcTemp(kit, &metadata.RollbackSvcTempOption{BizID: 1, ServiceTemplateID: 10, Version: 9})
+	require.Error(t, err)
+	require.Len(t, process.revisions, 3)
+}
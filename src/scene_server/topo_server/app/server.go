@@ -31,6 +31,7 @@ import (
 	"configcenter/src/common/types"
 	"configcenter/src/scene_server/topo_server/app/options"
 	"configcenter/src/scene_server/topo_server/logics"
+	"configcenter/src/scene_server/topo_server/logics/settemplate"
 	"configcenter/src/scene_server/topo_server/service"
 	"configcenter/src/storage/driver/redis"
 	"configcenter/src/thirdparty/elasticsearch"
@@ -134,6 +135,10 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	if err != nil {
 		return err
 	}
+
+	// run set template sync policies in background
+	go settemplate.NewSyncPolicyScheduler(engine.CoreAPI, engine.ServiceManageInterface, engine.CCErr).Run()
+
 	select {
 	case <-ctx.Done():
 	}
@@ -254,6 +254,7 @@ func (k *kube) isExistKubeResourceUnderCluster(kit *rest.Kit, option *types.Dele
 	workLoads := types.GetWorkLoadTables()
 	tables := []string{types.BKTableNameBaseNamespace, types.BKTableNameBaseNode, types.BKTableNameBasePod}
 	tables = append(tables, workLoads...)
+	tables = append(tables, types.GetNsResourceTables()...)
 
 	filter := []map[string]interface{}{{
 		types.BKClusterIDFiled: map[string]interface{}{common.BKDBIN: option.IDs},
@@ -0,0 +1,431 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package settemplate
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+const (
+	// syncPolicyCheckInterval 检查集群模板同步策略是否需要执行的周期
+	syncPolicyCheckInterval = time.Minute
+	// syncPolicyMaxRunning 同时执行的同步策略的最大数量
+	syncPolicyMaxRunning = 5
+	// syncTaskPollInterval 查询集群同步任务状态的周期
+	syncTaskPollInterval = 3 * time.Second
+	// syncTaskTimeout 等待单个集群同步任务完成的最长时间，超时视为同步失败
+	syncTaskTimeout = 30 * time.Minute
+)
+
+// SyncPolicyScheduler executes the auto and scheduled set template sync policies in background, the sync tasks are
+// created by the system user, so that they can be found by the set template sync history with its creator.
+type SyncPolicyScheduler struct {
+	st         *setTemplate
+	svcManager discovery.ServiceManageInterface
+	errorIf    errors.CCErrorIf
+
+	lock    sync.Mutex
+	running map[string]struct{}
+}
+
+// NewSyncPolicyScheduler new set template sync policy scheduler
+func NewSyncPolicyScheduler(client apimachinery.ClientSetInterface, svcManager discovery.ServiceManageInterface,
+	errorIf errors.CCErrorIf) *SyncPolicyScheduler {
+
+	return &SyncPolicyScheduler{
+		st:         &setTemplate{client: client},
+		svcManager: svcManager,
+		errorIf:    errorIf,
+		running:    make(map[string]struct{}),
+	}
+}
+
+// Run check and execute the set template sync policies periodically, only the master will execute the policies
+func (s *SyncPolicyScheduler) Run() {
+	for {
+		time.Sleep(syncPolicyCheckInterval)
+
+		rid := util.GenerateRID()
+		if !s.svcManager.IsMaster() {
+			blog.V(4).Infof("skip run set template sync policies, reason: not master, rid: %s", rid)
+			continue
+		}
+
+		s.schedule(rid)
+	}
+}
+
+func (s *SyncPolicyScheduler) schedule(rid string) {
+	kit := rest.NewKitFromHeader(headerutil.GenCommonHeader(common.CCSystemOperatorUserName,
+		common.BKDefaultOwnerID, rid), s.errorIf)
+
+	now := time.Now()
+	option := &metadata.ListSetTplSyncPolicyOption{
+		Modes: []metadata.SetTplSyncMode{metadata.SetTplSyncModeAuto, metadata.SetTplSyncModeScheduled},
+		Page:  metadata.BasePage{Limit: common.BKMaxPageSize},
+	}
+
+	for {
+		policies, err := s.st.client.CoreService().SetTemplate().ListSetTplSyncPolicy(kit.Ctx, kit.Header, option)
+		if err != nil {
+			blog.Errorf("list set template sync policies failed, option: %+v, err: %v, rid: %s", option, err, rid)
+			return
+		}
+
+		for idx := range policies.Info {
+			policy := policies.Info[idx]
+			if !s.shouldRun(kit, &policy, now) {
+				continue
+			}
+
+			if !s.tryAcquire(&policy) {
+				continue
+			}
+
+			go func() {
+				defer s.release(&policy)
+				s.runPolicy(&policy)
+			}()
+		}
+
+		if len(policies.Info) < option.Page.Limit {
+			return
+		}
+		option.Page.Start += option.Page.Limit
+	}
+}
+
+// shouldRun check if the policy need to be executed now, the sets that need to be synchronized are checked later.
+// a halted or failed run blocks the policy until the set template or the policy is changed, or the next maintenance
+// window, so that the failed sets are not synchronized again and again.
+func (s *SyncPolicyScheduler) shouldRun(kit *rest.Kit, policy *metadata.SetTplSyncPolicy, now time.Time) bool {
+	blocked := false
+	if policy.LastRun != nil {
+		switch policy.LastRun.Status {
+		case metadata.SetTplSyncRunHalted, metadata.SetTplSyncRunFailed:
+			blocked = !policy.LastTime.After(policy.LastRun.StartTime)
+		}
+	}
+
+	switch policy.Mode {
+	case metadata.SetTplSyncModeScheduled:
+		windowStart, active := policy.ActiveWindowStart(now)
+		if !active {
+			return false
+		}
+		return !blocked || policy.LastRun.StartTime.Before(windowStart)
+
+	case metadata.SetTplSyncModeAuto:
+		if !blocked {
+			return true
+		}
+
+		setTemplate, err := s.st.client.CoreService().SetTemplate().GetSetTemplate(kit.Ctx, kit.Header,
+			policy.BizID, policy.SetTemplateID)
+		if err != nil {
+			blog.Errorf("get set template %d failed, err: %v, rid: %s", policy.SetTemplateID, err, kit.Rid)
+			return false
+		}
+		return setTemplate.LastTime.After(policy.LastRun.StartTime)
+
+	default:
+		return false
+	}
+}
+
+func syncPolicyKey(policy *metadata.SetTplSyncPolicy) string {
+	return fmt.Sprintf("%d:%d", policy.BizID, policy.SetTemplateID)
+}
+
+func (s *SyncPolicyScheduler) tryAcquire(policy *metadata.SetTplSyncPolicy) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := syncPolicyKey(policy)
+	if _, exists := s.running[key]; exists {
+		return false
+	}
+
+	if len(s.running) >= syncPolicyMaxRunning {
+		return false
+	}
+
+	s.running[key] = struct{}{}
+	return true
+}
+
+func (s *SyncPolicyScheduler) release(policy *metadata.SetTplSyncPolicy) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.running, syncPolicyKey(policy))
+}
+
+// runPolicy synchronize the sets that need to be synchronized, the canary sets are synchronized first, and the rest
+// of the sets are synchronized only when all the canary sets are synchronized successfully.
+func (s *SyncPolicyScheduler) runPolicy(policy *metadata.SetTplSyncPolicy) {
+	header := headerutil.BuildHeader(common.CCSystemOperatorUserName, policy.SupplierAccount)
+	kit := rest.NewKitFromHeader(header, s.errorIf)
+
+	setIDs, err := s.st.listSetsNeedSync(kit, policy.BizID, policy.SetTemplateID)
+	if err != nil {
+		blog.Errorf("list sets need sync failed, policy: %+v, err: %v, rid: %s", policy, err, kit.Rid)
+		return
+	}
+
+	if len(setIDs) == 0 {
+		return
+	}
+
+	blog.Infof("start to run set template sync policy, biz: %d, set template: %d, sets: %v, rid: %s", policy.BizID,
+		policy.SetTemplateID, setIDs, kit.Rid)
+
+	canaryCount := policy.CanaryCount
+	if canaryCount > len(setIDs) {
+		canaryCount = len(setIDs)
+	}
+
+	run := &metadata.SetTplSyncRun{
+		Status:       metadata.SetTplSyncRunRunning,
+		StartTime:    time.Now(),
+		CanarySetIDs: setIDs[:canaryCount],
+		TaskIDs:      make([]string, 0),
+	}
+	s.updateRun(kit, policy, run)
+
+	serviceTemplates, err := s.st.client.CoreService().SetTemplate().ListSetTplRelatedSvcTpl(kit.Ctx, kit.Header,
+		policy.BizID, policy.SetTemplateID)
+	if err != nil {
+		blog.Errorf("list service templates failed, policy: %+v, err: %v, rid: %s", policy, err, kit.Rid)
+		s.finishRun(kit, policy, run, metadata.SetTplSyncRunFailed, err.Error())
+		return
+	}
+
+	if canaryCount > 0 {
+		s.syncSets(kit, policy, serviceTemplates, setIDs[:canaryCount], run)
+		if run.FailureCount > 0 {
+			run.SkipCount = len(setIDs) - canaryCount
+			msg := fmt.Sprintf("%d of %d canary sets failed to sync", run.FailureCount, canaryCount)
+			s.finishRun(kit, policy, run, metadata.SetTplSyncRunHalted, msg)
+			return
+		}
+	}
+
+	s.syncSets(kit, policy, serviceTemplates, setIDs[canaryCount:], run)
+	if run.FailureCount > 0 {
+		msg := fmt.Sprintf("%d of %d sets failed to sync", run.FailureCount, len(setIDs))
+		s.finishRun(kit, policy, run, metadata.SetTplSyncRunFailed, msg)
+		return
+	}
+	s.finishRun(kit, policy, run, metadata.SetTplSyncRunFinished, "")
+}
+
+// syncSets synchronize the sets with at most max concurrency sets at the same time, and record the results in run
+func (s *SyncPolicyScheduler) syncSets(kit *rest.Kit, policy *metadata.SetTplSyncPolicy,
+	serviceTemplates []metadata.ServiceTemplate, setIDs []int64, run *metadata.SetTplSyncRun) {
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+	)
+
+	pipeline := make(chan struct{}, policy.GetMaxConcurrency())
+	for _, setID := range setIDs {
+		pipeline <- struct{}{}
+		wg.Add(1)
+
+		go func(setID int64) {
+			defer func() {
+				wg.Done()
+				<-pipeline
+			}()
+
+			taskID, success := s.syncSet(kit, policy, serviceTemplates, setID)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if taskID != "" {
+				run.TaskIDs = append(run.TaskIDs, taskID)
+			}
+			if success {
+				run.SuccessCount++
+			} else {
+				run.FailureCount++
+			}
+		}(setID)
+	}
+	wg.Wait()
+
+	s.updateRun(kit, policy, run)
+}
+
+// syncSet dispatch the sync task of the set and wait for it to finish, returns the task id and if it is successful
+func (s *SyncPolicyScheduler) syncSet(kit *rest.Kit, policy *metadata.SetTplSyncPolicy,
+	serviceTemplates []metadata.ServiceTemplate, setID int64) (string, bool) {
+
+	option := metadata.DiffSetTplWithInstOption{SetID: setID}
+	setDiff, err := s.st.DiffSetTplWithInst(kit, policy.BizID, policy.SetTemplateID, option, serviceTemplates)
+	if err != nil {
+		blog.Errorf("diff set template with instance failed, bizID: %d, set template ID: %d, setID: %d, err: %v, "+
+			"rid: %s", policy.BizID, policy.SetTemplateID, setID, err, kit.Rid)
+		return "", false
+	}
+
+	tasks := make([]metadata.SyncModuleTask, 0)
+	for _, moduleDiff := range setDiff.ModuleDiffs {
+		tasks = append(tasks, metadata.SyncModuleTask{
+			Set:         setDiff.SetDetail,
+			ModuleDiff:  moduleDiff,
+			SetTopoPath: setDiff.TopoPath,
+		})
+	}
+
+	taskDetail, err := s.st.DispatchTask4ModuleSync(kit, common.SyncSetTaskFlag, setID, tasks...)
+	if err != nil {
+		return "", false
+	}
+
+	deadline := time.Now().Add(syncTaskTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(syncTaskPollInterval)
+
+		detail, err := s.st.client.TaskServer().Task().TaskDetail(kit.Ctx, kit.Header, taskDetail.TaskID)
+		if err != nil {
+			blog.Errorf("get sync task %s detail failed, err: %v, rid: %s", taskDetail.TaskID, err, kit.Rid)
+			continue
+		}
+
+		if detail.Info.Status.IsFinished() {
+			return taskDetail.TaskID, detail.Info.Status.IsSuccessful()
+		}
+	}
+
+	blog.Errorf("wait for sync task %s of set %d timeout, rid: %s", taskDetail.TaskID, setID, kit.Rid)
+	return taskDetail.TaskID, false
+}
+
+func (s *SyncPolicyScheduler) finishRun(kit *rest.Kit, policy *metadata.SetTplSyncPolicy,
+	run *metadata.SetTplSyncRun, status metadata.SetTplSyncRunStatus, msg string) {
+
+	now := time.Now()
+	run.Status = status
+	run.EndTime = &now
+	run.Message = msg
+	s.updateRun(kit, policy, run)
+
+	blog.Infof("set template sync policy run finished, biz: %d, set template: %d, run: %+v, rid: %s", policy.BizID,
+		policy.SetTemplateID, *run, kit.Rid)
+}
+
+func (s *SyncPolicyScheduler) updateRun(kit *rest.Kit, policy *metadata.SetTplSyncPolicy,
+	run *metadata.SetTplSyncRun) {
+
+	option := &metadata.UpdateSetTplSyncRunOption{
+		BizID:         policy.BizID,
+		SetTemplateID: policy.SetTemplateID,
+		LastRun:       run,
+	}
+	if err := s.st.client.CoreService().SetTemplate().UpdateSetTplSyncRun(kit.Ctx, kit.Header, option); err != nil {
+		blog.Errorf("update set template sync run failed, option: %+v, err: %v, rid: %s", option, err, kit.Rid)
+	}
+}
+
+// listSetsNeedSync returns the sets of the set template that are not up-to-date and has no unfinished sync task
+func (st *setTemplate) listSetsNeedSync(kit *rest.Kit, bizID, setTemplateID int64) ([]int64, errors.CCErrorCoder) {
+	attrIDs, setTemplateAttrValueMap, err := st.getSetTemplateAttrIdAndPropertyValue(kit, bizID, setTemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	propertyIDs, attrIdPropertyIdMap, err := st.getSetAttrIDAndPropertyID(kit, attrIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	option := &metadata.QueryCondition{
+		Fields: append([]string{common.BKSetIDField}, propertyIDs...),
+		Condition: map[string]interface{}{
+			common.BKSetTemplateIDField: setTemplateID,
+			common.BKAppIDField:         bizID,
+		},
+		Page:           metadata.BasePage{Limit: common.BKNoLimit, Sort: common.BKSetIDField},
+		DisableCounter: true,
+	}
+	sets, rawErr := st.client.CoreService().Instance().ReadInstance(kit.Ctx, kit.Header, common.BKInnerObjIDSet,
+		option)
+	if rawErr != nil {
+		blog.Errorf("get set failed, option: %+v, err: %v, rid: %s", option, rawErr, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrTopoSetSelectFailed, rawErr.Error())
+	}
+
+	if len(sets.Info) == 0 {
+		return make([]int64, 0), nil
+	}
+
+	setIDs := make([]int64, 0)
+	setMap := make(map[int64]mapstr.MapStr)
+	for _, set := range sets.Info {
+		setID, rawErr := util.GetInt64ByInterface(set[common.BKSetIDField])
+		if rawErr != nil {
+			return nil, kit.CCError.CCErrorf(common.CCErrTopoSetSelectFailed)
+		}
+		setIDs = append(setIDs, setID)
+		setMap[setID] = set
+	}
+
+	needSync, err := st.isSyncRequired(kit, bizID, setTemplateID, setIDs, setMap, false, attrIdPropertyIdMap,
+		setTemplateAttrValueMap)
+	if err != nil {
+		blog.Errorf("check set whether need sync failed, setIDs: %+v, err: %v, rid: %s", setIDs, err, kit.Rid)
+		return nil, err
+	}
+
+	taskCond := metadata.ListAPITaskDetail{
+		InstID: setIDs,
+		Fields: []string{common.BKInstIDField, common.BKStatusField},
+	}
+	latestTasks, err := st.GetLatestSyncTaskDetail(kit, taskCond)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]int64, 0)
+	for _, setID := range setIDs {
+		if !needSync[setID] {
+			continue
+		}
+
+		// skip the set that is being synchronized, its sync task can not be created again
+		if task, exists := latestTasks[setID]; exists && !task.Status.IsFinished() {
+			continue
+		}
+		result = append(result, setID)
+	}
+	return result, nil
+}
@@ -0,0 +1,241 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package settemplate
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/coreservice"
+	coresettemplate "configcenter/src/apimachinery/coreservice/settemplate"
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClientSet struct {
+	apimachinery.ClientSetInterface
+	coreService *fakeCoreService
+}
+
+// CoreService returns the fake core service client
+func (f *fakeClientSet) CoreService() coreservice.CoreServiceClientInterface {
+	return f.coreService
+}
+
+type fakeCoreService struct {
+	coreservice.CoreServiceClientInterface
+	setTemplate *fakeSetTemplateClient
+}
+
+// SetTemplate returns the fake set template client
+func (f *fakeCoreService) SetTemplate() coresettemplate.SetTemplateInterface {
+	return f.setTemplate
+}
+
+type fakeSetTemplateClient struct {
+	coresettemplate.SetTemplateInterface
+	setTemplate metadata.SetTemplate
+	err         errors.CCErrorCoder
+}
+
+// GetSetTemplate returns the fake set template
+func (f *fakeSetTemplateClient) GetSetTemplate(_ context.Context, _ http.Header, _ int64, _ int64) (
+	metadata.SetTemplate, errors.CCErrorCoder) {
+	return f.setTemplate, f.err
+}
+
+func newTestScheduler(tpl metadata.SetTemplate, err errors.CCErrorCoder) *SyncPolicyScheduler {
+	client := &fakeClientSet{coreService: &fakeCoreService{
+		setTemplate: &fakeSetTemplateClient{setTemplate: tpl, err: err},
+	}}
+	return &SyncPolicyScheduler{
+		st:      &setTemplate{client: client},
+		running: make(map[string]struct{}),
+	}
+}
+
+func TestSyncPolicyShouldRun(t *testing.T) {
+	// 2024-06-05 is a wednesday
+	now := time.Date(2024, 6, 5, 23, 30, 0, 0, time.Local)
+	policyTime := now.AddDate(0, 0, -7)
+	nightWindow := metadata.SetTplSyncWindow{StartTime: "22:00", EndTime: "02:00"}
+	dayWindow := metadata.SetTplSyncWindow{StartTime: "09:00", EndTime: "18:00"}
+	mondayWindow := metadata.SetTplSyncWindow{Weekdays: []int{int(time.Monday)}, StartTime: "22:00",
+		EndTime: "02:00"}
+	thursdayWindow := metadata.SetTplSyncWindow{Weekdays: []int{int(time.Thursday)}, StartTime: "22:00",
+		EndTime: "02:00"}
+
+	run := func(status metadata.SetTplSyncRunStatus, startTime time.Time) *metadata.SetTplSyncRun {
+		return &metadata.SetTplSyncRun{Status: status, StartTime: startTime}
+	}
+
+	tests := []struct {
+		name         string
+		now          time.Time
+		policy       metadata.SetTplSyncPolicy
+		tplLastTime  time.Time
+		tplErr       errors.CCErrorCoder
+		expectShould bool
+	}{
+		{
+			name:   "manual policy never runs",
+			now:    now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeManual, LastTime: policyTime},
+		},
+		{
+			name:         "auto policy runs without last run",
+			now:          now,
+			policy:       metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeAuto, LastTime: policyTime},
+			expectShould: true,
+		},
+		{
+			name: "auto policy runs after finished run",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeAuto, LastTime: policyTime,
+				LastRun: run(metadata.SetTplSyncRunFinished, now.Add(-time.Minute))},
+			expectShould: true,
+		},
+		{
+			name: "auto policy is blocked by halted run",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeAuto, LastTime: policyTime,
+				LastRun: run(metadata.SetTplSyncRunHalted, now.Add(-time.Hour))},
+			tplLastTime: now.Add(-2 * time.Hour),
+		},
+		{
+			name: "auto policy is unblocked by set template change",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeAuto, LastTime: policyTime,
+				LastRun: run(metadata.SetTplSyncRunFailed, now.Add(-time.Hour))},
+			tplLastTime:  now.Add(-time.Minute),
+			expectShould: true,
+		},
+		{
+			name: "auto policy is unblocked by policy change",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeAuto, LastTime: now.Add(-time.Minute),
+				LastRun: run(metadata.SetTplSyncRunFailed, now.Add(-time.Hour))},
+			expectShould: true,
+		},
+		{
+			name: "blocked auto policy does not run if set template is not found",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeAuto, LastTime: policyTime,
+				LastRun: run(metadata.SetTplSyncRunFailed, now.Add(-time.Hour))},
+			tplErr: errors.New(common.CCErrCommNotFound, "not found"),
+		},
+		{
+			name: "scheduled policy runs in window",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeScheduled, LastTime: policyTime,
+				Windows: []metadata.SetTplSyncWindow{dayWindow, nightWindow}},
+			expectShould: true,
+		},
+		{
+			name: "scheduled policy runs in window crossing midnight",
+			now:  now.Add(2 * time.Hour),
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeScheduled, LastTime: policyTime,
+				Windows: []metadata.SetTplSyncWindow{thursdayWindow, mondayWindow, nightWindow}},
+			expectShould: true,
+		},
+		{
+			name: "scheduled policy does not run out of window",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeScheduled, LastTime: policyTime,
+				Windows: []metadata.SetTplSyncWindow{dayWindow}},
+		},
+		{
+			name: "scheduled policy does not run in window end",
+			now:  now.Add(150 * time.Minute),
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeScheduled, LastTime: policyTime,
+				Windows: []metadata.SetTplSyncWindow{nightWindow}},
+		},
+		{
+			name: "scheduled policy does not run on other weekdays",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeScheduled, LastTime: policyTime,
+				Windows: []metadata.SetTplSyncWindow{mondayWindow, thursdayWindow}},
+		},
+		{
+			name: "scheduled policy is blocked by failed run in the same window",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeScheduled, LastTime: policyTime,
+				Windows: []metadata.SetTplSyncWindow{nightWindow},
+				LastRun: run(metadata.SetTplSyncRunFailed, now.Add(-time.Hour))},
+		},
+		{
+			name: "scheduled policy is unblocked in the next window",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeScheduled, LastTime: policyTime,
+				Windows: []metadata.SetTplSyncWindow{nightWindow},
+				LastRun: run(metadata.SetTplSyncRunHalted, now.AddDate(0, 0, -1))},
+			expectShould: true,
+		},
+		{
+			name: "overlapped windows are regarded as one window",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeScheduled, LastTime: policyTime,
+				Windows: []metadata.SetTplSyncWindow{nightWindow, {StartTime: "23:00", EndTime: "23:59"}},
+				LastRun: run(metadata.SetTplSyncRunFailed, now.Add(-time.Hour))},
+		},
+		{
+			name: "scheduled policy runs again in the same window after finished run",
+			now:  now,
+			policy: metadata.SetTplSyncPolicy{Mode: metadata.SetTplSyncModeScheduled, LastTime: policyTime,
+				Windows: []metadata.SetTplSyncWindow{nightWindow},
+				LastRun: run(metadata.SetTplSyncRunFinished, now.Add(-time.Hour))},
+			expectShould: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestScheduler(metadata.SetTemplate{LastTime: tt.tplLastTime}, tt.tplErr)
+			kit := &rest.Kit{Ctx: context.Background(), Header: make(http.Header), Rid: "rid"}
+			require.Equal(t, tt.expectShould, s.shouldRun(kit, &tt.policy, tt.now))
+		})
+	}
+}
+
+func TestSyncPolicyTryAcquire(t *testing.T) {
+	s := newTestScheduler(metadata.SetTemplate{}, nil)
+
+	policies := make([]metadata.SetTplSyncPolicy, syncPolicyMaxRunning+1)
+	for idx := range policies {
+		policies[idx] = metadata.SetTplSyncPolicy{BizID: 1, SetTemplateID: int64(idx + 1)}
+	}
+
+	for idx := 0; idx < syncPolicyMaxRunning; idx++ {
+		require.True(t, s.tryAcquire(&policies[idx]))
+	}
+
+	// the running policy can not be acquired again
+	require.False(t, s.tryAcquire(&policies[0]))
+	// the policies exceeding the max running count are not acquired
+	require.False(t, s.tryAcquire(&policies[syncPolicyMaxRunning]))
+
+	s.release(&policies[0])
+	require.True(t, s.tryAcquire(&policies[syncPolicyMaxRunning]))
+	require.False(t, s.tryAcquire(&policies[0]))
+}
@@ -0,0 +1,477 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// RevertAudit revert the operations recorded by audit logs. the inverse operations are computed from the audit logs
+// and validated against the current state of the resources, then applied in the order of newest to oldest through
+// the normal instance operations and the host update api, which generates their own audit logs
+func (s *Service) RevertAudit(ctx *rest.Contexts) {
+	opt := new(metadata.RevertAuditOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	auditLogs, err := s.getRevertAuditLogs(ctx.Kit, opt.IDs)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	planner := &auditRevertPlanner{s: s, kit: ctx.Kit, states: make(map[string]*revertInstState)}
+	result := &metadata.RevertAuditResult{Items: make([]metadata.RevertAuditItem, 0)}
+	allReady := true
+	for idx := range auditLogs {
+		item, err := planner.plan(&auditLogs[idx])
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+
+		result.Items = append(result.Items, *item)
+		if item.Status != metadata.RevertAuditReady {
+			allReady = false
+		}
+	}
+
+	if opt.DryRun || !allReady {
+		ctx.RespEntity(result)
+		return
+	}
+
+	if err := s.authorizeAuditRevert(ctx.Kit, result.Items); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		return s.applyAuditRevert(ctx.Kit, result.Items)
+	})
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	result.Reverted = true
+	ctx.RespEntity(result)
+}
+
+// getRevertAuditLogs get the audit logs to revert in the order of newest to oldest
+func (s *Service) getRevertAuditLogs(kit *rest.Kit, ids []int64) ([]metadata.AuditLog, error) {
+	ids = util.IntArrayUnique(ids)
+	query := metadata.QueryCondition{
+		Condition: mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: ids}},
+		Page:      metadata.BasePage{Limit: len(ids)},
+	}
+
+	rsp, err := s.Engine.CoreAPI.CoreService().Audit().SearchAuditLog(kit.Ctx, kit.Header, query)
+	if err != nil {
+		blog.Errorf("search audit logs %v to revert failed, err: %v, rid: %s", ids, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(rsp.Info) != len(ids) {
+		blog.Errorf("audit logs %v to revert are not all found, found count: %d, rid: %s", ids, len(rsp.Info),
+			kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID)
+	}
+
+	sort.Slice(rsp.Info, func(i, j int) bool {
+		return rsp.Info[i].ID > rsp.Info[j].ID
+	})
+	return rsp.Info, nil
+}
+
+// revertIgnoredFields are the system fields that are neither reverted nor compared when reverting audit logs
+var revertIgnoredFields = map[string]struct{}{
+	"_id":                  {},
+	common.BKFieldID:       {},
+	common.BKOwnerIDField:  {},
+	common.BKObjIDField:    {},
+	common.BKAppIDField:    {},
+	common.BKParentIDField: {},
+	common.CreateTimeField: {},
+	common.LastTimeField:   {},
+	common.BKCreatedAt:     {},
+	common.BKCreatedBy:     {},
+	common.BKUpdatedAt:     {},
+	common.BKUpdatedBy:     {},
+}
+
+func isRevertIgnoredField(objID, field string) bool {
+	if field == metadata.GetInstIDFieldByObjID(objID) {
+		return true
+	}
+	_, exists := revertIgnoredFields[field]
+	return exists
+}
+
+// isAuditValueEqual compares the audit value with the current value by their json form, since both of them are
+// decoded from json responses whose number types may differ
+func isAuditValueEqual(a, b interface{}) bool {
+	aJs, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bJs, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aJs, bJs)
+}
+
+// revertInstState is the state of an instance used to validate the revert operations
+type revertInstState struct {
+	exists bool
+	data   mapstr.MapStr
+}
+
+// auditRevertPlanner computes the inverse operations of audit logs and validates them against the instance states
+type auditRevertPlanner struct {
+	s   *Service
+	kit *rest.Kit
+	// states is the current states of the instances, the key is object id and instance id. it is updated with the
+	// planned revert operations so that multiple audit logs of the same instance can be reverted in sequence
+	states map[string]*revertInstState
+}
+
+func (p *auditRevertPlanner) plan(log *metadata.AuditLog) (*metadata.RevertAuditItem, error) {
+	item := &metadata.RevertAuditItem{
+		AuditID:      log.ID,
+		ResourceType: log.ResourceType,
+		Status:       metadata.RevertAuditUnsupported,
+	}
+
+	detail, ok := log.OperationDetail.(*metadata.InstanceOpDetail)
+	if !ok || detail.Details == nil ||
+		(log.ResourceType != metadata.HostRes && log.ResourceType != metadata.ModelInstanceRes) {
+		item.Message = "only host and model instance operations can be reverted"
+		return item, nil
+	}
+
+	instID, err := util.GetInt64ByInterface(log.ResourceID)
+	if err != nil {
+		item.Message = fmt.Sprintf("invalid resource id %v", log.ResourceID)
+		return item, nil
+	}
+	item.ResourceID = instID
+	item.ObjID = detail.ModelID
+
+	state, err := p.getState(item.ObjID, instID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch log.Action {
+	case metadata.AuditUpdate:
+		p.planUpdate(item, state, detail.Details)
+	case metadata.AuditDelete:
+		p.planDelete(item, state, detail.Details)
+	case metadata.AuditCreate:
+		p.planCreate(item, state, detail.Details)
+	default:
+		item.Message = fmt.Sprintf("%s operation can not be reverted", log.Action)
+	}
+
+	return item, nil
+}
+
+// planUpdate reverts update operation by updating the fields back to their previous values, the fields must not be
+// changed since the operation. only the fields whose previous values are recorded are reverted, the others did not
+// exist before the operation, and their previous values are unknown.
+func (p *auditRevertPlanner) planUpdate(item *metadata.RevertAuditItem, state *revertInstState,
+	content *metadata.BasicContent) {
+
+	item.Action = metadata.AuditUpdate
+	if !state.exists {
+		item.Status = metadata.RevertAuditConflict
+		item.Message = "instance has been deleted since the operation"
+		return
+	}
+
+	data := make(mapstr.MapStr)
+	for field, value := range content.UpdateFields {
+		if isRevertIgnoredField(item.ObjID, field) {
+			continue
+		}
+
+		if _, exists := content.PreData[field]; !exists {
+			continue
+		}
+
+		// host cloud area can not be updated by the host update api, it is changed by the cloud area apis
+		if item.ResourceType == metadata.HostRes && field == common.BKCloudIDField {
+			item.Status = metadata.RevertAuditUnsupported
+			item.Message = fmt.Sprintf("field %s of host can not be reverted", field)
+			return
+		}
+
+		if !isAuditValueEqual(state.data[field], value) {
+			item.Status = metadata.RevertAuditConflict
+			item.Message = fmt.Sprintf("field %s has been changed since the operation", field)
+			return
+		}
+		data[field] = content.PreData[field]
+	}
+
+	if len(data) == 0 {
+		item.Message = "no field to revert"
+		return
+	}
+
+	item.Data = data
+	item.Status = metadata.RevertAuditReady
+	for field, value := range data {
+		state.data[field] = value
+	}
+}
+
+// planDelete reverts delete operation by creating the instance with its previous data, the instance is recreated
+// with a new id. deleted host is not supported since it must be added to a business topology again
+func (p *auditRevertPlanner) planDelete(item *metadata.RevertAuditItem, state *revertInstState,
+	content *metadata.BasicContent) {
+
+	item.Action = metadata.AuditCreate
+	if item.ResourceType == metadata.HostRes {
+		item.Message = "deleted host can not be recreated, please add it again"
+		return
+	}
+
+	if state.exists {
+		item.Status = metadata.RevertAuditConflict
+		item.Message = "instance still exists"
+		return
+	}
+
+	data := make(mapstr.MapStr)
+	for field, value := range content.PreData {
+		if !isRevertIgnoredField(item.ObjID, field) {
+			data[field] = value
+		}
+	}
+
+	item.Data = data
+	item.Status = metadata.RevertAuditReady
+}
+
+// planCreate reverts create operation by deleting the instance, the instance must not be changed since the operation.
+// created host is not supported since its deletion needs to be done in the business topology
+func (p *auditRevertPlanner) planCreate(item *metadata.RevertAuditItem, state *revertInstState,
+	content *metadata.BasicContent) {
+
+	item.Action = metadata.AuditDelete
+	if item.ResourceType == metadata.HostRes {
+		item.Message = "created host can not be deleted by revert, please delete it in the business topology"
+		return
+	}
+
+	if !state.exists {
+		item.Status = metadata.RevertAuditConflict
+		item.Message = "instance has been deleted since the operation"
+		return
+	}
+
+	for field, value := range content.CurData {
+		if isRevertIgnoredField(item.ObjID, field) {
+			continue
+		}
+
+		if !isAuditValueEqual(state.data[field], value) {
+			item.Status = metadata.RevertAuditConflict
+			item.Message = fmt.Sprintf("field %s has been changed since the operation", field)
+			return
+		}
+	}
+
+	item.Status = metadata.RevertAuditReady
+	state.exists = false
+}
+
+// getState get the state of the instance, the current instance data is fetched at the first time
+func (p *auditRevertPlanner) getState(objID string, instID int64) (*revertInstState, error) {
+	key := fmt.Sprintf("%s:%d", objID, instID)
+	if state, exists := p.states[key]; exists {
+		return state, nil
+	}
+
+	query := &metadata.QueryCondition{
+		Condition:      mapstr.MapStr{metadata.GetInstIDFieldByObjID(objID): instID},
+		Page:           metadata.BasePage{Limit: 1},
+		DisableCounter: true,
+	}
+	rsp, err := p.s.Logics.InstOperation().FindInst(p.kit, objID, query)
+	if err != nil {
+		blog.Errorf("get %s instance %d failed, err: %v, rid: %s", objID, instID, err, p.kit.Rid)
+		return nil, err
+	}
+
+	state := &revertInstState{data: make(mapstr.MapStr)}
+	if len(rsp.Info) > 0 {
+		state.exists = true
+		state.data = rsp.Info[0]
+	}
+	p.states[key] = state
+	return state, nil
+}
+
+// authorizeAuditRevert authorize the revert operations with the same permissions as the operations themselves
+func (s *Service) authorizeAuditRevert(kit *rest.Kit, items []metadata.RevertAuditItem) error {
+	createObjIDs := make([]string, 0)
+	hostIDs := make([]int64, 0)
+	actionInstIDs := map[meta.Action]map[string][]int64{meta.Update: {}, meta.Delete: {}}
+	for _, item := range items {
+		if item.ResourceType == metadata.HostRes {
+			// only update operation of host can be reverted, which is authorized as the host update api does
+			hostIDs = append(hostIDs, item.ResourceID)
+			continue
+		}
+
+		switch item.Action {
+		case metadata.AuditCreate:
+			createObjIDs = append(createObjIDs, item.ObjID)
+		case metadata.AuditUpdate:
+			actionInstIDs[meta.Update][item.ObjID] = append(actionInstIDs[meta.Update][item.ObjID], item.ResourceID)
+		case metadata.AuditDelete:
+			actionInstIDs[meta.Delete][item.ObjID] = append(actionInstIDs[meta.Delete][item.ObjID], item.ResourceID)
+		}
+	}
+
+	if len(createObjIDs) > 0 {
+		_, authorized, err := s.AuthManager.HasInstOpAuth(kit, util.StrArrayUnique(createObjIDs), meta.Create)
+		if err != nil {
+			blog.Errorf("authorize create instance of %v failed, err: %v, rid: %s", createObjIDs, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommAuthorizeFailed)
+		}
+
+		if !authorized {
+			return kit.CCError.CCError(common.CCErrCommAuthNotHavePermission)
+		}
+	}
+
+	if len(hostIDs) > 0 {
+		err := s.AuthManager.AuthorizeByHostsIDs(kit.Ctx, kit.Header, meta.Update, util.IntArrayUnique(hostIDs)...)
+		if err != nil {
+			blog.Errorf("authorize update hosts %v failed, err: %v, rid: %s", hostIDs, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommAuthNotHavePermission)
+		}
+	}
+
+	for action, objInstIDs := range actionInstIDs {
+		for objID, instIDs := range objInstIDs {
+			err := s.AuthManager.AuthorizeByInstanceID(kit.Ctx, kit.Header, action, objID, instIDs...)
+			if err != nil {
+				blog.Errorf("authorize %s %s instances %v failed, err: %v, rid: %s", action, objID, instIDs, err,
+					kit.Rid)
+				return kit.CCError.CCError(common.CCErrCommAuthNotHavePermission)
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyAuditRevert apply the revert operations, each of them generates its own audit log
+func (s *Service) applyAuditRevert(kit *rest.Kit, items []metadata.RevertAuditItem) error {
+	for idx := range items {
+		item := &items[idx]
+		idField := metadata.GetInstIDFieldByObjID(item.ObjID)
+
+		switch item.Action {
+		case metadata.AuditUpdate:
+			if item.ResourceType == metadata.HostRes {
+				if err := s.revertHostUpdate(kit, item); err != nil {
+					return err
+				}
+				break
+			}
+
+			cond := mapstr.MapStr{idField: item.ResourceID}
+			if err := s.Logics.InstOperation().UpdateInst(kit, cond, item.Data.Clone(), item.ObjID); err != nil {
+				blog.Errorf("revert audit %d, update %s instance %d failed, err: %v, rid: %s", item.AuditID,
+					item.ObjID, item.ResourceID, err, kit.Rid)
+				return err
+			}
+		case metadata.AuditCreate:
+			inst, err := s.Logics.InstOperation().CreateInst(kit, item.ObjID, item.Data.Clone())
+			if err != nil {
+				blog.Errorf("revert audit %d, create %s instance failed, err: %v, rid: %s", item.AuditID,
+					item.ObjID, err, kit.Rid)
+				return err
+			}
+
+			item.NewInstID, err = inst.Int64(idField)
+			if err != nil {
+				blog.Errorf("parse created %s instance id failed, inst: %+v, err: %v, rid: %s", item.ObjID, inst,
+					err, kit.Rid)
+				return err
+			}
+		case metadata.AuditDelete:
+			err := s.Logics.InstOperation().DeleteInstByInstID(kit, item.ObjID, []int64{item.ResourceID}, true)
+			if err != nil {
+				blog.Errorf("revert audit %d, delete %s instance %d failed, err: %v, rid: %s", item.AuditID,
+					item.ObjID, item.ResourceID, err, kit.Rid)
+				return err
+			}
+		}
+
+		item.Status = metadata.RevertAuditReverted
+	}
+
+	return nil
+}
+
+// revertHostUpdate revert the host update operation by the host update api, so that the host is validated, authorized
+// and audited in the same way as it is updated by users
+func (s *Service) revertHostUpdate(kit *rest.Kit, item *metadata.RevertAuditItem) error {
+	data := item.Data.Clone()
+	data[common.BKHostIDField] = strconv.FormatInt(item.ResourceID, 10)
+
+	rsp, err := s.Engine.CoreAPI.HostServer().UpdateHostBatch(kit.Ctx, kit.Header, data)
+	if err != nil {
+		blog.Errorf("revert audit %d, update host %d failed, err: %v, rid: %s", item.AuditID, item.ResourceID, err,
+			kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if ccErr := rsp.CCError(); ccErr != nil {
+		blog.Errorf("revert audit %d, update host %d failed, err: %v, rid: %s", item.AuditID, item.ResourceID, ccErr,
+			kit.Rid)
+		return ccErr
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newRevertAuditLog(id int64, resType metadata.ResourceType, action metadata.ActionType, objID string,
+	instID int64, content *metadata.BasicContent) *metadata.AuditLog {
+
+	return &metadata.AuditLog{
+		ID:           id,
+		ResourceType: resType,
+		Action:       action,
+		ResourceID:   instID,
+		OperationDetail: &metadata.InstanceOpDetail{
+			BasicOpDetail: metadata.BasicOpDetail{Details: content},
+			ModelID:       objID,
+		},
+	}
+}
+
+func newTestRevertPlanner(states map[string]*revertInstState) *auditRevertPlanner {
+	return &auditRevertPlanner{states: states}
+}
+
+func TestAuditRevertPlanUpdate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		resType metadata.ResourceType
+		objID   string
+		state   *revertInstState
+		content *metadata.BasicContent
+		status  metadata.RevertAuditStatus
+		data    mapstr.MapStr
+	}{
+		{
+			name:    "revert updated fields",
+			resType: metadata.ModelInstanceRes,
+			objID:   "switch",
+			state: &revertInstState{exists: true, data: mapstr.MapStr{common.BKInstNameField: "b", "port": 2,
+				common.LastTimeField: "now"}},
+			content: &metadata.BasicContent{
+				PreData: mapstr.MapStr{common.BKInstNameField: "a", "port": 1, common.LastTimeField: "before"},
+				UpdateFields: mapstr.MapStr{common.BKInstNameField: "b", "port": float64(2),
+					common.LastTimeField: "then"},
+			},
+			status: metadata.RevertAuditReady,
+			data:   mapstr.MapStr{common.BKInstNameField: "a", "port": 1},
+		},
+		{
+			name:    "fields not in previous data are not reverted",
+			resType: metadata.HostRes,
+			objID:   common.BKInnerObjIDHost,
+			state:   &revertInstState{exists: true, data: mapstr.MapStr{common.BKHostNameField: "b", "new_field": "x"}},
+			content: &metadata.BasicContent{
+				PreData:      mapstr.MapStr{common.BKHostNameField: "a"},
+				UpdateFields: mapstr.MapStr{common.BKHostNameField: "b", "new_field": "x"},
+			},
+			status: metadata.RevertAuditReady,
+			data:   mapstr.MapStr{common.BKHostNameField: "a"},
+		},
+		{
+			name:    "only fields not in previous data",
+			resType: metadata.ModelInstanceRes,
+			objID:   "switch",
+			state:   &revertInstState{exists: true, data: mapstr.MapStr{"new_field": "x"}},
+			content: &metadata.BasicContent{
+				PreData:      mapstr.MapStr{common.BKInstNameField: "a"},
+				UpdateFields: mapstr.MapStr{"new_field": "x"},
+			},
+			status: metadata.RevertAuditUnsupported,
+		},
+		{
+			name:    "field changed since the operation",
+			resType: metadata.ModelInstanceRes,
+			objID:   "switch",
+			state:   &revertInstState{exists: true, data: mapstr.MapStr{common.BKInstNameField: "c"}},
+			content: &metadata.BasicContent{
+				PreData:      mapstr.MapStr{common.BKInstNameField: "a"},
+				UpdateFields: mapstr.MapStr{common.BKInstNameField: "b"},
+			},
+			status: metadata.RevertAuditConflict,
+		},
+		{
+			name:    "instance deleted",
+			resType: metadata.ModelInstanceRes,
+			objID:   "switch",
+			state:   &revertInstState{data: mapstr.MapStr{}},
+			content: &metadata.BasicContent{
+				PreData:      mapstr.MapStr{common.BKInstNameField: "a"},
+				UpdateFields: mapstr.MapStr{common.BKInstNameField: "b"},
+			},
+			status: metadata.RevertAuditConflict,
+		},
+		{
+			name:    "host cloud area",
+			resType: metadata.HostRes,
+			objID:   common.BKInnerObjIDHost,
+			state:   &revertInstState{exists: true, data: mapstr.MapStr{common.BKCloudIDField: 2}},
+			content: &metadata.BasicContent{
+				PreData:      mapstr.MapStr{common.BKCloudIDField: 1},
+				UpdateFields: mapstr.MapStr{common.BKCloudIDField: 2},
+			},
+			status: metadata.RevertAuditUnsupported,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestRevertPlanner(map[string]*revertInstState{tc.objID + ":1": tc.state})
+			item, err := p.plan(newRevertAuditLog(10, tc.resType, metadata.AuditUpdate, tc.objID, 1, tc.content))
+			require.NoError(t, err)
+			require.Equal(t, metadata.AuditUpdate, item.Action)
+			require.Equal(t, tc.status, item.Status, item.Message)
+			require.Equal(t, tc.data, item.Data)
+			require.Equal(t, int64(1), item.ResourceID)
+			require.Equal(t, tc.objID, item.ObjID)
+		})
+	}
+}
+
+func TestAuditRevertPlanSequence(t *testing.T) {
+	state := &revertInstState{exists: true, data: mapstr.MapStr{common.BKInstNameField: "c"}}
+	p := newTestRevertPlanner(map[string]*revertInstState{"switch:1": state})
+
+	// audit logs are reverted from newest to oldest, the later one is validated against the planned state
+	logs := []*metadata.AuditLog{
+		newRevertAuditLog(2, metadata.ModelInstanceRes, metadata.AuditUpdate, "switch", 1, &metadata.BasicContent{
+			PreData:      mapstr.MapStr{common.BKInstNameField: "b"},
+			UpdateFields: mapstr.MapStr{common.BKInstNameField: "c"},
+		}),
+		newRevertAuditLog(1, metadata.ModelInstanceRes, metadata.AuditUpdate, "switch", 1, &metadata.BasicContent{
+			PreData:      mapstr.MapStr{common.BKInstNameField: "a"},
+			UpdateFields: mapstr.MapStr{common.BKInstNameField: "b"},
+		}),
+		newRevertAuditLog(0, metadata.ModelInstanceRes, metadata.AuditCreate, "switch", 1, &metadata.BasicContent{
+			CurData: mapstr.MapStr{common.BKInstIDField: 1, common.BKInstNameField: "a"},
+		}),
+	}
+
+	expects := []mapstr.MapStr{{common.BKInstNameField: "b"}, {common.BKInstNameField: "a"}, nil}
+	for idx, log := range logs {
+		item, err := p.plan(log)
+		require.NoError(t, err)
+		require.Equal(t, metadata.RevertAuditReady, item.Status, item.Message)
+		require.Equal(t, expects[idx], item.Data)
+	}
+	require.False(t, state.exists)
+
+	// the instance is planned to be deleted, so the earlier operations conflict
+	item, err := p.plan(logs[1])
+	require.NoError(t, err)
+	require.Equal(t, metadata.RevertAuditConflict, item.Status)
+}
+
+func TestAuditRevertPlanCreateAndDelete(t *testing.T) {
+	testCases := []struct {
+		name    string
+		resType metadata.ResourceType
+		objID   string
+		action  metadata.ActionType
+		state   *revertInstState
+		content *metadata.BasicContent
+		revert  metadata.ActionType
+		status  metadata.RevertAuditStatus
+		data    mapstr.MapStr
+	}{
+		{
+			name:    "revert delete by creating",
+			resType: metadata.ModelInstanceRes,
+			objID:   "switch",
+			action:  metadata.AuditDelete,
+			state:   &revertInstState{data: mapstr.MapStr{}},
+			content: &metadata.BasicContent{PreData: mapstr.MapStr{common.BKInstIDField: 1,
+				common.BKObjIDField: "switch", common.BKInstNameField: "a", common.CreateTimeField: "then"}},
+			revert: metadata.AuditCreate,
+			status: metadata.RevertAuditReady,
+			data:   mapstr.MapStr{common.BKInstNameField: "a"},
+		},
+		{
+			name:    "revert delete of existing instance",
+			resType: metadata.ModelInstanceRes,
+			objID:   "switch",
+			action:  metadata.AuditDelete,
+			state:   &revertInstState{exists: true, data: mapstr.MapStr{}},
+			content: &metadata.BasicContent{PreData: mapstr.MapStr{common.BKInstNameField: "a"}},
+			revert:  metadata.AuditCreate,
+			status:  metadata.RevertAuditConflict,
+		},
+		{
+			name:    "revert delete of host",
+			resType: metadata.HostRes,
+			objID:   common.BKInnerObjIDHost,
+			action:  metadata.AuditDelete,
+			state:   &revertInstState{data: mapstr.MapStr{}},
+			content: &metadata.BasicContent{PreData: mapstr.MapStr{common.BKHostNameField: "a"}},
+			revert:  metadata.AuditCreate,
+			status:  metadata.RevertAuditUnsupported,
+		},
+		{
+			name:    "revert create by deleting",
+			resType: metadata.ModelInstanceRes,
+			objID:   "switch",
+			action:  metadata.AuditCreate,
+			state:   &revertInstState{exists: true, data: mapstr.MapStr{common.BKInstNameField: "a"}},
+			content: &metadata.BasicContent{CurData: mapstr.MapStr{common.BKInstNameField: "a"}},
+			revert:  metadata.AuditDelete,
+			status:  metadata.RevertAuditReady,
+		},
+		{
+			name:    "revert create of changed instance",
+			resType: metadata.ModelInstanceRes,
+			objID:   "switch",
+			action:  metadata.AuditCreate,
+			state:   &revertInstState{exists: true, data: mapstr.MapStr{common.BKInstNameField: "b"}},
+			content: &metadata.BasicContent{CurData: mapstr.MapStr{common.BKInstNameField: "a"}},
+			revert:  metadata.AuditDelete,
+			status:  metadata.RevertAuditConflict,
+		},
+		{
+			name:    "revert create of host",
+			resType: metadata.HostRes,
+			objID:   common.BKInnerObjIDHost,
+			action:  metadata.AuditCreate,
+			state:   &revertInstState{exists: true, data: mapstr.MapStr{common.BKHostNameField: "a"}},
+			content: &metadata.BasicContent{CurData: mapstr.MapStr{common.BKHostNameField: "a"}},
+			revert:  metadata.AuditDelete,
+			status:  metadata.RevertAuditUnsupported,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestRevertPlanner(map[string]*revertInstState{tc.objID + ":1": tc.state})
+			item, err := p.plan(newRevertAuditLog(10, tc.resType, tc.action, tc.objID, 1, tc.content))
+			require.NoError(t, err)
+			require.Equal(t, tc.revert, item.Action)
+			require.Equal(t, tc.status, item.Status, item.Message)
+			require.Equal(t, tc.data, item.Data)
+		})
+	}
+}
+
+func TestAuditRevertPlanUnsupported(t *testing.T) {
+	p := newTestRevertPlanner(map[string]*revertInstState{})
+
+	logs := []*metadata.AuditLog{
+		{ID: 1, ResourceType: metadata.BusinessRes, Action: metadata.AuditUpdate, ResourceID: 1,
+			OperationDetail: &metadata.InstanceOpDetail{BasicOpDetail: metadata.BasicOpDetail{
+				Details: &metadata.BasicContent{}}, ModelID: common.BKInnerObjIDApp}},
+		{ID: 2, ResourceType: metadata.ModelInstanceRes, Action: metadata.AuditUpdate, ResourceID: 1,
+			OperationDetail: &metadata.InstanceOpDetail{ModelID: "switch"}},
+		{ID: 3, ResourceType: metadata.ModelInstanceRes, Action: metadata.AuditUpdate, ResourceID: 1,
+			OperationDetail: &metadata.BasicOpDetail{Details: &metadata.BasicContent{}}},
+		{ID: 4, ResourceType: metadata.ModelInstanceRes, Action: metadata.AuditUpdate, ResourceID: "abc",
+			OperationDetail: &metadata.InstanceOpDetail{BasicOpDetail: metadata.BasicOpDetail{
+				Details: &metadata.BasicContent{}}, ModelID: "switch"}},
+	}
+
+	for _, log := range logs {
+		item, err := p.plan(log)
+		require.NoError(t, err)
+		require.Equal(t, metadata.RevertAuditUnsupported, item.Status, log.ID)
+		require.NotEmpty(t, item.Message)
+	}
+
+	// operations other than create, update and delete
+	p.states["switch:1"] = &revertInstState{exists: true, data: mapstr.MapStr{}}
+	log := newRevertAuditLog(5, metadata.ModelInstanceRes, metadata.AuditArchive, "switch", 1,
+		&metadata.BasicContent{})
+	item, err := p.plan(log)
+	require.NoError(t, err)
+	require.Equal(t, metadata.RevertAuditUnsupported, item.Status)
+}
+
+func TestIsAuditValueEqual(t *testing.T) {
+	require.True(t, isAuditValueEqual(1, float64(1)))
+	require.True(t, isAuditValueEqual(nil, nil))
+	require.True(t, isAuditValueEqual(map[string]interface{}{"a": int64(1)}, mapstr.MapStr{"a": 1.0}))
+	require.False(t, isAuditValueEqual("1", 1))
+	require.False(t, isAuditValueEqual(nil, ""))
+}
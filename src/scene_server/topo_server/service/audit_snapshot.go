@@ -0,0 +1,123 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// SearchAuditSnapshot reconstruct the state of a resource at a time point by replaying its audit logs
+func (s *Service) SearchAuditSnapshot(ctx *rest.Contexts) {
+	opt := new(metadata.AuditSnapshotOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	auditLogs, err := s.listResourceAuditLogs(ctx, &opt.AuditResource, opt.Time)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	snapshot := auditlog.NewAuditSnapshot(opt.AuditResource, opt.Time)
+	for idx := range auditLogs {
+		snapshot.Apply(&auditLogs[idx])
+	}
+
+	ctx.RespEntity(snapshot)
+}
+
+// SearchAuditSnapshotDiff compare the states of a resource between two time points, the states are reconstructed
+// by replaying its audit logs
+func (s *Service) SearchAuditSnapshotDiff(ctx *rest.Contexts) {
+	opt := new(metadata.AuditSnapshotDiffOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	startTime, rawErr := metadata.ParseAuditTime("start_time", opt.StartTime)
+	if rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	// get the audit logs until the end time, and replay the ones before the start time for the start state
+	auditLogs, err := s.listResourceAuditLogs(ctx, &opt.AuditResource, opt.EndTime)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	from := auditlog.NewAuditSnapshot(opt.AuditResource, opt.StartTime)
+	to := auditlog.NewAuditSnapshot(opt.AuditResource, opt.EndTime)
+	for idx := range auditLogs {
+		if !auditLogs[idx].OperationTime.After(startTime) {
+			from.Apply(&auditLogs[idx])
+		}
+		to.Apply(&auditLogs[idx])
+	}
+
+	ctx.RespEntity(auditlog.DiffAuditSnapshot(from, to))
+}
+
+// listResourceAuditLogs list all audit logs of the resource until the time point in the order of their ids
+func (s *Service) listResourceAuditLogs(ctx *rest.Contexts, res *metadata.AuditResource, endTime string) (
+	[]metadata.AuditLog, error) {
+
+	cond := res.ToAuditCond()
+	cond[common.BKOperationTimeField] = map[string]interface{}{common.BKDBLTE: endTime}
+
+	ctx.SetReadPreference(common.SecondaryPreferredMode)
+	auditLogs := make([]metadata.AuditLog, 0)
+	for start := 0; ; start += common.BKAuditLogPageLimit {
+		query := metadata.QueryCondition{
+			Condition: cond,
+			Page: metadata.BasePage{
+				Sort:  common.BKFieldID,
+				Start: start,
+				Limit: common.BKAuditLogPageLimit,
+			},
+		}
+
+		rsp, err := s.Engine.CoreAPI.CoreService().Audit().SearchAuditLog(ctx.Kit.Ctx, ctx.Kit.Header, query)
+		if err != nil {
+			blog.Errorf("search audit logs failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+			return nil, err
+		}
+
+		auditLogs = append(auditLogs, rsp.Info...)
+		if len(rsp.Info) < common.BKAuditLogPageLimit {
+			return auditLogs, nil
+		}
+	}
+}
@@ -38,7 +38,13 @@ func (s *service) SearchClusters(ctx *rest.Contexts) {
 		return
 	}
 
-	if cErr := searchCond.Validate(); cErr.ErrCode != 0 {
+	customFieldsType, err := s.getCustomFieldsType(ctx.Kit, types.KubeCluster, searchCond.BizID, searchCond.Filter)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if cErr := searchCond.Validate(customFieldsType); cErr.ErrCode != 0 {
 		blog.Errorf("validate request failed, err: %v, rid: %s", cErr, ctx.Kit.Rid)
 		ctx.RespAutoError(cErr.ToCCError(ctx.Kit.CCError))
 		return
@@ -133,6 +139,12 @@ func (s *service) UpdateClusterFields(ctx *rest.Contexts) {
 		return
 	}
 
+	err := s.validateCustomFields(ctx.Kit, types.KubeCluster, data.BizID, true, data.Data.CustomFields)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	clusters, err := s.getUpdateClustersInfo(ctx.Kit, data.BizID, data.IDs)
 	if err != nil {
 		ctx.RespAutoError(err)
@@ -331,6 +343,11 @@ func (s *service) CreateCluster(ctx *rest.Contexts) {
 		return
 	}
 
+	if err := s.validateCustomFields(ctx.Kit, types.KubeCluster, data.BizID, false, data.CustomFields); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	var id int64
 	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
 		// create cluster
@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kube
+
+import (
+	"configcenter/pkg/filter"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/criteria/enumor"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/kube/types"
+)
+
+// getCustomAttrs get the custom attributes of the kube resource type that can be used in the business, including
+// the global ones and the ones that belong to the business
+func (s *service) getCustomAttrs(kit *rest.Kit, kind string, bizID int64) ([]metadata.Attribute, error) {
+	objID, err := types.GetCustomFieldObjID(kind)
+	if err != nil {
+		blog.Errorf("get custom attribute object id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, types.KindField)
+	}
+
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{
+			common.BKObjIDField: objID,
+			common.BKAppIDField: mapstr.MapStr{common.BKDBIN: []int64{0, bizID}},
+		},
+		Page: metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	attrs, err := s.ClientSet.CoreService().Model().ReadModelAttr(kit.Ctx, kit.Header, objID, query)
+	if err != nil {
+		blog.Errorf("get custom attributes failed, obj: %s, biz: %d, err: %v, rid: %s", objID, bizID, err, kit.Rid)
+		return nil, err
+	}
+
+	return attrs.Info, nil
+}
+
+// validateCustomFields validate the custom fields of the kube resources to create or update by the custom attributes
+func (s *service) validateCustomFields(kit *rest.Kit, kind string, bizID int64, isUpdate bool,
+	fields ...*types.CustomFields) error {
+
+	hasCustomFields := false
+	for _, field := range fields {
+		if field != nil {
+			hasCustomFields = true
+			break
+		}
+	}
+
+	if !hasCustomFields {
+		return nil
+	}
+
+	attrs, err := s.getCustomAttrs(kit, kind, bizID)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if rawErr := types.ValidateCustomFields(kit.Ctx, attrs, field, isUpdate); rawErr.ErrCode != 0 {
+			blog.Errorf("validate %s custom fields %v failed, err: %v, rid: %s", kind, field, rawErr, kit.Rid)
+			return rawErr.ToCCError(kit.CCError)
+		}
+	}
+
+	return nil
+}
+
+// getCustomFieldsType get the filter field types of the custom attributes of the kube resource type, the custom
+// attributes are only needed when the list filter is set
+func (s *service) getCustomFieldsType(kit *rest.Kit, kind string, bizID int64, expr *filter.Expression) (
+	map[string]enumor.FieldType, error) {
+
+	if expr == nil {
+		return nil, nil
+	}
+
+	attrs, err := s.getCustomAttrs(kit, kind, bizID)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.CustomFieldsType(attrs), nil
+}
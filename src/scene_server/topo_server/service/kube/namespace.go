@@ -48,6 +48,15 @@ func (s *service) CreateNamespace(ctx *rest.Contexts) {
 		return
 	}
 
+	customFields := make([]*types.CustomFields, len(req.Data))
+	for idx := range req.Data {
+		customFields[idx] = req.Data[idx].CustomFields
+	}
+	if err := s.validateCustomFields(ctx.Kit, types.KubeNamespace, req.BizID, false, customFields...); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	var data *metadata.RspIDs
 	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
 		res, err := s.creatNamespace(ctx.Kit, req)
@@ -114,6 +123,12 @@ func (s *service) UpdateNamespace(ctx *rest.Contexts) {
 		return
 	}
 
+	err := s.validateCustomFields(ctx.Kit, types.KubeNamespace, req.BizID, true, req.Data.CustomFields)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	namespaces, err := s.checkNsData(ctx.Kit, req.BizID, req.IDs)
 	if err != nil {
 		ctx.RespAutoError(err)
@@ -265,7 +280,13 @@ func (s *service) ListNamespace(ctx *rest.Contexts) {
 		return
 	}
 
-	if rawErr := req.Validate(); rawErr.ErrCode != 0 {
+	customFieldsType, err := s.getCustomFieldsType(ctx.Kit, types.KubeNamespace, req.BizID, req.Filter)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := req.Validate(customFieldsType); rawErr.ErrCode != 0 {
 		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
 		return
 	}
@@ -0,0 +1,349 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kube
+
+import (
+	acmeta "configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/kube/types"
+)
+
+var nsResourceAuthTypes = map[types.NsResourceKind]acmeta.ResourceType{
+	types.KubeService:   acmeta.KubeService,
+	types.KubeIngress:   acmeta.KubeIngress,
+	types.KubeConfigMap: acmeta.KubeConfigMap,
+	types.KubeSecret:    acmeta.KubeSecret,
+}
+
+// authorizeNsResource authorize the operation of the namespace resources, returns false if not authorized.
+func (s *service) authorizeNsResource(ctx *rest.Contexts, kind types.NsResourceKind, action acmeta.Action,
+	bizID int64) bool {
+
+	authRes := acmeta.ResourceAttribute{Basic: acmeta.Basic{Type: nsResourceAuthTypes[kind], Action: action},
+		BusinessID: bizID}
+	if resp, authorized := s.AuthManager.Authorize(ctx.Kit, authRes); !authorized {
+		ctx.RespNoAuth(resp)
+		return false
+	}
+	return true
+}
+
+// CreateNsResource create namespace resources, such as service, ingress, configmap and secret
+func (s *service) CreateNsResource(ctx *rest.Contexts) {
+	kind := types.NsResourceKind(ctx.Request.PathParameter(types.KindField))
+	if err := kind.Validate(); err != nil {
+		blog.Errorf("namespace resource kind is invalid, kind: %v, err: %v, rid: %s", kind, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, types.KindField))
+		return
+	}
+
+	req := types.NsResCreateOption{Kind: kind}
+	if err := ctx.DecodeInto(&req); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := req.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if !s.authorizeNsResource(ctx, kind, acmeta.Create, req.BizID) {
+		return
+	}
+
+	var data *metadata.RspIDs
+	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		res, err := s.ClientSet.CoreService().Kube().CreateNsResource(ctx.Kit.Ctx, ctx.Kit.Header, kind, req.Data)
+		if err != nil {
+			blog.Errorf("create %s failed, data: %v, err: %v, rid: %s", kind, req, err, ctx.Kit.Rid)
+			return err
+		}
+		data = res
+
+		for idx := range req.Data {
+			base := req.Data[idx].GetNsResourceBase()
+			base.ID = data.IDs[idx]
+			base.SupplierAccount = ctx.Kit.SupplierAccount
+			req.Data[idx].SetNsResourceBase(base)
+		}
+
+		audit := auditlog.NewKubeAudit(s.ClientSet.CoreService())
+		auditParam := auditlog.NewGenerateAuditCommonParameter(ctx.Kit, metadata.AuditCreate)
+		auditLogs, err := audit.GenerateNsResourceAuditLog(auditParam, req.Data, kind)
+		if err != nil {
+			blog.Errorf("generate audit log failed, ids: %v, err: %v, rid: %s", data.IDs, err, ctx.Kit.Rid)
+			return err
+		}
+
+		if err = audit.SaveAuditLog(ctx.Kit, auditLogs...); err != nil {
+			blog.Errorf("save audit log failed, ids: %v, err: %v, rid: %s", data.IDs, err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(data)
+}
+
+// UpdateNsResource update namespace resources
+func (s *service) UpdateNsResource(ctx *rest.Contexts) {
+	kind := types.NsResourceKind(ctx.Request.PathParameter(types.KindField))
+	if err := kind.Validate(); err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, types.KindField))
+		return
+	}
+
+	req := new(types.NsResUpdateOption)
+	req.Kind = kind
+	if err := ctx.DecodeInto(req); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := req.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if !s.authorizeNsResource(ctx, kind, acmeta.Update, req.BizID) {
+		return
+	}
+
+	resources, err := s.getNsResources(ctx.Kit, req.BizID, req.IDs, kind)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if len(resources) == 0 {
+		blog.Errorf("no %s found, bizID: %d, ids: %v, rid: %s", kind, req.BizID, req.IDs, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommNotFound))
+		return
+	}
+
+	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		err = s.ClientSet.CoreService().Kube().UpdateNsResource(ctx.Kit.Ctx, ctx.Kit.Header, kind,
+			&req.NsResUpdateByIDsOption)
+		if err != nil {
+			blog.Errorf("update %s failed, data: %v, err: %v, rid: %s", kind, req, err, ctx.Kit.Rid)
+			return err
+		}
+
+		audit := auditlog.NewKubeAudit(s.ClientSet.CoreService())
+		auditParam := auditlog.NewGenerateAuditCommonParameter(ctx.Kit, metadata.AuditUpdate)
+		updateFields, err := mapstr.Struct2Map(req.Data)
+		if err != nil {
+			blog.Errorf("update fields convert failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+			return err
+		}
+		auditParam.WithUpdateFields(updateFields)
+		auditLogs, ccErr := audit.GenerateNsResourceAuditLog(auditParam, resources, kind)
+		if ccErr != nil {
+			blog.Errorf("generate audit log failed, data: %v, err: %v, rid: %s", resources, ccErr, ctx.Kit.Rid)
+			return ccErr
+		}
+		if ccErr = audit.SaveAuditLog(ctx.Kit, auditLogs...); ccErr != nil {
+			blog.Errorf("save audit log failed, data: %v, err: %v, rid: %s", resources, ccErr, ctx.Kit.Rid)
+			return ccErr
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteNsResource delete namespace resources
+func (s *service) DeleteNsResource(ctx *rest.Contexts) {
+	kind := types.NsResourceKind(ctx.Request.PathParameter(types.KindField))
+	if err := kind.Validate(); err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, types.KindField))
+		return
+	}
+
+	req := new(types.NsResDeleteOption)
+	if err := ctx.DecodeInto(req); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := req.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if !s.authorizeNsResource(ctx, kind, acmeta.Delete, req.BizID) {
+		return
+	}
+
+	resources, err := s.getNsResources(ctx.Kit, req.BizID, req.IDs, kind)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	// if all resources are already deleted, return
+	if len(resources) == 0 {
+		ctx.RespEntity(nil)
+		return
+	}
+
+	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		err = s.ClientSet.CoreService().Kube().DeleteNsResource(ctx.Kit.Ctx, ctx.Kit.Header, kind,
+			&req.NsResDeleteByIDsOption)
+		if err != nil {
+			blog.Errorf("delete %s failed, data: %v, err: %v, rid: %s", kind, req, err, ctx.Kit.Rid)
+			return err
+		}
+
+		audit := auditlog.NewKubeAudit(s.ClientSet.CoreService())
+		auditParam := auditlog.NewGenerateAuditCommonParameter(ctx.Kit, metadata.AuditDelete)
+		auditLogs, ccErr := audit.GenerateNsResourceAuditLog(auditParam, resources, kind)
+		if ccErr != nil {
+			blog.Errorf("generate audit log failed, data: %v, err: %v, rid: %s", resources, ccErr, ctx.Kit.Rid)
+			return ccErr
+		}
+		if ccErr = audit.SaveAuditLog(ctx.Kit, auditLogs...); ccErr != nil {
+			blog.Errorf("save audit log failed, data: %v, err: %v, rid: %s", resources, ccErr, ctx.Kit.Rid)
+			return ccErr
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// getNsResources get the namespace resources by ids, and checks if they can be operated by the biz.
+func (s *service) getNsResources(kit *rest.Kit, bizID int64, ids []int64, kind types.NsResourceKind) (
+	[]types.NsResourceInterface, error) {
+
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: ids}},
+		Page:      metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	resp, err := s.ClientSet.CoreService().Kube().ListNsResource(kit.Ctx, kit.Header, query, kind)
+	if err != nil {
+		blog.Errorf("list %s failed, bizID: %d, ids: %v, err: %v, rid: %s", kind, bizID, ids, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(resp.Info) == 0 {
+		return nil, nil
+	}
+
+	// checks if the resource's namespace is a shared namespace and if its biz id is not the same with the input biz id
+	mismatchNsIDs := make([]int64, 0)
+	for _, resource := range resp.Info {
+		base := resource.GetNsResourceBase()
+		if base.BizID != bizID {
+			mismatchNsIDs = append(mismatchNsIDs, base.NamespaceID)
+		}
+	}
+
+	if len(mismatchNsIDs) > 0 {
+		mismatchNsMap := map[int64][]int64{bizID: mismatchNsIDs}
+		if err := s.Logics.KubeOperation().CheckPlatBizSharedNs(kit, mismatchNsMap); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp.Info, nil
+}
+
+// ListNsResource list namespace resources
+func (s *service) ListNsResource(ctx *rest.Contexts) {
+	kind := types.NsResourceKind(ctx.Request.PathParameter(types.KindField))
+	tableName, err := kind.Table()
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, types.KindField))
+		return
+	}
+
+	req := new(types.NsResQueryOption)
+	if err := ctx.DecodeInto(req); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := req.Validate(kind); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if !s.authorizeNsResource(ctx, kind, acmeta.Find, req.BizID) {
+		return
+	}
+
+	// compatible for shared cluster scenario
+	cond, err := s.Logics.KubeOperation().GenSharedNsListCond(ctx.Kit, string(kind), req.BizID, req.Filter)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if req.Page.EnableCount {
+		counts, err := s.ClientSet.CoreService().Count().GetCountByFilter(ctx.Kit.Ctx, ctx.Kit.Header, tableName,
+			[]map[string]interface{}{cond})
+		if err != nil {
+			blog.Errorf("count %s failed, cond: %v, err: %v, rid: %s", kind, cond, err, ctx.Kit.Rid)
+			ctx.RespAutoError(err)
+			return
+		}
+		ctx.RespEntityWithCount(counts[0], make([]mapstr.MapStr, 0))
+		return
+	}
+
+	if req.Page.Sort == "" {
+		req.Page.Sort = common.BKFieldID
+	}
+
+	query := &metadata.QueryCondition{
+		Condition: cond,
+		Page:      req.Page,
+		Fields:    req.Fields,
+	}
+
+	resp, err := s.ClientSet.CoreService().Kube().ListNsResource(ctx.Kit.Ctx, ctx.Kit.Header, query, kind)
+	if err != nil {
+		blog.Errorf("list %s failed, bizID: %d, cond: %v, err: %v, rid: %s", kind, req.BizID, query, err,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntityWithCount(0, resp.Info)
+}
@@ -0,0 +1,374 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package kube
+
+import (
+	acmeta "configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/kube/types"
+)
+
+// relationPodFields are the pod fields needed to find the relations between namespace resources and workloads.
+var relationPodFields = []string{common.BKFieldID, types.KubeNameField, types.BKNamespaceIDField, types.LabelsField,
+	types.VolumesField, types.RefField}
+
+// FindNsResourceRelation find the pods and workloads related to the namespace resources
+func (s *service) FindNsResourceRelation(ctx *rest.Contexts) {
+	kind := types.NsResourceKind(ctx.Request.PathParameter(types.KindField))
+	if err := kind.Validate(); err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, types.KindField))
+		return
+	}
+
+	req := new(types.NsResRelationOption)
+	if err := ctx.DecodeInto(req); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := req.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if !s.authorizeNsResource(ctx, kind, acmeta.Find, req.BizID) {
+		return
+	}
+
+	resources, err := s.getNsResources(ctx.Kit, req.BizID, req.IDs, kind)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result := make([]types.NsResRelation, 0)
+	if len(resources) == 0 {
+		ctx.RespEntity(result)
+		return
+	}
+
+	nsIDs := make([]int64, 0)
+	for _, resource := range resources {
+		nsIDs = append(nsIDs, resource.GetNsResourceBase().NamespaceID)
+	}
+
+	podCond := mapstr.MapStr{types.BKNamespaceIDField: mapstr.MapStr{common.BKDBIN: nsIDs}}
+	pods, err := s.listRelationPods(ctx.Kit, podCond)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	podsByNs := make(map[int64][]types.Pod)
+	for _, pod := range pods {
+		podsByNs[pod.NamespaceID] = append(podsByNs[pod.NamespaceID], pod)
+	}
+
+	// the pods of an ingress are the pods of its backend services
+	var services map[int64]map[string]*types.Service
+	if kind == types.KubeIngress {
+		if services, err = s.listServicesByNs(ctx.Kit, nsIDs); err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
+	for _, resource := range resources {
+		base := resource.GetNsResourceBase()
+		relation := types.NsResRelation{
+			ID:        base.ID,
+			Name:      base.Name,
+			Pods:      make([]types.RelatedPod, 0),
+			Workloads: make([]types.Reference, 0),
+		}
+
+		existPods := make(map[int64]struct{})
+		existWls := make(map[types.WorkloadType]map[int64]struct{})
+		for _, pod := range podsByNs[base.NamespaceID] {
+			if !isPodRelatedToNsRes(resource, &pod, services[base.NamespaceID]) {
+				continue
+			}
+
+			if _, exists := existPods[pod.ID]; exists {
+				continue
+			}
+			existPods[pod.ID] = struct{}{}
+			relation.Pods = append(relation.Pods, types.RelatedPod{ID: pod.ID, Name: getPodName(&pod)})
+
+			if pod.Ref == nil {
+				continue
+			}
+			if _, exists := existWls[pod.Ref.Kind]; !exists {
+				existWls[pod.Ref.Kind] = make(map[int64]struct{})
+			}
+			if _, exists := existWls[pod.Ref.Kind][pod.Ref.ID]; exists {
+				continue
+			}
+			existWls[pod.Ref.Kind][pod.Ref.ID] = struct{}{}
+			relation.Workloads = append(relation.Workloads, *pod.Ref)
+		}
+
+		result = append(result, relation)
+	}
+
+	ctx.RespEntity(result)
+}
+
+// FindWorkloadNsResource find the services, ingresses, configmaps and secrets related to the workloads through their
+// pods, e.g. which ingress routes traffic to the deployment.
+func (s *service) FindWorkloadNsResource(ctx *rest.Contexts) {
+	kind := types.WorkloadType(ctx.Request.PathParameter(types.KindField))
+	if err := kind.Validate(); err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, types.KindField))
+		return
+	}
+
+	req := new(types.NsResRelationOption)
+	if err := ctx.DecodeInto(req); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := req.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	authRes := acmeta.ResourceAttribute{Basic: acmeta.Basic{Type: acmeta.KubeWorkload, Action: acmeta.Find},
+		BusinessID: req.BizID}
+	if resp, authorized := s.AuthManager.Authorize(ctx.Kit, authRes); !authorized {
+		ctx.RespNoAuth(resp)
+		return
+	}
+
+	workloads, err := s.checkWorkloadData(ctx.Kit, req.BizID, req.IDs, kind)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result := make([]types.WlNsResRelation, 0)
+	if len(workloads) == 0 {
+		ctx.RespEntity(result)
+		return
+	}
+
+	podCond := mapstr.MapStr{
+		types.RefKindField: kind,
+		types.RefIDField:   mapstr.MapStr{common.BKDBIN: req.IDs},
+	}
+	pods, err := s.listRelationPods(ctx.Kit, podCond)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	podsByWl := make(map[int64][]types.Pod)
+	nsIDs := make([]int64, 0)
+	for _, pod := range pods {
+		if pod.Ref == nil {
+			continue
+		}
+		podsByWl[pod.Ref.ID] = append(podsByWl[pod.Ref.ID], pod)
+		nsIDs = append(nsIDs, pod.NamespaceID)
+	}
+
+	resourcesByNs := make(map[types.NsResourceKind]map[int64][]types.NsResourceInterface)
+	if len(nsIDs) > 0 {
+		for _, resKind := range types.GetNsResourceKinds() {
+			resources, err := s.listNsResourcesByNs(ctx.Kit, resKind, nsIDs)
+			if err != nil {
+				ctx.RespAutoError(err)
+				return
+			}
+
+			resourcesByNs[resKind] = make(map[int64][]types.NsResourceInterface)
+			for _, resource := range resources {
+				nsID := resource.GetNsResourceBase().NamespaceID
+				resourcesByNs[resKind][nsID] = append(resourcesByNs[resKind][nsID], resource)
+			}
+		}
+	}
+
+	for _, workload := range workloads {
+		wlBase := workload.GetWorkloadBase()
+		result = append(result, types.WlNsResRelation{
+			ID:        wlBase.ID,
+			Resources: getPodsRelatedNsResources(podsByWl[wlBase.ID], wlBase.NamespaceID, resourcesByNs),
+		})
+	}
+
+	ctx.RespEntity(result)
+}
+
+// getPodsRelatedNsResources get the namespace resources in the namespace that are related to the pods.
+func getPodsRelatedNsResources(pods []types.Pod, nsID int64,
+	resourcesByNs map[types.NsResourceKind]map[int64][]types.NsResourceInterface) []types.NsResReference {
+
+	refs := make([]types.NsResReference, 0)
+	if len(pods) == 0 {
+		return refs
+	}
+
+	services := make(map[string]*types.Service)
+	for _, resource := range resourcesByNs[types.KubeService][nsID] {
+		if svc, ok := resource.(*types.Service); ok {
+			services[svc.Name] = svc
+		}
+	}
+
+	for _, resKind := range types.GetNsResourceKinds() {
+		for _, resource := range resourcesByNs[resKind][nsID] {
+			for idx := range pods {
+				if !isPodRelatedToNsRes(resource, &pods[idx], services) {
+					continue
+				}
+
+				base := resource.GetNsResourceBase()
+				refs = append(refs, types.NsResReference{Kind: resKind, ID: base.ID, Name: base.Name})
+				break
+			}
+		}
+	}
+
+	return refs
+}
+
+// isPodRelatedToNsRes checks if the pod is related to the namespace resource in the same namespace, services are
+// the services in the namespace keyed by name, which are used to find the pods of an ingress.
+func isPodRelatedToNsRes(resource types.NsResourceInterface, pod *types.Pod,
+	services map[string]*types.Service) bool {
+
+	switch res := resource.(type) {
+	case *types.Service:
+		return res.MatchLabels(pod.Labels)
+
+	case *types.Ingress:
+		for _, name := range res.GetServiceNames() {
+			if svc, exists := services[name]; exists && svc.MatchLabels(pod.Labels) {
+				return true
+			}
+		}
+		return false
+
+	case *types.ConfigMap:
+		configMaps, _ := pod.GetVolumeRefNames()
+		return containsName(configMaps, res.Name)
+
+	case *types.Secret:
+		_, secrets := pod.GetVolumeRefNames()
+		return containsName(secrets, res.Name)
+
+	default:
+		return false
+	}
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func getPodName(pod *types.Pod) string {
+	if pod.Name == nil {
+		return ""
+	}
+	return *pod.Name
+}
+
+// listRelationPods list all the pods that matches the condition with the fields needed to find relations.
+func (s *service) listRelationPods(kit *rest.Kit, cond mapstr.MapStr) ([]types.Pod, error) {
+	query := &metadata.QueryCondition{
+		Condition: cond,
+		Fields:    relationPodFields,
+		Page:      metadata.BasePage{Limit: common.BKMaxPageSize, Sort: common.BKFieldID},
+	}
+
+	pods := make([]types.Pod, 0)
+	for {
+		resp, err := s.ClientSet.CoreService().Kube().ListPod(kit.Ctx, kit.Header, query)
+		if err != nil {
+			blog.Errorf("list pods failed, cond: %v, err: %v, rid: %s", cond, err, kit.Rid)
+			return nil, err
+		}
+
+		pods = append(pods, resp.Info...)
+		if len(resp.Info) < common.BKMaxPageSize {
+			break
+		}
+		query.Page.Start += common.BKMaxPageSize
+	}
+
+	return pods, nil
+}
+
+// listNsResourcesByNs list all the namespace resources of the kind in the namespaces.
+func (s *service) listNsResourcesByNs(kit *rest.Kit, kind types.NsResourceKind, nsIDs []int64) (
+	[]types.NsResourceInterface, error) {
+
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{types.BKNamespaceIDField: mapstr.MapStr{common.BKDBIN: nsIDs}},
+		Page:      metadata.BasePage{Limit: common.BKMaxPageSize, Sort: common.BKFieldID},
+	}
+
+	resources := make([]types.NsResourceInterface, 0)
+	for {
+		resp, err := s.ClientSet.CoreService().Kube().ListNsResource(kit.Ctx, kit.Header, query, kind)
+		if err != nil {
+			blog.Errorf("list %s failed, namespace ids: %v, err: %v, rid: %s", kind, nsIDs, err, kit.Rid)
+			return nil, err
+		}
+
+		resources = append(resources, resp.Info...)
+		if len(resp.Info) < common.BKMaxPageSize {
+			break
+		}
+		query.Page.Start += common.BKMaxPageSize
+	}
+
+	return resources, nil
+}
+
+// listServicesByNs list the services in the namespaces, returns the map of namespace id to service name to service.
+func (s *service) listServicesByNs(kit *rest.Kit, nsIDs []int64) (map[int64]map[string]*types.Service, error) {
+	resources, err := s.listNsResourcesByNs(kit, types.KubeService, nsIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[int64]map[string]*types.Service)
+	for _, resource := range resources {
+		svc, ok := resource.(*types.Service)
+		if !ok {
+			continue
+		}
+
+		if _, exists := services[svc.NamespaceID]; !exists {
+			services[svc.NamespaceID] = make(map[string]*types.Service)
+		}
+		services[svc.NamespaceID][svc.Name] = svc
+	}
+
+	return services, nil
+}
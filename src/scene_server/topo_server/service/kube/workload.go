@@ -57,6 +57,15 @@ func (s *service) CreateWorkload(ctx *rest.Contexts) {
 		return
 	}
 
+	customFields := make([]*types.CustomFields, len(req.Data))
+	for idx, wl := range req.Data {
+		customFields[idx] = wl.GetWorkloadBase().CustomFields
+	}
+	if err := s.validateCustomFields(ctx.Kit, types.KubeWorkload, req.BizID, false, customFields...); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	var data *metadata.RspIDs
 	txnErr := s.ClientSet.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
 		res, err := s.createWorkload(ctx.Kit, kind, req)
@@ -137,6 +146,13 @@ func (s *service) UpdateWorkload(ctx *rest.Contexts) {
 		return
 	}
 
+	err := s.validateCustomFields(ctx.Kit, types.KubeWorkload, req.BizID, true,
+		req.Data.GetWorkloadBase().CustomFields)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	wlData, err := s.checkWorkloadData(ctx.Kit, req.BizID, req.IDs, kind)
 	if err != nil {
 		ctx.RespAutoError(err)
@@ -322,7 +338,13 @@ func (s *service) ListWorkload(ctx *rest.Contexts) {
 		return
 	}
 
-	if rawErr := req.Validate(kind); rawErr.ErrCode != 0 {
+	customFieldsType, err := s.getCustomFieldsType(ctx.Kit, types.KubeWorkload, req.BizID, req.Filter)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := req.Validate(kind, customFieldsType); rawErr.ErrCode != 0 {
 		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
 		return
 	}
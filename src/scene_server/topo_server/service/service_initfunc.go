@@ -58,6 +58,12 @@ func (s *Service) initAuditLog(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/audit_list", Handler: s.SearchAuditList})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/audit", Handler: s.SearchAuditDetail})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/inst_audit", Handler: s.SearchInstAudit})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/audit_snapshot",
+		Handler: s.SearchAuditSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/audit_snapshot/diff",
+		Handler: s.SearchAuditSnapshotDiff})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/revert/audit", Handler: s.RevertAudit})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/verify/audit_chain", Handler: s.VerifyAuditChain})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -84,6 +84,12 @@ func (s *Service) initSetTemplate(web *restful.WebService) {
 		Handler: s.DeleteSetTemplateAttribute})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/topo/set_template/attribute",
 		Handler: s.ListSetTemplateAttribute})
+	utility.AddHandler(rest.Action{Verb: http.MethodGet,
+		Path:    "/find/topo/set_template/{set_template_id}/bk_biz_id/{bk_biz_id}/sync_policy",
+		Handler: s.GetSetTplSyncPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut,
+		Path:    "/update/topo/set_template/{set_template_id}/bk_biz_id/{bk_biz_id}/sync_policy",
+		Handler: s.UpdateSetTplSyncPolicy})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -1187,3 +1187,93 @@ func (s *Service) ListSetTemplateAttribute(ctx *rest.Contexts) {
 
 	ctx.RespEntity(data)
 }
+
+// GetSetTplSyncPolicy get the sync policy of set template, returns the default manual policy if it is not set
+func (s *Service) GetSetTplSyncPolicy(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	setTemplateID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKSetTemplateIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKSetTemplateIDField))
+		return
+	}
+
+	setTemplate, err := s.Engine.CoreAPI.CoreService().SetTemplate().GetSetTemplate(ctx.Kit.Ctx, ctx.Kit.Header,
+		bizID, setTemplateID)
+	if err != nil {
+		blog.Errorf("get set template failed, bizID: %d, setTemplateID: %d, err: %v, rid: %s", bizID, setTemplateID,
+			err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	option := &metadata.ListSetTplSyncPolicyOption{
+		BizID:          bizID,
+		SetTemplateIDs: []int64{setTemplateID},
+		Page:           metadata.BasePage{Limit: 1},
+	}
+	policies, err := s.Engine.CoreAPI.CoreService().SetTemplate().ListSetTplSyncPolicy(ctx.Kit.Ctx, ctx.Kit.Header,
+		option)
+	if err != nil {
+		blog.Errorf("list set template sync policy failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if len(policies.Info) > 0 {
+		ctx.RespEntity(policies.Info[0])
+		return
+	}
+
+	ctx.RespEntity(metadata.SetTplSyncPolicy{
+		BizID:           bizID,
+		SetTemplateID:   setTemplateID,
+		Mode:            metadata.SetTplSyncModeManual,
+		Windows:         make([]metadata.SetTplSyncWindow, 0),
+		MaxConcurrency:  metadata.SetTplSyncDefaultConcurrency,
+		SupplierAccount: setTemplate.SupplierAccount,
+	})
+}
+
+// UpdateSetTplSyncPolicy create or update the sync policy of set template
+func (s *Service) UpdateSetTplSyncPolicy(ctx *rest.Contexts) {
+	option := new(metadata.SetTplSyncPolicyOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	setTemplateID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKSetTemplateIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKSetTemplateIDField))
+		return
+	}
+
+	option.BizID = bizID
+	option.SetTemplateID = setTemplateID
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	policy, err := s.Engine.CoreAPI.CoreService().SetTemplate().UpsertSetTplSyncPolicy(ctx.Kit.Ctx, ctx.Kit.Header,
+		option)
+	if err != nil {
+		blog.Errorf("update set template sync policy failed, option: %+v, err: %v, rid: %s", option, err,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(policy)
+}
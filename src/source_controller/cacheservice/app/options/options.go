@@ -35,11 +35,13 @@ type ServerOption struct {
 
 // Config export
 type Config struct {
-	Mongo      mongo.Config
-	WatchMongo mongo.Config
-	Redis      redis.Config
-	Auth       iam.AuthConfig
-	Audit      *auditconf.Config
+	Mongo          mongo.Config
+	WatchMongo     mongo.Config
+	Redis          redis.Config
+	Auth           iam.AuthConfig
+	Audit          *auditconf.Config
+	AuditRetention *auditconf.RetentionConfig
+	AuditExport    *auditconf.ExportConfig
 }
 
 // NewServerOption create a ServerOption object
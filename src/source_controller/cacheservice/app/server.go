@@ -154,5 +154,21 @@ func initResource(cacheSvr *CacheServer) error {
 		}
 	}
 
+	cacheSvr.Config.AuditRetention = new(auditconf.RetentionConfig)
+	if cc.IsExist("auditRetention") {
+		if err = cc.UnmarshalKey("auditRetention", cacheSvr.Config.AuditRetention); err != nil {
+			blog.Errorf("parse audit log retention config failed, err: %v", err)
+			return err
+		}
+	}
+
+	cacheSvr.Config.AuditExport = new(auditconf.ExportConfig)
+	if cc.IsExist("auditExport") {
+		if err = cc.UnmarshalKey("auditExport", cacheSvr.Config.AuditExport); err != nil {
+			blog.Errorf("parse audit log export config failed, err: %v", err)
+			return err
+		}
+	}
+
 	return nil
 }
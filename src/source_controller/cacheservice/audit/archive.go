@@ -0,0 +1,47 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/storage/dal"
+)
+
+// auditArchiver archives the expired audit logs into the cc_AuditLogArchive collection, the archive collection is in
+// the same database as the audit logs, so that it is shared by all the cache service instances and is backed up with
+// the other data. the audit log documents are archived as they are, including their tamper-evidence chain fields.
+type auditArchiver struct {
+	db dal.DB
+}
+
+// archive the audit logs, the audit logs may be archived more than once if the job fails after they are archived,
+// so the archived audit logs with the same ids are removed before they are inserted to keep the archive idempotent.
+func (a *auditArchiver) archive(ctx context.Context, logs []mapstr.MapStr, ids []int64) error {
+	cond := mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: ids}}
+	if err := a.db.Table(common.BKTableNameAuditLogArchive).Delete(ctx, cond); err != nil {
+		return fmt.Errorf("delete archived audit logs %v failed, err: %v", ids, err)
+	}
+
+	if err := a.db.Table(common.BKTableNameAuditLogArchive).Insert(ctx, logs); err != nil {
+		return fmt.Errorf("insert audit logs %v to archive failed, err: %v", ids, err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	tokenhandler "configcenter/src/source_controller/cacheservice/cache/token-handler"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/stream"
+	"configcenter/src/storage/stream/types"
+)
+
+// RunAuditChainSealing seal the newly created audit logs into the tamper-evident hash chain of their supplier
+// accounts. the audit logs are sealed in the order of the insert events, which is the order that they are committed.
+// the audit logs are sealed asynchronously after they are inserted, an audit log can be modified without breaking the
+// chain in the window before it is sealed, which is normally a few seconds. the chain verification reports the audit
+// logs that are still unsealed after metadata.DefaultAuditUnsealedTimeoutMinutes as broken, so that modifications in
+// a stuck sealing window are not hidden.
+func RunAuditChainSealing(loopW stream.LoopInterface) error {
+	name := "audit_chain"
+	tokenHandler := tokenhandler.NewSingleTokenHandler(name, mongodb.Client())
+
+	startAtTime, err := tokenHandler.GetStartWatchTime(context.Background())
+	if err != nil {
+		blog.Errorf("get %s start watch time failed, err: %v", name, err)
+		return err
+	}
+
+	operationType := types.Insert
+	loopOptions := &types.LoopBatchOptions{
+		LoopOptions: types.LoopOptions{
+			Name: name,
+			WatchOpt: &types.WatchOptions{
+				Options: types.Options{
+					OperationType:           &operationType,
+					Filter:                  make(mapstr.MapStr),
+					EventStruct:             new(metadata.AuditLog),
+					Collection:              common.BKTableNameAuditLog,
+					StartAtTime:             startAtTime,
+					WatchFatalErrorCallback: tokenHandler.ResetWatchToken,
+				},
+			},
+			TokenHandler: tokenHandler,
+			// audit logs that are skipped after retries are not sealed into the chain, so retry as much as possible
+			RetryOptions: &types.RetryOptions{
+				MaxRetryCount: 10,
+				RetryDuration: 3 * time.Second,
+			},
+		},
+		EventHandler: &types.BatchHandler{
+			DoBatch: sealAuditChain,
+		},
+		BatchSize: 200,
+	}
+
+	if err = loopW.WithBatch(loopOptions); err != nil {
+		blog.Errorf("watch %s failed, err: %v", name, err)
+		return err
+	}
+
+	return nil
+}
+
+// sealAuditChain seal the audit logs of the insert events into the hash chain
+func sealAuditChain(es []*types.Event) (retry bool) {
+	if len(es) == 0 {
+		return false
+	}
+
+	rid := es[0].ID()
+
+	logs := make([]*metadata.AuditLog, 0)
+	for _, event := range es {
+		if event.OperationType != types.Insert {
+			continue
+		}
+
+		auditLog, ok := event.Document.(*metadata.AuditLog)
+		if !ok {
+			blog.Errorf("received invalid audit event doc(%#v), oid: %s, rid: %s", event.Document, event.Oid, rid)
+			continue
+		}
+		logs = append(logs, auditLog)
+	}
+
+	if err := auditlog.SealAuditLogs(context.Background(), mongodb.Client(), logs); err != nil {
+		blog.Errorf("seal %d audit logs into hash chain failed, err: %v, rid: %s", len(logs), err, rid)
+		return true
+	}
+
+	return false
+}
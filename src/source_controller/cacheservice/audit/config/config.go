@@ -17,7 +17,12 @@
 // Package config is the audit data reporting configuration
 package config
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"configcenter/src/storage/dal/kafka"
+)
 
 // Config is the audit center related configuration
 type Config struct {
@@ -52,3 +57,94 @@ func (c Config) Validate() error {
 
 	return nil
 }
+
+// RetentionConfig is the audit log retention and archival configuration
+type RetentionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// DefaultTTLDays is the days that audit logs are kept by default, 0 means audit logs are kept forever
+	DefaultTTLDays int `mapstructure:"defaultTTLDays"`
+	// TTLDays is the days that audit logs of the audit type are kept, key is the audit type, it overwrites the
+	// DefaultTTLDays, 0 means audit logs of the audit type are kept forever
+	TTLDays map[string]int `mapstructure:"ttlDays"`
+	// Archive defines whether the expired audit logs are moved to the cc_AuditLogArchive collection before they
+	// are removed, expired audit logs are deleted directly if it is not set
+	Archive bool `mapstructure:"archive"`
+	// IntervalMinutes is the interval minutes between two retention jobs
+	IntervalMinutes int `mapstructure:"intervalMinutes"`
+}
+
+// Validate RetentionConfig
+func (c RetentionConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.DefaultTTLDays < 0 {
+		return errors.New("defaultTTLDays can not be negative")
+	}
+
+	for auditType, days := range c.TTLDays {
+		if days < 0 {
+			return fmt.Errorf("ttlDays of audit type %s can not be negative", auditType)
+		}
+	}
+
+	if c.IntervalMinutes < 0 {
+		return errors.New("intervalMinutes can not be negative")
+	}
+
+	return nil
+}
+
+// audit log export sink types
+const (
+	// KafkaSink export audit logs to kafka
+	KafkaSink = "kafka"
+	// SyslogSink export audit logs to syslog
+	SyslogSink = "syslog"
+)
+
+// ExportConfig is the configuration of exporting audit logs to external sink
+type ExportConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Sink is the type of the external sink that the audit logs are exported to
+	Sink   string       `mapstructure:"sink"`
+	Kafka  kafka.Config `mapstructure:"kafka"`
+	Syslog SyslogConfig `mapstructure:"syslog"`
+}
+
+// SyslogConfig is the configuration of the syslog sink
+type SyslogConfig struct {
+	// Network is the network of the syslog server, like tcp, udp, the local syslog server is used if it is not set
+	Network string `mapstructure:"network"`
+	// Address is the address of the syslog server
+	Address string `mapstructure:"address"`
+	// Tag is the tag of the syslog message
+	Tag string `mapstructure:"tag"`
+}
+
+// Validate ExportConfig
+func (c ExportConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	switch c.Sink {
+	case KafkaSink:
+		if len(c.Kafka.Brokers) == 0 {
+			return errors.New("kafka brokers is not set")
+		}
+
+		if c.Kafka.Topic == "" {
+			return errors.New("kafka topic is not set")
+		}
+	case SyslogSink:
+		if c.Syslog.Network != "" && c.Syslog.Address == "" {
+			return errors.New("syslog address is not set")
+		}
+	case "":
+		return errors.New("sink is not set")
+	}
+
+	return nil
+}
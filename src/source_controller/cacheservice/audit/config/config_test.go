@@ -0,0 +1,83 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package config
+
+import (
+	"testing"
+
+	"configcenter/src/storage/dal/kafka"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionConfigValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      RetentionConfig
+		expectErr bool
+	}{
+		{name: "disabled", conf: RetentionConfig{DefaultTTLDays: -1}},
+		{name: "valid", conf: RetentionConfig{Enabled: true, DefaultTTLDays: 180, TTLDays: map[string]int{"host": 0},
+			Archive: true, IntervalMinutes: 30}},
+		{name: "negative default ttl", conf: RetentionConfig{Enabled: true, DefaultTTLDays: -1}, expectErr: true},
+		{name: "negative audit type ttl", conf: RetentionConfig{Enabled: true, TTLDays: map[string]int{"host": -1}},
+			expectErr: true},
+		{name: "negative interval", conf: RetentionConfig{Enabled: true, IntervalMinutes: -1}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.conf.Validate()
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestExportConfigValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      ExportConfig
+		expectErr bool
+	}{
+		{name: "disabled", conf: ExportConfig{}},
+		{name: "no sink", conf: ExportConfig{Enabled: true}, expectErr: true},
+		{name: "valid kafka", conf: ExportConfig{Enabled: true, Sink: KafkaSink,
+			Kafka: kafka.Config{Brokers: []string{"127.0.0.1:9092"}, Topic: "audit"}}},
+		{name: "kafka without brokers", conf: ExportConfig{Enabled: true, Sink: KafkaSink,
+			Kafka: kafka.Config{Topic: "audit"}}, expectErr: true},
+		{name: "kafka without topic", conf: ExportConfig{Enabled: true, Sink: KafkaSink,
+			Kafka: kafka.Config{Brokers: []string{"127.0.0.1:9092"}}}, expectErr: true},
+		{name: "local syslog", conf: ExportConfig{Enabled: true, Sink: SyslogSink}},
+		{name: "remote syslog without address", conf: ExportConfig{Enabled: true, Sink: SyslogSink,
+			Syslog: SyslogConfig{Network: "udp"}}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.conf.Validate()
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/audit/config"
+	tokenhandler "configcenter/src/source_controller/cacheservice/cache/token-handler"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/stream"
+	"configcenter/src/storage/stream/types"
+)
+
+// ExportSchemaVersion is the version of the audit log export schema, it must be changed when the schema is changed
+// in an incompatible way, so that the consumers can tell the different schemas apart.
+const ExportSchemaVersion = "1"
+
+// ExportRecord is the stable schema of the audit log that is exported to the external sinks
+type ExportRecord struct {
+	SchemaVersion   string          `json:"schema_version"`
+	ID              int64           `json:"id"`
+	Time            string          `json:"time"`
+	SupplierAccount string          `json:"bk_supplier_account"`
+	User            string          `json:"user"`
+	AuditType       string          `json:"audit_type"`
+	ResourceType    string          `json:"resource_type"`
+	Action          string          `json:"action"`
+	OperateFrom     string          `json:"operate_from"`
+	BusinessID      int64           `json:"bk_biz_id"`
+	ResourceID      string          `json:"resource_id"`
+	ResourceName    string          `json:"resource_name"`
+	AppCode         string          `json:"app_code"`
+	RequestID       string          `json:"request_id"`
+	Detail          json.RawMessage `json:"detail"`
+}
+
+// NewExportRecord convert audit log to the export record
+func NewExportRecord(auditLog *metadata.AuditLog) (*ExportRecord, error) {
+	detail, err := json.Marshal(auditLog.OperationDetail)
+	if err != nil {
+		return nil, fmt.Errorf("marshal audit log %d detail failed, err: %v", auditLog.ID, err)
+	}
+
+	var resourceID string
+	if intID, err := util.GetInt64ByInterface(auditLog.ResourceID); err == nil {
+		resourceID = strconv.FormatInt(intID, 10)
+	} else {
+		resourceID = util.GetStrByInterface(auditLog.ResourceID)
+	}
+
+	return &ExportRecord{
+		SchemaVersion:   ExportSchemaVersion,
+		ID:              auditLog.ID,
+		Time:            auditLog.OperationTime.UTC().Format(time.RFC3339Nano),
+		SupplierAccount: auditLog.SupplierAccount,
+		User:            auditLog.User,
+		AuditType:       string(auditLog.AuditType),
+		ResourceType:    string(auditLog.ResourceType),
+		Action:          string(auditLog.Action),
+		OperateFrom:     string(auditLog.OperateFrom),
+		BusinessID:      auditLog.BusinessID,
+		ResourceID:      resourceID,
+		ResourceName:    auditLog.ResourceName,
+		AppCode:         auditLog.AppCode,
+		RequestID:       auditLog.RequestID,
+		Detail:          detail,
+	}, nil
+}
+
+// Exporter exports the audit logs to an external sink
+type Exporter interface {
+	// Export the audit log records to the external sink, all the records are exported again if error is returned,
+	// so the sink may receive duplicate records, consumers can deduplicate them by the audit log id.
+	Export(records []*ExportRecord) error
+	// Close the exporter
+	Close() error
+}
+
+// ExporterFactory create an exporter by the export config
+type ExporterFactory func(conf *config.ExportConfig) (Exporter, error)
+
+var (
+	exporterLock      sync.RWMutex
+	exporterFactories = make(map[string]ExporterFactory)
+)
+
+// RegisterExporter register the exporter factory of the sink type, the registered exporter will be used if the sink
+// type is configured
+func RegisterExporter(sink string, factory ExporterFactory) {
+	exporterLock.Lock()
+	defer exporterLock.Unlock()
+	exporterFactories[sink] = factory
+}
+
+func newExporter(conf *config.ExportConfig) (Exporter, error) {
+	exporterLock.RLock()
+	factory, exists := exporterFactories[conf.Sink]
+	exporterLock.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("audit log export sink %s is not supported", conf.Sink)
+	}
+	return factory(conf)
+}
+
+// auditExporter streams the newly created audit logs to the external sink
+type auditExporter struct {
+	loopW    stream.LoopInterface
+	exporter Exporter
+}
+
+// RunAuditExport run exporting new audit logs to the external sink
+func RunAuditExport(conf *config.ExportConfig, loopW stream.LoopInterface) error {
+	if conf == nil || !conf.Enabled {
+		blog.Info("audit log export is disabled")
+		return nil
+	}
+
+	if err := conf.Validate(); err != nil {
+		blog.Errorf("audit log export config(%+v) is invalid, err: %v", *conf, err)
+		return err
+	}
+
+	exporter, err := newExporter(conf)
+	if err != nil {
+		blog.Errorf("init audit log %s exporter failed, err: %v", conf.Sink, err)
+		return err
+	}
+
+	e := &auditExporter{
+		loopW:    loopW,
+		exporter: exporter,
+	}
+
+	if err = e.watch(); err != nil {
+		blog.Errorf("watch audit event and export to %s failed, err: %v", conf.Sink, err)
+		return err
+	}
+
+	return nil
+}
+
+// watch audit log event and export to the external sink
+func (e *auditExporter) watch() error {
+	ctx := util.SetDBReadPreference(context.Background(), common.SecondaryPreferredMode)
+
+	name := "audit_export"
+	tokenHandler := tokenhandler.NewSingleTokenHandler(name, mongodb.Client())
+
+	startAtTime, err := tokenHandler.GetStartWatchTime(ctx)
+	if err != nil {
+		blog.Errorf("get %s start watch time failed, err: %v", name, err)
+		return err
+	}
+
+	operationType := types.Insert
+	loopOptions := &types.LoopBatchOptions{
+		LoopOptions: types.LoopOptions{
+			Name: name,
+			WatchOpt: &types.WatchOptions{
+				Options: types.Options{
+					OperationType:           &operationType,
+					Filter:                  make(mapstr.MapStr),
+					EventStruct:             new(metadata.AuditLog),
+					Collection:              common.BKTableNameAuditLog,
+					StartAtTime:             startAtTime,
+					WatchFatalErrorCallback: tokenHandler.ResetWatchToken,
+				},
+			},
+			TokenHandler: tokenHandler,
+			RetryOptions: &types.RetryOptions{
+				MaxRetryCount: 2,
+				RetryDuration: 1 * time.Second,
+			},
+		},
+		EventHandler: &types.BatchHandler{
+			DoBatch: e.doBatch,
+		},
+		BatchSize: 100,
+	}
+
+	if err = e.loopW.WithBatch(loopOptions); err != nil {
+		blog.Errorf("watch %s failed, err: %v", name, err)
+		return err
+	}
+
+	return nil
+}
+
+// doBatch batch export audit event
+func (e *auditExporter) doBatch(es []*types.Event) (retry bool) {
+	if len(es) == 0 {
+		return false
+	}
+
+	rid := es[0].ID()
+
+	records := make([]*ExportRecord, 0)
+	for _, event := range es {
+		if event.OperationType != types.Insert {
+			continue
+		}
+
+		auditLog, ok := event.Document.(*metadata.AuditLog)
+		if !ok {
+			blog.Errorf("received invalid audit event doc(%#v), oid: %s, rid: %s", event.Document, event.Oid, rid)
+			continue
+		}
+
+		record, err := NewExportRecord(auditLog)
+		if err != nil {
+			blog.Errorf("convert audit log to export record failed, err: %v, rid: %s", err, rid)
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return false
+	}
+
+	if err := e.exporter.Export(records); err != nil {
+		blog.Errorf("export %d audit logs failed, err: %v, rid: %s", len(records), err, rid)
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,93 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"configcenter/src/source_controller/cacheservice/audit/config"
+	"configcenter/src/storage/dal/kafka"
+
+	"github.com/Shopify/sarama"
+)
+
+func init() {
+	RegisterExporter(config.KafkaSink, newKafkaExporter)
+}
+
+// kafkaExporter exports audit logs to kafka topic
+type kafkaExporter struct {
+	topic    string
+	producer sarama.SyncProducer
+}
+
+func newKafkaExporter(conf *config.ExportConfig) (Exporter, error) {
+	saramaConf := sarama.NewConfig()
+	saramaConf.Version = sarama.V0_10_2_0
+	saramaConf.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConf.Producer.Return.Successes = true
+	saramaConf.Producer.Retry.Max = 3
+	if conf.Kafka.User != "" && conf.Kafka.Password != "" {
+		saramaConf.Net.SASL.Enable = true
+		saramaConf.Net.SASL.User = conf.Kafka.User
+		saramaConf.Net.SASL.Password = conf.Kafka.Password
+		saramaConf.Net.SASL.Handshake = true
+		saramaConf.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &kafka.XDGSCRAMClient{HashGeneratorFcn: kafka.SHA512}
+		}
+		saramaConf.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+	}
+
+	producer, err := sarama.NewSyncProducer(conf.Kafka.Brokers, saramaConf)
+	if err != nil {
+		return nil, fmt.Errorf("create kafka producer failed, err: %v", err)
+	}
+
+	return &kafkaExporter{
+		topic:    conf.Kafka.Topic,
+		producer: producer,
+	}, nil
+}
+
+// Export audit log records to kafka, the audit log id is used as the message key
+func (k *kafkaExporter) Export(records []*ExportRecord) error {
+	messages := make([]*sarama.ProducerMessage, len(records))
+	for idx, record := range records {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("marshal audit log %d export record failed, err: %v", record.ID, err)
+		}
+
+		messages[idx] = &sarama.ProducerMessage{
+			Topic: k.topic,
+			Key:   sarama.StringEncoder(strconv.FormatInt(record.ID, 10)),
+			Value: sarama.ByteEncoder(value),
+		}
+	}
+
+	if err := k.producer.SendMessages(messages); err != nil {
+		return fmt.Errorf("send audit log messages to kafka topic %s failed, err: %v", k.topic, err)
+	}
+	return nil
+}
+
+// Close the kafka producer
+func (k *kafkaExporter) Close() error {
+	return k.producer.Close()
+}
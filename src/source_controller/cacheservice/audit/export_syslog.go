@@ -0,0 +1,72 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+
+	"configcenter/src/source_controller/cacheservice/audit/config"
+)
+
+func init() {
+	RegisterExporter(config.SyslogSink, newSyslogExporter)
+}
+
+// defaultSyslogTag is the default tag of the exported syslog messages
+const defaultSyslogTag = "bk-cmdb-audit"
+
+// syslogExporter exports audit logs to syslog, each audit log is written as a json message
+type syslogExporter struct {
+	writer *syslog.Writer
+}
+
+func newSyslogExporter(conf *config.ExportConfig) (Exporter, error) {
+	tag := conf.Syslog.Tag
+	if tag == "" {
+		tag = defaultSyslogTag
+	}
+
+	writer, err := syslog.Dial(conf.Syslog.Network, conf.Syslog.Address, syslog.LOG_INFO|syslog.LOG_LOCAL0, tag)
+	if err != nil {
+		return nil, fmt.Errorf("connect to syslog %s %s failed, err: %v", conf.Syslog.Network,
+			conf.Syslog.Address, err)
+	}
+
+	return &syslogExporter{writer: writer}, nil
+}
+
+// Export audit log records to syslog
+func (s *syslogExporter) Export(records []*ExportRecord) error {
+	for _, record := range records {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("marshal audit log %d export record failed, err: %v", record.ID, err)
+		}
+
+		if err = s.writer.Info(string(value)); err != nil {
+			return fmt.Errorf("write audit log %d to syslog failed, err: %v", record.ID, err)
+		}
+	}
+	return nil
+}
+
+// Close the syslog writer
+func (s *syslogExporter) Close() error {
+	return s.writer.Close()
+}
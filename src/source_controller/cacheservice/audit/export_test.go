@@ -0,0 +1,156 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/cacheservice/audit/config"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExportRecord(t *testing.T) {
+	opTime := time.Date(2024, 6, 1, 8, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	detail := &metadata.InstanceOpDetail{
+		BasicOpDetail: metadata.BasicOpDetail{Details: &metadata.BasicContent{
+			CurData: map[string]interface{}{"bk_host_innerip": "127.0.0.1"},
+		}},
+		ModelID: "host",
+	}
+
+	tests := []struct {
+		name       string
+		resourceID interface{}
+		expectID   string
+	}{
+		{name: "int resource id", resourceID: int64(10), expectID: "10"},
+		{name: "float resource id", resourceID: float64(11), expectID: "11"},
+		{name: "string resource id", resourceID: "host", expectID: "host"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditLog := &metadata.AuditLog{
+				ID:              1,
+				AuditType:       metadata.HostType,
+				SupplierAccount: "0",
+				User:            "admin",
+				ResourceType:    metadata.HostRes,
+				Action:          metadata.AuditCreate,
+				OperateFrom:     metadata.FromUser,
+				OperationDetail: detail,
+				OperationTime:   metadata.Time{Time: opTime},
+				BusinessID:      2,
+				ResourceID:      tt.resourceID,
+				ResourceName:    "127.0.0.1",
+				AppCode:         "app",
+				RequestID:       "rid",
+			}
+
+			record, err := NewExportRecord(auditLog)
+			require.NoError(t, err)
+			require.Equal(t, ExportSchemaVersion, record.SchemaVersion)
+			require.Equal(t, tt.expectID, record.ResourceID)
+			require.Equal(t, "2024-06-01T00:30:00Z", record.Time)
+			require.Equal(t, string(metadata.HostType), record.AuditType)
+			require.Equal(t, string(metadata.HostRes), record.ResourceType)
+			require.Equal(t, string(metadata.AuditCreate), record.Action)
+			require.EqualValues(t, 2, record.BusinessID)
+			require.Equal(t, "rid", record.RequestID)
+
+			actualDetail := new(metadata.InstanceOpDetail)
+			require.NoError(t, json.Unmarshal(record.Detail, actualDetail))
+			require.Equal(t, detail, actualDetail)
+		})
+	}
+}
+
+type fakeExporter struct {
+	records []*ExportRecord
+	err     error
+}
+
+// Export records the exported audit logs
+func (f *fakeExporter) Export(records []*ExportRecord) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.records = append(f.records, records...)
+	return nil
+}
+
+// Close the fake exporter
+func (f *fakeExporter) Close() error {
+	return nil
+}
+
+func TestExportDoBatch(t *testing.T) {
+	events := []*types.Event{
+		{OperationType: types.Insert, Document: &metadata.AuditLog{ID: 1, ResourceID: int64(1)}},
+		{OperationType: types.Update, Document: &metadata.AuditLog{ID: 2, ResourceID: int64(2)}},
+		{OperationType: types.Insert, Document: "invalid"},
+		{OperationType: types.Insert, Document: &metadata.AuditLog{ID: 3, ResourceID: int64(3)}},
+	}
+
+	tests := []struct {
+		name        string
+		events      []*types.Event
+		exportErr   error
+		expectRetry bool
+		expectIDs   []int64
+	}{
+		{name: "no events", expectIDs: []int64{}},
+		{name: "only insert audit logs are exported", events: events, expectIDs: []int64{1, 3}},
+		{name: "no audit log to export", events: events[1:3], expectIDs: []int64{}},
+		{name: "retry if export failed", events: events, exportErr: errors.New("export failed"), expectRetry: true,
+			expectIDs: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := &fakeExporter{err: tt.exportErr}
+			e := &auditExporter{exporter: exporter}
+			require.Equal(t, tt.expectRetry, e.doBatch(tt.events))
+
+			ids := make([]int64, 0)
+			for _, record := range exporter.records {
+				ids = append(ids, record.ID)
+			}
+			require.Equal(t, tt.expectIDs, ids)
+		})
+	}
+}
+
+func TestNewExporter(t *testing.T) {
+	exporter := new(fakeExporter)
+	RegisterExporter("fake", func(conf *config.ExportConfig) (Exporter, error) {
+		return exporter, nil
+	})
+
+	actual, err := newExporter(&config.ExportConfig{Sink: "fake"})
+	require.NoError(t, err)
+	require.Equal(t, exporter, actual)
+
+	_, err = newExporter(&config.ExportConfig{Sink: "unknown"})
+	require.Error(t, err)
+}
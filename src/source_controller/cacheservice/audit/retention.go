@@ -0,0 +1,185 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package audit
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	ccdiscovery "configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/audit/config"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+const (
+	// defaultRetentionInterval is the default interval between two audit log retention jobs
+	defaultRetentionInterval = time.Hour
+	// retentionBatchSize is the count of expired audit logs that are archived and deleted in one batch
+	retentionBatchSize = 500
+)
+
+// auditRetention removes the expired audit logs by the ttl of their audit types, the expired audit logs are archived
+// before they are removed if archive is enabled.
+type auditRetention struct {
+	conf     *config.RetentionConfig
+	isMaster ccdiscovery.ServiceManageInterface
+	db       dal.DB
+	archiver *auditArchiver
+}
+
+// RunAuditRetention run the audit log retention job periodically
+func RunAuditRetention(conf *config.RetentionConfig, isMaster ccdiscovery.ServiceManageInterface) error {
+	if conf == nil || !conf.Enabled {
+		blog.Info("audit log retention is disabled")
+		return nil
+	}
+
+	if err := conf.Validate(); err != nil {
+		blog.Errorf("audit log retention config(%+v) is invalid, err: %v", *conf, err)
+		return err
+	}
+
+	retention := &auditRetention{
+		conf:     conf,
+		isMaster: isMaster,
+		db:       mongodb.Client(),
+	}
+
+	if conf.Archive {
+		retention.archiver = &auditArchiver{db: retention.db}
+	}
+
+	go retention.loop()
+	return nil
+}
+
+func (r *auditRetention) loop() {
+	interval := defaultRetentionInterval
+	if r.conf.IntervalMinutes > 0 {
+		interval = time.Duration(r.conf.IntervalMinutes) * time.Minute
+	}
+
+	for {
+		time.Sleep(interval)
+
+		if !r.isMaster.IsMaster() {
+			continue
+		}
+
+		rid := util.GenerateRID()
+		blog.V(4).Infof("start audit log retention job, rid: %s", rid)
+
+		for _, cond := range r.expiredConds(time.Now()) {
+			if err := r.removeExpired(cond, rid); err != nil {
+				blog.Errorf("remove expired audit logs by cond %+v failed, err: %v, rid: %s", cond, err, rid)
+			}
+		}
+	}
+}
+
+// expiredConds generate the conditions of the expired audit logs, audit types whose ttl is configured use their
+// own ttl, the other audit types use the default ttl, audit logs are never expired if the ttl is 0.
+func (r *auditRetention) expiredConds(now time.Time) []mapstr.MapStr {
+	auditTypes := make([]string, 0)
+	for auditType := range r.conf.TTLDays {
+		auditTypes = append(auditTypes, auditType)
+	}
+	sort.Strings(auditTypes)
+
+	conds := make([]mapstr.MapStr, 0)
+	for _, auditType := range auditTypes {
+		days := r.conf.TTLDays[auditType]
+		if days == 0 {
+			continue
+		}
+
+		conds = append(conds, mapstr.MapStr{
+			common.BKAuditTypeField:     auditType,
+			common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: now.AddDate(0, 0, -days)},
+		})
+	}
+
+	if r.conf.DefaultTTLDays > 0 {
+		cond := mapstr.MapStr{
+			common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: now.AddDate(0, 0, -r.conf.DefaultTTLDays)},
+		}
+		if len(auditTypes) > 0 {
+			cond[common.BKAuditTypeField] = mapstr.MapStr{common.BKDBNIN: auditTypes}
+		}
+		conds = append(conds, cond)
+	}
+
+	return conds
+}
+
+// removeExpired archive and remove the audit logs matching the expired condition in batches
+func (r *auditRetention) removeExpired(cond mapstr.MapStr, rid string) error {
+	ctx := context.Background()
+	for {
+		// stop removing if this is no longer master, the new master will continue the job
+		if !r.isMaster.IsMaster() {
+			return nil
+		}
+
+		// audit logs are read as raw documents so that they are archived without losing any field
+		logs := make([]mapstr.MapStr, 0)
+		err := r.db.Table(common.BKTableNameAuditLog).Find(cond).Sort(common.BKFieldID).Limit(retentionBatchSize).
+			All(ctx, &logs)
+		if err != nil {
+			blog.Errorf("find expired audit logs failed, err: %v, cond: %+v, rid: %s", err, cond, rid)
+			return err
+		}
+
+		if len(logs) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(logs))
+		for idx, auditLog := range logs {
+			ids[idx], err = util.GetInt64ByInterface(auditLog[common.BKFieldID])
+			if err != nil {
+				blog.Errorf("parse expired audit log id failed, err: %v, log: %+v, rid: %s", err, auditLog, rid)
+				return err
+			}
+		}
+
+		if r.archiver != nil {
+			if err = r.archiver.archive(ctx, logs, ids); err != nil {
+				blog.Errorf("archive expired audit logs failed, err: %v, rid: %s", err, rid)
+				return err
+			}
+		}
+
+		delCond := mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: ids}}
+		if err = r.db.Table(common.BKTableNameAuditLog).Delete(ctx, delCond); err != nil {
+			blog.Errorf("delete expired audit logs failed, err: %v, ids: %v, rid: %s", err, ids, rid)
+			return err
+		}
+
+		blog.V(4).Infof("removed %d expired audit logs, last id: %d, rid: %s", len(ids), ids[len(ids)-1], rid)
+
+		if len(logs) < retentionBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,228 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package audit
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/audit/config"
+	"configcenter/src/storage/dal/fake"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeMaster bool
+
+// IsMaster returns if this is the master
+func (f fakeMaster) IsMaster() bool {
+	return bool(f)
+}
+
+var testNow = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func testAuditLog(id int64, auditType string, daysAgo int) mapstr.MapStr {
+	return fake.ToMapStr(mapstr.MapStr{
+		common.BKFieldID:            id,
+		common.BKAuditTypeField:     auditType,
+		common.BKOperationTimeField: testNow.AddDate(0, 0, -daysAgo),
+		common.BKAuditChainSeqField: id,
+		"chain_hash":                "hash",
+	})
+}
+
+func tableIDs(db *fake.DB, table string) []int64 {
+	ids := make([]int64, 0)
+	for _, doc := range db.Tables[table] {
+		id, _ := util.GetInt64ByInterface(doc[common.BKFieldID])
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestExpiredConds(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   *config.RetentionConfig
+		expect []mapstr.MapStr
+	}{
+		{
+			name:   "keep forever",
+			conf:   &config.RetentionConfig{},
+			expect: []mapstr.MapStr{},
+		},
+		{
+			name: "default ttl only",
+			conf: &config.RetentionConfig{DefaultTTLDays: 30},
+			expect: []mapstr.MapStr{
+				{common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: testNow.AddDate(0, 0, -30)}},
+			},
+		},
+		{
+			name: "audit type ttl overwrites default ttl",
+			conf: &config.RetentionConfig{DefaultTTLDays: 30, TTLDays: map[string]int{"host": 7, "business": 0,
+				"model": 90}},
+			expect: []mapstr.MapStr{
+				{
+					common.BKAuditTypeField:     "host",
+					common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: testNow.AddDate(0, 0, -7)},
+				},
+				{
+					common.BKAuditTypeField:     "model",
+					common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: testNow.AddDate(0, 0, -90)},
+				},
+				{
+					common.BKAuditTypeField:     mapstr.MapStr{common.BKDBNIN: []string{"business", "host", "model"}},
+					common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: testNow.AddDate(0, 0, -30)},
+				},
+			},
+		},
+		{
+			name: "audit type ttl without default ttl",
+			conf: &config.RetentionConfig{TTLDays: map[string]int{"host": 7}},
+			expect: []mapstr.MapStr{
+				{
+					common.BKAuditTypeField:     "host",
+					common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: testNow.AddDate(0, 0, -7)},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &auditRetention{conf: tt.conf}
+			require.Equal(t, tt.expect, r.expiredConds(testNow))
+		})
+	}
+}
+
+func TestRemoveExpired(t *testing.T) {
+	cond := mapstr.MapStr{
+		common.BKAuditTypeField:     "host",
+		common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: testNow.AddDate(0, 0, -7)},
+	}
+
+	tests := []struct {
+		name          string
+		archive       bool
+		isMaster      bool
+		logs          []mapstr.MapStr
+		archived      []mapstr.MapStr
+		insertErr     error
+		expectErr     bool
+		expectLogs    []int64
+		expectArchive []int64
+	}{
+		{
+			name:     "delete without archive",
+			isMaster: true,
+			logs: []mapstr.MapStr{testAuditLog(1, "host", 10), testAuditLog(2, "host", 1), testAuditLog(3, "model", 10),
+				testAuditLog(4, "host", 8)},
+			expectLogs:    []int64{2, 3},
+			expectArchive: []int64{},
+		},
+		{
+			name:     "archive then delete",
+			archive:  true,
+			isMaster: true,
+			logs: []mapstr.MapStr{testAuditLog(1, "host", 10), testAuditLog(2, "host", 1), testAuditLog(3, "model", 10),
+				testAuditLog(4, "host", 8)},
+			expectLogs:    []int64{2, 3},
+			expectArchive: []int64{1, 4},
+		},
+		{
+			name:          "archive is idempotent when the last job failed after archiving",
+			archive:       true,
+			isMaster:      true,
+			logs:          []mapstr.MapStr{testAuditLog(1, "host", 10), testAuditLog(2, "host", 10)},
+			archived:      []mapstr.MapStr{testAuditLog(1, "host", 10)},
+			expectLogs:    []int64{},
+			expectArchive: []int64{1, 2},
+		},
+		{
+			name:          "audit logs are kept if archive failed",
+			archive:       true,
+			isMaster:      true,
+			logs:          []mapstr.MapStr{testAuditLog(1, "host", 10)},
+			insertErr:     errors.New("insert failed"),
+			expectErr:     true,
+			expectLogs:    []int64{1},
+			expectArchive: []int64{},
+		},
+		{
+			name:          "not master",
+			archive:       true,
+			logs:          []mapstr.MapStr{testAuditLog(1, "host", 10)},
+			expectLogs:    []int64{1},
+			expectArchive: []int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := fake.NewDB()
+			db.Tables[common.BKTableNameAuditLog] = tt.logs
+			db.Tables[common.BKTableNameAuditLogArchive] = tt.archived
+			db.InsertErr[common.BKTableNameAuditLogArchive] = tt.insertErr
+
+			r := &auditRetention{isMaster: fakeMaster(tt.isMaster), db: db}
+			if tt.archive {
+				r.archiver = &auditArchiver{db: db}
+			}
+
+			err := r.removeExpired(cond, "rid")
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectLogs, tableIDs(db, common.BKTableNameAuditLog))
+			require.Equal(t, tt.expectArchive, tableIDs(db, common.BKTableNameAuditLogArchive))
+		})
+	}
+}
+
+func TestRemoveExpiredInBatches(t *testing.T) {
+	db := fake.NewDB()
+	total := retentionBatchSize*2 + 1
+	for id := 1; id <= total; id++ {
+		db.Tables[common.BKTableNameAuditLog] = append(db.Tables[common.BKTableNameAuditLog],
+			testAuditLog(int64(id), "host", 10))
+	}
+
+	r := &auditRetention{isMaster: fakeMaster(true), db: db, archiver: &auditArchiver{db: db}}
+	cond := mapstr.MapStr{common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: testNow}}
+	require.NoError(t, r.removeExpired(cond, "rid"))
+
+	require.Empty(t, db.Tables[common.BKTableNameAuditLog])
+	archived := db.Tables[common.BKTableNameAuditLogArchive]
+	require.Len(t, archived, total)
+
+	// the archived audit logs keep all the fields including the tamper-evidence chain fields
+	require.EqualValues(t, 1, archived[0][common.BKAuditChainSeqField])
+	require.Equal(t, "hash", archived[0]["chain_hash"])
+	require.Equal(t, primitive.NewDateTimeFromTime(testNow.AddDate(0, 0, -10)),
+		archived[0][common.BKOperationTimeField])
+}
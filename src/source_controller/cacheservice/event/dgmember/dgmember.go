@@ -0,0 +1,343 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package dgmember maintains the members of materialized dynamic groups
+package dgmember
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/stream"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	batchSize = 500
+	// reconcileInterval is the interval to check whether the dynamic groups of all businesses need to be reconciled
+	reconcileInterval = 5 * time.Second
+	// fullReconcileInterval is the interval to reconcile the dynamic groups of all businesses, since the changes of
+	// the custom object instances and associations referred by the dynamic group expressions are not watched
+	fullReconcileInterval = 10 * time.Minute
+)
+
+// watchResource is the resource whose changes may change the dynamic group memberships
+type watchResource struct {
+	collection string
+	fields     []string
+}
+
+var watchResources = []watchResource{
+	{collection: common.BKTableNameBaseHost, fields: []string{common.BKHostIDField}},
+	{collection: common.BKTableNameModuleHostConfig, fields: []string{common.BKAppIDField, common.BKHostIDField}},
+	{collection: common.BKTableNameBaseSet, fields: []string{common.BKAppIDField, common.BKSetIDField}},
+	{collection: common.BKTableNameBaseModule, fields: []string{common.BKAppIDField, common.BKModuleIDField}},
+	{collection: common.BKTableNameDynamicGroup, fields: []string{common.BKAppIDField, common.BKFieldID}},
+}
+
+// NewDynamicGroupMember init and run the member maintainer of materialized dynamic groups
+func NewDynamicGroupMember(watch stream.LoopInterface, isMaster discovery.ServiceManageInterface, watchDB dal.DB,
+	ccDB dal.DB) error {
+
+	m := &memberMaintainer{
+		watch:    watch,
+		isMaster: isMaster,
+		watchDB:  watchDB,
+		ccDB:     ccDB,
+	}
+
+	for _, res := range watchResources {
+		if err := m.watchResource(res); err != nil {
+			blog.Errorf("watch %s event for dynamic group member failed, err: %v", res.collection, err)
+			return err
+		}
+		blog.Infof("watch dynamic group member events, watch %s success", res.collection)
+	}
+
+	go m.loopReconcile()
+	return nil
+}
+
+type memberMaintainer struct {
+	watch    stream.LoopInterface
+	isMaster discovery.ServiceManageInterface
+	watchDB  dal.DB
+	ccDB     dal.DB
+}
+
+// watchResource watch the resource events and apply the member changes of the dynamic groups
+func (m *memberMaintainer) watchResource(res watchResource) error {
+	tokenHandler := newMemberTokenHandler(res.collection, m.watchDB)
+
+	startAtTime, err := tokenHandler.getStartWatchTime(context.Background())
+	if err != nil {
+		blog.Errorf("get start watch time for %s failed, err: %v", res.collection, err)
+		return err
+	}
+
+	watchOpts := &types.WatchOptions{
+		Options: types.Options{
+			EventStruct:             new(map[string]interface{}),
+			Collection:              res.collection,
+			StartAtTime:             startAtTime,
+			WatchFatalErrorCallback: tokenHandler.resetWatchToken,
+			Fields:                  res.fields,
+		},
+	}
+	if res.collection == common.BKTableNameBaseHost {
+		watchOpts.EventStruct = new(metadata.HostMapStr)
+	}
+
+	opts := &types.LoopBatchOptions{
+		LoopOptions: types.LoopOptions{
+			Name:         fmt.Sprintf("%s_%s", event.DynamicGroupMemberKey.Namespace(), res.collection),
+			WatchOpt:     watchOpts,
+			TokenHandler: tokenHandler,
+			RetryOptions: &types.RetryOptions{
+				MaxRetryCount: 10,
+				RetryDuration: 1 * time.Second,
+			},
+		},
+		EventHandler: &types.BatchHandler{
+			DoBatch: func(es []*types.Event) bool {
+				return m.doBatch(res.collection, es)
+			},
+		},
+		BatchSize: batchSize,
+	}
+
+	return m.watch.WithBatch(opts)
+}
+
+// doBatch re-evaluates the dynamic group memberships of the instances changed by the events, and applies the changes
+// of the memberships to the member table.
+func (m *memberMaintainer) doBatch(coll string, es []*types.Event) (retry bool) {
+	if len(es) == 0 {
+		return false
+	}
+
+	rid := es[0].ID()
+
+	changes, err := m.resolveChanges(coll, parseEventChanges(coll, es), rid)
+	if err != nil {
+		return true
+	}
+
+	if err = m.applyChanges(changes, rid); err != nil {
+		return true
+	}
+	return false
+}
+
+// eventChanges is the changed data parsed from the events of one collection
+type eventChanges struct {
+	// hostIDs is the ids of the changed hosts
+	hostIDs []int64
+	// topoDocs is the changed host relations, sets or modules
+	topoDocs []metadata.ModuleHost
+	// deleteOids is the oids of the deleted host relations or sets, their data is got from the delete archive
+	deleteOids []string
+	// groupIDs is the ids of the changed dynamic groups
+	groupIDs []string
+	// groupDeleted marks that some dynamic groups are deleted
+	groupDeleted bool
+}
+
+// parseEventChanges parse the changed data from the events of the collection
+func parseEventChanges(coll string, es []*types.Event) *eventChanges {
+	changes := &eventChanges{
+		hostIDs:    make([]int64, 0),
+		topoDocs:   make([]metadata.ModuleHost, 0),
+		deleteOids: make([]string, 0),
+		groupIDs:   make([]string, 0),
+	}
+
+	for _, e := range es {
+		switch e.OperationType {
+		case types.Insert, types.Update, types.Replace:
+		case types.Delete:
+			switch coll {
+			case common.BKTableNameDynamicGroup:
+				// deleted dynamic group is not archived, members of all the deleted dynamic groups are removed
+				changes.groupDeleted = true
+			case common.BKTableNameModuleHostConfig, common.BKTableNameBaseSet:
+				changes.deleteOids = append(changes.deleteOids, e.Oid)
+			}
+			// deleted host has no business, its host relation delete event is used instead. module with hosts can
+			// not be deleted, so its delete event is skipped.
+			continue
+		default:
+			continue
+		}
+
+		switch coll {
+		case common.BKTableNameBaseHost:
+			changes.hostIDs = append(changes.hostIDs, gjson.GetBytes(e.DocBytes, common.BKHostIDField).Int())
+		case common.BKTableNameDynamicGroup:
+			changes.groupIDs = append(changes.groupIDs, gjson.GetBytes(e.DocBytes, common.BKFieldID).String())
+		default:
+			changes.topoDocs = append(changes.topoDocs, metadata.ModuleHost{
+				AppID:    gjson.GetBytes(e.DocBytes, common.BKAppIDField).Int(),
+				HostID:   gjson.GetBytes(e.DocBytes, common.BKHostIDField).Int(),
+				SetID:    gjson.GetBytes(e.DocBytes, common.BKSetIDField).Int(),
+				ModuleID: gjson.GetBytes(e.DocBytes, common.BKModuleIDField).Int(),
+			})
+		}
+	}
+	return changes
+}
+
+// memberChanges is the changed instances whose dynamic group memberships need to be re-evaluated
+type memberChanges struct {
+	// bizHosts is the changed host ids of each business
+	bizHosts map[int64][]int64
+	// bizSets is the changed set ids of each business
+	bizSets map[int64][]int64
+	// groupIDs is the ids of the changed dynamic groups, they are reconciled as a whole
+	groupIDs []string
+	// groupDeleted marks that the members of the deleted dynamic groups need to be removed
+	groupDeleted bool
+}
+
+// resolveChanges find out the instances whose dynamic group memberships may be changed by the changed data
+func (m *memberMaintainer) resolveChanges(coll string, ec *eventChanges, rid string) (*memberChanges, error) {
+	changes := &memberChanges{
+		bizHosts:     make(map[int64][]int64),
+		bizSets:      make(map[int64][]int64),
+		groupIDs:     ec.groupIDs,
+		groupDeleted: ec.groupDeleted,
+	}
+
+	delDocs, err := m.getDeletedTopoDocs(coll, ec.deleteOids, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	switch coll {
+	case common.BKTableNameBaseHost:
+		relations, err := m.getHostRelations(common.BKHostIDField, ec.hostIDs, rid)
+		if err != nil {
+			return nil, err
+		}
+		changes.addHosts(relations)
+	case common.BKTableNameModuleHostConfig:
+		changes.addHosts(append(ec.topoDocs, delDocs...))
+	case common.BKTableNameBaseSet:
+		for _, doc := range append(ec.topoDocs, delDocs...) {
+			if doc.AppID <= 0 || doc.SetID <= 0 {
+				continue
+			}
+			changes.bizSets[doc.AppID] = append(changes.bizSets[doc.AppID], doc.SetID)
+		}
+
+		// deleted set has no hosts, only the hosts of the changed sets are re-evaluated for the set conditions
+		setIDs := make([]int64, len(ec.topoDocs))
+		for idx, doc := range ec.topoDocs {
+			setIDs[idx] = doc.SetID
+		}
+
+		relations, err := m.getHostRelations(common.BKSetIDField, setIDs, rid)
+		if err != nil {
+			return nil, err
+		}
+		changes.addHosts(relations)
+	case common.BKTableNameBaseModule:
+		moduleIDs := make([]int64, len(ec.topoDocs))
+		for idx, doc := range ec.topoDocs {
+			moduleIDs[idx] = doc.ModuleID
+		}
+
+		relations, err := m.getHostRelations(common.BKModuleIDField, moduleIDs, rid)
+		if err != nil {
+			return nil, err
+		}
+		changes.addHosts(relations)
+	}
+
+	return changes, nil
+}
+
+// addHosts add the hosts of the host relations to the changed hosts of their businesses
+func (c *memberChanges) addHosts(relations []metadata.ModuleHost) {
+	for _, relation := range relations {
+		if relation.AppID <= 0 || relation.HostID <= 0 {
+			continue
+		}
+		c.bizHosts[relation.AppID] = append(c.bizHosts[relation.AppID], relation.HostID)
+	}
+}
+
+// topoDelArchive is the delete archive of the host relation or set
+type topoDelArchive struct {
+	Detail metadata.ModuleHost `bson:"detail"`
+}
+
+// getDeletedTopoDocs get the deleted host relations or sets from the delete archive table
+func (m *memberMaintainer) getDeletedTopoDocs(coll string, oids []string, rid string) ([]metadata.ModuleHost,
+	error) {
+
+	if len(oids) == 0 {
+		return make([]metadata.ModuleHost, 0), nil
+	}
+
+	filter := map[string]interface{}{
+		"oid":  map[string]interface{}{common.BKDBIN: oids},
+		"coll": coll,
+	}
+
+	docs := make([]topoDelArchive, 0)
+	err := m.ccDB.Table(common.BKTableNameDelArchive).Find(filter).Fields("detail."+common.BKAppIDField,
+		"detail."+common.BKHostIDField, "detail."+common.BKSetIDField).All(context.Background(), &docs)
+	if err != nil {
+		blog.Errorf("get %s deleted docs from del archive failed, oids: %+v, err: %v, rid: %s", coll, oids, err, rid)
+		return nil, err
+	}
+
+	details := make([]metadata.ModuleHost, len(docs))
+	for idx, doc := range docs {
+		details[idx] = doc.Detail
+	}
+	return details, nil
+}
+
+// getHostRelations get the host relations by the host ids, set ids or module ids
+func (m *memberMaintainer) getHostRelations(field string, ids []int64, rid string) ([]metadata.ModuleHost, error) {
+	relations := make([]metadata.ModuleHost, 0)
+	if len(ids) == 0 {
+		return relations, nil
+	}
+
+	filter := map[string]interface{}{
+		field: map[string]interface{}{common.BKDBIN: util.IntArrayUnique(ids)},
+	}
+	err := m.ccDB.Table(common.BKTableNameModuleHostConfig).Find(filter).
+		Fields(common.BKAppIDField, common.BKHostIDField).All(context.Background(), &relations)
+	if err != nil {
+		blog.Errorf("get host relations failed, filter: %+v, err: %v, rid: %s", filter, err, rid)
+		return nil, err
+	}
+	return relations, nil
+}
@@ -0,0 +1,394 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package dgmember
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/fake"
+	streamtypes "configcenter/src/storage/stream/types"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	testBizID       = int64(2)
+	testOtherBizID  = int64(3)
+	testOwnerID     = "0"
+	testSwitchObjID = "switch"
+)
+
+// newTestDB returns a db with the test topology:
+// biz 2: set 10(db)/module 100(mysql): host 1, host 2; set 11(web)/module 101(nginx): host 3
+// biz 3: set 20(db)/module 200(mysql): host 4
+// host 2 is associated to switch 1000(sw1) as source, host 3 is associated by switch 1001(sw2) as destination
+func newTestDB(t *testing.T) *fake.DB {
+	db := fake.NewDB()
+	insert := func(table string, docs ...interface{}) {
+		require.NoError(t, db.Table(table).Insert(context.Background(), docs))
+	}
+
+	insert(common.BKTableNameBaseApp,
+		bson.M{common.BKAppIDField: testBizID, common.BkSupplierAccount: testOwnerID},
+		bson.M{common.BKAppIDField: testOtherBizID, common.BkSupplierAccount: testOwnerID})
+	insert(common.BKTableNameBaseSet,
+		bson.M{common.BKAppIDField: testBizID, common.BKSetIDField: 10, common.BKSetNameField: "db"},
+		bson.M{common.BKAppIDField: testBizID, common.BKSetIDField: 11, common.BKSetNameField: "web"},
+		bson.M{common.BKAppIDField: testOtherBizID, common.BKSetIDField: 20, common.BKSetNameField: "db"})
+	insert(common.BKTableNameBaseModule,
+		bson.M{common.BKAppIDField: testBizID, common.BKModuleIDField: 100, common.BKModuleNameField: "mysql"},
+		bson.M{common.BKAppIDField: testBizID, common.BKModuleIDField: 101, common.BKModuleNameField: "nginx"},
+		bson.M{common.BKAppIDField: testOtherBizID, common.BKModuleIDField: 200, common.BKModuleNameField: "mysql"})
+	insert(common.BKTableNameModuleHostConfig,
+		metadata.ModuleHost{AppID: testBizID, SetID: 10, ModuleID: 100, HostID: 1},
+		metadata.ModuleHost{AppID: testBizID, SetID: 10, ModuleID: 100, HostID: 2},
+		metadata.ModuleHost{AppID: testBizID, SetID: 11, ModuleID: 101, HostID: 3},
+		metadata.ModuleHost{AppID: testOtherBizID, SetID: 20, ModuleID: 200, HostID: 4})
+	insert(common.BKTableNameBaseHost,
+		bson.M{common.BKHostIDField: 1, common.BKOSTypeField: "1", common.BKCpuField: 2},
+		bson.M{common.BKHostIDField: 2, common.BKOSTypeField: "2", common.BKCpuField: 4},
+		bson.M{common.BKHostIDField: 3, common.BKOSTypeField: "1", common.BKCpuField: 8},
+		bson.M{common.BKHostIDField: 4, common.BKOSTypeField: "1", common.BKCpuField: 8})
+	insert(common.GetInstTableName(testSwitchObjID, testOwnerID),
+		bson.M{common.BKInstIDField: 1000, common.BKObjIDField: testSwitchObjID, common.BKInstNameField: "sw1"},
+		bson.M{common.BKInstIDField: 1001, common.BKObjIDField: testSwitchObjID, common.BKInstNameField: "sw2"})
+	insert(common.GetObjectInstAsstTableName(common.BKInnerObjIDHost, testOwnerID),
+		metadata.InstAsst{ObjectID: common.BKInnerObjIDHost, InstID: 2, AsstObjectID: testSwitchObjID,
+			AsstInstID: 1000, AssociationKindID: "connect"},
+		metadata.InstAsst{ObjectID: testSwitchObjID, InstID: 1001, AsstObjectID: common.BKInnerObjIDHost,
+			AsstInstID: 3, AssociationKindID: "connect"})
+	return db
+}
+
+func testAtom(field string, op filter.OpType, value interface{}) *filter.AtomRule {
+	return &filter.AtomRule{Field: field, Operator: op.Factory(), Value: value}
+}
+
+func testCond(objID, field, op string, value interface{}) metadata.DynamicGroupInfoCondition {
+	return metadata.DynamicGroupInfoCondition{
+		ObjID:     objID,
+		Condition: []metadata.DynamicGroupCondition{{Field: field, Operator: op, Value: value}},
+	}
+}
+
+func testExpr(rule filter.RuleFactory) metadata.DynamicGroupInfo {
+	return metadata.DynamicGroupInfo{Expression: &filter.Expression{RuleFactory: rule}}
+}
+
+func sortedIDs(ids []int64) []int64 {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestGroupEvaluator(t *testing.T) {
+	db := newTestDB(t)
+
+	testCases := []struct {
+		name       string
+		objID      string
+		info       metadata.DynamicGroupInfo
+		candidates []int64
+		expect     []int64
+	}{
+		{
+			name:  "host and set conditions",
+			objID: common.BKInnerObjIDHost,
+			info: metadata.DynamicGroupInfo{Condition: []metadata.DynamicGroupInfoCondition{
+				testCond(common.BKInnerObjIDHost, common.BKOSTypeField, common.BKDBEQ, "1"),
+				testCond(common.BKInnerObjIDSet, common.BKSetNameField, common.BKDBEQ, "db"),
+			}},
+			candidates: []int64{1, 2, 3, 4},
+			expect:     []int64{1},
+		},
+		{
+			name:  "module condition",
+			objID: common.BKInnerObjIDHost,
+			info: metadata.DynamicGroupInfo{Condition: []metadata.DynamicGroupInfoCondition{
+				testCond(common.BKInnerObjIDModule, common.BKModuleNameField, common.BKDBIN, []interface{}{"mysql"}),
+			}},
+			candidates: []int64{1, 2, 3, 4},
+			expect:     []int64{1, 2},
+		},
+		{
+			name:  "numeric host condition, only candidates are evaluated",
+			objID: common.BKInnerObjIDHost,
+			info: metadata.DynamicGroupInfo{Condition: []metadata.DynamicGroupInfoCondition{
+				testCond(common.BKInnerObjIDHost, common.BKCpuField, common.BKDBGTE, 4),
+			}},
+			candidates: []int64{1, 3, 4},
+			expect:     []int64{3},
+		},
+		{
+			name:  "expression of host and set rules",
+			objID: common.BKInnerObjIDHost,
+			info: testExpr(&filter.CombinedRule{Condition: filter.And, Rules: []filter.RuleFactory{
+				testAtom("host.bk_os_type", filter.Equal, "1"),
+				testAtom("set.bk_set_name", filter.Equal, "web"),
+			}}),
+			candidates: []int64{1, 2, 3, 4},
+			expect:     []int64{3},
+		},
+		{
+			name:  "expression of custom object and module rules",
+			objID: common.BKInnerObjIDHost,
+			info: testExpr(&filter.CombinedRule{Condition: filter.Or, Rules: []filter.RuleFactory{
+				testAtom("switch.bk_inst_name", filter.Equal, "sw1"),
+				testAtom("module.bk_module_name", filter.Equal, "nginx"),
+			}}),
+			candidates: []int64{1, 2, 3, 4},
+			expect:     []int64{2, 3},
+		},
+		{
+			name:       "expression of associations in both directions",
+			objID:      common.BKInnerObjIDHost,
+			info:       testExpr(testAtom("switch.bk_inst_name", filter.In, []interface{}{"sw1", "sw2"})),
+			candidates: []int64{1, 2, 3},
+			expect:     []int64{2, 3},
+		},
+		{
+			name:  "set condition",
+			objID: common.BKInnerObjIDSet,
+			info: metadata.DynamicGroupInfo{Condition: []metadata.DynamicGroupInfoCondition{
+				testCond(common.BKInnerObjIDSet, common.BKSetNameField, common.BKDBLIKE, "D"),
+			}},
+			candidates: []int64{10, 11, 20},
+			expect:     []int64{10},
+		},
+		{
+			name:       "set expression",
+			objID:      common.BKInnerObjIDSet,
+			info:       testExpr(testAtom("set.bk_set_name", filter.NotEqual, "db")),
+			candidates: []int64{10, 11, 20},
+			expect:     []int64{11},
+		},
+		{
+			name:       "no candidates",
+			objID:      common.BKInnerObjIDSet,
+			info:       testExpr(testAtom("set.bk_set_name", filter.NotEqual, "db")),
+			candidates: []int64{},
+			expect:     []int64{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &groupEvaluator{
+				db:      db,
+				group:   &metadata.DynamicGroup{AppID: testBizID, ID: "g", ObjID: tc.objID, Info: tc.info},
+				ownerID: testOwnerID,
+			}
+			matched, err := e.evaluate(context.Background(), tc.candidates)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, sortedIDs(matched))
+		})
+	}
+
+	e := &groupEvaluator{
+		db: db,
+		group: &metadata.DynamicGroup{AppID: testBizID, ObjID: common.BKInnerObjIDSet,
+			Info: testExpr(testAtom("module.bk_module_name", filter.Equal, "mysql"))},
+		ownerID: testOwnerID,
+	}
+	_, err := e.evaluate(context.Background(), []int64{10})
+	require.Error(t, err)
+}
+
+func groupMembers(t *testing.T, db *fake.DB, groupID string) []int64 {
+	members := make([]metadata.DynamicGroupMember, 0)
+	err := db.Table(common.BKTableNameDynamicGroupMember).Find(bson.M{common.BKDynamicGroupIDField: groupID}).
+		All(context.Background(), &members)
+	require.NoError(t, err)
+
+	ids := make([]int64, len(members))
+	for idx, member := range members {
+		ids[idx] = member.InstID
+	}
+	return sortedIDs(ids)
+}
+
+func insertGroup(t *testing.T, db *fake.DB, group metadata.DynamicGroup, memberIDs ...int64) {
+	require.NoError(t, db.Table(common.BKTableNameDynamicGroup).Insert(context.Background(), group))
+	for _, id := range memberIDs {
+		member := metadata.DynamicGroupMember{AppID: group.AppID, GroupID: group.ID, ObjID: group.ObjID, InstID: id}
+		require.NoError(t, db.Table(common.BKTableNameDynamicGroupMember).Insert(context.Background(), member))
+	}
+}
+
+func TestApplyChanges(t *testing.T) {
+	db := newTestDB(t)
+	m := &memberMaintainer{ccDB: db}
+
+	hostGroup := metadata.DynamicGroup{AppID: testBizID, ID: "host", ObjID: common.BKInnerObjIDHost,
+		Materialized: true, Info: testExpr(testAtom("host.bk_os_type", filter.Equal, "1"))}
+	setGroup := metadata.DynamicGroup{AppID: testBizID, ID: "set", ObjID: common.BKInnerObjIDSet, Materialized: true,
+		Info: metadata.DynamicGroupInfo{Condition: []metadata.DynamicGroupInfoCondition{
+			testCond(common.BKInnerObjIDSet, common.BKSetNameField, common.BKDBEQ, "db"),
+		}}}
+	plainGroup := metadata.DynamicGroup{AppID: testBizID, ID: "plain", ObjID: common.BKInnerObjIDHost,
+		Info: testExpr(testAtom("host.bk_os_type", filter.Equal, "1"))}
+	insertGroup(t, db, hostGroup, 2, 4)
+	insertGroup(t, db, setGroup, 11)
+	insertGroup(t, db, plainGroup, 1)
+	insertGroup(t, db, metadata.DynamicGroup{AppID: testBizID, ID: "deleted", ObjID: common.BKInnerObjIDHost}, 3)
+	require.NoError(t, db.Table(common.BKTableNameDynamicGroup).Delete(context.Background(),
+		bson.M{common.BKFieldID: "deleted"}))
+
+	// only the changed instances are re-evaluated
+	changes := &memberChanges{
+		bizHosts: map[int64][]int64{testBizID: {1, 2}},
+		bizSets:  map[int64][]int64{testBizID: {10}},
+	}
+	require.NoError(t, m.applyChanges(changes, ""))
+	require.Equal(t, []int64{1, 4}, groupMembers(t, db, hostGroup.ID))
+	require.Equal(t, []int64{10, 11}, groupMembers(t, db, setGroup.ID))
+	require.Equal(t, []int64{1}, groupMembers(t, db, plainGroup.ID))
+	require.Equal(t, []int64{3}, groupMembers(t, db, "deleted"))
+
+	// the changed dynamic groups are reconciled as a whole, and the members of the deleted groups are removed
+	changes = &memberChanges{groupIDs: []string{hostGroup.ID, setGroup.ID, plainGroup.ID}, groupDeleted: true}
+	require.NoError(t, m.applyChanges(changes, ""))
+	require.Equal(t, []int64{1, 3}, groupMembers(t, db, hostGroup.ID))
+	require.Equal(t, []int64{10}, groupMembers(t, db, setGroup.ID))
+	require.Empty(t, groupMembers(t, db, plainGroup.ID))
+	require.Empty(t, groupMembers(t, db, "deleted"))
+}
+
+func TestReconcileBiz(t *testing.T) {
+	db := newTestDB(t)
+	m := &memberMaintainer{ccDB: db}
+
+	hostGroup := metadata.DynamicGroup{AppID: testBizID, ID: "host", ObjID: common.BKInnerObjIDHost,
+		Materialized: true, Info: metadata.DynamicGroupInfo{Condition: []metadata.DynamicGroupInfoCondition{
+			testCond(common.BKInnerObjIDModule, common.BKModuleNameField, common.BKDBEQ, "mysql"),
+		}}}
+	insertGroup(t, db, hostGroup, 1, 3, 4)
+	insertGroup(t, db, metadata.DynamicGroup{AppID: testBizID, ID: "plain", ObjID: common.BKInnerObjIDHost}, 1)
+
+	bizIDs, err := m.getMaterializedBizIDs("")
+	require.NoError(t, err)
+	require.Equal(t, []int64{testBizID}, util.IntArrayUnique(bizIDs))
+
+	require.NoError(t, m.reconcileBiz(testBizID, ""))
+	require.Equal(t, []int64{1, 2}, groupMembers(t, db, hostGroup.ID))
+	require.Empty(t, groupMembers(t, db, "plain"))
+}
+
+func TestParseEventChanges(t *testing.T) {
+	newEvent := func(op streamtypes.OperType, doc string) *streamtypes.Event {
+		return &streamtypes.Event{Oid: "oid", OperationType: op, DocBytes: []byte(doc)}
+	}
+
+	changes := parseEventChanges(common.BKTableNameBaseHost, []*streamtypes.Event{
+		newEvent(streamtypes.Insert, `{"bk_host_id":1}`),
+		newEvent(streamtypes.Update, `{"bk_host_id":2}`),
+		newEvent(streamtypes.Delete, `{"bk_host_id":3}`),
+	})
+	require.Equal(t, []int64{1, 2}, changes.hostIDs)
+	require.Empty(t, changes.deleteOids)
+
+	changes = parseEventChanges(common.BKTableNameModuleHostConfig, []*streamtypes.Event{
+		newEvent(streamtypes.Insert, `{"bk_biz_id":2,"bk_host_id":1}`),
+		newEvent(streamtypes.Delete, `{}`),
+	})
+	require.Equal(t, []metadata.ModuleHost{{AppID: 2, HostID: 1}}, changes.topoDocs)
+	require.Equal(t, []string{"oid"}, changes.deleteOids)
+
+	changes = parseEventChanges(common.BKTableNameBaseModule, []*streamtypes.Event{
+		newEvent(streamtypes.Replace, `{"bk_biz_id":2,"bk_module_id":100}`),
+		newEvent(streamtypes.Delete, `{}`),
+	})
+	require.Equal(t, []metadata.ModuleHost{{AppID: 2, ModuleID: 100}}, changes.topoDocs)
+	require.Empty(t, changes.deleteOids)
+
+	changes = parseEventChanges(common.BKTableNameDynamicGroup, []*streamtypes.Event{
+		newEvent(streamtypes.Update, `{"bk_biz_id":2,"id":"g1"}`),
+		newEvent(streamtypes.Delete, `{}`),
+	})
+	require.Equal(t, []string{"g1"}, changes.groupIDs)
+	require.True(t, changes.groupDeleted)
+}
+
+func TestResolveChanges(t *testing.T) {
+	db := newTestDB(t)
+	m := &memberMaintainer{ccDB: db}
+
+	require.NoError(t, db.Table(common.BKTableNameDelArchive).Insert(context.Background(), bson.M{"oid": "del",
+		"coll": common.BKTableNameBaseSet, "detail": bson.M{common.BKAppIDField: testBizID, common.BKSetIDField: 12}}))
+
+	changes, err := m.resolveChanges(common.BKTableNameBaseHost, &eventChanges{hostIDs: []int64{1, 4}}, "")
+	require.NoError(t, err)
+	require.Equal(t, map[int64][]int64{testBizID: {1}, testOtherBizID: {4}}, changes.bizHosts)
+
+	changes, err = m.resolveChanges(common.BKTableNameBaseSet, &eventChanges{
+		topoDocs:   []metadata.ModuleHost{{AppID: testBizID, SetID: 10}},
+		deleteOids: []string{"del"},
+	}, "")
+	require.NoError(t, err)
+	require.Equal(t, map[int64][]int64{testBizID: {10, 12}}, changes.bizSets)
+	require.Equal(t, []int64{1, 2}, sortedIDs(changes.bizHosts[testBizID]))
+
+	changes, err = m.resolveChanges(common.BKTableNameBaseModule, &eventChanges{
+		topoDocs: []metadata.ModuleHost{{AppID: testBizID, ModuleID: 101}},
+	}, "")
+	require.NoError(t, err)
+	require.Equal(t, map[int64][]int64{testBizID: {3}}, changes.bizHosts)
+}
+
+func TestDiffMembers(t *testing.T) {
+	members := []metadata.DynamicGroupMember{
+		{ObjID: common.BKInnerObjIDHost, InstID: 1},
+		{ObjID: common.BKInnerObjIDHost, InstID: 2},
+		{ObjID: common.BKInnerObjIDSet, InstID: 3},
+	}
+
+	left, joined := diffMembers(common.BKInnerObjIDHost, members, []int64{2, 3, 4, 4})
+	require.Equal(t, []int64{1, 3}, sortedIDs(left))
+	require.Equal(t, []int64{3, 4}, sortedIDs(joined))
+
+	left, joined = diffMembers(common.BKInnerObjIDHost, nil, nil)
+	require.Empty(t, left)
+	require.Empty(t, joined)
+}
+
+func TestBuildObjFilter(t *testing.T) {
+	conds := groupObjConds([]metadata.DynamicGroupInfoCondition{
+		testCond(common.BKInnerObjIDSet, common.BKSetNameField, common.BKDBEQ, "db"),
+		testCond(common.BKInnerObjIDSet, common.BKSetEnvField, common.BKDBIN, []interface{}{"3"}),
+		testCond(common.BKInnerObjIDHost, common.BKCpuField, common.BKDBLTE, 8),
+	})
+	require.Len(t, conds, 2)
+
+	setFilter, err := buildObjFilter(common.BKInnerObjIDSet, conds[common.BKInnerObjIDSet])
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		common.BKSetNameField: "db",
+		common.BKSetEnvField:  map[string]interface{}{common.BKDBIN: []interface{}{"3"}},
+	}, setFilter)
+
+	hostFilter, err := buildObjFilter(common.BKInnerObjIDHost, conds[common.BKInnerObjIDHost])
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		common.BKCpuField: map[string]interface{}{common.BKDBLTE: 8},
+	}, hostFilter)
+}
@@ -0,0 +1,35 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package dgmember
+
+/*
+  Dynamic group member event is the join and leave event of the members of a materialized dynamic group. It has these
+  features as follows:
+  1. Only the dynamic groups with "materialized" set to true are maintained, their members are stored in the
+     cc_DynamicGroupMember table. A member is inserted when the host/set joins the dynamic group, and is deleted when
+     it leaves the dynamic group. The changes of this table is converted to the dynamic_group_member events by the
+     common event flow, the insert event is the join event and the delete event is the leave event.
+  2. The maintainer watches host, host relation, set, module and dynamic group events to find out the changed
+     instances, and re-evaluates only these instances for the materialized dynamic groups of their businesses by the
+     db directly, then applies the membership changes to the member table. The changed dynamic group is re-evaluated
+     as a whole.
+  3. Since the changes of the custom object instances and associations referred by the dynamic group expression are not
+     watched, all businesses with materialized dynamic groups are fully reconciled periodically in the same way.
+  4. Members of the dynamic groups that are deleted or no longer materialized are removed in the reconciliation.
+  5. Dynamic group member event uses the dynamic group id as the sub resource, so that the events of one dynamic group
+     can be watched by specifying the sub resource. It's auth resource is redirect to host resource.
+*/
@@ -0,0 +1,438 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package dgmember
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	params "configcenter/src/common/paraparse"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+// groupEvaluator evaluates whether the candidate instances are the members of a materialized dynamic group by the db
+// directly. only the candidates are evaluated, so that the changed instances can be re-evaluated without executing
+// the whole dynamic group.
+type groupEvaluator struct {
+	db      dal.DB
+	group   *metadata.DynamicGroup
+	ownerID string
+	rid     string
+}
+
+// evaluate returns the candidates that are the members of the dynamic group, the candidates are evaluated in batches.
+func (e *groupEvaluator) evaluate(ctx context.Context, candidates []int64) ([]int64, error) {
+	candidates = util.IntArrayUnique(candidates)
+
+	matched := make([]int64, 0)
+	for start := 0; start < len(candidates); start += batchSize {
+		end := start + batchSize
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+
+		var ids []int64
+		var err error
+		switch e.group.ObjID {
+		case common.BKInnerObjIDHost:
+			ids, err = e.evaluateHosts(ctx, candidates[start:end])
+		case common.BKInnerObjIDSet:
+			ids, err = e.evaluateSets(ctx, candidates[start:end])
+		default:
+			return nil, fmt.Errorf("dynamic group object %s is invalid", e.group.ObjID)
+		}
+		if err != nil {
+			blog.Errorf("evaluate dynamic group %s members failed, err: %v, rid: %s", e.group.ID, err, e.rid)
+			return nil, err
+		}
+		matched = append(matched, ids...)
+	}
+	return matched, nil
+}
+
+// evaluateHosts evaluates the hosts, only the hosts in the business of the dynamic group can be its members.
+func (e *groupEvaluator) evaluateHosts(ctx context.Context, hostIDs []int64) ([]int64, error) {
+	relFilter := map[string]interface{}{
+		common.BKAppIDField:  e.group.AppID,
+		common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs},
+	}
+	relations := make([]metadata.ModuleHost, 0)
+	err := e.db.Table(common.BKTableNameModuleHostConfig).Find(relFilter).
+		Fields(common.BKHostIDField, common.BKSetIDField, common.BKModuleIDField).All(ctx, &relations)
+	if err != nil {
+		blog.Errorf("get host relations failed, filter: %+v, err: %v, rid: %s", relFilter, err, e.rid)
+		return nil, err
+	}
+
+	if len(relations) == 0 {
+		return make([]int64, 0), nil
+	}
+
+	if e.group.Info.Expression != nil {
+		cond, err := e.buildExprCond(ctx, e.group.Info.Expression.RuleFactory, relationHostIDs(relations), relations)
+		if err != nil {
+			return nil, err
+		}
+		return e.distinctIDsIn(ctx, common.BKTableNameBaseHost, common.BKHostIDField, cond,
+			relationHostIDs(relations))
+	}
+
+	objConds := groupObjConds(e.group.Info.Condition)
+	for _, objID := range []string{common.BKInnerObjIDSet, common.BKInnerObjIDModule} {
+		objCond, exists := objConds[objID]
+		if !exists {
+			continue
+		}
+
+		objFilter, err := buildObjFilter(objID, objCond)
+		if err != nil {
+			return nil, err
+		}
+
+		relations, err = e.filterRelationsByTopo(ctx, objID, objFilter, relations)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(relations) == 0 {
+			return make([]int64, 0), nil
+		}
+	}
+
+	hostCond, exists := objConds[common.BKInnerObjIDHost]
+	if !exists {
+		return relationHostIDs(relations), nil
+	}
+
+	hostFilter, err := buildObjFilter(common.BKInnerObjIDHost, hostCond)
+	if err != nil {
+		return nil, err
+	}
+	return e.distinctIDsIn(ctx, common.BKTableNameBaseHost, common.BKHostIDField, hostFilter,
+		relationHostIDs(relations))
+}
+
+// evaluateSets evaluates the sets, only the sets in the business of the dynamic group can be its members.
+func (e *groupEvaluator) evaluateSets(ctx context.Context, setIDs []int64) ([]int64, error) {
+	var cond map[string]interface{}
+	if e.group.Info.Expression != nil {
+		var err error
+		cond, err = e.buildExprCond(ctx, e.group.Info.Expression.RuleFactory, setIDs, nil)
+		if err != nil {
+			return nil, err
+		}
+	} else if setCond, exists := groupObjConds(e.group.Info.Condition)[common.BKInnerObjIDSet]; exists {
+		var err error
+		cond, err = buildObjFilter(common.BKInnerObjIDSet, setCond)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bizCond := map[string]interface{}{common.BKAppIDField: e.group.AppID}
+	if len(cond) != 0 {
+		bizCond = map[string]interface{}{common.BKDBAND: []map[string]interface{}{cond, bizCond}}
+	}
+	return e.distinctIDsIn(ctx, common.BKTableNameBaseSet, common.BKSetIDField, bizCond, setIDs)
+}
+
+// buildExprCond build the condition of the dynamic group object by the expression rule, the rules of other objects
+// are converted to the ids of the candidates that are related to the matched instances.
+func (e *groupEvaluator) buildExprCond(ctx context.Context, rule filter.RuleFactory, candidates []int64,
+	relations []metadata.ModuleHost) (map[string]interface{}, error) {
+
+	objIDs, err := metadata.GetExprRuleObjects(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	// the rule is of one object, convert it as a whole
+	if len(objIDs) == 1 {
+		objRule, err := metadata.TrimExprRuleObject(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		cond, err := objRule.ToMgo()
+		if err != nil {
+			return nil, err
+		}
+
+		if objIDs[0] == e.group.ObjID {
+			return cond, nil
+		}
+
+		ids, err := e.getRelatedIDs(ctx, objIDs[0], cond, candidates, relations)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{common.GetInstIDField(e.group.ObjID): map[string]interface{}{
+			common.BKDBIN: ids}}, nil
+	}
+
+	// the rule is of multiple objects, it must be a combined rule, convert the sub rules and combine them
+	combinedRule, ok := rule.(*filter.CombinedRule)
+	if !ok {
+		return nil, fmt.Errorf("rule type %s is invalid", rule.WithType())
+	}
+
+	conds := make([]map[string]interface{}, len(combinedRule.Rules))
+	for idx, subRule := range combinedRule.Rules {
+		conds[idx], err = e.buildExprCond(ctx, subRule, candidates, relations)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch combinedRule.Condition {
+	case filter.And:
+		return map[string]interface{}{common.BKDBAND: conds}, nil
+	case filter.Or:
+		return map[string]interface{}{common.BKDBOR: conds}, nil
+	default:
+		return nil, fmt.Errorf("combined rule condition %s is invalid", combinedRule.Condition)
+	}
+}
+
+// getRelatedIDs get the candidates that are related to the instances of the object matching the condition
+func (e *groupEvaluator) getRelatedIDs(ctx context.Context, objID string, cond map[string]interface{},
+	candidates []int64, relations []metadata.ModuleHost) ([]int64, error) {
+
+	switch objID {
+	case common.BKInnerObjIDSet, common.BKInnerObjIDModule:
+		if e.group.ObjID != common.BKInnerObjIDHost {
+			return nil, fmt.Errorf("%s rule is invalid for %s dynamic group", objID, e.group.ObjID)
+		}
+
+		matched, err := e.filterRelationsByTopo(ctx, objID, cond, relations)
+		if err != nil {
+			return nil, err
+		}
+		return relationHostIDs(matched), nil
+	default:
+		if !metadata.IsCommon(objID) {
+			return nil, fmt.Errorf("%s rule is invalid for %s dynamic group", objID, e.group.ObjID)
+		}
+		return e.getAsstIDs(ctx, objID, cond, candidates)
+	}
+}
+
+// filterRelationsByTopo returns the host relations whose set or module in the business matches the condition
+func (e *groupEvaluator) filterRelationsByTopo(ctx context.Context, objID string, cond map[string]interface{},
+	relations []metadata.ModuleHost) ([]metadata.ModuleHost, error) {
+
+	topoIDs := make([]int64, len(relations))
+	for idx, relation := range relations {
+		topoIDs[idx] = relationTopoID(objID, relation)
+	}
+
+	topoCond := map[string]interface{}{
+		common.BKDBAND: []map[string]interface{}{cond, {common.BKAppIDField: e.group.AppID}},
+	}
+	matched, err := e.distinctIDsIn(ctx, common.GetInstTableName(objID, e.ownerID), common.GetInstIDField(objID),
+		topoCond, topoIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterRelations(objID, relations, matched), nil
+}
+
+// getAsstIDs get the candidates that are associated with the instances of the object matching the condition, both
+// the associations with the dynamic group object as source and as destination are included.
+func (e *groupEvaluator) getAsstIDs(ctx context.Context, objID string, cond map[string]interface{},
+	candidates []int64) ([]int64, error) {
+
+	asstFilter := map[string]interface{}{
+		common.BKDBOR: []map[string]interface{}{
+			{
+				common.BKObjIDField:           e.group.ObjID,
+				common.BKInstIDField:          map[string]interface{}{common.BKDBIN: candidates},
+				common.BKAsstObjIDField:       objID,
+				common.AssociationKindIDField: map[string]interface{}{common.BKDBNE: common.AssociationKindMainline},
+			},
+			{
+				common.BKObjIDField:           objID,
+				common.BKAsstObjIDField:       e.group.ObjID,
+				common.BKAsstInstIDField:      map[string]interface{}{common.BKDBIN: candidates},
+				common.AssociationKindIDField: map[string]interface{}{common.BKDBNE: common.AssociationKindMainline},
+			},
+		},
+	}
+
+	assts := make([]metadata.InstAsst, 0)
+	err := e.db.Table(common.GetObjectInstAsstTableName(e.group.ObjID, e.ownerID)).Find(asstFilter).
+		Fields(common.BKObjIDField, common.BKInstIDField, common.BKAsstInstIDField).All(ctx, &assts)
+	if err != nil {
+		blog.Errorf("get %s associations failed, filter: %+v, err: %v, rid: %s", objID, asstFilter, err, e.rid)
+		return nil, err
+	}
+
+	// related is the mapping of the associated instance id to the candidates
+	related := make(map[int64][]int64)
+	instIDs := make([]int64, 0)
+	for _, asst := range assts {
+		instID, candidate := asst.InstID, asst.AsstInstID
+		if asst.ObjectID == e.group.ObjID {
+			instID, candidate = asst.AsstInstID, asst.InstID
+		}
+
+		if _, exists := related[instID]; !exists {
+			instIDs = append(instIDs, instID)
+		}
+		related[instID] = append(related[instID], candidate)
+	}
+
+	if len(instIDs) == 0 {
+		return make([]int64, 0), nil
+	}
+
+	instCond := map[string]interface{}{
+		common.BKDBAND: []map[string]interface{}{cond, {common.BKObjIDField: objID}},
+	}
+	matched, err := e.distinctIDsIn(ctx, common.GetInstTableName(objID, e.ownerID), common.BKInstIDField, instCond,
+		instIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0)
+	for _, instID := range matched {
+		ids = append(ids, related[instID]...)
+	}
+	return util.IntArrayUnique(ids), nil
+}
+
+// distinctIDsIn get the ids in the given ids that match the condition, the ids are queried in batches
+func (e *groupEvaluator) distinctIDsIn(ctx context.Context, table, idField string, cond map[string]interface{},
+	ids []int64) ([]int64, error) {
+
+	ids = util.IntArrayUnique(ids)
+	result := make([]int64, 0)
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		idCond := map[string]interface{}{idField: map[string]interface{}{common.BKDBIN: ids[start:end]}}
+		if len(cond) != 0 {
+			idCond = map[string]interface{}{common.BKDBAND: []map[string]interface{}{cond, idCond}}
+		}
+
+		matched, err := e.db.Table(table).Distinct(ctx, idField, idCond)
+		if err != nil {
+			blog.Errorf("get distinct %s from %s failed, cond: %+v, err: %v, rid: %s", idField, table, idCond, err,
+				e.rid)
+			return nil, err
+		}
+
+		matchedIDs, err := util.SliceInterfaceToInt64(matched)
+		if err != nil {
+			blog.Errorf("parse distinct %s from %s failed, ids: %+v, err: %v, rid: %s", idField, table, matched, err,
+				e.rid)
+			return nil, err
+		}
+		result = append(result, matchedIDs...)
+	}
+	return result, nil
+}
+
+// groupObjConds merges the dynamic group conditions of the same object
+func groupObjConds(conds []metadata.DynamicGroupInfoCondition) map[string]*metadata.ConditionWithTime {
+	objConds := make(map[string]*metadata.ConditionWithTime)
+	for _, cond := range conds {
+		objCond, exists := objConds[cond.ObjID]
+		if !exists {
+			objCond = new(metadata.ConditionWithTime)
+			objConds[cond.ObjID] = objCond
+		}
+
+		for _, item := range cond.Condition {
+			objCond.Condition = append(objCond.Condition, metadata.ConditionItem{Field: item.Field,
+				Operator: item.Operator, Value: item.Value})
+		}
+
+		if cond.TimeCondition == nil {
+			continue
+		}
+
+		if objCond.TimeCondition == nil {
+			objCond.TimeCondition = &metadata.TimeCondition{Operator: cond.TimeCondition.Operator}
+		}
+		objCond.TimeCondition.Rules = append(objCond.TimeCondition.Rules, cond.TimeCondition.Rules...)
+	}
+	return objConds
+}
+
+// buildObjFilter build the db filter of the object by the dynamic group condition, the same as the host server does
+func buildObjFilter(objID string, cond *metadata.ConditionWithTime) (map[string]interface{}, error) {
+	var objFilter map[string]interface{}
+	var err error
+	if objID == common.BKInnerObjIDHost {
+		objFilter, err = params.ParseHostParams(cond.Condition)
+	} else {
+		objFilter = make(map[string]interface{})
+		err = params.ParseCommonParams(cond.Condition, objFilter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s condition failed, err: %v", objID, err)
+	}
+
+	if cond.TimeCondition == nil {
+		return objFilter, nil
+	}
+	return cond.TimeCondition.MergeTimeCondition(objFilter)
+}
+
+// relationTopoID returns the set id or module id of the host relation
+func relationTopoID(objID string, relation metadata.ModuleHost) int64 {
+	if objID == common.BKInnerObjIDSet {
+		return relation.SetID
+	}
+	return relation.ModuleID
+}
+
+// filterRelations returns the host relations whose set or module is in the topo ids
+func filterRelations(objID string, relations []metadata.ModuleHost, topoIDs []int64) []metadata.ModuleHost {
+	topoIDMap := make(map[int64]struct{}, len(topoIDs))
+	for _, id := range topoIDs {
+		topoIDMap[id] = struct{}{}
+	}
+
+	matched := make([]metadata.ModuleHost, 0)
+	for _, relation := range relations {
+		if _, exists := topoIDMap[relationTopoID(objID, relation)]; exists {
+			matched = append(matched, relation)
+		}
+	}
+	return matched
+}
+
+// relationHostIDs returns the unique host ids of the host relations
+func relationHostIDs(relations []metadata.ModuleHost) []int64 {
+	hostIDs := make([]int64, len(relations))
+	for idx, relation := range relations {
+		hostIDs[idx] = relation.HostID
+	}
+	return util.IntArrayUnique(hostIDs)
+}
@@ -0,0 +1,119 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package dgmember
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/stream/types"
+)
+
+var _ = types.TokenHandler(&memberTokenHandler{})
+
+// memberTokenHandler a token handler for the resources watched by dynamic group member maintainer, stores the token
+// in the form of {"_id": $member_collection:$watched_collection, "token": $token, "start_at_time": $start_at_time}
+type memberTokenHandler struct {
+	id      string
+	watchDB dal.DB
+}
+
+// newMemberTokenHandler generate a new dynamic group member token handler
+func newMemberTokenHandler(coll string, watchDB dal.DB) *memberTokenHandler {
+	return &memberTokenHandler{
+		id:      event.DynamicGroupMemberKey.Collection() + ":" + coll,
+		watchDB: watchDB,
+	}
+}
+
+// SetLastWatchToken set last watch token after the events are handled
+func (m *memberTokenHandler) SetLastWatchToken(ctx context.Context, token string) error {
+	filter := map[string]interface{}{
+		"_id": m.id,
+	}
+
+	data := mapstr.MapStr{
+		common.BKTokenField: token,
+	}
+
+	if err := m.watchDB.Table(common.BKTableNameWatchToken).Upsert(ctx, filter, data); err != nil {
+		blog.Errorf("set %s last watch token failed, data: %+v, err: %v", m.id, data, err)
+		return err
+	}
+	return nil
+}
+
+// GetStartWatchToken get start watch token from watch token db, watch from now on if it is not exist
+func (m *memberTokenHandler) GetStartWatchToken(ctx context.Context) (string, error) {
+	data, err := m.getLastChainNodeData(ctx)
+	if err != nil {
+		return "", err
+	}
+	return data.Token, nil
+}
+
+// resetWatchToken set watch token to empty and set the start watch time to the given one for next watch
+func (m *memberTokenHandler) resetWatchToken(startAtTime types.TimeStamp) error {
+	filter := map[string]interface{}{
+		"_id": m.id,
+	}
+
+	data := mapstr.MapStr{
+		common.BKTokenField:       "",
+		common.BKStartAtTimeField: startAtTime,
+	}
+
+	if err := m.watchDB.Table(common.BKTableNameWatchToken).Upsert(context.Background(), filter, data); err != nil {
+		blog.Errorf("reset %s watch token failed, data: %+v, err: %v", m.id, data, err)
+		return err
+	}
+	return nil
+}
+
+// getStartWatchTime get start watch time, returns nil to watch from now on if it is not exist
+func (m *memberTokenHandler) getStartWatchTime(ctx context.Context) (*types.TimeStamp, error) {
+	data, err := m.getLastChainNodeData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.StartAtTime.Sec == 0 {
+		return nil, nil
+	}
+	return &data.StartAtTime, nil
+}
+
+func (m *memberTokenHandler) getLastChainNodeData(ctx context.Context) (*watch.LastChainNodeData, error) {
+	filter := map[string]interface{}{
+		"_id": m.id,
+	}
+
+	data := new(watch.LastChainNodeData)
+	if err := m.watchDB.Table(common.BKTableNameWatchToken).Find(filter).One(ctx, data); err != nil {
+		if m.watchDB.IsNotFoundError(err) {
+			return data, nil
+		}
+		blog.Errorf("get %s last watch token failed, err: %v", m.id, err)
+		return nil, err
+	}
+	return data, nil
+}
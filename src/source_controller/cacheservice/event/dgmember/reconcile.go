@@ -0,0 +1,455 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package dgmember
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// applyChanges re-evaluates the changed instances for the materialized dynamic groups of their businesses, and
+// reconciles the changed dynamic groups as a whole.
+func (m *memberMaintainer) applyChanges(changes *memberChanges, rid string) error {
+	if changes.groupDeleted {
+		if err := m.removeDeletedGroupMembers(rid); err != nil {
+			return err
+		}
+	}
+
+	for _, groupID := range util.StrArrayUnique(changes.groupIDs) {
+		if err := m.reconcileGroupByID(groupID, rid); err != nil {
+			return err
+		}
+	}
+
+	bizIDs := make([]int64, 0)
+	for bizID := range changes.bizHosts {
+		bizIDs = append(bizIDs, bizID)
+	}
+	for bizID := range changes.bizSets {
+		bizIDs = append(bizIDs, bizID)
+	}
+
+	for _, bizID := range util.IntArrayUnique(bizIDs) {
+		groups, err := m.getMaterializedGroups(bizID, rid)
+		if err != nil {
+			return err
+		}
+
+		if len(groups) == 0 {
+			continue
+		}
+
+		ownerID, err := m.getBizOwnerID(bizID, rid)
+		if err != nil {
+			// the members of the deleted business are removed in the reconciliation
+			if m.ccDB.IsNotFoundError(err) {
+				continue
+			}
+			return err
+		}
+
+		for _, group := range groups {
+			instIDs := changes.bizHosts[bizID]
+			if group.ObjID == common.BKInnerObjIDSet {
+				instIDs = changes.bizSets[bizID]
+			}
+
+			if err = m.applyGroupChanges(&group, ownerID, instIDs, rid); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyGroupChanges re-evaluates the changed instances of the dynamic group, and applies their membership changes
+func (m *memberMaintainer) applyGroupChanges(group *metadata.DynamicGroup, ownerID string, instIDs []int64,
+	rid string) error {
+
+	if len(instIDs) == 0 || len(group.Info.VariableCondition) > 0 {
+		return nil
+	}
+
+	evaluator := &groupEvaluator{db: m.ccDB, group: group, ownerID: ownerID, rid: rid}
+	instIDs = util.IntArrayUnique(instIDs)
+	for start := 0; start < len(instIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(instIDs) {
+			end = len(instIDs)
+		}
+
+		matched, err := evaluator.evaluate(context.Background(), instIDs[start:end])
+		if err != nil {
+			return err
+		}
+
+		if err = m.applyMembers(group, ownerID, instIDs[start:end], matched, rid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeDeletedGroupMembers removes the members of the dynamic groups that are deleted or no longer materialized
+func (m *memberMaintainer) removeDeletedGroupMembers(rid string) error {
+	ctx := context.Background()
+
+	groupIDs, err := m.ccDB.Table(common.BKTableNameDynamicGroupMember).Distinct(ctx, common.BKDynamicGroupIDField,
+		map[string]interface{}{})
+	if err != nil {
+		blog.Errorf("get dynamic group ids of members failed, err: %v, rid: %s", err, rid)
+		return err
+	}
+
+	if len(groupIDs) == 0 {
+		return nil
+	}
+
+	groupFilter := map[string]interface{}{
+		common.BKFieldID:                       map[string]interface{}{common.BKDBIN: groupIDs},
+		common.BKDynamicGroupMaterializedField: true,
+	}
+	existIDs, err := m.ccDB.Table(common.BKTableNameDynamicGroup).Distinct(ctx, common.BKFieldID, groupFilter)
+	if err != nil {
+		blog.Errorf("get materialized dynamic group ids failed, err: %v, rid: %s", err, rid)
+		return err
+	}
+
+	existMap := make(map[string]struct{}, len(existIDs))
+	for _, id := range existIDs {
+		existMap[util.GetStrByInterface(id)] = struct{}{}
+	}
+
+	delIDs := make([]string, 0)
+	for _, id := range groupIDs {
+		if _, exists := existMap[util.GetStrByInterface(id)]; !exists {
+			delIDs = append(delIDs, util.GetStrByInterface(id))
+		}
+	}
+
+	if len(delIDs) == 0 {
+		return nil
+	}
+
+	delFilter := map[string]interface{}{
+		common.BKDynamicGroupIDField: map[string]interface{}{common.BKDBIN: delIDs},
+	}
+	if err = m.ccDB.Table(common.BKTableNameDynamicGroupMember).Delete(ctx, delFilter); err != nil {
+		blog.Errorf("delete members of dynamic groups %v failed, err: %v, rid: %s", delIDs, err, rid)
+		return err
+	}
+	return nil
+}
+
+// reconcileGroupByID reconcile the changed dynamic group as a whole, its members are removed if it is no longer
+// materialized.
+func (m *memberMaintainer) reconcileGroupByID(groupID string, rid string) error {
+	ctx := context.Background()
+
+	group := new(metadata.DynamicGroup)
+	err := m.ccDB.Table(common.BKTableNameDynamicGroup).Find(map[string]interface{}{common.BKFieldID: groupID}).
+		One(ctx, group)
+	if err != nil && !m.ccDB.IsNotFoundError(err) {
+		blog.Errorf("get dynamic group %s failed, err: %v, rid: %s", groupID, err, rid)
+		return err
+	}
+
+	if err != nil || !group.Materialized {
+		delFilter := map[string]interface{}{common.BKDynamicGroupIDField: groupID}
+		if err = m.ccDB.Table(common.BKTableNameDynamicGroupMember).Delete(ctx, delFilter); err != nil {
+			blog.Errorf("delete members of dynamic group %s failed, err: %v, rid: %s", groupID, err, rid)
+			return err
+		}
+		return nil
+	}
+
+	ownerID, err := m.getBizOwnerID(group.AppID, rid)
+	if err != nil {
+		if m.ccDB.IsNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	return m.reconcileGroup(group, ownerID, rid)
+}
+
+// loopReconcile reconcile the dynamic groups of all businesses periodically and when this becomes master, since the
+// changes of the custom object instances and associations referred by the dynamic group expressions are not watched,
+// and the events may be handled by the others when this is not master.
+func (m *memberMaintainer) loopReconcile() {
+	var lastFullReconcile time.Time
+	for {
+		time.Sleep(reconcileInterval)
+
+		if !m.isMaster.IsMaster() {
+			lastFullReconcile = time.Time{}
+			continue
+		}
+
+		if time.Since(lastFullReconcile) < fullReconcileInterval {
+			continue
+		}
+
+		rid := util.GenerateRID()
+		bizIDs, err := m.getMaterializedBizIDs(rid)
+		if err != nil {
+			continue
+		}
+
+		// retry the failed businesses in the next round
+		var reconcileErr error
+		for _, bizID := range util.IntArrayUnique(bizIDs) {
+			if err := m.reconcileBiz(bizID, rid); err != nil {
+				reconcileErr = err
+			}
+		}
+
+		if reconcileErr == nil {
+			lastFullReconcile = time.Now()
+		}
+	}
+}
+
+// getMaterializedBizIDs get the ids of the businesses with materialized dynamic groups or dynamic group members.
+func (m *memberMaintainer) getMaterializedBizIDs(rid string) ([]int64, error) {
+	groupFilter := map[string]interface{}{
+		common.BKDynamicGroupMaterializedField: true,
+	}
+	groupBizIDs, err := m.ccDB.Table(common.BKTableNameDynamicGroup).Distinct(context.Background(),
+		common.BKAppIDField, groupFilter)
+	if err != nil {
+		blog.Errorf("get materialized dynamic group biz ids failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	memberBizIDs, err := m.ccDB.Table(common.BKTableNameDynamicGroupMember).Distinct(context.Background(),
+		common.BKAppIDField, map[string]interface{}{})
+	if err != nil {
+		blog.Errorf("get dynamic group member biz ids failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	return util.SliceInterfaceToInt64(append(groupBizIDs, memberBizIDs...))
+}
+
+// getMaterializedGroups get the materialized dynamic groups in the business
+func (m *memberMaintainer) getMaterializedGroups(bizID int64, rid string) ([]metadata.DynamicGroup, error) {
+	groupFilter := map[string]interface{}{
+		common.BKAppIDField:                    bizID,
+		common.BKDynamicGroupMaterializedField: true,
+	}
+	groups := make([]metadata.DynamicGroup, 0)
+	err := m.ccDB.Table(common.BKTableNameDynamicGroup).Find(groupFilter).All(context.Background(), &groups)
+	if err != nil {
+		blog.Errorf("get materialized dynamic groups failed, biz id: %d, err: %v, rid: %s", bizID, err, rid)
+		return nil, err
+	}
+	return groups, nil
+}
+
+// reconcileBiz reconcile the members of the materialized dynamic groups in the business, and remove the members of
+// the dynamic groups that are deleted or no longer materialized.
+func (m *memberMaintainer) reconcileBiz(bizID int64, rid string) error {
+	groups, err := m.getMaterializedGroups(bizID, rid)
+	if err != nil {
+		return err
+	}
+
+	groupIDs := make([]string, len(groups))
+	for idx, group := range groups {
+		groupIDs[idx] = group.ID
+	}
+
+	// the dynamic groups of the deleted business are deleted too, so all of its members are removed
+	ownerID, err := m.getBizOwnerID(bizID, rid)
+	if err != nil {
+		if !m.ccDB.IsNotFoundError(err) {
+			return err
+		}
+		groupIDs = make([]string, 0)
+	}
+
+	delFilter := map[string]interface{}{
+		common.BKAppIDField:          bizID,
+		common.BKDynamicGroupIDField: map[string]interface{}{common.BKDBNIN: groupIDs},
+	}
+	if err := m.ccDB.Table(common.BKTableNameDynamicGroupMember).Delete(context.Background(), delFilter); err != nil {
+		blog.Errorf("delete members of not materialized dynamic groups failed, biz id: %d, err: %v, rid: %s",
+			bizID, err, rid)
+		return err
+	}
+
+	if len(groupIDs) == 0 {
+		return nil
+	}
+
+	var reconcileErr error
+	for _, group := range groups {
+		if err := m.reconcileGroup(&group, ownerID, rid); err != nil {
+			reconcileErr = err
+		}
+	}
+	return reconcileErr
+}
+
+// getBizOwnerID get the supplier account of the business
+func (m *memberMaintainer) getBizOwnerID(bizID int64, rid string) (string, error) {
+	biz := make(mapstr.MapStr)
+	filter := map[string]interface{}{common.BKAppIDField: bizID}
+	err := m.ccDB.Table(common.BKTableNameBaseApp).Find(filter).Fields(common.BkSupplierAccount).
+		One(context.Background(), &biz)
+	if err != nil {
+		blog.Errorf("get biz %d supplier account failed, err: %v, rid: %s", bizID, err, rid)
+		return "", err
+	}
+	return util.GetStrByInterface(biz[common.BkSupplierAccount]), nil
+}
+
+// reconcileGroup evaluates all the instances in the business of the dynamic group, and applies the differences with
+// the stored members.
+func (m *memberMaintainer) reconcileGroup(group *metadata.DynamicGroup, ownerID string, rid string) error {
+	if len(group.Info.VariableCondition) > 0 {
+		blog.Errorf("materialized dynamic group %s has variable condition, skip it, rid: %s", group.ID, rid)
+		return nil
+	}
+
+	instIDs, err := m.getBizInstIDs(group, rid)
+	if err != nil {
+		return err
+	}
+
+	evaluator := &groupEvaluator{db: m.ccDB, group: group, ownerID: ownerID, rid: rid}
+	matched, err := evaluator.evaluate(context.Background(), instIDs)
+	if err != nil {
+		return err
+	}
+
+	return m.applyMembers(group, ownerID, nil, matched, rid)
+}
+
+// getBizInstIDs get the ids of all the hosts or sets in the business of the dynamic group
+func (m *memberMaintainer) getBizInstIDs(group *metadata.DynamicGroup, rid string) ([]int64, error) {
+	table, idField := common.BKTableNameModuleHostConfig, common.BKHostIDField
+	if group.ObjID == common.BKInnerObjIDSet {
+		table, idField = common.BKTableNameBaseSet, common.BKSetIDField
+	}
+
+	filter := map[string]interface{}{common.BKAppIDField: group.AppID}
+	ids, err := m.ccDB.Table(table).Distinct(context.Background(), idField, filter)
+	if err != nil {
+		blog.Errorf("get biz %d %s ids failed, err: %v, rid: %s", group.AppID, group.ObjID, err, rid)
+		return nil, err
+	}
+	return util.SliceInterfaceToInt64(ids)
+}
+
+// applyMembers compares the matched instances with the stored members of the candidates, inserts the joined members
+// and deletes the left members. all the stored members are compared if the candidates is nil.
+func (m *memberMaintainer) applyMembers(group *metadata.DynamicGroup, ownerID string, candidates, matched []int64,
+	rid string) error {
+
+	ctx := context.Background()
+
+	filter := map[string]interface{}{
+		common.BKDynamicGroupIDField: group.ID,
+	}
+	if candidates != nil {
+		filter[common.BKInstIDField] = map[string]interface{}{common.BKDBIN: candidates}
+	}
+
+	members := make([]metadata.DynamicGroupMember, 0)
+	err := m.ccDB.Table(common.BKTableNameDynamicGroupMember).Find(filter).
+		Fields(common.BKObjIDField, common.BKInstIDField).All(ctx, &members)
+	if err != nil {
+		blog.Errorf("get dynamic group %s members failed, err: %v, rid: %s", group.ID, err, rid)
+		return err
+	}
+
+	leftIDs, joinedIDs := diffMembers(group.ObjID, members, matched)
+
+	if len(leftIDs) > 0 {
+		delFilter := map[string]interface{}{
+			common.BKDynamicGroupIDField: group.ID,
+			common.BKInstIDField:         map[string]interface{}{common.BKDBIN: leftIDs},
+		}
+		if err = m.ccDB.Table(common.BKTableNameDynamicGroupMember).Delete(ctx, delFilter); err != nil {
+			blog.Errorf("delete dynamic group %s left members %v failed, err: %v, rid: %s", group.ID, leftIDs, err,
+				rid)
+			return err
+		}
+	}
+
+	if len(joinedIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	joined := make([]metadata.DynamicGroupMember, len(joinedIDs))
+	for idx, instID := range joinedIDs {
+		joined[idx] = metadata.DynamicGroupMember{
+			AppID:      group.AppID,
+			GroupID:    group.ID,
+			ObjID:      group.ObjID,
+			InstID:     instID,
+			OwnerID:    ownerID,
+			CreateTime: now,
+		}
+	}
+
+	if err = m.ccDB.Table(common.BKTableNameDynamicGroupMember).Insert(ctx, joined); err != nil {
+		blog.Errorf("insert dynamic group %s joined members failed, err: %v, rid: %s", group.ID, err, rid)
+		return err
+	}
+	blog.V(4).Infof("dynamic group %s members changed, left: %v, joined: %v, rid: %s", group.ID, leftIDs, joinedIDs,
+		rid)
+	return nil
+}
+
+// diffMembers compares the stored members with the matched instances, returns the ids of the left members and the
+// joined instances. members of another object are left, since the object of the dynamic group is changed.
+func diffMembers(objID string, members []metadata.DynamicGroupMember, matched []int64) ([]int64, []int64) {
+	matchedMap := make(map[int64]struct{}, len(matched))
+	for _, instID := range matched {
+		matchedMap[instID] = struct{}{}
+	}
+
+	memberMap := make(map[int64]struct{})
+	leftIDs := make([]int64, 0)
+	for _, member := range members {
+		if _, exists := matchedMap[member.InstID]; !exists || member.ObjID != objID {
+			leftIDs = append(leftIDs, member.InstID)
+			continue
+		}
+		memberMap[member.InstID] = struct{}{}
+	}
+
+	joinedIDs := make([]int64, 0)
+	for _, instID := range util.IntArrayUnique(matched) {
+		if _, exists := memberMap[instID]; !exists {
+			joinedIDs = append(joinedIDs, instID)
+		}
+	}
+	return util.IntArrayUnique(leftIDs), joinedIDs
+}
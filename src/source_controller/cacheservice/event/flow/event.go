@@ -103,6 +103,22 @@ func NewEvent(watch stream.LoopInterface, isMaster discovery.ServiceManageInterf
 		blog.Errorf("run project event flow failed, err: %v", err)
 	}
 
+	if err := e.runDynamicGroupMember(context.Background()); err != nil {
+		blog.Errorf("run dynamic group member event flow failed, err: %v", err)
+	}
+
+	if err := e.runModelMetadata(context.Background()); err != nil {
+		blog.Errorf("run model metadata event flow failed, err: %v", err)
+	}
+
+	if err := e.runTemplate(context.Background()); err != nil {
+		blog.Errorf("run template event flow failed, err: %v", err)
+	}
+
+	if err := e.runKubeNsResource(context.Background()); err != nil {
+		blog.Errorf("run kube namespace resource event flow failed, err: %v", err)
+	}
+
 	return nil
 }
 
@@ -269,3 +285,63 @@ func (e *Event) runProject(ctx context.Context) error {
 
 	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
 }
+
+func (e *Event) runDynamicGroupMember(ctx context.Context) error {
+	opts := flowOptions{
+		key:         event.DynamicGroupMemberKey,
+		watch:       e.watch,
+		watchDB:     e.watchDB,
+		ccDB:        e.ccDB,
+		isMaster:    e.isMaster,
+		EventStruct: new(map[string]interface{}),
+	}
+
+	return newFlow(ctx, opts, getDeleteEventDetails, parseDynamicGroupMemberEvent)
+}
+
+// runModelMetadata run the event flows of model metadata, including model definitions, attributes, attribute groups,
+// unique rules, model associations, association kinds and classifications
+func (e *Event) runModelMetadata(ctx context.Context) error {
+	keys := []event.Key{event.ObjectKey, event.ObjectAttributeKey, event.ObjectAttributeGroupKey,
+		event.ObjectUniqueKey, event.ModelAssociationKey, event.AssociationKindKey, event.ObjectClassificationKey}
+
+	return e.runFlows(ctx, keys, parseModelEvent)
+}
+
+// runTemplate run the event flows of service instances, service templates, process templates, set templates
+// and host apply rules, all of which use the business id as the sub resource
+func (e *Event) runTemplate(ctx context.Context) error {
+	keys := []event.Key{event.ServiceInstanceKey, event.ServiceTemplateKey, event.ProcessTemplateKey,
+		event.SetTemplateKey, event.HostApplyRuleKey}
+
+	return e.runFlows(ctx, keys, parseBizResourceEvent)
+}
+
+// runKubeNsResource run the event flows of kube services, ingresses, configmaps and secrets, all of which use the
+// business id as the sub resource
+func (e *Event) runKubeNsResource(ctx context.Context) error {
+	keys := []event.Key{event.KubeServiceKey, event.KubeIngressKey, event.KubeConfigMapKey, event.KubeSecretKey}
+
+	return e.runFlows(ctx, keys, parseBizResourceEvent)
+}
+
+// runFlows run the event flows of the keys whose event detail is a plain db document parsed by the same parser
+func (e *Event) runFlows(ctx context.Context, keys []event.Key, parseEvent parseEventFunc) error {
+	for _, key := range keys {
+		opts := flowOptions{
+			key:         key,
+			watch:       e.watch,
+			watchDB:     e.watchDB,
+			ccDB:        e.ccDB,
+			isMaster:    e.isMaster,
+			EventStruct: new(map[string]interface{}),
+		}
+
+		if err := newFlow(ctx, opts, getDeleteEventDetails, parseEvent); err != nil {
+			blog.Errorf("run %s event flow failed, err: %v", key.Collection(), err)
+			return err
+		}
+	}
+
+	return nil
+}
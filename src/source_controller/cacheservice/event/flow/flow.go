@@ -246,8 +246,19 @@ func (f *Flow) doBatch(es []*types.Event) (retry bool) {
 		// if hit cursor conflict, the former cursor node's detail will be overwrite by the later one, so it
 		// is not needed to remove the overlapped cursor node's detail again.
 		ttl := time.Duration(f.key.TTLSeconds()) * time.Second
-		pipe.Set(f.key.DetailKey(chainNode.Cursor), string(detail.eventInfo), ttl)
-		pipe.Set(f.key.GeneralResDetailKey(chainNode), string(detail.resDetail), ttl)
+		if f.key.IsGeneralRes() {
+			pipe.Set(f.key.DetailKey(chainNode.Cursor), string(detail.eventInfo), ttl)
+			pipe.Set(f.key.GeneralResDetailKey(chainNode), string(detail.resDetail), ttl)
+		} else {
+			// resource that is not general resource has no separate detail cache, store its detail with event info
+			eventDetail, err := detail.combine()
+			if err != nil {
+				blog.Errorf("run flow, combine %s event detail failed, oid: %s, err: %v, rid: %s",
+					f.key.Collection(), e.ID(), err, rid)
+				return false
+			}
+			pipe.Set(f.key.DetailKey(chainNode.Cursor), eventDetail, ttl)
+		}
 
 		// validate if the cursor already exists in the batch, this happens when the concurrency is very high.
 		// which will generate the same operation event with same cluster time, and generate with the same cursor
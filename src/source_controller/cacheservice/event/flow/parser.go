@@ -46,6 +46,23 @@ type eventDetail struct {
 	resDetail []byte
 }
 
+// combine the event info and resource detail into one event detail, it is used to store the detail of the resource
+// that is not general resource, whose detail is not stored in general resource detail cache
+func (d *eventDetail) combine() (string, error) {
+	detail := types.EventDetail{Detail: types.JsonString(d.resDetail)}
+	if len(d.eventInfo) > 0 {
+		if err := json.Unmarshal(d.eventInfo, &detail.EventInfo); err != nil {
+			return "", err
+		}
+	}
+
+	detailBytes, err := json.Marshal(detail)
+	if err != nil {
+		return "", err
+	}
+	return string(detailBytes), nil
+}
+
 // parseEvent parse event into db chain nodes to store in db and details to store in redis
 func parseEvent(db dal.DB, key event.Key, e *types.Event, oidDetailMap map[oidCollKey][]byte, id uint64, rid string) (
 	*watch.ChainNode, *eventDetail, bool, error) {
@@ -94,6 +111,38 @@ func parseEvent(db dal.DB, key event.Key, e *types.Event, oidDetailMap map[oidCo
 	return parseEventToNodeAndDetail(key, e, id, rid)
 }
 
+// newSubResourceEventParser returns the event parser that parses event into db chain nodes to store in db and details
+// to store in redis, the not empty values of the specified fields in the event doc are used as the sub resources
+func newSubResourceEventParser(fields ...string) parseEventFunc {
+	return func(db dal.DB, key event.Key, e *types.Event, oidDetailMap map[oidCollKey][]byte, id uint64,
+		rid string) (*watch.ChainNode, *eventDetail, bool, error) {
+
+		chainNode, detail, retry, err := parseEvent(db, key, e, oidDetailMap, id, rid)
+		if err != nil || chainNode == nil {
+			return chainNode, detail, retry, err
+		}
+
+		subResources := make([]string, 0)
+		for _, value := range gjson.GetManyBytes(e.DocBytes, fields...) {
+			if len(value.String()) > 0 {
+				subResources = append(subResources, value.String())
+			}
+		}
+
+		if len(subResources) > 0 {
+			chainNode.SubResource = subResources
+		}
+		return chainNode, detail, retry, nil
+	}
+}
+
+// parseModelEvent parse model metadata event, the bk_obj_id of the model(and the bk_asst_obj_id for model
+// association) is used as the sub resource
+var parseModelEvent = newSubResourceEventParser(common.BKObjIDField, common.BKAsstObjIDField)
+
+// parseBizResourceEvent parse business scoped resource event like templates, the bk_biz_id is used as the sub resource
+var parseBizResourceEvent = newSubResourceEventParser(common.BKAppIDField)
+
 // parseInstAsstEvent parse instance association event into db chain nodes to store in db and details to store in redis
 func parseInstAsstEvent(db dal.DB, key event.Key, e *types.Event, oidDetailMap map[oidCollKey][]byte, id uint64,
 	rid string) (*watch.ChainNode, *eventDetail, bool, error) {
@@ -186,6 +235,48 @@ func parseInstAsstEvent(db dal.DB, key event.Key, e *types.Event, oidDetailMap m
 	return chainNode, &eventDetail{eventInfo: detailBytes, resDetail: e.DocBytes}, false, nil
 }
 
+// parseDynamicGroupMemberEvent parse dynamic group member event into db chain nodes to store in db and details to
+// store in redis, the dynamic group id is used as the sub resource so that the members of one group can be watched
+func parseDynamicGroupMemberEvent(db dal.DB, key event.Key, e *types.Event, oidDetailMap map[oidCollKey][]byte,
+	id uint64, rid string) (*watch.ChainNode, *eventDetail, bool, error) {
+
+	switch e.OperationType {
+	case types.Insert:
+		if err := key.Validate(e.DocBytes); err != nil {
+			blog.Errorf("run flow, received %s event, but got invalid event, doc: %s, oid: %s, err: %v, rid: %s",
+				key.Collection(), e.DocBytes, e.Oid, err, rid)
+			return nil, nil, false, nil
+		}
+	case types.Delete:
+		doc, exist := oidDetailMap[oidCollKey{oid: e.Oid, coll: e.Collection}]
+		if !exist {
+			blog.Errorf("%s event delete doc[oid: %s] detail not exists, rid: %s", key.Collection(), e.Oid, rid)
+			return nil, nil, false, nil
+		}
+		// update delete event detail doc bytes from del archive
+		e.DocBytes = doc
+
+		if err := key.Validate(doc); err != nil {
+			blog.Errorf("run flow, received %s event, but got invalid event, doc: %s, oid: %s, err: %v, rid: %s",
+				key.Collection(), e.DocBytes, e.Oid, err, rid)
+			return nil, nil, false, nil
+		}
+
+	// dynamic group member is only inserted when joining the group and deleted when leaving the group, skip others
+	default:
+		blog.Errorf("loop flow, received invalid event op type: %s, doc: %s, rid: %s", e.OperationType, e.DocBytes, rid)
+		return nil, nil, false, nil
+	}
+
+	chainNode, detail, retry, err := parseEventToNodeAndDetail(key, e, id, rid)
+	if err != nil {
+		return nil, nil, retry, err
+	}
+
+	chainNode.SubResource = []string{gjson.GetBytes(e.DocBytes, common.BKDynamicGroupIDField).String()}
+	return chainNode, detail, false, nil
+}
+
 // parseEventToNodeAndDetail parse validated event into db chain nodes to store in db and details to store in redis
 func parseEventToNodeAndDetail(key event.Key, e *types.Event, id uint64, rid string) (*watch.ChainNode, *eventDetail,
 	bool, error) {
@@ -0,0 +1,153 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package flow
+
+import (
+	"testing"
+
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+type subResourceParseCase struct {
+	name        string
+	key         event.Key
+	operation   types.OperType
+	doc         string
+	subResource []string
+	dropped     bool
+}
+
+// testSubResourceParse checks the sub resources of the chain nodes parsed from the events, the doc of delete event
+// is the archived doc in oidDetailMap
+func testSubResourceParse(t *testing.T, parse parseEventFunc, cases []subResourceParseCase) {
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := &types.Event{
+				Oid:           "5eb385974770a118f4922abe",
+				OperationType: c.operation,
+				Collection:    c.key.Collection(),
+				ClusterTime:   types.TimeStamp{Sec: uint32(1588853652)},
+				ChangeDesc:    new(types.ChangeDescription),
+			}
+
+			oidDetailMap := make(map[oidCollKey][]byte)
+			if c.operation == types.Delete {
+				oidDetailMap[oidCollKey{oid: e.Oid, coll: e.Collection}] = []byte(c.doc)
+			} else {
+				e.DocBytes = []byte(c.doc)
+			}
+
+			chainNode, detail, retry, err := parse(nil, c.key, e, oidDetailMap, 10, "rid")
+			require.NoError(t, err)
+			require.False(t, retry)
+			if c.dropped {
+				require.Nil(t, chainNode)
+				return
+			}
+
+			require.NotNil(t, chainNode)
+			require.Equal(t, uint64(10), chainNode.ID)
+			require.Equal(t, int64(1), chainNode.InstanceID)
+			require.Equal(t, watch.ConvertOperateType(c.operation), chainNode.EventType)
+			require.Equal(t, c.subResource, chainNode.SubResource)
+			require.Equal(t, c.doc, string(detail.resDetail))
+		})
+	}
+}
+
+func TestParseModelEvent(t *testing.T) {
+	testSubResourceParse(t, parseModelEvent, []subResourceParseCase{
+		{
+			name:        "object attribute uses bk_obj_id",
+			key:         event.ObjectAttributeKey,
+			operation:   types.Insert,
+			doc:         `{"id":1,"bk_obj_id":"switch","bk_property_id":"vendor"}`,
+			subResource: []string{"switch"},
+		},
+		{
+			name:        "deleted model uses bk_obj_id of archived doc",
+			key:         event.ObjectKey,
+			operation:   types.Delete,
+			doc:         `{"id":1,"bk_obj_id":"switch"}`,
+			subResource: []string{"switch"},
+		},
+		{
+			name:        "model association uses bk_obj_id and bk_asst_obj_id",
+			key:         event.ModelAssociationKey,
+			operation:   types.Update,
+			doc:         `{"id":1,"bk_obj_id":"switch","bk_asst_obj_id":"host","bk_obj_asst_id":"switch_connect_host"}`,
+			subResource: []string{"switch", "host"},
+		},
+		{
+			name:      "classification has no sub resource",
+			key:       event.ObjectClassificationKey,
+			operation: types.Insert,
+			doc:       `{"id":1,"bk_classification_id":"network"}`,
+		},
+		{
+			name:      "invalid doc without bk_obj_id is dropped",
+			key:       event.ObjectUniqueKey,
+			operation: types.Insert,
+			doc:       `{"id":1}`,
+			dropped:   true,
+		},
+	})
+}
+
+func TestParseBizResourceEvent(t *testing.T) {
+	testSubResourceParse(t, parseBizResourceEvent, []subResourceParseCase{
+		{
+			name:        "service instance uses bk_biz_id",
+			key:         event.ServiceInstanceKey,
+			operation:   types.Insert,
+			doc:         `{"id":1,"bk_biz_id":2,"name":"127.0.0.1_nginx_80","bk_module_id":3}`,
+			subResource: []string{"2"},
+		},
+		{
+			name:        "deleted service instance uses bk_biz_id of archived doc",
+			key:         event.ServiceInstanceKey,
+			operation:   types.Delete,
+			doc:         `{"id":1,"bk_biz_id":2,"name":"127.0.0.1_nginx_80","bk_module_id":3,"bk_host_id":4}`,
+			subResource: []string{"2"},
+		},
+		{
+			name:        "process template uses bk_biz_id",
+			key:         event.ProcessTemplateKey,
+			operation:   types.Update,
+			doc:         `{"id":1,"bk_biz_id":2,"service_template_id":3,"bk_process_name":"nginx"}`,
+			subResource: []string{"2"},
+		},
+		{
+			name:        "host apply rule uses bk_biz_id",
+			key:         event.HostApplyRuleKey,
+			operation:   types.Replace,
+			doc:         `{"id":1,"bk_biz_id":2,"bk_module_id":3}`,
+			subResource: []string{"2"},
+		},
+		{
+			name:      "invalid doc without bk_biz_id is dropped",
+			key:       event.SetTemplateKey,
+			operation: types.Insert,
+			doc:       `{"id":1,"name":"gamesvr"}`,
+			dropped:   true,
+		},
+	})
+}
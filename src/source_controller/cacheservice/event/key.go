@@ -490,6 +490,62 @@ var KubePodKey = Key{
 	},
 }
 
+// KubeServiceKey kube service event watch key
+var KubeServiceKey = Key{
+	namespace:  watchCacheNamespace + "kube_service",
+	collection: kubetypes.BKTableNameBaseService,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(kubeFields...),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// KubeIngressKey kube ingress event watch key
+var KubeIngressKey = Key{
+	namespace:  watchCacheNamespace + "kube_ingress",
+	collection: kubetypes.BKTableNameBaseIngress,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(kubeFields...),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// KubeConfigMapKey kube configmap event watch key
+var KubeConfigMapKey = Key{
+	namespace:  watchCacheNamespace + "kube_configmap",
+	collection: kubetypes.BKTableNameBaseConfigMap,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(kubeFields...),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// KubeSecretKey kube secret event watch key
+var KubeSecretKey = Key{
+	namespace:  watchCacheNamespace + "kube_secret",
+	collection: kubetypes.BKTableNameBaseSecret,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(kubeFields...),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
 var projectFields = []string{common.BKFieldID, common.BKProjectNameField}
 
 // ProjectKey project event watch key
@@ -515,6 +571,205 @@ var ProjectKey = Key{
 	},
 }
 
+var dynamicGroupMemberFields = []string{common.BKDynamicGroupIDField, common.BKInstIDField}
+
+// DynamicGroupMemberKey materialized dynamic group member event watch key
+var DynamicGroupMemberKey = Key{
+	namespace:  watchCacheNamespace + "dynamic_group_member",
+	collection: common.BKTableNameDynamicGroupMember,
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, dynamicGroupMemberFields...)
+		for idx := range dynamicGroupMemberFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", dynamicGroupMemberFields[idx])
+			}
+		}
+		return nil
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKInstIDField).Int()
+	},
+}
+
+// model metadata related event watch keys, their events are not general resources, so event details are stored by
+// DetailKey, and bk_obj_id is used as the sub resource of the events except for association kind and classification
+
+// ObjectKey model definition event watch key
+var ObjectKey = Key{
+	namespace:  watchCacheNamespace + "object",
+	collection: common.BKTableNameObjDes,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.BKObjIDField),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKObjNameField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// ObjectAttributeKey model attribute event watch key
+var ObjectAttributeKey = Key{
+	namespace:  watchCacheNamespace + "object_attribute",
+	collection: common.BKTableNameObjAttDes,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.BKObjIDField, common.BKPropertyIDField),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKPropertyNameField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// ObjectAttributeGroupKey model attribute group event watch key
+var ObjectAttributeGroupKey = Key{
+	namespace:  watchCacheNamespace + "object_attribute_group",
+	collection: common.BKTableNamePropertyGroup,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.BKObjIDField, common.BKPropertyGroupIDField),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKPropertyGroupNameField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// ObjectUniqueKey model unique rule event watch key
+var ObjectUniqueKey = Key{
+	namespace:  watchCacheNamespace + "object_unique",
+	collection: common.BKTableNameObjUnique,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.BKObjIDField),
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// ModelAssociationKey model association event watch key
+var ModelAssociationKey = Key{
+	namespace:  watchCacheNamespace + "model_association",
+	collection: common.BKTableNameObjAsst,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.BKObjIDField, common.BKAsstObjIDField),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.AssociationObjAsstIDField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// AssociationKindKey association kind event watch key
+var AssociationKindKey = Key{
+	namespace:  watchCacheNamespace + "association_kind",
+	collection: common.BKTableNameAsstDes,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.AssociationKindIDField),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.AssociationKindNameField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// ObjectClassificationKey model classification event watch key
+var ObjectClassificationKey = Key{
+	namespace:  watchCacheNamespace + "object_classification",
+	collection: common.BKTableNameObjClassification,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.BKClassificationIDField),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKClassificationNameField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// ServiceInstanceKey service instance event watch key
+var ServiceInstanceKey = Key{
+	namespace:  watchCacheNamespace + "service_instance",
+	collection: common.BKTableNameServiceInstance,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.BKAppIDField),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// ServiceTemplateKey service template event watch key
+var ServiceTemplateKey = Key{
+	namespace:  watchCacheNamespace + "service_template",
+	collection: common.BKTableNameServiceTemplate,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.BKAppIDField),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// ProcessTemplateKey process template event watch key
+var ProcessTemplateKey = Key{
+	namespace:  watchCacheNamespace + "process_template",
+	collection: common.BKTableNameProcessTemplate,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.BKAppIDField, common.BKServiceTemplateIDField),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKProcessNameField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// SetTemplateKey set template event watch key
+var SetTemplateKey = Key{
+	namespace:  watchCacheNamespace + "set_template",
+	collection: common.BKTableNameSetTemplate,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.BKAppIDField),
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// HostApplyRuleKey host apply rule event watch key
+var HostApplyRuleKey = Key{
+	namespace:  watchCacheNamespace + "host_apply_rule",
+	collection: common.BKTableNameHostApplyRule,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  newFieldsValidator(common.BKFieldID, common.BKAppIDField),
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// newFieldsValidator returns the validator that checks if all the fields exist in the event doc
+func newFieldsValidator(fields ...string) func(doc []byte) error {
+	return func(doc []byte) error {
+		values := gjson.GetManyBytes(doc, fields...)
+		for idx := range fields {
+			if !values[idx].Exists() {
+				return fmt.Errorf("field %s not exist", fields[idx])
+			}
+		}
+		return nil
+	}
+}
+
 // Key TODO
 type Key struct {
 	namespace string
@@ -0,0 +1,184 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package event
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/util/table"
+	"configcenter/src/common/watch"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type keyValidateCase struct {
+	cursorType     watch.CursorType
+	key            Key
+	doc            map[string]interface{}
+	requiredFields []string
+	name           string
+}
+
+// testKeyValidate checks that the key of the cursor type accepts the valid doc and rejects the doc without any one
+// of the required fields, and that the name and id are parsed from the doc
+func testKeyValidate(t *testing.T, cases []keyValidateCase) {
+	for _, c := range cases {
+		t.Run(string(c.cursorType), func(t *testing.T) {
+			key, err := GetResourceKeyWithCursorType(c.cursorType)
+			require.NoError(t, err)
+			require.Equal(t, c.key.Collection(), key.Collection())
+
+			doc, err := json.Marshal(c.doc)
+			require.NoError(t, err)
+			require.NoError(t, key.Validate(doc))
+			require.Equal(t, c.name, key.Name(doc))
+			require.Equal(t, int64(1), key.InstanceID(doc))
+
+			for _, field := range c.requiredFields {
+				invalid := make(map[string]interface{})
+				for k, v := range c.doc {
+					if k != field {
+						invalid[k] = v
+					}
+				}
+
+				invalidDoc, err := json.Marshal(invalid)
+				require.NoError(t, err)
+				require.Error(t, key.Validate(invalidDoc), "doc without %s should be invalid", field)
+			}
+		})
+	}
+}
+
+func TestModelMetadataKeyValidate(t *testing.T) {
+	testKeyValidate(t, []keyValidateCase{
+		{
+			cursorType:     watch.Object,
+			key:            ObjectKey,
+			doc:            map[string]interface{}{"id": 1, "bk_obj_id": "switch", "bk_obj_name": "Switch"},
+			requiredFields: []string{common.BKFieldID, common.BKObjIDField},
+			name:           "Switch",
+		},
+		{
+			cursorType: watch.ObjectAttribute,
+			key:        ObjectAttributeKey,
+			doc: map[string]interface{}{"id": 1, "bk_obj_id": "switch", "bk_property_id": "vendor",
+				"bk_property_name": "Vendor"},
+			requiredFields: []string{common.BKFieldID, common.BKObjIDField, common.BKPropertyIDField},
+			name:           "Vendor",
+		},
+		{
+			cursorType: watch.ObjectAttributeGroup,
+			key:        ObjectAttributeGroupKey,
+			doc: map[string]interface{}{"id": 1, "bk_obj_id": "switch", "bk_group_id": "default",
+				"bk_group_name": "Default"},
+			requiredFields: []string{common.BKFieldID, common.BKObjIDField, common.BKPropertyGroupIDField},
+			name:           "Default",
+		},
+		{
+			cursorType:     watch.ObjectUnique,
+			key:            ObjectUniqueKey,
+			doc:            map[string]interface{}{"id": 1, "bk_obj_id": "switch"},
+			requiredFields: []string{common.BKFieldID, common.BKObjIDField},
+		},
+		{
+			cursorType: watch.ModelAssociation,
+			key:        ModelAssociationKey,
+			doc: map[string]interface{}{"id": 1, "bk_obj_id": "switch", "bk_asst_obj_id": "host",
+				"bk_obj_asst_id": "switch_connect_host"},
+			requiredFields: []string{common.BKFieldID, common.BKObjIDField, common.BKAsstObjIDField},
+			name:           "switch_connect_host",
+		},
+		{
+			cursorType: watch.AssociationKind,
+			key:        AssociationKindKey,
+			doc: map[string]interface{}{"id": 1, "bk_asst_id": "connect",
+				"bk_asst_name": "Connect"},
+			requiredFields: []string{common.BKFieldID, common.AssociationKindIDField},
+			name:           "Connect",
+		},
+		{
+			cursorType: watch.ObjectClassification,
+			key:        ObjectClassificationKey,
+			doc: map[string]interface{}{"id": 1, "bk_classification_id": "network",
+				"bk_classification_name": "Network"},
+			requiredFields: []string{common.BKFieldID, common.BKClassificationIDField},
+			name:           "Network",
+		},
+	})
+}
+
+func TestTemplateKeyValidate(t *testing.T) {
+	testKeyValidate(t, []keyValidateCase{
+		{
+			cursorType: watch.ServiceInstance,
+			key:        ServiceInstanceKey,
+			doc: map[string]interface{}{"id": 1, "bk_biz_id": 2, "name": "127.0.0.1_nginx_80",
+				"bk_module_id": 3},
+			requiredFields: []string{common.BKFieldID, common.BKAppIDField},
+			name:           "127.0.0.1_nginx_80",
+		},
+		{
+			cursorType:     watch.ServiceTemplate,
+			key:            ServiceTemplateKey,
+			doc:            map[string]interface{}{"id": 1, "bk_biz_id": 2, "name": "nginx"},
+			requiredFields: []string{common.BKFieldID, common.BKAppIDField},
+			name:           "nginx",
+		},
+		{
+			cursorType: watch.ProcessTemplate,
+			key:        ProcessTemplateKey,
+			doc: map[string]interface{}{"id": 1, "bk_biz_id": 2, "service_template_id": 3,
+				"bk_process_name": "nginx"},
+			requiredFields: []string{common.BKFieldID, common.BKAppIDField, common.BKServiceTemplateIDField},
+			name:           "nginx",
+		},
+		{
+			cursorType:     watch.SetTemplate,
+			key:            SetTemplateKey,
+			doc:            map[string]interface{}{"id": 1, "bk_biz_id": 2, "name": "gamesvr"},
+			requiredFields: []string{common.BKFieldID, common.BKAppIDField},
+			name:           "gamesvr",
+		},
+		{
+			cursorType:     watch.HostApplyRule,
+			key:            HostApplyRuleKey,
+			doc:            map[string]interface{}{"id": 1, "bk_biz_id": 2, "bk_module_id": 3},
+			requiredFields: []string{common.BKFieldID, common.BKAppIDField},
+		},
+	})
+}
+
+func TestServiceInstanceDelArchiveFields(t *testing.T) {
+	// the deleted service instance only archives the specified fields, which must be enough for the delete event
+	fields := table.GetDelArchiveFields(common.BKTableNameServiceInstance)
+	doc := make(map[string]interface{})
+	for _, field := range fields {
+		doc[field] = 1
+	}
+	doc[common.BKFieldName] = "127.0.0.1_nginx_80"
+
+	docBytes, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.NoError(t, ServiceInstanceKey.Validate(docBytes))
+	require.Equal(t, "127.0.0.1_nginx_80", ServiceInstanceKey.Name(docBytes))
+	require.Equal(t, int64(1), ServiceInstanceKey.InstanceID(docBytes))
+	require.Equal(t, "1", gjson.GetBytes(docBytes, common.BKAppIDField).String())
+}
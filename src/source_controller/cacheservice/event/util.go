@@ -48,6 +48,23 @@ var resourceKeyMap = map[watch.CursorType]Key{
 	watch.KubeWorkload:            KubeWorkloadKey,
 	watch.KubePod:                 KubePodKey,
 	watch.Project:                 ProjectKey,
+	watch.DynamicGroupMember:      DynamicGroupMemberKey,
+	watch.Object:                  ObjectKey,
+	watch.ObjectAttribute:         ObjectAttributeKey,
+	watch.ObjectAttributeGroup:    ObjectAttributeGroupKey,
+	watch.ObjectUnique:            ObjectUniqueKey,
+	watch.ModelAssociation:        ModelAssociationKey,
+	watch.AssociationKind:         AssociationKindKey,
+	watch.ObjectClassification:    ObjectClassificationKey,
+	watch.ServiceInstance:         ServiceInstanceKey,
+	watch.ServiceTemplate:         ServiceTemplateKey,
+	watch.ProcessTemplate:         ProcessTemplateKey,
+	watch.SetTemplate:             SetTemplateKey,
+	watch.HostApplyRule:           HostApplyRuleKey,
+	watch.KubeService:             KubeServiceKey,
+	watch.KubeIngress:             KubeIngressKey,
+	watch.KubeConfigMap:           KubeConfigMapKey,
+	watch.KubeSecret:              KubeSecretKey,
 }
 
 // GetResourceKeyWithCursorType get resource key
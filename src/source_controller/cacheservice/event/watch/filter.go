@@ -0,0 +1,214 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package watch
+
+import (
+	"fmt"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/stream/types"
+)
+
+// filterEventDetails filter the event details by the content filter in watch options, then cut the matched details
+// with the needed fields. if no event is matched, returns the last node's cursor without detail, so that user can
+// watch from this node continually.
+func (c *Client) filterEventDetails(kit *rest.Kit, opts *watch.WatchEventOptions, hitNodes []*watch.ChainNode,
+	details []*watch.WatchEventDetail, key event.Key) ([]*watch.WatchEventDetail, error) {
+
+	if len(hitNodes) == 0 {
+		return details, nil
+	}
+
+	eventInfos, err := c.searchEventInfosFromRedis(kit, opts.Filter, hitNodes, key)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*watch.WatchEventDetail, 0)
+	for _, detail := range details {
+		jsonDetail, ok := detail.Detail.(watch.JsonString)
+		if !ok {
+			continue
+		}
+
+		jsonStr := string(jsonDetail)
+		if !isEventHitContentFilter(kit, opts.Filter, detail.EventType, detail.Cursor, jsonStr, eventInfos) {
+			continue
+		}
+
+		if isDetailCutByFields(opts.Resource) {
+			detail.Detail = watch.JsonString(*json.CutJsonDataWithFields(&jsonStr, opts.Fields))
+		}
+		matched = append(matched, detail)
+	}
+
+	if len(matched) == 0 {
+		lastNode := hitNodes[len(hitNodes)-1]
+		return []*watch.WatchEventDetail{{
+			Cursor:   lastNode.Cursor,
+			Resource: opts.Resource,
+			Detail:   nil,
+		}}, nil
+	}
+
+	return matched, nil
+}
+
+// getEventDetailWithFilter get event detail with the needed fields by chain node, returns if the event matches the
+// content filter in watch options at the same time
+func (c *Client) getEventDetailWithFilter(kit *rest.Kit, opts *watch.WatchEventOptions, node *watch.ChainNode,
+	key event.Key) (*string, bool, bool, error) {
+
+	if !opts.Filter.HasContentFilter() {
+		detail, exists, err := c.getEventDetail(kit, node, opts.Fields, key)
+		return detail, exists, true, err
+	}
+
+	// get the whole event detail to match the content filter, the needed fields are cut after matching
+	detail, exists, err := c.getEventDetail(kit, node, nil, key)
+	if err != nil || !exists {
+		return detail, exists, false, err
+	}
+
+	if detail == nil || len(*detail) == 0 {
+		return detail, exists, false, nil
+	}
+
+	eventInfos, err := c.searchEventInfosFromRedis(kit, opts.Filter, []*watch.ChainNode{node}, key)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	if !isEventHitContentFilter(kit, opts.Filter, node.EventType, node.Cursor, *detail, eventInfos) {
+		return detail, exists, false, nil
+	}
+
+	if isDetailCutByFields(opts.Resource) {
+		detail = json.CutJsonDataWithFields(detail, opts.Fields)
+	}
+	return detail, exists, true, nil
+}
+
+// searchEventInfosFromRedis get the changed fields info of the update events by cursors from redis, it is only
+// needed when the updated fields filter is set. returns the map of cursor to event info, update events whose event
+// info is expired are not in the map.
+func (c *Client) searchEventInfosFromRedis(kit *rest.Kit, opt watch.WatchEventFilter, nodes []*watch.ChainNode,
+	key event.Key) (map[string]*types.EventInfo, error) {
+
+	eventInfos := make(map[string]*types.EventInfo)
+	if len(opt.UpdatedFields) == 0 {
+		return eventInfos, nil
+	}
+
+	cursors := make([]string, 0)
+	detailKeys := make([]string, 0)
+	for _, node := range nodes {
+		if node.EventType != watch.Update {
+			continue
+		}
+		cursors = append(cursors, node.Cursor)
+		detailKeys = append(detailKeys, key.DetailKey(node.Cursor))
+	}
+
+	if len(detailKeys) == 0 {
+		return eventInfos, nil
+	}
+
+	results, err := c.cache.MGet(kit.Ctx, detailKeys...).Result()
+	if err != nil {
+		blog.Errorf("search event infos by keys(%+v) failed, err: %v, rid: %s", detailKeys, err, kit.Rid)
+		return nil, fmt.Errorf("search event infos by keys(%+v) failed, err: %v", detailKeys, err)
+	}
+
+	for index, result := range results {
+		resultStr, ok := result.(string)
+		if !ok || resultStr == "" {
+			blog.V(4).Infof("event info for cursor(%s) do not exist in redis, rid: %s", cursors[index], kit.Rid)
+			continue
+		}
+
+		// the event info of general resource is stored separately, others are stored with the detail, both of them
+		// have the changed fields in the first level, so they can be parsed in the same way.
+		eventInfo := new(types.EventInfo)
+		if err := json.UnmarshalFromString(resultStr, eventInfo); err != nil {
+			blog.Errorf("unmarshal event info(%s) failed, err: %v, rid: %s", resultStr, err, kit.Rid)
+			continue
+		}
+		eventInfos[cursors[index]] = eventInfo
+	}
+
+	return eventInfos, nil
+}
+
+// isEventHitContentFilter check if the event hit the content filter, not specifying content filter means matching all
+func isEventHitContentFilter(kit *rest.Kit, opt watch.WatchEventFilter, eventType watch.EventType, cursor string,
+	detail string, eventInfos map[string]*types.EventInfo) bool {
+
+	if len(opt.UpdatedFields) > 0 && eventType == watch.Update {
+		eventInfo, exists := eventInfos[cursor]
+		if exists && !isEventHitUpdatedFields(eventInfo, opt.UpdatedFields) {
+			return false
+		}
+	}
+
+	if opt.Expression == nil {
+		return true
+	}
+
+	if len(detail) == 0 {
+		return false
+	}
+
+	matched, err := opt.Expression.Match(filter.JsonString(detail))
+	if err != nil {
+		blog.V(4).Infof("event detail(%s) do not match expression(%s), err: %v, rid: %s", detail,
+			opt.Expression.String(), err, kit.Rid)
+		return false
+	}
+	return matched
+}
+
+// isEventHitUpdatedFields check if the update event updated or removed one of the updated fields
+func isEventHitUpdatedFields(eventInfo *types.EventInfo, updatedFields []string) bool {
+	for _, field := range updatedFields {
+		if _, exists := eventInfo.UpdatedFields[field]; exists {
+			return true
+		}
+
+		for _, removedField := range eventInfo.RemovedFields {
+			if removedField == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isDetailCutByFields returns if the event detail of the resource is cut with the needed fields, host identifier
+// and biz set relation events always return the whole detail
+func isDetailCutByFields(resource watch.CursorType) bool {
+	switch resource {
+	case watch.HostIdentifier, watch.BizSetRelation:
+		return false
+	}
+	return true
+}
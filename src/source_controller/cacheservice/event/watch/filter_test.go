@@ -0,0 +1,168 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package watch
+
+import (
+	"testing"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/watch"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsEventHitUpdatedFields(t *testing.T) {
+	testCases := []struct {
+		name          string
+		eventInfo     *types.EventInfo
+		updatedFields []string
+		expect        bool
+	}{
+		{
+			name:          "updated field",
+			eventInfo:     &types.EventInfo{UpdatedFields: map[string]interface{}{"bk_host_name": "a"}},
+			updatedFields: []string{"bk_os_type", "bk_host_name"},
+			expect:        true,
+		},
+		{
+			name:          "removed field",
+			eventInfo:     &types.EventInfo{RemovedFields: []string{"bk_os_type"}},
+			updatedFields: []string{"bk_os_type"},
+			expect:        true,
+		},
+		{
+			name: "other fields changed",
+			eventInfo: &types.EventInfo{UpdatedFields: map[string]interface{}{"bk_host_name": "a"},
+				RemovedFields: []string{"bk_comment"}},
+			updatedFields: []string{"bk_os_type"},
+			expect:        false,
+		},
+		{
+			name:          "no changed fields",
+			eventInfo:     &types.EventInfo{},
+			updatedFields: []string{"bk_os_type"},
+			expect:        false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expect, isEventHitUpdatedFields(tc.eventInfo, tc.updatedFields))
+		})
+	}
+}
+
+func TestIsEventHitContentFilter(t *testing.T) {
+	expr := &filter.Expression{RuleFactory: &filter.AtomRule{Field: "bk_os_type", Operator: filter.Equal.Factory(),
+		Value: "1"}}
+	eventInfos := map[string]*types.EventInfo{
+		"name": {UpdatedFields: map[string]interface{}{"bk_host_name": "a"}},
+		"os":   {UpdatedFields: map[string]interface{}{"bk_os_type": "1"}},
+	}
+
+	testCases := []struct {
+		name      string
+		opt       watch.WatchEventFilter
+		eventType watch.EventType
+		cursor    string
+		detail    string
+		expect    bool
+	}{
+		{
+			name:      "no content filter",
+			eventType: watch.Create,
+			detail:    `{"bk_os_type":"2"}`,
+			expect:    true,
+		},
+		{
+			name:      "create event matches expression",
+			opt:       watch.WatchEventFilter{Expression: expr, UpdatedFields: []string{"bk_os_type"}},
+			eventType: watch.Create,
+			detail:    `{"bk_os_type":"1"}`,
+			expect:    true,
+		},
+		{
+			name:      "create event does not match expression",
+			opt:       watch.WatchEventFilter{Expression: expr},
+			eventType: watch.Create,
+			detail:    `{"bk_os_type":"2"}`,
+			expect:    false,
+		},
+		{
+			name:      "update event hits updated fields and expression",
+			opt:       watch.WatchEventFilter{Expression: expr, UpdatedFields: []string{"bk_os_type"}},
+			eventType: watch.Update,
+			cursor:    "os",
+			detail:    `{"bk_os_type":"1"}`,
+			expect:    true,
+		},
+		{
+			name:      "update event misses updated fields",
+			opt:       watch.WatchEventFilter{UpdatedFields: []string{"bk_os_type"}},
+			eventType: watch.Update,
+			cursor:    "name",
+			detail:    `{"bk_os_type":"1"}`,
+			expect:    false,
+		},
+		{
+			name:      "update event with expired changed fields",
+			opt:       watch.WatchEventFilter{UpdatedFields: []string{"bk_os_type"}},
+			eventType: watch.Update,
+			cursor:    "expired",
+			detail:    `{"bk_os_type":"1"}`,
+			expect:    true,
+		},
+		{
+			name:      "delete event is not filtered by updated fields",
+			opt:       watch.WatchEventFilter{Expression: expr, UpdatedFields: []string{"bk_os_type"}},
+			eventType: watch.Delete,
+			cursor:    "name",
+			detail:    `{"bk_os_type":"1"}`,
+			expect:    true,
+		},
+		{
+			name:      "delete event does not match expression",
+			opt:       watch.WatchEventFilter{Expression: expr},
+			eventType: watch.Delete,
+			detail:    `{"bk_os_type":"2"}`,
+			expect:    false,
+		},
+		{
+			name:      "detail misses expression field",
+			opt:       watch.WatchEventFilter{Expression: expr},
+			eventType: watch.Create,
+			detail:    `{"bk_host_name":"a"}`,
+			expect:    false,
+		},
+		{
+			name:      "empty detail",
+			opt:       watch.WatchEventFilter{Expression: expr},
+			eventType: watch.Delete,
+			expect:    false,
+		},
+	}
+
+	kit := &rest.Kit{Rid: "test"}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expect, isEventHitContentFilter(kit, tc.opt, tc.eventType, tc.cursor, tc.detail,
+				eventInfos))
+		})
+	}
+}
@@ -96,7 +96,7 @@ func (c *Client) WatchWithStartFrom(kit *rest.Kit, key event.Key, opts *watch.Wa
 			}}, nil
 		}
 
-		detail, exists, err := c.getEventDetail(kit, tailNode, opts.Fields, key)
+		detail, exists, hit, err := c.getEventDetailWithFilter(kit, opts, tailNode, key)
 		if err != nil {
 			blog.Errorf("get latest event detail failed, err: %v, rid: %s", err, rid)
 			return nil, err
@@ -106,6 +106,16 @@ func (c *Client) WatchWithStartFrom(kit *rest.Kit, key event.Key, opts *watch.Wa
 			return nil, kit.CCError.CCError(common.CCErrEventDetailNotExist)
 		}
 
+		if !hit {
+			// not matched the content filter, set to no event cursor with empty detail
+			return []*watch.WatchEventDetail{{
+				Cursor:    watch.NoEventCursor,
+				Resource:  opts.Resource,
+				EventType: "",
+				Detail:    nil,
+			}}, nil
+		}
+
 		event := &watch.WatchEventDetail{
 			Cursor:    tailNode.Cursor,
 			Resource:  opts.Resource,
@@ -188,10 +198,26 @@ func (c *Client) WatchWithStartFrom(kit *rest.Kit, key event.Key, opts *watch.Wa
 	return c.getEventDetailsWithNodes(kit, opts, nodes, key)
 }
 
-// getEventDetailsWithNodes get event details with nodes, first get from redis, then get failed ones from mongo
+// getEventDetailsWithNodes get event details with nodes, the events that do not match the content filter are dropped
 func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventOptions, hitNodes []*watch.ChainNode,
-	key event.Key) (
-	[]*watch.WatchEventDetail, error) {
+	key event.Key) ([]*watch.WatchEventDetail, error) {
+
+	if !opts.Filter.HasContentFilter() {
+		return c.searchEventDetailsWithNodes(kit, opts, hitNodes, opts.Fields, key)
+	}
+
+	// get the whole event details to match the content filter, the needed fields are cut after matching
+	details, err := c.searchEventDetailsWithNodes(kit, opts, hitNodes, nil, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.filterEventDetails(kit, opts, hitNodes, details, key)
+}
+
+// searchEventDetailsWithNodes search event details with nodes, first get from redis, then get failed ones from mongo
+func (c *Client) searchEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventOptions,
+	hitNodes []*watch.ChainNode, fields []string, key event.Key) ([]*watch.WatchEventDetail, error) {
 
 	if len(hitNodes) == 0 {
 		return make([]*watch.WatchEventDetail, 0), nil
@@ -215,7 +241,7 @@ func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventO
 	if len(errNodes) == 0 {
 		resp := make([]*watch.WatchEventDetail, len(details))
 		for idx, detail := range details {
-			detail = *json.CutJsonDataWithFields(&detail, opts.Fields)
+			detail = *json.CutJsonDataWithFields(&detail, fields)
 			resp[idx] = &watch.WatchEventDetail{
 				Cursor:    hitNodes[idx].Cursor,
 				Resource:  opts.Resource,
@@ -227,7 +253,7 @@ func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventO
 		return resp, nil
 	}
 
-	indexDetailMap, err := c.searchEventDetailsFromMongo(kit, errNodes, opts.Fields, errCursorIndexMap, key)
+	indexDetailMap, err := c.searchEventDetailsFromMongo(kit, errNodes, fields, errCursorIndexMap, key)
 	if err != nil {
 		blog.Errorf("get details from mongo failed, err: %v, cursors: %+v, rid: %s", err, errNodes, kit.Rid)
 		return nil, err
@@ -243,7 +269,7 @@ func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventO
 			if !key.IsGeneralRes() {
 				jsonStr = types.GetEventDetail(&detail)
 			}
-			detail = *json.CutJsonDataWithFields(jsonStr, opts.Fields)
+			detail = *json.CutJsonDataWithFields(jsonStr, fields)
 		}
 
 		resp[idx] = &watch.WatchEventDetail{
@@ -538,7 +564,7 @@ func (c *Client) WatchFromNow(kit *rest.Kit, key event.Key, opts *watch.WatchEve
 		}, nil
 	}
 
-	detail, exists, err := c.getEventDetail(kit, node, opts.Fields, key)
+	detail, exists, hit, err := c.getEventDetailWithFilter(kit, opts, node, key)
 	if err != nil {
 		blog.Errorf("watch from now, but get latest event detail failed, err: %v, rid: %s", err, rid)
 		return nil, err
@@ -548,6 +574,16 @@ func (c *Client) WatchFromNow(kit *rest.Kit, key event.Key, opts *watch.WatchEve
 		return nil, kit.CCError.CCError(common.CCErrEventDetailNotExist)
 	}
 
+	if !hit {
+		// not matched the content filter, set to no event cursor with empty detail
+		return &watch.WatchEventDetail{
+			Cursor:    watch.NoEventCursor,
+			Resource:  opts.Resource,
+			EventType: "",
+			Detail:    nil,
+		}, nil
+	}
+
 	e := &watch.WatchEventDetail{
 		Cursor:    node.Cursor,
 		Resource:  opts.Resource,
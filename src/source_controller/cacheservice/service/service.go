@@ -38,6 +38,7 @@ import (
 	"configcenter/src/source_controller/cacheservice/cache"
 	cacheop "configcenter/src/source_controller/cacheservice/cache"
 	"configcenter/src/source_controller/cacheservice/event/bsrelation"
+	"configcenter/src/source_controller/cacheservice/event/dgmember"
 	"configcenter/src/source_controller/cacheservice/event/flow"
 	"configcenter/src/source_controller/cacheservice/event/identifier"
 	"configcenter/src/source_controller/coreservice/core"
@@ -150,9 +151,27 @@ func (s *cacheService) SetConfig(cfg options.Config, engine *backbone.Engine, er
 		return err
 	}
 
+	if err := dgmember.NewDynamicGroupMember(watcher, engine.ServiceManageInterface, watchDB, ccDB); err != nil {
+		blog.Errorf("new dynamic group member event failed, err: %v", err)
+		return err
+	}
+
 	if err := audit.RunAuditDataReporting(cfg.Audit, loopW); err != nil {
 		return err
 	}
+
+	if err := audit.RunAuditChainSealing(loopW); err != nil {
+		blog.Errorf("run audit log hash chain sealing failed, err: %v", err)
+		return err
+	}
+
+	if err := audit.RunAuditExport(cfg.AuditExport, loopW); err != nil {
+		return err
+	}
+
+	if err := audit.RunAuditRetention(cfg.AuditRetention, engine.ServiceManageInterface); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util/table"
 	"configcenter/src/storage/driver/mongodb"
 )
 
@@ -235,7 +236,8 @@ func (m *associationKind) DeleteAssociationKind(kit *rest.Kit, inputParam metada
 		blog.Errorf(" pre association can not be delete [%#v], rid: %s", inputParam.Condition, kit.Rid)
 		return &metadata.DeletedCount{}, kit.CCError.Error(common.CCErrorTopoPreAssKindCanNotBeDelete)
 	}
-	cnt, err := mongodb.Client().Table(common.BKTableNameAsstDes).DeleteMany(kit.Ctx, inputParam.Condition)
+	ctx := table.WithDelArchive(kit.Ctx)
+	cnt, err := mongodb.Client().Table(common.BKTableNameAsstDes).DeleteMany(ctx, inputParam.Condition)
 	if nil != err {
 		blog.Errorf("delete association kind by condition [%#v],error:%s, rid: %s", inputParam.Condition, err.Error(), kit.Rid)
 		return &metadata.DeletedCount{}, err
@@ -262,7 +264,8 @@ func (m *associationKind) CascadeDeleteAssociationKind(kit *rest.Kit, inputParam
 		}
 	}
 
-	cnt, err := mongodb.Client().Table(common.BKTableNameAsstDes).DeleteMany(kit.Ctx, inputParam.Condition)
+	ctx := table.WithDelArchive(kit.Ctx)
+	cnt, err := mongodb.Client().Table(common.BKTableNameAsstDes).DeleteMany(ctx, inputParam.Condition)
 	if nil != err {
 		blog.Errorf("delete association kind by condition [%#v],error:%s, rid: %s", inputParam.Condition, err.Error(), kit.Rid)
 		return &metadata.DeletedCount{}, err
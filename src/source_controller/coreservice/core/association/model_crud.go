@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/universalsql"
+	"configcenter/src/common/util/table"
 	"configcenter/src/storage/driver/mongodb"
 )
 
@@ -77,7 +78,8 @@ func (m *associationModel) update(kit *rest.Kit, data mapstr.MapStr, cond univer
 
 func (m *associationModel) delete(kit *rest.Kit, cond universalsql.Condition) (cnt uint64, err error) {
 
-	cnt, err = mongodb.Client().Table(common.BKTableNameObjAsst).DeleteMany(kit.Ctx, cond.ToMapStr())
+	ctx := table.WithDelArchive(kit.Ctx)
+	cnt, err = mongodb.Client().Table(common.BKTableNameObjAsst).DeleteMany(ctx, cond.ToMapStr())
 	if nil != err {
 		blog.Errorf("request(%s): it is to delete some data on the table (%s) by the condition (%#v), error info is %s", kit.Rid, common.BKTableNameObjAsst, cond.ToMapStr(), err.Error())
 		return 0, err
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
 	"configcenter/src/common/blog"
 	httpheader "configcenter/src/common/http/header"
 	"configcenter/src/common/http/rest"
@@ -134,3 +135,19 @@ func (m *auditManager) SearchAuditLog(kit *rest.Kit, param metadata.QueryConditi
 
 	return rows, cnt, nil
 }
+
+// VerifyAuditChain verify the audit log hash chain of the supplier account, returns the first broken link
+func (m *auditManager) VerifyAuditChain(kit *rest.Kit, opt metadata.VerifyAuditChainOption) (
+	*metadata.AuditChainVerifyResult, error) {
+
+	result, err := auditlog.VerifyAuditChain(kit.Ctx, mongodb.Client(), kit.SupplierAccount, opt)
+	if err != nil {
+		blog.Errorf("verify audit log chain failed, err: %v, opt: %+v, rid: %s", err, opt, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if result.BrokenLink != nil {
+		blog.Errorf("audit log chain is broken, link: %+v, rid: %s", *result.BrokenLink, kit.Rid)
+	}
+	return result, nil
+}
@@ -182,6 +182,12 @@ func (c *cloudOperation) validCreateSyncTask(kit *rest.Kit, task *metadata.Cloud
 		return err
 	}
 
+	if err := c.validResourceMappings(kit, task.ResourceMappings); err != nil {
+		blog.ErrorJSON("validCreateSyncTask failed, error %s, mappings: %s, rid: %s", err, task.ResourceMappings,
+			kit.Rid)
+		return err
+	}
+
 	// account task count check, one account can only have one task
 	option := &metadata.SearchCloudOption{Condition: mapstr.MapStr{common.BKCloudAccountID: task.AccountID}}
 	multiTask, err := c.SearchSyncTask(kit, option)
@@ -256,6 +262,95 @@ func (c *cloudOperation) validUpdateSyncTask(kit *rest.Kit, taskID int64, option
 
 	}
 
+	if mappingInfo, ok := option.Get(common.BKCloudResourceMappings); ok {
+		bs, err := json.Marshal(mappingInfo)
+		if err != nil {
+			blog.ErrorJSON("validUpdateSyncTask failed, error %s, mappings: %s, rid: %s", err, mappingInfo, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommJSONMarshalFailed)
+		}
+		mappings := make([]metadata.CloudResourceMapping, 0)
+		if err = json.Unmarshal(bs, &mappings); err != nil {
+			blog.ErrorJSON("validUpdateSyncTask failed, error %s, mappings: %s, rid: %s", err, mappingInfo, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+		}
+
+		if err := c.validResourceMappings(kit, mappings); err != nil {
+			blog.ErrorJSON("validUpdateSyncTask failed, error %s, mappings: %s, rid: %s", err, mappingInfo, kit.Rid)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validResourceMappings valid the mappings of cloud resources other than host, the target model and its
+// attributes must exist, and the association with host must be defined between the model and host
+func (c *cloudOperation) validResourceMappings(kit *rest.Kit,
+	mappings []metadata.CloudResourceMapping) errors.CCErrorCoder {
+
+	objIDs := make(map[string]bool)
+	for _, mapping := range mappings {
+		if rawErr := mapping.Validate(); rawErr.ErrCode != 0 {
+			return rawErr.ToCCError(kit.CCError)
+		}
+		// one model can only be synchronized from one kind of cloud resource
+		if objIDs[mapping.ObjID] {
+			blog.Errorf("model %s is mapped to multiple cloud resources, rid: %s", mapping.ObjID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+		}
+		objIDs[mapping.ObjID] = true
+
+		fields := []string{mapping.GetIDField()}
+		for _, field := range mapping.FieldMapping {
+			fields = append(fields, field)
+		}
+		attrCond := mapstr.MapStr{
+			common.BKObjIDField:      mapping.ObjID,
+			common.BKPropertyIDField: mapstr.MapStr{common.BKDBIN: fields},
+		}
+		attrCond = util.SetQueryOwner(attrCond, kit.SupplierAccount)
+		attrs := make([]metadata.Attribute, 0)
+		err := c.dbProxy.Table(common.BKTableNameObjAttDes).Find(attrCond).Fields(common.BKPropertyIDField).
+			All(kit.Ctx, &attrs)
+		if err != nil {
+			blog.ErrorJSON("get attributes failed, err: %s, cond: %s, rid: %s", err, attrCond, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		existAttrs := make(map[string]bool)
+		for _, attr := range attrs {
+			existAttrs[attr.PropertyID] = true
+		}
+		for _, field := range fields {
+			if !existAttrs[field] {
+				blog.Errorf("attribute %s of model %s not exists, rid: %s", field, mapping.ObjID, kit.Rid)
+				return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+			}
+		}
+
+		if mapping.HostAsstID == "" {
+			continue
+		}
+
+		asstCond := mapstr.MapStr{
+			common.AssociationObjAsstIDField: mapping.HostAsstID,
+			common.BKDBOR: []mapstr.MapStr{
+				{common.BKObjIDField: mapping.ObjID, common.BKAsstObjIDField: common.BKInnerObjIDHost},
+				{common.BKObjIDField: common.BKInnerObjIDHost, common.BKAsstObjIDField: mapping.ObjID},
+			},
+		}
+		asstCond = util.SetQueryOwner(asstCond, kit.SupplierAccount)
+		cnt, err := c.dbProxy.Table(common.BKTableNameObjAsst).Find(asstCond).Count(kit.Ctx)
+		if err != nil {
+			blog.ErrorJSON("count model association failed, err: %s, cond: %s, rid: %s", err, asstCond, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		if cnt == 0 {
+			blog.Errorf("association %s between %s and host not exists, rid: %s", mapping.HostAsstID,
+				mapping.ObjID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "host_obj_asst_id")
+		}
+	}
+
 	return nil
 }
 
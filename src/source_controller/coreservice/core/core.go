@@ -240,6 +240,7 @@ type AssociationOperation interface {
 type AuditOperation interface {
 	CreateAuditLog(kit *rest.Kit, logs ...metadata.AuditLog) error
 	SearchAuditLog(kit *rest.Kit, param metadata.QueryCondition) ([]metadata.AuditLog, uint64, error)
+	VerifyAuditChain(kit *rest.Kit, opt metadata.VerifyAuditChainOption) (*metadata.AuditChainVerifyResult, error)
 }
 
 // StatisticOperation TODO
@@ -253,6 +254,9 @@ type StatisticOperation interface {
 	UpdateOperationChart(kit *rest.Kit, inputParam map[string]interface{}) (interface{}, error)
 	SearchTimerChartData(kit *rest.Kit, inputParam metadata.ChartConfig) (interface{}, error)
 	TimerFreshData(kit *rest.Kit) error
+	CreateChartSnapshots(kit *rest.Kit) error
+	SearchChartSnapshots(kit *rest.Kit, opt *metadata.SearchChartSnapshotOption) (map[string][]metadata.StringIDCount,
+		error)
 }
 
 // Core core itnerfaces methods
@@ -356,6 +360,15 @@ type ProcessOperation interface {
 	DeleteServiceTemplateAttribute(kit *rest.Kit, option *metadata.DeleteServTempAttrOption) errors.CCErrorCoder
 	ListServiceTemplateAttribute(kit *rest.Kit, option *metadata.ListServTempAttrOption) (*metadata.ServTempAttrData,
 		errors.CCErrorCoder)
+
+	// CreateSvcTempRevision service template revision
+	CreateSvcTempRevision(kit *rest.Kit, revision *metadata.ServiceTemplateRevision) (
+		*metadata.ServiceTemplateRevision, errors.CCErrorCoder)
+	ListSvcTempRevision(kit *rest.Kit, option *metadata.ListSvcTempRevisionOption) (*metadata.MultipleSvcTempRevision,
+		errors.CCErrorCoder)
+	PinSvcTempVersion(kit *rest.Kit, option *metadata.PinSvcTempVersionOption) errors.CCErrorCoder
+	ListSvcTempVersionPin(kit *rest.Kit, option *metadata.ListSvcTempVersionPinOption) ([]metadata.SvcTempVersionPin,
+		errors.CCErrorCoder)
 }
 
 // LabelOperation TODO
@@ -386,6 +399,11 @@ type SetTemplateOperation interface {
 	DeleteSetTemplateAttribute(kit *rest.Kit, option *metadata.DeleteSetTempAttrOption) errors.CCErrorCoder
 	ListSetTemplateAttribute(kit *rest.Kit, option *metadata.ListSetTempAttrOption) (*metadata.SetTempAttrData,
 		errors.CCErrorCoder)
+	UpsertSetTplSyncPolicy(kit *rest.Kit, option *metadata.SetTplSyncPolicyOption) (*metadata.SetTplSyncPolicy,
+		errors.CCErrorCoder)
+	ListSetTplSyncPolicy(kit *rest.Kit, option *metadata.ListSetTplSyncPolicyOption) (
+		*metadata.MultipleSetTplSyncPolicy, errors.CCErrorCoder)
+	UpdateSetTplSyncRun(kit *rest.Kit, option *metadata.UpdateSetTplSyncRunOption) errors.CCErrorCoder
 }
 
 // HostApplyRuleOperation TODO
@@ -35,6 +35,7 @@ import (
 	"configcenter/src/common/universalsql"
 	"configcenter/src/common/universalsql/mongo"
 	"configcenter/src/common/util"
+	"configcenter/src/common/util/table"
 	"configcenter/src/common/valid"
 	attrvalid "configcenter/src/common/valid/attribute"
 	"configcenter/src/common/valid/attribute/manager"
@@ -854,7 +855,8 @@ func (m *modelAttribute) delete(kit *rest.Kit, cond universalsql.Condition, isFr
 		}
 	}
 
-	deleteCnt, err := mongodb.Client().Table(common.BKTableNameObjAttDes).DeleteMany(kit.Ctx, condMap)
+	ctx := table.WithDelArchive(kit.Ctx)
+	deleteCnt, err := mongodb.Client().Table(common.BKTableNameObjAttDes).DeleteMany(ctx, condMap)
 	if nil != err {
 		blog.Errorf("request(%s): database deletion operation is failed, error info is %s", kit.Rid, err.Error())
 		return deleteCnt, err
@@ -1725,7 +1727,8 @@ func (m *modelAttribute) delIDRuleUnique(kit *rest.Kit, objIDPropertyIDArr map[s
 	cond.Or(orCondArr...)
 	condMap := util.SetModOwner(cond.ToMapStr(), kit.SupplierAccount)
 
-	if _, err := mongodb.Client().Table(common.BKTableNameObjUnique).DeleteMany(kit.Ctx, condMap); err != nil {
+	ctx := table.WithDelArchive(kit.Ctx)
+	if _, err := mongodb.Client().Table(common.BKTableNameObjUnique).DeleteMany(ctx, condMap); err != nil {
 		blog.ErrorJSON("delete unique failed, cond: %+v, err: %v, rid: %s", condMap, err, kit.Rid)
 		return kit.CCError.Error(common.CCErrCommDBDeleteFailed)
 	}
@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/universalsql"
 	"configcenter/src/common/util"
+	"configcenter/src/common/util/table"
 	"configcenter/src/storage/driver/mongodb"
 )
 
@@ -65,7 +66,8 @@ func (m *modelClassification) update(kit *rest.Kit, data mapstr.MapStr, cond uni
 
 func (m *modelClassification) delete(kit *rest.Kit, cond universalsql.Condition) (cnt uint64, err error) {
 
-	cnt, err = mongodb.Client().Table(common.BKTableNameObjClassification).DeleteMany(kit.Ctx, cond.ToMapStr())
+	ctx := table.WithDelArchive(kit.Ctx)
+	cnt, err = mongodb.Client().Table(common.BKTableNameObjClassification).DeleteMany(ctx, cond.ToMapStr())
 	if nil != err {
 		blog.Errorf("request(%s): it is failed to execute a database deletion operation on the table(%s) by the condition(%#v), error info is %s", kit.Rid, common.BKTableNameObjClassification, cond.ToMapStr(), err.Error())
 		return 0, err
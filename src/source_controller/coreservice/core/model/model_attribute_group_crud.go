@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/universalsql"
+	"configcenter/src/common/util/table"
 	"configcenter/src/storage/driver/mongodb"
 )
 
@@ -47,7 +48,8 @@ func (g *modelAttributeGroup) save(kit *rest.Kit, group metadata.Group) (uint64,
 }
 
 func (g *modelAttributeGroup) delete(kit *rest.Kit, cond universalsql.Condition) (uint64, error) {
-	cnt, err := mongodb.Client().Table(common.BKTableNamePropertyGroup).DeleteMany(kit.Ctx, cond.ToMapStr())
+	ctx := table.WithDelArchive(kit.Ctx)
+	cnt, err := mongodb.Client().Table(common.BKTableNamePropertyGroup).DeleteMany(ctx, cond.ToMapStr())
 	if err != nil {
 		blog.ErrorJSON("delete model attribute group error. cond: %s, err: %s, rid: %s", cond, err.Error(), kit.Rid)
 		return 0, err
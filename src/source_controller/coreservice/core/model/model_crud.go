@@ -32,6 +32,7 @@ import (
 	"configcenter/src/common/universalsql"
 	"configcenter/src/common/universalsql/mongo"
 	"configcenter/src/common/util"
+	"configcenter/src/common/util/table"
 	"configcenter/src/storage/dal/types"
 	"configcenter/src/storage/driver/mongodb"
 )
@@ -330,7 +331,8 @@ func (m *modelManager) delete(kit *rest.Kit, cond universalsql.Condition) (uint6
 			err, cond, kit.Rid)
 		return 0, err
 	}
-	cnt, err := mongodb.Client().Table(common.BKTableNameObjDes).DeleteMany(kit.Ctx, cond.ToMapStr())
+	ctx := table.WithDelArchive(kit.Ctx)
+	cnt, err := mongodb.Client().Table(common.BKTableNameObjDes).DeleteMany(ctx, cond.ToMapStr())
 	if err != nil {
 		blog.Errorf("it is failed to execute a deletion operation on the table, err: %v, table: %s, rid: %s",
 			err, common.BKTableNameObjDes, kit.Rid)
@@ -370,20 +372,21 @@ func (m *modelManager) cascadeDelete(kit *rest.Kit, objIDs []string) (uint64, er
 	delCond.Element(mongo.Field(common.BKObjIDField).In(objIDs))
 	delCondMap := util.SetQueryOwner(delCond.ToMapStr(), kit.SupplierAccount)
 
+	ctx := table.WithDelArchive(kit.Ctx)
 	// delete model property group
-	if err := mongodb.Client().Table(common.BKTableNamePropertyGroup).Delete(kit.Ctx, delCondMap); err != nil {
+	if err := mongodb.Client().Table(common.BKTableNamePropertyGroup).Delete(ctx, delCondMap); err != nil {
 		blog.Errorf("delete model attribute group error. err: %v, cond: %s, rid: %s", err, delCondMap, kit.Rid)
 		return 0, kit.CCError.Error(common.CCErrCommDBDeleteFailed)
 	}
 
 	// delete model property attribute
-	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Delete(kit.Ctx, delCondMap); err != nil {
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Delete(ctx, delCondMap); err != nil {
 		blog.Errorf("delete model attribute error. err: %v, cond: %s, rid: %s", err, delCondMap, kit.Rid)
 		return 0, kit.CCError.Error(common.CCErrCommDBDeleteFailed)
 	}
 
 	// delete model unique
-	if err := mongodb.Client().Table(common.BKTableNameObjUnique).Delete(kit.Ctx, delCondMap); err != nil {
+	if err := mongodb.Client().Table(common.BKTableNameObjUnique).Delete(ctx, delCondMap); err != nil {
 		blog.Errorf("delete model unique error. err: %v, cond: %s, rid: %s", err, delCondMap, kit.Rid)
 		return 0, kit.CCError.Error(common.CCErrCommDBDeleteFailed)
 	}
@@ -395,7 +398,7 @@ func (m *modelManager) cascadeDelete(kit *rest.Kit, objIDs []string) (uint64, er
 	}
 
 	// delete model
-	cnt, err := mongodb.Client().Table(common.BKTableNameObjDes).DeleteMany(kit.Ctx, delCondMap)
+	cnt, err := mongodb.Client().Table(common.BKTableNameObjDes).DeleteMany(ctx, delCondMap)
 	if err != nil {
 		blog.Errorf("delete model unique error. err: %v, cond: %s, rid: %s", err, delCondMap, kit.Rid)
 		return 0, kit.CCError.Error(common.CCErrCommDBDeleteFailed)
@@ -423,7 +426,8 @@ func (m *modelManager) cascadeDeleteTable(kit *rest.Kit, input metadata.DeleteTa
 	}
 	modelDelCond = util.SetQueryOwner(modelDelCond, kit.SupplierAccount)
 
-	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Delete(kit.Ctx, modelDelCond); err != nil {
+	ctx := table.WithDelArchive(kit.Ctx)
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Delete(ctx, modelDelCond); err != nil {
 		blog.Errorf("delete model attribute failed, err: %v, cond: %v, rid: %s", err, modelDelCond, kit.Rid)
 		return kit.CCError.Error(common.CCErrCommDBSelectFailed)
 	}
@@ -447,13 +451,13 @@ func (m *modelManager) cascadeDeleteTable(kit *rest.Kit, input metadata.DeleteTa
 	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
 
 	// delete model property group.
-	if err := mongodb.Client().Table(common.BKTableNamePropertyGroup).Delete(kit.Ctx, cond); err != nil {
+	if err := mongodb.Client().Table(common.BKTableNamePropertyGroup).Delete(ctx, cond); err != nil {
 		blog.Errorf("delete model attribute group failed, err: %v, cond: %v, rid: %s", err, cond, kit.Rid)
 		return kit.CCError.Error(common.CCErrCommDBSelectFailed)
 	}
 
 	// delete table model.
-	_, err = mongodb.Client().Table(common.BKTableNameObjDes).DeleteMany(kit.Ctx, cond)
+	_, err = mongodb.Client().Table(common.BKTableNameObjDes).DeleteMany(ctx, cond)
 	if err != nil {
 		blog.Errorf("delete model failed, err: %v, cond: %v, rid: %s", err, cond, kit.Rid)
 		return kit.CCError.Error(common.CCErrCommDBSelectFailed)
@@ -30,6 +30,7 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/common/util/table"
 	"configcenter/src/storage/dal/types"
 	"configcenter/src/storage/driver/mongodb"
 )
@@ -236,7 +237,7 @@ func (m *modelAttrUnique) deleteModelAttrUnique(kit *rest.Kit, objID string, id
 		return kit.CCError.CCErrorf(common.CCErrorTopoFieldTemplateForbiddenDeleteIndex, id, unique.TemplateID)
 	}
 
-	err = mongodb.Client().Table(common.BKTableNameObjUnique).Delete(kit.Ctx, fCond)
+	err = mongodb.Client().Table(common.BKTableNameObjUnique).Delete(table.WithDelArchive(kit.Ctx), fCond)
 	if nil != err {
 		blog.Errorf("delete object unique error, raw: %#v, err: %v, rid: %s", fCond, err, kit.Rid)
 		return kit.CCError.Error(common.CCErrObjectDBOpErrno)
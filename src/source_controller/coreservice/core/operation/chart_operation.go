@@ -117,6 +117,12 @@ func (m *operationManager) DeleteOperationChart(kit *rest.Kit, id int64) (interf
 		return nil, kit.CCError.CCError(common.CCErrOperationDeleteChartFail)
 	}
 
+	// 删除快照类型图表的历史快照数据
+	if err := mongodb.Client().Table(common.BKTableNameChartSnapshot).Delete(kit.Ctx, opt); err != nil {
+		blog.Errorf("delete chart snapshots fail, err: %v, rid: %v", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrOperationDeleteChartFail)
+	}
+
 	return nil, nil
 }
 
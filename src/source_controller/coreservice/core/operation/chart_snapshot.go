@@ -0,0 +1,221 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package operation
+
+import (
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// chartSnapshotKeepDays is the days that the chart snapshots are kept
+const chartSnapshotKeepDays = 180
+
+// snapshotGroupCount is the instance count of one group field value
+type snapshotGroupCount struct {
+	ID    interface{} `bson:"_id"`
+	Count int64       `bson:"count"`
+}
+
+// CreateChartSnapshots 统计所有快照类型图表当天的数据，并清理过期的快照
+func (m *operationManager) CreateChartSnapshots(kit *rest.Kit) error {
+	charts := make([]metadata.ChartConfig, 0)
+	cond := mapstr.MapStr{common.OperationReportType: common.OperationSnapshot}
+	if err := mongodb.Client().Table(common.BKTableNameChartConfig).Find(cond).All(kit.Ctx, &charts); err != nil {
+		blog.Errorf("get snapshot charts failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+
+	now := time.Now()
+	date := now.Format(metadata.ChartSnapshotDateFormat)
+	for _, chart := range charts {
+		data, err := m.countSnapshotData(kit, chart)
+		if err != nil {
+			blog.Errorf("count chart %d snapshot data failed, err: %v, rid: %s", chart.ConfigID, err, kit.Rid)
+			continue
+		}
+
+		snapshot := metadata.ChartSnapshot{
+			ConfigID:   chart.ConfigID,
+			Date:       date,
+			Data:       data,
+			OwnerID:    chart.OwnerID,
+			CreateTime: now,
+		}
+		snapshotCond := mapstr.MapStr{common.OperationConfigID: chart.ConfigID, "date": date}
+		err = mongodb.Client().Table(common.BKTableNameChartSnapshot).Upsert(kit.Ctx, snapshotCond, snapshot)
+		if err != nil {
+			blog.Errorf("save chart %d snapshot failed, err: %v, rid: %s", chart.ConfigID, err, kit.Rid)
+			continue
+		}
+	}
+
+	expireCond := mapstr.MapStr{"date": mapstr.MapStr{
+		common.BKDBLT: now.AddDate(0, 0, -chartSnapshotKeepDays).Format(metadata.ChartSnapshotDateFormat),
+	}}
+	if err := mongodb.Client().Table(common.BKTableNameChartSnapshot).Delete(kit.Ctx, expireCond); err != nil {
+		blog.Errorf("clear expired chart snapshots failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+
+	return nil
+}
+
+// countSnapshotData count the instances that matches the chart filter, grouped by the chart field
+func (m *operationManager) countSnapshotData(kit *rest.Kit, chart metadata.ChartConfig) ([]metadata.StringIDCount,
+	error) {
+
+	if err := chart.ValidateSnapshot(); err != nil {
+		return nil, err
+	}
+
+	filter, key, err := chart.Filter.ToMgo()
+	if err != nil {
+		return nil, fmt.Errorf("parse filter.%s failed, err: %v", key, err)
+	}
+
+	cond := M{}
+	for k, v := range filter {
+		cond[k] = v
+	}
+	if common.IsObjectInstShardingTable(common.GetInstTableName(chart.ObjID, kit.SupplierAccount)) {
+		cond = M{common.BKDBAND: []M{cond, {common.BKObjIDField: chart.ObjID}}}
+	}
+
+	var groupCounts []snapshotGroupCount
+	if chart.ObjID == common.BKInnerObjIDHost && metadata.IsHostRelSnapshotGroupField(chart.Field) {
+		groupCounts, err = countHostByRelation(kit, cond, chart.Field)
+	} else {
+		groupCounts = make([]snapshotGroupCount, 0)
+		pipeline := []M{
+			{common.BKDBMatch: cond},
+			{common.BKDBGroup: M{"_id": "$" + chart.Field, "count": M{common.BKDBSum: 1}}},
+		}
+		table := common.GetInstTableName(chart.ObjID, kit.SupplierAccount)
+		err = mongodb.Client().Table(table).AggregateAll(kit.Ctx, pipeline, &groupCounts)
+	}
+	if err != nil {
+		blog.Errorf("count chart %d instances failed, err: %v, rid: %s", chart.ConfigID, err, kit.Rid)
+		return nil, err
+	}
+
+	data := make([]metadata.StringIDCount, len(groupCounts))
+	for i, groupCount := range groupCounts {
+		data[i] = metadata.StringIDCount{
+			ID:    util.GetStrByInterface(groupCount.ID),
+			Count: groupCount.Count,
+		}
+	}
+	return data, nil
+}
+
+// countHostByRelation count the hosts that matches the condition grouped by the topology field of host relation,
+// host that belongs to multiple modules is only counted once for each group
+func countHostByRelation(kit *rest.Kit, cond M, field string) ([]snapshotGroupCount, error) {
+	countMap := make(map[string]*snapshotGroupCount)
+	lastHostID := int64(0)
+	for {
+		hosts := make([]metadata.HostMapStr, 0)
+		pageCond := M{common.BKDBAND: []M{cond, {common.BKHostIDField: M{common.BKDBGT: lastHostID}}}}
+		err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(pageCond).Fields(common.BKHostIDField).
+			Sort(common.BKHostIDField).Limit(common.BKMaxPageSize).All(kit.Ctx, &hosts)
+		if err != nil {
+			blog.Errorf("get hosts failed, err: %v, cond: %+v, rid: %s", err, pageCond, kit.Rid)
+			return nil, err
+		}
+
+		if len(hosts) == 0 {
+			break
+		}
+
+		hostIDs := make([]int64, len(hosts))
+		for i, host := range hosts {
+			hostIDs[i], err = util.GetInt64ByInterface(host[common.BKHostIDField])
+			if err != nil {
+				blog.Errorf("parse host id failed, err: %v, host: %+v, rid: %s", err, host, kit.Rid)
+				return nil, err
+			}
+		}
+		lastHostID = hostIDs[len(hostIDs)-1]
+
+		pipeline := []M{
+			{common.BKDBMatch: M{common.BKHostIDField: M{common.BKDBIN: hostIDs}}},
+			{common.BKDBGroup: M{"_id": M{"field": "$" + field, "host": "$" + common.BKHostIDField}}},
+			{common.BKDBGroup: M{"_id": "$_id.field", "count": M{common.BKDBSum: 1}}},
+		}
+		groupCounts := make([]snapshotGroupCount, 0)
+		err = mongodb.Client().Table(common.BKTableNameModuleHostConfig).AggregateAll(kit.Ctx, pipeline, &groupCounts)
+		if err != nil {
+			blog.Errorf("count host relations failed, err: %v, rid: %s", err, kit.Rid)
+			return nil, err
+		}
+
+		// hosts of different pages are different, so the counts of the same group can be added up
+		for i, groupCount := range groupCounts {
+			id := util.GetStrByInterface(groupCount.ID)
+			if _, exists := countMap[id]; !exists {
+				countMap[id] = &groupCounts[i]
+				continue
+			}
+			countMap[id].Count += groupCount.Count
+		}
+
+		if len(hosts) < common.BKMaxPageSize {
+			break
+		}
+	}
+
+	result := make([]snapshotGroupCount, 0, len(countMap))
+	for _, groupCount := range countMap {
+		result = append(result, *groupCount)
+	}
+	return result, nil
+}
+
+// SearchChartSnapshots 查询快照类型图表在时间范围内每天的数据，返回分组字段值到每天数量的映射
+func (m *operationManager) SearchChartSnapshots(kit *rest.Kit, opt *metadata.SearchChartSnapshotOption) (
+	map[string][]metadata.StringIDCount, error) {
+
+	cond := mapstr.MapStr{
+		common.OperationConfigID: opt.ConfigID,
+		"date":                   mapstr.MapStr{common.BKDBGTE: opt.StartDate, common.BKDBLTE: opt.EndDate},
+	}
+	snapshots := make([]metadata.ChartSnapshot, 0)
+	err := mongodb.Client().Table(common.BKTableNameChartSnapshot).Find(cond).Sort("date").All(kit.Ctx, &snapshots)
+	if err != nil {
+		blog.Errorf("search chart snapshots failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return nil, err
+	}
+
+	result := make(map[string][]metadata.StringIDCount)
+	for _, snapshot := range snapshots {
+		for _, data := range snapshot.Data {
+			result[data.ID] = append(result[data.ID], metadata.StringIDCount{
+				ID:    snapshot.Date,
+				Count: data.Count,
+			})
+		}
+	}
+	return result, nil
+}
@@ -1103,6 +1103,15 @@ func (p *processOperation) RemoveTemplateBindingOnModule(kit *rest.Kit, moduleID
 		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
 	}
 
+	// the module no longer uses the service template, so its version pin is useless
+	pinFilter := map[string]int64{common.BKModuleIDField: moduleID}
+	if err := mongodb.Client().Table(common.BKTableNameServiceTemplateVersionPin).Delete(kit.Ctx,
+		pinFilter); err != nil {
+		blog.Errorf("remove template binding on module failed, delete version pin failed, module: %d, err: %v, "+
+			"rid: %s", moduleID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
 	// clear service instance template
 	serviceInstanceFilter := map[string]int64{
 		common.BKModuleIDField: moduleID,
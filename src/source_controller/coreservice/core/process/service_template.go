@@ -422,6 +422,10 @@ func (p *processOperation) DeleteServiceTemplate(kit *rest.Kit, serviceTemplateI
 		return err
 	}
 
+	if err := p.deleteSvcTempRevisionData(kit, template.ID); err != nil {
+		return err
+	}
+
 	delAttrFilter := map[string]int64{common.BKServiceTemplateIDField: template.ID}
 	if err := mongodb.Client().Table(common.BKTableNameServiceTemplateAttr).Delete(kit.Ctx, delAttrFilter); err != nil {
 		blog.Errorf("delete service template attr failed, filter: %+v, err: %v, rid: %s", delAttrFilter, err, kit.Rid)
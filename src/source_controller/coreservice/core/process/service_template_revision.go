@@ -0,0 +1,229 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package process
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+const svcTempRevisionVersionField = "version"
+
+// CreateSvcTempRevision create a new revision of service template, the version is assigned incrementally
+func (p *processOperation) CreateSvcTempRevision(kit *rest.Kit, revision *metadata.ServiceTemplateRevision) (
+	*metadata.ServiceTemplateRevision, errors.CCErrorCoder) {
+
+	if err := p.validateServiceTemplate(kit, revision.BizID, revision.ServiceTemplateID); err != nil {
+		return nil, err
+	}
+
+	filter := mapstr.MapStr{common.BKServiceTemplateIDField: revision.ServiceTemplateID}
+	latest := make([]metadata.ServiceTemplateRevision, 0)
+	err := mongodb.Client().Table(common.BKTableNameServiceTemplateRevision).Find(filter).
+		Fields(svcTempRevisionVersionField).Sort(svcTempRevisionVersionField+":-1").Limit(1).All(kit.Ctx, &latest)
+	if err != nil {
+		blog.Errorf("get latest service template revision failed, filter: %+v, err: %v, rid: %s", filter, err,
+			kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	revision.Version = 1
+	if len(latest) > 0 {
+		revision.Version = latest[0].Version + 1
+	}
+	revision.Creator = kit.User
+	revision.CreateTime = time.Now()
+	revision.SupplierAccount = kit.SupplierAccount
+
+	// the unique index of service template id and version prevents concurrent creation of the same version
+	if err := mongodb.Client().Table(common.BKTableNameServiceTemplateRevision).Insert(kit.Ctx, revision); err != nil {
+		blog.Errorf("create service template revision(%+v) failed, err: %v, rid: %s", revision, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return revision, nil
+}
+
+// ListSvcTempRevision list revisions of service template, sorted by version in descending order by default
+func (p *processOperation) ListSvcTempRevision(kit *rest.Kit, option *metadata.ListSvcTempRevisionOption) (
+	*metadata.MultipleSvcTempRevision, errors.CCErrorCoder) {
+
+	filter := mapstr.MapStr{
+		common.BKAppIDField:             option.BizID,
+		common.BKServiceTemplateIDField: option.ServiceTemplateID,
+	}
+	if len(option.Versions) > 0 {
+		filter[svcTempRevisionVersionField] = mapstr.MapStr{common.BKDBIN: option.Versions}
+	}
+
+	query := mongodb.Client().Table(common.BKTableNameServiceTemplateRevision).Find(filter)
+	total, err := query.Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count service template revision failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(option.Page.Sort) > 0 {
+		query = query.Sort(option.Page.Sort)
+	} else {
+		query = query.Sort(svcTempRevisionVersionField + ":-1")
+	}
+	if option.Page.Limit > 0 && option.Page.Limit != common.BKNoLimit {
+		query = query.Limit(uint64(option.Page.Limit))
+	}
+	if option.Page.Start > 0 {
+		query = query.Start(uint64(option.Page.Start))
+	}
+
+	revisions := make([]metadata.ServiceTemplateRevision, 0)
+	if err := query.Fields(option.Fields...).All(kit.Ctx, &revisions); err != nil {
+		blog.Errorf("list service template revision failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.MultipleSvcTempRevision{
+		Count: int64(total),
+		Info:  revisions,
+	}, nil
+}
+
+// PinSvcTempVersion pin modules to a revision of their service template, unpin them if version is 0
+func (p *processOperation) PinSvcTempVersion(kit *rest.Kit,
+	option *metadata.PinSvcTempVersionOption) errors.CCErrorCoder {
+
+	moduleIDs := util.IntArrayUnique(option.ModuleIDs)
+
+	// modules to pin must belong to the service template
+	moduleFilter := mapstr.MapStr{
+		common.BKAppIDField:             option.BizID,
+		common.BKServiceTemplateIDField: option.ServiceTemplateID,
+		common.BKModuleIDField:          mapstr.MapStr{common.BKDBIN: moduleIDs},
+	}
+	moduleCnt, err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(moduleFilter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count modules failed, filter: %+v, err: %v, rid: %s", moduleFilter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if moduleCnt != uint64(len(moduleIDs)) {
+		blog.Errorf("modules %v are not all bound to service template %d, rid: %s", moduleIDs,
+			option.ServiceTemplateID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "bk_module_ids")
+	}
+
+	if option.Version != 0 {
+		revisionFilter := mapstr.MapStr{
+			common.BKAppIDField:             option.BizID,
+			common.BKServiceTemplateIDField: option.ServiceTemplateID,
+			svcTempRevisionVersionField:     option.Version,
+		}
+		cnt, err := mongodb.Client().Table(common.BKTableNameServiceTemplateRevision).Find(revisionFilter).
+			Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count service template revision failed, filter: %+v, err: %v, rid: %s", revisionFilter, err,
+				kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		if cnt == 0 {
+			blog.Errorf("service template %d has no version %d, rid: %s", option.ServiceTemplateID, option.Version,
+				kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "version")
+		}
+	}
+
+	pinFilter := mapstr.MapStr{common.BKModuleIDField: mapstr.MapStr{common.BKDBIN: moduleIDs}}
+	if err := mongodb.Client().Table(common.BKTableNameServiceTemplateVersionPin).Delete(kit.Ctx,
+		pinFilter); err != nil {
+		blog.Errorf("delete service template version pins failed, filter: %+v, err: %v, rid: %s", pinFilter, err,
+			kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
+	if option.Version == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	pins := make([]metadata.SvcTempVersionPin, len(moduleIDs))
+	for idx, moduleID := range moduleIDs {
+		pins[idx] = metadata.SvcTempVersionPin{
+			BizID:             option.BizID,
+			ServiceTemplateID: option.ServiceTemplateID,
+			ModuleID:          moduleID,
+			Version:           option.Version,
+			Creator:           kit.User,
+			CreateTime:        now,
+			SupplierAccount:   kit.SupplierAccount,
+		}
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameServiceTemplateVersionPin).Insert(kit.Ctx, pins); err != nil {
+		blog.Errorf("create service template version pins(%+v) failed, err: %v, rid: %s", pins, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return nil
+}
+
+// ListSvcTempVersionPin list the service template version pins of modules
+func (p *processOperation) ListSvcTempVersionPin(kit *rest.Kit, option *metadata.ListSvcTempVersionPinOption) (
+	[]metadata.SvcTempVersionPin, errors.CCErrorCoder) {
+
+	filter := mapstr.MapStr{common.BKAppIDField: option.BizID}
+	if option.ServiceTemplateID != 0 {
+		filter[common.BKServiceTemplateIDField] = option.ServiceTemplateID
+	}
+	if len(option.ModuleIDs) > 0 {
+		filter[common.BKModuleIDField] = mapstr.MapStr{common.BKDBIN: option.ModuleIDs}
+	}
+
+	pins := make([]metadata.SvcTempVersionPin, 0)
+	err := mongodb.Client().Table(common.BKTableNameServiceTemplateVersionPin).Find(filter).
+		Sort(common.BKModuleIDField).All(kit.Ctx, &pins)
+	if err != nil {
+		blog.Errorf("list service template version pins failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return pins, nil
+}
+
+// deleteSvcTempRevisionData delete all revisions and version pins of the service template
+func (p *processOperation) deleteSvcTempRevisionData(kit *rest.Kit, serviceTemplateID int64) errors.CCErrorCoder {
+	filter := mapstr.MapStr{common.BKServiceTemplateIDField: serviceTemplateID}
+	if err := mongodb.Client().Table(common.BKTableNameServiceTemplateVersionPin).Delete(kit.Ctx, filter); err != nil {
+		blog.Errorf("delete service template version pins failed, filter: %+v, err: %v, rid: %s", filter, err,
+			kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameServiceTemplateRevision).Delete(kit.Ctx, filter); err != nil {
+		blog.Errorf("delete service template revisions failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
+	return nil
+}
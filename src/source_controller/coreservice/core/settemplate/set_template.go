@@ -323,6 +323,18 @@ func (p *setTemplateOperation) DeleteSetTemplate(kit *rest.Kit, bizID int64, opt
 		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
 	}
 
+	// delete sync policies
+	policyFilter := map[string]interface{}{
+		common.BKAppIDField: bizID,
+		common.BKSetTemplateIDField: map[string]interface{}{
+			common.BKDBIN: option.SetTemplateIDs,
+		},
+	}
+	if err := mongodb.Client().Table(common.BKTableNameSetTemplateSyncPolicy).Delete(kit.Ctx, policyFilter); err != nil {
+		blog.Errorf("DeleteSetTemplate failed, db remove sync policies failed, filter: %+v, err: %+v, rid: %s", policyFilter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
 	return nil
 }
 
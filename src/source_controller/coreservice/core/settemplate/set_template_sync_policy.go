@@ -0,0 +1,182 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package settemplate
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// UpsertSetTplSyncPolicy create or update the sync policy of set template
+func (p *setTemplateOperation) UpsertSetTplSyncPolicy(kit *rest.Kit, option *metadata.SetTplSyncPolicyOption) (
+	*metadata.SetTplSyncPolicy, errors.CCErrorCoder) {
+
+	if err := p.validateSetTemplate(kit, option.BizID, option.SetTemplateID); err != nil {
+		return nil, err
+	}
+
+	filter := mapstr.MapStr{
+		common.BKAppIDField:         option.BizID,
+		common.BKSetTemplateIDField: option.SetTemplateID,
+	}
+
+	policy := new(metadata.SetTplSyncPolicy)
+	err := mongodb.Client().Table(common.BKTableNameSetTemplateSyncPolicy).Find(filter).One(kit.Ctx, policy)
+	if err != nil && !mongodb.Client().IsNotFoundError(err) {
+		blog.Errorf("get set template sync policy failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	now := time.Now()
+	if err != nil {
+		policy = newSetTplSyncPolicy(kit, option, now)
+		if err := mongodb.Client().Table(common.BKTableNameSetTemplateSyncPolicy).Insert(kit.Ctx, policy); err != nil {
+			blog.Errorf("create set template sync policy(%+v) failed, err: %v, rid: %s", policy, err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+		}
+		return policy, nil
+	}
+
+	updateData := updateSetTplSyncPolicy(kit, policy, option, now)
+	if err := mongodb.Client().Table(common.BKTableNameSetTemplateSyncPolicy).Update(kit.Ctx, filter,
+		updateData); err != nil {
+		blog.Errorf("update set template sync policy failed, filter: %+v, data: %+v, err: %v, rid: %s", filter,
+			updateData, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	return policy, nil
+}
+
+// newSetTplSyncPolicy generate a new set template sync policy by the option
+func newSetTplSyncPolicy(kit *rest.Kit, option *metadata.SetTplSyncPolicyOption,
+	now time.Time) *metadata.SetTplSyncPolicy {
+
+	return &metadata.SetTplSyncPolicy{
+		BizID:           option.BizID,
+		SetTemplateID:   option.SetTemplateID,
+		Mode:            option.Mode,
+		Windows:         option.Windows,
+		MaxConcurrency:  option.MaxConcurrency,
+		CanaryCount:     option.CanaryCount,
+		Creator:         kit.User,
+		Modifier:        kit.User,
+		CreateTime:      now,
+		LastTime:        now,
+		SupplierAccount: kit.SupplierAccount,
+	}
+}
+
+// updateSetTplSyncPolicy apply the option to the existing policy, returns the data to update, the last run is kept
+// so that a halted or failed run is still known, and the changed last time unblocks the policy.
+func updateSetTplSyncPolicy(kit *rest.Kit, policy *metadata.SetTplSyncPolicy, option *metadata.SetTplSyncPolicyOption,
+	now time.Time) mapstr.MapStr {
+
+	policy.Mode = option.Mode
+	policy.Windows = option.Windows
+	policy.MaxConcurrency = option.MaxConcurrency
+	policy.CanaryCount = option.CanaryCount
+	policy.Modifier = kit.User
+	policy.LastTime = now
+
+	return mapstr.MapStr{
+		"mode":               policy.Mode,
+		"windows":            policy.Windows,
+		"max_concurrency":    policy.MaxConcurrency,
+		"canary_count":       policy.CanaryCount,
+		common.ModifierField: policy.Modifier,
+		common.LastTimeField: policy.LastTime,
+	}
+}
+
+// ListSetTplSyncPolicy list set template sync policies
+func (p *setTemplateOperation) ListSetTplSyncPolicy(kit *rest.Kit, option *metadata.ListSetTplSyncPolicyOption) (
+	*metadata.MultipleSetTplSyncPolicy, errors.CCErrorCoder) {
+
+	filter := listSetTplSyncPolicyFilter(option)
+	query := mongodb.Client().Table(common.BKTableNameSetTemplateSyncPolicy).Find(filter)
+	total, err := query.Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count set template sync policy failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(option.Page.Sort) > 0 {
+		query = query.Sort(option.Page.Sort)
+	} else {
+		query = query.Sort(common.BKAppIDField + "," + common.BKSetTemplateIDField)
+	}
+	if option.Page.Limit > 0 && option.Page.Limit != common.BKNoLimit {
+		query = query.Limit(uint64(option.Page.Limit))
+	}
+	if option.Page.Start > 0 {
+		query = query.Start(uint64(option.Page.Start))
+	}
+
+	policies := make([]metadata.SetTplSyncPolicy, 0)
+	if err := query.All(kit.Ctx, &policies); err != nil {
+		blog.Errorf("list set template sync policy failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.MultipleSetTplSyncPolicy{
+		Count: int64(total),
+		Info:  policies,
+	}, nil
+}
+
+// listSetTplSyncPolicyFilter generate the db filter of the list set template sync policy option
+func listSetTplSyncPolicyFilter(option *metadata.ListSetTplSyncPolicyOption) mapstr.MapStr {
+	filter := mapstr.MapStr{}
+	if option.BizID != 0 {
+		filter[common.BKAppIDField] = option.BizID
+	}
+	if len(option.SetTemplateIDs) > 0 {
+		filter[common.BKSetTemplateIDField] = mapstr.MapStr{common.BKDBIN: option.SetTemplateIDs}
+	}
+	if len(option.Modes) > 0 {
+		filter["mode"] = mapstr.MapStr{common.BKDBIN: option.Modes}
+	}
+	return filter
+}
+
+// UpdateSetTplSyncRun update the last run record of set template sync policy
+func (p *setTemplateOperation) UpdateSetTplSyncRun(kit *rest.Kit,
+	option *metadata.UpdateSetTplSyncRunOption) errors.CCErrorCoder {
+
+	filter := mapstr.MapStr{
+		common.BKAppIDField:         option.BizID,
+		common.BKSetTemplateIDField: option.SetTemplateID,
+	}
+
+	// do not update the last time of the policy, it represents the time when the policy is changed by user
+	updateData := mapstr.MapStr{"last_run": option.LastRun}
+	if err := mongodb.Client().Table(common.BKTableNameSetTemplateSyncPolicy).Update(kit.Ctx, filter,
+		updateData); err != nil {
+		blog.Errorf("update set template sync run failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	return nil
+}
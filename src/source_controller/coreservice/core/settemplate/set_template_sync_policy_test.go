@@ -0,0 +1,271 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package settemplate
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTplSyncPolicyOptionValidate(t *testing.T) {
+	window := metadata.SetTplSyncWindow{Weekdays: []int{1, 5}, StartTime: "22:00", EndTime: "02:00"}
+	manyWindows := make([]metadata.SetTplSyncWindow, metadata.SetTplSyncMaxWindowCount+1)
+	for idx := range manyWindows {
+		manyWindows[idx] = window
+	}
+
+	tests := []struct {
+		name      string
+		option    metadata.SetTplSyncPolicyOption
+		errCode   int
+		errFields []interface{}
+	}{
+		{
+			name:   "manual",
+			option: metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeManual},
+		},
+		{
+			name: "auto with canary",
+			option: metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeAuto,
+				MaxConcurrency: metadata.SetTplSyncMaxConcurrency, CanaryCount: 3},
+		},
+		{
+			name: "scheduled",
+			option: metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2,
+				Mode: metadata.SetTplSyncModeScheduled, Windows: []metadata.SetTplSyncWindow{window}},
+		},
+		{
+			name:      "no biz",
+			option:    metadata.SetTplSyncPolicyOption{SetTemplateID: 2, Mode: metadata.SetTplSyncModeManual},
+			errCode:   common.CCErrCommParamsNeedSet,
+			errFields: []interface{}{common.BKAppIDField},
+		},
+		{
+			name:      "no set template",
+			option:    metadata.SetTplSyncPolicyOption{BizID: 1, Mode: metadata.SetTplSyncModeManual},
+			errCode:   common.CCErrCommParamsNeedSet,
+			errFields: []interface{}{common.BKSetTemplateIDField},
+		},
+		{
+			name:      "invalid mode",
+			option:    metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: "daily"},
+			errCode:   common.CCErrCommParamsIsInvalid,
+			errFields: []interface{}{"mode"},
+		},
+		{
+			name:      "scheduled without window",
+			option:    metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeScheduled},
+			errCode:   common.CCErrCommParamsNeedSet,
+			errFields: []interface{}{"windows"},
+		},
+		{
+			name: "too many windows",
+			option: metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2,
+				Mode: metadata.SetTplSyncModeScheduled, Windows: manyWindows},
+			errCode:   common.CCErrCommXXExceedLimit,
+			errFields: []interface{}{"windows", metadata.SetTplSyncMaxWindowCount},
+		},
+		{
+			name: "invalid weekday",
+			option: metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeScheduled,
+				Windows: []metadata.SetTplSyncWindow{{Weekdays: []int{7}, StartTime: "01:00", EndTime: "02:00"}}},
+			errCode:   common.CCErrCommParamsIsInvalid,
+			errFields: []interface{}{"windows"},
+		},
+		{
+			name: "invalid window time",
+			option: metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeScheduled,
+				Windows: []metadata.SetTplSyncWindow{{StartTime: "1:00am", EndTime: "02:00"}}},
+			errCode:   common.CCErrCommParamsIsInvalid,
+			errFields: []interface{}{"windows"},
+		},
+		{
+			name: "empty window",
+			option: metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeScheduled,
+				Windows: []metadata.SetTplSyncWindow{{StartTime: "02:00", EndTime: "02:00"}}},
+			errCode:   common.CCErrCommParamsIsInvalid,
+			errFields: []interface{}{"windows"},
+		},
+		{
+			name: "window on auto mode is also validated",
+			option: metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeAuto,
+				Windows: []metadata.SetTplSyncWindow{{StartTime: "25:00", EndTime: "02:00"}}},
+			errCode:   common.CCErrCommParamsIsInvalid,
+			errFields: []interface{}{"windows"},
+		},
+		{
+			name: "max concurrency exceeds limit",
+			option: metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeAuto,
+				MaxConcurrency: metadata.SetTplSyncMaxConcurrency + 1},
+			errCode:   common.CCErrCommParamsIsInvalid,
+			errFields: []interface{}{"max_concurrency"},
+		},
+		{
+			name: "negative max concurrency",
+			option: metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeAuto,
+				MaxConcurrency: -1},
+			errCode:   common.CCErrCommParamsIsInvalid,
+			errFields: []interface{}{"max_concurrency"},
+		},
+		{
+			name: "negative canary count",
+			option: metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeAuto,
+				CanaryCount: -1},
+			errCode:   common.CCErrCommParamsIsInvalid,
+			errFields: []interface{}{"canary_count"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawErr := tt.option.Validate()
+			require.Equal(t, tt.errCode, rawErr.ErrCode)
+			require.Equal(t, tt.errFields, rawErr.Args)
+		})
+	}
+}
+
+func TestListSetTplSyncPolicyOptionValidate(t *testing.T) {
+	tooManyIDs := make([]int64, common.BKMaxPageSize+1)
+
+	tests := []struct {
+		name    string
+		option  metadata.ListSetTplSyncPolicyOption
+		errCode int
+	}{
+		{
+			name:   "valid",
+			option: metadata.ListSetTplSyncPolicyOption{SetTemplateIDs: []int64{1}, Page: metadata.BasePage{Limit: 10}},
+		},
+		{
+			name:    "too many set templates",
+			option:  metadata.ListSetTplSyncPolicyOption{SetTemplateIDs: tooManyIDs, Page: metadata.BasePage{Limit: 10}},
+			errCode: common.CCErrCommXXExceedLimit,
+		},
+		{
+			name:    "page limit exceeded",
+			option:  metadata.ListSetTplSyncPolicyOption{Page: metadata.BasePage{Limit: common.BKMaxPageSize + 1}},
+			errCode: common.CCErrCommPageLimitIsExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.errCode, tt.option.Validate().ErrCode)
+		})
+	}
+}
+
+func TestUpdateSetTplSyncRunOptionValidate(t *testing.T) {
+	run := &metadata.SetTplSyncRun{Status: metadata.SetTplSyncRunRunning}
+
+	tests := []struct {
+		name    string
+		option  metadata.UpdateSetTplSyncRunOption
+		errCode int
+	}{
+		{name: "valid", option: metadata.UpdateSetTplSyncRunOption{BizID: 1, SetTemplateID: 2, LastRun: run}},
+		{name: "no biz", option: metadata.UpdateSetTplSyncRunOption{SetTemplateID: 2, LastRun: run},
+			errCode: common.CCErrCommParamsNeedSet},
+		{name: "no set template", option: metadata.UpdateSetTplSyncRunOption{BizID: 1, LastRun: run},
+			errCode: common.CCErrCommParamsNeedSet},
+		{name: "no last run", option: metadata.UpdateSetTplSyncRunOption{BizID: 1, SetTemplateID: 2},
+			errCode: common.CCErrCommParamsNeedSet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.errCode, tt.option.Validate().ErrCode)
+		})
+	}
+}
+
+func TestUpsertSetTplSyncPolicyData(t *testing.T) {
+	createTime := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	kit := &rest.Kit{User: "creator", SupplierAccount: "0"}
+	option := &metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeAuto,
+		MaxConcurrency: 5, CanaryCount: 1}
+
+	policy := newSetTplSyncPolicy(kit, option, createTime)
+	require.Equal(t, &metadata.SetTplSyncPolicy{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeAuto,
+		MaxConcurrency: 5, CanaryCount: 1, Creator: "creator", Modifier: "creator", CreateTime: createTime,
+		LastTime: createTime, SupplierAccount: "0"}, policy)
+
+	// updating the policy keeps its creator and last run, and changes its last time to unblock the failed run
+	lastRun := &metadata.SetTplSyncRun{Status: metadata.SetTplSyncRunHalted, StartTime: createTime}
+	policy.LastRun = lastRun
+	updateTime := createTime.Add(time.Hour)
+	window := metadata.SetTplSyncWindow{StartTime: "01:00", EndTime: "03:00"}
+	option = &metadata.SetTplSyncPolicyOption{BizID: 1, SetTemplateID: 2, Mode: metadata.SetTplSyncModeScheduled,
+		Windows: []metadata.SetTplSyncWindow{window}}
+
+	data := updateSetTplSyncPolicy(&rest.Kit{User: "modifier", SupplierAccount: "0"}, policy, option, updateTime)
+	require.Equal(t, mapstr.MapStr{
+		"mode":               metadata.SetTplSyncModeScheduled,
+		"windows":            []metadata.SetTplSyncWindow{window},
+		"max_concurrency":    0,
+		"canary_count":       0,
+		common.ModifierField: "modifier",
+		common.LastTimeField: updateTime,
+	}, data)
+	require.Equal(t, "creator", policy.Creator)
+	require.Equal(t, createTime, policy.CreateTime)
+	require.Equal(t, lastRun, policy.LastRun)
+	require.True(t, policy.LastTime.After(policy.LastRun.StartTime))
+}
+
+func TestListSetTplSyncPolicyFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		option *metadata.ListSetTplSyncPolicyOption
+		expect mapstr.MapStr
+	}{
+		{
+			name:   "all policies",
+			option: &metadata.ListSetTplSyncPolicyOption{},
+			expect: mapstr.MapStr{},
+		},
+		{
+			name: "policies to be scheduled",
+			option: &metadata.ListSetTplSyncPolicyOption{
+				Modes: []metadata.SetTplSyncMode{metadata.SetTplSyncModeAuto, metadata.SetTplSyncModeScheduled}},
+			expect: mapstr.MapStr{"mode": mapstr.MapStr{common.BKDBIN: []metadata.SetTplSyncMode{
+				metadata.SetTplSyncModeAuto, metadata.SetTplSyncModeScheduled}}},
+		},
+		{
+			name:   "policies of set templates in biz",
+			option: &metadata.ListSetTplSyncPolicyOption{BizID: 1, SetTemplateIDs: []int64{2, 3}},
+			expect: mapstr.MapStr{
+				common.BKAppIDField:         int64(1),
+				common.BKSetTemplateIDField: mapstr.MapStr{common.BKDBIN: []int64{2, 3}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, listSetTplSyncPolicyFilter(tt.option))
+		})
+	}
+}
@@ -59,6 +59,28 @@ func (s *coreService) SearchAuditLog(ctx *rest.Contexts) {
 	ctx.RespEntityWithCount(int64(count), auditLogs)
 }
 
+// VerifyAuditChain verify the audit log hash chain of the supplier account
+func (s *coreService) VerifyAuditChain(ctx *rest.Contexts) {
+	opt := metadata.VerifyAuditChainOption{}
+	if err := ctx.DecodeInto(&opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.core.AuditOperation().VerifyAuditChain(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
 // CreateAuditLogDependence is a dependence for host to create service instance audit logs for transfer operation
 func (s *coreService) CreateAuditLogDependence(kit *rest.Kit, logs ...metadata.AuditLog) error {
 	return s.core.AuditOperation().CreateAuditLog(kit, logs...)
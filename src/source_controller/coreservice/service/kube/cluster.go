@@ -84,6 +84,7 @@ func (s *service) BatchUpdateCluster(ctx *rest.Contexts) {
 		ctx.RespAutoError(ctx.Kit.CCError.Error(common.CCErrCommDBUpdateFailed))
 		return
 	}
+	types.FlattenCustomFields(updateData)
 
 	err = mongodb.Client().Table(types.BKTableNameBaseCluster).Update(ctx.Kit.Ctx, filter, updateData)
 	if err != nil {
@@ -125,6 +126,7 @@ func (s *service) CreateCluster(ctx *rest.Contexts) {
 		LastTime:   now,
 	}
 	cluster.SupplierAccount = ctx.Kit.SupplierAccount
+	cluster.CustomFields = types.InitCustomFields(cluster.CustomFields)
 
 	err = mongodb.Client().Table(types.BKTableNameBaseCluster).Insert(ctx.Kit.Ctx, cluster)
 	if err != nil {
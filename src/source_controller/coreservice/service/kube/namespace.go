@@ -83,6 +83,7 @@ func (s *service) CreateNamespace(ctx *rest.Contexts) {
 			LastTime:   now,
 		}
 		data.SupplierAccount = ctx.Kit.SupplierAccount
+		data.CustomFields = types.InitCustomFields(data.CustomFields)
 		namespaces[idx] = data
 
 		if cluster.BizID == data.BizID {
@@ -189,6 +190,7 @@ func (s *service) UpdateNamespace(ctx *rest.Contexts) {
 		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBUpdateFailed))
 		return
 	}
+	types.FlattenCustomFields(updateData)
 
 	// update namespace
 	err = mongodb.Client().Table(types.BKTableNameBaseNamespace).Update(ctx.Kit.Ctx, filter, updateData)
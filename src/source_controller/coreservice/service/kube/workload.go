@@ -104,6 +104,7 @@ func (s *service) CreateWorkload(ctx *rest.Contexts) {
 		}
 		wlBase.Revision = revision
 		wlBase.SupplierAccount = ctx.Kit.SupplierAccount
+		wlBase.CustomFields = types.InitCustomFields(wlBase.CustomFields)
 		data.SetWorkloadBase(wlBase)
 		createData[idx] = data
 	}
@@ -200,6 +201,7 @@ func (s *service) UpdateWorkload(ctx *rest.Contexts) {
 		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBUpdateFailed))
 		return
 	}
+	types.FlattenCustomFields(updateData)
 
 	err = mongodb.Client().Table(table).Update(ctx.Kit.Ctx, cond, updateData)
 	if err != nil {
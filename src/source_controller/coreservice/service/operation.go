@@ -243,6 +243,39 @@ func (s *coreService) TimerFreshData(ctx *rest.Contexts) {
 	ctx.RespEntity(true)
 }
 
+// CreateChartSnapshots create today's snapshots of all snapshot charts
+func (s *coreService) CreateChartSnapshots(ctx *rest.Contexts) {
+	ctx.SetReadPreference(common.SecondaryPreferredMode)
+	if err := s.core.StatisticOperation().CreateChartSnapshots(ctx.Kit); err != nil {
+		blog.Errorf("create chart snapshots failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// SearchChartSnapshots search daily snapshot data of snapshot chart
+func (s *coreService) SearchChartSnapshots(ctx *rest.Contexts) {
+	opt := new(metadata.SearchChartSnapshotOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Validate(); err != nil {
+		blog.Errorf("search chart snapshots option is invalid, err: %v, opt: %+v, rid: %s", err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	result, err := s.core.StatisticOperation().SearchChartSnapshots(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
 // SearchCloudMapping TODO
 func (s *coreService) SearchCloudMapping(ctx *rest.Contexts) {
 	opt := make(map[string]interface{})
@@ -351,6 +351,8 @@ func (s *coreService) audit(web *restful.WebService) {
 		Handler: s.CreateAuditLog})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/auditlog",
 		Handler: s.SearchAuditLog})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/auditlog/chain/verify",
+		Handler: s.VerifyAuditChain})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -382,6 +384,10 @@ func (s *coreService) initOperation(web *restful.WebService) {
 		Handler: s.SearchTimerChartData})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/start/operation/chart/timer",
 		Handler: s.TimerFreshData})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/operation/chart/snapshot",
+		Handler: s.CreateChartSnapshots})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/operation/chart/snapshot",
+		Handler: s.SearchChartSnapshots})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -91,5 +91,15 @@ func (s *coreService) initProcess(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/service_template/attribute",
 		Handler: s.ListServiceTemplateAttribute})
 
+	// service template revision
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/process/service_template/revision",
+		Handler: s.CreateSvcTempRevision})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/process/service_template/revision",
+		Handler: s.ListSvcTempRevision})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/process/service_template/version_pin",
+		Handler: s.PinSvcTempVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/process/service_template/version_pin",
+		Handler: s.ListSvcTempVersionPin})
+
 	utility.AddToRestfulWebService(web)
 }
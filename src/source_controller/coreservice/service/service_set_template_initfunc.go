@@ -52,5 +52,12 @@ func (s *coreService) initSetTemplate(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/set_template/attribute",
 		Handler: s.ListSetTemplateAttribute})
 
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/set_template/sync_policy",
+		Handler: s.UpsertSetTplSyncPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/set_template/sync_policy",
+		Handler: s.ListSetTplSyncPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/set_template/sync_policy/last_run",
+		Handler: s.UpdateSetTplSyncRun})
+
 	utility.AddToRestfulWebService(web)
 }
@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// CreateSvcTempRevision create service template revision
+func (s *coreService) CreateSvcTempRevision(ctx *rest.Contexts) {
+	revision := new(metadata.ServiceTemplateRevision)
+	if err := ctx.DecodeInto(revision); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := revision.Validate(); err.ErrCode != 0 {
+		ctx.RespAutoError(err.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.core.ProcessOperation().CreateSvcTempRevision(ctx.Kit, revision)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// ListSvcTempRevision list service template revisions
+func (s *coreService) ListSvcTempRevision(ctx *rest.Contexts) {
+	option := new(metadata.ListSvcTempRevisionOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := option.Validate(); err.ErrCode != 0 {
+		ctx.RespAutoError(err.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.core.ProcessOperation().ListSvcTempRevision(ctx.Kit, option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// PinSvcTempVersion pin modules to a service template revision
+func (s *coreService) PinSvcTempVersion(ctx *rest.Contexts) {
+	option := new(metadata.PinSvcTempVersionOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := option.Validate(); err.ErrCode != 0 {
+		ctx.RespAutoError(err.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.core.ProcessOperation().PinSvcTempVersion(ctx.Kit, option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ListSvcTempVersionPin list service template version pins of modules
+func (s *coreService) ListSvcTempVersionPin(ctx *rest.Contexts) {
+	option := new(metadata.ListSvcTempVersionPinOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := option.Validate(); err.ErrCode != 0 {
+		ctx.RespAutoError(err.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	pins, err := s.core.ProcessOperation().ListSvcTempVersionPin(ctx.Kit, option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(pins)
+}
@@ -331,3 +331,68 @@ func (s *coreService) ListSetTemplateAttribute(ctx *rest.Contexts) {
 
 	ctx.RespEntity(data)
 }
+
+// UpsertSetTplSyncPolicy create or update set template sync policy
+func (s *coreService) UpsertSetTplSyncPolicy(ctx *rest.Contexts) {
+	option := new(metadata.SetTplSyncPolicyOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := option.Validate(); err.ErrCode != 0 {
+		ctx.RespAutoError(err.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	policy, err := s.core.SetTemplateOperation().UpsertSetTplSyncPolicy(ctx.Kit, option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(policy)
+}
+
+// ListSetTplSyncPolicy list set template sync policies
+func (s *coreService) ListSetTplSyncPolicy(ctx *rest.Contexts) {
+	option := new(metadata.ListSetTplSyncPolicyOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := option.Validate(); err.ErrCode != 0 {
+		ctx.RespAutoError(err.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	data, err := s.core.SetTemplateOperation().ListSetTplSyncPolicy(ctx.Kit, option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(data)
+}
+
+// UpdateSetTplSyncRun update the last run record of set template sync policy
+func (s *coreService) UpdateSetTplSyncRun(ctx *rest.Contexts) {
+	option := new(metadata.UpdateSetTplSyncRunOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := option.Validate(); err.ErrCode != 0 {
+		ctx.RespAutoError(err.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.core.SetTemplateOperation().UpdateSetTplSyncRun(ctx.Kit, option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
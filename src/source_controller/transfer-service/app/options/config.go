@@ -49,6 +49,10 @@ type SyncConfig struct {
 	SyncIntervalHours int `mapstructure:"syncIntervalHours"`
 	// TransMediumAddr is the transfer medium addresses
 	TransMediumAddr []string `mapstructure:"transferMediumAddress"`
+	// MediumType is the transfer medium type, default is http
+	MediumType MediumType `mapstructure:"mediumType"`
+	// FileMedium is the file system transfer medium config, only used when medium type is file
+	FileMedium *FileMediumConfig `mapstructure:"fileMedium"`
 }
 
 // Validate SyncConfig
@@ -71,8 +75,51 @@ func (s *SyncConfig) Validate() error {
 		return fmt.Errorf("invalid sync role: %s", s.Role)
 	}
 
-	if len(s.TransMediumAddr) == 0 {
-		return fmt.Errorf("transfer medium address is not set")
+	switch s.MediumType {
+	case "", MediumTypeHttp:
+		if len(s.TransMediumAddr) == 0 {
+			return fmt.Errorf("transfer medium address is not set")
+		}
+	case MediumTypeFile:
+		if s.FileMedium == nil {
+			return errors.New("file medium config is not set")
+		}
+		if err := s.FileMedium.Validate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid transfer medium type: %s", s.MediumType)
+	}
+
+	return nil
+}
+
+// MediumType is the type of transfer medium that cmdb sync data is exchanged through
+type MediumType string
+
+const (
+	// MediumTypeHttp is the http transfer medium service
+	MediumTypeHttp MediumType = "http"
+	// MediumTypeFile is the file system transfer medium, sync data is exchanged by bundle files in a directory
+	MediumTypeFile MediumType = "file"
+)
+
+// FileMediumConfig is the file system transfer medium config
+type FileMediumConfig struct {
+	// Dir is the directory that sync data bundles are written to or imported from
+	Dir string `mapstructure:"dir"`
+	// SignKey is the key used to sign and verify sync data bundles, must be the same in all environments
+	SignKey string `mapstructure:"signKey"`
+}
+
+// Validate FileMediumConfig
+func (s *FileMediumConfig) Validate() error {
+	if s.Dir == "" {
+		return errors.New("file medium directory is not set")
+	}
+
+	if s.SignKey == "" {
+		return errors.New("file medium sign key is not set")
 	}
 
 	return nil
@@ -92,6 +139,9 @@ const (
 type DestExSyncConf struct {
 	IDRules     []IDRuleEnvConf   `mapstructure:"idRules"`
 	InnerDataID []InnerDataIDConf `mapstructure:"innerDataID"`
+	// ConflictPolicies are the conflict policies of the resources, the policy of resource that is not set is
+	// source_wins, which overwrites the locally modified data with the incoming data
+	ConflictPolicies []ConflictPolicyConf `mapstructure:"conflictPolicies"`
 }
 
 // Validate DestExSyncConf
@@ -151,9 +201,47 @@ func (s *DestExSyncConf) Validate() error {
 		}
 	}
 
+	// validate conflict policies
+	resPolicyMap := make(map[types.ResType]struct{})
+	for i, policy := range s.ConflictPolicies {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("validate conflict policy(index: %d) failed, err: %v", i, err)
+		}
+
+		if _, exists := resPolicyMap[policy.Resource]; exists {
+			return fmt.Errorf("%s conflict policy is duplicated", policy.Resource)
+		}
+		resPolicyMap[policy.Resource] = struct{}{}
+	}
+
 	return nil
 }
 
+// ConflictPolicyConf is the conflict policy config for one resource
+type ConflictPolicyConf struct {
+	// Resource is the resource type that the policy takes effect
+	Resource types.ResType `mapstructure:"resource"`
+	// Policy is the policy to handle conflicts between locally modified data and the incoming sync data
+	Policy types.ConflictPolicy `mapstructure:"policy"`
+}
+
+// Validate ConflictPolicyConf
+func (s *ConflictPolicyConf) Validate() error {
+	isValidRes := false
+	for _, resType := range types.ListAllResType() {
+		if resType == s.Resource {
+			isValidRes = true
+			break
+		}
+	}
+
+	if !isValidRes {
+		return fmt.Errorf("conflict policy resource %s is invalid", s.Resource)
+	}
+
+	return s.Policy.Validate()
+}
+
 // IDRuleEnvConf is the id rule config for one environment
 type IDRuleEnvConf struct {
 	// Name is the cmdb transfer service name
@@ -94,6 +94,8 @@ func (s *Service) initService(api *restful.WebService) {
 	})
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/sync/cmdb/data", Handler: s.SyncCmdbData})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/sync/conflict",
+		Handler: s.ListSyncConflicts})
 
 	utility.AddToRestfulWebService(api)
 }
@@ -51,3 +51,21 @@ func (s *Service) SyncCmdbData(cts *rest.Contexts) {
 
 	cts.RespEntity(nil)
 }
+
+// ListSyncConflicts list the conflicts between local data and incoming sync data in destination cmdb
+func (s *Service) ListSyncConflicts(cts *rest.Contexts) {
+	opt := new(types.ListSyncConflictOpt)
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	res, err := s.syncer.ListSyncConflicts(cts.Kit, opt)
+	if err != nil {
+		blog.Errorf("list sync conflicts failed, err: %v, opt: %+v, rid: %s", err, opt, cts.Kit.Rid)
+		cts.RespAutoError(err)
+		return
+	}
+
+	cts.RespEntity(res)
+}
@@ -27,6 +27,8 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/lock"
 	commonutil "configcenter/src/common/util"
+	"configcenter/src/source_controller/transfer-service/app/options"
+	"configcenter/src/source_controller/transfer-service/sync/logics"
 	"configcenter/src/source_controller/transfer-service/sync/util"
 	"configcenter/src/storage/driver/redis"
 )
@@ -90,3 +92,22 @@ func (s *Syncer) SyncCmdbData(kit *rest.Kit, opt *types.SyncCmdbDataOption) erro
 
 	return nil
 }
+
+// ListSyncConflicts list the conflicts between local data and incoming sync data in destination cmdb
+func (s *Syncer) ListSyncConflicts(kit *rest.Kit, opt *types.ListSyncConflictOpt) (*types.ListSyncConflictRes,
+	error) {
+
+	if !s.enableSync {
+		return nil, errors.New("sync is disabled")
+	}
+
+	if s.role != options.SyncRoleDest {
+		return nil, errors.New("sync conflicts only exist in destination cmdb")
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	return logics.ListConflicts(util.ConvertKit(kit), opt)
+}
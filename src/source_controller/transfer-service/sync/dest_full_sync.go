@@ -48,20 +48,33 @@ func (s *Syncer) loopPullFullSyncData() {
 			continue
 		}
 
-		// get object ids for object instance resource sync
+		// get object ids for object instance resource sync after model metadata is synced, so that the instances of
+		// the newly synced objects can also be synced
 		var objIDs, quotedObjIDs []string
-		util.RetryWrapper(3, func() (bool, error) {
-			objIDs, quotedObjIDs, err = s.metadata.GetCommonObjIDs()
-			if err != nil {
-				blog.Errorf("get object ids failed, err: %v", err)
-				return true, err
+		objIDsFetched := false
+		getObjIDs := func() {
+			if objIDsFetched {
+				return
 			}
-			return false, nil
-		})
+			util.RetryWrapper(3, func() (bool, error) {
+				objIDs, quotedObjIDs, err = s.metadata.GetCommonObjIDs()
+				if err != nil {
+					blog.Errorf("get object ids failed, err: %v", err)
+					return true, err
+				}
+				return false, nil
+			})
+			objIDsFetched = true
+		}
 
 		for _, resType := range types.ListAllResType() {
 			syncer := s.resSyncerMap[resType]
 
+			switch resType {
+			case types.ObjectInstance, types.InstAsst, types.QuotedInstance:
+				getObjIDs()
+			}
+
 			switch resType {
 			case types.ObjectInstance:
 				for _, objID := range objIDs {
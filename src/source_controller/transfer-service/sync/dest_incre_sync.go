@@ -41,7 +41,10 @@ func (s *Syncer) loopPullIncrSyncData(resType types.ResType) {
 
 		hasMore, err := syncer.pullIncrSyncData(ack)
 		if err != nil {
+			// do not acknowledge the failed data so that it can be synced again, e.g. the instances are synced again
+			// after their objects are synced
 			time.Sleep(3 * time.Second)
+			ack = false
 			continue
 		}
 
@@ -0,0 +1,281 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+	"configcenter/src/storage/driver/mongodb"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// conflictIgnoredFields are the fields that are not compared when checking conflicts
+var conflictIgnoredFields = map[string]struct{}{
+	"_id":                {},
+	common.LastTimeField: {},
+}
+
+// handleConflicts check if the incoming data conflicts with the local data, records the conflicts and returns the
+// data that should still be written by the conflict policy of the resource.
+// conflict occurs when the local data is modified after the incoming data is generated in the source cmdb, which
+// means that the local data has been changed in the destination cmdb since the last sync.
+func (l *dataWithIDLogics[T]) handleConflicts(kit *util.Kit, subRes string, op types.ConflictOperation,
+	dataArr []DataWithID[T]) ([]DataWithID[T], error) {
+
+	if len(dataArr) == 0 {
+		return dataArr, nil
+	}
+
+	ids := make([]int64, len(dataArr))
+	for i, data := range dataArr {
+		ids[i] = data.ID
+	}
+
+	// get local data to compare with incoming data
+	localArr := make([]T, 0)
+	cond := mapstr.MapStr{l.idField: mapstr.MapStr{common.BKDBIN: ids}}
+	table := l.table(subRes)
+	if err := mongodb.Client().Table(table).Find(cond).All(kit.Ctx, &localArr); err != nil {
+		blog.Errorf("get local %s data failed, err: %v, cond: %+v, rid: %s", table, err, cond, kit.Rid)
+		return nil, err
+	}
+
+	localMap := make(map[int64]map[string]any)
+	for _, local := range localArr {
+		id, err := l.getID(local, l.idField)
+		if err != nil {
+			blog.Errorf("get local %s data id failed, err: %v, data: %+v, rid: %s", table, err, local, kit.Rid)
+			continue
+		}
+
+		localData, err := convertToComparableMap(local)
+		if err != nil {
+			blog.Errorf("convert local %s data failed, err: %v, data: %+v, rid: %s", table, err, local, kit.Rid)
+			continue
+		}
+		localMap[id] = localData
+	}
+
+	result := make([]DataWithID[T], 0)
+	conflicts := make([]*types.SyncConflict, 0)
+	for _, data := range dataArr {
+		local, exists := localMap[data.ID]
+		if !exists {
+			result = append(result, data)
+			continue
+		}
+
+		incoming, err := convertToComparableMap(data.Data)
+		if err != nil {
+			blog.Errorf("convert incoming %s data failed, err: %v, data: %+v, rid: %s", table, err, data, kit.Rid)
+			result = append(result, data)
+			continue
+		}
+
+		conflict := l.checkConflict(local, incoming)
+		if conflict == nil {
+			result = append(result, data)
+			continue
+		}
+
+		conflict.SubRes = subRes
+		conflict.ID = data.ID
+		conflict.SrcEnv = data.Env
+		conflict.Operation = op
+		conflicts = append(conflicts, conflict)
+
+		if l.conflictPolicy == types.SourceWins {
+			result = append(result, data)
+		}
+	}
+
+	if err := saveConflicts(kit, conflicts); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// checkConflict check if the local data is modified after the incoming data is generated, returns nil if no conflict
+func (l *dataWithIDLogics[T]) checkConflict(local, incoming map[string]any) *types.SyncConflict {
+	localTime, srcTime := getLastTime(local), getLastTime(incoming)
+	if localTime == nil || srcTime == nil || !localTime.After(*srcTime) {
+		return nil
+	}
+
+	diffs := make([]types.ConflictFieldDiff, 0)
+	for field, localVal := range local {
+		if _, ignored := conflictIgnoredFields[field]; ignored {
+			continue
+		}
+		if incomingVal := incoming[field]; !cmp.Equal(localVal, incomingVal) {
+			diffs = append(diffs, types.ConflictFieldDiff{Field: field, LocalValue: localVal,
+				IncomingValue: incomingVal})
+		}
+	}
+
+	for field, incomingVal := range incoming {
+		if _, ignored := conflictIgnoredFields[field]; ignored {
+			continue
+		}
+		if _, exists := local[field]; !exists {
+			diffs = append(diffs, types.ConflictFieldDiff{Field: field, IncomingValue: incomingVal})
+		}
+	}
+
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Field < diffs[j].Field
+	})
+
+	status := types.ConflictResolved
+	if l.conflictPolicy == types.RejectAndReport {
+		status = types.ConflictPending
+	}
+
+	return &types.SyncConflict{
+		ResType:       l.resType,
+		Policy:        l.conflictPolicy,
+		Status:        status,
+		Diffs:         diffs,
+		LocalLastTime: localTime,
+		SrcLastTime:   srcTime,
+	}
+}
+
+// convertToComparableMap convert data to map with json types, so that the local data read from db and the incoming
+// data parsed from json can be compared
+func convertToComparableMap(data any) (map[string]any, error) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]any)
+	if err = json.Unmarshal(js, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// getLastTime get the last modified time of the data, returns nil if the data has no valid last modified time
+func getLastTime(data map[string]any) *time.Time {
+	timeStr, ok := data[common.LastTimeField].(string)
+	if !ok {
+		return nil
+	}
+
+	lastTime, err := time.Parse(time.RFC3339Nano, timeStr)
+	if err != nil || lastTime.IsZero() {
+		return nil
+	}
+	return &lastTime
+}
+
+// saveConflicts save sync conflicts, the conflict of the same data and operation is updated to the latest one
+func saveConflicts(kit *util.Kit, conflicts []*types.SyncConflict) error {
+	now := time.Now()
+	for _, conflict := range conflicts {
+		conflict.LastTime = now
+
+		cond := mapstr.MapStr{
+			"resource_type": conflict.ResType,
+			"sub_resource":  conflict.SubRes,
+			"id":            conflict.ID,
+			"operation":     conflict.Operation,
+		}
+		err := mongodb.Client().Table(types.SyncConflictTable).Upsert(kit.Ctx, cond, conflict)
+		if err != nil {
+			blog.Errorf("save sync conflict failed, err: %v, conflict: %+v, rid: %s", err, conflict, kit.Rid)
+			return err
+		}
+
+		blog.Warnf("%s %s-%s data %d conflicts with local data, policy: %s, rid: %s", conflict.Operation,
+			conflict.ResType, conflict.SubRes, conflict.ID, conflict.Policy, kit.Rid)
+	}
+	return nil
+}
+
+// InitConflictTable create sync conflict table if not exists
+func InitConflictTable(ctx context.Context) error {
+	exists, err := mongodb.Client().HasTable(ctx, types.SyncConflictTable)
+	if err != nil {
+		blog.Errorf("check if %s table exists failed, err: %v", types.SyncConflictTable, err)
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	err = mongodb.Client().CreateTable(ctx, types.SyncConflictTable)
+	if err != nil && !mongodb.Client().IsDuplicatedError(err) {
+		blog.Errorf("create %s table failed, err: %v", types.SyncConflictTable, err)
+		return err
+	}
+	return nil
+}
+
+// ListConflicts list sync conflicts
+func ListConflicts(kit *util.Kit, opt *types.ListSyncConflictOpt) (*types.ListSyncConflictRes, error) {
+	cond := mapstr.MapStr{}
+	if opt.ResType != "" {
+		cond["resource_type"] = opt.ResType
+	}
+	if opt.SubRes != "" {
+		cond["sub_resource"] = opt.SubRes
+	}
+	if opt.Status != "" {
+		cond["status"] = opt.Status
+	}
+
+	if opt.Page.EnableCount {
+		count, err := mongodb.Client().Table(types.SyncConflictTable).Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count sync conflicts failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+			return nil, err
+		}
+		return &types.ListSyncConflictRes{Count: count}, nil
+	}
+
+	sort := opt.Page.Sort
+	if sort == "" {
+		sort = "-" + common.LastTimeField
+	}
+
+	conflicts := make([]types.SyncConflict, 0)
+	err := mongodb.Client().Table(types.SyncConflictTable).Find(cond).Sort(sort).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).All(kit.Ctx, &conflicts)
+	if err != nil {
+		blog.Errorf("list sync conflicts failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return nil, err
+	}
+
+	return &types.ListSyncConflictRes{Info: conflicts}, nil
+}
@@ -0,0 +1,183 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertToComparableMap(t *testing.T) {
+	type testData struct {
+		ID       int64     `json:"id"`
+		Name     string    `json:"name"`
+		Tags     []string  `json:"tags"`
+		LastTime time.Time `json:"last_time"`
+	}
+	lastTime := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	testCases := []struct {
+		name     string
+		data     any
+		expected map[string]any
+		hasErr   bool
+	}{
+		{
+			name: "struct is converted to json types",
+			data: testData{ID: 1, Name: "host", Tags: []string{"a"}, LastTime: lastTime},
+			expected: map[string]any{
+				"id":        float64(1),
+				"name":      "host",
+				"tags":      []any{"a"},
+				"last_time": "2024-01-02T03:04:05.000000006Z",
+			},
+		},
+		{
+			name:     "map with int value is converted to float",
+			data:     map[string]any{"id": int64(2), "nested": map[string]int{"a": 1}},
+			expected: map[string]any{"id": float64(2), "nested": map[string]any{"a": float64(1)}},
+		},
+		{
+			name:   "data that can not be marshaled",
+			data:   map[string]any{"ch": make(chan int)},
+			hasErr: true,
+		},
+		{
+			name:   "data that is not an object",
+			data:   []int{1, 2},
+			hasErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		res, err := convertToComparableMap(tc.data)
+		if tc.hasErr {
+			require.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, res, tc.name)
+	}
+}
+
+func TestCheckConflict(t *testing.T) {
+	srcTime := "2024-01-01T00:00:00Z"
+	localTime := "2024-01-02T00:00:00Z"
+	srcTimeVal, _ := time.Parse(time.RFC3339Nano, srcTime)
+	localTimeVal, _ := time.Parse(time.RFC3339Nano, localTime)
+
+	testCases := []struct {
+		name     string
+		policy   types.ConflictPolicy
+		local    map[string]any
+		incoming map[string]any
+		expected *types.SyncConflict
+	}{
+		{
+			name:     "local data is not modified after incoming data",
+			policy:   types.SourceWins,
+			local:    map[string]any{"name": "a", common.LastTimeField: srcTime},
+			incoming: map[string]any{"name": "b", common.LastTimeField: localTime},
+		},
+		{
+			name:     "local data has the same modified time as incoming data",
+			policy:   types.SourceWins,
+			local:    map[string]any{"name": "a", common.LastTimeField: srcTime},
+			incoming: map[string]any{"name": "b", common.LastTimeField: srcTime},
+		},
+		{
+			name:     "local data has no last time",
+			policy:   types.SourceWins,
+			local:    map[string]any{"name": "a"},
+			incoming: map[string]any{"name": "b", common.LastTimeField: srcTime},
+		},
+		{
+			name:     "incoming data has invalid last time",
+			policy:   types.SourceWins,
+			local:    map[string]any{"name": "a", common.LastTimeField: localTime},
+			incoming: map[string]any{"name": "b", common.LastTimeField: "invalid"},
+		},
+		{
+			name:     "local data is modified later but has no field difference",
+			policy:   types.SourceWins,
+			local:    map[string]any{"_id": "x", "name": "a", "tags": []any{"t"}, common.LastTimeField: localTime},
+			incoming: map[string]any{"_id": "y", "name": "a", "tags": []any{"t"}, common.LastTimeField: srcTime},
+		},
+		{
+			name:   "conflict resolved by source wins policy",
+			policy: types.SourceWins,
+			local: map[string]any{"name": "a", "ip": "1.1.1.1", "local_only": 1.0,
+				common.LastTimeField: localTime},
+			incoming: map[string]any{"name": "b", "ip": "1.1.1.1", "incoming_only": true,
+				common.LastTimeField: srcTime},
+			expected: &types.SyncConflict{
+				ResType: types.Host,
+				Policy:  types.SourceWins,
+				Status:  types.ConflictResolved,
+				Diffs: []types.ConflictFieldDiff{
+					{Field: "incoming_only", IncomingValue: true},
+					{Field: "local_only", LocalValue: 1.0},
+					{Field: "name", LocalValue: "a", IncomingValue: "b"},
+				},
+				LocalLastTime: &localTimeVal,
+				SrcLastTime:   &srcTimeVal,
+			},
+		},
+		{
+			name:     "conflict pending by reject policy",
+			policy:   types.RejectAndReport,
+			local:    map[string]any{"name": "a", common.LastTimeField: localTime},
+			incoming: map[string]any{"name": "b", common.LastTimeField: srcTime},
+			expected: &types.SyncConflict{
+				ResType:       types.Host,
+				Policy:        types.RejectAndReport,
+				Status:        types.ConflictPending,
+				Diffs:         []types.ConflictFieldDiff{{Field: "name", LocalValue: "a", IncomingValue: "b"}},
+				LocalLastTime: &localTimeVal,
+				SrcLastTime:   &srcTimeVal,
+			},
+		},
+		{
+			name:     "conflict resolved by dest wins policy",
+			policy:   types.DestWins,
+			local:    map[string]any{"tags": []any{"a"}, common.LastTimeField: localTime},
+			incoming: map[string]any{"tags": []any{"a", "b"}, common.LastTimeField: srcTime},
+			expected: &types.SyncConflict{
+				ResType: types.Host,
+				Policy:  types.DestWins,
+				Status:  types.ConflictResolved,
+				Diffs: []types.ConflictFieldDiff{
+					{Field: "tags", LocalValue: []any{"a"}, IncomingValue: []any{"a", "b"}},
+				},
+				LocalLastTime: &localTimeVal,
+				SrcLastTime:   &srcTimeVal,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		lgc := &dataWithIDLogics[any]{
+			resLogicsConfig: &resLogicsConfig{resType: types.Host, conflictPolicy: tc.policy},
+		}
+		require.Equal(t, tc.expected, lgc.checkConflict(tc.local, tc.incoming), tc.name)
+	}
+}
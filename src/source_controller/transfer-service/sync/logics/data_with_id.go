@@ -42,6 +42,9 @@ type dataWithIDLgc[T any] struct {
 	parseData     func(data T, srcIDConf, destIDConf *options.InnerDataIDConf) (T, error)
 	getID         func(data T, idField string) (int64, error)
 	getRelatedIDs func(subRes string, data T) (map[types.ResType][]int64, error)
+	// getObjIDs get the ids of the objects that the data belongs to, the data can only be inserted after the objects
+	// are synced, nil means that the data does not belong to any object
+	getObjIDs func(subRes string, data T) []string
 }
 
 func newDataWithIDLogics[T any](conf *resLogicsConfig, lgc *dataWithIDLgc[T]) *dataWithIDLogics[T] {
@@ -55,6 +58,8 @@ func newDataWithIDLogics[T any](conf *resLogicsConfig, lgc *dataWithIDLgc[T]) *d
 type DataWithID[T any] struct {
 	ID   int64
 	Data T
+	// Env is the name of the source environment that the data belongs to
+	Env string
 }
 
 // ParseDataArr parse data array to actual type
@@ -107,6 +112,7 @@ func (l *dataWithIDLogics[T]) convertToDataWithIDArr(isSrc bool, srcEnv, subRes
 		res = append(res, DataWithID[T]{
 			ID:   id,
 			Data: val,
+			Env:  srcEnv,
 		})
 	}
 	return res
@@ -285,6 +291,10 @@ func (l *dataWithIDLogics[T]) InsertData(kit *util.Kit, subRes string, data any)
 		return nil
 	}
 
+	if err := l.checkObjExists(kit, subRes, dataArr); err != nil {
+		return err
+	}
+
 	insertData := make([]T, len(dataArr))
 	for i, info := range dataArr {
 		insertData[i] = info.Data
@@ -306,6 +316,11 @@ func (l *dataWithIDLogics[T]) UpdateData(kit *util.Kit, subRes string, data any)
 		return fmt.Errorf("data type %T is invalid", data)
 	}
 
+	dataArr, err := l.handleConflicts(kit, subRes, types.ConflictUpdate, dataArr)
+	if err != nil {
+		return err
+	}
+
 	if len(dataArr) == 0 {
 		return nil
 	}
@@ -329,6 +344,12 @@ func (l *dataWithIDLogics[T]) DeleteData(kit *util.Kit, subRes string, data any)
 	case []int64:
 		ids = val
 	case []DataWithID[T]:
+		// only the deleted data with detail can be checked for conflicts
+		var err error
+		val, err = l.handleConflicts(kit, subRes, types.ConflictDelete, val)
+		if err != nil {
+			return err
+		}
 		ids = make([]int64, len(val))
 		for i, info := range val {
 			ids[i] = info.ID
@@ -353,3 +374,17 @@ func (l *dataWithIDLogics[T]) DeleteData(kit *util.Kit, subRes string, data any)
 	}
 	return nil
 }
+
+// checkObjExists check if the objects that the data belongs to are synced
+func (l *dataWithIDLogics[T]) checkObjExists(kit *util.Kit, subRes string, dataArr []DataWithID[T]) error {
+	if l.getObjIDs == nil {
+		return nil
+	}
+
+	objIDs := make([]string, 0)
+	for _, data := range dataArr {
+		objIDs = append(objIDs, l.getObjIDs(subRes, data.Data)...)
+	}
+
+	return checkObjExists(kit, objIDs)
+}
@@ -45,6 +45,9 @@ func newObjInstLogics(conf *resLogicsConfig) *objInstLogics {
 			},
 			parseData: parseMapStr,
 			getID:     getMapStrID,
+			getObjIDs: func(subRes string, _ mapstr.MapStr) []string {
+				return []string{subRes}
+			},
 			getRelatedIDs: func(subRes string, data mapstr.MapStr) (map[types.ResType][]int64, error) {
 				_, exists := data[common.BKAppIDField]
 				if exists {
@@ -67,6 +70,10 @@ func (o *objInstLogics) InsertData(kit *util.Kit, subRes string, data any) error
 		return nil
 	}
 
+	if err := o.checkObjExists(kit, subRes, dataArr); err != nil {
+		return err
+	}
+
 	insertData := make([]mapstr.MapStr, len(dataArr))
 	mappings := make([]metadata.ObjectMapping, len(dataArr))
 	for i, info := range dataArr {
@@ -100,6 +107,14 @@ func (o *objInstLogics) DeleteData(kit *util.Kit, subRes string, data any) error
 	case []int64:
 		ids = val
 	case []DataWithID[mapstr.MapStr]:
+		// only the deleted data with detail can be checked for conflicts
+		var err error
+		val, err = o.handleConflicts(kit, subRes, types.ConflictDelete, val)
+		if err != nil {
+			return err
+		}
+		data = val
+
 		ids = make([]int64, len(val))
 		for i, info := range val {
 			ids[i] = info.ID
@@ -142,6 +157,9 @@ var instAsstLgc = &dataWithIDLgc[metadata.InstAsst]{
 	getID: func(data metadata.InstAsst, idField string) (int64, error) {
 		return data.ID, nil
 	},
+	getObjIDs: func(_ string, data metadata.InstAsst) []string {
+		return []string{data.ObjectID, data.AsstObjectID}
+	},
 	getRelatedIDs: func(subRes string, data metadata.InstAsst) (map[types.ResType][]int64, error) {
 		idMap := make(map[types.ResType][]int64)
 		idMap[getObjResType(data.ObjectID)] = append(idMap[getObjResType(data.ObjectID)], data.InstID)
@@ -158,6 +176,9 @@ var quotedInstLgc = &dataWithIDLgc[mapstr.MapStr]{
 	},
 	parseData: parseMapStr,
 	getID:     getMapStrID,
+	getObjIDs: func(subRes string, _ mapstr.MapStr) []string {
+		return []string{subRes}
+	},
 	getRelatedIDs: func(subRes string, data mapstr.MapStr) (map[types.ResType][]int64, error) {
 		srcObjID := metadata.GetModelQuoteSrcObjID(subRes)
 		instID, err := commonutil.GetInt64ByInterface(data[common.BKInstIDField])
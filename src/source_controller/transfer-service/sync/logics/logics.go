@@ -40,6 +40,12 @@ type Logics interface {
 // New creates a new resource type to resource sync logics map
 func New(conf *LogicsConfig) map[types.ResType]Logics {
 	lgcMap := map[types.ResType]Logics{
+		types.Classification:  newDataWithIDLogics(conf.genResLgcConf(types.Classification), classificationLgc),
+		types.AsstKind:        newDataWithIDLogics(conf.genResLgcConf(types.AsstKind), asstKindLgc),
+		types.Object:          newDataWithIDLogics(conf.genResLgcConf(types.Object), objectLgc),
+		types.AttributeGroup:  newDataWithIDLogics(conf.genResLgcConf(types.AttributeGroup), attrGroupLgc),
+		types.ObjectAttribute: newDataWithIDLogics(conf.genResLgcConf(types.ObjectAttribute), objAttrLgc),
+		types.ObjectUnique:    newDataWithIDLogics(conf.genResLgcConf(types.ObjectUnique), objUniqueLgc),
 		types.Biz:             newDataWithIDLogics(conf.genResLgcConf(types.Biz), bizLgc),
 		types.Set:             newDataWithIDLogics(conf.genResLgcConf(types.Set), setLgc),
 		types.Module:          newDataWithIDLogics(conf.genResLgcConf(types.Module), moduleLgc),
@@ -61,14 +67,22 @@ type LogicsConfig struct {
 	Metadata      *metadata.Metadata
 	IDRuleMap     map[types.ResType]map[string][]options.IDRuleInfo
 	SrcInnerIDMap map[string]*options.InnerDataIDConf
+	// ConflictPolicies is the resource type to conflict policy map, default policy is source wins
+	ConflictPolicies map[types.ResType]types.ConflictPolicy
 }
 
 func (c *LogicsConfig) genResLgcConf(resType types.ResType) *resLogicsConfig {
+	policy, exists := c.ConflictPolicies[resType]
+	if !exists {
+		policy = types.SourceWins
+	}
+
 	return &resLogicsConfig{
-		resType:       resType,
-		metadata:      c.Metadata,
-		idRuleMap:     c.IDRuleMap,
-		srcInnerIDMap: c.SrcInnerIDMap,
+		resType:        resType,
+		metadata:       c.Metadata,
+		idRuleMap:      c.IDRuleMap,
+		srcInnerIDMap:  c.SrcInnerIDMap,
+		conflictPolicy: policy,
 	}
 }
 
@@ -78,6 +92,8 @@ type resLogicsConfig struct {
 	metadata      *metadata.Metadata
 	idRuleMap     map[types.ResType]map[string][]options.IDRuleInfo
 	srcInnerIDMap map[string]*options.InnerDataIDConf
+	// conflictPolicy is the policy to handle conflicts between local data and incoming sync data
+	conflictPolicy types.ConflictPolicy
 }
 
 // ResType get resource type
@@ -0,0 +1,99 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	commonutil "configcenter/src/common/util"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+var (
+	classificationLgc = newModelLgc(common.BKTableNameObjClassification, nil)
+	asstKindLgc       = newModelLgc(common.BKTableNameAsstDes, nil)
+	objectLgc         = newModelLgc(common.BKTableNameObjDes, nil)
+	attrGroupLgc      = newModelLgc(common.BKTableNamePropertyGroup, getMapStrObjIDs)
+	objAttrLgc        = newModelLgc(common.BKTableNameObjAttDes, getMapStrObjIDs)
+	objUniqueLgc      = newModelLgc(common.BKTableNameObjUnique, getMapStrObjIDs)
+)
+
+// newModelLgc new model metadata sync logics, model metadata are all stored with an unique "id" field
+func newModelLgc(table string,
+	getObjIDs func(subRes string, data mapstr.MapStr) []string) *dataWithIDLgc[mapstr.MapStr] {
+
+	return &dataWithIDLgc[mapstr.MapStr]{
+		idField: common.BKFieldID,
+		table: func(_ string) string {
+			return table
+		},
+		parseData: parseMapStr,
+		getID:     getMapStrID,
+		getObjIDs: getObjIDs,
+	}
+}
+
+// getMapStrObjIDs get the object id of the model metadata or instance that the data belongs to
+func getMapStrObjIDs(_ string, data mapstr.MapStr) []string {
+	return []string{commonutil.GetStrByInterface(data[common.BKObjIDField])}
+}
+
+// checkObjExists check if all the objects exist in this environment, data that belongs to the objects can only be
+// written after the objects are synced, otherwise returns error so that the data can be synced again later
+func checkObjExists(kit *util.Kit, objIDs []string) error {
+	objIDMap := make(map[string]struct{})
+	for _, objID := range objIDs {
+		if objID != "" {
+			objIDMap[objID] = struct{}{}
+		}
+	}
+
+	if len(objIDMap) == 0 {
+		return nil
+	}
+
+	uniqueObjIDs := make([]string, 0, len(objIDMap))
+	for objID := range objIDMap {
+		uniqueObjIDs = append(uniqueObjIDs, objID)
+	}
+
+	cond := mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: uniqueObjIDs}}
+	existObjIDs, err := mongodb.Client().Table(common.BKTableNameObjDes).Distinct(kit.Ctx, common.BKObjIDField, cond)
+	if err != nil {
+		blog.Errorf("get exist object ids failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return err
+	}
+
+	for _, objID := range existObjIDs {
+		delete(objIDMap, commonutil.GetStrByInterface(objID))
+	}
+
+	if len(objIDMap) > 0 {
+		notExistObjIDs := make([]string, 0, len(objIDMap))
+		for objID := range objIDMap {
+			notExistObjIDs = append(notExistObjIDs, objID)
+		}
+		blog.Errorf("objects %+v are not synced yet, rid: %s", notExistObjIDs, kit.Rid)
+		return fmt.Errorf("objects %v are not synced yet", notExistObjIDs)
+	}
+
+	return nil
+}
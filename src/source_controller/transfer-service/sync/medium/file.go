@@ -0,0 +1,307 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common/blog"
+)
+
+const (
+	// bundleExt is the file extension of sync data bundle, files without this extension are ignored when pulling,
+	// so bundles that are still being copied into the directory should use another extension and be renamed after
+	bundleExt = ".bundle"
+	// pendingFile is the file that records the bundle that has been pulled but not acknowledged yet
+	pendingFile = ".pending"
+	// invalidDir is the directory that stores the bundles that can not be verified or decoded
+	invalidDir = "invalid"
+	// emptySubRes is the directory name for sync data without sub resource
+	emptySubRes = "_"
+)
+
+// fileBundle is the signed and compressed sync data bundle stored in the file system
+type fileBundle struct {
+	ResType     types.ResType `json:"resource_type"`
+	SubRes      string        `json:"sub_resource"`
+	IsIncrement bool          `json:"is_increment"`
+	CreateTime  int64         `json:"create_time"`
+	// Data is the gzip compressed json sync data, which is FullSyncTransData or IncrSyncTransData
+	Data []byte `json:"data"`
+	// Signature is the hex encoded hmac-sha256 signature of the bundle
+	Signature string `json:"signature"`
+}
+
+// sign generates the signature of the bundle using the sign key
+func (b *fileBundle) sign(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(fmt.Sprintf("%s\n%s\n%t\n%d\n", b.ResType, b.SubRes, b.IsIncrement, b.CreateTime)))
+	mac.Write(b.Data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// fileMedium is the transfer medium that exchanges sync data by bundle files in a directory, it is used for the
+// clusters that can not connect to each other, the bundles pushed by the source cmdb are copied to the directory
+// of the destination cmdb and imported by it.
+type fileMedium struct {
+	dir     string
+	signKey []byte
+	// seq is used to generate unique bundle names for bundles pushed in the same nanosecond
+	seq uint64
+	// lock prevents concurrent pull and acknowledgement of the same bundle
+	lock sync.Mutex
+}
+
+// NewFileMedium new file system transfer medium client
+func NewFileMedium(dir, signKey string) (ClientI, error) {
+	if dir == "" {
+		return nil, errors.New("file medium directory is not set")
+	}
+
+	if signKey == "" {
+		return nil, errors.New("file medium sign key is not set")
+	}
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		blog.Errorf("create file medium directory %s failed, err: %v", dir, err)
+		return nil, err
+	}
+
+	return &fileMedium{dir: dir, signKey: []byte(signKey)}, nil
+}
+
+// queueDir returns the directory that stores bundles of the specified sync data type
+func (f *fileMedium) queueDir(resType types.ResType, subRes string, isIncrement bool) string {
+	if subRes == "" {
+		subRes = emptySubRes
+	}
+
+	syncType := "full"
+	if isIncrement {
+		syncType = "incr"
+	}
+
+	return filepath.Join(f.dir, string(resType), url.PathEscape(subRes), syncType)
+}
+
+// PushSyncData write sync data into a signed and compressed bundle file
+func (f *fileMedium) PushSyncData(_ context.Context, _ http.Header, opt *types.PushSyncDataOpt) error {
+	data, err := json.Marshal(opt.Data)
+	if err != nil {
+		return fmt.Errorf("marshal sync data failed, err: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	if _, err = zw.Write(data); err != nil {
+		return fmt.Errorf("compress sync data failed, err: %v", err)
+	}
+	if err = zw.Close(); err != nil {
+		return fmt.Errorf("compress sync data failed, err: %v", err)
+	}
+
+	now := time.Now()
+	bundle := &fileBundle{
+		ResType:     opt.ResType,
+		SubRes:      opt.SubRes,
+		IsIncrement: opt.IsIncrement,
+		CreateTime:  now.UnixNano(),
+		Data:        buf.Bytes(),
+	}
+	bundle.Signature = bundle.sign(f.signKey)
+
+	content, err := json.Marshal(bundle)
+	if err != nil {
+		return fmt.Errorf("marshal sync data bundle failed, err: %v", err)
+	}
+
+	dir := f.queueDir(opt.ResType, opt.SubRes, opt.IsIncrement)
+	if err = os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("create bundle directory %s failed, err: %v", dir, err)
+	}
+
+	// bundle names are ordered by the push time, write to a temporary file first so that the bundle can not be
+	// pulled before it is completely written
+	name := fmt.Sprintf("%020d-%06d%s", now.UnixNano(), atomic.AddUint64(&f.seq, 1)%1000000, bundleExt)
+	tmpPath := filepath.Join(dir, name+".tmp")
+	if err = os.WriteFile(tmpPath, content, 0640); err != nil {
+		return fmt.Errorf("write bundle file %s failed, err: %v", tmpPath, err)
+	}
+
+	if err = os.Rename(tmpPath, filepath.Join(dir, name)); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("rename bundle file %s failed, err: %v", tmpPath, err)
+	}
+
+	return nil
+}
+
+// PullSyncData read the earliest bundle of the sync data type. If ack is true, the previously pulled bundle is
+// acknowledged and removed, otherwise the previously pulled bundle is returned again so that the sync can be resumed.
+func (f *fileMedium) PullSyncData(_ context.Context, _ http.Header, opt *types.PullSyncDataOpt) (
+	*types.PullSyncDataRes, error) {
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	dir := f.queueDir(opt.ResType, opt.SubRes, opt.IsIncrement)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("create bundle directory %s failed, err: %v", dir, err)
+	}
+
+	pendingPath := filepath.Join(dir, pendingFile)
+	pending, err := readPending(pendingPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt.Ack && pending != "" {
+		if err = os.Remove(filepath.Join(dir, pending)); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("remove acknowledged bundle %s failed, err: %v", pending, err)
+		}
+		if err = os.Remove(pendingPath); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("remove pending bundle record failed, err: %v", err)
+		}
+		pending = ""
+	}
+
+	names, err := listBundles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		return &types.PullSyncDataRes{Total: 0}, nil
+	}
+
+	// the unacknowledged bundle is always the earliest one, because bundles are only removed after acknowledgement
+	name := names[0]
+	if pending != "" && pending != name {
+		blog.Warnf("pending bundle %s is not the earliest bundle %s in %s, pull the earliest one", pending, name, dir)
+	}
+
+	data, err := f.readBundle(filepath.Join(dir, name), opt)
+	if err != nil {
+		blog.Errorf("bundle %s in %s is invalid, move it to %s, err: %v", name, dir, invalidDir, err)
+		if err = moveInvalidBundle(dir, name); err != nil {
+			return nil, err
+		}
+		return &types.PullSyncDataRes{Total: int64(len(names) - 1)}, nil
+	}
+
+	if err = os.WriteFile(pendingPath, []byte(name), 0640); err != nil {
+		return nil, fmt.Errorf("record pending bundle %s failed, err: %v", name, err)
+	}
+
+	return &types.PullSyncDataRes{Total: int64(len(names)), Info: data}, nil
+}
+
+// readBundle read bundle file, verify its signature and return the decompressed sync data
+func (f *fileMedium) readBundle(path string, opt *types.PullSyncDataOpt) (json.RawMessage, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := new(fileBundle)
+	if err = json.Unmarshal(content, bundle); err != nil {
+		return nil, fmt.Errorf("unmarshal bundle failed, err: %v", err)
+	}
+
+	if !hmac.Equal([]byte(bundle.sign(f.signKey)), []byte(bundle.Signature)) {
+		return nil, errors.New("bundle signature is invalid")
+	}
+
+	if bundle.ResType != opt.ResType || bundle.SubRes != opt.SubRes || bundle.IsIncrement != opt.IsIncrement {
+		return nil, fmt.Errorf("bundle type %s-%s-%t does not match the directory", bundle.ResType, bundle.SubRes,
+			bundle.IsIncrement)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(bundle.Data))
+	if err != nil {
+		return nil, fmt.Errorf("decompress bundle data failed, err: %v", err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("decompress bundle data failed, err: %v", err)
+	}
+
+	return data, nil
+}
+
+// readPending read the name of the bundle that has been pulled but not acknowledged yet
+func readPending(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("read pending bundle record failed, err: %v", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// listBundles list the names of all bundles in the directory in push order
+func listBundles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle directory %s failed, err: %v", dir, err)
+	}
+
+	names := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), bundleExt) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// moveInvalidBundle move the invalid bundle out of the queue so that the following bundles can be pulled
+func moveInvalidBundle(dir, name string) error {
+	if err := os.MkdirAll(filepath.Join(dir, invalidDir), 0750); err != nil {
+		return fmt.Errorf("create invalid bundle directory failed, err: %v", err)
+	}
+
+	if err := os.Rename(filepath.Join(dir, name), filepath.Join(dir, invalidDir, name)); err != nil {
+		return fmt.Errorf("move invalid bundle %s failed, err: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"configcenter/pkg/synchronize/types"
+)
+
+func TestFileMediumPushPull(t *testing.T) {
+	dir := t.TempDir()
+	cli, err := NewFileMedium(dir, "sign-key")
+	if err != nil {
+		t.Fatalf("new file medium failed, err: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, name := range []string{"first", "second"} {
+		pushOpt := &types.PushSyncDataOpt{
+			ResType:     types.Host,
+			IsIncrement: true,
+			Data:        &types.IncrSyncTransData{Name: name},
+		}
+		if err = cli.PushSyncData(ctx, nil, pushOpt); err != nil {
+			t.Fatalf("push sync data failed, err: %v", err)
+		}
+	}
+
+	pull := func(ack bool) (*types.PullSyncDataRes, string) {
+		res, err := cli.PullSyncData(ctx, nil, &types.PullSyncDataOpt{ResType: types.Host, IsIncrement: true,
+			Ack: ack})
+		if err != nil {
+			t.Fatalf("pull sync data failed, err: %v", err)
+		}
+		if len(res.Info) == 0 {
+			return res, ""
+		}
+		data := new(types.IncrSyncTransData)
+		if err = json.Unmarshal(res.Info, data); err != nil {
+			t.Fatalf("unmarshal sync data failed, err: %v", err)
+		}
+		return res, data.Name
+	}
+
+	// not acknowledged data is pulled again
+	if res, name := pull(false); name != "first" || res.Total != 2 {
+		t.Fatalf("expect first data with total 2, got %s with total %d", name, res.Total)
+	}
+	if _, name := pull(false); name != "first" {
+		t.Fatalf("expect first data to be pulled again, got %s", name)
+	}
+
+	// resume from the pending data with a new client
+	cli, err = NewFileMedium(dir, "sign-key")
+	if err != nil {
+		t.Fatalf("new file medium failed, err: %v", err)
+	}
+	if res, name := pull(true); name != "second" || res.Total != 1 {
+		t.Fatalf("expect second data with total 1, got %s with total %d", name, res.Total)
+	}
+	if res, name := pull(true); name != "" || res.Total != 0 {
+		t.Fatalf("expect no data, got %s with total %d", name, res.Total)
+	}
+
+	// acknowledgement without pulled data does not remove new data
+	if err = cli.PushSyncData(ctx, nil, &types.PushSyncDataOpt{ResType: types.Host, IsIncrement: true,
+		Data: &types.IncrSyncTransData{Name: "third"}}); err != nil {
+		t.Fatalf("push sync data failed, err: %v", err)
+	}
+	if _, name := pull(true); name != "third" {
+		t.Fatalf("expect third data, got %s", name)
+	}
+}
+
+func TestFileMediumInvalidBundle(t *testing.T) {
+	dir := t.TempDir()
+	srcCli, err := NewFileMedium(dir, "src-key")
+	if err != nil {
+		t.Fatalf("new file medium failed, err: %v", err)
+	}
+
+	pushOpt := &types.PushSyncDataOpt{ResType: types.Biz, Data: &types.FullSyncTransData{Name: "src"}}
+	if err = srcCli.PushSyncData(context.Background(), nil, pushOpt); err != nil {
+		t.Fatalf("push sync data failed, err: %v", err)
+	}
+
+	destCli, err := NewFileMedium(dir, "another-key")
+	if err != nil {
+		t.Fatalf("new file medium failed, err: %v", err)
+	}
+
+	res, err := destCli.PullSyncData(context.Background(), nil, &types.PullSyncDataOpt{ResType: types.Biz})
+	if err != nil {
+		t.Fatalf("pull sync data failed, err: %v", err)
+	}
+	if len(res.Info) != 0 || res.Total != 0 {
+		t.Fatalf("expect bundle with invalid signature to be skipped, got %s with total %d", res.Info, res.Total)
+	}
+
+	invalid, err := os.ReadDir(filepath.Join(dir, string(types.Biz), emptySubRes, "full", invalidDir))
+	if err != nil || len(invalid) != 1 {
+		t.Fatalf("expect invalid bundle to be moved, err: %v, count: %d", err, len(invalid))
+	}
+}
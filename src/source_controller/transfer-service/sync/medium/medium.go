@@ -19,6 +19,7 @@ package medium
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"configcenter/pkg/synchronize/types"
@@ -26,17 +27,33 @@ import (
 	"configcenter/src/apimachinery/rest"
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/common/blog"
+	"configcenter/src/source_controller/transfer-service/app/options"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 // ClientI defines transfer medium client interface
 type ClientI interface {
+	// PushSyncData push full or incremental sync data to the transfer medium
 	PushSyncData(ctx context.Context, h http.Header, opt *types.PushSyncDataOpt) error
+	// PullSyncData pull the earliest sync data from the transfer medium, if ack is true, the previously pulled data
+	// is acknowledged, otherwise the previously pulled data is returned again
 	PullSyncData(ctx context.Context, h http.Header, opt *types.PullSyncDataOpt) (*types.PullSyncDataRes, error)
 }
 
-// NewTransferMedium new transfer medium client
+// New new transfer medium client by the medium type in sync config
+func New(conf *options.SyncConfig, reg prometheus.Registerer) (ClientI, error) {
+	switch conf.MediumType {
+	case "", options.MediumTypeHttp:
+		return NewTransferMedium(conf.TransMediumAddr, reg)
+	case options.MediumTypeFile:
+		return NewFileMedium(conf.FileMedium.Dir, conf.FileMedium.SignKey)
+	default:
+		return nil, fmt.Errorf("invalid transfer medium type: %s", conf.MediumType)
+	}
+}
+
+// NewTransferMedium new http transfer medium client
 func NewTransferMedium(addr []string, reg prometheus.Registerer) (ClientI, error) {
 	client, err := util.NewClient(nil)
 	if err != nil {
@@ -56,7 +73,7 @@ func NewTransferMedium(addr []string, reg prometheus.Registerer) (ClientI, error
 	return &transMediumCli{client: restCli}, nil
 }
 
-// transMediumCli defines transfer medium client
+// transMediumCli defines http transfer medium client
 type transMediumCli struct {
 	client rest.ClientInterface
 }
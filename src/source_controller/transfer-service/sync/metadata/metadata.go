@@ -209,10 +209,28 @@ func (m *Metadata) GetCommonObjIDs() ([]string, []string, error) {
 var bizRelatedResTypeMap = map[types.ResType]struct{}{types.Biz: {}, types.ObjectInstance: {}, types.Set: {},
 	types.Module: {}, types.HostRelation: {}, types.ServiceInstance: {}, types.Process: {}, types.ProcessRelation: {}}
 
+// innerClassificationType is the classification type of the inner classifications
+const innerClassificationType = "inner"
+
+// modelResTypeMap stores the model metadata resource types, preset model metadata is initialized in every environment
+// and should not be synced
+var modelResTypeMap = map[types.ResType]struct{}{types.AsstKind: {}, types.Object: {}, types.AttributeGroup: {},
+	types.ObjectAttribute: {}, types.ObjectUnique: {}}
+
 // AddListCond add list condition for resource full sync list data logics
 func (m *Metadata) AddListCond(resType types.ResType, cond mapstr.MapStr) mapstr.MapStr {
 	extraCond := make(mapstr.MapStr)
+	if _, exists := modelResTypeMap[resType]; exists {
+		// do not sync preset model metadata
+		extraCond[common.BKIsPre] = mapstr.MapStr{common.BKDBNE: true}
+		return mergeCond(cond, extraCond)
+	}
+
 	switch resType {
+	case types.Classification:
+		// do not sync inner classifications
+		extraCond[common.BKClassificationTypeField] = mapstr.MapStr{common.BKDBNE: innerClassificationType}
+		return mergeCond(cond, extraCond)
 	case types.Biz, types.ObjectInstance:
 		// do not sync host pool and blueking biz
 		if m.blueking == nil || m.blueking.bizID == 0 {
@@ -293,6 +311,11 @@ func (m *Metadata) ParseEventDetail(eventType watch.EventType, resType types.Res
 		return m.parseHostRelEvent(event)
 	}
 
+	// do not sync preset model metadata
+	if _, exists := modelResTypeMap[resType]; exists {
+		return event, !gjson.GetBytes(detail, common.BKIsPre).Bool()
+	}
+
 	// do not sync resource in blueking biz
 	_, exists := bizRelatedResTypeMap[resType]
 	if exists {
@@ -302,6 +325,9 @@ func (m *Metadata) ParseEventDetail(eventType watch.EventType, resType types.Res
 	}
 
 	switch resType {
+	case types.Classification:
+		// do not sync inner classifications
+		return event, gjson.GetBytes(detail, common.BKClassificationTypeField).String() != innerClassificationType
 	case types.Biz:
 		// do not sync host pool biz
 		return event, gjson.GetBytes(detail, common.BKAppIDField).Int() != m.InnerIDInfo.HostPool.Biz
@@ -43,6 +43,7 @@ import (
 // Syncer is cmdb data syncer
 type Syncer struct {
 	enableSync   bool
+	role         options.SyncRole
 	isMaster     discovery.ServiceManageInterface
 	metadata     *metadata.Metadata
 	resSyncerMap map[types.ResType]*resSyncer
@@ -77,21 +78,30 @@ func NewSyncer(conf *options.Config, isMaster discovery.ServiceManageInterface,
 		return nil, err
 	}
 
-	transMedium, err := medium.NewTransferMedium(conf.Sync.TransMediumAddr, reg)
+	transMedium, err := medium.New(conf.Sync, reg)
 	if err != nil {
-		blog.Errorf("new transfer medium failed, err: %v, addr: %+v", err, conf.Sync.TransMediumAddr)
+		blog.Errorf("new %s transfer medium failed, err: %v, addr: %+v", conf.Sync.MediumType, err,
+			conf.Sync.TransMediumAddr)
 		return nil, err
 	}
 
+	if conf.Sync.Role == options.SyncRoleDest {
+		if err = logics.InitConflictTable(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
 	idRuleMap, srcInnerIDMap := parseDestExConf(conf)
 	resLgcMap := logics.New(&logics.LogicsConfig{
-		Metadata:      meta,
-		IDRuleMap:     idRuleMap,
-		SrcInnerIDMap: srcInnerIDMap,
+		Metadata:         meta,
+		IDRuleMap:        idRuleMap,
+		SrcInnerIDMap:    srcInnerIDMap,
+		ConflictPolicies: parseConflictPolicies(conf),
 	})
 
 	syncer := &Syncer{
 		enableSync:   true,
+		role:         conf.Sync.Role,
 		isMaster:     isMaster,
 		metadata:     meta,
 		resSyncerMap: make(map[types.ResType]*resSyncer),
@@ -162,6 +172,19 @@ func parseDestExConf(conf *options.Config) (map[types.ResType]map[string][]optio
 	return idRuleMap, innerDataIDMap
 }
 
+// parseConflictPolicies parse conflict policy config into map[resource]policy
+func parseConflictPolicies(conf *options.Config) map[types.ResType]types.ConflictPolicy {
+	policies := make(map[types.ResType]types.ConflictPolicy)
+	if conf.DestExConf == nil {
+		return policies
+	}
+
+	for _, policy := range conf.DestExConf.ConflictPolicies {
+		policies[policy.Resource] = policy.Policy
+	}
+	return policies
+}
+
 func (s *Syncer) run(conf *options.Config, loopW stream.LoopInterface, transMedium medium.ClientI,
 	cacheCli cacheservice.CacheServiceClientInterface) error {
 
@@ -38,6 +38,30 @@ var resTypeWatchOptMap = map[synctypes.ResType]types.Options{
 		EventStruct: new(metadata.ServiceInstance),
 		Collection:  common.BKTableNameServiceInstance,
 	},
+	synctypes.Classification: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameObjClassification,
+	},
+	synctypes.AsstKind: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameAsstDes,
+	},
+	synctypes.Object: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameObjDes,
+	},
+	synctypes.AttributeGroup: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNamePropertyGroup,
+	},
+	synctypes.ObjectAttribute: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameObjAttDes,
+	},
+	synctypes.ObjectUnique: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameObjUnique,
+	},
 }
 
 // watchDB watch db events for resource that are not watched by flow
@@ -0,0 +1,263 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package fake is an in memory implementation of dal.DB for unit tests, it supports the table operations and filter
+// operators that are commonly used, the other operations panic since they are not implemented.
+package fake
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrNotFound is returned by the find one operation if no document matches the filter
+var ErrNotFound = errors.New("not found")
+
+// DB is an in memory db, the documents are stored as the bson copies of the inserted data, so they have the same
+// field types as the documents read from mongodb, e.g. time.Time is stored as primitive.DateTime
+type DB struct {
+	dal.DB
+	// Tables is the documents of the tables
+	Tables map[string][]mapstr.MapStr
+	// InsertErr is returned by the insert operation of the table if it is set
+	InsertErr map[string]error
+	// SeqStart is the start of the sequences of all tables
+	SeqStart uint64
+	seqs     map[string]uint64
+}
+
+// NewDB returns a new empty in memory db
+func NewDB() *DB {
+	return &DB{
+		Tables:    make(map[string][]mapstr.MapStr),
+		InsertErr: make(map[string]error),
+		seqs:      make(map[string]uint64),
+	}
+}
+
+// Table returns the in memory table
+func (d *DB) Table(name string) types.Table {
+	return &table{db: d, name: name}
+}
+
+// NextSequence returns the next sequence of the table
+func (d *DB) NextSequence(ctx context.Context, name string) (uint64, error) {
+	ids, err := d.NextSequences(ctx, name, 1)
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// NextSequences returns the next sequences of the table, all tables start from SeqStart
+func (d *DB) NextSequences(_ context.Context, name string, num int) ([]uint64, error) {
+	if _, exists := d.seqs[name]; !exists {
+		d.seqs[name] = d.SeqStart
+	}
+
+	ids := make([]uint64, num)
+	for idx := range ids {
+		d.seqs[name]++
+		ids[idx] = d.seqs[name]
+	}
+	return ids, nil
+}
+
+// IsNotFoundError checks if the error is the not found error of the in memory db
+func (d *DB) IsNotFoundError(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// FindDocs returns the documents of the table that match the filter
+func (d *DB) FindDocs(table string, filter types.Filter) []mapstr.MapStr {
+	cond := ToMapStr(filter)
+	docs := make([]mapstr.MapStr, 0)
+	for _, doc := range d.Tables[table] {
+		if Match(doc, cond) {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+type table struct {
+	types.Table
+	db   *DB
+	name string
+}
+
+// Find returns the find operation of the table
+func (t *table) Find(filter types.Filter, _ ...*types.FindOpts) types.Find {
+	return &find{table: t, filter: filter}
+}
+
+// Insert saves the bson copies of the document or the slice of documents
+func (t *table) Insert(_ context.Context, docs interface{}) error {
+	if err := t.db.InsertErr[t.name]; err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(docs)
+	if value.Kind() != reflect.Slice {
+		value = reflect.ValueOf([]interface{}{docs})
+	}
+
+	for idx := 0; idx < value.Len(); idx++ {
+		t.db.Tables[t.name] = append(t.db.Tables[t.name], ToMapStr(value.Index(idx).Interface()))
+	}
+	return nil
+}
+
+// Delete removes the matched documents
+func (t *table) Delete(_ context.Context, filter types.Filter) error {
+	cond := ToMapStr(filter)
+	docs := make([]mapstr.MapStr, 0)
+	for _, doc := range t.db.Tables[t.name] {
+		if !Match(doc, cond) {
+			docs = append(docs, doc)
+		}
+	}
+	t.db.Tables[t.name] = docs
+	return nil
+}
+
+// Distinct returns the distinct values of the field of the matched documents
+func (t *table) Distinct(_ context.Context, field string, filter types.Filter) ([]interface{}, error) {
+	values := make([]interface{}, 0)
+	for _, doc := range t.db.FindDocs(t.name, filter) {
+		exists := false
+		for _, value := range values {
+			exists = exists || Compare(value, doc[field]) == 0
+		}
+		if !exists {
+			values = append(values, doc[field])
+		}
+	}
+	return values, nil
+}
+
+type find struct {
+	types.Find
+	table  *table
+	filter types.Filter
+	sort   string
+	start  uint64
+	limit  uint64
+}
+
+// Fields returns all fields
+func (f *find) Fields(_ ...string) types.Find {
+	return f
+}
+
+// Sort sets the sort fields in the form of "field1,-field2" or "field1:1,field2:-1"
+func (f *find) Sort(sort string) types.Find {
+	f.sort = sort
+	return f
+}
+
+// Start sets the count of the matched documents to skip
+func (f *find) Start(start uint64) types.Find {
+	f.start = start
+	return f
+}
+
+// Limit sets the max count of the matched documents
+func (f *find) Limit(limit uint64) types.Find {
+	f.limit = limit
+	return f
+}
+
+func (f *find) docs() []mapstr.MapStr {
+	docs := f.table.db.FindDocs(f.table.name, f.filter)
+	for _, item := range sortItems(f.sort) {
+		sort.SliceStable(docs, func(i, j int) bool {
+			return Compare(docs[i][item.field], docs[j][item.field])*item.order < 0
+		})
+	}
+
+	if f.start >= uint64(len(docs)) {
+		return make([]mapstr.MapStr, 0)
+	}
+	docs = docs[f.start:]
+
+	if f.limit > 0 && uint64(len(docs)) > f.limit {
+		docs = docs[:f.limit]
+	}
+	return docs
+}
+
+type sortItem struct {
+	field string
+	order int
+}
+
+// sortItems parses the sort string, the items are returned in reversed order so that the stable sort by each of
+// them in turn results in the sort by all of them
+func sortItems(sortStr string) []sortItem {
+	items := make([]sortItem, 0)
+	if sortStr == "" {
+		return items
+	}
+
+	for _, sortField := range strings.Split(sortStr, ",") {
+		fieldArr := strings.Split(strings.TrimSpace(sortField), ":")
+		item := sortItem{field: strings.TrimLeft(fieldArr[0], "+-"), order: 1}
+		if len(fieldArr) == 2 && strings.TrimSpace(fieldArr[1]) == "-1" ||
+			len(fieldArr) == 1 && strings.HasPrefix(fieldArr[0], "-") {
+			item.order = -1
+		}
+		items = append([]sortItem{item}, items...)
+	}
+	return items
+}
+
+// All decodes the bson copies of the matched documents into result
+func (f *find) All(_ context.Context, result interface{}) error {
+	raw, err := bson.Marshal(bson.M{"docs": f.docs()})
+	if err != nil {
+		return err
+	}
+	return bson.Raw(raw).Lookup("docs").Unmarshal(result)
+}
+
+// One decodes the bson copy of the first matched document into result
+func (f *find) One(_ context.Context, result interface{}) error {
+	docs := f.docs()
+	if len(docs) == 0 {
+		return ErrNotFound
+	}
+
+	raw, err := bson.Marshal(docs[0])
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, result)
+}
+
+// Count returns the count of the matched documents
+func (f *find) Count(_ context.Context) (uint64, error) {
+	return uint64(len(f.table.db.FindDocs(f.table.name, f.filter))), nil
+}
@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	doc := ToMapStr(mapstr.MapStr{"id": int64(1), "name": "Host", "time": now, "tags": "a"})
+
+	tests := []struct {
+		name   string
+		filter mapstr.MapStr
+		expect bool
+	}{
+		{name: "equal number of different types", filter: mapstr.MapStr{"id": 1}, expect: true},
+		{name: "number is not equal to string", filter: mapstr.MapStr{"id": "1"}},
+		{name: "$eq and $ne", filter: mapstr.MapStr{"id": mapstr.MapStr{common.BKDBEQ: 1, common.BKDBNE: 2}},
+			expect: true},
+		{name: "$in", filter: mapstr.MapStr{"id": mapstr.MapStr{common.BKDBIN: []int64{1, 2}}}, expect: true},
+		{name: "$nin", filter: mapstr.MapStr{"id": mapstr.MapStr{common.BKDBNIN: []int64{1, 2}}}},
+		{name: "time range", filter: mapstr.MapStr{"time": mapstr.MapStr{common.BKDBGT: now.Add(-time.Hour),
+			common.BKDBLTE: now}}, expect: true},
+		{name: "$lt time", filter: mapstr.MapStr{"time": mapstr.MapStr{common.BKDBLT: now}}},
+		{name: "$gte missing field", filter: mapstr.MapStr{"size": mapstr.MapStr{common.BKDBGTE: 0}}},
+		{name: "$regex with options", filter: mapstr.MapStr{"name": mapstr.MapStr{common.BKDBLIKE: "^ho",
+			common.BKDBOPTIONS: "i"}}, expect: true},
+		{name: "$or", filter: mapstr.MapStr{common.BKDBOR: []mapstr.MapStr{{"id": 2}, {"name": "Host"}}},
+			expect: true},
+		{name: "$and", filter: mapstr.MapStr{common.BKDBAND: []mapstr.MapStr{{"id": 1}, {"name": "host"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, Match(doc, ToMapStr(tt.filter)))
+		})
+	}
+}
+
+func TestTable(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB()
+	db.SeqStart = 10
+	docs := []mapstr.MapStr{{"id": 3, "type": "b"}, {"id": 1, "type": "a"}, {"id": 2, "type": "b"}}
+	require.NoError(t, db.Table("test").Insert(ctx, docs))
+	require.NoError(t, db.Table("test").Insert(ctx, mapstr.MapStr{"id": 4, "type": "a"}))
+
+	result := make([]struct {
+		ID int64 `bson:"id"`
+	}, 0)
+	err := db.Table("test").Find(mapstr.MapStr{}).Sort("type,id:-1").Start(1).Limit(2).All(ctx, &result)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	require.Equal(t, int64(1), result[0].ID)
+	require.Equal(t, int64(3), result[1].ID)
+
+	one := make(mapstr.MapStr)
+	err = db.Table("test").Find(mapstr.MapStr{"id": 5}).One(ctx, &one)
+	require.True(t, db.IsNotFoundError(err))
+
+	count, err := db.Table("test").Find(mapstr.MapStr{"type": "b"}).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+
+	values, err := db.Table("test").Distinct(ctx, "type", mapstr.MapStr{})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []interface{}{"a", "b"}, values)
+
+	require.NoError(t, db.Table("test").Delete(ctx, mapstr.MapStr{"type": "b"}))
+	require.Len(t, db.FindDocs("test", mapstr.MapStr{}), 2)
+
+	db.InsertErr["test"] = errors.New("insert failed")
+	require.Error(t, db.Table("test").Insert(ctx, mapstr.MapStr{"id": 5}))
+	require.Len(t, db.Tables["test"], 2)
+
+	ids, err := db.NextSequences(ctx, "test", 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{11, 12}, ids)
+	id, err := db.NextSequence(ctx, "other")
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), id)
+}
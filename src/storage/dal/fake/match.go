@@ -0,0 +1,190 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package fake
+
+import (
+	"reflect"
+	"regexp"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ToMapStr converts the data to its bson copy, panics if the data can not be converted
+func ToMapStr(data interface{}) mapstr.MapStr {
+	raw, err := bson.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+
+	doc := make(mapstr.MapStr)
+	if err = bson.Unmarshal(raw, &doc); err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+// Match checks if the document matches the filter, the filter should be converted by ToMapStr. only the $and, $or,
+// $eq, $ne, $in, $nin, $gt, $gte, $lt, $lte, $regex operators are supported, the others cause panic.
+func Match(doc mapstr.MapStr, filter mapstr.MapStr) bool {
+	for field, value := range filter {
+		switch field {
+		case common.BKDBAND, common.BKDBOR:
+			matchedCount := 0
+			subConds := toArray(value)
+			for _, sub := range subConds {
+				subCond, _ := toMap(sub)
+				if Match(doc, subCond) {
+					matchedCount++
+				}
+			}
+
+			if field == common.BKDBAND && matchedCount != len(subConds) || field == common.BKDBOR && matchedCount == 0 {
+				return false
+			}
+		default:
+			if !matchField(doc[field], value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchField(value interface{}, cond interface{}) bool {
+	condM, isMap := toMap(cond)
+	if !isMap {
+		return Compare(value, cond) == 0
+	}
+
+	for op, arg := range condM {
+		switch op {
+		case common.BKDBEQ:
+			if Compare(value, arg) != 0 {
+				return false
+			}
+		case common.BKDBNE:
+			if Compare(value, arg) == 0 {
+				return false
+			}
+		case common.BKDBIN, common.BKDBNIN:
+			in := false
+			for _, item := range toArray(arg) {
+				in = in || Compare(value, item) == 0
+			}
+			if in != (op == common.BKDBIN) {
+				return false
+			}
+		case common.BKDBGT, common.BKDBGTE, common.BKDBLT, common.BKDBLTE:
+			if value == nil || !compareMatch(op, Compare(value, arg)) {
+				return false
+			}
+		case common.BKDBLIKE:
+			pattern := util.GetStrByInterface(arg)
+			if condM[common.BKDBOPTIONS] == "i" {
+				pattern = "(?i)" + pattern
+			}
+			str, ok := value.(string)
+			if !ok || !regexp.MustCompile(pattern).MatchString(str) {
+				return false
+			}
+		case common.BKDBOPTIONS:
+		default:
+			panic("unsupported operator " + op)
+		}
+	}
+	return true
+}
+
+func compareMatch(op string, result int) bool {
+	switch op {
+	case common.BKDBGT:
+		return result > 0
+	case common.BKDBGTE:
+		return result >= 0
+	case common.BKDBLT:
+		return result < 0
+	default:
+		return result <= 0
+	}
+}
+
+func toMap(value interface{}) (mapstr.MapStr, bool) {
+	switch val := value.(type) {
+	case mapstr.MapStr:
+		return val, true
+	case bson.M:
+		return mapstr.MapStr(val), true
+	case map[string]interface{}:
+		return val, true
+	case primitive.D:
+		return mapstr.MapStr(val.Map()), true
+	}
+	return nil, false
+}
+
+func toArray(value interface{}) []interface{} {
+	switch val := value.(type) {
+	case primitive.A:
+		return val
+	case []interface{}:
+		return val
+	}
+	return nil
+}
+
+// Compare compares the numbers, times or strings in the documents, numbers of different types are compared by
+// their values, returns 0 if they are equal, -1 if a is less than b, and 1 otherwise
+func Compare(a, b interface{}) int {
+	if timeA, ok := a.(primitive.DateTime); ok {
+		a = int64(timeA)
+	}
+	if timeB, ok := b.(primitive.DateTime); ok {
+		b = int64(timeB)
+	}
+
+	numA, isNumA := toNumber(a)
+	numB, isNumB := toNumber(b)
+	switch {
+	case isNumA && isNumB && numA < numB:
+		return -1
+	case isNumA && isNumB && numA > numB:
+		return 1
+	case isNumA && isNumB, reflect.DeepEqual(a, b):
+		return 0
+	case util.GetStrByInterface(a) < util.GetStrByInterface(b):
+		return -1
+	}
+	return 1
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	val := reflect.ValueOf(value)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	}
+	return 0, false
+}
@@ -767,7 +767,7 @@ func (c *Collection) DeleteMany(ctx context.Context, filter types.Filter) (uint6
 }
 
 func (c *Collection) tryArchiveDeletedDoc(ctx context.Context, filter types.Filter) error {
-	delArchiveTable, exists := table.GetDelArchiveTable(c.collName)
+	delArchiveTable, exists := table.NeedDelArchive(ctx, c.collName)
 	if !exists {
 		// do not archive the delete docs
 		return nil
@@ -30,4 +30,5 @@ const (
 	MongoDDLFatalError MonitorType = "mongo_ddl_fatal_error"
 	EventTestInfo      MonitorType = "event_test_info"
 	HttpFatalError     MonitorType = "http_fatal_error"
+	AuditChainBroken   MonitorType = "audit_chain_broken"
 )
@@ -0,0 +1,141 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewAuditCommand())
+}
+
+type auditVerifyConf struct {
+	supplierAccount string
+	all             bool
+	startSeq        int64
+	allowGaps       bool
+	unsealedTimeout int64
+}
+
+//  audit
+//    --verify
+//             --supplier-account（要校验的开发商） --all（校验所有开发商） --start-seq（开始校验的链序号）
+//             --allow-gaps（允许审计日志缺失，如被审计日志保留策略清理）
+//             --unsealed-timeout（审计日志创建后允许未加入哈希链的分钟数，超时未加入的审计日志视为断链）
+
+// NewAuditCommand new audit log command
+func NewAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "audit log operations",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	verifyConf := new(auditVerifyConf)
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "verify the audit log hash chain and report the first broken link",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuditVerify(verifyConf)
+		},
+	}
+	verifyCmd.Flags().StringVar(&verifyConf.supplierAccount, "supplier-account", common.BKDefaultOwnerID,
+		"the supplier account whose audit log hash chain is verified")
+	verifyCmd.Flags().BoolVar(&verifyConf.all, "all", false,
+		"verify the audit log hash chains of all supplier accounts")
+	verifyCmd.Flags().Int64Var(&verifyConf.startSeq, "start-seq", 0,
+		"the chain sequence that the verification starts from, default is the first sequence")
+	verifyCmd.Flags().BoolVar(&verifyConf.allowGaps, "allow-gaps", false,
+		"allow the audit logs in the chain to be missing, such as removed by the audit log retention job")
+	verifyCmd.Flags().Int64Var(&verifyConf.unsealedTimeout, "unsealed-timeout",
+		metadata.DefaultAuditUnsealedTimeoutMinutes, "the minutes that an audit log can stay unsealed after it is "+
+			"created, the audit logs that are unsealed for a longer time are reported as broken")
+	cmd.AddCommand(verifyCmd)
+
+	return cmd
+}
+
+func runAuditVerify(c *auditVerifyConf) error {
+	if c.startSeq < 0 {
+		return errors.New("start-seq can not be negative")
+	}
+
+	if c.unsealedTimeout <= 0 {
+		return errors.New("unsealed-timeout must be positive")
+	}
+
+	service, err := config.NewMongoService(config.Conf.MongoURI, config.Conf.MongoRsName)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	supplierAccounts := []string{c.supplierAccount}
+	if c.all {
+		heads, err := auditlog.ListAuditChainHeads(ctx, service.DbProxy)
+		if err != nil {
+			return err
+		}
+
+		supplierAccounts = make([]string, len(heads))
+		for idx, head := range heads {
+			supplierAccounts[idx] = head.SupplierAccount
+		}
+	}
+
+	opt := metadata.VerifyAuditChainOption{StartSeq: c.startSeq, AllowGaps: c.allowGaps,
+		UnsealedTimeoutMinutes: c.unsealedTimeout}
+	brokenCnt := 0
+	for _, supplierAccount := range supplierAccounts {
+		fmt.Printf(WithBlueColor(fmt.Sprintf("start verifying audit log chain of supplier account %s",
+			supplierAccount)))
+
+		result, err := auditlog.VerifyAuditChain(ctx, service.DbProxy, supplierAccount, opt)
+		if err != nil {
+			return err
+		}
+
+		if result.BrokenLink != nil {
+			brokenCnt++
+			link := result.BrokenLink
+			fmt.Printf(WithRedColor(fmt.Sprintf("audit log chain of supplier account %s is broken at chain sequence "+
+				"%d, audit id: %d, reason: %s, %s", supplierAccount, link.ChainSeq, link.AuditID, link.Reason,
+				link.Message)))
+			continue
+		}
+
+		fmt.Printf(WithGreenColor(fmt.Sprintf("audit log chain of supplier account %s is intact, verified: %d, "+
+			"gaps: %d, last sequence: %d", supplierAccount, result.Verified, result.Gaps, result.LastSeq)))
+	}
+
+	if brokenCnt > 0 {
+		return fmt.Errorf("%d audit log chains are broken", brokenCnt)
+	}
+	return nil
+}
@@ -105,25 +105,9 @@ func newAuthService(c *authConf) (*authService, error) {
 		return nil, errors.New("resource must be set via resource flag or resource file specified by rsc-file flag")
 	}
 
-	client := zk.NewZkClient(config.Conf.ZkAddr, 40*time.Second, &config.Conf.ZkTLS)
-	if err := client.Start(); err != nil {
-		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", config.Conf.ZkAddr, err)
-	}
-	if err := client.Ping(); err != nil {
-		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", config.Conf.ZkAddr, err)
-	}
-	serviceDiscovery, err := discovery.NewServiceDiscovery(client, c.env)
+	clientSet, err := newAPIMachinery(c.env)
 	if err != nil {
-		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", config.Conf.ZkAddr, err)
-	}
-	apiMachineryConfig := &util.APIMachineryConfig{
-		QPS:       1000,
-		Burst:     2000,
-		TLSConfig: nil,
-	}
-	clientSet, err := apimachinery.NewApiMachinery(apiMachineryConfig, serviceDiscovery)
-	if err != nil {
-		return nil, fmt.Errorf("new api machinery failed, err: %v", err)
+		return nil, err
 	}
 	service := &authService{
 		authorizer: iam.NewAuthorizer(clientSet),
@@ -153,6 +137,31 @@ func newAuthService(c *authConf) (*authService, error) {
 	return service, nil
 }
 
+// newAPIMachinery new api machinery that discovers the cmdb services in the environment by zookeeper
+func newAPIMachinery(env string) (apimachinery.ClientSetInterface, error) {
+	client := zk.NewZkClient(config.Conf.ZkAddr, 40*time.Second, &config.Conf.ZkTLS)
+	if err := client.Start(); err != nil {
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", config.Conf.ZkAddr, err)
+	}
+	if err := client.Ping(); err != nil {
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", config.Conf.ZkAddr, err)
+	}
+	serviceDiscovery, err := discovery.NewServiceDiscovery(client, env)
+	if err != nil {
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", config.Conf.ZkAddr, err)
+	}
+	apiMachineryConfig := &util.APIMachineryConfig{
+		QPS:       1000,
+		Burst:     2000,
+		TLSConfig: nil,
+	}
+	clientSet, err := apimachinery.NewApiMachinery(apiMachineryConfig, serviceDiscovery)
+	if err != nil {
+		return nil, fmt.Errorf("new api machinery failed, err: %v", err)
+	}
+	return clientSet, nil
+}
+
 func runAuthCheckCmd(c *authConf, userName string, supplierAccount string) error {
 	srv, err := newAuthService(c)
 	if err != nil {
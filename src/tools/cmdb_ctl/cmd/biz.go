@@ -0,0 +1,253 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"configcenter/src/ac/iam"
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewBizCommand())
+}
+
+type bizExportConf struct {
+	bizID int64
+	file  string
+}
+
+type bizImportConf struct {
+	file            string
+	bizName         string
+	supplierAccount string
+	dryRun          bool
+	operator        string
+	registerIAM     bool
+	env             string
+}
+
+//  biz
+//    --export
+//             --biz-id（要导出的业务ID） --file（导出的业务数据包文件路径）
+//    --import
+//             --file（要导入的业务数据包文件路径） --biz-name（导入后的业务名称） --supplier-account（导入的开发商）
+//             --dry-run（只输出冲突报告，不实际导入） --operator（导入操作人，记录到审计日志中）
+//             --register-iam（将操作人注册为导入的业务和模板在权限中心的创建者） --environment（服务发现的环境）
+
+// NewBizCommand new business topology export and import command
+func NewBizCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "biz",
+		Short: "export or import a business topology as a portable bundle",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	exportConf := new(bizExportConf)
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "export the topology of a business to a bundle file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBizExport(exportConf)
+		},
+	}
+	exportCmd.Flags().Int64Var(&exportConf.bizID, "biz-id", 0, "the id of the business to be exported")
+	exportCmd.Flags().StringVar(&exportConf.file, "file", "", "the path of the exported bundle file")
+	cmd.AddCommand(exportCmd)
+
+	importConf := new(bizImportConf)
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "import a business bundle file as a new business",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBizImport(importConf)
+		},
+	}
+	importCmd.Flags().StringVar(&importConf.file, "file", "", "the path of the bundle file to be imported")
+	importCmd.Flags().StringVar(&importConf.bizName, "biz-name", "",
+		"the name of the imported business, default is the name of the exported business")
+	importCmd.Flags().StringVar(&importConf.supplierAccount, "supplier-account", "",
+		"the supplier account to import the business into, default is the one of the exported business")
+	importCmd.Flags().BoolVar(&importConf.dryRun, "dry-run", false,
+		"only report the collisions and the data to be created, do not import the business")
+	importCmd.Flags().StringVar(&importConf.operator, "operator", "",
+		"the user who imports the business, it is recorded in the audit logs of the imported data")
+	importCmd.Flags().BoolVar(&importConf.registerIAM, "register-iam", false,
+		"register the operator as the creator of the imported business and templates in iam, "+
+			"need to be set when the auth is enabled")
+	importCmd.Flags().StringVarP(&importConf.env, "environment", "e", "",
+		"the environment for service discovery, used when register-iam is set")
+	cmd.AddCommand(importCmd)
+
+	return cmd
+}
+
+func runBizExport(c *bizExportConf) error {
+	if c.bizID <= 0 {
+		return errors.New("biz-id must be set")
+	}
+	if c.file == "" {
+		return errors.New("file must be set")
+	}
+
+	service, err := config.NewMongoService(config.Conf.MongoURI, config.Conf.MongoRsName)
+	if err != nil {
+		return err
+	}
+
+	exporter := &bizExporter{
+		db:    service.DbProxy,
+		ctx:   context.Background(),
+		bizID: c.bizID,
+	}
+	return exporter.export(c.file)
+}
+
+func runBizImport(c *bizImportConf) error {
+	if c.file == "" {
+		return errors.New("file must be set")
+	}
+
+	if !c.dryRun && c.operator == "" {
+		return errors.New("operator must be set")
+	}
+
+	bundle, err := readBizBundle(c.file)
+	if err != nil {
+		return err
+	}
+
+	service, err := config.NewMongoService(config.Conf.MongoURI, config.Conf.MongoRsName)
+	if err != nil {
+		return err
+	}
+
+	importer := newBizImporter(service.DbProxy, bundle, c)
+	if c.registerIAM && !c.dryRun {
+		clientSet, err := newAPIMachinery(c.env)
+		if err != nil {
+			return err
+		}
+		importer.authorizer = iam.NewAuthorizer(clientSet)
+	}
+	return importer.run()
+}
+
+// bizBundleVersion is the version of the business bundle format, it is checked when importing
+const bizBundleVersion = 1
+
+// business bundle resource types, the import is done in the order of bizBundleResources
+const (
+	bundleBiz                 = "biz"
+	bundlePropertyGroup       = "property_group"
+	bundleAttribute           = "attribute"
+	bundleServiceCategory     = "service_category"
+	bundleSetTemplate         = "set_template"
+	bundleSetTemplateAttr     = "set_template_attr"
+	bundleServiceTemplate     = "service_template"
+	bundleServiceTemplateAttr = "service_template_attr"
+	bundleProcessTemplate     = "process_template"
+	bundleSetSvcTplRelation   = "set_service_template_relation"
+	bundleMainlineInstance    = "mainline_instance"
+	bundleSet                 = "set"
+	bundleModule              = "module"
+	bundleHostApplyRule       = "host_apply_rule"
+	bundleObjectInstance      = "object_instance"
+	bundleInstAsst            = "inst_asst"
+)
+
+var bizBundleResources = []string{bundleBiz, bundlePropertyGroup, bundleAttribute, bundleServiceCategory,
+	bundleSetTemplate, bundleSetTemplateAttr, bundleServiceTemplate, bundleServiceTemplateAttr, bundleProcessTemplate,
+	bundleSetSvcTplRelation, bundleMainlineInstance, bundleSet, bundleModule, bundleHostApplyRule,
+	bundleObjectInstance, bundleInstAsst}
+
+// bizBundle is the self-contained bundle of a business topology, ids in the bundle are the ids of the exported
+// environment, they are re-allocated and remapped when the bundle is imported into another environment
+type bizBundle struct {
+	Version         int       `bson:"version"`
+	ExportTime      time.Time `bson:"export_time"`
+	SupplierAccount string    `bson:"bk_supplier_account"`
+	BizID           int64     `bson:"bk_biz_id"`
+	// MainlineObjects is the custom mainline objects between business and set, ordered from top to bottom
+	MainlineObjects []string `bson:"mainline_objects"`
+	// Attributes is the global attributes referenced by template attributes and host apply rules,
+	// they are matched by object id and property id in the imported environment
+	Attributes []bundleGlobalAttr `bson:"attributes"`
+	// BuiltinCategories is the built-in service categories referenced by the business, they are matched
+	// by name and parent category in the imported environment
+	BuiltinCategories []mapstr.MapStr `bson:"builtin_categories"`
+	// Data is the data of the business, key is the bundle resource type
+	Data map[string][]mapstr.MapStr `bson:"data"`
+}
+
+// bundleGlobalAttr is the global attribute referenced by the business bundle
+type bundleGlobalAttr struct {
+	ID         int64  `bson:"id"`
+	ObjectID   string `bson:"bk_obj_id"`
+	PropertyID string `bson:"bk_property_id"`
+}
+
+// getMainlineObjects get the custom mainline objects between business and set, ordered from top to bottom
+func getMainlineObjects(ctx context.Context, db dal.RDB) ([]string, error) {
+	associations := make([]metadata.Association, 0)
+	cond := mapstr.MapStr{common.AssociationKindIDField: common.AssociationKindMainline}
+	if err := db.Table(common.BKTableNameObjAsst).Find(cond).All(ctx, &associations); err != nil {
+		return nil, fmt.Errorf("get mainline associations failed, err: %v", err)
+	}
+
+	childMap := make(map[string]string)
+	for _, association := range associations {
+		childMap[association.AsstObjID] = association.ObjectID
+	}
+
+	objIDs := make([]string, 0)
+	for child := childMap[common.BKInnerObjIDApp]; child != "" && child != common.BKInnerObjIDSet; {
+		objIDs = append(objIDs, child)
+		child = childMap[child]
+		if len(objIDs) > len(associations) {
+			return nil, fmt.Errorf("mainline associations %+v are circular", associations)
+		}
+	}
+	return objIDs, nil
+}
+
+// getInt64Field get the int64 value of the field in the document, returns 0 if the field is not set
+func getInt64Field(doc mapstr.MapStr, field string) (int64, error) {
+	val, exists := doc[field]
+	if !exists || val == nil {
+		return 0, nil
+	}
+
+	intVal, err := util.GetInt64ByInterface(val)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s field %v failed, err: %v", field, val, err)
+	}
+	return intVal, nil
+}
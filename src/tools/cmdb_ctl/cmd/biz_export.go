@@ -0,0 +1,425 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// bizExportBatchSize is the max count of ids in one $in condition when exporting business data
+const bizExportBatchSize = 500
+
+type bizExporter struct {
+	db     dal.RDB
+	ctx    context.Context
+	bizID  int64
+	bundle *bizBundle
+	// topoIDs is the topology instance ids of the business, key is object id
+	topoIDs map[string][]int64
+}
+
+func (e *bizExporter) export(file string) error {
+	fmt.Printf(WithBlueColor(fmt.Sprintf("start exporting business %d", e.bizID)))
+
+	e.bundle = &bizBundle{
+		Version:    bizBundleVersion,
+		ExportTime: time.Now(),
+		BizID:      e.bizID,
+		Data:       make(map[string][]mapstr.MapStr),
+	}
+	e.topoIDs = make(map[string][]int64)
+
+	steps := []func() error{e.exportBiz, e.exportAttributes, e.exportTemplates, e.exportTopology,
+		e.exportServiceCategories, e.exportHostApplyRules, e.exportGlobalAttributes, e.exportInstances}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+
+	data, err := bson.MarshalExtJSON(e.bundle, true, false)
+	if err != nil {
+		return fmt.Errorf("marshal business bundle failed, err: %v", err)
+	}
+
+	if err = os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("write business bundle to file %s failed, err: %v", file, err)
+	}
+
+	for _, resource := range bizBundleResources {
+		fmt.Printf("%-32s %d\n", resource, len(e.bundle.Data[resource]))
+	}
+	fmt.Printf(WithGreenColor(fmt.Sprintf("export business %d to %s success", e.bizID, file)))
+	return nil
+}
+
+// find all the documents that matches the condition in the table
+func (e *bizExporter) find(table string, cond mapstr.MapStr) ([]mapstr.MapStr, error) {
+	docs := make([]mapstr.MapStr, 0)
+	if err := e.db.Table(table).Find(cond).All(e.ctx, &docs); err != nil {
+		return nil, fmt.Errorf("find %s data failed, cond: %+v, err: %v", table, cond, err)
+	}
+
+	for _, doc := range docs {
+		delete(doc, "_id")
+	}
+	return docs, nil
+}
+
+// findByIDs find all the documents whose id field is in the ids in batches
+func (e *bizExporter) findByIDs(table, idField string, ids []int64, cond mapstr.MapStr) ([]mapstr.MapStr, error) {
+	docs := make([]mapstr.MapStr, 0)
+	for start := 0; start < len(ids); start += bizExportBatchSize {
+		end := start + bizExportBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		batchCond := mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: ids[start:end]}}
+		for key, val := range cond {
+			batchCond[key] = val
+		}
+
+		batchDocs, err := e.find(table, batchCond)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, batchDocs...)
+	}
+	return docs, nil
+}
+
+func (e *bizExporter) exportBiz() error {
+	bizCond := mapstr.MapStr{common.BKAppIDField: e.bizID}
+	bizList, err := e.find(common.BKTableNameBaseApp, bizCond)
+	if err != nil {
+		return err
+	}
+
+	if len(bizList) != 1 {
+		return fmt.Errorf("business %d not found or has too many(num = %d) instances", e.bizID, len(bizList))
+	}
+
+	e.bundle.SupplierAccount = util.GetStrByInterface(bizList[0][common.BKOwnerIDField])
+	e.bundle.Data[bundleBiz] = bizList
+
+	e.bundle.MainlineObjects, err = getMainlineObjects(e.ctx, e.db)
+	return err
+}
+
+// exportAttributes export the attributes and attribute groups that belongs to the business
+func (e *bizExporter) exportAttributes() error {
+	bizCond := mapstr.MapStr{common.BKAppIDField: e.bizID}
+
+	var err error
+	if e.bundle.Data[bundlePropertyGroup], err = e.find(common.BKTableNamePropertyGroup, bizCond); err != nil {
+		return err
+	}
+
+	if e.bundle.Data[bundleAttribute], err = e.find(common.BKTableNameObjAttDes, bizCond); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *bizExporter) exportTemplates() error {
+	bizCond := mapstr.MapStr{common.BKAppIDField: e.bizID}
+	tables := map[string]string{
+		bundleSetTemplate:         common.BKTableNameSetTemplate,
+		bundleSetTemplateAttr:     common.BKTableNameSetTemplateAttr,
+		bundleServiceTemplate:     common.BKTableNameServiceTemplate,
+		bundleServiceTemplateAttr: common.BKTableNameServiceTemplateAttr,
+		bundleProcessTemplate:     common.BKTableNameProcessTemplate,
+		bundleSetSvcTplRelation:   common.BKTableNameSetServiceTemplateRelation,
+	}
+
+	for resource, table := range tables {
+		docs, err := e.find(table, bizCond)
+		if err != nil {
+			return err
+		}
+		e.bundle.Data[resource] = docs
+	}
+	return nil
+}
+
+// exportServiceCategories export the service categories of the business and the built-in categories they reference
+func (e *bizExporter) exportServiceCategories() error {
+	bizCond := mapstr.MapStr{common.BKAppIDField: e.bizID}
+	categories, err := e.find(common.BKTableNameServiceCategory, bizCond)
+	if err != nil {
+		return err
+	}
+	e.bundle.Data[bundleServiceCategory] = categories
+
+	refIDs := make([]int64, 0)
+	for _, category := range categories {
+		for _, field := range []string{common.BKParentIDField, common.BKRootIDField} {
+			id, err := getInt64Field(category, field)
+			if err != nil {
+				return err
+			}
+			refIDs = append(refIDs, id)
+		}
+	}
+
+	for _, resource := range []string{bundleServiceTemplate, bundleModule} {
+		ids, err := getIDs(e.bundle.Data[resource], common.BKServiceCategoryIDField)
+		if err != nil {
+			return err
+		}
+		refIDs = append(refIDs, ids...)
+	}
+
+	// built-in service categories has no business id, the parent category must also be exported to match them
+	builtinCond := mapstr.MapStr{common.BKAppIDField: 0}
+	builtins, err := e.findByIDs(common.BKTableNameServiceCategory, common.BKFieldID, util.IntArrayUnique(refIDs),
+		builtinCond)
+	if err != nil {
+		return err
+	}
+
+	parentIDs := make([]int64, 0)
+	for _, category := range builtins {
+		parentID, err := getInt64Field(category, common.BKParentIDField)
+		if err != nil {
+			return err
+		}
+		if parentID > 0 {
+			parentIDs = append(parentIDs, parentID)
+		}
+	}
+
+	parents, err := e.findByIDs(common.BKTableNameServiceCategory, common.BKFieldID, util.IntArrayUnique(parentIDs),
+		builtinCond)
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[int64]struct{})
+	e.bundle.BuiltinCategories = make([]mapstr.MapStr, 0)
+	for _, category := range append(parents, builtins...) {
+		id, err := getInt64Field(category, common.BKFieldID)
+		if err != nil {
+			return err
+		}
+		if _, ok := exists[id]; ok {
+			continue
+		}
+		exists[id] = struct{}{}
+		e.bundle.BuiltinCategories = append(e.bundle.BuiltinCategories, category)
+	}
+	return nil
+}
+
+// exportTopology export the mainline instances, sets and modules of the business
+func (e *bizExporter) exportTopology() error {
+	e.topoIDs[common.BKInnerObjIDApp] = []int64{e.bizID}
+	bizCond := mapstr.MapStr{common.BKAppIDField: e.bizID}
+
+	mainlineInstances := make([]mapstr.MapStr, 0)
+	for _, objID := range e.bundle.MainlineObjects {
+		cond := mapstr.MapStr{common.BKAppIDField: e.bizID, common.BKObjIDField: objID}
+		instances, err := e.find(common.GetInstTableName(objID, e.bundle.SupplierAccount), cond)
+		if err != nil {
+			return err
+		}
+
+		if e.topoIDs[objID], err = getIDs(instances, common.BKInstIDField); err != nil {
+			return err
+		}
+		mainlineInstances = append(mainlineInstances, instances...)
+	}
+	e.bundle.Data[bundleMainlineInstance] = mainlineInstances
+
+	sets, err := e.find(common.BKTableNameBaseSet, bizCond)
+	if err != nil {
+		return err
+	}
+	if e.topoIDs[common.BKInnerObjIDSet], err = getIDs(sets, common.BKSetIDField); err != nil {
+		return err
+	}
+	e.bundle.Data[bundleSet] = sets
+
+	modules, err := e.find(common.BKTableNameBaseModule, bizCond)
+	if err != nil {
+		return err
+	}
+	if e.topoIDs[common.BKInnerObjIDModule], err = getIDs(modules, common.BKModuleIDField); err != nil {
+		return err
+	}
+	e.bundle.Data[bundleModule] = modules
+	return nil
+}
+
+func (e *bizExporter) exportHostApplyRules() error {
+	rules, err := e.find(common.BKTableNameHostApplyRule, mapstr.MapStr{common.BKAppIDField: e.bizID})
+	if err != nil {
+		return err
+	}
+	e.bundle.Data[bundleHostApplyRule] = rules
+	return nil
+}
+
+// exportGlobalAttributes export the object id and property id of the global attributes that are referenced by the
+// template attributes and host apply rules, so that they can be matched in the imported environment
+func (e *bizExporter) exportGlobalAttributes() error {
+	attrIDs := make([]int64, 0)
+	for _, resource := range []string{bundleSetTemplateAttr, bundleServiceTemplateAttr, bundleHostApplyRule} {
+		ids, err := getIDs(e.bundle.Data[resource], common.BKAttributeIDField)
+		if err != nil {
+			return err
+		}
+		attrIDs = append(attrIDs, ids...)
+	}
+
+	attrs, err := e.findByIDs(common.BKTableNameObjAttDes, common.BKFieldID, util.IntArrayUnique(attrIDs),
+		mapstr.MapStr{common.BKAppIDField: 0})
+	if err != nil {
+		return err
+	}
+
+	e.bundle.Attributes = make([]bundleGlobalAttr, len(attrs))
+	for idx, attr := range attrs {
+		id, err := getInt64Field(attr, common.BKFieldID)
+		if err != nil {
+			return err
+		}
+
+		e.bundle.Attributes[idx] = bundleGlobalAttr{
+			ID:         id,
+			ObjectID:   util.GetStrByInterface(attr[common.BKObjIDField]),
+			PropertyID: util.GetStrByInterface(attr[common.BKPropertyIDField]),
+		}
+	}
+	return nil
+}
+
+// exportInstances export the custom model instances that are associated with the business topology instances and
+// the associations between them, associations with other resources like hosts are not exported
+func (e *bizExporter) exportInstances() error {
+	topoIDMap := make(map[string]map[int64]struct{})
+	for objID, ids := range e.topoIDs {
+		topoIDMap[objID] = make(map[int64]struct{}, len(ids))
+		for _, id := range ids {
+			topoIDMap[objID][id] = struct{}{}
+		}
+	}
+
+	assts := make([]mapstr.MapStr, 0)
+	asstIDs := make(map[int64]struct{})
+	instIDs := make(map[string][]int64)
+	skipCount := 0
+	for objID, ids := range e.topoIDs {
+		table := common.GetObjectInstAsstTableName(objID, e.bundle.SupplierAccount)
+		for _, idField := range []string{common.BKInstIDField, common.BKAsstInstIDField} {
+			objIDField := common.BKObjIDField
+			if idField == common.BKAsstInstIDField {
+				objIDField = common.BKAsstObjIDField
+			}
+
+			docs, err := e.findByIDs(table, idField, ids, mapstr.MapStr{objIDField: objID})
+			if err != nil {
+				return err
+			}
+
+			for _, doc := range docs {
+				id, err := getInt64Field(doc, common.BKFieldID)
+				if err != nil {
+					return err
+				}
+				if _, exists := asstIDs[id]; exists {
+					continue
+				}
+				asstIDs[id] = struct{}{}
+
+				otherObjID := util.GetStrByInterface(doc[common.BKAsstObjIDField])
+				otherIDField := common.BKAsstInstIDField
+				if idField == common.BKAsstInstIDField {
+					otherObjID = util.GetStrByInterface(doc[common.BKObjIDField])
+					otherIDField = common.BKInstIDField
+				}
+
+				otherID, err := getInt64Field(doc, otherIDField)
+				if err != nil {
+					return err
+				}
+
+				otherTopoIDs, isTopoObj := topoIDMap[otherObjID]
+				if isTopoObj {
+					if _, exists := otherTopoIDs[otherID]; !exists {
+						// associated with the topology instance of another business
+						skipCount++
+						continue
+					}
+				} else if !metadata.IsCommon(otherObjID) {
+					// associated with the inner resources like hosts that are not exported
+					skipCount++
+					continue
+				} else {
+					instIDs[otherObjID] = append(instIDs[otherObjID], otherID)
+				}
+
+				assts = append(assts, doc)
+			}
+		}
+	}
+
+	instances := make([]mapstr.MapStr, 0)
+	for objID, ids := range instIDs {
+		docs, err := e.findByIDs(common.GetInstTableName(objID, e.bundle.SupplierAccount), common.BKInstIDField,
+			util.IntArrayUnique(ids), mapstr.MapStr{common.BKObjIDField: objID})
+		if err != nil {
+			return err
+		}
+		instances = append(instances, docs...)
+	}
+
+	e.bundle.Data[bundleObjectInstance] = instances
+	e.bundle.Data[bundleInstAsst] = assts
+	if skipCount > 0 {
+		fmt.Printf(WithBlueColor(fmt.Sprintf("skip %d instance associations with resources out of the business",
+			skipCount)))
+	}
+	return nil
+}
+
+// getIDs get the int64 ids of the id field of the documents, documents without the id field are ignored
+func getIDs(docs []mapstr.MapStr, idField string) ([]int64, error) {
+	ids := make([]int64, 0, len(docs))
+	for _, doc := range docs {
+		id, err := getInt64Field(doc, idField)
+		if err != nil {
+			return nil, err
+		}
+		if id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,1032 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/ac"
+	"configcenter/src/ac/iam"
+	"configcenter/src/common"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// objectBaseMappingTable is the table that maps the custom object instance id to its object id
+const objectBaseMappingTable = "cc_ObjectBaseMapping"
+
+func readBizBundle(file string) (*bizBundle, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read business bundle file %s failed, err: %v", file, err)
+	}
+
+	bundle := new(bizBundle)
+	if err = bson.UnmarshalExtJSON(data, true, bundle); err != nil {
+		return nil, fmt.Errorf("unmarshal business bundle failed, err: %v", err)
+	}
+
+	if bundle.Version != bizBundleVersion {
+		return nil, fmt.Errorf("business bundle version %d is not supported, expected version is %d",
+			bundle.Version, bizBundleVersion)
+	}
+
+	if len(bundle.Data[bundleBiz]) != 1 {
+		return nil, fmt.Errorf("business bundle has %d businesses, expected 1", len(bundle.Data[bundleBiz]))
+	}
+	return bundle, nil
+}
+
+// bizCollision is the collision between the business bundle and the data in the imported environment
+type bizCollision struct {
+	// fatal collision blocks the import, the others are handled by the import, like reusing the existing instance
+	fatal    bool
+	resource string
+	message  string
+}
+
+type bizImporter struct {
+	db              dal.RDB
+	ctx             context.Context
+	bundle          *bizBundle
+	bizName         string
+	supplierAccount string
+	dryRun          bool
+	now             time.Time
+	// idMap maps the ids in the bundle to the ids in this environment, key is the id kind, which is the object id
+	// for instances and the table name for the others
+	idMap map[string]map[int64]int64
+	// reusedInsts is the custom instances in the bundle that already exist in this environment, key is object id
+	reusedInsts map[string]map[int64]struct{}
+	collisions  []bizCollision
+	operator    string
+	rid         string
+	// authorizer registers the operator as the creator of the imported resources in iam, it is nil if not needed
+	authorizer ac.AuthorizeInterface
+	// created is the data created by the import in creation order, it is deleted when the import fails
+	created []createdData
+	// auditLogs is the audit logs of the created instances and instance associations, saved after the import
+	auditLogs []metadata.AuditLog
+	// instNames is the names of the created and reused instances, key is object id and instance id in this
+	// environment, it is used to generate the audit logs of the instance associations
+	instNames map[string]map[int64]string
+}
+
+// createdData is the data created by the import in a table, cond matches all the created documents
+type createdData struct {
+	table string
+	cond  mapstr.MapStr
+}
+
+func newBizImporter(db dal.RDB, bundle *bizBundle, c *bizImportConf) *bizImporter {
+	i := &bizImporter{
+		db:              db,
+		ctx:             context.Background(),
+		bundle:          bundle,
+		bizName:         c.bizName,
+		supplierAccount: c.supplierAccount,
+		dryRun:          c.dryRun,
+		now:             time.Now(),
+		idMap:           make(map[string]map[int64]int64),
+		reusedInsts:     make(map[string]map[int64]struct{}),
+		collisions:      make([]bizCollision, 0),
+		operator:        c.operator,
+		rid:             util.GenerateRID(),
+		created:         make([]createdData, 0),
+		auditLogs:       make([]metadata.AuditLog, 0),
+		instNames:       make(map[string]map[int64]string),
+	}
+
+	if i.bizName == "" {
+		i.bizName = util.GetStrByInterface(bundle.Data[bundleBiz][0][common.BKAppNameField])
+	}
+	if i.supplierAccount == "" {
+		i.supplierAccount = bundle.SupplierAccount
+	}
+	return i
+}
+
+func (i *bizImporter) run() error {
+	fmt.Printf(WithBlueColor(fmt.Sprintf("start checking business bundle of business %d exported at %s",
+		i.bundle.BizID, i.bundle.ExportTime.Format(time.RFC3339))))
+
+	checks := []func() error{i.checkBiz, i.checkMainline, i.checkObjects, i.checkAttributes,
+		i.checkBuiltinCategories, i.checkModelAssociations, i.checkInstances}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+
+	fatalCount := i.printReport()
+	if fatalCount > 0 {
+		return fmt.Errorf("found %d collisions that block the import", fatalCount)
+	}
+
+	if i.dryRun {
+		fmt.Printf(WithGreenColor("dry run finished, nothing is imported"))
+		return nil
+	}
+
+	// iam registration is done after all the data is created, so that it is not done for the rolled back data
+	steps := []func() error{i.importBiz, i.importAttributes, i.importServiceCategories, i.importTemplates,
+		i.importTopology, i.importHostApplyRules, i.importInstances, i.importInstAssts, i.registerIAMCreator}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return i.rollback(err)
+		}
+	}
+
+	if err := i.saveAuditLogs(); err != nil {
+		return i.rollback(err)
+	}
+
+	bizID := i.idMap[common.BKInnerObjIDApp][i.bundle.BizID]
+	fmt.Printf(WithGreenColor(fmt.Sprintf("import business %s success, business id: %d", i.bizName, bizID)))
+	return nil
+}
+
+// rollback delete the data created by the import in reverse order, so that a failed import does not leave a
+// half-created business, the deletion continues when one of the tables fails so that as much data as possible
+// is cleaned up, the failed conditions are printed for manual clean up
+func (i *bizImporter) rollback(importErr error) error {
+	fmt.Printf(WithRedColor(fmt.Sprintf("import business failed, start rolling back the created data, err: %v",
+		importErr)))
+
+	failed := false
+	for idx := len(i.created) - 1; idx >= 0; idx-- {
+		data := i.created[idx]
+		if err := i.db.Table(data.table).Delete(i.ctx, data.cond); err != nil {
+			failed = true
+			fmt.Printf(WithRedColor(fmt.Sprintf("delete created %s data failed, cond: %+v, err: %v", data.table,
+				data.cond, err)))
+		}
+	}
+
+	if failed {
+		return fmt.Errorf("import business failed and rollback is not finished, please delete the data that failed "+
+			"to roll back manually, err: %v", importErr)
+	}
+	return fmt.Errorf("import business failed and the created data is rolled back, err: %v", importErr)
+}
+
+// recordCreated record the data to be created in the table, it is recorded before the insertion so that the data
+// partially inserted by a failed insertion can also be rolled back
+func (i *bizImporter) recordCreated(table string, cond mapstr.MapStr) {
+	i.created = append(i.created, createdData{table: table, cond: cond})
+}
+
+func (i *bizImporter) addCollision(fatal bool, resource, format string, args ...interface{}) {
+	i.collisions = append(i.collisions, bizCollision{
+		fatal:    fatal,
+		resource: resource,
+		message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// printReport print the collisions and the count of data to be created, returns the count of fatal collisions
+func (i *bizImporter) printReport() int {
+	fmt.Println("=====================\ncollisions:")
+	fatalCount := 0
+	for _, collision := range i.collisions {
+		if collision.fatal {
+			fatalCount++
+			fmt.Printf(WithRedColor(fmt.Sprintf("[%s] %s", collision.resource, collision.message)))
+			continue
+		}
+		fmt.Printf(WithBlueColor(fmt.Sprintf("[%s] %s", collision.resource, collision.message)))
+	}
+	if len(i.collisions) == 0 {
+		fmt.Println("no collision")
+	}
+
+	fmt.Println("=====================\ndata to be created:")
+	for _, resource := range bizBundleResources {
+		count := len(i.bundle.Data[resource])
+		if resource == bundleObjectInstance {
+			for _, ids := range i.reusedInsts {
+				count -= len(ids)
+			}
+		}
+		fmt.Printf("%-32s %d\n", resource, count)
+	}
+	fmt.Println("=====================")
+	return fatalCount
+}
+
+func (i *bizImporter) checkBiz() error {
+	cond := mapstr.MapStr{common.BKAppNameField: i.bizName, common.BKOwnerIDField: i.supplierAccount}
+	count, err := i.db.Table(common.BKTableNameBaseApp).Find(cond).Count(i.ctx)
+	if err != nil {
+		return fmt.Errorf("count business by name %s failed, err: %v", i.bizName, err)
+	}
+
+	if count > 0 {
+		i.addCollision(true, bundleBiz, "business %s already exists, use --biz-name to import with another name",
+			i.bizName)
+	}
+	return nil
+}
+
+// checkMainline check if the mainline topology is the same as the exported environment
+func (i *bizImporter) checkMainline() error {
+	objIDs, err := getMainlineObjects(i.ctx, i.db)
+	if err != nil {
+		return err
+	}
+
+	if strings.Join(objIDs, ",") != strings.Join(i.bundle.MainlineObjects, ",") {
+		i.addCollision(true, bundleMainlineInstance, "mainline objects %v are different from the exported %v",
+			objIDs, i.bundle.MainlineObjects)
+	}
+	return nil
+}
+
+// checkObjects check if the objects that the bundle data belongs to exist
+func (i *bizImporter) checkObjects() error {
+	objIDs := append([]string{}, i.bundle.MainlineObjects...)
+	for _, resource := range []string{bundlePropertyGroup, bundleAttribute, bundleObjectInstance} {
+		for _, doc := range i.bundle.Data[resource] {
+			objIDs = append(objIDs, util.GetStrByInterface(doc[common.BKObjIDField]))
+		}
+	}
+	objIDs = util.StrArrayUnique(objIDs)
+	if len(objIDs) == 0 {
+		return nil
+	}
+
+	cond := mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objIDs}}
+	existObjIDs, err := i.db.Table(common.BKTableNameObjDes).Distinct(i.ctx, common.BKObjIDField, cond)
+	if err != nil {
+		return fmt.Errorf("get exist objects failed, err: %v", err)
+	}
+
+	existMap := make(map[string]struct{})
+	for _, objID := range existObjIDs {
+		existMap[util.GetStrByInterface(objID)] = struct{}{}
+	}
+
+	for _, objID := range objIDs {
+		if _, exists := existMap[objID]; !exists {
+			i.addCollision(true, "object", "object %s does not exist", objID)
+		}
+	}
+	return nil
+}
+
+// checkAttributes match the referenced global attributes by property id, and check if the business attributes
+// conflict with the global attributes
+func (i *bizImporter) checkAttributes() error {
+	attrKind := common.BKTableNameObjAttDes
+	for _, attr := range i.bundle.Attributes {
+		cond := mapstr.MapStr{
+			common.BKObjIDField:      attr.ObjectID,
+			common.BKPropertyIDField: attr.PropertyID,
+			common.BKAppIDField:      0,
+		}
+		existAttrs := make([]mapstr.MapStr, 0)
+		if err := i.db.Table(common.BKTableNameObjAttDes).Find(cond).Fields(common.BKFieldID).
+			All(i.ctx, &existAttrs); err != nil {
+			return fmt.Errorf("get attribute failed, cond: %+v, err: %v", cond, err)
+		}
+
+		if len(existAttrs) == 0 {
+			i.addCollision(true, bundleAttribute, "attribute %s of object %s does not exist", attr.PropertyID,
+				attr.ObjectID)
+			continue
+		}
+
+		id, err := getInt64Field(existAttrs[0], common.BKFieldID)
+		if err != nil {
+			return err
+		}
+		i.setID(attrKind, attr.ID, id)
+	}
+
+	for _, attr := range i.bundle.Data[bundleAttribute] {
+		cond := mapstr.MapStr{
+			common.BKObjIDField:      attr[common.BKObjIDField],
+			common.BKPropertyIDField: attr[common.BKPropertyIDField],
+			common.BKAppIDField:      0,
+		}
+		count, err := i.db.Table(common.BKTableNameObjAttDes).Find(cond).Count(i.ctx)
+		if err != nil {
+			return fmt.Errorf("count attribute failed, cond: %+v, err: %v", cond, err)
+		}
+
+		if count > 0 {
+			i.addCollision(true, bundleAttribute, "business attribute %v of object %v conflicts with global attribute",
+				attr[common.BKPropertyIDField], attr[common.BKObjIDField])
+		}
+	}
+	return nil
+}
+
+// checkBuiltinCategories match the referenced built-in service categories by name and parent category
+func (i *bizImporter) checkBuiltinCategories() error {
+	categories, err := sortCategories(i.bundle.BuiltinCategories)
+	if err != nil {
+		return err
+	}
+
+	categoryKind := common.BKTableNameServiceCategory
+	for _, category := range categories {
+		id, err := getInt64Field(category, common.BKFieldID)
+		if err != nil {
+			return err
+		}
+
+		parentID, err := getInt64Field(category, common.BKParentIDField)
+		if err != nil {
+			return err
+		}
+
+		if parentID > 0 {
+			if parentID, err = i.getID(categoryKind, parentID); err != nil {
+				// the parent category is already reported as collision
+				continue
+			}
+		}
+
+		cond := mapstr.MapStr{
+			common.BKFieldName:     category[common.BKFieldName],
+			common.BKParentIDField: parentID,
+			common.BKAppIDField:    0,
+		}
+		existCategories := make([]mapstr.MapStr, 0)
+		if err = i.db.Table(common.BKTableNameServiceCategory).Find(cond).Fields(common.BKFieldID).
+			All(i.ctx, &existCategories); err != nil {
+			return fmt.Errorf("get built-in service category failed, cond: %+v, err: %v", cond, err)
+		}
+
+		if len(existCategories) == 0 {
+			i.addCollision(true, bundleServiceCategory, "built-in service category %v does not exist",
+				category[common.BKFieldName])
+			continue
+		}
+
+		existID, err := getInt64Field(existCategories[0], common.BKFieldID)
+		if err != nil {
+			return err
+		}
+		i.setID(categoryKind, id, existID)
+	}
+	return nil
+}
+
+// checkModelAssociations check if the model associations of the instance associations exist
+func (i *bizImporter) checkModelAssociations() error {
+	objAsstIDs := make([]string, 0)
+	for _, asst := range i.bundle.Data[bundleInstAsst] {
+		objAsstIDs = append(objAsstIDs, util.GetStrByInterface(asst[common.AssociationObjAsstIDField]))
+	}
+	objAsstIDs = util.StrArrayUnique(objAsstIDs)
+	if len(objAsstIDs) == 0 {
+		return nil
+	}
+
+	cond := mapstr.MapStr{common.AssociationObjAsstIDField: mapstr.MapStr{common.BKDBIN: objAsstIDs}}
+	existIDs, err := i.db.Table(common.BKTableNameObjAsst).Distinct(i.ctx, common.AssociationObjAsstIDField, cond)
+	if err != nil {
+		return fmt.Errorf("get exist model associations failed, err: %v", err)
+	}
+
+	existMap := make(map[string]struct{})
+	for _, id := range existIDs {
+		existMap[util.GetStrByInterface(id)] = struct{}{}
+	}
+
+	for _, id := range objAsstIDs {
+		if _, exists := existMap[id]; !exists {
+			i.addCollision(true, bundleInstAsst, "model association %s does not exist", id)
+		}
+	}
+	return nil
+}
+
+// checkInstances check if the custom instances with the same name exist, they are reused instead of created
+func (i *bizImporter) checkInstances() error {
+	for _, inst := range i.bundle.Data[bundleObjectInstance] {
+		objID := util.GetStrByInterface(inst[common.BKObjIDField])
+		cond := mapstr.MapStr{
+			common.BKObjIDField:    objID,
+			common.BKInstNameField: inst[common.BKInstNameField],
+		}
+
+		existInsts := make([]mapstr.MapStr, 0)
+		if err := i.db.Table(common.GetInstTableName(objID, i.supplierAccount)).Find(cond).
+			Fields(common.BKInstIDField).All(i.ctx, &existInsts); err != nil {
+			return fmt.Errorf("get %s instance failed, cond: %+v, err: %v", objID, cond, err)
+		}
+
+		if len(existInsts) == 0 {
+			continue
+		}
+
+		id, err := getInt64Field(inst, common.BKInstIDField)
+		if err != nil {
+			return err
+		}
+
+		existID, err := getInt64Field(existInsts[0], common.BKInstIDField)
+		if err != nil {
+			return err
+		}
+
+		i.setID(objID, id, existID)
+		i.setInstName(objID, existID, util.GetStrByInterface(inst[common.BKInstNameField]))
+		if _, exists := i.reusedInsts[objID]; !exists {
+			i.reusedInsts[objID] = make(map[int64]struct{})
+		}
+		i.reusedInsts[objID][id] = struct{}{}
+		i.addCollision(false, bundleObjectInstance, "%s instance %v already exists, reuse instance %d", objID,
+			inst[common.BKInstNameField], existID)
+	}
+	return nil
+}
+
+func (i *bizImporter) setID(kind string, oldID, newID int64) {
+	if _, exists := i.idMap[kind]; !exists {
+		i.idMap[kind] = make(map[int64]int64)
+	}
+	i.idMap[kind][oldID] = newID
+}
+
+func (i *bizImporter) setInstName(objID string, id int64, name string) {
+	if _, exists := i.instNames[objID]; !exists {
+		i.instNames[objID] = make(map[int64]string)
+	}
+	i.instNames[objID][id] = name
+}
+
+// newBizID returns the id of the imported business in this environment
+func (i *bizImporter) newBizID() int64 {
+	return i.idMap[common.BKInnerObjIDApp][i.bundle.BizID]
+}
+
+func (i *bizImporter) getID(kind string, oldID int64) (int64, error) {
+	newID, exists := i.idMap[kind][oldID]
+	if !exists {
+		return 0, fmt.Errorf("%s id %d is not in the business bundle", kind, oldID)
+	}
+	return newID, nil
+}
+
+// remapField replace the id in the field of the document with the id in this environment, 0 value is not remapped
+func (i *bizImporter) remapField(doc mapstr.MapStr, field, kind string) error {
+	oldID, err := getInt64Field(doc, field)
+	if err != nil {
+		return err
+	}
+
+	if oldID == 0 {
+		return nil
+	}
+
+	newID, err := i.getID(kind, oldID)
+	if err != nil {
+		return fmt.Errorf("remap field %s failed, err: %v", field, err)
+	}
+	doc[field] = newID
+	return nil
+}
+
+// prepareDoc reset the supplier account and the time fields of the document to be created
+func (i *bizImporter) prepareDoc(doc mapstr.MapStr) {
+	delete(doc, "_id")
+	doc[common.BKOwnerIDField] = i.supplierAccount
+	for _, field := range []string{common.CreateTimeField, common.LastTimeField, common.BKCreatedAt,
+		common.BKUpdatedAt} {
+		if _, exists := doc[field]; exists {
+			doc[field] = i.now
+		}
+	}
+}
+
+// createDocs allocate new ids for the documents in this environment, remap their reference fields and insert them
+// into the table, the id field can be empty for documents that have no id, refs maps reference field to its id kind
+func (i *bizImporter) createDocs(table, idField string, docs []mapstr.MapStr, refs map[string]string) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	// documents that have no id all belong to the imported business, they are rolled back by the business id
+	createdCond := mapstr.MapStr{common.BKAppIDField: i.newBizID()}
+	if idField != "" {
+		ids, err := i.db.NextSequences(i.ctx, table, len(docs))
+		if err != nil {
+			return fmt.Errorf("generate %d ids for %s failed, err: %v", len(docs), table, err)
+		}
+
+		newIDs := make([]int64, len(docs))
+		for idx, doc := range docs {
+			oldID, err := getInt64Field(doc, idField)
+			if err != nil {
+				return err
+			}
+			newIDs[idx] = int64(ids[idx])
+			i.setID(table, oldID, newIDs[idx])
+			doc[idField] = newIDs[idx]
+		}
+		createdCond = mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: newIDs}}
+	}
+
+	for _, doc := range docs {
+		i.prepareDoc(doc)
+		for field, kind := range refs {
+			if err := i.remapField(doc, field, kind); err != nil {
+				return fmt.Errorf("remap %s data failed, err: %v", table, err)
+			}
+		}
+	}
+
+	i.recordCreated(table, createdCond)
+	if err := i.db.Table(table).Insert(i.ctx, docs); err != nil {
+		return fmt.Errorf("insert %d %s data failed, err: %v", len(docs), table, err)
+	}
+	return nil
+}
+
+// createInstances create the instances of the object, instance ids are mapped by object id
+func (i *bizImporter) createInstances(objID string, docs []mapstr.MapStr, refs map[string]string) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	table := common.GetInstTableName(objID, i.supplierAccount)
+	idField := common.GetInstIDField(objID)
+	if err := i.createDocs(table, idField, docs, refs); err != nil {
+		return err
+	}
+
+	for oldID, newID := range i.idMap[table] {
+		i.setID(objID, oldID, newID)
+	}
+
+	if err := i.addInstAuditLogs(objID, idField, docs); err != nil {
+		return err
+	}
+
+	if objID == common.BKInnerObjIDApp || objID == common.BKInnerObjIDSet || objID == common.BKInnerObjIDModule {
+		return nil
+	}
+
+	// custom object instances need to be saved in the instance mapping table
+	mappings := make([]mapstr.MapStr, len(docs))
+	ids := make([]interface{}, len(docs))
+	for idx, doc := range docs {
+		ids[idx] = doc[common.BKInstIDField]
+		mappings[idx] = mapstr.MapStr{
+			common.BKInstIDField:  doc[common.BKInstIDField],
+			common.BKObjIDField:   objID,
+			common.BKOwnerIDField: i.supplierAccount,
+		}
+	}
+
+	i.recordCreated(objectBaseMappingTable, mapstr.MapStr{
+		common.BKObjIDField:  objID,
+		common.BKInstIDField: mapstr.MapStr{common.BKDBIN: ids},
+	})
+	if err := i.db.Table(objectBaseMappingTable).Insert(i.ctx, mappings); err != nil {
+		return fmt.Errorf("insert %s instance mappings failed, err: %v", objID, err)
+	}
+	return nil
+}
+
+func (i *bizImporter) importBiz() error {
+	biz := i.bundle.Data[bundleBiz][0]
+	biz[common.BKAppNameField] = i.bizName
+	return i.createInstances(common.BKInnerObjIDApp, []mapstr.MapStr{biz}, nil)
+}
+
+func (i *bizImporter) importAttributes() error {
+	bizRef := map[string]string{common.BKAppIDField: common.BKInnerObjIDApp}
+	err := i.createDocs(common.BKTableNamePropertyGroup, common.BKFieldID, i.bundle.Data[bundlePropertyGroup], bizRef)
+	if err != nil {
+		return err
+	}
+
+	return i.createDocs(common.BKTableNameObjAttDes, common.BKFieldID, i.bundle.Data[bundleAttribute], bizRef)
+}
+
+func (i *bizImporter) importServiceCategories() error {
+	categories, err := sortCategories(i.bundle.Data[bundleServiceCategory])
+	if err != nil {
+		return err
+	}
+
+	return i.createDocs(common.BKTableNameServiceCategory, common.BKFieldID, categories, map[string]string{
+		common.BKAppIDField:    common.BKInnerObjIDApp,
+		common.BKParentIDField: common.BKTableNameServiceCategory,
+		common.BKRootIDField:   common.BKTableNameServiceCategory,
+	})
+}
+
+func (i *bizImporter) importTemplates() error {
+	attrKind := common.BKTableNameObjAttDes
+	steps := []struct {
+		table    string
+		idField  string
+		resource string
+		refs     map[string]string
+	}{
+		{
+			table:    common.BKTableNameSetTemplate,
+			idField:  common.BKFieldID,
+			resource: bundleSetTemplate,
+		},
+		{
+			table:    common.BKTableNameSetTemplateAttr,
+			idField:  common.BKFieldID,
+			resource: bundleSetTemplateAttr,
+			refs: map[string]string{
+				common.BKSetTemplateIDField: common.BKTableNameSetTemplate,
+				common.BKAttributeIDField:   attrKind,
+			},
+		},
+		{
+			table:    common.BKTableNameServiceTemplate,
+			idField:  common.BKFieldID,
+			resource: bundleServiceTemplate,
+			refs:     map[string]string{common.BKServiceCategoryIDField: common.BKTableNameServiceCategory},
+		},
+		{
+			table:    common.BKTableNameServiceTemplateAttr,
+			idField:  common.BKFieldID,
+			resource: bundleServiceTemplateAttr,
+			refs: map[string]string{
+				common.BKServiceTemplateIDField: common.BKTableNameServiceTemplate,
+				common.BKAttributeIDField:       attrKind,
+			},
+		},
+		{
+			table:    common.BKTableNameProcessTemplate,
+			idField:  common.BKFieldID,
+			resource: bundleProcessTemplate,
+			refs:     map[string]string{common.BKServiceTemplateIDField: common.BKTableNameServiceTemplate},
+		},
+		{
+			table:    common.BKTableNameSetServiceTemplateRelation,
+			resource: bundleSetSvcTplRelation,
+			refs: map[string]string{
+				common.BKSetTemplateIDField:     common.BKTableNameSetTemplate,
+				common.BKServiceTemplateIDField: common.BKTableNameServiceTemplate,
+			},
+		},
+	}
+
+	for _, step := range steps {
+		refs := map[string]string{common.BKAppIDField: common.BKInnerObjIDApp}
+		for field, kind := range step.refs {
+			refs[field] = kind
+		}
+
+		if err := i.createDocs(step.table, step.idField, i.bundle.Data[step.resource], refs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importTopology import the mainline instances, sets and modules from top to bottom
+func (i *bizImporter) importTopology() error {
+	mainlineInsts := make(map[string][]mapstr.MapStr)
+	for _, inst := range i.bundle.Data[bundleMainlineInstance] {
+		objID := util.GetStrByInterface(inst[common.BKObjIDField])
+		mainlineInsts[objID] = append(mainlineInsts[objID], inst)
+	}
+
+	parentObjID := common.BKInnerObjIDApp
+	for _, objID := range i.bundle.MainlineObjects {
+		refs := map[string]string{
+			common.BKAppIDField:    common.BKInnerObjIDApp,
+			common.BKInstParentStr: parentObjID,
+		}
+		if err := i.createInstances(objID, mainlineInsts[objID], refs); err != nil {
+			return err
+		}
+		parentObjID = objID
+	}
+
+	// the default set like idle pool is always under the business
+	defaultSets, sets := make([]mapstr.MapStr, 0), make([]mapstr.MapStr, 0)
+	for _, set := range i.bundle.Data[bundleSet] {
+		isDefault, err := getInt64Field(set, common.BKDefaultField)
+		if err != nil {
+			return err
+		}
+
+		if isDefault != 0 {
+			defaultSets = append(defaultSets, set)
+			continue
+		}
+		sets = append(sets, set)
+	}
+
+	setRefs := map[string]string{
+		common.BKAppIDField:         common.BKInnerObjIDApp,
+		common.BKInstParentStr:      common.BKInnerObjIDApp,
+		common.BKSetTemplateIDField: common.BKTableNameSetTemplate,
+	}
+	if err := i.createInstances(common.BKInnerObjIDSet, defaultSets, setRefs); err != nil {
+		return err
+	}
+
+	setRefs[common.BKInstParentStr] = parentObjID
+	if err := i.createInstances(common.BKInnerObjIDSet, sets, setRefs); err != nil {
+		return err
+	}
+
+	return i.createInstances(common.BKInnerObjIDModule, i.bundle.Data[bundleModule], map[string]string{
+		common.BKAppIDField:             common.BKInnerObjIDApp,
+		common.BKSetIDField:             common.BKInnerObjIDSet,
+		common.BKInstParentStr:          common.BKInnerObjIDSet,
+		common.BKServiceTemplateIDField: common.BKTableNameServiceTemplate,
+		common.BKSetTemplateIDField:     common.BKTableNameSetTemplate,
+		common.BKServiceCategoryIDField: common.BKTableNameServiceCategory,
+	})
+}
+
+func (i *bizImporter) importHostApplyRules() error {
+	return i.createDocs(common.BKTableNameHostApplyRule, common.BKFieldID, i.bundle.Data[bundleHostApplyRule],
+		map[string]string{
+			common.BKAppIDField:             common.BKInnerObjIDApp,
+			common.BKModuleIDField:          common.BKInnerObjIDModule,
+			common.BKServiceTemplateIDField: common.BKTableNameServiceTemplate,
+			common.BKAttributeIDField:       common.BKTableNameObjAttDes,
+		})
+}
+
+// importInstances import the custom instances that are not reused
+func (i *bizImporter) importInstances() error {
+	objInsts := make(map[string][]mapstr.MapStr)
+	for _, inst := range i.bundle.Data[bundleObjectInstance] {
+		objID := util.GetStrByInterface(inst[common.BKObjIDField])
+		id, err := getInt64Field(inst, common.BKInstIDField)
+		if err != nil {
+			return err
+		}
+
+		if _, reused := i.reusedInsts[objID][id]; reused {
+			continue
+		}
+		objInsts[objID] = append(objInsts[objID], inst)
+	}
+
+	for objID, insts := range objInsts {
+		if err := i.createInstances(objID, insts, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importInstAssts import the instance associations, each association is saved in the association tables of both
+// objects, associations that already exist between the reused instances are skipped
+func (i *bizImporter) importInstAssts() error {
+	assts := make([]mapstr.MapStr, 0)
+	for _, asst := range i.bundle.Data[bundleInstAsst] {
+		i.prepareDoc(asst)
+		objID := util.GetStrByInterface(asst[common.BKObjIDField])
+		asstObjID := util.GetStrByInterface(asst[common.BKAsstObjIDField])
+		if err := i.remapField(asst, common.BKInstIDField, objID); err != nil {
+			return err
+		}
+		if err := i.remapField(asst, common.BKAsstInstIDField, asstObjID); err != nil {
+			return err
+		}
+		if err := i.remapField(asst, common.BKAppIDField, common.BKInnerObjIDApp); err != nil {
+			return err
+		}
+
+		cond := mapstr.MapStr{
+			common.AssociationObjAsstIDField: asst[common.AssociationObjAsstIDField],
+			common.BKInstIDField:             asst[common.BKInstIDField],
+			common.BKAsstInstIDField:         asst[common.BKAsstInstIDField],
+		}
+		count, err := i.db.Table(common.GetObjectInstAsstTableName(objID, i.supplierAccount)).Find(cond).Count(i.ctx)
+		if err != nil {
+			return fmt.Errorf("count instance association failed, cond: %+v, err: %v", cond, err)
+		}
+		if count > 0 {
+			continue
+		}
+		assts = append(assts, asst)
+	}
+
+	if len(assts) == 0 {
+		return nil
+	}
+
+	ids, err := i.db.NextSequences(i.ctx, common.BKTableNameInstAsst, len(assts))
+	if err != nil {
+		return fmt.Errorf("generate %d ids for instance associations failed, err: %v", len(assts), err)
+	}
+
+	tableAssts := make(map[string][]mapstr.MapStr)
+	tableAsstIDs := make(map[string][]int64)
+	for idx, asst := range assts {
+		asst[common.BKFieldID] = int64(ids[idx])
+		i.addInstAsstAuditLog(asst)
+		objID := util.GetStrByInterface(asst[common.BKObjIDField])
+		asstObjID := util.GetStrByInterface(asst[common.BKAsstObjIDField])
+
+		table := common.GetObjectInstAsstTableName(objID, i.supplierAccount)
+		tableAssts[table] = append(tableAssts[table], asst)
+		tableAsstIDs[table] = append(tableAsstIDs[table], int64(ids[idx]))
+		if objID != asstObjID {
+			asstTable := common.GetObjectInstAsstTableName(asstObjID, i.supplierAccount)
+			tableAssts[asstTable] = append(tableAssts[asstTable], asst)
+			tableAsstIDs[asstTable] = append(tableAsstIDs[asstTable], int64(ids[idx]))
+		}
+	}
+
+	for table, docs := range tableAssts {
+		i.recordCreated(table, mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: tableAsstIDs[table]}})
+		if err = i.db.Table(table).Insert(i.ctx, docs); err != nil {
+			return fmt.Errorf("insert %d instance associations into %s failed, err: %v", len(docs), table, err)
+		}
+	}
+	return nil
+}
+
+// addInstAuditLogs generate the creation audit logs of the created instances, the same as the ones that are generated
+// when the instances are created by the api
+func (i *bizImporter) addInstAuditLogs(objID, idField string, docs []mapstr.MapStr) error {
+	isMainline := util.InStrArr(i.bundle.MainlineObjects, objID)
+	bizID := i.newBizID()
+	for _, doc := range docs {
+		id, err := getInt64Field(doc, idField)
+		if err != nil {
+			return err
+		}
+
+		name := util.GetStrByInterface(doc[metadata.GetInstNameFieldName(objID)])
+		i.setInstName(objID, id, name)
+
+		auditLog := metadata.AuditLog{
+			AuditType:    metadata.GetAuditTypeByObjID(objID, isMainline),
+			ResourceType: metadata.GetResourceTypeByObjID(objID, isMainline),
+			Action:       metadata.AuditCreate,
+			ResourceID:   id,
+			ResourceName: name,
+			OperationDetail: &metadata.InstanceOpDetail{
+				BasicOpDetail: metadata.BasicOpDetail{
+					Details: &metadata.BasicContent{CurData: doc},
+				},
+				ModelID: objID,
+			},
+		}
+		if _, exists := doc[common.BKAppIDField]; exists {
+			auditLog.BusinessID = bizID
+		}
+		i.auditLogs = append(i.auditLogs, auditLog)
+	}
+	return nil
+}
+
+// addInstAsstAuditLog generate the creation audit log of the created instance association
+func (i *bizImporter) addInstAsstAuditLog(asst mapstr.MapStr) {
+	objID := util.GetStrByInterface(asst[common.BKObjIDField])
+	asstObjID := util.GetStrByInterface(asst[common.BKAsstObjIDField])
+	instID, _ := getInt64Field(asst, common.BKInstIDField)
+	asstInstID, _ := getInt64Field(asst, common.BKAsstInstIDField)
+
+	i.auditLogs = append(i.auditLogs, metadata.AuditLog{
+		AuditType:    metadata.ModelInstanceType,
+		ResourceType: metadata.InstanceAssociationRes,
+		Action:       metadata.AuditCreate,
+		ResourceID:   instID,
+		ResourceName: i.instNames[objID][instID],
+		OperationDetail: &metadata.InstanceAssociationOpDetail{
+			AssociationOpDetail: metadata.AssociationOpDetail{
+				AssociationID:   util.GetStrByInterface(asst[common.AssociationObjAsstIDField]),
+				AssociationKind: util.GetStrByInterface(asst[common.AssociationKindIDField]),
+			},
+			SourceModelID:      objID,
+			TargetModelID:      asstObjID,
+			TargetInstanceID:   asstInstID,
+			TargetInstanceName: i.instNames[asstObjID][asstInstID],
+		},
+	})
+}
+
+// saveAuditLogs save the audit logs of the imported data with the operator, in batches
+func (i *bizImporter) saveAuditLogs() error {
+	if len(i.auditLogs) == 0 {
+		return nil
+	}
+
+	ids, err := i.db.NextSequences(i.ctx, common.BKTableNameAuditLog, len(i.auditLogs))
+	if err != nil {
+		return fmt.Errorf("generate %d audit log ids failed, err: %v", len(i.auditLogs), err)
+	}
+
+	now := metadata.Now()
+	for idx := range i.auditLogs {
+		i.auditLogs[idx].ID = int64(ids[idx])
+		i.auditLogs[idx].SupplierAccount = i.supplierAccount
+		i.auditLogs[idx].User = i.operator
+		i.auditLogs[idx].OperateFrom = metadata.FromUser
+		i.auditLogs[idx].OperationTime = now
+		i.auditLogs[idx].RequestID = i.rid
+	}
+
+	for start := 0; start < len(i.auditLogs); start += bizExportBatchSize {
+		end := start + bizExportBatchSize
+		if end > len(i.auditLogs) {
+			end = len(i.auditLogs)
+		}
+
+		if err = i.db.Table(common.BKTableNameAuditLog).Insert(i.ctx, i.auditLogs[start:end]); err != nil {
+			return fmt.Errorf("insert %d audit logs failed, err: %v", end-start, err)
+		}
+	}
+	return nil
+}
+
+// registerIAMCreator register the operator as the creator of the imported business and templates in iam, the same
+// as the business and templates are created by the api
+func (i *bizImporter) registerIAMCreator() error {
+	if i.authorizer == nil {
+		return nil
+	}
+
+	instances := []metadata.IamInstanceWithCreator{{
+		Type:    string(iam.Business),
+		ID:      strconv.FormatInt(i.newBizID(), 10),
+		Name:    i.bizName,
+		Creator: i.operator,
+	}}
+
+	templates := map[string]iam.TypeID{
+		bundleSetTemplate:     iam.BizSetTemplate,
+		bundleServiceTemplate: iam.BizProcessServiceTemplate,
+	}
+	for _, resource := range []string{bundleSetTemplate, bundleServiceTemplate} {
+		for _, template := range i.bundle.Data[resource] {
+			id, err := getInt64Field(template, common.BKFieldID)
+			if err != nil {
+				return err
+			}
+
+			instances = append(instances, metadata.IamInstanceWithCreator{
+				Type:    string(templates[resource]),
+				ID:      strconv.FormatInt(id, 10),
+				Name:    util.GetStrByInterface(template[common.BKFieldName]),
+				Creator: i.operator,
+			})
+		}
+	}
+
+	header := headerutil.BuildHeader(i.operator, i.supplierAccount)
+	for _, instance := range instances {
+		if _, err := i.authorizer.RegisterResourceCreatorAction(i.ctx, header, instance); err != nil {
+			return fmt.Errorf("register %s %s creator to iam failed, err: %v", instance.Type, instance.ID, err)
+		}
+	}
+	return nil
+}
+
+// sortCategories sort the service categories so that the root categories are in front of their children
+func sortCategories(categories []mapstr.MapStr) ([]mapstr.MapStr, error) {
+	roots, children := make([]mapstr.MapStr, 0), make([]mapstr.MapStr, 0)
+	for _, category := range categories {
+		parentID, err := getInt64Field(category, common.BKParentIDField)
+		if err != nil {
+			return nil, err
+		}
+
+		if parentID == 0 {
+			roots = append(roots, category)
+			continue
+		}
+		children = append(children, category)
+	}
+
+	return append(roots, children...), nil
+}
@@ -0,0 +1,253 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/fake"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeBizDB returns the in memory db whose table sequences all start from seqStart
+func newFakeBizDB(seqStart uint64) *fake.DB {
+	db := fake.NewDB()
+	db.SeqStart = seqStart
+	return db
+}
+
+const (
+	testSwitchObjID = "switch"
+	testRegionObjID = "region"
+	testObjAsstID   = "module_connect_switch"
+)
+
+// newTestMetadata returns the model and built-in data of an environment, the ids of the built-in categories and
+// global attributes are different in each environment
+func newTestMetadata(idOffset int64) map[string][]mapstr.MapStr {
+	objects := make([]mapstr.MapStr, 0)
+	for _, objID := range []string{common.BKInnerObjIDApp, common.BKInnerObjIDSet, common.BKInnerObjIDModule,
+		common.BKInnerObjIDHost, testRegionObjID, testSwitchObjID} {
+		objects = append(objects, mapstr.MapStr{common.BKObjIDField: objID})
+	}
+
+	return map[string][]mapstr.MapStr{
+		common.BKTableNameObjDes: objects,
+		common.BKTableNameObjAsst: {
+			{common.BKObjIDField: testRegionObjID, common.BKAsstObjIDField: common.BKInnerObjIDApp,
+				common.AssociationKindIDField: common.AssociationKindMainline},
+			{common.BKObjIDField: common.BKInnerObjIDSet, common.BKAsstObjIDField: testRegionObjID,
+				common.AssociationKindIDField: common.AssociationKindMainline},
+			{common.BKObjIDField: common.BKInnerObjIDModule, common.BKAsstObjIDField: common.BKInnerObjIDSet,
+				common.AssociationKindIDField: common.AssociationKindMainline},
+			{common.BKObjIDField: common.BKInnerObjIDModule, common.BKAsstObjIDField: testSwitchObjID,
+				common.AssociationKindIDField: "connect", common.AssociationObjAsstIDField: testObjAsstID},
+		},
+		common.BKTableNameServiceCategory: {
+			{common.BKFieldID: 1 + idOffset, common.BKFieldName: "Default", common.BKParentIDField: 0,
+				common.BKRootIDField: 1 + idOffset, common.BKAppIDField: 0},
+			{common.BKFieldID: 2 + idOffset, common.BKFieldName: "Default", common.BKParentIDField: 1 + idOffset,
+				common.BKRootIDField: 1 + idOffset, common.BKAppIDField: 0},
+		},
+		common.BKTableNameObjAttDes: {
+			{common.BKFieldID: 1 + idOffset, common.BKObjIDField: common.BKInnerObjIDHost,
+				common.BKPropertyIDField: "bk_comment", common.BKAppIDField: 0},
+		},
+	}
+}
+
+// newTestBizDB returns the exported environment with a business that has a custom mainline instance, a default set
+// and a template set, a service template, a host apply rule and a custom instance associated with the module
+func newTestBizDB() *fake.DB {
+	db := newFakeBizDB(1000)
+	db.Tables = newTestMetadata(0)
+
+	db.Tables[common.BKTableNameBaseApp] = []mapstr.MapStr{
+		{common.BKAppIDField: 2, common.BKAppNameField: "biz", common.BKOwnerIDField: "0"},
+	}
+	db.Tables[common.GetInstTableName(testRegionObjID, "0")] = []mapstr.MapStr{
+		{common.BKInstIDField: 10, common.BKInstNameField: "region1", common.BKObjIDField: testRegionObjID,
+			common.BKAppIDField: 2, common.BKInstParentStr: 2, common.BKOwnerIDField: "0"},
+	}
+	db.Tables[common.BKTableNameSetTemplate] = []mapstr.MapStr{
+		{common.BKFieldID: 20, common.BKFieldName: "set template", common.BKAppIDField: 2},
+	}
+	db.Tables[common.BKTableNameServiceTemplate] = []mapstr.MapStr{
+		{common.BKFieldID: 21, common.BKFieldName: "service template", common.BKAppIDField: 2,
+			common.BKServiceCategoryIDField: 2},
+	}
+	db.Tables[common.BKTableNameProcessTemplate] = []mapstr.MapStr{
+		{common.BKFieldID: 22, common.BKAppIDField: 2, common.BKServiceTemplateIDField: 21},
+	}
+	db.Tables[common.BKTableNameSetServiceTemplateRelation] = []mapstr.MapStr{
+		{common.BKAppIDField: 2, common.BKSetTemplateIDField: 20, common.BKServiceTemplateIDField: 21},
+	}
+	db.Tables[common.BKTableNameBaseSet] = []mapstr.MapStr{
+		{common.BKSetIDField: 3, common.BKSetNameField: "idle pool", common.BKAppIDField: 2,
+			common.BKInstParentStr: 2, common.BKDefaultField: 1, common.BKSetTemplateIDField: 0},
+		{common.BKSetIDField: 4, common.BKSetNameField: "set", common.BKAppIDField: 2,
+			common.BKInstParentStr: 10, common.BKDefaultField: 0, common.BKSetTemplateIDField: 20},
+	}
+	db.Tables[common.BKTableNameBaseModule] = []mapstr.MapStr{
+		{common.BKModuleIDField: 5, common.BKModuleNameField: "idle host", common.BKAppIDField: 2,
+			common.BKSetIDField: 3, common.BKInstParentStr: 3, common.BKDefaultField: 1,
+			common.BKServiceTemplateIDField: 0, common.BKSetTemplateIDField: 0, common.BKServiceCategoryIDField: 2},
+		{common.BKModuleIDField: 6, common.BKModuleNameField: "module", common.BKAppIDField: 2,
+			common.BKSetIDField: 4, common.BKInstParentStr: 4, common.BKDefaultField: 0,
+			common.BKServiceTemplateIDField: 21, common.BKSetTemplateIDField: 20, common.BKServiceCategoryIDField: 2},
+	}
+	db.Tables[common.BKTableNameHostApplyRule] = []mapstr.MapStr{
+		{common.BKFieldID: 30, common.BKAppIDField: 2, common.BKModuleIDField: 6, common.BKAttributeIDField: 1},
+	}
+	db.Tables[common.GetInstTableName(testSwitchObjID, "0")] = []mapstr.MapStr{
+		{common.BKInstIDField: 40, common.BKInstNameField: "switch1", common.BKObjIDField: testSwitchObjID},
+	}
+
+	asst := mapstr.MapStr{common.BKFieldID: 50, common.BKObjIDField: common.BKInnerObjIDModule,
+		common.BKInstIDField: 6, common.BKAsstObjIDField: testSwitchObjID, common.BKAsstInstIDField: 40,
+		common.AssociationObjAsstIDField: testObjAsstID, common.AssociationKindIDField: "connect"}
+	db.Tables[common.GetObjectInstAsstTableName(common.BKInnerObjIDModule, "0")] = []mapstr.MapStr{asst}
+	db.Tables[common.GetObjectInstAsstTableName(testSwitchObjID, "0")] = []mapstr.MapStr{asst.Clone()}
+	return db
+}
+
+func exportTestBiz(t *testing.T) *bizBundle {
+	file := filepath.Join(t.TempDir(), "biz.json")
+	exporter := &bizExporter{db: newTestBizDB(), ctx: context.Background(), bizID: 2}
+	require.NoError(t, exporter.export(file))
+
+	bundle, err := readBizBundle(file)
+	require.NoError(t, err)
+	return bundle
+}
+
+func findOne(t *testing.T, db *fake.DB, table string, cond mapstr.MapStr) mapstr.MapStr {
+	docs := db.FindDocs(table, cond)
+	require.Len(t, docs, 1, "table: %s, cond: %v", table, cond)
+	return docs[0]
+}
+
+func requireField(t *testing.T, doc mapstr.MapStr, field string, expected int64) {
+	val, err := getInt64Field(doc, field)
+	require.NoError(t, err)
+	require.Equal(t, expected, val, "field: %s, doc: %v", field, doc)
+}
+
+func TestBizExportImport(t *testing.T) {
+	bundle := exportTestBiz(t)
+	require.Equal(t, []string{testRegionObjID}, bundle.MainlineObjects)
+
+	db := newFakeBizDB(100)
+	db.Tables = newTestMetadata(10)
+	importer := newBizImporter(db, bundle, &bizImportConf{bizName: "imported", operator: "admin"})
+	require.NoError(t, importer.run())
+
+	biz := findOne(t, db, common.BKTableNameBaseApp, mapstr.MapStr{common.BKAppNameField: "imported"})
+	bizID, err := getInt64Field(biz, common.BKAppIDField)
+	require.NoError(t, err)
+	require.NotEqual(t, int64(2), bizID)
+
+	region := findOne(t, db, common.GetInstTableName(testRegionObjID, "0"), mapstr.MapStr{})
+	requireField(t, region, common.BKInstParentStr, bizID)
+	regionID, _ := getInt64Field(region, common.BKInstIDField)
+
+	setTemplate := findOne(t, db, common.BKTableNameSetTemplate, mapstr.MapStr{})
+	setTemplateID, _ := getInt64Field(setTemplate, common.BKFieldID)
+	svcTemplate := findOne(t, db, common.BKTableNameServiceTemplate, mapstr.MapStr{})
+	svcTemplateID, _ := getInt64Field(svcTemplate, common.BKFieldID)
+	// built-in service categories are matched by name and parent category
+	requireField(t, svcTemplate, common.BKServiceCategoryIDField, 12)
+	requireField(t, findOne(t, db, common.BKTableNameProcessTemplate, mapstr.MapStr{}),
+		common.BKServiceTemplateIDField, svcTemplateID)
+	relation := findOne(t, db, common.BKTableNameSetServiceTemplateRelation, mapstr.MapStr{})
+	requireField(t, relation, common.BKSetTemplateIDField, setTemplateID)
+	requireField(t, relation, common.BKServiceTemplateIDField, svcTemplateID)
+
+	defaultSet := findOne(t, db, common.BKTableNameBaseSet, mapstr.MapStr{common.BKDefaultField: 1})
+	requireField(t, defaultSet, common.BKInstParentStr, bizID)
+	set := findOne(t, db, common.BKTableNameBaseSet, mapstr.MapStr{common.BKDefaultField: 0})
+	requireField(t, set, common.BKInstParentStr, regionID)
+	requireField(t, set, common.BKSetTemplateIDField, setTemplateID)
+	setID, _ := getInt64Field(set, common.BKSetIDField)
+
+	module := findOne(t, db, common.BKTableNameBaseModule, mapstr.MapStr{common.BKDefaultField: 0})
+	requireField(t, module, common.BKAppIDField, bizID)
+	requireField(t, module, common.BKSetIDField, setID)
+	requireField(t, module, common.BKServiceTemplateIDField, svcTemplateID)
+	requireField(t, module, common.BKServiceCategoryIDField, 12)
+	moduleID, _ := getInt64Field(module, common.BKModuleIDField)
+
+	rule := findOne(t, db, common.BKTableNameHostApplyRule, mapstr.MapStr{})
+	requireField(t, rule, common.BKModuleIDField, moduleID)
+	requireField(t, rule, common.BKAttributeIDField, 11)
+
+	switchInst := findOne(t, db, common.GetInstTableName(testSwitchObjID, "0"), mapstr.MapStr{})
+	switchID, _ := getInt64Field(switchInst, common.BKInstIDField)
+	findOne(t, db, objectBaseMappingTable, mapstr.MapStr{common.BKInstIDField: switchID,
+		common.BKObjIDField: testSwitchObjID})
+
+	for _, objID := range []string{common.BKInnerObjIDModule, testSwitchObjID} {
+		asst := findOne(t, db, common.GetObjectInstAsstTableName(objID, "0"), mapstr.MapStr{})
+		requireField(t, asst, common.BKInstIDField, moduleID)
+		requireField(t, asst, common.BKAsstInstIDField, switchID)
+	}
+
+	// biz, region, 2 sets, 2 modules, switch and the instance association are audited
+	audits := db.FindDocs(common.BKTableNameAuditLog, mapstr.MapStr{"user": "admin"})
+	require.Len(t, audits, 8)
+	findOne(t, db, common.BKTableNameAuditLog, mapstr.MapStr{"resource_type": string(metadata.BusinessRes),
+		"resource_id": bizID, "action": string(metadata.AuditCreate), common.BKAppIDField: bizID})
+	findOne(t, db, common.BKTableNameAuditLog, mapstr.MapStr{"resource_type": string(metadata.InstanceAssociationRes),
+		"resource_id": moduleID, "resource_name": "module"})
+
+	// the imported business can not be imported again with the same name
+	importer = newBizImporter(db, exportTestBiz(t), &bizImportConf{bizName: "imported", operator: "admin"})
+	require.Error(t, importer.run())
+	require.Len(t, db.FindDocs(common.BKTableNameBaseApp, mapstr.MapStr{}), 1)
+}
+
+func TestBizImportRollback(t *testing.T) {
+	failTables := []string{
+		common.BKTableNameSetTemplate,
+		common.BKTableNameBaseModule,
+		objectBaseMappingTable,
+		common.GetObjectInstAsstTableName(testSwitchObjID, "0"),
+	}
+
+	for _, failTable := range failTables {
+		t.Run(failTable, func(t *testing.T) {
+			db := newFakeBizDB(100)
+			db.Tables = newTestMetadata(10)
+			db.InsertErr[failTable] = errors.New("insert failed")
+
+			importer := newBizImporter(db, exportTestBiz(t), &bizImportConf{bizName: "imported", operator: "admin"})
+			require.Error(t, importer.run())
+
+			expected := newTestMetadata(10)
+			for table, docs := range db.Tables {
+				require.Len(t, docs, len(expected[table]), "table %s is not rolled back", table)
+			}
+		})
+	}
+}